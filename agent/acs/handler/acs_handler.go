@@ -17,7 +17,11 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"net"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
@@ -29,19 +33,26 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/config"
 	"github.com/aws/amazon-ecs-agent/agent/data"
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient/dockerapi"
+	dockerdoctor "github.com/aws/amazon-ecs-agent/agent/doctor"
 	"github.com/aws/amazon-ecs-agent/agent/engine"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
 	"github.com/aws/amazon-ecs-agent/agent/eventhandler"
 	"github.com/aws/amazon-ecs-agent/agent/eventstream"
 	"github.com/aws/amazon-ecs-agent/agent/version"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
 	rolecredentials "github.com/aws/amazon-ecs-agent/ecs-agent/credentials"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/doctor"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger/field"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/utils/retry"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/utils/ttime"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/wsclient"
 
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/cihub/seelog"
+	"github.com/gorilla/websocket"
 )
 
 const (
@@ -49,12 +60,19 @@ const (
 	// without disconnecting
 	heartbeatTimeout = 1 * time.Minute
 	heartbeatJitter  = 1 * time.Minute
-	// wsRWTimeout is the duration of read and write deadline for the
-	// websocket connection
-	wsRWTimeout = 2*heartbeatTimeout + heartbeatJitter
 
 	inactiveInstanceReconnectDelay = 1 * time.Hour
 
+	// tryAgainLaterReconnectDelay is the minimum time to wait before reconnecting when ACS closes
+	// the connection asking the agent to try again later. A jitter of up to the same duration is
+	// added so that instances disconnected together do not reconnect together.
+	tryAgainLaterReconnectDelay = 5 * time.Minute
+
+	// policyViolationReconnectDelay is the minimum time to wait before reconnecting when ACS closes
+	// the connection for a policy violation. A jitter of up to the same duration is added, which
+	// caps the delay, so that the container instance keeps reconnecting without loading ACS.
+	policyViolationReconnectDelay = 30 * time.Minute
+
 	// connectionTime is the maximum time after which agent closes its connection to ACS
 	connectionTime   = 15 * time.Minute
 	connectionJitter = 30 * time.Minute
@@ -70,20 +88,77 @@ const (
 	// in the ACS URL that is used to indicate if ACS should send
 	// credentials for all tasks on establishing the connection
 	sendCredentialsURLParameterName = "sendCredentials"
+	// redactedURLParameterValue replaces the values of sensitive URL parameters
+	// when the ACS URL is logged
+	redactedURLParameterValue       = "REDACTED"
 	inactiveInstanceExceptionPrefix = "InactiveInstanceException:"
 	// ACS protocol version spec:
 	// 1: default protocol version
 	// 2: ACS will proactively close the connection when heartbeat acks are missing
 	acsProtocolVersion = 2
+	// acsProtocolVersionHeader is the header of the ACS handshake response that carries the
+	// protocol version accepted by ACS, which may differ from the advertised one. ACS accepted
+	// the advertised protocol version when the header is absent.
+	acsProtocolVersionHeader = "X-Amzn-Ecs-Protocol-Version"
 	// numOfHandlersSendingAcks is the number of handlers that send acks back to ACS and that are not saved across
 	// sessions. We use this to send pending acks, before agent initiates a disconnect to ACS.
 	// they are: refreshCredentialsHandler, taskManifestHandler, and payloadHandler
 	numOfHandlersSendingAcks = 3
 )
 
+// reconnectPolicy determines how the session reconnects to ACS after a connection ends.
+type reconnectPolicy int
+
+const (
+	// reconnectImmediately reconnects without waiting and resets the backoff.
+	reconnectImmediately reconnectPolicy = iota
+	// reconnectWithBackoff reconnects after the next backoff duration.
+	reconnectWithBackoff
+	// reconnectWithLongBackoff reconnects after at least tryAgainLaterReconnectDelay.
+	reconnectWithLongBackoff
+	// reconnectAfterPolicyViolation reconnects after at least policyViolationReconnectDelay.
+	reconnectAfterPolicyViolation
+)
+
+// closeCodeReconnectPolicies maps websocket close codes sent by ACS to the policy used to reconnect.
+// Close codes that are not listed are reconnected with backoff.
+var closeCodeReconnectPolicies = map[int]reconnectPolicy{
+	websocket.CloseNormalClosure:     reconnectImmediately,
+	websocket.CloseGoingAway:         reconnectImmediately,
+	websocket.CloseServiceRestart:    reconnectWithBackoff,
+	websocket.CloseTryAgainLater:     reconnectWithLongBackoff,
+	websocket.ClosePolicyViolation:   reconnectAfterPolicyViolation,
+	websocket.CloseInternalServerErr: reconnectWithBackoff,
+}
+
+// sensitiveURLParameterSubstrings are matched against the lowercased names of the ACS URL parameters
+// to find the ones whose values are redacted when the URL is logged.
+var sensitiveURLParameterSubstrings = []string{
+	"credential",
+	"password",
+	"secret",
+	"signature",
+	"token",
+}
+
 // Session defines an interface for handler's long-lived connection with ACS.
 type Session interface {
 	Start() error
+	// Status returns the state of the session's connection with ACS
+	Status() SessionStatus
+}
+
+// SessionStatus describes the state of a session's connection with ACS.
+type SessionStatus struct {
+	// ProtocolVersion is the ACS protocol version negotiated by the most recent
+	// connection. It is zero until a connection is established.
+	ProtocolVersion int
+}
+
+// handshakeHeaderProvider is implemented by the ACS clients that record the headers
+// of the server's response to the websocket handshake.
+type handshakeHeaderProvider interface {
+	HandshakeHeader() http.Header
 }
 
 // session encapsulates all arguments needed by the handler to connect to ACS
@@ -104,15 +179,41 @@ type session struct {
 	ctx                             context.Context
 	cancel                          context.CancelFunc
 	backoff                         retry.Backoff
+	discoverPollEndpointBackoff     retry.Backoff
 	clientFactory                   wsclient.ClientFactory
 	sendCredentials                 bool
 	latestSeqNumTaskManifest        *int64
 	doctor                          *doctor.Doctor
 	_heartbeatTimeout               time.Duration
 	_heartbeatJitter                time.Duration
-	connectionTime                  time.Duration
+	_connectionTime                 time.Duration
 	connectionJitter                time.Duration
 	_inactiveInstanceReconnectDelay time.Duration
+	processingPauser                *ProcessingPauser
+	recentMessages                  *RecentMessages
+	drainState                      *DrainState
+	agentMetrics                    sessionMetrics
+	metricsFactory                  metrics.EntryFactory
+	status                          SessionStatus
+	statusLock                      sync.RWMutex
+	// connectionParametersLock guards _heartbeatTimeout and _connectionTime, which ACS can
+	// update while a connection is served
+	connectionParametersLock sync.RWMutex
+	// consecutiveConnectFailures is the number of failures to connect to ACS since the
+	// last successful connection. It is only accessed by the goroutine running the session
+	consecutiveConnectFailures int
+	// consecutiveDiscoverAuthFailures is the number of consecutive authentication errors from
+	// DiscoverPollEndpoint. It is only accessed by the goroutine running the session
+	consecutiveDiscoverAuthFailures int
+	// consecutiveDiscoverDNSFailures is the number of consecutive DNS resolution errors from
+	// DiscoverPollEndpoint. It is only accessed by the goroutine running the session
+	consecutiveDiscoverDNSFailures int
+	// discoverHealthcheck reports the agent as impaired while DiscoverPollEndpoint keeps failing
+	// with authentication errors. It is added to the doctor the first time that happens
+	discoverHealthcheck authFailureHealthcheck
+	// sessionLimiter limits the number of concurrent runs of Start for the container instance.
+	// They are not limited when it is nil
+	sessionLimiter *sessionLimiter
 }
 
 // NewSession creates a new Session object
@@ -132,9 +233,14 @@ func NewSession(
 	latestSeqNumTaskManifest *int64,
 	doctor *doctor.Doctor,
 	clientFactory wsclient.ClientFactory,
+	processingPauser *ProcessingPauser,
+	recentMessages *RecentMessages,
+	drainState *DrainState,
 ) Session {
 	backoff := retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax,
 		connectionBackoffJitter, connectionBackoffMultiplier)
+	discoverPollEndpointBackoff := retry.NewExponentialBackoff(config.DiscoverPollEndpointMinBackoff,
+		config.DiscoverPollEndpointMaxBackoff, connectionBackoffJitter, connectionBackoffMultiplier)
 	derivedContext, cancel := context.WithCancel(ctx)
 
 	return &session{
@@ -152,23 +258,49 @@ func NewSession(
 		ctx:                             derivedContext,
 		cancel:                          cancel,
 		backoff:                         backoff,
+		discoverPollEndpointBackoff:     discoverPollEndpointBackoff,
 		latestSeqNumTaskManifest:        latestSeqNumTaskManifest,
 		doctor:                          doctor,
 		clientFactory:                   clientFactory,
 		sendCredentials:                 true,
 		_heartbeatTimeout:               heartbeatTimeout,
 		_heartbeatJitter:                heartbeatJitter,
-		connectionTime:                  connectionTime,
+		_connectionTime:                 connectionTime,
 		connectionJitter:                connectionJitter,
 		_inactiveInstanceReconnectDelay: inactiveInstanceReconnectDelay,
+		processingPauser:                processingPauser,
+		recentMessages:                  recentMessages,
+		drainState:                      drainState,
+		metricsFactory:                  metrics.NewNopEntryFactory(),
+		sessionLimiter:                  newSessionLimiter(),
 	}
 }
 
 // Start starts the session. It'll forever keep trying to connect to ACS unless
-// the context is cancelled.
+// the context is cancelled or the session's max lifetime, if any, has elapsed.
 //
-// Returns nil always TODO: consider removing error return value completely
+// Returns nil unless ACS closed the connection with a close code that does not allow
+// reconnecting, in which case the error that ended the session is returned.
 func (acsSession *session) Start() error {
+	// Only a limited number of sessions can be active for the container instance, others wait
+	// for one of them to end. A session stopped while waiting ends like any stopped session
+	if acsSession.sessionLimiter != nil {
+		if err := acsSession.sessionLimiter.acquire(acsSession.ctx, acsSession.containerInstanceARN,
+			acsSession.agentConfig.ACSMaxSessionsPerInstance); err != nil {
+			seelog.Infof("ACS session stopped while waiting for another session to end: %v", err)
+			return nil
+		}
+		defer acsSession.sessionLimiter.release(acsSession.containerInstanceARN)
+	}
+
+	// Ephemeral agents can limit the lifetime of the session, which then ends as if it was stopped
+	if maxLifetime := acsSession.agentConfig.ACSSessionMaxLifetime; maxLifetime > 0 {
+		seelog.Infof("ACS session will end after its max lifetime of %s", maxLifetime)
+		var cancel context.CancelFunc
+		acsSession.ctx, cancel = context.WithTimeout(acsSession.ctx, maxLifetime)
+		defer cancel()
+	}
+
 	// Loop continuously until context is closed/cancelled
 	for {
 		seelog.Debugf("Attempting connect to ACS")
@@ -180,11 +312,15 @@ func (acsSession *session) Start() error {
 			return nil
 		}
 
-		// If ACS closed the connection, reconnect immediately
-		if shouldReconnectWithoutBackoff(acsError) {
+		policy := reconnectPolicyFor(acsError)
+		switch policy {
+		case reconnectImmediately:
+			// If ACS closed the connection, reconnect immediately
 			seelog.Infof("ACS Websocket connection closed for a valid reason: %v", acsError)
 			acsSession.backoff.Reset()
 			continue
+		case reconnectAfterPolicyViolation:
+			seelog.Criticalf("ACS Websocket connection closed for a policy violation: %v", acsError)
 		}
 
 		// Session with ACS was stopped with some error, start processing the error
@@ -200,8 +336,17 @@ func (acsSession *session) Start() error {
 		}
 
 		// Disconnected unexpectedly from ACS, compute backoff duration to
-		// reconnect
-		reconnectDelay := acsSession.computeReconnectDelay(isInactiveInstance)
+		// reconnect. Failures to discover the ACS endpoint back off separately,
+		// as they are usually caused by the control plane throttling discovery.
+		var reconnectDelay time.Duration
+		if !isInactiveInstance && isDiscoverPollEndpointError(acsError) {
+			reconnectDelay = acsSession.discoverPollEndpointRetryDelay(acsError)
+		} else {
+			reconnectDelay = acsSession.computeReconnectDelay(isInactiveInstance)
+		}
+		if minDelay := minReconnectDelay(policy); reconnectDelay < minDelay {
+			reconnectDelay = retry.AddJitter(minDelay, minDelay)
+		}
 		seelog.Infof("Reconnecting to ACS in: %s", reconnectDelay.String())
 		waitComplete := acsSession.waitForDuration(reconnectDelay)
 		if !waitComplete {
@@ -222,40 +367,131 @@ func (acsSession *session) Start() error {
 // in arguments
 func (acsSession *session) startSessionOnce() error {
 	minAgentCfg := &wsclient.WSClientMinAgentConfig{
-		AcceptInsecureCert: acsSession.agentConfig.AcceptInsecureCert,
-		AWSRegion:          acsSession.agentConfig.AWSRegion,
+		AcceptInsecureCert:   acsSession.agentConfig.AcceptInsecureCert,
+		AWSRegion:            acsSession.agentConfig.AWSRegion,
+		ExpectedServerName:   acsSession.agentConfig.ACSExpectedServerName,
+		TLSSessionResumption: acsSession.agentConfig.ACSTLSSessionResumption.Enabled(),
 	}
 
-	acsEndpoint, err := acsSession.ecsClient.DiscoverPollEndpoint(acsSession.containerInstanceARN)
+	acsEndpoint, err := acsSession.discoverPollEndpoint()
+	acsSession.recordDiscoverPollEndpointResult(err)
 	if err != nil {
 		seelog.Errorf("acs: unable to discover poll endpoint, err: %v", err)
-		return err
+		return discoverPollEndpointError{err}
 	}
+	acsSession.discoverPollEndpointBackoff.Reset()
+	acsSession.consecutiveDiscoverDNSFailures = 0
 
 	url := acsSession.acsURL(acsEndpoint)
+	acsSession.logConnect(url)
 	client := acsSession.clientFactory.New(
 		url,
 		acsSession.credentialsProvider,
-		wsRWTimeout,
+		wsRWTimeout(acsSession.heartbeatTimeout(), acsSession.heartbeatJitter()),
 		minAgentCfg)
 	defer client.Close()
 
 	return acsSession.startACSSession(client)
 }
 
+// discoverPollEndpoint discovers the ACS endpoint. The ECS client call is cancelled when it doesn't
+// return within the configured timeout or when the session is cancelled. Start retries a timed out
+// discovery with the DiscoverPollEndpoint backoff.
+func (acsSession *session) discoverPollEndpoint() (string, error) {
+	ctx, cancel := context.WithTimeout(acsSession.ctx, acsSession.agentConfig.DiscoverPollEndpointTimeout)
+	defer cancel()
+
+	endpoint, err := acsSession.ecsClient.DiscoverPollEndpoint(ctx, acsSession.containerInstanceARN)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("DiscoverPollEndpoint did not return within %s: %w",
+			acsSession.agentConfig.DiscoverPollEndpointTimeout, ctx.Err())
+	}
+	return endpoint, err
+}
+
+// discoverPollEndpointRetryDelay returns how long to wait before retrying a failed DiscoverPollEndpoint
+// call. The first DiscoverDNSRetryAttempts consecutive DNS resolution errors are retried after a short,
+// fixed interval, as DNS is often briefly unavailable at boot and recovers quickly. Any other error is
+// retried with the DiscoverPollEndpoint backoff.
+func (acsSession *session) discoverPollEndpointRetryDelay(err error) time.Duration {
+	if !isDNSError(err) {
+		acsSession.consecutiveDiscoverDNSFailures = 0
+		return acsSession.discoverPollEndpointBackoff.Duration()
+	}
+
+	acsSession.consecutiveDiscoverDNSFailures++
+	if acsSession.consecutiveDiscoverDNSFailures > acsSession.agentConfig.DiscoverDNSRetryAttempts {
+		return acsSession.discoverPollEndpointBackoff.Duration()
+	}
+	seelog.Infof("acs: unable to resolve the ECS endpoint (attempt %d of %d), retrying after %s",
+		acsSession.consecutiveDiscoverDNSFailures, acsSession.agentConfig.DiscoverDNSRetryAttempts,
+		acsSession.agentConfig.DiscoverDNSRetryInterval)
+	return acsSession.agentConfig.DiscoverDNSRetryInterval
+}
+
+// recordDiscoverPollEndpointResult tracks consecutive authentication errors from DiscoverPollEndpoint.
+// Once DiscoverAuthFailureThreshold of them are reached, a critical error is logged and the agent is
+// reported as impaired until the endpoint is discovered again. Other errors are not counted, and don't
+// reset the count either.
+func (acsSession *session) recordDiscoverPollEndpointResult(err error) {
+	threshold := acsSession.agentConfig.DiscoverAuthFailureThreshold
+	if err == nil {
+		if threshold > 0 && acsSession.consecutiveDiscoverAuthFailures >= threshold {
+			seelog.Info("acs: discovered the poll endpoint after persistent authentication errors")
+		}
+		acsSession.consecutiveDiscoverAuthFailures = 0
+		if acsSession.discoverHealthcheck != nil {
+			acsSession.discoverHealthcheck.SetAuthFailing(false)
+		}
+		return
+	}
+	if !isAuthError(err) {
+		return
+	}
+
+	acsSession.consecutiveDiscoverAuthFailures++
+	if threshold <= 0 || acsSession.consecutiveDiscoverAuthFailures < threshold {
+		return
+	}
+	if acsSession.consecutiveDiscoverAuthFailures == threshold {
+		seelog.Criticalf("acs: unable to discover poll endpoint after %d consecutive authentication errors, "+
+			"check the instance credentials and their permissions: %v", threshold, err)
+	}
+	if acsSession.doctor == nil {
+		return
+	}
+	if acsSession.discoverHealthcheck == nil {
+		healthcheck := dockerdoctor.NewDiscoverPollEndpointHealthcheck()
+		acsSession.discoverHealthcheck = healthcheck
+		acsSession.doctor.AddHealthcheck(healthcheck)
+	}
+	acsSession.discoverHealthcheck.SetAuthFailing(true)
+}
+
 // startACSSession starts a session with ACS. It adds request handlers for various
 // kinds of messages expected from ACS. It returns on server disconnection or when
 // the context is cancelled
 func (acsSession *session) startACSSession(client wsclient.ClientServer) error {
 	cfg := acsSession.agentConfig
 
+	// Messages that are not handled within the processing timeout configured for their type, or
+	// that fail to be handled, are nacked
+	nacker := newMessageNacker(client, cfg.Cluster, acsSession.containerInstanceARN)
+	// Handling messages is tracked to detect stalls of the message handling loop
+	watchdog := newHandlerWatchdog(cfg.ACSHandlerStallThreshold, cfg.ACSMessageProcessingTimeouts)
+	addRequestHandler := func(handler wsclient.RequestHandler) {
+		client.AddRequestHandler(watchdog.track(withProcessingTimeout(handler, cfg.ACSMessageProcessingTimeouts,
+			nacker, acsSession.metricsFactory)))
+	}
+
 	refreshCredsHandler := newRefreshCredentialsHandler(acsSession.ctx, cfg.Cluster, acsSession.containerInstanceARN,
-		client, acsSession.credentialsManager, acsSession.taskEngine)
+		client, acsSession.credentialsManager, acsSession.taskEngine, cfg.ACSCredentialsRefreshConcurrency)
+	refreshCredsHandler.metricsFactory = acsSession.metricsFactory
 	defer refreshCredsHandler.clearAcks()
 	refreshCredsHandler.start()
 	defer refreshCredsHandler.stop()
 
-	client.AddRequestHandler(refreshCredsHandler.handlerFunc())
+	addRequestHandler(refreshCredsHandler.handlerFunc())
 
 	eniHandler := &eniHandler{
 		state:      acsSession.state,
@@ -273,7 +509,7 @@ func (acsSession *session) startACSSession(client wsclient.ClientServer) error {
 	eniAttachHandler.start()
 	defer eniAttachHandler.stop()
 
-	client.AddRequestHandler(eniAttachHandler.handlerFunc())
+	addRequestHandler(eniAttachHandler.handlerFunc())
 
 	// Add handler to ack instance ENI attach message
 	instanceENIAttachHandler := newAttachInstanceENIHandler(
@@ -286,7 +522,7 @@ func (acsSession *session) startACSSession(client wsclient.ClientServer) error {
 	instanceENIAttachHandler.start()
 	defer instanceENIAttachHandler.stop()
 
-	client.AddRequestHandler(instanceENIAttachHandler.handlerFunc())
+	addRequestHandler(instanceENIAttachHandler.handlerFunc())
 
 	manifestMessageIDAccessor := &manifestMessageIDAccessor{}
 
@@ -299,8 +535,8 @@ func (acsSession *session) startACSSession(client wsclient.ClientServer) error {
 	taskManifestHandler.start()
 	defer taskManifestHandler.stop()
 
-	client.AddRequestHandler(taskManifestHandler.handlerFuncTaskManifestMessage())
-	client.AddRequestHandler(taskManifestHandler.handlerFuncTaskStopVerificationMessage())
+	addRequestHandler(taskManifestHandler.handlerFuncTaskManifestMessage())
+	addRequestHandler(taskManifestHandler.handlerFuncTaskStopVerificationMessage())
 
 	// Add request handler for handling payload messages from ACS
 	payloadHandler := newPayloadRequestHandler(
@@ -313,35 +549,66 @@ func (acsSession *session) startACSSession(client wsclient.ClientServer) error {
 		acsSession.dataClient,
 		refreshCredsHandler,
 		acsSession.credentialsManager,
-		acsSession.taskHandler, acsSession.latestSeqNumTaskManifest)
+		acsSession.taskHandler, acsSession.latestSeqNumTaskManifest,
+		acsSession.processingPauser,
+		cfg.ACSAckAfterTaskPersisted.Enabled())
 	// Clear the acks channel on return because acks of messageids don't have any value across sessions
 	defer payloadHandler.clearAcks()
 	payloadHandler.start()
 	defer payloadHandler.stop()
 
-	client.AddRequestHandler(payloadHandler.handlerFunc())
+	addRequestHandler(payloadHandler.handlerFunc())
 
-	client.AddRequestHandler(HeartbeatHandlerFunc(client, acsSession.doctor))
+	heartbeatHandler := HeartbeatHandlerFunc(client, acsSession.doctor)
+	addRequestHandler(func(message *ecsacs.HeartbeatMessage) {
+		acsSession.agentMetrics.recordHeartbeat()
+		heartbeatHandler(message)
+	})
+
+	addRequestHandler(instanceCredentialsHandlerFunc(client, acsSession.credentialsProvider, nacker,
+		cfg.Cluster, acsSession.containerInstanceARN))
+
+	addRequestHandler(capabilitiesUpdateHandlerFunc(client, acsSession.dataClient, nacker,
+		cfg.Cluster, acsSession.containerInstanceARN))
+
+	addRequestHandler(drainHandlerFunc(client, acsSession.dataClient, acsSession.drainState, nacker,
+		cfg.Cluster, acsSession.containerInstanceARN))
+
+	addRequestHandler(connectionParametersHandlerFunc(client, acsSession, cfg.Cluster,
+		acsSession.containerInstanceARN))
 
 	updater.AddAgentUpdateHandlers(client, cfg, acsSession.state, acsSession.dataClient, acsSession.taskEngine)
 
 	err := client.Connect()
 	if err != nil {
-		seelog.Errorf("Error connecting to ACS: %v", err)
+		acsSession.logConnectFailure(err)
 		return err
 	}
 
 	seelog.Info("Connected to ACS endpoint")
+	acsSession.consecutiveConnectFailures = 0
+	acsSession.setProtocolVersion(negotiatedProtocolVersion(client))
+	acsSession.agentMetrics.recordConnection()
+	// Send agent metrics to ACS for as long as the connection lasts, if enabled
+	agentMetricsCtx, cancelAgentMetrics := context.WithCancel(acsSession.ctx)
+	defer cancelAgentMetrics()
+	newAgentMetricsReporter(client, cfg.Cluster, acsSession.containerInstanceARN, acsSession.state,
+		&acsSession.agentMetrics, cfg.ACSAgentMetricsInterval).start(agentMetricsCtx)
+
+	// The connection parameters are read once per connection, so that updates from ACS apply to
+	// the next connection
+	heartbeatTimeout := acsSession.heartbeatTimeout()
 	// Start a connection timer; agent will send pending acks and close its ACS websocket connection
 	// after this timer expires
-	connectionTimer := newConnectionTimer(client, acsSession.connectionTime, acsSession.connectionJitter,
+	connectionTimer := newConnectionTimer(client, acsSession.connectionTime(), acsSession.connectionJitter,
 		&refreshCredsHandler, &taskManifestHandler, &payloadHandler)
 	defer connectionTimer.Stop()
 
 	// Start a heartbeat timer for closing the connection
-	heartbeatTimer := newHeartbeatTimer(client, acsSession.heartbeatTimeout(), acsSession.heartbeatJitter())
+	heartbeatTimer := newHeartbeatTimer(client, heartbeatTimeout, acsSession.heartbeatJitter())
 	// Any message from the server resets the heartbeat timer
-	client.SetAnyRequestHandler(anyMessageHandler(heartbeatTimer, client))
+	client.SetAnyRequestHandler(watchdog.track(anyMessageHandler(heartbeatTimer, heartbeatTimeout,
+		acsSession.heartbeatJitter(), client, acsSession.recentMessages)))
 	defer heartbeatTimer.Stop()
 
 	// Connection to ACS was successful. Moving forward, rely on ACS to send credentials to Agent at its own cadence
@@ -349,7 +616,7 @@ func (acsSession *session) startACSSession(client wsclient.ClientServer) error {
 	acsSession.sendCredentials = false
 
 	backoffResetTimer := time.AfterFunc(
-		retry.AddJitter(acsSession.heartbeatTimeout(), acsSession.heartbeatJitter()), func() {
+		retry.AddJitter(heartbeatTimeout, acsSession.heartbeatJitter()), func() {
 			// If we do not have an error connecting and remain connected for at
 			// least 1 or so minutes, reset the backoff. This prevents disconnect
 			// errors that only happen infrequently from damaging the reconnect
@@ -358,7 +625,125 @@ func (acsSession *session) startACSSession(client wsclient.ClientServer) error {
 		})
 	defer backoffResetTimer.Stop()
 
-	return client.Serve(acsSession.ctx)
+	return acsSession.serve(client, watchdog)
+}
+
+// serve serves the messages of the ACS connection until the connection is closed or the session
+// is cancelled. If the message handling loop stalls, the connection is closed and
+// errACSHandlerStalled is returned, so that the session reconnects to ACS. The loop is waited for
+// up to stalledServeJoinTimeout, as it only returns once the stalled handler does.
+func (acsSession *session) serve(client wsclient.ClientServer, watchdog *handlerWatchdog) error {
+	watchdogCtx, cancelWatchdog := context.WithCancel(acsSession.ctx)
+	defer cancelWatchdog()
+	stalled := watchdog.watch(watchdogCtx)
+
+	serveCtx, cancelServe := context.WithCancel(acsSession.ctx)
+	defer cancelServe()
+	served := make(chan error, 1)
+	go func() {
+		served <- client.Serve(serveCtx)
+	}()
+	select {
+	case err := <-served:
+		return err
+	case <-stalled:
+	}
+
+	cancelServe()
+	if err := client.Close(); err != nil {
+		logger.Debug("Error closing the ACS connection of the stalled message handling loop", logger.Fields{
+			field.Error: err,
+		})
+	}
+	timer := time.NewTimer(stalledServeJoinTimeout)
+	defer timer.Stop()
+	select {
+	case <-served:
+	case <-timer.C:
+		logger.Error("ACS message handling loop still stalled after closing its connection, "+
+			"reconnecting without waiting for it", logger.Fields{
+			"joinTimeout": stalledServeJoinTimeout.String(),
+		})
+	}
+	return errACSHandlerStalled
+}
+
+// Status returns the state of the session's connection with ACS
+func (acsSession *session) Status() SessionStatus {
+	acsSession.statusLock.RLock()
+	defer acsSession.statusLock.RUnlock()
+
+	return acsSession.status
+}
+
+// logConnectFailure logs a failure to connect to ACS with a severity that escalates
+// with the number of consecutive failures, so that transient failures do not
+// flood the logs with errors
+func (acsSession *session) logConnectFailure(err error) {
+	acsSession.consecutiveConnectFailures++
+	fields := logger.Fields{
+		field.Error:                  err,
+		"consecutiveConnectFailures": acsSession.consecutiveConnectFailures,
+	}
+	switch connectFailureLogLevel(acsSession.consecutiveConnectFailures,
+		acsSession.agentConfig.ACSConnectFailureWarnThreshold,
+		acsSession.agentConfig.ACSConnectFailureErrorThreshold) {
+	case seelog.ErrorLvl:
+		logger.Error("Error connecting to ACS", fields)
+	case seelog.WarnLvl:
+		logger.Warn("Error connecting to ACS", fields)
+	default:
+		logger.Info("Error connecting to ACS", fields)
+	}
+}
+
+// connectFailureLogLevel returns the severity with which to log a failure to connect
+// to ACS, given the number of consecutive failures. A threshold that is not set
+// disables the escalation to its level.
+func connectFailureLogLevel(consecutiveFailures, warnThreshold, errorThreshold int) seelog.LogLevel {
+	switch {
+	case errorThreshold > 0 && consecutiveFailures >= errorThreshold:
+		return seelog.ErrorLvl
+	case warnThreshold > 0 && consecutiveFailures >= warnThreshold:
+		return seelog.WarnLvl
+	default:
+		return seelog.InfoLvl
+	}
+}
+
+func (acsSession *session) setProtocolVersion(protocolVersion int) {
+	acsSession.statusLock.Lock()
+	defer acsSession.statusLock.Unlock()
+
+	acsSession.status.ProtocolVersion = protocolVersion
+}
+
+// negotiatedProtocolVersion returns the protocol version accepted by ACS for the
+// client's connection, as reported in the handshake response. The advertised
+// protocol version is returned when ACS does not report a valid version.
+func negotiatedProtocolVersion(client wsclient.ClientServer) int {
+	provider, ok := client.(handshakeHeaderProvider)
+	if !ok {
+		return acsProtocolVersion
+	}
+	value := provider.HandshakeHeader().Get(acsProtocolVersionHeader)
+	if value == "" {
+		return acsProtocolVersion
+	}
+	protocolVersion, err := strconv.Atoi(value)
+	if err != nil || protocolVersion <= 0 {
+		logger.Warn("Ignoring invalid protocol version in the ACS handshake response", logger.Fields{
+			"protocolVersion": value,
+		})
+		return acsProtocolVersion
+	}
+	if protocolVersion != acsProtocolVersion {
+		logger.Info("ACS accepted a different protocol version than advertised", logger.Fields{
+			"advertisedProtocolVersion": acsProtocolVersion,
+			"protocolVersion":           protocolVersion,
+		})
+	}
+	return protocolVersion
 }
 
 func (acsSession *session) computeReconnectDelay(isInactiveInstance bool) time.Duration {
@@ -383,6 +768,9 @@ func (acsSession *session) waitForDuration(delay time.Duration) bool {
 }
 
 func (acsSession *session) heartbeatTimeout() time.Duration {
+	acsSession.connectionParametersLock.RLock()
+	defer acsSession.connectionParametersLock.RUnlock()
+
 	return acsSession._heartbeatTimeout
 }
 
@@ -390,6 +778,34 @@ func (acsSession *session) heartbeatJitter() time.Duration {
 	return acsSession._heartbeatJitter
 }
 
+// connectionTime returns the time after which the agent closes its connection to ACS, before jitter
+func (acsSession *session) connectionTime() time.Duration {
+	acsSession.connectionParametersLock.RLock()
+	defer acsSession.connectionParametersLock.RUnlock()
+
+	return acsSession._connectionTime
+}
+
+// setConnectionParameters sets the heartbeat timeout and the connection time used by the next
+// connections to ACS. A zero value leaves the corresponding parameter unchanged.
+func (acsSession *session) setConnectionParameters(heartbeatTimeout, connectionTime time.Duration) {
+	acsSession.connectionParametersLock.Lock()
+	defer acsSession.connectionParametersLock.Unlock()
+
+	if heartbeatTimeout > 0 {
+		acsSession._heartbeatTimeout = heartbeatTimeout
+	}
+	if connectionTime > 0 {
+		acsSession._connectionTime = connectionTime
+	}
+}
+
+// wsRWTimeout returns the duration of read and write deadline for the websocket connection,
+// which allows for a missed heartbeat
+func wsRWTimeout(heartbeatTimeout, heartbeatJitter time.Duration) time.Duration {
+	return 2*heartbeatTimeout + heartbeatJitter
+}
+
 // acsURL returns the websocket url for ACS given the endpoint
 func (acsSession *session) acsURL(endpoint string) string {
 	acsURL := endpoint
@@ -408,9 +824,66 @@ func (acsSession *session) acsURL(endpoint string) string {
 		query.Set("dockerVersion", "DockerVersion: "+dockerVersion)
 	}
 	query.Set(sendCredentialsURLParameterName, strconv.FormatBool(acsSession.sendCredentials))
+	// A container instance that's draining reports it on every connection, so that an agent
+	// restart doesn't lose it
+	if acsSession.drainState.Draining() {
+		query.Set(drainingURLParameterName, "true")
+	}
 	return acsURL + "?" + query.Encode()
 }
 
+// logConnect logs the ACS websocket url being connected to. It's logged at info level if
+// ACSURLLoggingEnabled is set, so that operators can debug endpoint and region issues.
+func (acsSession *session) logConnect(acsURL string) {
+	fields := acsURLLogFields(acsURL)
+	if acsSession.agentConfig.ACSURLLoggingEnabled.Enabled() {
+		logger.Info("Connecting to ACS", fields)
+		return
+	}
+	logger.Debug("Connecting to ACS", fields)
+}
+
+// acsURLLogFields returns the fields logged for the ACS websocket url on each connect. The values of
+// query parameters that could carry credentials are redacted from the logged url, so that they
+// can't leak into the logs if they are ever added to it.
+func acsURLLogFields(acsURL string) logger.Fields {
+	parsedURL, err := url.Parse(acsURL)
+	if err != nil {
+		return logger.Fields{
+			field.Error: err,
+		}
+	}
+	parsedURL.User = nil
+	query := parsedURL.Query()
+	for name := range query {
+		if isSensitiveURLParameter(name) {
+			query.Set(name, redactedURLParameterValue)
+		}
+	}
+	parsedURL.RawQuery = query.Encode()
+	return logger.Fields{
+		"url":                           parsedURL.String(),
+		field.Cluster:                   query.Get("clusterArn"),
+		"containerInstanceArn":          query.Get("containerInstanceArn"),
+		"seqNum":                        query.Get("seqNum"),
+		sendCredentialsURLParameterName: query.Get(sendCredentialsURLParameterName),
+	}
+}
+
+// isSensitiveURLParameter returns true if the value of the url query parameter could be a credential.
+func isSensitiveURLParameter(name string) bool {
+	if name == sendCredentialsURLParameterName {
+		return false
+	}
+	name = strings.ToLower(name)
+	for _, substring := range sensitiveURLParameterSubstrings {
+		if strings.Contains(name, substring) {
+			return true
+		}
+	}
+	return false
+}
+
 // newHeartbeatTimer creates a new time object, with a callback to
 // disconnect from ACS on inactivity
 func newHeartbeatTimer(client wsclient.ClientServer, timeout time.Duration, jitter time.Duration) ttime.Timer {
@@ -477,12 +950,15 @@ func newConnectionTimer(
 }
 
 // anyMessageHandler handles any server message. Any server message means the
-// connection is active and thus the heartbeat disconnect should not occur
-func anyMessageHandler(timer ttime.Timer, client wsclient.ClientServer) func(interface{}) {
-	return func(interface{}) {
+// connection is active and thus the heartbeat disconnect should not occur. The
+// message is also recorded in recentMessages for debugging.
+func anyMessageHandler(timer ttime.Timer, heartbeatTimeout, heartbeatJitter time.Duration,
+	client wsclient.ClientServer, recentMessages *RecentMessages) func(interface{}) {
+	return func(message interface{}) {
 		seelog.Debug("ACS activity occurred")
+		recentMessages.Record(message)
 		// Reset read deadline as there's activity on the channel
-		if err := client.SetReadDeadline(time.Now().Add(wsRWTimeout)); err != nil {
+		if err := client.SetReadDeadline(time.Now().Add(wsRWTimeout(heartbeatTimeout, heartbeatJitter))); err != nil {
 			seelog.Warnf("Unable to extend read deadline for ACS connection: %v", err)
 		}
 
@@ -491,8 +967,100 @@ func anyMessageHandler(timer ttime.Timer, client wsclient.ClientServer) func(int
 	}
 }
 
-func shouldReconnectWithoutBackoff(acsError error) bool {
-	return acsError == nil || acsError == io.EOF
+// reconnectPolicyFor returns the reconnect policy for the error that ended a session with ACS.
+// Connections closed for a valid reason are reconnected immediately, websocket close errors use
+// the policy of their close code and any other error is reconnected with backoff.
+func reconnectPolicyFor(acsError error) reconnectPolicy {
+	if acsError == nil || acsError == io.EOF {
+		return reconnectImmediately
+	}
+	var closeErr *websocket.CloseError
+	if errors.As(acsError, &closeErr) {
+		if policy, ok := closeCodeReconnectPolicies[closeErr.Code]; ok {
+			return policy
+		}
+	}
+	return reconnectWithBackoff
+}
+
+// minReconnectDelay returns the minimum time to wait before reconnecting with the reconnect policy,
+// to which a jitter of up to the same duration is added.
+func minReconnectDelay(policy reconnectPolicy) time.Duration {
+	switch policy {
+	case reconnectWithLongBackoff:
+		return tryAgainLaterReconnectDelay
+	case reconnectAfterPolicyViolation:
+		return policyViolationReconnectDelay
+	}
+	return 0
+}
+
+// discoverPollEndpointError wraps an error returned when discovering the ACS endpoint.
+type discoverPollEndpointError struct {
+	error
+}
+
+func (e discoverPollEndpointError) Unwrap() error {
+	return e.error
+}
+
+func isDiscoverPollEndpointError(acsError error) bool {
+	return errors.As(acsError, &discoverPollEndpointError{})
+}
+
+// authFailureHealthcheck is a healthcheck that is impaired while authentication is failing.
+type authFailureHealthcheck interface {
+	doctor.Healthcheck
+	SetAuthFailing(bool)
+}
+
+// authErrorCodes are the error codes returned by the ECS API when the credentials of the
+// caller are invalid, expired or not allowed to make the call.
+var authErrorCodes = map[string]struct{}{
+	"AccessDeniedException":       {},
+	"UnrecognizedClientException": {},
+	"InvalidClientTokenId":        {},
+	"InvalidSignatureException":   {},
+	"IncompleteSignature":         {},
+	"MissingAuthenticationToken":  {},
+	"ExpiredTokenException":       {},
+	"NoCredentialProviders":       {},
+}
+
+// isAuthError returns true if the error returned by an ECS API call is caused by the credentials
+// of the caller, rather than by the service or the network.
+func isAuthError(err error) bool {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return false
+	}
+	if _, ok := authErrorCodes[awsErr.Code()]; ok {
+		return true
+	}
+	var requestFailure awserr.RequestFailure
+	if errors.As(err, &requestFailure) {
+		return requestFailure.StatusCode() == http.StatusUnauthorized ||
+			requestFailure.StatusCode() == http.StatusForbidden
+	}
+	return false
+}
+
+// isDNSError returns true if the error returned by an ECS API call is caused by a failure to
+// resolve the endpoint. The SDK doesn't support unwrapping its errors, so their original errors
+// are walked explicitly.
+func isDNSError(err error) bool {
+	for err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) {
+			return true
+		}
+		var awsErr awserr.Error
+		if !errors.As(err, &awsErr) {
+			return false
+		}
+		err = awsErr.OrigErr()
+	}
+	return false
 }
 
 func isInactiveInstanceError(acsError error) bool {
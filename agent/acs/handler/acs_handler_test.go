@@ -16,9 +16,11 @@
 package handler
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -46,16 +48,20 @@ import (
 	rolecredentials "github.com/aws/amazon-ecs-agent/ecs-agent/credentials"
 	mock_credentials "github.com/aws/amazon-ecs-agent/ecs-agent/credentials/mocks"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/doctor"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger/field"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/utils/retry"
 	mock_retry "github.com/aws/amazon-ecs-agent/ecs-agent/utils/retry/mock"
 	mock_wsclient "github.com/aws/amazon-ecs-agent/ecs-agent/wsclient/mock"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/cihub/seelog"
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -139,8 +145,9 @@ const (
 )
 
 var testConfig = &config.Config{
-	Cluster:            "someCluster",
-	AcceptInsecureCert: true,
+	Cluster:                     "someCluster",
+	AcceptInsecureCert:          true,
+	DiscoverPollEndpointTimeout: config.DefaultDiscoverPollEndpointTimeout,
 }
 
 var testCreds = credentials.NewStaticCredentials("test-id", "test-secret", "test-token")
@@ -175,6 +182,105 @@ func TestACSURL(t *testing.T) {
 	assert.True(t, protocolVersion > 1, "ACS protocol version should be greater than 1")
 }
 
+// TestACSURLLogFields tests that the fields logged when connecting to ACS match the parameters of the
+// ACS URL, and that sensitive parameters are redacted from the logged URL
+func TestACSURLLogFields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
+
+	taskEngine.EXPECT().Version().Return("Docker version result", nil)
+
+	acsSession := session{
+		taskEngine:           taskEngine,
+		sendCredentials:      false,
+		agentConfig:          testConfig,
+		containerInstanceARN: "myContainerInstance",
+	}
+	wsurl := acsSession.acsURL(acsURL)
+	parsed, err := url.Parse(wsurl)
+	require.NoError(t, err, "should be able to parse URL")
+
+	fields := acsURLLogFields(wsurl)
+	assert.Equal(t, wsurl, fields["url"], "URL without sensitive parameters should be logged as is")
+	assert.Equal(t, parsed.Query().Get("clusterArn"), fields[field.Cluster], "wrong cluster")
+	assert.Equal(t, parsed.Query().Get("containerInstanceArn"), fields["containerInstanceArn"], "wrong container instance")
+	assert.Equal(t, parsed.Query().Get("seqNum"), fields["seqNum"], "wrong seqNum")
+	assert.Equal(t, parsed.Query().Get(sendCredentialsURLParameterName), fields[sendCredentialsURLParameterName],
+		"wrong value for: %s", sendCredentialsURLParameterName)
+	assert.Equal(t, "false", fields[sendCredentialsURLParameterName])
+
+	query := parsed.Query()
+	query.Set("X-Amz-Security-Token", "secret-token")
+	query.Set("clientSecret", "secret-value")
+	parsed.RawQuery = query.Encode()
+	parsed.User = url.UserPassword("user", "secret-password")
+
+	fields = acsURLLogFields(parsed.String())
+	loggedURL, ok := fields["url"].(string)
+	require.True(t, ok, "logged URL should be a string")
+	assert.NotContains(t, loggedURL, "secret")
+	loggedParsed, err := url.Parse(loggedURL)
+	require.NoError(t, err, "should be able to parse logged URL")
+	assert.Nil(t, loggedParsed.User)
+	assert.Equal(t, redactedURLParameterValue, loggedParsed.Query().Get("X-Amz-Security-Token"))
+	assert.Equal(t, redactedURLParameterValue, loggedParsed.Query().Get("clientSecret"))
+	assert.Equal(t, "myContainerInstance", loggedParsed.Query().Get("containerInstanceArn"))
+	assert.Equal(t, "false", loggedParsed.Query().Get(sendCredentialsURLParameterName))
+}
+
+// TestLogConnect tests that the redacted ACS URL is logged at info level on connect when ACS URL
+// logging is enabled, and only at debug level otherwise
+func TestLogConnect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
+	taskEngine.EXPECT().Version().Return("Docker version result", nil).AnyTimes()
+
+	currentLogger := seelog.Current
+	defer seelog.ReplaceLogger(currentLogger)
+
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("enabled %t", enabled), func(t *testing.T) {
+			var logs bytes.Buffer
+			infoLogger, err := seelog.LoggerFromWriterWithMinLevelAndFormat(&logs, seelog.InfoLvl, "%Msg%n")
+			require.NoError(t, err)
+			require.NoError(t, seelog.ReplaceLogger(infoLogger))
+
+			cfg := *testConfig
+			cfg.ACSURLLoggingEnabled = config.BooleanDefaultFalse{Value: config.ExplicitlyDisabled}
+			if enabled {
+				cfg.ACSURLLoggingEnabled = config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled}
+			}
+			acsSession := session{
+				taskEngine:           taskEngine,
+				agentConfig:          &cfg,
+				containerInstanceARN: "myContainerInstance",
+			}
+			parsed, err := url.Parse(acsSession.acsURL(acsURL))
+			require.NoError(t, err)
+			query := parsed.Query()
+			query.Set("X-Amz-Security-Token", "secret-token")
+			parsed.RawQuery = query.Encode()
+
+			acsSession.logConnect(parsed.String())
+			infoLogger.Flush()
+
+			if !enabled {
+				assert.Empty(t, logs.String())
+				return
+			}
+			logged := logs.String()
+			assert.Contains(t, logged, "Connecting to ACS")
+			assert.Contains(t, logged, url.QueryEscape(cfg.Cluster))
+			assert.Contains(t, logged, "myContainerInstance")
+			assert.Contains(t, logged, "agentVersion="+url.QueryEscape(version.Version))
+			assert.Contains(t, logged, redactedURLParameterValue)
+			assert.NotContains(t, logged, "secret-token")
+		})
+	}
+}
+
 // TestHandlerReconnectsOnConnectErrors tests if handler reconnects retries
 // to establish the session with ACS when ClientServer.Connect() returns errors
 func TestHandlerReconnectsOnConnectErrors(t *testing.T) {
@@ -184,7 +290,7 @@ func TestHandlerReconnectsOnConnectErrors(t *testing.T) {
 	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
 
 	ecsClient := mock_api.NewMockECSClient(ctrl)
-	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(acsURL, nil).AnyTimes()
+	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil).AnyTimes()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
@@ -210,30 +316,222 @@ func TestHandlerReconnectsOnConnectErrors(t *testing.T) {
 		}).Return(nil).MinTimes(1),
 	)
 	acsSession := session{
+		containerInstanceARN:        "myArn",
+		credentialsProvider:         testCreds,
+		agentConfig:                 testConfig,
+		taskEngine:                  taskEngine,
+		ecsClient:                   ecsClient,
+		dataClient:                  data.NewNoopClient(),
+		taskHandler:                 taskHandler,
+		backoff:                     retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		discoverPollEndpointBackoff: retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		ctx:                         ctx,
+		cancel:                      cancel,
+		clientFactory:               mockClientFactory,
+		_heartbeatTimeout:           20 * time.Millisecond,
+		_heartbeatJitter:            10 * time.Millisecond,
+		_connectionTime:             30 * time.Millisecond,
+		connectionJitter:            10 * time.Millisecond,
+	}
+	go func() {
+		acsSession.Start()
+	}()
+
+	// Wait for context to be cancelled
+	select {
+	case <-ctx.Done():
+	}
+}
+
+// TestHandlerSecondSessionForSameInstanceWaits tests that a session waits to connect while
+// another session is active for the same container instance, and connects once it ends
+func TestHandlerSecondSessionForSameInstanceWaits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
+	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
+
+	ecsClient := mock_api.NewMockECSClient(ctrl)
+	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil).AnyTimes()
+
+	firstCtx, firstCancel := context.WithCancel(context.Background())
+	defer firstCancel()
+	secondCtx, secondCancel := context.WithCancel(context.Background())
+	defer secondCancel()
+	taskHandler := eventhandler.NewTaskHandler(firstCtx, data.NewNoopClient(), nil, nil)
+
+	firstConnected := make(chan struct{})
+	secondConnected := make(chan struct{})
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	mockClientFactory := mock_wsclient.NewMockClientFactory(ctrl)
+	mockClientFactory.EXPECT().
+		New(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(mockWsClient).Times(2)
+	mockWsClient.EXPECT().SetAnyRequestHandler(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().AddRequestHandler(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().WriteCloseMessage().Return(nil).AnyTimes()
+	mockWsClient.EXPECT().Close().Return(nil).AnyTimes()
+	// Each session stays connected until its context is cancelled
+	gomock.InOrder(
+		mockWsClient.EXPECT().Connect().Do(func() {
+			close(firstConnected)
+			<-firstCtx.Done()
+		}).Return(io.EOF),
+		mockWsClient.EXPECT().Connect().Do(func() {
+			close(secondConnected)
+			<-secondCtx.Done()
+		}).Return(io.EOF),
+	)
+
+	limiter := newSessionLimiter()
+	newTestSession := func(ctx context.Context, cancel context.CancelFunc) *session {
+		return &session{
+			containerInstanceARN:        "myArn",
+			credentialsProvider:         testCreds,
+			agentConfig:                 testConfig,
+			taskEngine:                  taskEngine,
+			ecsClient:                   ecsClient,
+			dataClient:                  data.NewNoopClient(),
+			taskHandler:                 taskHandler,
+			backoff:                     retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+			discoverPollEndpointBackoff: retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+			ctx:                         ctx,
+			cancel:                      cancel,
+			clientFactory:               mockClientFactory,
+			_heartbeatTimeout:           20 * time.Millisecond,
+			_heartbeatJitter:            10 * time.Millisecond,
+			_connectionTime:             30 * time.Millisecond,
+			connectionJitter:            10 * time.Millisecond,
+			sessionLimiter:              limiter,
+		}
+	}
+
+	firstSessionDone := make(chan error, 1)
+	go func() {
+		firstSessionDone <- newTestSession(firstCtx, firstCancel).Start()
+	}()
+	<-firstConnected
+
+	// The second session for the same container instance waits without connecting
+	secondSessionDone := make(chan error, 1)
+	go func() {
+		secondSessionDone <- newTestSession(secondCtx, secondCancel).Start()
+	}()
+	select {
+	case <-secondConnected:
+		t.Fatal("second session connected while the first session was active")
+	case err := <-secondSessionDone:
+		t.Fatalf("second session ended while the first session was active: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Once the first session ends, the second session connects
+	firstCancel()
+	assert.NoError(t, <-firstSessionDone)
+	select {
+	case <-secondConnected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("second session did not connect after the first session ended")
+	}
+
+	secondCancel()
+	assert.NoError(t, <-secondSessionDone)
+	assert.Empty(t, limiter.sessions, "expected both sessions to be released")
+}
+
+// TestHandlerWaitingSessionStops tests that a session waiting for another session of the same
+// container instance to end stops without error when its context is cancelled
+func TestHandlerWaitingSessionStops(t *testing.T) {
+	limiter := newSessionLimiter()
+	require.NoError(t, limiter.acquire(context.Background(), "myArn", 1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	acsSession := &session{
 		containerInstanceARN: "myArn",
-		credentialsProvider:  testCreds,
 		agentConfig:          testConfig,
-		taskEngine:           taskEngine,
-		ecsClient:            ecsClient,
-		dataClient:           data.NewNoopClient(),
-		taskHandler:          taskHandler,
-		backoff:              retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
 		ctx:                  ctx,
 		cancel:               cancel,
-		clientFactory:        mockClientFactory,
-		_heartbeatTimeout:    20 * time.Millisecond,
-		_heartbeatJitter:     10 * time.Millisecond,
-		connectionTime:       30 * time.Millisecond,
-		connectionJitter:     10 * time.Millisecond,
+		sessionLimiter:       limiter,
 	}
+	done := make(chan error, 1)
 	go func() {
-		acsSession.Start()
+		done <- acsSession.Start()
+	}()
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiting session did not stop when cancelled")
+	}
+	assert.Equal(t, 1, limiter.sessions["myArn"], "expected only the first session to be active")
+}
+
+// TestHandlerStopsAfterSessionMaxLifetime tests that the session ends once its max lifetime
+// has elapsed, even while it is connected to ACS
+func TestHandlerStopsAfterSessionMaxLifetime(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
+	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
+
+	ecsClient := mock_api.NewMockECSClient(ctrl)
+	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil).AnyTimes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
+
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	mockClientFactory := mock_wsclient.NewMockClientFactory(ctrl)
+	mockClientFactory.EXPECT().
+		New(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(mockWsClient).Times(1)
+	mockWsClient.EXPECT().SetAnyRequestHandler(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().AddRequestHandler(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().WriteCloseMessage().Return(nil).AnyTimes()
+	mockWsClient.EXPECT().Close().Return(nil).AnyTimes()
+	mockWsClient.EXPECT().Connect().Return(nil)
+	// The connection is served until the session's context is done
+	mockWsClient.EXPECT().Serve(gomock.Any()).DoAndReturn(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	cfg := *testConfig
+	cfg.ACSSessionMaxLifetime = 50 * time.Millisecond
+	acsSession := session{
+		containerInstanceARN:        "myArn",
+		credentialsProvider:         testCreds,
+		agentConfig:                 &cfg,
+		taskEngine:                  taskEngine,
+		ecsClient:                   ecsClient,
+		dataClient:                  data.NewNoopClient(),
+		taskHandler:                 taskHandler,
+		backoff:                     retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		discoverPollEndpointBackoff: retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		ctx:                         ctx,
+		cancel:                      cancel,
+		clientFactory:               mockClientFactory,
+		_heartbeatTimeout:           time.Hour,
+		_heartbeatJitter:            time.Millisecond,
+		_connectionTime:             time.Hour,
+		connectionJitter:            time.Millisecond,
+	}
+
+	sessionDone := make(chan error, 1)
+	go func() {
+		sessionDone <- acsSession.Start()
 	}()
 
-	// Wait for context to be cancelled
 	select {
-	case <-ctx.Done():
+	case err := <-sessionDone:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("session did not end after its max lifetime")
 	}
+	// The agent's context is not cancelled by the session ending
+	assert.NoError(t, ctx.Err())
 }
 
 // TestIsInactiveInstanceErrorReturnsTrueForInactiveInstance tests if the 'InactiveInstance'
@@ -303,14 +601,140 @@ func TestWaitForDurationReturnsFalseWhenContextCancelled(t *testing.T) {
 		"WaitForDuration should return false when interrupted")
 }
 
-func TestShouldReconnectWithoutBackoffReturnsTrueForEOF(t *testing.T) {
-	assert.True(t, shouldReconnectWithoutBackoff(io.EOF),
-		"Reconnect without backoff should return true when connection is closed")
+func TestReconnectPolicyForCloseCodes(t *testing.T) {
+	testCases := []struct {
+		name           string
+		acsError       error
+		expectedPolicy reconnectPolicy
+	}{
+		{
+			name:           "connection closed for a valid reason",
+			acsError:       io.EOF,
+			expectedPolicy: reconnectImmediately,
+		},
+		{
+			name:           "no error",
+			acsError:       nil,
+			expectedPolicy: reconnectImmediately,
+		},
+		{
+			name:           "going away",
+			acsError:       &websocket.CloseError{Code: websocket.CloseGoingAway},
+			expectedPolicy: reconnectImmediately,
+		},
+		{
+			name:           "service restart",
+			acsError:       &websocket.CloseError{Code: websocket.CloseServiceRestart},
+			expectedPolicy: reconnectWithBackoff,
+		},
+		{
+			name:           "try again later",
+			acsError:       &websocket.CloseError{Code: websocket.CloseTryAgainLater, Text: ":("},
+			expectedPolicy: reconnectWithLongBackoff,
+		},
+		{
+			name:           "policy violation",
+			acsError:       &websocket.CloseError{Code: websocket.ClosePolicyViolation},
+			expectedPolicy: reconnectAfterPolicyViolation,
+		},
+		{
+			name:           "unmapped close code",
+			acsError:       &websocket.CloseError{Code: websocket.CloseUnsupportedData},
+			expectedPolicy: reconnectWithBackoff,
+		},
+		{
+			name:           "non websocket error",
+			acsError:       fmt.Errorf("not EOF"),
+			expectedPolicy: reconnectWithBackoff,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedPolicy, reconnectPolicyFor(tc.acsError))
+		})
+	}
 }
 
-func TestShouldReconnectWithoutBackoffReturnsFalseForNonEOF(t *testing.T) {
-	assert.False(t, shouldReconnectWithoutBackoff(fmt.Errorf("not EOF")),
-		"Reconnect without backoff should return false for non io.EOF error")
+func TestMinReconnectDelay(t *testing.T) {
+	assert.Zero(t, minReconnectDelay(reconnectImmediately))
+	assert.Zero(t, minReconnectDelay(reconnectWithBackoff))
+	assert.Equal(t, tryAgainLaterReconnectDelay, minReconnectDelay(reconnectWithLongBackoff))
+	assert.Equal(t, policyViolationReconnectDelay, minReconnectDelay(reconnectAfterPolicyViolation))
+}
+
+// TestHandlerKeepsReconnectingOnPolicyViolation tests that the session handler waits for the
+// policy violation reconnect delay, rather than ending the session, when the connection is
+// closed with the policy violation close code
+func TestHandlerKeepsReconnectingOnPolicyViolation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
+	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
+
+	ecsClient := mock_api.NewMockECSClient(ctrl)
+	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil).AnyTimes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
+
+	deregisterInstanceEventStream := eventstream.NewEventStream("DeregisterContainerInstance", ctx)
+	deregisterInstanceEventStream.StartListening()
+
+	backoffComputed := make(chan struct{})
+	mockBackoff := mock_retry.NewMockBackoff(ctrl)
+	mockBackoff.EXPECT().Duration().Do(func() {
+		close(backoffComputed)
+	}).Return(connectionBackoffMin)
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	mockClientFactory := mock_wsclient.NewMockClientFactory(ctrl)
+	mockClientFactory.EXPECT().
+		New(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(mockWsClient).AnyTimes()
+	mockWsClient.EXPECT().SetAnyRequestHandler(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().AddRequestHandler(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().WriteCloseMessage().Return(nil).AnyTimes()
+	mockWsClient.EXPECT().Close().Return(nil).AnyTimes()
+	policyViolation := &websocket.CloseError{Code: websocket.ClosePolicyViolation}
+	mockWsClient.EXPECT().Connect().Return(policyViolation).Times(1)
+
+	acsSession := session{
+		containerInstanceARN:            "myArn",
+		credentialsProvider:             testCreds,
+		agentConfig:                     testConfig,
+		taskEngine:                      taskEngine,
+		ecsClient:                       ecsClient,
+		deregisterInstanceEventStream:   deregisterInstanceEventStream,
+		dataClient:                      data.NewNoopClient(),
+		taskHandler:                     taskHandler,
+		backoff:                         mockBackoff,
+		discoverPollEndpointBackoff:     retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		ctx:                             ctx,
+		cancel:                          cancel,
+		clientFactory:                   mockClientFactory,
+		latestSeqNumTaskManifest:        aws.Int64(10),
+		_heartbeatTimeout:               20 * time.Millisecond,
+		_heartbeatJitter:                10 * time.Millisecond,
+		_connectionTime:                 30 * time.Millisecond,
+		connectionJitter:                10 * time.Millisecond,
+		_inactiveInstanceReconnectDelay: inactiveInstanceReconnectDelay,
+	}
+	sessionEnded := make(chan error)
+	go func() {
+		sessionEnded <- acsSession.Start()
+	}()
+
+	// The session doesn't end and doesn't reconnect before the policy violation reconnect delay
+	<-backoffComputed
+	select {
+	case err := <-sessionEnded:
+		t.Fatalf("session ended after a policy violation: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	cancel()
+	assert.NoError(t, <-sessionEnded)
 }
 
 // TestHandlerReconnectsWithoutBackoffOnEOFError tests if the session handler reconnects
@@ -323,7 +747,7 @@ func TestHandlerReconnectsWithoutBackoffOnEOFError(t *testing.T) {
 	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
 
 	ecsClient := mock_api.NewMockECSClient(ctrl)
-	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(acsURL, nil).AnyTimes()
+	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil).AnyTimes()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
@@ -363,13 +787,14 @@ func TestHandlerReconnectsWithoutBackoffOnEOFError(t *testing.T) {
 		dataClient:                      data.NewNoopClient(),
 		taskHandler:                     taskHandler,
 		backoff:                         mockBackoff,
+		discoverPollEndpointBackoff:     retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
 		ctx:                             ctx,
 		cancel:                          cancel,
 		clientFactory:                   mockClientFactory,
 		latestSeqNumTaskManifest:        aws.Int64(10),
 		_heartbeatTimeout:               20 * time.Millisecond,
 		_heartbeatJitter:                10 * time.Millisecond,
-		connectionTime:                  30 * time.Millisecond,
+		_connectionTime:                 30 * time.Millisecond,
 		connectionJitter:                10 * time.Millisecond,
 		_inactiveInstanceReconnectDelay: inactiveInstanceReconnectDelay,
 	}
@@ -393,7 +818,7 @@ func TestHandlerReconnectsWithBackoffOnNonEOFError(t *testing.T) {
 	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
 
 	ecsClient := mock_api.NewMockECSClient(ctrl)
-	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(acsURL, nil).AnyTimes()
+	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil).AnyTimes()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
@@ -433,12 +858,13 @@ func TestHandlerReconnectsWithBackoffOnNonEOFError(t *testing.T) {
 		dataClient:                    data.NewNoopClient(),
 		taskHandler:                   taskHandler,
 		backoff:                       mockBackoff,
+		discoverPollEndpointBackoff:   retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
 		ctx:                           ctx,
 		cancel:                        cancel,
 		clientFactory:                 mockClientFactory,
 		_heartbeatTimeout:             20 * time.Millisecond,
 		_heartbeatJitter:              10 * time.Millisecond,
-		connectionTime:                30 * time.Millisecond,
+		_connectionTime:               30 * time.Millisecond,
 		connectionJitter:              10 * time.Millisecond,
 	}
 	go func() {
@@ -461,7 +887,7 @@ func TestHandlerGeneratesDeregisteredInstanceEvent(t *testing.T) {
 	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
 
 	ecsClient := mock_api.NewMockECSClient(ctrl)
-	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(acsURL, nil).AnyTimes()
+	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil).AnyTimes()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
@@ -498,12 +924,13 @@ func TestHandlerGeneratesDeregisteredInstanceEvent(t *testing.T) {
 		dataClient:                      data.NewNoopClient(),
 		taskHandler:                     taskHandler,
 		backoff:                         retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		discoverPollEndpointBackoff:     retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
 		ctx:                             ctx,
 		cancel:                          cancel,
 		clientFactory:                   mockClientFactory,
 		_heartbeatTimeout:               20 * time.Millisecond,
 		_heartbeatJitter:                10 * time.Millisecond,
-		connectionTime:                  30 * time.Millisecond,
+		_connectionTime:                 30 * time.Millisecond,
 		connectionJitter:                10 * time.Millisecond,
 		_inactiveInstanceReconnectDelay: inactiveInstanceReconnectDelay,
 	}
@@ -527,7 +954,7 @@ func TestHandlerReconnectDelayForInactiveInstanceError(t *testing.T) {
 	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
 
 	ecsClient := mock_api.NewMockECSClient(ctrl)
-	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(acsURL, nil).AnyTimes()
+	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil).AnyTimes()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
@@ -574,12 +1001,13 @@ func TestHandlerReconnectDelayForInactiveInstanceError(t *testing.T) {
 		dataClient:                      data.NewNoopClient(),
 		taskHandler:                     taskHandler,
 		backoff:                         retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		discoverPollEndpointBackoff:     retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
 		ctx:                             ctx,
 		cancel:                          cancel,
 		clientFactory:                   mockClientFactory,
 		_heartbeatTimeout:               20 * time.Millisecond,
 		_heartbeatJitter:                10 * time.Millisecond,
-		connectionTime:                  30 * time.Millisecond,
+		_connectionTime:                 30 * time.Millisecond,
 		connectionJitter:                10 * time.Millisecond,
 		_inactiveInstanceReconnectDelay: inactiveInstanceReconnectDelay,
 	}
@@ -602,7 +1030,7 @@ func TestHandlerReconnectsOnServeErrors(t *testing.T) {
 	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
 
 	ecsClient := mock_api.NewMockECSClient(ctrl)
-	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(acsURL, nil).AnyTimes()
+	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil).AnyTimes()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
@@ -629,21 +1057,22 @@ func TestHandlerReconnectsOnServeErrors(t *testing.T) {
 	)
 
 	acsSession := session{
-		containerInstanceARN: "myArn",
-		credentialsProvider:  testCreds,
-		agentConfig:          testConfig,
-		taskEngine:           taskEngine,
-		ecsClient:            ecsClient,
-		dataClient:           data.NewNoopClient(),
-		taskHandler:          taskHandler,
-		backoff:              retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
-		ctx:                  ctx,
-		cancel:               cancel,
-		clientFactory:        mockClientFactory,
-		_heartbeatTimeout:    20 * time.Millisecond,
-		_heartbeatJitter:     10 * time.Millisecond,
-		connectionTime:       30 * time.Millisecond,
-		connectionJitter:     10 * time.Millisecond,
+		containerInstanceARN:        "myArn",
+		credentialsProvider:         testCreds,
+		agentConfig:                 testConfig,
+		taskEngine:                  taskEngine,
+		ecsClient:                   ecsClient,
+		dataClient:                  data.NewNoopClient(),
+		taskHandler:                 taskHandler,
+		backoff:                     retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		discoverPollEndpointBackoff: retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		ctx:                         ctx,
+		cancel:                      cancel,
+		clientFactory:               mockClientFactory,
+		_heartbeatTimeout:           20 * time.Millisecond,
+		_heartbeatJitter:            10 * time.Millisecond,
+		_connectionTime:             30 * time.Millisecond,
+		connectionJitter:            10 * time.Millisecond,
 	}
 	go func() {
 		acsSession.Start()
@@ -664,7 +1093,7 @@ func TestHandlerStopsWhenContextIsCancelled(t *testing.T) {
 	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
 
 	ecsClient := mock_api.NewMockECSClient(ctrl)
-	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(acsURL, nil).AnyTimes()
+	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil).AnyTimes()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
@@ -686,21 +1115,22 @@ func TestHandlerStopsWhenContextIsCancelled(t *testing.T) {
 		}).Return(errors.New("InactiveInstanceException")),
 	)
 	acsSession := session{
-		containerInstanceARN: "myArn",
-		credentialsProvider:  testCreds,
-		agentConfig:          testConfig,
-		taskEngine:           taskEngine,
-		ecsClient:            ecsClient,
-		dataClient:           data.NewNoopClient(),
-		taskHandler:          taskHandler,
-		backoff:              retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
-		ctx:                  ctx,
-		cancel:               cancel,
-		clientFactory:        mockClientFactory,
-		_heartbeatTimeout:    20 * time.Millisecond,
-		_heartbeatJitter:     10 * time.Millisecond,
-		connectionTime:       30 * time.Millisecond,
-		connectionJitter:     10 * time.Millisecond,
+		containerInstanceARN:        "myArn",
+		credentialsProvider:         testCreds,
+		agentConfig:                 testConfig,
+		taskEngine:                  taskEngine,
+		ecsClient:                   ecsClient,
+		dataClient:                  data.NewNoopClient(),
+		taskHandler:                 taskHandler,
+		backoff:                     retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		discoverPollEndpointBackoff: retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		ctx:                         ctx,
+		cancel:                      cancel,
+		clientFactory:               mockClientFactory,
+		_heartbeatTimeout:           20 * time.Millisecond,
+		_heartbeatJitter:            10 * time.Millisecond,
+		_connectionTime:             30 * time.Millisecond,
+		connectionJitter:            10 * time.Millisecond,
 	}
 
 	// The session error channel would have an event when the Start() method returns
@@ -722,7 +1152,7 @@ func TestHandlerStopsWhenContextIsError(t *testing.T) {
 	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
 
 	ecsClient := mock_api.NewMockECSClient(ctrl)
-	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(acsURL, nil).AnyTimes()
+	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil).AnyTimes()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Millisecond)
 	taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
@@ -742,19 +1172,20 @@ func TestHandlerStopsWhenContextIsError(t *testing.T) {
 	}).Return(io.EOF).AnyTimes()
 
 	acsSession := session{
-		containerInstanceARN: "myArn",
-		credentialsProvider:  testCreds,
-		agentConfig:          testConfig,
-		taskEngine:           taskEngine,
-		ecsClient:            ecsClient,
-		dataClient:           data.NewNoopClient(),
-		taskHandler:          taskHandler,
-		backoff:              retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
-		ctx:                  ctx,
-		cancel:               cancel,
-		clientFactory:        mockClientFactory,
-		_heartbeatTimeout:    20 * time.Millisecond,
-		_heartbeatJitter:     10 * time.Millisecond,
+		containerInstanceARN:        "myArn",
+		credentialsProvider:         testCreds,
+		agentConfig:                 testConfig,
+		taskEngine:                  taskEngine,
+		ecsClient:                   ecsClient,
+		dataClient:                  data.NewNoopClient(),
+		taskHandler:                 taskHandler,
+		backoff:                     retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		discoverPollEndpointBackoff: retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		ctx:                         ctx,
+		cancel:                      cancel,
+		clientFactory:               mockClientFactory,
+		_heartbeatTimeout:           20 * time.Millisecond,
+		_heartbeatJitter:            10 * time.Millisecond,
 	}
 
 	// The session error channel would have an event when the Start() method returns
@@ -776,7 +1207,7 @@ func TestHandlerStopsWhenContextIsErrorReconnectDelay(t *testing.T) {
 	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
 
 	ecsClient := mock_api.NewMockECSClient(ctrl)
-	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(acsURL, nil).AnyTimes()
+	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil).AnyTimes()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Millisecond)
 	taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
@@ -802,6 +1233,7 @@ func TestHandlerStopsWhenContextIsErrorReconnectDelay(t *testing.T) {
 		dataClient:                      data.NewNoopClient(),
 		taskHandler:                     taskHandler,
 		backoff:                         retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		discoverPollEndpointBackoff:     retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
 		ctx:                             ctx,
 		cancel:                          cancel,
 		clientFactory:                   mockClientFactory,
@@ -849,26 +1281,27 @@ func TestHandlerReconnectsOnDiscoverPollEndpointError(t *testing.T) {
 
 	gomock.InOrder(
 		// DiscoverPollEndpoint returns an error on its first invocation
-		ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return("", fmt.Errorf("oops")).Times(1),
+		ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return("", fmt.Errorf("oops")).Times(1),
 		// Second invocation returns a success
-		ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(acsURL, nil).Times(1),
+		ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil).Times(1),
 	)
 	acsSession := session{
-		containerInstanceARN: "myArn",
-		credentialsProvider:  testCreds,
-		agentConfig:          testConfig,
-		taskEngine:           taskEngine,
-		ecsClient:            ecsClient,
-		dataClient:           data.NewNoopClient(),
-		taskHandler:          taskHandler,
-		backoff:              retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
-		ctx:                  ctx,
-		cancel:               cancel,
-		clientFactory:        mockClientFactory,
-		_heartbeatTimeout:    20 * time.Millisecond,
-		_heartbeatJitter:     10 * time.Millisecond,
-		connectionTime:       30 * time.Millisecond,
-		connectionJitter:     10 * time.Millisecond,
+		containerInstanceARN:        "myArn",
+		credentialsProvider:         testCreds,
+		agentConfig:                 testConfig,
+		taskEngine:                  taskEngine,
+		ecsClient:                   ecsClient,
+		dataClient:                  data.NewNoopClient(),
+		taskHandler:                 taskHandler,
+		backoff:                     retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		discoverPollEndpointBackoff: retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		ctx:                         ctx,
+		cancel:                      cancel,
+		clientFactory:               mockClientFactory,
+		_heartbeatTimeout:           20 * time.Millisecond,
+		_heartbeatJitter:            10 * time.Millisecond,
+		_connectionTime:             30 * time.Millisecond,
+		connectionJitter:            10 * time.Millisecond,
 	}
 	go func() {
 		acsSession.Start()
@@ -894,6 +1327,232 @@ func TestHandlerReconnectsOnDiscoverPollEndpointError(t *testing.T) {
 	}
 }
 
+// TestHandlerUsesDiscoverPollEndpointBackoffOnDiscoveryErrors tests that DiscoverPollEndpoint
+// errors are retried with the discovery backoff instead of the connection backoff, and that the
+// discovery backoff is reset once the endpoint is discovered
+func TestHandlerUsesDiscoverPollEndpointBackoffOnDiscoveryErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
+	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
+
+	ecsClient := mock_api.NewMockECSClient(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
+
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	mockClientFactory := mock_wsclient.NewMockClientFactory(ctrl)
+	mockClientFactory.EXPECT().
+		New(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(mockWsClient).AnyTimes()
+	mockWsClient.EXPECT().SetAnyRequestHandler(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().AddRequestHandler(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().Serve(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().WriteCloseMessage().Return(nil).AnyTimes()
+	mockWsClient.EXPECT().Close().Return(nil).AnyTimes()
+	mockWsClient.EXPECT().Connect().Do(func() {
+		cancel()
+	}).Return(nil).MinTimes(1)
+
+	// The connection backoff is never used to delay discovery retries
+	connectionBackoff := mock_retry.NewMockBackoff(ctrl)
+	connectionBackoff.EXPECT().Reset().AnyTimes()
+	discoverPollEndpointBackoff := mock_retry.NewMockBackoff(ctrl)
+	gomock.InOrder(
+		ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return("", fmt.Errorf("ThrottlingException")),
+		discoverPollEndpointBackoff.EXPECT().Duration().Return(time.Millisecond),
+		ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return("", fmt.Errorf("ThrottlingException")),
+		discoverPollEndpointBackoff.EXPECT().Duration().Return(time.Millisecond),
+		ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil),
+		discoverPollEndpointBackoff.EXPECT().Reset(),
+	)
+
+	acsSession := session{
+		containerInstanceARN:        "myArn",
+		credentialsProvider:         testCreds,
+		agentConfig:                 testConfig,
+		taskEngine:                  taskEngine,
+		ecsClient:                   ecsClient,
+		dataClient:                  data.NewNoopClient(),
+		taskHandler:                 taskHandler,
+		backoff:                     connectionBackoff,
+		discoverPollEndpointBackoff: discoverPollEndpointBackoff,
+		ctx:                         ctx,
+		cancel:                      cancel,
+		clientFactory:               mockClientFactory,
+		_heartbeatTimeout:           20 * time.Millisecond,
+		_heartbeatJitter:            10 * time.Millisecond,
+		_connectionTime:             30 * time.Millisecond,
+		connectionJitter:            10 * time.Millisecond,
+	}
+	assert.NoError(t, acsSession.Start())
+}
+
+// TestHandlerRetriesDiscoverPollEndpointDNSErrorsQuickly tests that the first DNS resolution
+// errors from DiscoverPollEndpoint are retried after a fixed interval, and later ones with backoff
+func TestHandlerRetriesDiscoverPollEndpointDNSErrorsQuickly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
+	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
+
+	ecsClient := mock_api.NewMockECSClient(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
+
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	mockClientFactory := mock_wsclient.NewMockClientFactory(ctrl)
+	mockClientFactory.EXPECT().
+		New(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(mockWsClient).AnyTimes()
+	mockWsClient.EXPECT().SetAnyRequestHandler(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().AddRequestHandler(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().Serve(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().WriteCloseMessage().Return(nil).AnyTimes()
+	mockWsClient.EXPECT().Close().Return(nil).AnyTimes()
+	mockWsClient.EXPECT().Connect().Do(func() {
+		cancel()
+	}).Return(nil).MinTimes(1)
+
+	dnsErr := awserr.New("RequestError", "send request failed", &url.Error{
+		Op:  "Post",
+		URL: "https://ecs.us-west-2.amazonaws.com",
+		Err: &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{
+			Err:  "no such host",
+			Name: "ecs.us-west-2.amazonaws.com",
+		}},
+	})
+	// The backoff is only used once the DNS retry attempts are exhausted
+	connectionBackoff := mock_retry.NewMockBackoff(ctrl)
+	connectionBackoff.EXPECT().Reset().AnyTimes()
+	discoverPollEndpointBackoff := mock_retry.NewMockBackoff(ctrl)
+	gomock.InOrder(
+		ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return("", dnsErr),
+		ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return("", dnsErr),
+		ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return("", dnsErr),
+		discoverPollEndpointBackoff.EXPECT().Duration().Return(time.Millisecond),
+		ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil),
+		discoverPollEndpointBackoff.EXPECT().Reset(),
+	)
+
+	cfg := *testConfig
+	cfg.DiscoverDNSRetryAttempts = 2
+	cfg.DiscoverDNSRetryInterval = time.Millisecond
+	acsSession := session{
+		containerInstanceARN:        "myArn",
+		credentialsProvider:         testCreds,
+		agentConfig:                 &cfg,
+		taskEngine:                  taskEngine,
+		ecsClient:                   ecsClient,
+		dataClient:                  data.NewNoopClient(),
+		taskHandler:                 taskHandler,
+		backoff:                     connectionBackoff,
+		discoverPollEndpointBackoff: discoverPollEndpointBackoff,
+		ctx:                         ctx,
+		cancel:                      cancel,
+		clientFactory:               mockClientFactory,
+		_heartbeatTimeout:           20 * time.Millisecond,
+		_heartbeatJitter:            10 * time.Millisecond,
+		_connectionTime:             30 * time.Millisecond,
+		connectionJitter:            10 * time.Millisecond,
+	}
+	assert.NoError(t, acsSession.Start())
+	assert.Zero(t, acsSession.consecutiveDiscoverDNSFailures)
+}
+
+func TestIsDNSError(t *testing.T) {
+	dnsErr := &net.DNSError{Err: "no such host", Name: "ecs.us-west-2.amazonaws.com"}
+	assert.True(t, isDNSError(dnsErr))
+	assert.True(t, isDNSError(awserr.New("RequestError", "send request failed",
+		&url.Error{Op: "Post", URL: "https://ecs.us-west-2.amazonaws.com", Err: dnsErr})))
+	assert.True(t, isDNSError(discoverPollEndpointError{awserr.New("RequestError", "send request failed", dnsErr)}))
+	assert.False(t, isDNSError(awserr.New("ThrottlingException", "Rate exceeded", nil)))
+	assert.False(t, isDNSError(errors.New("connection refused")))
+	assert.False(t, isDNSError(nil))
+}
+
+// TestHandlerRetriesDiscoverPollEndpointOnTimeout tests that a DiscoverPollEndpoint call that
+// doesn't return within the timeout is given up on and retried with backoff
+func TestHandlerRetriesDiscoverPollEndpointOnTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
+	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
+
+	ecsClient := mock_api.NewMockECSClient(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
+
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	mockClientFactory := mock_wsclient.NewMockClientFactory(ctrl)
+	mockClientFactory.EXPECT().
+		New(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(mockWsClient).AnyTimes()
+	mockWsClient.EXPECT().SetAnyRequestHandler(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().AddRequestHandler(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().Serve(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().WriteCloseMessage().Return(nil).AnyTimes()
+	mockWsClient.EXPECT().Close().Return(nil).AnyTimes()
+	mockWsClient.EXPECT().Connect().Do(func() {
+		cancel()
+	}).Return(nil).MinTimes(1)
+
+	// The first call blocks until it's cancelled, as if the control plane hung
+	discoverCancelled := make(chan struct{})
+	connectionBackoff := mock_retry.NewMockBackoff(ctrl)
+	connectionBackoff.EXPECT().Reset().AnyTimes()
+	discoverPollEndpointBackoff := mock_retry.NewMockBackoff(ctrl)
+	gomock.InOrder(
+		ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, _ string) (string, error) {
+				<-ctx.Done()
+				close(discoverCancelled)
+				return "", ctx.Err()
+			}),
+		discoverPollEndpointBackoff.EXPECT().Duration().Return(time.Millisecond),
+		ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil),
+		discoverPollEndpointBackoff.EXPECT().Reset(),
+	)
+
+	cfg := *testConfig
+	cfg.DiscoverPollEndpointTimeout = 10 * time.Millisecond
+	acsSession := session{
+		containerInstanceARN:        "myArn",
+		credentialsProvider:         testCreds,
+		agentConfig:                 &cfg,
+		taskEngine:                  taskEngine,
+		ecsClient:                   ecsClient,
+		dataClient:                  data.NewNoopClient(),
+		taskHandler:                 taskHandler,
+		backoff:                     connectionBackoff,
+		discoverPollEndpointBackoff: discoverPollEndpointBackoff,
+		ctx:                         ctx,
+		cancel:                      cancel,
+		clientFactory:               mockClientFactory,
+		_heartbeatTimeout:           20 * time.Millisecond,
+		_heartbeatJitter:            10 * time.Millisecond,
+		_connectionTime:             30 * time.Millisecond,
+		connectionJitter:            10 * time.Millisecond,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- acsSession.Start()
+	}()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("session was blocked by a hung DiscoverPollEndpoint call")
+	}
+	// The hung call is cancelled rather than abandoned
+	select {
+	case <-discoverCancelled:
+	default:
+		t.Error("hung DiscoverPollEndpoint call was not cancelled")
+	}
+}
+
 // TestConnectionIsClosedOnIdle tests if the connection to ACS is closed
 // when the channel is idle
 func TestConnectionIsClosedOnIdle(t *testing.T) {
@@ -927,19 +1586,20 @@ func TestConnectionIsClosedOnIdle(t *testing.T) {
 		connectionClosed <- true
 	}).Return(nil)
 	acsSession := session{
-		containerInstanceARN: "myArn",
-		credentialsProvider:  testCreds,
-		agentConfig:          testConfig,
-		taskEngine:           taskEngine,
-		ecsClient:            ecsClient,
-		dataClient:           data.NewNoopClient(),
-		taskHandler:          taskHandler,
-		ctx:                  context.Background(),
-		backoff:              retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
-		_heartbeatTimeout:    20 * time.Millisecond,
-		_heartbeatJitter:     10 * time.Millisecond,
-		connectionTime:       30 * time.Millisecond,
-		connectionJitter:     10 * time.Millisecond,
+		containerInstanceARN:        "myArn",
+		credentialsProvider:         testCreds,
+		agentConfig:                 testConfig,
+		taskEngine:                  taskEngine,
+		ecsClient:                   ecsClient,
+		dataClient:                  data.NewNoopClient(),
+		taskHandler:                 taskHandler,
+		ctx:                         context.Background(),
+		backoff:                     retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		discoverPollEndpointBackoff: retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		_heartbeatTimeout:           20 * time.Millisecond,
+		_heartbeatJitter:            10 * time.Millisecond,
+		_connectionTime:             30 * time.Millisecond,
+		connectionJitter:            10 * time.Millisecond,
 	}
 	go acsSession.startACSSession(mockWsClient)
 
@@ -948,6 +1608,222 @@ func TestConnectionIsClosedOnIdle(t *testing.T) {
 	<-connectionClosed
 }
 
+// handshakeClientServer is an ACS client whose handshake response carries the given headers
+type handshakeClientServer struct {
+	*mock_wsclient.MockClientServer
+	header http.Header
+}
+
+func (cs *handshakeClientServer) HandshakeHeader() http.Header {
+	return cs.header
+}
+
+// TestSessionStatusProtocolVersion tests that the protocol version negotiated with ACS
+// is captured from the handshake response when connecting.
+func TestSessionStatusProtocolVersion(t *testing.T) {
+	for _, tc := range []struct {
+		name                    string
+		header                  http.Header
+		expectedProtocolVersion int
+	}{
+		{
+			name:                    "negotiated version",
+			header:                  http.Header{acsProtocolVersionHeader: []string{"1"}},
+			expectedProtocolVersion: 1,
+		},
+		{
+			name:                    "no negotiated version",
+			header:                  http.Header{},
+			expectedProtocolVersion: acsProtocolVersion,
+		},
+		{
+			name:                    "invalid negotiated version",
+			header:                  http.Header{acsProtocolVersionHeader: []string{"v1"}},
+			expectedProtocolVersion: acsProtocolVersion,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			taskEngine := mock_engine.NewMockTaskEngine(ctrl)
+			taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
+			ecsClient := mock_api.NewMockECSClient(ctrl)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
+
+			acsSession := session{
+				containerInstanceARN:        "myArn",
+				credentialsProvider:         testCreds,
+				agentConfig:                 testConfig,
+				taskEngine:                  taskEngine,
+				ecsClient:                   ecsClient,
+				dataClient:                  data.NewNoopClient(),
+				taskHandler:                 taskHandler,
+				ctx:                         ctx,
+				backoff:                     retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+				discoverPollEndpointBackoff: retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+				_heartbeatTimeout:           20 * time.Millisecond,
+				_heartbeatJitter:            10 * time.Millisecond,
+				_connectionTime:             30 * time.Millisecond,
+				connectionJitter:            10 * time.Millisecond,
+			}
+			assert.Zero(t, acsSession.Status().ProtocolVersion)
+
+			mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+			mockWsClient.EXPECT().SetAnyRequestHandler(gomock.Any()).AnyTimes()
+			mockWsClient.EXPECT().AddRequestHandler(gomock.Any()).AnyTimes()
+			mockWsClient.EXPECT().WriteCloseMessage().Return(nil).AnyTimes()
+			mockWsClient.EXPECT().Close().Return(nil).AnyTimes()
+			mockWsClient.EXPECT().Connect().Return(nil)
+			var status SessionStatus
+			mockWsClient.EXPECT().Serve(gomock.Any()).Do(func(interface{}) {
+				status = acsSession.Status()
+			}).Return(io.EOF)
+
+			err := acsSession.startACSSession(&handshakeClientServer{
+				MockClientServer: mockWsClient,
+				header:           tc.header,
+			})
+			assert.Equal(t, io.EOF, err)
+			assert.Equal(t, tc.expectedProtocolVersion, status.ProtocolVersion)
+			assert.Equal(t, tc.expectedProtocolVersion, acsSession.Status().ProtocolVersion)
+		})
+	}
+}
+
+// TestConnectFailureLogLevel tests that the severity of connect failures escalates
+// with the number of consecutive failures
+func TestConnectFailureLogLevel(t *testing.T) {
+	for _, tc := range []struct {
+		name                string
+		consecutiveFailures int
+		warnThreshold       int
+		errorThreshold      int
+		expectedLevel       seelog.LogLevel
+	}{
+		{"below warn threshold", 2, 3, 5, seelog.InfoLvl},
+		{"at warn threshold", 3, 3, 5, seelog.WarnLvl},
+		{"between thresholds", 4, 3, 5, seelog.WarnLvl},
+		{"at error threshold", 5, 3, 5, seelog.ErrorLvl},
+		{"above error threshold", 9, 3, 5, seelog.ErrorLvl},
+		{"equal thresholds", 3, 3, 3, seelog.ErrorLvl},
+		{"thresholds not set", 9, 0, 0, seelog.InfoLvl},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedLevel,
+				connectFailureLogLevel(tc.consecutiveFailures, tc.warnThreshold, tc.errorThreshold))
+		})
+	}
+}
+
+// TestConsecutiveConnectFailures tests that consecutive connect failures are counted
+// to escalate their severity, and that the count is reset on a successful connect
+func TestConsecutiveConnectFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
+	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
+	ecsClient := mock_api.NewMockECSClient(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
+
+	cfg := *testConfig
+	cfg.ACSConnectFailureWarnThreshold = 2
+	cfg.ACSConnectFailureErrorThreshold = 3
+	acsSession := session{
+		containerInstanceARN:        "myArn",
+		credentialsProvider:         testCreds,
+		agentConfig:                 &cfg,
+		taskEngine:                  taskEngine,
+		ecsClient:                   ecsClient,
+		dataClient:                  data.NewNoopClient(),
+		taskHandler:                 taskHandler,
+		ctx:                         ctx,
+		backoff:                     retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		discoverPollEndpointBackoff: retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		_heartbeatTimeout:           20 * time.Millisecond,
+		_heartbeatJitter:            10 * time.Millisecond,
+		_connectionTime:             30 * time.Millisecond,
+		connectionJitter:            10 * time.Millisecond,
+	}
+
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	mockWsClient.EXPECT().SetAnyRequestHandler(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().AddRequestHandler(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().WriteCloseMessage().Return(nil).AnyTimes()
+	mockWsClient.EXPECT().Close().Return(nil).AnyTimes()
+	connectErr := errors.New("connect error")
+	mockWsClient.EXPECT().Connect().Return(connectErr).Times(3)
+
+	for _, expectedLevel := range []seelog.LogLevel{seelog.InfoLvl, seelog.WarnLvl, seelog.ErrorLvl} {
+		err := acsSession.startACSSession(mockWsClient)
+		assert.Equal(t, connectErr, err)
+		assert.Equal(t, expectedLevel, connectFailureLogLevel(acsSession.consecutiveConnectFailures,
+			cfg.ACSConnectFailureWarnThreshold, cfg.ACSConnectFailureErrorThreshold))
+	}
+	assert.Equal(t, 3, acsSession.consecutiveConnectFailures)
+
+	mockWsClient.EXPECT().Connect().Return(nil)
+	mockWsClient.EXPECT().Serve(gomock.Any()).Return(io.EOF)
+	err := acsSession.startACSSession(mockWsClient)
+	assert.Equal(t, io.EOF, err)
+	assert.Zero(t, acsSession.consecutiveConnectFailures)
+}
+
+// TestDiscoverPollEndpointAuthFailures tests that persistent authentication errors from
+// DiscoverPollEndpoint are reported as unhealthy after the configured number of attempts,
+// and that the agent is reported healthy again once the endpoint is discovered.
+func TestDiscoverPollEndpointAuthFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ecsClient := mock_api.NewMockECSClient(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := *testConfig
+	cfg.DiscoverAuthFailureThreshold = 3
+	testDoctor, _ := doctor.NewDoctor([]doctor.Healthcheck{}, "test-cluster", "this:is:an:instance:arn")
+	acsSession := session{
+		containerInstanceARN:        "myArn",
+		credentialsProvider:         testCreds,
+		agentConfig:                 &cfg,
+		ecsClient:                   ecsClient,
+		ctx:                         ctx,
+		discoverPollEndpointBackoff: retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		doctor:                      testDoctor,
+	}
+
+	authErr := awserr.New("UnrecognizedClientException", "The security token included in the request is invalid", nil)
+	// A throttling error neither counts as, nor resets, the authentication errors.
+	throttlingErr := awserr.New("ThrottlingException", "Rate exceeded", nil)
+	gomock.InOrder(
+		ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return("", authErr).Times(2),
+		ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return("", throttlingErr),
+	)
+	for i := 0; i < 3; i++ {
+		err := acsSession.startSessionOnce()
+		assert.True(t, isDiscoverPollEndpointError(err))
+	}
+	assert.Equal(t, 2, acsSession.consecutiveDiscoverAuthFailures)
+	assert.Empty(t, *testDoctor.GetHealthchecks(), "agent should not be reported unhealthy below the threshold")
+	assert.True(t, testDoctor.RunHealthchecks())
+
+	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return("", authErr)
+	err := acsSession.startSessionOnce()
+	assert.True(t, isDiscoverPollEndpointError(err))
+	require.Len(t, *testDoctor.GetHealthchecks(), 1)
+	assert.False(t, testDoctor.RunHealthchecks(), "agent should be reported unhealthy at the threshold")
+	assert.Equal(t, doctor.HealthcheckStatusImpaired, (*testDoctor.GetHealthchecks())[0].GetHealthcheckStatus())
+
+	// Once the endpoint is discovered, the agent is reported healthy again.
+	acsSession.recordDiscoverPollEndpointResult(nil)
+	assert.Zero(t, acsSession.consecutiveDiscoverAuthFailures)
+	assert.True(t, testDoctor.RunHealthchecks())
+	assert.Len(t, *testDoctor.GetHealthchecks(), 1)
+}
+
 // TestConnectionIsClosedAfterTimeIsUp tests if the connection to ACS is closed
 // when the session's connection time is expired.
 func TestConnectionIsClosedAfterTimeIsUp(t *testing.T) {
@@ -976,19 +1852,20 @@ func TestConnectionIsClosedAfterTimeIsUp(t *testing.T) {
 	// set connectionTime to a value lower than the heartbeatTimeout to avoid
 	// closing the connection due to the heartbeatTimer's callback func
 	acsSession := session{
-		containerInstanceARN: "myArn",
-		credentialsProvider:  testCreds,
-		agentConfig:          testConfig,
-		taskEngine:           taskEngine,
-		ecsClient:            ecsClient,
-		dataClient:           data.NewNoopClient(),
-		taskHandler:          taskHandler,
-		ctx:                  context.Background(),
-		backoff:              retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
-		_heartbeatTimeout:    50 * time.Millisecond,
-		_heartbeatJitter:     10 * time.Millisecond,
-		connectionTime:       20 * time.Millisecond,
-		connectionJitter:     10 * time.Millisecond,
+		containerInstanceARN:        "myArn",
+		credentialsProvider:         testCreds,
+		agentConfig:                 testConfig,
+		taskEngine:                  taskEngine,
+		ecsClient:                   ecsClient,
+		dataClient:                  data.NewNoopClient(),
+		taskHandler:                 taskHandler,
+		ctx:                         context.Background(),
+		backoff:                     retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		discoverPollEndpointBackoff: retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+		_heartbeatTimeout:           50 * time.Millisecond,
+		_heartbeatJitter:            10 * time.Millisecond,
+		_connectionTime:             20 * time.Millisecond,
+		connectionJitter:            10 * time.Millisecond,
 	}
 
 	go func() {
@@ -1034,7 +1911,7 @@ func TestHandlerDoesntLeakGoroutines(t *testing.T) {
 	}()
 
 	timesConnected := 0
-	ecsClient.EXPECT().DiscoverPollEndpoint("myArn").Return(server.URL, nil).AnyTimes().Do(func(_ interface{}) {
+	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), "myArn").Return(server.URL, nil).AnyTimes().Do(func(_, _ interface{}) {
 		timesConnected++
 	})
 	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
@@ -1048,21 +1925,22 @@ func TestHandlerDoesntLeakGoroutines(t *testing.T) {
 	go func() {
 
 		acsSession := session{
-			containerInstanceARN:     "myArn",
-			credentialsProvider:      testCreds,
-			agentConfig:              testConfig,
-			taskEngine:               taskEngine,
-			dockerClient:             dockerClient,
-			ecsClient:                ecsClient,
-			dataClient:               data.NewNoopClient(),
-			taskHandler:              taskHandler,
-			ctx:                      ctx,
-			clientFactory:            acsclient.NewACSClientFactory(),
-			_heartbeatTimeout:        1 * time.Second,
-			backoff:                  retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
-			credentialsManager:       rolecredentials.NewManager(),
-			latestSeqNumTaskManifest: aws.Int64(12),
-			doctor:                   emptyDoctor,
+			containerInstanceARN:        "myArn",
+			credentialsProvider:         testCreds,
+			agentConfig:                 testConfig,
+			taskEngine:                  taskEngine,
+			dockerClient:                dockerClient,
+			ecsClient:                   ecsClient,
+			dataClient:                  data.NewNoopClient(),
+			taskHandler:                 taskHandler,
+			ctx:                         ctx,
+			clientFactory:               acsclient.NewACSClientFactory(),
+			_heartbeatTimeout:           1 * time.Second,
+			backoff:                     retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+			discoverPollEndpointBackoff: retry.NewExponentialBackoff(connectionBackoffMin, connectionBackoffMax, connectionBackoffJitter, connectionBackoffMultiplier),
+			credentialsManager:          rolecredentials.NewManager(),
+			latestSeqNumTaskManifest:    aws.Int64(12),
+			doctor:                      emptyDoctor,
 		}
 		acsSession.Start()
 		ended <- true
@@ -1123,7 +2001,7 @@ func TestStartSessionHandlesRefreshCredentialsMessages(t *testing.T) {
 	}()
 
 	// DiscoverPollEndpoint returns the URL for the server that we started
-	ecsClient.EXPECT().DiscoverPollEndpoint("myArn").Return(server.URL, nil).Times(1)
+	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), "myArn").Return(server.URL, nil).Times(1)
 	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
 
 	credentialsManager := mock_credentials.NewMockManager(ctrl)
@@ -1150,6 +2028,9 @@ func TestStartSessionHandlesRefreshCredentialsMessages(t *testing.T) {
 			&latestSeqNumberTaskManifest,
 			emptyDoctor,
 			acsclient.NewACSClientFactory(),
+			nil,
+			nil,
+			nil,
 		)
 		acsSession.Start()
 		// StartSession should never return unless the context is canceled
@@ -1247,10 +2128,13 @@ func TestHandlerCorrectlySetsSendCredentials(t *testing.T) {
 		taskHandler,
 		aws.Int64(10),
 		emptyDoctor,
-		mockClientFactory)
+		mockClientFactory,
+		nil,
+		nil,
+		nil)
 	acsSession.(*session)._heartbeatTimeout = 20 * time.Millisecond
 	acsSession.(*session)._heartbeatJitter = 10 * time.Millisecond
-	acsSession.(*session).connectionTime = 30 * time.Millisecond
+	acsSession.(*session)._connectionTime = 30 * time.Millisecond
 	acsSession.(*session).connectionJitter = 10 * time.Millisecond
 	gomock.InOrder(
 		// When the websocket client connects to ACS for the first
@@ -1287,7 +2171,7 @@ func TestHandlerReconnectCorrectlySetsAcsUrl(t *testing.T) {
 	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
 	taskEngine.EXPECT().Version().Return(fmt.Sprintf("Docker: %s", dockerVerStr), nil).AnyTimes()
 	ecsClient := mock_api.NewMockECSClient(ctrl)
-	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(acsURL, nil).AnyTimes()
+	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil).AnyTimes()
 	ctx, cancel := context.WithCancel(context.Background())
 	taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
 	deregisterInstanceEventStream := eventstream.NewEventStream("DeregisterContainerInstance", ctx)
@@ -1345,11 +2229,14 @@ func TestHandlerReconnectCorrectlySetsAcsUrl(t *testing.T) {
 		taskHandler,
 		aws.Int64(10),
 		emptyDoctor,
-		mockClientFactory)
+		mockClientFactory,
+		nil,
+		nil,
+		nil)
 	acsSession.(*session).backoff = mockBackoff
 	acsSession.(*session)._heartbeatTimeout = 20 * time.Millisecond
 	acsSession.(*session)._heartbeatJitter = 10 * time.Millisecond
-	acsSession.(*session).connectionTime = 30 * time.Millisecond
+	acsSession.(*session)._connectionTime = 30 * time.Millisecond
 	acsSession.(*session).connectionJitter = 10 * time.Millisecond
 
 	go func() {
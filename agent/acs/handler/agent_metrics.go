@@ -0,0 +1,132 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger/field"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/wsclient"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// sessionMetrics tracks the statistics of a session with ACS that are reported
+// to ACS as agent metrics. They are kept across connections of the session.
+type sessionMetrics struct {
+	lock            sync.RWMutex
+	connectionCount int64
+	heartbeatCount  int64
+	lastHeartbeatAt time.Time
+}
+
+// recordConnection records that a connection to ACS was established.
+func (m *sessionMetrics) recordConnection() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.connectionCount++
+}
+
+// recordHeartbeat records that a heartbeat message was received from ACS.
+func (m *sessionMetrics) recordHeartbeat() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.heartbeatCount++
+	m.lastHeartbeatAt = time.Now()
+}
+
+// agentMetricsReporter periodically sends the agent metrics of the session to ACS.
+type agentMetricsReporter struct {
+	acsClient            wsclient.ClientServer
+	cluster              string
+	containerInstanceArn string
+	state                dockerstate.TaskEngineState
+	metrics              *sessionMetrics
+	interval             time.Duration
+}
+
+// newAgentMetricsReporter returns a new agentMetricsReporter object
+func newAgentMetricsReporter(acsClient wsclient.ClientServer, cluster, containerInstanceArn string,
+	state dockerstate.TaskEngineState, metrics *sessionMetrics, interval time.Duration) *agentMetricsReporter {
+	return &agentMetricsReporter{
+		acsClient:            acsClient,
+		cluster:              cluster,
+		containerInstanceArn: containerInstanceArn,
+		state:                state,
+		metrics:              metrics,
+		interval:             interval,
+	}
+}
+
+// start sends agent metrics to ACS at every interval until the context is cancelled.
+// Nothing is sent if the interval is zero.
+func (reporter *agentMetricsReporter) start(ctx context.Context) {
+	if reporter.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(reporter.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reporter.report()
+			}
+		}
+	}()
+}
+
+// report sends the current agent metrics to ACS.
+func (reporter *agentMetricsReporter) report() {
+	request := reporter.newAgentMetricsRequest()
+	logger.Debug("Sending agent metrics to ACS", logger.Fields{
+		"reconnectCount": aws.Int64Value(request.ReconnectCount),
+		"taskCount":      aws.Int64Value(request.TaskCount),
+		"heartbeatCount": aws.Int64Value(request.HeartbeatCount),
+	})
+	if err := reporter.acsClient.MakeRequest(request); err != nil {
+		logger.Warn("Error sending agent metrics to ACS", logger.Fields{
+			field.Error: err,
+		})
+	}
+}
+
+func (reporter *agentMetricsReporter) newAgentMetricsRequest() *ecsacs.AgentMetricsRequest {
+	reporter.metrics.lock.RLock()
+	defer reporter.metrics.lock.RUnlock()
+
+	reconnectCount := reporter.metrics.connectionCount - 1
+	if reconnectCount < 0 {
+		reconnectCount = 0
+	}
+	request := &ecsacs.AgentMetricsRequest{
+		Cluster:           aws.String(reporter.cluster),
+		ContainerInstance: aws.String(reporter.containerInstanceArn),
+		ReconnectCount:    aws.Int64(reconnectCount),
+		TaskCount:         aws.Int64(int64(len(reporter.state.AllTasks()))),
+		HeartbeatCount:    aws.Int64(reporter.metrics.heartbeatCount),
+	}
+	if !reporter.metrics.lastHeartbeatAt.IsZero() {
+		request.LastHeartbeatAgeSeconds = aws.Int64(int64(time.Since(reporter.metrics.lastHeartbeatAt).Seconds()))
+	}
+	return request
+}
@@ -0,0 +1,117 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
+	mock_dockerstate "github.com/aws/amazon-ecs-agent/agent/engine/dockerstate/mocks"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	mock_wsclient "github.com/aws/amazon-ecs-agent/ecs-agent/wsclient/mock"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAgentMetricsInterval = 50 * time.Millisecond
+
+// TestAgentMetricsReporterSendsAtInterval tests that agent metrics are sent to ACS
+// at the configured interval
+func TestAgentMetricsReporterSendsAtInterval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	mockState := mock_dockerstate.NewMockTaskEngineState(ctrl)
+
+	metrics := &sessionMetrics{}
+	metrics.recordConnection()
+	metrics.recordConnection()
+	metrics.recordConnection()
+	metrics.recordHeartbeat()
+	metrics.recordHeartbeat()
+
+	mockState.EXPECT().AllTasks().Return([]*apitask.Task{{Arn: taskArn}}).AnyTimes()
+	sentAt := make(chan time.Time, 10)
+	mockWsClient.EXPECT().MakeRequest(gomock.Any()).Do(func(message interface{}) {
+		request, ok := message.(*ecsacs.AgentMetricsRequest)
+		require.True(t, ok, "expected an AgentMetricsRequest, got %T", message)
+		assert.Equal(t, clusterName, aws.StringValue(request.Cluster))
+		assert.Equal(t, containerInstanceArn, aws.StringValue(request.ContainerInstance))
+		assert.Equal(t, int64(2), aws.Int64Value(request.ReconnectCount))
+		assert.Equal(t, int64(1), aws.Int64Value(request.TaskCount))
+		assert.Equal(t, int64(2), aws.Int64Value(request.HeartbeatCount))
+		assert.NotNil(t, request.LastHeartbeatAgeSeconds)
+		sentAt <- time.Now()
+	}).Return(nil).MinTimes(3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	startedAt := time.Now()
+	newAgentMetricsReporter(mockWsClient, clusterName, containerInstanceArn, mockState, metrics,
+		testAgentMetricsInterval).start(ctx)
+
+	previous := startedAt
+	for i := 0; i < 3; i++ {
+		select {
+		case sent := <-sentAt:
+			// Allow for some imprecision of the ticker
+			assert.GreaterOrEqual(t, sent.Sub(previous), testAgentMetricsInterval/2)
+			previous = sent
+		case <-time.After(10 * testAgentMetricsInterval):
+			t.Fatal("timed out waiting for agent metrics to be sent")
+		}
+	}
+	cancel()
+}
+
+// TestAgentMetricsReporterDisabled tests that no agent metrics are sent to ACS when
+// the interval is zero
+func TestAgentMetricsReporterDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	mockState := mock_dockerstate.NewMockTaskEngineState(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	newAgentMetricsReporter(mockWsClient, clusterName, containerInstanceArn, mockState, &sessionMetrics{},
+		0).start(ctx)
+	time.Sleep(2 * testAgentMetricsInterval)
+}
+
+// TestNewAgentMetricsRequestBeforeHeartbeat tests that the last heartbeat age is omitted
+// until a heartbeat has been received
+func TestNewAgentMetricsRequestBeforeHeartbeat(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockState := mock_dockerstate.NewMockTaskEngineState(ctrl)
+	mockState.EXPECT().AllTasks().Return(nil)
+
+	metrics := &sessionMetrics{}
+	metrics.recordConnection()
+	reporter := newAgentMetricsReporter(nil, clusterName, containerInstanceArn, mockState, metrics,
+		testAgentMetricsInterval)
+
+	request := reporter.newAgentMetricsRequest()
+	assert.Equal(t, int64(0), aws.Int64Value(request.ReconnectCount))
+	assert.Equal(t, int64(0), aws.Int64Value(request.TaskCount))
+	assert.Equal(t, int64(0), aws.Int64Value(request.HeartbeatCount))
+	assert.Nil(t, request.LastHeartbeatAgeSeconds)
+}
@@ -0,0 +1,188 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ecs-agent/agent/data"
+	"github.com/aws/amazon-ecs-agent/agent/ecs_client/model/ecs"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger/field"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/wsclient"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pkg/errors"
+)
+
+const (
+	// nackReasonCapabilitiesNotUpdated is the reason code used when the capability updates
+	// sent by ACS could not be saved.
+	nackReasonCapabilitiesNotUpdated = "CapabilitiesNotUpdated"
+	// metadataNotFoundErrMsg is part of the error returned by the data client for metadata
+	// that was never saved.
+	metadataNotFoundErrMsg = "not found"
+)
+
+// CapabilityUpdates are the changes ACS made to the set of capabilities the container instance
+// registers with. They're saved with the data client, and applied to the capabilities detected
+// by the agent the next time it registers the container instance.
+//
+// A nil *CapabilityUpdates is valid and changes nothing.
+type CapabilityUpdates struct {
+	// SeqNum is the sequence number of the latest update applied. Updates with a sequence
+	// number that's not greater are redeliveries, or are stale, and are not applied again
+	SeqNum int64
+	// Added maps the names of the added capabilities to their values
+	Added map[string]string
+	// Removed is the set of the names of the removed capabilities
+	Removed map[string]bool
+}
+
+// LoadCapabilityUpdates returns the capability updates saved with the data client. Empty
+// updates are returned if none were saved.
+func LoadCapabilityUpdates(dataClient data.Client) (*CapabilityUpdates, error) {
+	updates := &CapabilityUpdates{
+		Added:   make(map[string]string),
+		Removed: make(map[string]bool),
+	}
+	val, err := dataClient.GetMetadata(data.CapabilityUpdatesKey)
+	if err != nil {
+		if strings.Contains(err.Error(), metadataNotFoundErrMsg) {
+			return updates, nil
+		}
+		return nil, errors.Wrap(err, "failed to get saved capability updates")
+	}
+	if val == "" {
+		return updates, nil
+	}
+	if err := json.Unmarshal([]byte(val), updates); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal saved capability updates")
+	}
+	if updates.Added == nil {
+		updates.Added = make(map[string]string)
+	}
+	if updates.Removed == nil {
+		updates.Removed = make(map[string]bool)
+	}
+	return updates, nil
+}
+
+// save saves the capability updates with the data client.
+func (updates *CapabilityUpdates) save(dataClient data.Client) error {
+	val, err := json.Marshal(updates)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal capability updates")
+	}
+	return dataClient.SaveMetadata(data.CapabilityUpdatesKey, string(val))
+}
+
+// merge merges the update of the message into the capability updates. A capability that is
+// removed after being added, or added after being removed, only reflects the latest update.
+// It returns false, and merges nothing, if the message is a redelivery of an update that was
+// already applied or is older than it.
+func (updates *CapabilityUpdates) merge(message *ecsacs.UpdateCapabilitiesMessage) bool {
+	if message.SeqNum != nil {
+		if aws.Int64Value(message.SeqNum) <= updates.SeqNum {
+			return false
+		}
+		updates.SeqNum = aws.Int64Value(message.SeqNum)
+	}
+	for _, capability := range message.AddedCapabilities {
+		name := aws.StringValue(capability.Name)
+		updates.Added[name] = aws.StringValue(capability.Value)
+		delete(updates.Removed, name)
+	}
+	for _, name := range message.RemovedCapabilities {
+		delete(updates.Added, aws.StringValue(name))
+		updates.Removed[aws.StringValue(name)] = true
+	}
+	return true
+}
+
+// Apply returns the capabilities with the updates applied. Removed capabilities are left out,
+// and added capabilities replace the detected capabilities of the same name.
+func (updates *CapabilityUpdates) Apply(capabilities []*ecs.Attribute) []*ecs.Attribute {
+	if updates == nil || (len(updates.Added) == 0 && len(updates.Removed) == 0) {
+		return capabilities
+	}
+	var ret []*ecs.Attribute
+	for _, capability := range capabilities {
+		name := aws.StringValue(capability.Name)
+		if _, added := updates.Added[name]; added || updates.Removed[name] {
+			continue
+		}
+		ret = append(ret, capability)
+	}
+	names := make([]string, 0, len(updates.Added))
+	for name := range updates.Added {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		capability := &ecs.Attribute{Name: aws.String(name)}
+		if value := updates.Added[name]; value != "" {
+			capability.Value = aws.String(value)
+		}
+		ret = append(ret, capability)
+	}
+	return ret
+}
+
+// capabilitiesUpdateHandlerFunc returns the handler for messages updating the capabilities of
+// the container instance. The updates are merged into the saved ones, which are applied the
+// next time the container instance is registered.
+func capabilitiesUpdateHandlerFunc(acsClient wsclient.ClientServer, dataClient data.Client,
+	nacker *messageNacker, cluster, containerInstanceArn string) func(message *ecsacs.UpdateCapabilitiesMessage) {
+	// lock serializes the updates of the saved capability updates
+	var lock sync.Mutex
+	return func(message *ecsacs.UpdateCapabilitiesMessage) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		messageID := aws.StringValue(message.MessageId)
+		fields := logger.Fields{
+			"messageID": messageID,
+			"seqNum":    aws.Int64Value(message.SeqNum),
+		}
+		updates, err := LoadCapabilityUpdates(dataClient)
+		if err == nil {
+			if updates.merge(message) {
+				logger.Info("Updating container instance capabilities as instructed by ACS", fields)
+				err = updates.save(dataClient)
+			} else {
+				logger.Info("Capability update was already applied; acknowledging it again", fields)
+			}
+		}
+		if err != nil {
+			fields[field.Error] = err
+			logger.Error("Unable to update container instance capabilities", fields)
+			nacker.nack(messageID, nackReasonCapabilitiesNotUpdated, err.Error())
+			return
+		}
+
+		err = acsClient.MakeRequest(&ecsacs.AckRequest{
+			Cluster:           aws.String(cluster),
+			ContainerInstance: aws.String(containerInstanceArn),
+			MessageId:         message.MessageId,
+		})
+		if err != nil {
+			fields[field.Error] = err
+			logger.Warn("Error acknowledging capability update", fields)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/agent/ecs_client/model/ecs"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	mock_wsclient "github.com/aws/amazon-ecs-agent/ecs-agent/wsclient/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const capabilitiesUpdateMessageId = "capabilitiesUpdateMessageId"
+
+func capabilitiesUpdateAck(messageID string) *ecsacs.AckRequest {
+	return &ecsacs.AckRequest{
+		Cluster:           aws.String(clusterName),
+		ContainerInstance: aws.String(containerInstanceArn),
+		MessageId:         aws.String(messageID),
+	}
+}
+
+// Tests that capability updates are merged into the saved ones and acked, that removing a
+// capability undoes its addition, and that a redelivered update is acked without being applied
+// again.
+func TestCapabilitiesUpdateHandlerFunc(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	dataClient := newTestDataClient(t)
+
+	handler := capabilitiesUpdateHandlerFunc(mockWsClient, dataClient,
+		newMessageNacker(mockWsClient, clusterName, containerInstanceArn), clusterName, containerInstanceArn)
+	addMessage := &ecsacs.UpdateCapabilitiesMessage{
+		ClusterArn:           aws.String(clusterName),
+		ContainerInstanceArn: aws.String(containerInstanceArn),
+		MessageId:            aws.String(capabilitiesUpdateMessageId + "1"),
+		SeqNum:               aws.Int64(1),
+		AddedCapabilities: []*ecsacs.Capability{
+			{Name: aws.String("com.amazonaws.ecs.capability.feature-a")},
+			{Name: aws.String("ecs.capability.feature-b"), Value: aws.String("v2")},
+		},
+	}
+	removeMessage := &ecsacs.UpdateCapabilitiesMessage{
+		ClusterArn:           aws.String(clusterName),
+		ContainerInstanceArn: aws.String(containerInstanceArn),
+		MessageId:            aws.String(capabilitiesUpdateMessageId + "2"),
+		SeqNum:               aws.Int64(2),
+		RemovedCapabilities: []*string{
+			aws.String("com.amazonaws.ecs.capability.feature-a"),
+			aws.String("com.amazonaws.ecs.capability.selinux"),
+		},
+	}
+	gomock.InOrder(
+		mockWsClient.EXPECT().MakeRequest(capabilitiesUpdateAck(capabilitiesUpdateMessageId+"1")).Return(nil),
+		mockWsClient.EXPECT().MakeRequest(capabilitiesUpdateAck(capabilitiesUpdateMessageId+"2")).Return(nil),
+		mockWsClient.EXPECT().MakeRequest(capabilitiesUpdateAck(capabilitiesUpdateMessageId+"1")).Return(nil),
+	)
+
+	handler(addMessage)
+	updates, err := LoadCapabilityUpdates(dataClient)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updates.SeqNum)
+	assert.Equal(t, map[string]string{
+		"com.amazonaws.ecs.capability.feature-a": "",
+		"ecs.capability.feature-b":               "v2",
+	}, updates.Added)
+	assert.Empty(t, updates.Removed)
+
+	handler(removeMessage)
+	updates, err = LoadCapabilityUpdates(dataClient)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updates.SeqNum)
+	assert.Equal(t, map[string]string{"ecs.capability.feature-b": "v2"}, updates.Added)
+	assert.Equal(t, map[string]bool{
+		"com.amazonaws.ecs.capability.feature-a": true,
+		"com.amazonaws.ecs.capability.selinux":   true,
+	}, updates.Removed)
+
+	// Redelivery of the first update doesn't add back the capability removed since
+	handler(addMessage)
+	redelivered, err := LoadCapabilityUpdates(dataClient)
+	require.NoError(t, err)
+	assert.Equal(t, updates, redelivered)
+}
+
+func TestCapabilitiesUpdateHandlerFuncSaveFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	dataClient := newTestDataClient(t)
+	// Closing the data client makes reading and saving the capability updates fail
+	require.NoError(t, dataClient.Close())
+
+	mockWsClient.EXPECT().MakeRequest(gomock.Any()).Do(func(request interface{}) {
+		nack, ok := request.(*ecsacs.NackRequest)
+		require.True(t, ok, "expected the update to be nacked")
+		assert.Equal(t, capabilitiesUpdateMessageId, aws.StringValue(nack.MessageId))
+		assert.Contains(t, aws.StringValue(nack.Reason), nackReasonCapabilitiesNotUpdated)
+	}).Return(nil)
+
+	handler := capabilitiesUpdateHandlerFunc(mockWsClient, dataClient,
+		newMessageNacker(mockWsClient, clusterName, containerInstanceArn), clusterName, containerInstanceArn)
+	handler(&ecsacs.UpdateCapabilitiesMessage{
+		MessageId: aws.String(capabilitiesUpdateMessageId),
+		SeqNum:    aws.Int64(1),
+		AddedCapabilities: []*ecsacs.Capability{
+			{Name: aws.String("com.amazonaws.ecs.capability.feature-a")},
+		},
+	})
+}
+
+func TestCapabilityUpdatesApply(t *testing.T) {
+	capabilities := []*ecs.Attribute{
+		{Name: aws.String("com.amazonaws.ecs.capability.selinux")},
+		{Name: aws.String("com.amazonaws.ecs.capability.privileged-container")},
+		{Name: aws.String("ecs.capability.feature-b"), Value: aws.String("v1")},
+	}
+	updates := &CapabilityUpdates{
+		Added: map[string]string{
+			"ecs.capability.feature-b":               "v2",
+			"com.amazonaws.ecs.capability.feature-a": "",
+		},
+		Removed: map[string]bool{"com.amazonaws.ecs.capability.selinux": true},
+	}
+
+	assert.Equal(t, []*ecs.Attribute{
+		{Name: aws.String("com.amazonaws.ecs.capability.privileged-container")},
+		{Name: aws.String("com.amazonaws.ecs.capability.feature-a")},
+		{Name: aws.String("ecs.capability.feature-b"), Value: aws.String("v2")},
+	}, updates.Apply(capabilities))
+
+	var noUpdates *CapabilityUpdates
+	assert.Equal(t, capabilities, noUpdates.Apply(capabilities))
+}
@@ -0,0 +1,95 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger/field"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/wsclient"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+const (
+	// minHeartbeatTimeout and maxHeartbeatTimeout bound the heartbeat timeout that ACS can set.
+	minHeartbeatTimeout = 30 * time.Second
+	maxHeartbeatTimeout = 5 * time.Minute
+	// minConnectionTime and maxConnectionTime bound the connection time that ACS can set.
+	minConnectionTime = 1 * time.Minute
+	maxConnectionTime = 2 * time.Hour
+)
+
+// connectionParametersHandlerFunc returns the handler for messages updating the heartbeat timeout
+// and the connection time of the session's connections to ACS. The connection being served keeps
+// its parameters, the updated ones are used from the next connection on.
+func connectionParametersHandlerFunc(acsClient wsclient.ClientServer, acsSession *session,
+	cluster, containerInstanceArn string) func(message *ecsacs.UpdateConnectionParametersMessage) {
+	return func(message *ecsacs.UpdateConnectionParametersMessage) {
+		handleSingleUpdateConnectionParametersMessage(acsClient, acsSession, cluster, containerInstanceArn, message)
+	}
+}
+
+// handleSingleUpdateConnectionParametersMessage applies the connection parameters of the message
+// to the session and acks it. Parameters that are not set are left unchanged, and parameters out
+// of their bounds are ignored, so that ACS can't make the agent drop or hold on to its connections
+// for too long.
+func handleSingleUpdateConnectionParametersMessage(acsClient wsclient.ClientServer, acsSession *session,
+	cluster, containerInstanceArn string, message *ecsacs.UpdateConnectionParametersMessage) {
+	messageID := aws.StringValue(message.MessageId)
+	heartbeatTimeout := connectionParameter(messageID, "heartbeatTimeout", message.HeartbeatTimeoutSeconds,
+		minHeartbeatTimeout, maxHeartbeatTimeout)
+	connectionTime := connectionParameter(messageID, "connectionTime", message.ConnectionTimeSeconds,
+		minConnectionTime, maxConnectionTime)
+	acsSession.setConnectionParameters(heartbeatTimeout, connectionTime)
+	logger.Info("Updated ACS connection parameters, they apply from the next connection", logger.Fields{
+		"messageID":        messageID,
+		"heartbeatTimeout": acsSession.heartbeatTimeout().String(),
+		"connectionTime":   acsSession.connectionTime().String(),
+	})
+
+	err := acsClient.MakeRequest(&ecsacs.AckRequest{
+		Cluster:           aws.String(cluster),
+		ContainerInstance: aws.String(containerInstanceArn),
+		MessageId:         message.MessageId,
+	})
+	if err != nil {
+		logger.Warn("Error acknowledging ACS connection parameters update", logger.Fields{
+			"messageID": messageID,
+			field.Error: err,
+		})
+	}
+}
+
+// connectionParameter returns the duration of a connection parameter given in seconds. Zero is
+// returned if the parameter is not set or is out of bounds, in which case it's not updated.
+func connectionParameter(messageID, name string, seconds *int64, min, max time.Duration) time.Duration {
+	if seconds == nil {
+		return 0
+	}
+	// The bounds are checked in seconds, as converting a large value to a duration overflows
+	value := aws.Int64Value(seconds)
+	if value < int64(min/time.Second) || value > int64(max/time.Second) {
+		logger.Warn("Ignoring out of bounds ACS connection parameter", logger.Fields{
+			"messageID": messageID,
+			"parameter": name,
+			"seconds":   value,
+			"min":       min.String(),
+			"max":       max.String(),
+		})
+		return 0
+	}
+	return time.Duration(value) * time.Second
+}
@@ -0,0 +1,203 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	mock_api "github.com/aws/amazon-ecs-agent/agent/api/mocks"
+	"github.com/aws/amazon-ecs-agent/agent/data"
+	mock_dockerapi "github.com/aws/amazon-ecs-agent/agent/dockerclient/dockerapi/mocks"
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	mock_engine "github.com/aws/amazon-ecs-agent/agent/engine/mocks"
+	"github.com/aws/amazon-ecs-agent/agent/eventhandler"
+	"github.com/aws/amazon-ecs-agent/agent/eventstream"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	rolecredentials "github.com/aws/amazon-ecs-agent/ecs-agent/credentials"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/doctor"
+	mock_retry "github.com/aws/amazon-ecs-agent/ecs-agent/utils/retry/mock"
+	mock_wsclient "github.com/aws/amazon-ecs-agent/ecs-agent/wsclient/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const connectionParametersMessageId = "connectionParametersMessageId"
+
+func TestUpdateConnectionParametersMessage(t *testing.T) {
+	testCases := []struct {
+		name                     string
+		heartbeatTimeoutSeconds  *int64
+		connectionTimeSeconds    *int64
+		expectedHeartbeatTimeout time.Duration
+		expectedConnectionTime   time.Duration
+	}{
+		{
+			name:                     "both parameters updated",
+			heartbeatTimeoutSeconds:  aws.Int64(90),
+			connectionTimeSeconds:    aws.Int64(3600),
+			expectedHeartbeatTimeout: 90 * time.Second,
+			expectedConnectionTime:   time.Hour,
+		},
+		{
+			name:                     "parameters at their bounds",
+			heartbeatTimeoutSeconds:  aws.Int64(int64(minHeartbeatTimeout / time.Second)),
+			connectionTimeSeconds:    aws.Int64(int64(maxConnectionTime / time.Second)),
+			expectedHeartbeatTimeout: minHeartbeatTimeout,
+			expectedConnectionTime:   maxConnectionTime,
+		},
+		{
+			name:                     "unset parameter unchanged",
+			connectionTimeSeconds:    aws.Int64(1800),
+			expectedHeartbeatTimeout: heartbeatTimeout,
+			expectedConnectionTime:   30 * time.Minute,
+		},
+		{
+			name:                     "out of bounds parameters ignored",
+			heartbeatTimeoutSeconds:  aws.Int64(1),
+			connectionTimeSeconds:    aws.Int64(int64(maxConnectionTime/time.Second) + 1),
+			expectedHeartbeatTimeout: heartbeatTimeout,
+			expectedConnectionTime:   connectionTime,
+		},
+		{
+			name:                     "overflowing parameter ignored",
+			heartbeatTimeoutSeconds:  aws.Int64(1 << 62),
+			expectedHeartbeatTimeout: heartbeatTimeout,
+			expectedConnectionTime:   connectionTime,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+
+			acsSession := &session{
+				_heartbeatTimeout: heartbeatTimeout,
+				_connectionTime:   connectionTime,
+			}
+			mockWsClient.EXPECT().MakeRequest(&ecsacs.AckRequest{
+				Cluster:           aws.String(clusterName),
+				ContainerInstance: aws.String(containerInstanceArn),
+				MessageId:         aws.String(connectionParametersMessageId),
+			}).Return(nil)
+
+			handler := connectionParametersHandlerFunc(mockWsClient, acsSession, clusterName, containerInstanceArn)
+			handler(&ecsacs.UpdateConnectionParametersMessage{
+				ClusterArn:              aws.String(clusterName),
+				ContainerInstanceArn:    aws.String(containerInstanceArn),
+				MessageId:               aws.String(connectionParametersMessageId),
+				HeartbeatTimeoutSeconds: tc.heartbeatTimeoutSeconds,
+				ConnectionTimeSeconds:   tc.connectionTimeSeconds,
+			})
+			assert.Equal(t, tc.expectedHeartbeatTimeout, acsSession.heartbeatTimeout())
+			assert.Equal(t, tc.expectedConnectionTime, acsSession.connectionTime())
+		})
+	}
+}
+
+// Tests that connection parameters sent by ACS during a connection are used by the next one.
+func TestHandlerUsesUpdatedConnectionParametersOnNextConnection(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
+	taskEngine.EXPECT().Version().Return("Docker: 1.5.0", nil).AnyTimes()
+	ecsClient := mock_api.NewMockECSClient(ctrl)
+	ecsClient.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return(acsURL, nil).AnyTimes()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	taskHandler := eventhandler.NewTaskHandler(ctx, data.NewNoopClient(), nil, nil)
+	deregisterInstanceEventStream := eventstream.NewEventStream("DeregisterContainerInstance", ctx)
+	deregisterInstanceEventStream.StartListening()
+	dockerClient := mock_dockerapi.NewMockDockerClient(ctrl)
+	emptyDoctor, _ := doctor.NewDoctor([]doctor.Healthcheck{}, "test-cluster", "this:is:an:instance:arn")
+
+	mockBackoff := mock_retry.NewMockBackoff(ctrl)
+	mockBackoff.EXPECT().Reset().AnyTimes()
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	mockClientFactory := mock_wsclient.NewMockClientFactory(ctrl)
+	var updateConnectionParameters func(*ecsacs.UpdateConnectionParametersMessage)
+	mockWsClient.EXPECT().AddRequestHandler(gomock.Any()).Do(func(handler interface{}) {
+		if h, ok := handler.(func(*ecsacs.UpdateConnectionParametersMessage)); ok {
+			updateConnectionParameters = h
+		}
+	}).AnyTimes()
+	mockWsClient.EXPECT().SetAnyRequestHandler(gomock.Any()).AnyTimes()
+	mockWsClient.EXPECT().WriteCloseMessage().AnyTimes()
+	mockWsClient.EXPECT().Close().Return(nil).AnyTimes()
+	mockWsClient.EXPECT().Serve(gomock.Any()).Return(io.EOF).AnyTimes()
+	mockWsClient.EXPECT().MakeRequest(&ecsacs.AckRequest{
+		Cluster:           aws.String(testConfig.Cluster),
+		ContainerInstance: aws.String("myArn"),
+		MessageId:         aws.String(connectionParametersMessageId),
+	}).Return(nil)
+
+	acsSession := NewSession(
+		ctx,
+		testConfig,
+		deregisterInstanceEventStream,
+		"myArn",
+		testCreds,
+		dockerClient,
+		ecsClient,
+		dockerstate.NewTaskEngineState(),
+		data.NewNoopClient(),
+		taskEngine,
+		rolecredentials.NewManager(),
+		taskHandler,
+		aws.Int64(10),
+		emptyDoctor,
+		mockClientFactory,
+		nil,
+		nil,
+		nil).(*session)
+	acsSession.backoff = mockBackoff
+	acsSession._heartbeatTimeout = 20 * time.Millisecond
+	acsSession._heartbeatJitter = 10 * time.Millisecond
+	acsSession._connectionTime = 30 * time.Millisecond
+	acsSession.connectionJitter = 10 * time.Millisecond
+
+	gomock.InOrder(
+		mockClientFactory.EXPECT().
+			New(gomock.Any(), gomock.Any(), wsRWTimeout(20*time.Millisecond, 10*time.Millisecond), gomock.Any()).
+			Return(mockWsClient),
+		mockWsClient.EXPECT().Connect().Do(func() {
+			require.NotNil(t, updateConnectionParameters)
+			updateConnectionParameters(&ecsacs.UpdateConnectionParametersMessage{
+				MessageId:               aws.String(connectionParametersMessageId),
+				HeartbeatTimeoutSeconds: aws.Int64(120),
+				ConnectionTimeSeconds:   aws.Int64(3600),
+			})
+		}).Return(nil),
+		mockClientFactory.EXPECT().
+			New(gomock.Any(), gomock.Any(), wsRWTimeout(2*time.Minute, 10*time.Millisecond), gomock.Any()).
+			Return(mockWsClient),
+		mockWsClient.EXPECT().Connect().Do(func() {
+			assert.Equal(t, 2*time.Minute, acsSession.heartbeatTimeout())
+			assert.Equal(t, time.Hour, acsSession.connectionTime())
+			cancel()
+		}).Return(nil),
+	)
+
+	acsSession.Start()
+}
@@ -0,0 +1,158 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ecs-agent/agent/data"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger/field"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/wsclient"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/pkg/errors"
+)
+
+const (
+	// nackReasonDrainStateNotSaved is the reason code used when the drain state sent by ACS
+	// could not be saved.
+	nackReasonDrainStateNotSaved = "DrainStateNotSaved"
+	// drainingURLParameterName is the name of the URL parameter reporting to ACS that the
+	// container instance is draining.
+	drainingURLParameterName = "draining"
+)
+
+// DrainState is whether ACS set the container instance to draining, for instance for the duration
+// of a maintenance window. It's saved with the data client and restored when the agent starts, so
+// that an agent restart doesn't report the container instance as no longer draining.
+//
+// A nil *DrainState is valid and is never draining.
+type DrainState struct {
+	lock  sync.RWMutex
+	saved savedDrainState
+}
+
+// savedDrainState is the drain state saved with the data client.
+type savedDrainState struct {
+	// Draining is true if the container instance is draining
+	Draining bool
+	// SeqNum is the sequence number of the latest drain message applied. Messages with a
+	// sequence number that's not greater are redeliveries, or are stale, and are not applied again
+	SeqNum int64
+}
+
+// NewDrainState returns a new DrainState that's not draining.
+func NewDrainState() *DrainState {
+	return &DrainState{}
+}
+
+// LoadDrainState returns the drain state saved with the data client. A drain state that's not
+// draining is returned if none was saved.
+func LoadDrainState(dataClient data.Client) (*DrainState, error) {
+	state := NewDrainState()
+	val, err := dataClient.GetMetadata(data.DrainStateKey)
+	if err != nil {
+		if strings.Contains(err.Error(), metadataNotFoundErrMsg) {
+			return state, nil
+		}
+		return nil, errors.Wrap(err, "failed to get saved drain state")
+	}
+	if val == "" {
+		return state, nil
+	}
+	if err := json.Unmarshal([]byte(val), &state.saved); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal saved drain state")
+	}
+	return state, nil
+}
+
+// Draining returns true if the container instance is draining.
+func (state *DrainState) Draining() bool {
+	if state == nil {
+		return false
+	}
+	state.lock.RLock()
+	defer state.lock.RUnlock()
+
+	return state.saved.Draining
+}
+
+// update applies the drain state of the message and saves it with the data client. The drain
+// state is only changed once it's saved. It returns false, and changes nothing, if the message is a
+// redelivery of a drain message that was already applied or is older than it.
+func (state *DrainState) update(dataClient data.Client, message *ecsacs.DrainContainerInstanceMessage) (bool, error) {
+	if state == nil {
+		return false, errors.New("no drain state to update")
+	}
+	state.lock.Lock()
+	defer state.lock.Unlock()
+
+	updated := state.saved
+	if message.SeqNum != nil {
+		if aws.Int64Value(message.SeqNum) <= updated.SeqNum {
+			return false, nil
+		}
+		updated.SeqNum = aws.Int64Value(message.SeqNum)
+	}
+	updated.Draining = aws.BoolValue(message.Draining)
+	val, err := json.Marshal(updated)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to marshal drain state")
+	}
+	if err := dataClient.SaveMetadata(data.DrainStateKey, string(val)); err != nil {
+		return false, errors.Wrap(err, "failed to save drain state")
+	}
+	state.saved = updated
+	return true, nil
+}
+
+// drainHandlerFunc returns the handler for messages starting or stopping the draining of the
+// container instance. The drain state is saved before the message is acked, and is reported to
+// ACS on the next connection.
+func drainHandlerFunc(acsClient wsclient.ClientServer, dataClient data.Client, drainState *DrainState,
+	nacker *messageNacker, cluster, containerInstanceArn string) func(message *ecsacs.DrainContainerInstanceMessage) {
+	return func(message *ecsacs.DrainContainerInstanceMessage) {
+		messageID := aws.StringValue(message.MessageId)
+		fields := logger.Fields{
+			"messageID": messageID,
+			"seqNum":    aws.Int64Value(message.SeqNum),
+			"draining":  aws.BoolValue(message.Draining),
+		}
+		updated, err := drainState.update(dataClient, message)
+		if err != nil {
+			fields[field.Error] = err
+			logger.Error("Unable to update container instance drain state", fields)
+			nacker.nack(messageID, nackReasonDrainStateNotSaved, err.Error())
+			return
+		}
+		if updated {
+			logger.Info("Updated container instance drain state as instructed by ACS", fields)
+		} else {
+			logger.Info("Drain message was already applied; acknowledging it again", fields)
+		}
+
+		err = acsClient.MakeRequest(&ecsacs.AckRequest{
+			Cluster:           aws.String(cluster),
+			ContainerInstance: aws.String(containerInstanceArn),
+			MessageId:         message.MessageId,
+		})
+		if err != nil {
+			fields[field.Error] = err
+			logger.Warn("Error acknowledging drain message", fields)
+		}
+	}
+}
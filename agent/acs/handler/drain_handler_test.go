@@ -0,0 +1,144 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/agent/data"
+	mock_engine "github.com/aws/amazon-ecs-agent/agent/engine/mocks"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	mock_wsclient "github.com/aws/amazon-ecs-agent/ecs-agent/wsclient/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const drainMessageId = "drainMessageId"
+
+func drainMessage(messageID string, seqNum int64, draining bool) *ecsacs.DrainContainerInstanceMessage {
+	return &ecsacs.DrainContainerInstanceMessage{
+		ClusterArn:           aws.String(clusterName),
+		ContainerInstanceArn: aws.String(containerInstanceArn),
+		MessageId:            aws.String(messageID),
+		SeqNum:               aws.Int64(seqNum),
+		Draining:             aws.Bool(draining),
+	}
+}
+
+func drainAck(messageID string) *ecsacs.AckRequest {
+	return &ecsacs.AckRequest{
+		Cluster:           aws.String(clusterName),
+		ContainerInstance: aws.String(containerInstanceArn),
+		MessageId:         aws.String(messageID),
+	}
+}
+
+// Tests that the drain state set by ACS is restored after a restart of the agent, and that a
+// redelivered drain message doesn't undo a later one.
+func TestDrainHandlerFuncStateRestoredAfterRestart(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	dataDir := t.TempDir()
+	dataClient, err := data.NewWithSetup(dataDir)
+	require.NoError(t, err)
+
+	drainState, err := LoadDrainState(dataClient)
+	require.NoError(t, err)
+	assert.False(t, drainState.Draining())
+
+	handler := drainHandlerFunc(mockWsClient, dataClient, drainState,
+		newMessageNacker(mockWsClient, clusterName, containerInstanceArn), clusterName, containerInstanceArn)
+	mockWsClient.EXPECT().MakeRequest(drainAck(drainMessageId)).Return(nil)
+	handler(drainMessage(drainMessageId, 1, true))
+	assert.True(t, drainState.Draining())
+
+	// Restart the agent
+	require.NoError(t, dataClient.Close())
+	dataClient, err = data.NewWithSetup(dataDir)
+	require.NoError(t, err)
+	defer dataClient.Close()
+	drainState, err = LoadDrainState(dataClient)
+	require.NoError(t, err)
+	assert.True(t, drainState.Draining(), "Expected the drain state to be restored")
+
+	handler = drainHandlerFunc(mockWsClient, dataClient, drainState,
+		newMessageNacker(mockWsClient, clusterName, containerInstanceArn), clusterName, containerInstanceArn)
+	gomock.InOrder(
+		mockWsClient.EXPECT().MakeRequest(drainAck(drainMessageId+"2")).Return(nil),
+		mockWsClient.EXPECT().MakeRequest(drainAck(drainMessageId)).Return(nil),
+	)
+	handler(drainMessage(drainMessageId+"2", 2, false))
+	assert.False(t, drainState.Draining())
+	// Redelivery of the first message doesn't set the container instance back to draining
+	handler(drainMessage(drainMessageId, 1, true))
+	assert.False(t, drainState.Draining())
+
+	restored, err := LoadDrainState(dataClient)
+	require.NoError(t, err)
+	assert.False(t, restored.Draining())
+}
+
+func TestDrainHandlerFuncSaveFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	dataClient := newTestDataClient(t)
+	drainState := NewDrainState()
+	// Closing the data client makes saving the drain state fail
+	require.NoError(t, dataClient.Close())
+
+	mockWsClient.EXPECT().MakeRequest(gomock.Any()).Do(func(request interface{}) {
+		nack, ok := request.(*ecsacs.NackRequest)
+		require.True(t, ok, "expected the drain message to be nacked")
+		assert.Equal(t, drainMessageId, aws.StringValue(nack.MessageId))
+		assert.Contains(t, aws.StringValue(nack.Reason), nackReasonDrainStateNotSaved)
+	}).Return(nil)
+
+	handler := drainHandlerFunc(mockWsClient, dataClient, drainState,
+		newMessageNacker(mockWsClient, clusterName, containerInstanceArn), clusterName, containerInstanceArn)
+	handler(drainMessage(drainMessageId, 1, true))
+	assert.False(t, drainState.Draining(), "Expected the drain state to change only once saved")
+}
+
+// Tests that a container instance that's draining reports it when connecting to ACS.
+func TestACSURLDraining(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
+	taskEngine.EXPECT().Version().Return("Docker version result", nil).Times(2)
+
+	drainState := NewDrainState()
+	acsSession := session{
+		taskEngine:           taskEngine,
+		agentConfig:          testConfig,
+		containerInstanceARN: "myContainerInstance",
+		drainState:           drainState,
+	}
+	parsed, err := url.Parse(acsSession.acsURL(acsURL))
+	require.NoError(t, err)
+	assert.False(t, parsed.Query().Has(drainingURLParameterName))
+
+	drainState.saved.Draining = true
+	parsed, err = url.Parse(acsSession.acsURL(acsURL))
+	require.NoError(t, err)
+	assert.Equal(t, "true", parsed.Query().Get(drainingURLParameterName))
+}
@@ -0,0 +1,177 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/wsclient"
+	"github.com/cihub/seelog"
+	"github.com/pkg/errors"
+)
+
+const (
+	// handlerWatchdogChecksPerThreshold is the number of times the handler watchdog checks
+	// for a stall during a stall threshold.
+	handlerWatchdogChecksPerThreshold = 4
+	// maxStackDumpSize bounds the size of the stack dump logged when the message handling
+	// loop stalls, so that a single log message stays small enough for log drivers and rotation.
+	maxStackDumpSize = 1024 * 1024
+	// processingTimeoutStallGrace is the time after its processing timeout that handling a
+	// message may take, nacking it included, before the loop is considered stalled.
+	processingTimeoutStallGrace = 30 * time.Second
+)
+
+// stalledServeJoinTimeout bounds the time a session waits for a stalled message handling loop to
+// return once its connection is closed. It's a variable so that tests can shorten it.
+var stalledServeJoinTimeout = 30 * time.Second
+
+// errACSHandlerStalled is returned by a session whose message handling loop stalled.
+var errACSHandlerStalled = errors.New("acs: message handling stalled")
+
+// handlerWatchdog detects stalls of the loop that handles the messages of an ACS connection.
+// The loop makes progress as long as it's waiting for messages or handling one for less than
+// the stall threshold. Otherwise it's stalled, typically on a lock, and it stops reading
+// messages while the connection may still look healthy.
+//
+// The processing timeout of a message type takes precedence over the stall threshold: a message
+// with a processing timeout is only considered stalled once the timeout, plus a grace period for
+// nacking it, has passed. A message that times out is thus nacked, and the loop moves on to the
+// next message, before the watchdog drops the connection.
+type handlerWatchdog struct {
+	threshold time.Duration
+	// processingTimeouts maps message types to their processing timeout
+	processingTimeouts map[string]time.Duration
+
+	lock sync.Mutex
+	// handling is true while a message is being handled
+	handling bool
+	// handlingSince is the time handling the current message started at
+	handlingSince time.Time
+	// messageType is the type of the message being handled
+	messageType string
+}
+
+// newHandlerWatchdog returns a new handlerWatchdog. Stalls are not detected when the threshold
+// is not positive.
+func newHandlerWatchdog(threshold time.Duration, processingTimeouts map[string]time.Duration) *handlerWatchdog {
+	return &handlerWatchdog{
+		threshold:          threshold,
+		processingTimeouts: processingTimeouts,
+	}
+}
+
+// stallThreshold returns how long handling a message of the type may take before the loop is
+// considered stalled.
+func (w *handlerWatchdog) stallThreshold(messageType string) time.Duration {
+	timeout, ok := w.processingTimeouts[messageType]
+	if !ok || timeout <= 0 || timeout+processingTimeoutStallGrace <= w.threshold {
+		return w.threshold
+	}
+	return timeout + processingTimeoutStallGrace
+}
+
+// track wraps an ACS request handler so that handling its messages is tracked by the watchdog.
+func (w *handlerWatchdog) track(handler wsclient.RequestHandler) wsclient.RequestHandler {
+	handlerValue := reflect.ValueOf(handler)
+	handlerType := handlerValue.Type()
+	// The handler of any message takes an interface
+	messageType := "message"
+	if handlerType.In(0).Kind() == reflect.Ptr {
+		messageType = handlerType.In(0).Elem().Name()
+	}
+	return reflect.MakeFunc(handlerType, func(args []reflect.Value) []reflect.Value {
+		w.begin(messageType)
+		defer w.end()
+		return handlerValue.Call(args)
+	}).Interface()
+}
+
+func (w *handlerWatchdog) begin(messageType string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.handling = true
+	w.handlingSince = time.Now()
+	w.messageType = messageType
+}
+
+func (w *handlerWatchdog) end() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.handling = false
+}
+
+// stalled returns the type of the message being handled and how long it has been handled for,
+// if that's longer than the stall threshold of the message type.
+func (w *handlerWatchdog) stalled(now time.Time) (string, time.Duration, bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if !w.handling {
+		return "", 0, false
+	}
+	handlingFor := now.Sub(w.handlingSince)
+	return w.messageType, handlingFor, handlingFor > w.stallThreshold(w.messageType)
+}
+
+// watch checks for stalls of the message handling loop until the context is done. The returned
+// channel is closed once a stall is detected, after the stacks of all goroutines are logged.
+func (w *handlerWatchdog) watch(ctx context.Context) <-chan struct{} {
+	stalled := make(chan struct{})
+	if w.threshold <= 0 {
+		return stalled
+	}
+	go func() {
+		ticker := time.NewTicker(w.threshold / handlerWatchdogChecksPerThreshold)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				messageType, handlingFor, ok := w.stalled(now)
+				if !ok {
+					continue
+				}
+				seelog.Criticalf("ACS message handling stalled: handling %s for %s, more than the threshold of %s; "+
+					"reconnecting to ACS. Stacks of all goroutines:\n%s", messageType, handlingFor, w.stallThreshold(messageType),
+					goroutineStacks())
+				close(stalled)
+				return
+			}
+		}
+	}()
+	return stalled
+}
+
+// goroutineStacks returns the stack traces of all goroutines, truncated to maxStackDumpSize.
+func goroutineStacks() []byte {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		if len(buf) >= maxStackDumpSize {
+			return append(buf[:n], "\n... truncated"...)
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,186 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	mock_wsclient "github.com/aws/amazon-ecs-agent/ecs-agent/wsclient/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSessionReconnectsWhenHandlerStalls tests that a session stops serving a connection whose
+// message handling loop is stuck on a lock, closing the connection and waiting for the loop.
+func TestSessionReconnectsWhenHandlerStalls(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	acsSession := &session{ctx: ctx}
+
+	var lock sync.Mutex
+	lock.Lock()
+	watchdog := newHandlerWatchdog(50*time.Millisecond, nil)
+	stalledHandler := watchdog.track(func(*ecsacs.HeartbeatMessage) {
+		lock.Lock()
+		defer lock.Unlock()
+	}).(func(*ecsacs.HeartbeatMessage))
+
+	serveReturned := make(chan struct{})
+	client := mock_wsclient.NewMockClientServer(ctrl)
+	client.EXPECT().Serve(gomock.Any()).DoAndReturn(func(context.Context) error {
+		defer close(serveReturned)
+		stalledHandler(&ecsacs.HeartbeatMessage{})
+		return nil
+	})
+	// Closing the connection lets the stalled handler return, as failing to write to it would
+	client.EXPECT().Close().Do(func() {
+		lock.Unlock()
+	}).Return(nil)
+
+	served := make(chan error)
+	go func() {
+		served <- acsSession.serve(client, watchdog)
+	}()
+	select {
+	case err := <-served:
+		assert.Equal(t, errACSHandlerStalled, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the watchdog to detect the stalled handler")
+	}
+	select {
+	case <-serveReturned:
+	default:
+		t.Fatal("Expected the session to wait for the stalled message handling loop")
+	}
+}
+
+// TestSessionReconnectsWhenHandlerStaysStalled tests that a session waits for a message handling
+// loop that stays stalled once its connection is closed for a bounded time only.
+func TestSessionReconnectsWhenHandlerStaysStalled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	defer func(timeout time.Duration) {
+		stalledServeJoinTimeout = timeout
+	}(stalledServeJoinTimeout)
+	stalledServeJoinTimeout = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	acsSession := &session{ctx: ctx}
+
+	var lock sync.Mutex
+	lock.Lock()
+	defer lock.Unlock()
+	watchdog := newHandlerWatchdog(50*time.Millisecond, nil)
+	stalledHandler := watchdog.track(func(*ecsacs.HeartbeatMessage) {
+		lock.Lock()
+		defer lock.Unlock()
+	}).(func(*ecsacs.HeartbeatMessage))
+
+	client := mock_wsclient.NewMockClientServer(ctrl)
+	client.EXPECT().Serve(gomock.Any()).DoAndReturn(func(context.Context) error {
+		stalledHandler(&ecsacs.HeartbeatMessage{})
+		return nil
+	})
+	client.EXPECT().Close().Return(nil)
+
+	served := make(chan error)
+	go func() {
+		served <- acsSession.serve(client, watchdog)
+	}()
+	select {
+	case err := <-served:
+		assert.Equal(t, errACSHandlerStalled, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the session to stop waiting for the stalled message handling loop")
+	}
+}
+
+// TestHandlerWatchdogProgress tests that a message handling loop that keeps handling messages
+// quickly, or that waits for messages, is not considered stalled.
+func TestHandlerWatchdogProgress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watchdog := newHandlerWatchdog(50*time.Millisecond, nil)
+	stalled := watchdog.watch(ctx)
+	handler := watchdog.track(func(interface{}) {
+		time.Sleep(5 * time.Millisecond)
+	}).(func(interface{}))
+
+	deadline := time.Now().Add(250 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		handler(&ecsacs.HeartbeatMessage{})
+	}
+	// Idle for longer than the threshold
+	time.Sleep(150 * time.Millisecond)
+
+	select {
+	case <-stalled:
+		t.Fatal("Expected the message handling loop not to be considered stalled")
+	default:
+	}
+}
+
+func TestHandlerWatchdogStalled(t *testing.T) {
+	watchdog := newHandlerWatchdog(time.Minute, nil)
+	start := time.Now()
+	watchdog.begin("PayloadMessage")
+
+	_, _, ok := watchdog.stalled(start.Add(30 * time.Second))
+	assert.False(t, ok)
+
+	messageType, handlingFor, ok := watchdog.stalled(start.Add(2 * time.Minute))
+	assert.True(t, ok)
+	assert.Equal(t, "PayloadMessage", messageType)
+	assert.True(t, handlingFor > time.Minute)
+
+	watchdog.end()
+	_, _, ok = watchdog.stalled(start.Add(2 * time.Minute))
+	assert.False(t, ok)
+}
+
+// TestHandlerWatchdogProcessingTimeoutPrecedence tests that a message with a processing timeout
+// longer than the stall threshold is only considered stalled once its timeout has passed.
+func TestHandlerWatchdogProcessingTimeoutPrecedence(t *testing.T) {
+	watchdog := newHandlerWatchdog(time.Minute, map[string]time.Duration{
+		"PayloadMessage":            2 * time.Minute,
+		"IAMRoleCredentialsMessage": 5 * time.Second,
+	})
+	assert.Equal(t, 2*time.Minute+processingTimeoutStallGrace, watchdog.stallThreshold("PayloadMessage"))
+	assert.Equal(t, time.Minute, watchdog.stallThreshold("IAMRoleCredentialsMessage"))
+	assert.Equal(t, time.Minute, watchdog.stallThreshold("HeartbeatMessage"))
+
+	start := time.Now()
+	watchdog.begin("PayloadMessage")
+	_, _, ok := watchdog.stalled(start.Add(2 * time.Minute))
+	assert.False(t, ok, "Expected the processing timeout to be given time to nack the message")
+	_, _, ok = watchdog.stalled(start.Add(3 * time.Minute))
+	assert.True(t, ok)
+}
+
+func TestGoroutineStacksSizeBounded(t *testing.T) {
+	assert.LessOrEqual(t, len(goroutineStacks()), maxStackDumpSize+len("\n... truncated"))
+}
@@ -0,0 +1,73 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger/field"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/wsclient"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// nackReasonInstanceCredentialsNotRefreshed is the reason code used when the instance
+// credentials could not be refreshed as instructed by ACS.
+const nackReasonInstanceCredentialsNotRefreshed = "InstanceCredentialsNotRefreshed"
+
+// instanceCredentialsHandlerFunc returns the handler for messages instructing the agent to
+// refresh the credentials of its container instance.
+func instanceCredentialsHandlerFunc(acsClient wsclient.ClientServer, credentialsProvider *credentials.Credentials,
+	nacker *messageNacker, cluster, containerInstanceArn string) func(message *ecsacs.RefreshInstanceCredentialsMessage) {
+	return func(message *ecsacs.RefreshInstanceCredentialsMessage) {
+		// Retrieving credentials may block, so the refresh is done in the background
+		// to avoid holding up the handling of other messages.
+		go handleSingleRefreshInstanceCredentialsMessage(acsClient, credentialsProvider, nacker, cluster,
+			containerInstanceArn, message)
+	}
+}
+
+// handleSingleRefreshInstanceCredentialsMessage expires the instance credentials and retrieves
+// new ones. The message is acked if new credentials were retrieved and nacked otherwise, in
+// which case the agent keeps using the old credentials until they are refreshed again.
+func handleSingleRefreshInstanceCredentialsMessage(acsClient wsclient.ClientServer,
+	credentialsProvider *credentials.Credentials, nacker *messageNacker, cluster, containerInstanceArn string,
+	message *ecsacs.RefreshInstanceCredentialsMessage) {
+	messageID := aws.StringValue(message.MessageId)
+	logger.Info("Refreshing instance credentials as instructed by ACS", logger.Fields{
+		"messageID": messageID,
+	})
+
+	credentialsProvider.Expire()
+	if _, err := credentialsProvider.Get(); err != nil {
+		logger.Error("Unable to refresh instance credentials", logger.Fields{
+			"messageID": messageID,
+			field.Error: err,
+		})
+		nacker.nack(messageID, nackReasonInstanceCredentialsNotRefreshed, err.Error())
+		return
+	}
+
+	err := acsClient.MakeRequest(&ecsacs.AckRequest{
+		Cluster:           aws.String(cluster),
+		ContainerInstance: aws.String(containerInstanceArn),
+		MessageId:         message.MessageId,
+	})
+	if err != nil {
+		logger.Warn("Error acknowledging instance credentials refresh", logger.Fields{
+			"messageID": messageID,
+			field.Error: err,
+		})
+	}
+}
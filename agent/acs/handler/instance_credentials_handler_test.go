@@ -0,0 +1,124 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	mock_wsclient "github.com/aws/amazon-ecs-agent/ecs-agent/wsclient/mock"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const instanceCredentialsMessageId = "instanceCredentialsMessageId"
+
+// countingCredentialsProvider is a credentials provider that counts the number of times
+// credentials are retrieved from it.
+type countingCredentialsProvider struct {
+	retrieveCount int
+	err           error
+}
+
+func (p *countingCredentialsProvider) Retrieve() (credentials.Value, error) {
+	p.retrieveCount++
+	if p.err != nil {
+		return credentials.Value{}, p.err
+	}
+	return credentials.Value{AccessKeyID: "akid", SecretAccessKey: "secret"}, nil
+}
+
+func (p *countingCredentialsProvider) IsExpired() bool {
+	return false
+}
+
+func TestRefreshInstanceCredentialsMessage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+
+	provider := &countingCredentialsProvider{}
+	credentialsProvider := credentials.NewCredentials(provider)
+	_, err := credentialsProvider.Get()
+	assert.NoError(t, err)
+
+	mockWsClient.EXPECT().MakeRequest(&ecsacs.AckRequest{
+		Cluster:           aws.String(clusterName),
+		ContainerInstance: aws.String(containerInstanceArn),
+		MessageId:         aws.String(instanceCredentialsMessageId),
+	}).Return(nil)
+
+	handleSingleRefreshInstanceCredentialsMessage(mockWsClient, credentialsProvider,
+		newMessageNacker(mockWsClient, clusterName, containerInstanceArn), clusterName, containerInstanceArn,
+		&ecsacs.RefreshInstanceCredentialsMessage{
+			ClusterArn:           aws.String(clusterName),
+			ContainerInstanceArn: aws.String(containerInstanceArn),
+			MessageId:            aws.String(instanceCredentialsMessageId),
+		})
+	assert.Equal(t, 2, provider.retrieveCount, "credentials should have been retrieved again")
+}
+
+func TestRefreshInstanceCredentialsMessageRefreshFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+
+	provider := &countingCredentialsProvider{err: errors.New("no credentials")}
+	credentialsProvider := credentials.NewCredentials(provider)
+
+	mockWsClient.EXPECT().MakeRequest(&ecsacs.NackRequest{
+		Cluster:           aws.String(clusterName),
+		ContainerInstance: aws.String(containerInstanceArn),
+		MessageId:         aws.String(instanceCredentialsMessageId),
+		Reason:            aws.String(nackReasonInstanceCredentialsNotRefreshed + ": no credentials"),
+	}).Return(nil)
+
+	handleSingleRefreshInstanceCredentialsMessage(mockWsClient, credentialsProvider,
+		newMessageNacker(mockWsClient, clusterName, containerInstanceArn), clusterName, containerInstanceArn,
+		&ecsacs.RefreshInstanceCredentialsMessage{
+			MessageId: aws.String(instanceCredentialsMessageId),
+		})
+	assert.Equal(t, 1, provider.retrieveCount)
+}
+
+// Tests that the handler returns without waiting for the credentials to be refreshed, and
+// that the message is acked once they are.
+func TestInstanceCredentialsHandlerFunc(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+
+	provider := &countingCredentialsProvider{}
+	acked := make(chan struct{})
+	mockWsClient.EXPECT().MakeRequest(&ecsacs.AckRequest{
+		Cluster:           aws.String(clusterName),
+		ContainerInstance: aws.String(containerInstanceArn),
+		MessageId:         aws.String(instanceCredentialsMessageId),
+	}).Do(func(interface{}) { close(acked) }).Return(nil)
+
+	handler := instanceCredentialsHandlerFunc(mockWsClient, credentials.NewCredentials(provider),
+		newMessageNacker(mockWsClient, clusterName, containerInstanceArn), clusterName, containerInstanceArn)
+	handler(&ecsacs.RefreshInstanceCredentialsMessage{
+		MessageId: aws.String(instanceCredentialsMessageId),
+	})
+	<-acked
+	assert.Equal(t, 1, provider.retrieveCount)
+}
@@ -0,0 +1,112 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger/field"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/wsclient"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+const (
+	// maxNacksPerMessage bounds the number of nacks sent for the same message id, so that
+	// a message that keeps failing doesn't result in an endless nack/redelivery loop.
+	maxNacksPerMessage = 3
+	// maxNackedMessages bounds the number of message ids tracked by the messageNacker.
+	maxNackedMessages = 1024
+)
+
+// messageNacker sends negative acknowledgments for ACS messages that could not be
+// processed, so that ACS learns that the message was rejected and why.
+type messageNacker struct {
+	acsClient            wsclient.ClientServer
+	cluster              string
+	containerInstanceArn string
+
+	lock sync.Mutex
+	// nackCounts tracks the number of nacks sent per message id
+	nackCounts map[string]int
+	// nackedMessageIDs holds the tracked message ids in the order they were first nacked,
+	// and is used to evict the oldest ones once maxNackedMessages is reached
+	nackedMessageIDs []string
+}
+
+// newMessageNacker returns a new messageNacker object
+func newMessageNacker(acsClient wsclient.ClientServer, cluster, containerInstanceArn string) *messageNacker {
+	return &messageNacker{
+		acsClient:            acsClient,
+		cluster:              cluster,
+		containerInstanceArn: containerInstanceArn,
+		nackCounts:           make(map[string]int),
+	}
+}
+
+// nack sends a NackRequest for the message id, with a reason of the form "<reasonCode>: <details>".
+// Once maxNacksPerMessage nacks have been sent for a message id, further nacks for it are
+// suppressed and false is returned.
+func (nacker *messageNacker) nack(messageID, reasonCode, details string) bool {
+	if !nacker.recordNack(messageID) {
+		logger.Warn("Not nacking message again, nack limit reached", logger.Fields{
+			"messageID":  messageID,
+			"reasonCode": reasonCode,
+		})
+		return false
+	}
+
+	reason := fmt.Sprintf("%s: %s", reasonCode, details)
+	logger.Info("Nacking message", logger.Fields{
+		"messageID": messageID,
+		"reason":    reason,
+	})
+	err := nacker.acsClient.MakeRequest(&ecsacs.NackRequest{
+		Cluster:           aws.String(nacker.cluster),
+		ContainerInstance: aws.String(nacker.containerInstanceArn),
+		MessageId:         aws.String(messageID),
+		Reason:            aws.String(reason),
+	})
+	if err != nil {
+		logger.Warn("Error nack'ing request", logger.Fields{
+			"messageID": messageID,
+			field.Error: err,
+		})
+	}
+	return true
+}
+
+// recordNack increments the nack count for the message id, returning false if the
+// message id has already been nacked maxNacksPerMessage times.
+func (nacker *messageNacker) recordNack(messageID string) bool {
+	nacker.lock.Lock()
+	defer nacker.lock.Unlock()
+
+	count, ok := nacker.nackCounts[messageID]
+	if count >= maxNacksPerMessage {
+		return false
+	}
+	if !ok {
+		if len(nacker.nackedMessageIDs) >= maxNackedMessages {
+			oldest := nacker.nackedMessageIDs[0]
+			nacker.nackedMessageIDs = nacker.nackedMessageIDs[1:]
+			delete(nacker.nackCounts, oldest)
+		}
+		nacker.nackedMessageIDs = append(nacker.nackedMessageIDs, messageID)
+	}
+	nacker.nackCounts[messageID] = count + 1
+	return true
+}
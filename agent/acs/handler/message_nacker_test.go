@@ -0,0 +1,64 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	mock_wsclient "github.com/aws/amazon-ecs-agent/ecs-agent/wsclient/mock"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageNackerNack(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	nacker := newMessageNacker(mockWsClient, clusterName, containerInstanceArn)
+
+	mockWsClient.EXPECT().MakeRequest(&ecsacs.NackRequest{
+		Cluster:           aws.String(clusterName),
+		ContainerInstance: aws.String(containerInstanceArn),
+		MessageId:         aws.String(payloadMessageId),
+		Reason:            aws.String("TestReason: test details"),
+	}).Return(nil).Times(maxNacksPerMessage)
+
+	for i := 0; i < maxNacksPerMessage; i++ {
+		assert.True(t, nacker.nack(payloadMessageId, "TestReason", "test details"))
+	}
+	assert.False(t, nacker.nack(payloadMessageId, "TestReason", "test details"),
+		"message should not be nacked once the nack limit is reached")
+}
+
+func TestMessageNackerEvictsOldestMessages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	mockWsClient.EXPECT().MakeRequest(gomock.Any()).Return(nil).AnyTimes()
+	nacker := newMessageNacker(mockWsClient, clusterName, containerInstanceArn)
+
+	for i := 0; i < maxNackedMessages+1; i++ {
+		nacker.nack(fmt.Sprintf("message-%d", i), "TestReason", "test details")
+	}
+	assert.Len(t, nacker.nackCounts, maxNackedMessages)
+	assert.Len(t, nacker.nackedMessageIDs, maxNackedMessages)
+	assert.NotContains(t, nacker.nackCounts, "message-0")
+	assert.Contains(t, nacker.nackCounts, fmt.Sprintf("message-%d", maxNackedMessages))
+}
@@ -35,6 +35,47 @@ import (
 	"github.com/cihub/seelog"
 )
 
+const (
+	// nackReasonInvalidTask is the reason code used when a payload message contains a nil task.
+	nackReasonInvalidTask = "InvalidTask"
+	// nackReasonUnsupportedTask is the reason code used when a task in a payload message cannot be
+	// converted to a task the agent supports.
+	nackReasonUnsupportedTask = "UnsupportedTask"
+	// nackReasonInvalidNetworkInterface is the reason code used when an ENI of a task in a payload
+	// message is malformed.
+	nackReasonInvalidNetworkInterface = "InvalidNetworkInterface"
+	// nackReasonInvalidProxyConfiguration is the reason code used when the proxy configuration of a
+	// task in a payload message is malformed.
+	nackReasonInvalidProxyConfiguration = "InvalidProxyConfiguration"
+	// nackReasonCredentialsNotSet is the reason code used when the credentials of a task in a
+	// payload message could not be added to the credentials manager.
+	nackReasonCredentialsNotSet = "CredentialsNotSet"
+	// nackReasonCredentialsNotAcked is the reason code used when the credentials of a task in a
+	// payload message could not be acknowledged.
+	nackReasonCredentialsNotAcked = "CredentialsNotAcked"
+	// nackReasonTaskNotPersisted is the reason code used when a task in a payload message could not
+	// be saved to the data client.
+	nackReasonTaskNotPersisted = "TaskNotPersisted"
+	// nackReasonProcessingPaused is the reason code used when a payload message was received
+	// while processing is paused and the payload message buffer is full.
+	nackReasonProcessingPaused = "ProcessingPaused"
+)
+
+// payloadRejection describes why a task in a payload message could not be handled. It is sent to
+// ACS as the reason of the nack for the payload message.
+type payloadRejection struct {
+	reasonCode string
+	details    string
+}
+
+// newPayloadRejection returns a payloadRejection for the task with the given reason code and error.
+func newPayloadRejection(reasonCode string, taskARN string, err error) *payloadRejection {
+	return &payloadRejection{
+		reasonCode: reasonCode,
+		details:    fmt.Sprintf("task %s: %v", taskARN, err),
+	}
+}
+
 // payloadRequestHandler represents the payload operation for the ACS client
 type payloadRequestHandler struct {
 	// messageBuffer is used to process PayloadMessages received from the server
@@ -54,6 +95,13 @@ type payloadRequestHandler struct {
 	refreshHandler              refreshCredentialsHandler
 	credentialsManager          credentials.Manager
 	latestSeqNumberTaskManifest *int64
+	// processingPauser is used to hold payload messages while processing is paused
+	processingPauser *ProcessingPauser
+	// ackAfterTaskPersisted indicates that new tasks must be saved to the data client
+	// before they are added to the task engine and the payload is acked
+	ackAfterTaskPersisted bool
+	// nacker is used to send nacks for payload messages that could not be handled
+	nacker *messageNacker
 }
 
 // newPayloadRequestHandler returns a new payloadRequestHandler object
@@ -67,7 +115,9 @@ func newPayloadRequestHandler(
 	dataClient data.Client,
 	refreshHandler refreshCredentialsHandler,
 	credentialsManager credentials.Manager,
-	taskHandler *eventhandler.TaskHandler, seqNumTaskManifest *int64) payloadRequestHandler {
+	taskHandler *eventhandler.TaskHandler, seqNumTaskManifest *int64,
+	processingPauser *ProcessingPauser,
+	ackAfterTaskPersisted bool) payloadRequestHandler {
 	// Create a cancelable context from the parent context
 	derivedContext, cancel := context.WithCancel(ctx)
 	return payloadRequestHandler{
@@ -85,6 +135,9 @@ func newPayloadRequestHandler(
 		refreshHandler:              refreshHandler,
 		credentialsManager:          credentialsManager,
 		latestSeqNumberTaskManifest: seqNumTaskManifest,
+		processingPauser:            processingPauser,
+		ackAfterTaskPersisted:       ackAfterTaskPersisted,
+		nacker:                      newMessageNacker(acsClient, cluster, containerInstanceArn),
 	}
 }
 
@@ -92,7 +145,28 @@ func newPayloadRequestHandler(
 func (payloadHandler *payloadRequestHandler) handlerFunc() func(payload *ecsacs.PayloadMessage) {
 	// return a function that just enqueues PayloadMessages into the message buffer
 	return func(payload *ecsacs.PayloadMessage) {
-		payloadHandler.messageBuffer <- payload
+		if payloadHandler.processingPauser.IsPaused() {
+			// Don't block reading from the connection while paused; payload messages that
+			// don't fit in the buffer are nacked, so that ACS redelivers them
+			select {
+			case payloadHandler.messageBuffer <- payload:
+				return
+			default:
+			}
+			messageID := aws.StringValue(payload.MessageId)
+			if payloadHandler.nacker.nack(messageID, nackReasonProcessingPaused,
+				fmt.Sprintf("payload processing is paused and %d payload messages are held", payloadMessageBufferSize)) {
+				return
+			}
+			// ACS keeps redelivering the message; wait for room in the buffer rather than
+			// dropping it
+			seelog.Warnf("ACS payload processing is paused and the payload buffer is full, waiting for room for payload message id: %s",
+				messageID)
+		}
+		select {
+		case payloadHandler.messageBuffer <- payload:
+		case <-payloadHandler.ctx.Done():
+		}
 	}
 }
 
@@ -155,6 +229,10 @@ func (payloadHandler *payloadRequestHandler) handleMessages() {
 	for {
 		select {
 		case payload := <-payloadHandler.messageBuffer:
+			// Hold on to the payload message for as long as processing is paused
+			if !payloadHandler.processingPauser.waitUntilResumed(payloadHandler.ctx) {
+				return
+			}
 			payloadHandler.handleSingleMessage(payload)
 		case <-payloadHandler.ctx.Done():
 			return
@@ -171,7 +249,7 @@ func (payloadHandler *payloadRequestHandler) handleSingleMessage(payload *ecsacs
 		return fmt.Errorf("received a payload with no message id")
 	}
 	seelog.Debugf("Received payload message, message id: %s", aws.StringValue(payload.MessageId))
-	credentialsAcks, allTasksHandled := payloadHandler.addPayloadTasks(payload)
+	credentialsAcks, rejection := payloadHandler.addPayloadTasks(payload)
 
 	// Update latestSeqNumberTaskManifest for it to get updated in state file
 	if payloadHandler.latestSeqNumberTaskManifest != nil && payload.SeqNum != nil &&
@@ -180,7 +258,8 @@ func (payloadHandler *payloadRequestHandler) handleSingleMessage(payload *ecsacs
 		*payloadHandler.latestSeqNumberTaskManifest = *payload.SeqNum
 	}
 
-	if !allTasksHandled {
+	if rejection != nil {
+		payloadHandler.rejectPayload(aws.StringValue(payload.MessageId), rejection)
 		return fmt.Errorf("did not handle all tasks")
 	}
 
@@ -195,24 +274,36 @@ func (payloadHandler *payloadRequestHandler) handleSingleMessage(payload *ecsacs
 	return nil
 }
 
+// rejectPayload sends a nack for the payload message with the reason code and details of the rejection
+func (payloadHandler *payloadRequestHandler) rejectPayload(messageID string, rejection *payloadRejection) {
+	payloadHandler.nacker.nack(messageID, rejection.reasonCode, rejection.details)
+}
+
 // addPayloadTasks does validation on each task and, for all valid ones, adds
-// it to the task engine. It returns a bool indicating if it could add every
-// task to the taskEngine and a slice of credential ack requests
-func (payloadHandler *payloadRequestHandler) addPayloadTasks(payload *ecsacs.PayloadMessage) ([]*ecsacs.IAMRoleCredentialsAckRequest, bool) {
+// it to the task engine. It returns a slice of credential ack requests and, if
+// it could not add every task to the taskEngine, the first rejection encountered
+func (payloadHandler *payloadRequestHandler) addPayloadTasks(payload *ecsacs.PayloadMessage) ([]*ecsacs.IAMRoleCredentialsAckRequest, *payloadRejection) {
 	// verify that we were able to work with all tasks in this payload so we know whether to ack the whole thing or not
-	allTasksOK := true
+	var rejection *payloadRejection
+	rejectTask := func(task *ecsacs.Task, reasonCode string, err error) {
+		payloadHandler.handleUnrecognizedTask(task, err, payload)
+		if rejection == nil {
+			rejection = newPayloadRejection(reasonCode, aws.StringValue(task.Arn), err)
+		}
+	}
 
 	validTasks := make([]*apitask.Task, 0, len(payload.Tasks))
 	for _, task := range payload.Tasks {
 		if task == nil {
 			seelog.Criticalf("Received nil task for messageId: %s", aws.StringValue(payload.MessageId))
-			allTasksOK = false
+			if rejection == nil {
+				rejection = &payloadRejection{reasonCode: nackReasonInvalidTask, details: "received nil task"}
+			}
 			continue
 		}
 		apiTask, err := apitask.TaskFromACS(task, payload)
 		if err != nil {
-			payloadHandler.handleUnrecognizedTask(task, err, payload)
-			allTasksOK = false
+			rejectTask(task, nackReasonUnsupportedTask, err)
 			continue
 		}
 
@@ -233,8 +324,7 @@ func (payloadHandler *payloadRequestHandler) addPayloadTasks(payload *ecsacs.Pay
 					IAMRoleCredentials: taskIAMRoleCredentials,
 				}))
 			if err != nil {
-				payloadHandler.handleUnrecognizedTask(task, err, payload)
-				allTasksOK = false
+				rejectTask(task, nackReasonCredentialsNotSet, err)
 				continue
 			}
 			apiTask.SetCredentialsID(taskIAMRoleCredentials.CredentialsID)
@@ -244,8 +334,7 @@ func (payloadHandler *payloadRequestHandler) addPayloadTasks(payload *ecsacs.Pay
 		for _, acsENI := range task.ElasticNetworkInterfaces {
 			eni, err := apieni.ENIFromACS(acsENI)
 			if err != nil {
-				payloadHandler.handleUnrecognizedTask(task, err, payload)
-				allTasksOK = false
+				rejectTask(task, nackReasonInvalidNetworkInterface, err)
 				continue
 			}
 			apiTask.AddTaskENI(eni)
@@ -255,8 +344,7 @@ func (payloadHandler *payloadRequestHandler) addPayloadTasks(payload *ecsacs.Pay
 		if task.ProxyConfiguration != nil {
 			appmesh, err := apiappmesh.AppMeshFromACS(task.ProxyConfiguration)
 			if err != nil {
-				payloadHandler.handleUnrecognizedTask(task, err, payload)
-				allTasksOK = false
+				rejectTask(task, nackReasonInvalidProxyConfiguration, err)
 				continue
 			}
 			apiTask.SetAppMesh(appmesh)
@@ -273,8 +361,7 @@ func (payloadHandler *payloadRequestHandler) addPayloadTasks(payload *ecsacs.Pay
 					IAMRoleCredentials: taskExecutionIAMRoleCredentials,
 				}))
 			if err != nil {
-				payloadHandler.handleUnrecognizedTask(task, err, payload)
-				allTasksOK = false
+				rejectTask(task, nackReasonCredentialsNotSet, err)
 				continue
 			}
 			apiTask.SetExecutionRoleCredentialsID(taskExecutionIAMRoleCredentials.CredentialsID)
@@ -287,42 +374,62 @@ func (payloadHandler *payloadRequestHandler) addPayloadTasks(payload *ecsacs.Pay
 	// Because a 'start' sequence number should only be proceeded if all 'stop's
 	// of the same sequence number have completed, the 'start' events need to be
 	// added after the 'stop' events are there to block them.
-	stoppedTasksCredentialsAcks, stoppedTasksAddedOK := payloadHandler.addTasks(payload, validTasks, isTaskStatusNotStopped)
-	newTasksCredentialsAcks, newTasksAddedOK := payloadHandler.addTasks(payload, validTasks, isTaskStatusStopped)
-	if !stoppedTasksAddedOK || !newTasksAddedOK {
-		allTasksOK = false
+	stoppedTasksCredentialsAcks, stoppedTasksRejection := payloadHandler.addTasks(payload, validTasks, isTaskStatusNotStopped)
+	newTasksCredentialsAcks, newTasksRejection := payloadHandler.addTasks(payload, validTasks, isTaskStatusStopped)
+	if rejection == nil {
+		rejection = stoppedTasksRejection
+	}
+	if rejection == nil {
+		rejection = newTasksRejection
 	}
 
 	// Construct a slice with credentials acks from all tasks
 	credentialsAcks := append(stoppedTasksCredentialsAcks, newTasksCredentialsAcks...)
-	return credentialsAcks, allTasksOK
+	return credentialsAcks, rejection
 }
 
 // addTasks adds the tasks to the task engine based on the skipAddTask condition
-// This is used to add non-stopped tasks before adding stopped tasks
-func (payloadHandler *payloadRequestHandler) addTasks(payload *ecsacs.PayloadMessage, tasks []*apitask.Task, skipAddTask skipAddTaskComparatorFunc) ([]*ecsacs.IAMRoleCredentialsAckRequest, bool) {
-	allTasksOK := true
+// This is used to add non-stopped tasks before adding stopped tasks. If not every
+// task could be handled, the first rejection encountered is returned
+func (payloadHandler *payloadRequestHandler) addTasks(payload *ecsacs.PayloadMessage, tasks []*apitask.Task, skipAddTask skipAddTaskComparatorFunc) ([]*ecsacs.IAMRoleCredentialsAckRequest, *payloadRejection) {
+	var rejection *payloadRejection
+	rejectTask := func(task *apitask.Task, reasonCode string, err error) {
+		if rejection == nil {
+			rejection = newPayloadRejection(reasonCode, task.Arn, err)
+		}
+	}
 	var credentialsAcks []*ecsacs.IAMRoleCredentialsAckRequest
 	for _, task := range tasks {
 		if skipAddTask(task.GetDesiredStatus()) {
 			continue
 		}
-		payloadHandler.taskEngine.AddTask(task)
 		// Only need to save task to DB when its desired status is RUNNING (i.e. this is a new task that we are going
 		// to manage). When its desired status is STOPPED, the task is already in the DB and the desired status change
 		// will be saved by task manager.
-		if task.GetDesiredStatus() == apitaskstatus.TaskRunning {
+		saveTask := task.GetDesiredStatus() == apitaskstatus.TaskRunning
+		if saveTask && payloadHandler.ackAfterTaskPersisted {
+			// Persist the task before it's handed to the task engine. If this fails the task is
+			// neither started nor acked, so that ACS redelivers it.
+			if err := payloadHandler.dataClient.SaveTask(task); err != nil {
+				seelog.Errorf("Failed to save data for task %s, not adding it to the task engine: %v", task.Arn, err)
+				rejectTask(task, nackReasonTaskNotPersisted, err)
+				continue
+			}
+			saveTask = false
+		}
+		payloadHandler.taskEngine.AddTask(task)
+		if saveTask {
 			err := payloadHandler.dataClient.SaveTask(task)
 			if err != nil {
 				seelog.Errorf("Failed to save data for task %s: %v", task.Arn, err)
-				allTasksOK = false
+				rejectTask(task, nackReasonTaskNotPersisted, err)
 			}
 		}
 
 		ackCredentials := func(id string, description string) {
 			ack, err := payloadHandler.ackCredentials(payload.MessageId, id)
 			if err != nil {
-				allTasksOK = false
+				rejectTask(task, nackReasonCredentialsNotAcked, err)
 				seelog.Errorf("Failed to acknowledge %s credentials for task: %s, err: %v", description, task.String(), err)
 				return
 			}
@@ -341,7 +448,7 @@ func (payloadHandler *payloadRequestHandler) addTasks(payload *ecsacs.PayloadMes
 			ackCredentials(taskExecutionCredentialsID, "task execution role")
 		}
 	}
-	return credentialsAcks, allTasksOK
+	return credentialsAcks, rejection
 }
 
 func (payloadHandler *payloadRequestHandler) ackCredentials(messageID *string, credentialsID string) (*ecsacs.IAMRoleCredentialsAckRequest, error) {
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -84,7 +85,7 @@ func setup(t *testing.T) *testHelper {
 		data.NewNoopClient(),
 		refreshCredentialsHandler{},
 		credentialsManager,
-		taskHandler, &latestSeqNumberTaskManifest)
+		taskHandler, &latestSeqNumberTaskManifest, nil, false)
 
 	return &testHelper{
 		ctrl:               ctrl,
@@ -197,6 +198,8 @@ func TestHandlePayloadMessageSaveDataError(t *testing.T) {
 	}).Times(1)
 
 	tester.payloadHandler.dataClient = dataClient
+	// The payload is nacked since the task could not be handled.
+	tester.mockWsClient.EXPECT().MakeRequest(gomock.AssignableToTypeOf(&ecsacs.NackRequest{})).Times(1)
 
 	// Check if handleSingleMessage returns an error when we get error saving task data.
 	err := tester.payloadHandler.handleSingleMessage(&ecsacs.PayloadMessage{
@@ -221,6 +224,123 @@ func TestHandlePayloadMessageSaveDataError(t *testing.T) {
 	assert.Equal(t, expectedTask, addedTask, "added task is not expected")
 }
 
+// TestHandlePayloadMessageNackedWhenTasksNotHandled tests that a payload message whose tasks
+// could not all be handled is nacked with a reason, and that repeated deliveries of the same
+// message are nacked a bounded number of times.
+func TestHandlePayloadMessageNackedWhenTasksNotHandled(t *testing.T) {
+	tester := setup(t)
+	defer tester.ctrl.Finish()
+
+	tester.payloadHandler.dataClient = newTestDataClient(t)
+	tester.mockTaskEngine.EXPECT().AddTask(gomock.Any()).Times(maxNacksPerMessage + 1)
+
+	var nackRequests []*ecsacs.NackRequest
+	tester.mockWsClient.EXPECT().MakeRequest(gomock.Any()).Do(func(nackRequest *ecsacs.NackRequest) {
+		nackRequests = append(nackRequests, nackRequest)
+	}).Times(maxNacksPerMessage)
+
+	// Redeliver the same payload more times than it may be nacked.
+	for i := 0; i < maxNacksPerMessage+1; i++ {
+		err := tester.payloadHandler.handleSingleMessage(&ecsacs.PayloadMessage{
+			Tasks: []*ecsacs.Task{
+				{
+					Arn:           aws.String("t1"), // Use an invalid task arn to trigger error on saving task.
+					DesiredStatus: aws.String("RUNNING"),
+				},
+			},
+			MessageId: aws.String(payloadMessageId),
+		})
+		assert.Error(t, err)
+	}
+
+	require.Len(t, nackRequests, maxNacksPerMessage)
+	for _, nackRequest := range nackRequests {
+		assert.Equal(t, payloadMessageId, aws.StringValue(nackRequest.MessageId))
+		assert.Equal(t, clusterName, aws.StringValue(nackRequest.Cluster))
+		assert.Equal(t, containerInstanceArn, aws.StringValue(nackRequest.ContainerInstance))
+		assert.True(t, strings.HasPrefix(aws.StringValue(nackRequest.Reason), nackReasonTaskNotPersisted+": task t1: "),
+			"unexpected nack reason: %s", aws.StringValue(nackRequest.Reason))
+	}
+}
+
+// TestHandlePayloadMessageNackReasons tests that a payload message that is rejected is nacked
+// with a reason code describing why its task could not be handled.
+func TestHandlePayloadMessageNackReasons(t *testing.T) {
+	testCases := []struct {
+		name               string
+		task               *ecsacs.Task
+		expectedReasonCode string
+	}{
+		{
+			name:               "nil task",
+			expectedReasonCode: nackReasonInvalidTask,
+		},
+		{
+			name: "malformed eni",
+			task: &ecsacs.Task{
+				Arn:           aws.String("arn"),
+				DesiredStatus: aws.String("RUNNING"),
+				ElasticNetworkInterfaces: []*ecsacs.ElasticNetworkInterface{
+					{
+						Ec2Id:      aws.String("eni-id"),
+						MacAddress: aws.String("mac"),
+					},
+				},
+			},
+			expectedReasonCode: nackReasonInvalidNetworkInterface,
+		},
+		{
+			name: "unsupported proxy type",
+			task: &ecsacs.Task{
+				Arn:           aws.String("arn"),
+				DesiredStatus: aws.String("RUNNING"),
+				ProxyConfiguration: &ecsacs.ProxyConfiguration{
+					Type: aws.String("UNSUPPORTED"),
+				},
+			},
+			expectedReasonCode: nackReasonInvalidProxyConfiguration,
+		},
+		{
+			name: "task not persisted",
+			task: &ecsacs.Task{
+				Arn:           aws.String("t1"), // Use an invalid task arn to trigger error on saving task.
+				DesiredStatus: aws.String("RUNNING"),
+			},
+			expectedReasonCode: nackReasonTaskNotPersisted,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tester := setup(t)
+			defer tester.ctrl.Finish()
+
+			tester.payloadHandler.dataClient = newTestDataClient(t)
+			mockECSClient := mock_api.NewMockECSClient(tester.ctrl)
+			mockECSClient.EXPECT().SubmitTaskStateChange(gomock.Any()).AnyTimes()
+			tester.payloadHandler.ecsClient = mockECSClient
+			tester.payloadHandler.taskHandler = eventhandler.NewTaskHandler(tester.ctx, data.NewNoopClient(),
+				dockerstate.NewTaskEngineState(), mockECSClient)
+			tester.mockTaskEngine.EXPECT().AddTask(gomock.Any()).AnyTimes()
+
+			var nackRequest *ecsacs.NackRequest
+			tester.mockWsClient.EXPECT().MakeRequest(gomock.Any()).Do(func(request *ecsacs.NackRequest) {
+				nackRequest = request
+			}).Times(1)
+
+			err := tester.payloadHandler.handleSingleMessage(&ecsacs.PayloadMessage{
+				Tasks:     []*ecsacs.Task{tc.task},
+				MessageId: aws.String(payloadMessageId),
+			})
+			assert.Error(t, err)
+			require.NotNil(t, nackRequest)
+			assert.Equal(t, payloadMessageId, aws.StringValue(nackRequest.MessageId))
+			assert.True(t, strings.HasPrefix(aws.StringValue(nackRequest.Reason), tc.expectedReasonCode+": "),
+				"unexpected nack reason: %s", aws.StringValue(nackRequest.Reason))
+		})
+	}
+}
+
 func newTestDataClient(t *testing.T) data.Client {
 	testDir := t.TempDir()
 
@@ -309,7 +429,7 @@ func TestHandlePayloadMessageCredentialsAckedWhenTaskAdded(t *testing.T) {
 		}),
 	)
 
-	refreshCredsHandler := newRefreshCredentialsHandler(tester.ctx, clusterName, containerInstanceArn, tester.mockWsClient, tester.credentialsManager, tester.mockTaskEngine)
+	refreshCredsHandler := newRefreshCredentialsHandler(tester.ctx, clusterName, containerInstanceArn, tester.mockWsClient, tester.credentialsManager, tester.mockTaskEngine, 1)
 	defer refreshCredsHandler.clearAcks()
 	refreshCredsHandler.start()
 	tester.payloadHandler.refreshHandler = refreshCredsHandler
@@ -400,8 +520,8 @@ func TestAddPayloadTaskAddsNonStoppedTasksAfterStoppedTasks(t *testing.T) {
 		MessageId: aws.String(payloadMessageId),
 	}
 
-	_, ok := tester.payloadHandler.addPayloadTasks(payloadMessage)
-	assert.True(t, ok)
+	_, rejection := tester.payloadHandler.addPayloadTasks(payloadMessage)
+	assert.Nil(t, rejection)
 	assert.Len(t, tasksAddedToEngine, 2)
 
 	// Verify if stopped task is added before running task
@@ -459,6 +579,169 @@ func TestPayloadBufferHandler(t *testing.T) {
 	assert.Equal(t, expectedTask, addedTask, "received task is not expected")
 }
 
+// blockingSaveTaskClient is a data client whose SaveTask calls block until released
+type blockingSaveTaskClient struct {
+	data.Client
+	saveStarted chan struct{}
+	release     chan struct{}
+	saved       chan struct{}
+}
+
+func (c *blockingSaveTaskClient) SaveTask(task *apitask.Task) error {
+	close(c.saveStarted)
+	<-c.release
+	close(c.saved)
+	return nil
+}
+
+// TestPayloadHandlerAckAfterTaskPersisted tests that when acking after persistence is
+// enabled, the task is saved before being added to the task engine and the payload
+// is only acked once the save has completed
+func TestPayloadHandlerAckAfterTaskPersisted(t *testing.T) {
+	tester := setup(t)
+	defer tester.ctrl.Finish()
+
+	dataClient := &blockingSaveTaskClient{
+		Client:      data.NewNoopClient(),
+		saveStarted: make(chan struct{}),
+		release:     make(chan struct{}),
+		saved:       make(chan struct{}),
+	}
+	tester.payloadHandler.dataClient = dataClient
+	tester.payloadHandler.ackAfterTaskPersisted = true
+
+	tester.mockTaskEngine.EXPECT().AddTask(gomock.Any()).Do(func(task *apitask.Task) {
+		select {
+		case <-dataClient.saved:
+		default:
+			t.Error("task was added to the task engine before it was saved")
+		}
+	}).Times(1)
+	var ackRequested *ecsacs.AckRequest
+	tester.mockWsClient.EXPECT().MakeRequest(gomock.Any()).Do(func(ackRequest *ecsacs.AckRequest) {
+		select {
+		case <-dataClient.saved:
+		default:
+			t.Error("payload was acked before the task was saved")
+		}
+		ackRequested = ackRequest
+		tester.cancel()
+	}).Times(1)
+
+	go tester.payloadHandler.start()
+	tester.payloadHandler.handlerFunc()(&ecsacs.PayloadMessage{
+		Tasks: []*ecsacs.Task{
+			{
+				Arn:           aws.String("t1"),
+				DesiredStatus: aws.String("RUNNING"),
+			},
+		},
+		MessageId: aws.String(payloadMessageId),
+	})
+
+	<-dataClient.saveStarted
+	// Give the handler a chance to (incorrectly) proceed while the save is in flight
+	time.Sleep(50 * time.Millisecond)
+	close(dataClient.release)
+
+	<-tester.ctx.Done()
+	assert.Equal(t, payloadMessageId, aws.StringValue(ackRequested.MessageId))
+}
+
+// TestPayloadHandlerProcessingPaused tests that payload messages received while processing
+// is paused are not processed until processing is resumed
+func TestPayloadHandlerProcessingPaused(t *testing.T) {
+	tester := setup(t)
+	defer tester.ctrl.Finish()
+
+	pauser := NewProcessingPauser()
+	tester.payloadHandler.processingPauser = pauser
+	pauser.Pause()
+
+	taskAdded := make(chan struct{})
+	tester.mockTaskEngine.EXPECT().AddTask(gomock.Any()).Do(func(task *apitask.Task) {
+		close(taskAdded)
+	}).Times(1)
+	var ackRequested *ecsacs.AckRequest
+	tester.mockWsClient.EXPECT().MakeRequest(gomock.Any()).Do(func(ackRequest *ecsacs.AckRequest) {
+		ackRequested = ackRequest
+		tester.cancel()
+	}).Times(1)
+
+	go tester.payloadHandler.start()
+	tester.payloadHandler.handlerFunc()(&ecsacs.PayloadMessage{
+		Tasks: []*ecsacs.Task{
+			{
+				Arn: aws.String("t1"),
+			},
+		},
+		MessageId: aws.String(payloadMessageId),
+	})
+
+	select {
+	case <-taskAdded:
+		t.Fatal("payload message was processed while processing was paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	pauser.Resume()
+	<-taskAdded
+	<-tester.ctx.Done()
+	assert.Equal(t, payloadMessageId, aws.StringValue(ackRequested.MessageId))
+}
+
+// TestPayloadHandlerProcessingPausedBufferFull tests that payload messages received when
+// processing is paused and the buffer is full are nacked rather than blocking the connection,
+// and that they are processed once redelivered after processing is resumed
+func TestPayloadHandlerProcessingPausedBufferFull(t *testing.T) {
+	tester := setup(t)
+	defer tester.ctrl.Finish()
+	defer tester.cancel()
+
+	pauser := NewProcessingPauser()
+	tester.payloadHandler.processingPauser = pauser
+	pauser.Pause()
+
+	overflowMessageID := fmt.Sprintf("%d", payloadMessageBufferSize)
+	requests := make(chan interface{}, payloadMessageBufferSize+2)
+	tester.mockWsClient.EXPECT().MakeRequest(gomock.Any()).Do(func(request interface{}) {
+		requests <- request
+	}).Return(nil).Times(payloadMessageBufferSize + 2)
+
+	go tester.payloadHandler.start()
+	for i := 0; i < payloadMessageBufferSize+1; i++ {
+		tester.payloadHandler.handlerFunc()(&ecsacs.PayloadMessage{
+			MessageId: aws.String(fmt.Sprintf("%d", i)),
+		})
+	}
+
+	// The message received once the buffer was full is nacked, and nothing is acked
+	nack, ok := (<-requests).(*ecsacs.NackRequest)
+	require.True(t, ok, "expected the message received once the buffer was full to be nacked")
+	assert.Equal(t, overflowMessageID, aws.StringValue(nack.MessageId))
+	assert.Contains(t, aws.StringValue(nack.Reason), nackReasonProcessingPaused)
+	select {
+	case request := <-requests:
+		t.Fatalf("unexpected request while processing was paused: %v", request)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Once resumed, the held messages and the redelivered message are processed
+	pauser.Resume()
+	tester.payloadHandler.handlerFunc()(&ecsacs.PayloadMessage{
+		MessageId: aws.String(overflowMessageID),
+	})
+	acked := make(map[string]bool)
+	for i := 0; i < payloadMessageBufferSize+1; i++ {
+		ack, ok := (<-requests).(*ecsacs.AckRequest)
+		require.True(t, ok, "expected the payload message to be acked")
+		acked[aws.StringValue(ack.MessageId)] = true
+	}
+	for i := 0; i < payloadMessageBufferSize+1; i++ {
+		assert.True(t, acked[fmt.Sprintf("%d", i)], "expected message %d to be acked", i)
+	}
+}
+
 // TestPayloadBufferHandlerWithCredentials tests if the async payloadBufferHandler routine
 // acks the payload message and credentials after adding tasks
 func TestPayloadBufferHandlerWithCredentials(t *testing.T) {
@@ -498,7 +781,7 @@ func TestPayloadBufferHandlerWithCredentials(t *testing.T) {
 		}),
 	)
 
-	refreshCredsHandler := newRefreshCredentialsHandler(tester.ctx, clusterName, containerInstanceArn, tester.mockWsClient, tester.credentialsManager, tester.mockTaskEngine)
+	refreshCredsHandler := newRefreshCredentialsHandler(tester.ctx, clusterName, containerInstanceArn, tester.mockWsClient, tester.credentialsManager, tester.mockTaskEngine, 1)
 	defer refreshCredsHandler.clearAcks()
 	refreshCredsHandler.start()
 	tester.payloadHandler.refreshHandler = refreshCredsHandler
@@ -620,7 +903,7 @@ func TestAddPayloadTaskAddsExecutionRoles(t *testing.T) {
 			tester.cancel()
 		}),
 	)
-	refreshCredsHandler := newRefreshCredentialsHandler(tester.ctx, clusterName, containerInstanceArn, tester.mockWsClient, tester.credentialsManager, tester.mockTaskEngine)
+	refreshCredsHandler := newRefreshCredentialsHandler(tester.ctx, clusterName, containerInstanceArn, tester.mockWsClient, tester.credentialsManager, tester.mockTaskEngine, 1)
 	defer refreshCredsHandler.clearAcks()
 	refreshCredsHandler.start()
 
@@ -0,0 +1,105 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cihub/seelog"
+)
+
+// ProcessingPauser allows processing of ACS payload messages to be paused and
+// resumed at runtime. While processing is paused the session stays connected to
+// ACS and keeps handling heartbeats, but payload messages are held in the payload
+// message buffer. Payload messages received once the buffer is full are nacked, so
+// that ACS redelivers them.
+//
+// A nil *ProcessingPauser is valid and never pauses processing.
+type ProcessingPauser struct {
+	lock    sync.RWMutex
+	paused  bool
+	resumed chan struct{}
+}
+
+// NewProcessingPauser creates a new ProcessingPauser with processing enabled.
+func NewProcessingPauser() *ProcessingPauser {
+	return &ProcessingPauser{}
+}
+
+// Pause pauses processing of ACS payload messages. Payload messages received while
+// processing is paused are buffered, and once the buffer is full they are nacked, to be
+// redelivered by ACS.
+func (p *ProcessingPauser) Pause() {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.paused {
+		return
+	}
+	seelog.Info("Pausing processing of ACS payload messages")
+	p.paused = true
+	p.resumed = make(chan struct{})
+}
+
+// Resume resumes processing of ACS payload messages.
+func (p *ProcessingPauser) Resume() {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if !p.paused {
+		return
+	}
+	seelog.Info("Resuming processing of ACS payload messages")
+	p.paused = false
+	close(p.resumed)
+}
+
+// IsPaused returns true if processing of ACS payload messages is paused.
+func (p *ProcessingPauser) IsPaused() bool {
+	if p == nil {
+		return false
+	}
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.paused
+}
+
+// waitUntilResumed blocks for as long as processing is paused. It returns false
+// if the context was cancelled before processing was resumed.
+func (p *ProcessingPauser) waitUntilResumed(ctx context.Context) bool {
+	if p == nil {
+		return true
+	}
+	p.lock.RLock()
+	paused, resumed := p.paused, p.resumed
+	p.lock.RUnlock()
+
+	if !paused {
+		return true
+	}
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,49 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNilProcessingPauserNeverPauses(t *testing.T) {
+	var pauser *ProcessingPauser
+
+	pauser.Pause()
+	assert.False(t, pauser.IsPaused())
+	assert.True(t, pauser.waitUntilResumed(context.Background()))
+	pauser.Resume()
+	assert.False(t, pauser.IsPaused())
+}
+
+func TestProcessingPauser(t *testing.T) {
+	pauser := NewProcessingPauser()
+	assert.False(t, pauser.IsPaused())
+
+	pauser.Pause()
+	assert.True(t, pauser.IsPaused())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, pauser.waitUntilResumed(ctx), "expected waiting to end when the context is cancelled")
+
+	pauser.Resume()
+	assert.False(t, pauser.IsPaused())
+	assert.True(t, pauser.waitUntilResumed(context.Background()))
+}
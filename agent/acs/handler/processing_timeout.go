@@ -0,0 +1,147 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/wsclient"
+)
+
+const (
+	// nackReasonProcessingTimeout is the reason code used when a message was not
+	// handled within its processing timeout.
+	nackReasonProcessingTimeout = "ProcessingTimeout"
+	// nackReasonTooManyTimedOutHandlers is the reason code used when a message was
+	// not handled because too many handlers for its type have timed out and are
+	// still running.
+	nackReasonTooManyTimedOutHandlers = "TooManyTimedOutHandlers"
+
+	// maxTimedOutHandlersPerType bounds the number of timed out handlers of a message type
+	// that can keep running in the background, so that a handler that hangs for good
+	// can't leak a goroutine for every message of its type.
+	maxTimedOutHandlersPerType = 10
+)
+
+// withProcessingTimeout wraps an ACS request handler so that, if handling a message takes
+// longer than the processing timeout configured for its type, the message is nacked with
+// a timeout reason, a metric is emitted and the client moves on to the next message. The
+// timed out handler keeps running in the background. While maxTimedOutHandlersPerType of
+// them are still running, further messages of the type are nacked without being handled.
+// Handlers for message types without a timeout are returned as is.
+func withProcessingTimeout(handler wsclient.RequestHandler, timeouts map[string]time.Duration,
+	nacker *messageNacker, metricsFactory metrics.EntryFactory) wsclient.RequestHandler {
+	handlerValue := reflect.ValueOf(handler)
+	messageType := handlerValue.Type().In(0).Elem().Name()
+	timeout, ok := timeouts[messageType]
+	if !ok || timeout <= 0 {
+		return handler
+	}
+	if metricsFactory == nil {
+		metricsFactory = metrics.NewNopEntryFactory()
+	}
+
+	// timedOutHandlers is the number of timed out handlers that are still running
+	var timedOutHandlers int64
+	return reflect.MakeFunc(handlerValue.Type(), func(args []reflect.Value) []reflect.Value {
+		messageID := messageIDOf(args[0])
+		if running := atomic.LoadInt64(&timedOutHandlers); running >= maxTimedOutHandlersPerType {
+			logger.Error("Too many timed out ACS message handlers still running, not handling message", logger.Fields{
+				"messageType":      messageType,
+				"messageID":        messageID,
+				"timedOutHandlers": running,
+			})
+			if messageID != "" {
+				nacker.nack(messageID, nackReasonTooManyTimedOutHandlers,
+					fmt.Sprintf("%d %s handlers timed out and are still running", running, messageType))
+			}
+			return nil
+		}
+
+		var (
+			lock     sync.Mutex
+			finished bool
+			timedOut bool
+		)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			handlerValue.Call(args)
+
+			lock.Lock()
+			defer lock.Unlock()
+			finished = true
+			if timedOut {
+				atomic.AddInt64(&timedOutHandlers, -1)
+				logger.Info("Timed out ACS message handler finished", logger.Fields{
+					"messageType": messageType,
+					"messageID":   messageID,
+				})
+			}
+		}()
+
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-done:
+			return nil
+		case <-timer.C:
+		}
+
+		lock.Lock()
+		if finished {
+			// The handler finished just as the timeout expired
+			lock.Unlock()
+			return nil
+		}
+		timedOut = true
+		atomic.AddInt64(&timedOutHandlers, 1)
+		lock.Unlock()
+
+		logger.Warn("Timed out handling ACS message, moving on to the next message", logger.Fields{
+			"messageType": messageType,
+			"messageID":   messageID,
+			"timeout":     timeout.String(),
+		})
+		metricsFactory.New(metrics.ACSMessageProcessingTimeoutMetricName).
+			WithFields(map[string]interface{}{"messageType": messageType}).
+			WithCount(1).
+			Done(nil)()
+		if messageID != "" {
+			nacker.nack(messageID, nackReasonProcessingTimeout,
+				fmt.Sprintf("%s not processed within %s", messageType, timeout))
+		}
+		return nil
+	}).Interface()
+}
+
+// messageIDOf returns the value of the MessageId field of an ACS message, or an
+// empty string if the message doesn't have one.
+func messageIDOf(message reflect.Value) string {
+	if message.Kind() != reflect.Ptr || message.IsNil() {
+		return ""
+	}
+	messageID := message.Elem().FieldByName("MessageId")
+	if !messageID.IsValid() || messageID.Kind() != reflect.Ptr || messageID.IsNil() ||
+		messageID.Elem().Kind() != reflect.String {
+		return ""
+	}
+	return messageID.Elem().String()
+}
@@ -0,0 +1,166 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
+	mock_metrics "github.com/aws/amazon-ecs-agent/ecs-agent/metrics/mocks"
+	mock_wsclient "github.com/aws/amazon-ecs-agent/ecs-agent/wsclient/mock"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithProcessingTimeoutNacksSlowHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	nacker := newMessageNacker(mockWsClient, clusterName, containerInstanceArn)
+	metricsFactory := mock_metrics.NewMockEntryFactory(ctrl)
+	entry := mock_metrics.NewMockEntry(ctrl)
+	gomock.InOrder(
+		metricsFactory.EXPECT().New(metrics.ACSMessageProcessingTimeoutMetricName).Return(entry),
+		entry.EXPECT().WithFields(map[string]interface{}{"messageType": "PayloadMessage"}).Return(entry),
+		entry.EXPECT().WithCount(1).Return(entry),
+		entry.EXPECT().Done(nil).Return(func() {}),
+	)
+
+	nackSent := make(chan *ecsacs.NackRequest, 1)
+	mockWsClient.EXPECT().MakeRequest(gomock.Any()).Do(func(nackRequest *ecsacs.NackRequest) {
+		nackSent <- nackRequest
+	}).Times(1)
+
+	unblock := make(chan struct{})
+	defer close(unblock)
+	var handled []string
+	slowHandler := func(payload *ecsacs.PayloadMessage) {
+		if aws.StringValue(payload.MessageId) == "slow" {
+			<-unblock
+			return
+		}
+		handled = append(handled, aws.StringValue(payload.MessageId))
+	}
+	handler, ok := withProcessingTimeout(slowHandler, map[string]time.Duration{
+		"PayloadMessage": 10 * time.Millisecond,
+	}, nacker, metricsFactory).(func(*ecsacs.PayloadMessage))
+	require.True(t, ok, "wrapped handler should keep the handler type")
+
+	start := time.Now()
+	handler(&ecsacs.PayloadMessage{MessageId: aws.String("slow")})
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+
+	select {
+	case nackRequest := <-nackSent:
+		assert.Equal(t, "slow", aws.StringValue(nackRequest.MessageId))
+		assert.Equal(t, nackReasonProcessingTimeout+": PayloadMessage not processed within 10ms",
+			aws.StringValue(nackRequest.Reason))
+	default:
+		t.Fatal("expected slow message to be nacked after the timeout")
+	}
+
+	// Processing continues with the next message while the slow handler is still blocked.
+	handler(&ecsacs.PayloadMessage{MessageId: aws.String("fast")})
+	assert.Equal(t, []string{"fast"}, handled)
+}
+
+// Tests that once maxTimedOutHandlersPerType handlers have timed out and are still running,
+// further messages are nacked without being handled, and that they are handled again once
+// the timed out handlers finish.
+func TestWithProcessingTimeoutTimedOutHandlersLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	nacker := newMessageNacker(mockWsClient, clusterName, containerInstanceArn)
+
+	var nackLock sync.Mutex
+	nackReasons := make(map[string]string)
+	mockWsClient.EXPECT().MakeRequest(gomock.Any()).Do(func(nackRequest *ecsacs.NackRequest) {
+		nackLock.Lock()
+		defer nackLock.Unlock()
+		nackReasons[aws.StringValue(nackRequest.MessageId)] = aws.StringValue(nackRequest.Reason)
+	}).AnyTimes()
+
+	unblock := make(chan struct{})
+	var hanging sync.WaitGroup
+	handled := make(chan string, 1)
+	handler := withProcessingTimeout(func(payload *ecsacs.PayloadMessage) {
+		if strings.HasPrefix(aws.StringValue(payload.MessageId), "hang") {
+			defer hanging.Done()
+			<-unblock
+			return
+		}
+		handled <- aws.StringValue(payload.MessageId)
+	}, map[string]time.Duration{
+		"PayloadMessage": time.Millisecond,
+	}, nacker, nil).(func(*ecsacs.PayloadMessage))
+
+	hanging.Add(maxTimedOutHandlersPerType)
+	for i := 0; i < maxTimedOutHandlersPerType; i++ {
+		handler(&ecsacs.PayloadMessage{MessageId: aws.String(fmt.Sprintf("hang-%d", i))})
+	}
+	handler(&ecsacs.PayloadMessage{MessageId: aws.String("guarded")})
+	select {
+	case messageID := <-handled:
+		t.Fatalf("message %s should not have been handled", messageID)
+	default:
+	}
+
+	nackLock.Lock()
+	assert.Len(t, nackReasons, maxTimedOutHandlersPerType+1)
+	assert.True(t, strings.HasPrefix(nackReasons["hang-0"], nackReasonProcessingTimeout+":"))
+	assert.Equal(t, fmt.Sprintf("%s: %d PayloadMessage handlers timed out and are still running",
+		nackReasonTooManyTimedOutHandlers, maxTimedOutHandlersPerType), nackReasons["guarded"])
+	nackLock.Unlock()
+
+	// Once the hanging handlers finish, messages are handled again
+	close(unblock)
+	hanging.Wait()
+	require.Eventually(t, func() bool {
+		handler(&ecsacs.PayloadMessage{MessageId: aws.String("after")})
+		select {
+		case messageID := <-handled:
+			return messageID == "after"
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestWithProcessingTimeoutNoTimeoutForType(t *testing.T) {
+	handler := func(message *ecsacs.HeartbeatMessage) {}
+	wrapped := withProcessingTimeout(handler, map[string]time.Duration{
+		"PayloadMessage": time.Second,
+	}, nil, nil)
+	assert.Equal(t, reflect.ValueOf(handler).Pointer(), reflect.ValueOf(wrapped).Pointer(),
+		"handlers for message types without a timeout should not be wrapped")
+}
+
+func TestMessageIDOf(t *testing.T) {
+	assert.Equal(t, "mid", messageIDOf(reflect.ValueOf(&ecsacs.PayloadMessage{MessageId: aws.String("mid")})))
+	assert.Equal(t, "", messageIDOf(reflect.ValueOf(&ecsacs.PayloadMessage{})))
+	assert.Equal(t, "", messageIDOf(reflect.ValueOf((*ecsacs.PayloadMessage)(nil))))
+	assert.Equal(t, "", messageIDOf(reflect.ValueOf(&ecsacs.CloseMessage{})))
+}
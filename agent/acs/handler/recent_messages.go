@@ -0,0 +1,171 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultRecentMessagesCapacity is the number of most recent ACS messages kept for debugging.
+	DefaultRecentMessagesCapacity = 50
+
+	recentMessagesFilePrefix     = "acs-messages-"
+	recentMessagesFileTimeFormat = "20060102T150405Z"
+	recentMessagesFileMode       = 0600
+	redactedValue                = "REDACTED"
+)
+
+// sensitiveMessageFields are the (lower cased) fields of ACS messages whose values are
+// redacted before the messages are kept in RecentMessages.
+var sensitiveMessageFields = map[string]struct{}{
+	"accesskeyid":     {},
+	"secretaccesskey": {},
+	"sessiontoken":    {},
+	"environment":     {},
+	"dockerconfig":    {},
+}
+
+// RecentMessage is a redacted ACS message received by the session.
+type RecentMessage struct {
+	ReceivedAt time.Time       `json:"receivedAt"`
+	Type       string          `json:"type"`
+	Message    json.RawMessage `json:"message"`
+}
+
+// RecentMessages is a fixed size ring buffer of the most recent messages received from
+// ACS. Messages are redacted as they are recorded, so that credentials and container
+// environment never end up in a debug dump.
+//
+// A nil *RecentMessages is valid and records nothing.
+type RecentMessages struct {
+	lock     sync.Mutex
+	messages []RecentMessage
+	next     int
+	full     bool
+}
+
+// NewRecentMessages creates a new RecentMessages keeping up to capacity messages.
+func NewRecentMessages(capacity int) *RecentMessages {
+	return &RecentMessages{
+		messages: make([]RecentMessage, capacity),
+	}
+}
+
+// Record redacts the message and adds it to the buffer, evicting the oldest message
+// if the buffer is full.
+func (r *RecentMessages) Record(message interface{}) {
+	if r == nil || len(r.messages) == 0 {
+		return
+	}
+	recent := RecentMessage{
+		ReceivedAt: time.Now().UTC(),
+		Type:       messageTypeName(message),
+		Message:    redactMessage(message),
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.messages[r.next] = recent
+	r.next = (r.next + 1) % len(r.messages)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Messages returns the recorded messages, oldest first.
+func (r *RecentMessages) Messages() []RecentMessage {
+	if r == nil {
+		return nil
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.full {
+		return append([]RecentMessage{}, r.messages[:r.next]...)
+	}
+	return append(append([]RecentMessage{}, r.messages[r.next:]...), r.messages[:r.next]...)
+}
+
+// Dump writes the recorded messages to a timestamped file in dir and returns the path
+// of the file.
+func (r *RecentMessages) Dump(dir string) (string, error) {
+	data, err := json.MarshalIndent(r.Messages(), "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "unable to marshal recent ACS messages")
+	}
+	path := filepath.Join(dir,
+		recentMessagesFilePrefix+time.Now().UTC().Format(recentMessagesFileTimeFormat)+".json")
+	if err := os.WriteFile(path, data, recentMessagesFileMode); err != nil {
+		return "", errors.Wrapf(err, "unable to write recent ACS messages to %s", path)
+	}
+	return path, nil
+}
+
+// messageTypeName returns the name of the message's type, without the pointer and package.
+func messageTypeName(message interface{}) string {
+	t := reflect.TypeOf(message)
+	if t == nil {
+		return ""
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// redactMessage returns the JSON representation of the message with the values of all
+// sensitive fields replaced, at any depth.
+func redactMessage(message interface{}) json.RawMessage {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return json.RawMessage(fmt.Sprintf("%q", "unable to marshal message: "+err.Error()))
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return json.RawMessage(fmt.Sprintf("%q", "unable to unmarshal message: "+err.Error()))
+	}
+	redacted, err := json.Marshal(redactValue(decoded))
+	if err != nil {
+		return json.RawMessage(fmt.Sprintf("%q", "unable to marshal redacted message: "+err.Error()))
+	}
+	return redacted
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if _, ok := sensitiveMessageFields[strings.ToLower(key)]; ok && field != nil {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = redactValue(field)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = redactValue(elem)
+		}
+	}
+	return value
+}
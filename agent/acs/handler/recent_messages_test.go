@@ -0,0 +1,95 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentMessagesKeepsMostRecent(t *testing.T) {
+	recentMessages := NewRecentMessages(2)
+	for _, id := range []string{"msg1", "msg2", "msg3"} {
+		recentMessages.Record(&ecsacs.HeartbeatMessage{MessageId: aws.String(id)})
+	}
+
+	messages := recentMessages.Messages()
+	require.Len(t, messages, 2)
+	assert.Equal(t, "HeartbeatMessage", messages[0].Type)
+	assert.Contains(t, string(messages[0].Message), "msg2")
+	assert.Contains(t, string(messages[1].Message), "msg3")
+}
+
+func TestRecentMessagesNil(t *testing.T) {
+	var recentMessages *RecentMessages
+	recentMessages.Record(&ecsacs.HeartbeatMessage{})
+	assert.Empty(t, recentMessages.Messages())
+}
+
+func TestRecentMessagesDumpIsRedacted(t *testing.T) {
+	const (
+		testAccessKeyID     = "test-access-key-id"
+		testSecretAccessKey = "test-secret-access-key"
+		testSessionToken    = "test-session-token"
+		testEnvValue        = "test-env-value"
+	)
+	recentMessages := NewRecentMessages(DefaultRecentMessagesCapacity)
+	recentMessages.Record(&ecsacs.IAMRoleCredentialsMessage{
+		MessageId: aws.String("credentials-msg"),
+		TaskArn:   aws.String("task-arn"),
+		RoleCredentials: &ecsacs.IAMRoleCredentials{
+			CredentialsId:   aws.String("credentials-id"),
+			AccessKeyId:     aws.String(testAccessKeyID),
+			SecretAccessKey: aws.String(testSecretAccessKey),
+			SessionToken:    aws.String(testSessionToken),
+		},
+	})
+	recentMessages.Record(&ecsacs.PayloadMessage{
+		MessageId: aws.String("payload-msg"),
+		Tasks: []*ecsacs.Task{{
+			Arn: aws.String("task-arn"),
+			Containers: []*ecsacs.Container{{
+				Name:        aws.String("container"),
+				Environment: map[string]*string{"SECRET": aws.String(testEnvValue)},
+			}},
+		}},
+	})
+
+	dir := t.TempDir()
+	path, err := recentMessages.Dump(dir)
+	require.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(path))
+	assert.True(t, strings.HasPrefix(filepath.Base(path), recentMessagesFilePrefix))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	dump := string(data)
+	assert.Contains(t, dump, "credentials-msg")
+	assert.Contains(t, dump, "credentials-id")
+	assert.Contains(t, dump, "payload-msg")
+	assert.Contains(t, dump, redactedValue)
+	for _, secret := range []string{testAccessKeyID, testSecretAccessKey, testSessionToken, testEnvValue} {
+		assert.NotContains(t, dump, secret)
+	}
+}
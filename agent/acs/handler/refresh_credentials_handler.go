@@ -15,10 +15,15 @@ package handler
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
+	"time"
 
 	"github.com/aws/amazon-ecs-agent/agent/engine"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/credentials"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger/field"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/wsclient"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/cihub/seelog"
@@ -26,6 +31,15 @@ import (
 	"github.com/pkg/errors"
 )
 
+const (
+	// refreshWorkerBufferSize is the number of refresh credentials messages that can be
+	// queued for each refresh worker
+	refreshWorkerBufferSize = 10
+	// prematureRefreshRemainingLifetimeFraction is the fraction of the lifetime of refreshed credentials
+	// that the credentials they replace must still have remaining for the refresh to be reported as premature
+	prematureRefreshRemainingLifetimeFraction = 0.5
+)
+
 var (
 	// For ease of unit testing
 	checkAndSetDomainlessGMSATaskExecutionRoleCredentialsImpl = checkAndSetDomainlessGMSATaskExecutionRoleCredentials
@@ -45,12 +59,25 @@ type refreshCredentialsHandler struct {
 	acsClient          wsclient.ClientServer
 	credentialsManager credentials.Manager
 	taskEngine         engine.TaskEngine
+	// workers are used to apply refresh credentials messages concurrently. Messages for
+	// a task are always dispatched to the same worker so that they are applied in order
+	workers []chan *ecsacs.IAMRoleCredentialsMessage
+	// metricsFactory is used to report refreshes that replace credentials long before they expire
+	metricsFactory metrics.EntryFactory
 }
 
-// newRefreshCredentialsHandler returns a new refreshCredentialsHandler object
-func newRefreshCredentialsHandler(ctx context.Context, cluster string, containerInstanceArn string, acsClient wsclient.ClientServer, credentialsManager credentials.Manager, taskEngine engine.TaskEngine) refreshCredentialsHandler {
+// newRefreshCredentialsHandler returns a new refreshCredentialsHandler object that applies
+// at most maxConcurrentRefreshes refresh credentials messages concurrently
+func newRefreshCredentialsHandler(ctx context.Context, cluster string, containerInstanceArn string, acsClient wsclient.ClientServer, credentialsManager credentials.Manager, taskEngine engine.TaskEngine, maxConcurrentRefreshes int) refreshCredentialsHandler {
 	// Create a cancelable context from the parent context
 	derivedContext, cancel := context.WithCancel(ctx)
+	if maxConcurrentRefreshes < 1 {
+		maxConcurrentRefreshes = 1
+	}
+	workers := make([]chan *ecsacs.IAMRoleCredentialsMessage, maxConcurrentRefreshes)
+	for i := range workers {
+		workers[i] = make(chan *ecsacs.IAMRoleCredentialsMessage, refreshWorkerBufferSize)
+	}
 	return refreshCredentialsHandler{
 		messageBuffer:      make(chan *ecsacs.IAMRoleCredentialsMessage),
 		ackRequest:         make(chan *ecsacs.IAMRoleCredentialsAckRequest),
@@ -61,6 +88,8 @@ func newRefreshCredentialsHandler(ctx context.Context, cluster string, container
 		acsClient:          acsClient,
 		credentialsManager: credentialsManager,
 		taskEngine:         taskEngine,
+		workers:            workers,
+		metricsFactory:     metrics.NewNopEntryFactory(),
 	}
 }
 
@@ -73,9 +102,13 @@ func (refreshHandler *refreshCredentialsHandler) handlerFunc() func(message *ecs
 }
 
 // start invokes go routines to:
-// 1. handle messages in the refresh credentials message buffer
-// 2. handle ack requests to be sent to ACS
+// 1. dispatch messages in the refresh credentials message buffer to the refresh workers
+// 2. apply the messages dispatched to each refresh worker
+// 3. handle ack requests to be sent to ACS
 func (refreshHandler *refreshCredentialsHandler) start() {
+	for _, worker := range refreshHandler.workers {
+		go refreshHandler.handleWorkerMessages(worker)
+	}
 	go refreshHandler.handleMessages()
 	go refreshHandler.sendAcks()
 }
@@ -119,11 +152,28 @@ func (refreshHandler *refreshCredentialsHandler) ackMessage(ack *ecsacs.IAMRoleC
 	seelog.Debugf("Acking credentials message: %s", ack.String())
 }
 
-// handleMessages processes refresh credentials messages in the buffer in-order
+// handleMessages dispatches refresh credentials messages in the buffer to the refresh
+// workers, in-order
 func (refreshHandler *refreshCredentialsHandler) handleMessages() {
 	for {
 		select {
 		case message := <-refreshHandler.messageBuffer:
+			select {
+			case refreshHandler.workerFor(message) <- message:
+			case <-refreshHandler.ctx.Done():
+				return
+			}
+		case <-refreshHandler.ctx.Done():
+			return
+		}
+	}
+}
+
+// handleWorkerMessages processes refresh credentials messages dispatched to a refresh worker in-order
+func (refreshHandler *refreshCredentialsHandler) handleWorkerMessages(worker <-chan *ecsacs.IAMRoleCredentialsMessage) {
+	for {
+		select {
+		case message := <-worker:
 			refreshHandler.handleSingleMessage(message)
 		case <-refreshHandler.ctx.Done():
 			return
@@ -131,6 +181,16 @@ func (refreshHandler *refreshCredentialsHandler) handleMessages() {
 	}
 }
 
+// workerFor returns the refresh worker responsible for the task in the refresh credentials message
+func (refreshHandler *refreshCredentialsHandler) workerFor(message *ecsacs.IAMRoleCredentialsMessage) chan *ecsacs.IAMRoleCredentialsMessage {
+	if message == nil || len(refreshHandler.workers) == 1 {
+		return refreshHandler.workers[0]
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(aws.StringValue(message.TaskArn)))
+	return refreshHandler.workers[hash.Sum32()%uint32(len(refreshHandler.workers))]
+}
+
 // handleSingleMessage processes a single refresh credentials message.
 func (refreshHandler *refreshCredentialsHandler) handleSingleMessage(message *ecsacs.IAMRoleCredentialsMessage) error {
 	// Validate fields in the message
@@ -152,6 +212,7 @@ func (refreshHandler *refreshCredentialsHandler) handleSingleMessage(message *ec
 		seelog.Errorf("Unknown RoleType for task in credentials message, roleType: %s arn: %s, messageId: %s", roleType, taskArn, messageId)
 	} else {
 		iamRoleCredentials := credentials.IAMRoleCredentialsFromACS(message.RoleCredentials, roleType)
+		refreshHandler.checkPrematureRefresh(task.GetCredentialsID(), task.GetExecutionCredentialsID(), iamRoleCredentials, taskArn)
 		err = refreshHandler.credentialsManager.SetTaskCredentials(
 			&(credentials.TaskIAMRoleCredentials{
 				ARN:                taskArn,
@@ -187,6 +248,53 @@ func (refreshHandler *refreshCredentialsHandler) handleSingleMessage(message *ec
 	return nil
 }
 
+// checkPrematureRefresh logs and reports a metric when the refreshed credentials replace credentials of the
+// same role type that still have a substantial part of their lifetime remaining, which can indicate an issue
+// with the backend. Refreshes whose expiration times can't be parsed are not checked.
+func (refreshHandler *refreshCredentialsHandler) checkPrematureRefresh(credentialsID string,
+	executionCredentialsID string, refreshed credentials.IAMRoleCredentials, taskArn string) {
+	previousCredentialsID := credentialsID
+	if refreshed.RoleType == credentials.ExecutionRoleType {
+		previousCredentialsID = executionCredentialsID
+	}
+	if previousCredentialsID == "" || previousCredentialsID == refreshed.CredentialsID {
+		return
+	}
+	previous, ok := refreshHandler.credentialsManager.GetTaskCredentials(previousCredentialsID)
+	if !ok {
+		return
+	}
+	previousExpiration, err := time.Parse(time.RFC3339, previous.IAMRoleCredentials.Expiration)
+	if err != nil {
+		return
+	}
+	refreshedExpiration, err := time.Parse(time.RFC3339, refreshed.Expiration)
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	previousRemaining := previousExpiration.Sub(now)
+	refreshedLifetime := refreshedExpiration.Sub(now)
+	if refreshedLifetime <= 0 ||
+		float64(previousRemaining) <= prematureRefreshRemainingLifetimeFraction*float64(refreshedLifetime) {
+		return
+	}
+	logger.Warn("Credentials refresh replaced credentials long before they expire", logger.Fields{
+		field.TaskARN:               taskArn,
+		"roleType":                  refreshed.RoleType,
+		"previousCredentialsID":     previousCredentialsID,
+		"previousExpiration":        previous.IAMRoleCredentials.Expiration,
+		"previousRemainingLifetime": previousRemaining.String(),
+		"refreshedCredentialsID":    refreshed.CredentialsID,
+		"refreshedExpiration":       refreshed.Expiration,
+	})
+	refreshHandler.metricsFactory.New(metrics.ACSPrematureCredentialsRefreshMetricName).
+		WithFields(map[string]interface{}{"roleType": refreshed.RoleType}).
+		WithCount(1).
+		Done(nil)()
+}
+
 // validateIAMRoleCredentialsMessage validates fields in the IAMRoleCredentialsMessage
 // It returns an error if any of the following fields are not set in the message:
 // messageId, taskArn, roleCredentials
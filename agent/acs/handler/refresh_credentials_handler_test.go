@@ -28,6 +28,8 @@ import (
 	mock_engine "github.com/aws/amazon-ecs-agent/agent/engine/mocks"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/credentials"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
+	mock_metrics "github.com/aws/amazon-ecs-agent/ecs-agent/metrics/mocks"
 	mock_wsclient "github.com/aws/amazon-ecs-agent/ecs-agent/wsclient/mock"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -176,7 +178,7 @@ func TestInvalidCredentialsMessageNotAcked(t *testing.T) {
 	credentialsManager := credentials.NewManager()
 
 	ctx, cancel := context.WithCancel(context.Background())
-	handler := newRefreshCredentialsHandler(ctx, cluster, containerInstance, nil, credentialsManager, nil)
+	handler := newRefreshCredentialsHandler(ctx, cluster, containerInstance, nil, credentialsManager, nil, 1)
 
 	// Start a goroutine to listen for acks. Cancelling the context stops the goroutine
 	go func() {
@@ -213,7 +215,7 @@ func TestCredentialsMessageNotAckedWhenTaskNotFound(t *testing.T) {
 	taskEngine.EXPECT().GetTaskByArn(taskArn).Return(nil, false)
 
 	ctx, cancel := context.WithCancel(context.Background())
-	handler := newRefreshCredentialsHandler(ctx, cluster, containerInstance, nil, credentialsManager, taskEngine)
+	handler := newRefreshCredentialsHandler(ctx, cluster, containerInstance, nil, credentialsManager, taskEngine, 1)
 
 	// Start a goroutine to listen for acks. Cancelling the context stops the goroutine
 	go func() {
@@ -283,7 +285,7 @@ func TestHandleRefreshMessageAckedWhenCredentialsUpdated(t *testing.T) {
 				checkAndSetDomainlessGMSATaskExecutionRoleCredentialsImpl = checkAndSetDomainlessGMSATaskExecutionRoleCredentials
 			}()
 
-			handler := newRefreshCredentialsHandler(ctx, clusterName, containerInstanceArn, mockWsClient, credentialsManager, taskEngine)
+			handler := newRefreshCredentialsHandler(ctx, clusterName, containerInstanceArn, mockWsClient, credentialsManager, taskEngine, 1)
 			go handler.sendAcks()
 
 			// test adding a credentials message without the MessageId field
@@ -356,7 +358,7 @@ func TestCredentialsMessageNotAckedWhenDomainlessGMSACredentialsError(t *testing
 			}()
 
 			ctx, cancel := context.WithCancel(context.Background())
-			handler := newRefreshCredentialsHandler(ctx, cluster, containerInstance, nil, credentialsManager, taskEngine)
+			handler := newRefreshCredentialsHandler(ctx, cluster, containerInstance, nil, credentialsManager, taskEngine, 1)
 
 			// Start a goroutine to listen for acks. Cancelling the context stops the goroutine
 			go func() {
@@ -389,7 +391,7 @@ func TestRefreshCredentialsHandlerSendPendingAcks(t *testing.T) {
 	mockWSClient.EXPECT().MakeRequest(gomock.Any()).Return(nil).Times(1)
 
 	handler := newRefreshCredentialsHandler(ctx, clusterName, containerInstanceArn, mockWSClient,
-		credentialsManager, taskEngine)
+		credentialsManager, taskEngine, 1)
 
 	wg := sync.WaitGroup{}
 	wg.Add(2)
@@ -436,7 +438,7 @@ func TestRefreshCredentialsHandler(t *testing.T) {
 	// Return a task from the engine for GetTaskByArn
 	taskEngine.EXPECT().GetTaskByArn(taskArn).Return(&apitask.Task{}, true)
 
-	handler := newRefreshCredentialsHandler(ctx, clusterName, containerInstanceArn, mockWsClient, credentialsManager, taskEngine)
+	handler := newRefreshCredentialsHandler(ctx, clusterName, containerInstanceArn, mockWsClient, credentialsManager, taskEngine, 1)
 	go handler.start()
 
 	handler.messageBuffer <- message
@@ -457,3 +459,173 @@ func TestRefreshCredentialsHandler(t *testing.T) {
 		t.Errorf("Mismatch between expected credentials and credentials for task. Expected: %v, got: %v", expectedCredentials, creds)
 	}
 }
+
+// concurrencyTrackingCredentialsManager records the number of concurrent SetTaskCredentials
+// calls and the order in which credentials are set for each task
+type concurrencyTrackingCredentialsManager struct {
+	credentials.Manager
+	lock           sync.Mutex
+	inFlight       int
+	maxInFlight    int
+	credentialsIDs map[string][]string
+}
+
+func (m *concurrencyTrackingCredentialsManager) SetTaskCredentials(taskCredentials *credentials.TaskIAMRoleCredentials) error {
+	m.lock.Lock()
+	m.inFlight++
+	if m.inFlight > m.maxInFlight {
+		m.maxInFlight = m.inFlight
+	}
+	m.lock.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.inFlight--
+	m.credentialsIDs[taskCredentials.ARN] = append(m.credentialsIDs[taskCredentials.ARN],
+		taskCredentials.IAMRoleCredentials.CredentialsID)
+	return m.Manager.SetTaskCredentials(taskCredentials)
+}
+
+// TestRefreshCredentialsConcurrencyBounded tests that a burst of refresh credentials messages
+// is applied by at most the configured number of workers, in order for each task
+func TestRefreshCredentialsConcurrencyBounded(t *testing.T) {
+	const (
+		maxConcurrentRefreshes = 3
+		numTasks               = 12
+		refreshesPerTask       = 4
+	)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	credentialsManager := &concurrencyTrackingCredentialsManager{
+		Manager:        credentials.NewManager(),
+		credentialsIDs: make(map[string][]string),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	acks := make(chan *ecsacs.IAMRoleCredentialsAckRequest, numTasks*refreshesPerTask)
+	mockWsClient := mock_wsclient.NewMockClientServer(ctrl)
+	mockWsClient.EXPECT().MakeRequest(gomock.Any()).Do(func(ackRequest *ecsacs.IAMRoleCredentialsAckRequest) {
+		acks <- ackRequest
+	}).Return(nil).Times(numTasks * refreshesPerTask)
+
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
+	taskEngine.EXPECT().GetTaskByArn(gomock.Any()).DoAndReturn(func(arn string) (*apitask.Task, bool) {
+		return &apitask.Task{Arn: arn}, true
+	}).Times(numTasks * refreshesPerTask)
+
+	handler := newRefreshCredentialsHandler(ctx, clusterName, containerInstanceArn, mockWsClient,
+		credentialsManager, taskEngine, maxConcurrentRefreshes)
+	handler.start()
+
+	for i := 0; i < refreshesPerTask; i++ {
+		for j := 0; j < numTasks; j++ {
+			handler.handlerFunc()(&ecsacs.IAMRoleCredentialsMessage{
+				MessageId: aws.String(fmt.Sprintf("message-%d-%d", j, i)),
+				TaskArn:   aws.String(fmt.Sprintf("task-%d", j)),
+				RoleType:  aws.String(credentials.ApplicationRoleType),
+				RoleCredentials: &ecsacs.IAMRoleCredentials{
+					CredentialsId: aws.String(fmt.Sprintf("credentials-%d-%d", j, i)),
+					Expiration:    aws.String(expiration),
+				},
+			})
+		}
+	}
+
+	for i := 0; i < numTasks*refreshesPerTask; i++ {
+		select {
+		case <-acks:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("Timed out waiting for credentials refreshes to be acked, got %d acks", i)
+		}
+	}
+
+	credentialsManager.lock.Lock()
+	defer credentialsManager.lock.Unlock()
+	assert.LessOrEqual(t, credentialsManager.maxInFlight, maxConcurrentRefreshes)
+	assert.Len(t, credentialsManager.credentialsIDs, numTasks)
+	for j := 0; j < numTasks; j++ {
+		var expectedCredentialsIDs []string
+		for i := 0; i < refreshesPerTask; i++ {
+			expectedCredentialsIDs = append(expectedCredentialsIDs, fmt.Sprintf("credentials-%d-%d", j, i))
+		}
+		assert.Equal(t, expectedCredentialsIDs, credentialsManager.credentialsIDs[fmt.Sprintf("task-%d", j)])
+	}
+}
+
+// TestPrematureCredentialsRefreshReported tests that a metric is reported when a credentials message
+// replaces credentials that still have a substantial part of their lifetime remaining
+func TestPrematureCredentialsRefreshReported(t *testing.T) {
+	testCases := []struct {
+		name                   string
+		previousExpiresIn      time.Duration
+		expectPrematureRefresh bool
+	}{
+		{
+			name:                   "PrematureRefresh",
+			previousExpiresIn:      5 * time.Hour,
+			expectPrematureRefresh: true,
+		},
+		{
+			name:                   "RefreshCloseToExpiry",
+			previousExpiresIn:      10 * time.Minute,
+			expectPrematureRefresh: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			now := time.Now()
+			credentialsManager := credentials.NewManager()
+			err := credentialsManager.SetTaskCredentials(&credentials.TaskIAMRoleCredentials{
+				ARN: taskArn,
+				IAMRoleCredentials: credentials.IAMRoleCredentials{
+					CredentialsID: "previouscredsid",
+					Expiration:    now.Add(tc.previousExpiresIn).UTC().Format(time.RFC3339),
+					RoleType:      credentials.ApplicationRoleType,
+				},
+			})
+			assert.NoError(t, err)
+
+			task := &apitask.Task{Arn: taskArn}
+			task.SetCredentialsID("previouscredsid")
+			taskEngine := mock_engine.NewMockTaskEngine(ctrl)
+			taskEngine.EXPECT().GetTaskByArn(taskArn).Return(task, true)
+
+			metricsFactory := mock_metrics.NewMockEntryFactory(ctrl)
+			if tc.expectPrematureRefresh {
+				entry := mock_metrics.NewMockEntry(ctrl)
+				gomock.InOrder(
+					metricsFactory.EXPECT().New(metrics.ACSPrematureCredentialsRefreshMetricName).Return(entry),
+					entry.EXPECT().WithFields(map[string]interface{}{"roleType": credentials.ApplicationRoleType}).Return(entry),
+					entry.EXPECT().WithCount(1).Return(entry),
+					entry.EXPECT().Done(nil).Return(func() {}),
+				)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			handler := newRefreshCredentialsHandler(ctx, cluster, containerInstance, nil, credentialsManager, taskEngine, 1)
+			handler.metricsFactory = metricsFactory
+
+			refreshMessage := &ecsacs.IAMRoleCredentialsMessage{
+				MessageId: aws.String(messageId),
+				TaskArn:   aws.String(taskArn),
+				RoleType:  aws.String(credentials.ApplicationRoleType),
+				RoleCredentials: &ecsacs.IAMRoleCredentials{
+					CredentialsId: aws.String(credentialsId),
+					Expiration:    aws.String(now.Add(6 * time.Hour).UTC().Format(time.RFC3339)),
+				},
+			}
+			assert.NoError(t, handler.handleSingleMessage(refreshMessage))
+			<-handler.ackRequest
+			assert.Equal(t, credentialsId, task.GetCredentialsID())
+		})
+	}
+}
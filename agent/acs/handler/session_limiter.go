@@ -0,0 +1,84 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cihub/seelog"
+)
+
+// sessionLimiter counts the active ACS sessions of each container instance, so
+// that a session waits to connect while the limit is reached. This prevents
+// accidentally connecting to ACS more than once for the same container instance.
+type sessionLimiter struct {
+	// sessions maps a container instance ARN to its number of active sessions
+	sessions map[string]int
+	// released is closed, and replaced, whenever a session is released, to wake up
+	// the sessions waiting to be acquired
+	released chan struct{}
+	lock     sync.Mutex
+}
+
+func newSessionLimiter() *sessionLimiter {
+	return &sessionLimiter{
+		sessions: make(map[string]int),
+		released: make(chan struct{}),
+	}
+}
+
+// acquire registers a new active session for the container instance. If the container
+// instance already has maxSessions active sessions, it waits until one of them is released,
+// or returns the context's error if the context is done first. A single session is allowed
+// when maxSessions is not positive.
+func (limiter *sessionLimiter) acquire(ctx context.Context, containerInstanceARN string, maxSessions int) error {
+	if maxSessions <= 0 {
+		maxSessions = 1
+	}
+	for logged := false; ; logged = true {
+		limiter.lock.Lock()
+		active := limiter.sessions[containerInstanceARN]
+		if active < maxSessions {
+			limiter.sessions[containerInstanceARN]++
+			limiter.lock.Unlock()
+			return nil
+		}
+		released := limiter.released
+		limiter.lock.Unlock()
+
+		if !logged {
+			seelog.Warnf("Container instance %s already has %d active ACS session(s), the maximum allowed. "+
+				"Waiting for a session to end before connecting", containerInstanceARN, active)
+		}
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release unregisters an active session of the container instance.
+func (limiter *sessionLimiter) release(containerInstanceARN string) {
+	limiter.lock.Lock()
+	defer limiter.lock.Unlock()
+
+	limiter.sessions[containerInstanceARN]--
+	if limiter.sessions[containerInstanceARN] <= 0 {
+		delete(limiter.sessions, containerInstanceARN)
+	}
+	close(limiter.released)
+	limiter.released = make(chan struct{})
+}
@@ -0,0 +1,83 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// expiredContext returns a context that is already done, so that acquiring a session
+// beyond the limit returns instead of waiting.
+func expiredContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+func TestSessionLimiter(t *testing.T) {
+	limiter := newSessionLimiter()
+	ctx := context.Background()
+
+	assert.NoError(t, limiter.acquire(ctx, "arn1", 2))
+	assert.NoError(t, limiter.acquire(ctx, "arn1", 2))
+	assert.Error(t, limiter.acquire(expiredContext(), "arn1", 2), "expected a third session to exceed the limit")
+	// Sessions of other container instances are limited separately
+	assert.NoError(t, limiter.acquire(ctx, "arn2", 2))
+
+	limiter.release("arn1")
+	assert.NoError(t, limiter.acquire(ctx, "arn1", 2), "expected a released session to make room")
+
+	limiter.release("arn1")
+	limiter.release("arn1")
+	limiter.release("arn2")
+	assert.Empty(t, limiter.sessions)
+}
+
+func TestSessionLimiterDefaultsToSingleSession(t *testing.T) {
+	limiter := newSessionLimiter()
+
+	assert.NoError(t, limiter.acquire(context.Background(), "arn", 0))
+	assert.Error(t, limiter.acquire(expiredContext(), "arn", 0))
+}
+
+func TestSessionLimiterWaitsForRelease(t *testing.T) {
+	limiter := newSessionLimiter()
+	assert.NoError(t, limiter.acquire(context.Background(), "arn", 1))
+
+	acquired := make(chan error, 1)
+	go func() {
+		acquired <- limiter.acquire(context.Background(), "arn", 1)
+	}()
+	select {
+	case err := <-acquired:
+		t.Fatalf("expected acquiring a session beyond the limit to wait, got: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	limiter.release("arn")
+	select {
+	case err := <-acquired:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the waiting session to be acquired once a session was released")
+	}
+	assert.Equal(t, 1, limiter.sessions["arn"])
+}
@@ -14,6 +14,7 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"strconv"
 
 	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
@@ -30,7 +31,7 @@ import (
 // taskManifestHandler handles task manifest message for the ACS client
 type taskManifestHandler struct {
 	messageBufferTaskManifest                chan *ecsacs.TaskManifestMessage
-	messageBufferTaskManifestAck             chan string
+	messageBufferTaskManifestAck             chan *ecsacs.TaskManifestAckRequest
 	messageBufferTaskStopVerificationMessage chan *ecsacs.TaskStopVerificationMessage
 	messageBufferTaskStopVerificationAck     chan *ecsacs.TaskStopVerificationAck
 	ctx                                      context.Context
@@ -54,7 +55,7 @@ func newTaskManifestHandler(ctx context.Context,
 	derivedContext, cancel := context.WithCancel(ctx)
 	return taskManifestHandler{
 		messageBufferTaskManifest:                make(chan *ecsacs.TaskManifestMessage),
-		messageBufferTaskManifestAck:             make(chan string),
+		messageBufferTaskManifestAck:             make(chan *ecsacs.TaskManifestAckRequest),
 		messageBufferTaskStopVerificationMessage: make(chan *ecsacs.TaskStopVerificationMessage),
 		messageBufferTaskStopVerificationAck:     make(chan *ecsacs.TaskStopVerificationAck),
 		ctx:                                      derivedContext,
@@ -157,15 +158,27 @@ func (taskManifestHandler *taskManifestHandler) clearAcks() {
 	}
 }
 
-func (taskManifestHandler *taskManifestHandler) ackTaskManifestMessage(messageID string) {
-	seelog.Debugf("Acking task manifest message id: %s", messageID)
-	err := taskManifestHandler.acsClient.MakeRequest(&ecsacs.AckRequest{
+func (taskManifestHandler *taskManifestHandler) ackTaskManifestMessage(ack *ecsacs.TaskManifestAckRequest) {
+	seelog.Debugf("Acking task manifest message id: %s, seqNum: %d", aws.StringValue(ack.MessageId),
+		aws.Int64Value(ack.SeqNum))
+	err := taskManifestHandler.acsClient.MakeRequest(ack)
+	if err != nil {
+		seelog.Warnf("Error 'ack'ing TaskManifestMessage with messageID: %s, error: %v",
+			aws.StringValue(ack.MessageId), err)
+	}
+}
+
+// newTaskManifestAck builds the ack for a processed task manifest message. The ack carries the sequence
+// number of the manifest along with the tasks that were reconciled against it, so that ACS can match the
+// ack to the manifest it sent.
+func (taskManifestHandler *taskManifestHandler) newTaskManifestAck(message *ecsacs.TaskManifestMessage,
+	reconciledTasks []*ecsacs.TaskIdentifier) *ecsacs.TaskManifestAckRequest {
+	return &ecsacs.TaskManifestAckRequest{
 		Cluster:           aws.String(taskManifestHandler.cluster),
 		ContainerInstance: aws.String(taskManifestHandler.containerInstanceArn),
-		MessageId:         aws.String(messageID),
-	})
-	if err != nil {
-		seelog.Warnf("Error 'ack'ing TaskManifestMessage with messageID: %s, error: %v", messageID, err)
+		MessageId:         message.MessageId,
+		SeqNum:            message.Timeline,
+		Tasks:             reconciledTasks,
 	}
 }
 
@@ -254,9 +267,12 @@ func (taskManifestHandler *taskManifestHandler) handleSingleMessageVerificationA
 
 func (taskManifestHandler *taskManifestHandler) handleTaskManifestSingleMessage(
 	message *ecsacs.TaskManifestMessage) error {
+	if message.MessageId == nil || message.Timeline == nil {
+		return errors.New("task manifest message is missing the message id or sequence number")
+	}
 	taskListManifestHandler := message.Tasks
 	seqNumberFromMessage := *message.Timeline
-	clusterARN := *message.ClusterArn
+	clusterARN := aws.StringValue(message.ClusterArn)
 	agentLatestSequenceNumber := *taskManifestHandler.latestSeqNumberTaskManifest
 
 	// Check if the sequence number of message received is more than the one stored in Agent
@@ -272,7 +288,10 @@ func (taskManifestHandler *taskManifestHandler) handleTaskManifestSingleMessage(
 			return err
 		}
 
+		// An empty manifest means that no task is expected to be running on the instance, in which case
+		// every running task is a stop candidate.
 		tasksToKill := compareTasks(taskListManifestHandler, runningTasksOnInstance, clusterARN)
+		taskManifestAck := taskManifestHandler.newTaskManifestAck(message, tasksToKill)
 
 		// Update messageId so that it can be compared to the messageId in TaskStopVerificationAck message
 		taskManifestHandler.manifestMessageIDAccessor.SetMessageID(*message.MessageId)
@@ -280,7 +299,7 @@ func (taskManifestHandler *taskManifestHandler) handleTaskManifestSingleMessage(
 		// Throw the task manifest ack and task verification message in async so that it does not block the current
 		// thread.
 		go func() {
-			taskManifestHandler.messageBufferTaskManifestAck <- *message.MessageId
+			taskManifestHandler.messageBufferTaskManifestAck <- taskManifestAck
 			if len(tasksToKill) > 0 {
 				taskStopVerificationMessage := ecsacs.TaskStopVerificationMessage{
 					MessageId:      message.MessageId,
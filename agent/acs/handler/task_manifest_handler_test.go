@@ -58,12 +58,6 @@ func TestManifestHandlerKillAllTasks(t *testing.T) {
 	newTaskManifest := newTaskManifestHandler(ctx, cluster, containerInstanceArn, mockWSClient,
 		dataClient, taskEngine, aws.Int64(11), manifestMessageIDAccessor)
 
-	ackRequested := &ecsacs.AckRequest{
-		Cluster:           aws.String(cluster),
-		ContainerInstance: aws.String(containerInstanceArn),
-		MessageId:         aws.String(messageId),
-	}
-
 	task2 := &task.Task{Arn: "arn2", DesiredStatusUnsafe: apitaskstatus.TaskRunning}
 	task1 := &task.Task{Arn: "arn1", DesiredStatusUnsafe: apitaskstatus.TaskRunning}
 
@@ -75,6 +69,14 @@ func TestManifestHandlerKillAllTasks(t *testing.T) {
 		{DesiredStatus: aws.String(apitaskstatus.TaskStoppedString), TaskArn: aws.String("arn2"), TaskClusterArn: aws.String(cluster)},
 	}
 
+	ackRequested := &ecsacs.TaskManifestAckRequest{
+		Cluster:           aws.String(cluster),
+		ContainerInstance: aws.String(containerInstanceArn),
+		MessageId:         aws.String(messageId),
+		SeqNum:            aws.Int64(testSeqNum),
+		Tasks:             taskIdentifierFinal,
+	}
+
 	taskStopVerificationMessage := &ecsacs.TaskStopVerificationMessage{
 		MessageId:      aws.String(messageId),
 		StopCandidates: taskIdentifierFinal,
@@ -153,12 +155,6 @@ func TestManifestHandlerKillFewTasks(t *testing.T) {
 	newTaskManifest := newTaskManifestHandler(ctx, cluster, containerInstanceArn, mockWSClient,
 		dataClient, taskEngine, aws.Int64(11), manifestMessageIDAccessor)
 
-	ackRequested := &ecsacs.AckRequest{
-		Cluster:           aws.String(cluster),
-		ContainerInstance: aws.String(containerInstanceArn),
-		MessageId:         aws.String(messageId),
-	}
-
 	task2 := &task.Task{Arn: "arn2", DesiredStatusUnsafe: apitaskstatus.TaskRunning}
 	task1 := &task.Task{Arn: "arn1", DesiredStatusUnsafe: apitaskstatus.TaskRunning}
 	task3 := &task.Task{Arn: "arn3", DesiredStatusUnsafe: apitaskstatus.TaskRunning}
@@ -171,6 +167,14 @@ func TestManifestHandlerKillFewTasks(t *testing.T) {
 		{DesiredStatus: aws.String(apitaskstatus.TaskStoppedString), TaskArn: aws.String("arn3"), TaskClusterArn: aws.String(cluster)},
 	}
 
+	ackRequested := &ecsacs.TaskManifestAckRequest{
+		Cluster:           aws.String(cluster),
+		ContainerInstance: aws.String(containerInstanceArn),
+		MessageId:         aws.String(messageId),
+		SeqNum:            aws.Int64(testSeqNum),
+		Tasks:             taskIdentifierFinal,
+	}
+
 	taskStopVerificationMessage := &ecsacs.TaskStopVerificationMessage{
 		MessageId:      aws.String(messageId),
 		StopCandidates: taskIdentifierFinal,
@@ -257,12 +261,6 @@ func TestManifestHandlerKillNoTasks(t *testing.T) {
 	newTaskManifest := newTaskManifestHandler(ctx, cluster, containerInstanceArn, mockWSClient,
 		dataClient, taskEngine, aws.Int64(11), manifestMessageIDAccessor)
 
-	ackRequested := &ecsacs.AckRequest{
-		Cluster:           aws.String(cluster),
-		ContainerInstance: aws.String(containerInstanceArn),
-		MessageId:         aws.String(messageId),
-	}
-
 	task2 := &task.Task{Arn: "arn2", DesiredStatusUnsafe: apitaskstatus.TaskRunning}
 	task1 := &task.Task{Arn: "arn1", DesiredStatusUnsafe: apitaskstatus.TaskRunning}
 	task3 := &task.Task{Arn: "arn3", DesiredStatusUnsafe: apitaskstatus.TaskRunning}
@@ -275,6 +273,14 @@ func TestManifestHandlerKillNoTasks(t *testing.T) {
 		{DesiredStatus: aws.String("STOPPED"), TaskArn: aws.String("arn3")},
 	}
 
+	ackRequested := &ecsacs.TaskManifestAckRequest{
+		Cluster:           aws.String(cluster),
+		ContainerInstance: aws.String(containerInstanceArn),
+		MessageId:         aws.String(messageId),
+		SeqNum:            aws.Int64(testSeqNum),
+		Tasks:             []*ecsacs.TaskIdentifier{},
+	}
+
 	taskStopVerificationMessage := &ecsacs.TaskStopVerificationMessage{
 		MessageId:      aws.String(messageId),
 		StopCandidates: taskIdentifierFinal,
@@ -283,7 +289,7 @@ func TestManifestHandlerKillNoTasks(t *testing.T) {
 	taskEngine.EXPECT().ListTasks().Return(taskList, nil).Times(1)
 
 	mockWSClient.EXPECT().MakeRequest(taskStopVerificationMessage).Times(0)
-	mockWSClient.EXPECT().MakeRequest(ackRequested).Times(1).Do(func(message *ecsacs.AckRequest) {
+	mockWSClient.EXPECT().MakeRequest(ackRequested).Times(1).Do(func(message *ecsacs.TaskManifestAckRequest) {
 		newTaskManifest.stop()
 	})
 
@@ -347,12 +353,6 @@ func TestManifestHandlerDifferentTaskLists(t *testing.T) {
 	newTaskManifest := newTaskManifestHandler(ctx, cluster, containerInstanceArn, mockWSClient,
 		dataClient, taskEngine, aws.Int64(11), manifestMessageIDAccessor)
 
-	ackRequested := &ecsacs.AckRequest{
-		Cluster:           aws.String(cluster),
-		ContainerInstance: aws.String(containerInstanceArn),
-		MessageId:         aws.String(messageId),
-	}
-
 	task2 := &task.Task{Arn: "arn2", DesiredStatusUnsafe: apitaskstatus.TaskRunning}
 	task1 := &task.Task{Arn: "arn1", DesiredStatusUnsafe: apitaskstatus.TaskRunning}
 
@@ -377,6 +377,14 @@ func TestManifestHandlerDifferentTaskLists(t *testing.T) {
 		{DesiredStatus: aws.String(apitaskstatus.TaskStoppedString), TaskArn: aws.String("arn2"), TaskClusterArn: aws.String(cluster)},
 	}
 
+	ackRequested := &ecsacs.TaskManifestAckRequest{
+		Cluster:           aws.String(cluster),
+		ContainerInstance: aws.String(containerInstanceArn),
+		MessageId:         aws.String(messageId),
+		SeqNum:            aws.Int64(testSeqNum),
+		Tasks:             taskIdentifierMessage,
+	}
+
 	taskStopVerificationMessage := &ecsacs.TaskStopVerificationMessage{
 		MessageId:      aws.String(messageId),
 		StopCandidates: taskIdentifierMessage,
@@ -508,6 +516,70 @@ func TestManifestHandlerSequenceNumbers(t *testing.T) {
 	}
 }
 
+// Tests that an empty task manifest is acked with its sequence number and with every running task on the
+// instance as a stop candidate
+func TestManifestHandlerEmptyManifestAck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
+	mockWSClient := mock_wsclient.NewMockClientServer(ctrl)
+	messageId := "mock-message-id"
+	manifestMessageIDAccessor := &manifestMessageIDAccessor{}
+
+	newTaskManifest := newTaskManifestHandler(context.TODO(), cluster, containerInstanceArn, mockWSClient,
+		data.NewNoopClient(), taskEngine, aws.Int64(11), manifestMessageIDAccessor)
+
+	taskList := []*task.Task{
+		{Arn: "arn1", DesiredStatusUnsafe: apitaskstatus.TaskRunning},
+		{Arn: "arn2", DesiredStatusUnsafe: apitaskstatus.TaskStopped},
+	}
+	taskEngine.EXPECT().ListTasks().Return(taskList, nil)
+
+	message := &ecsacs.TaskManifestMessage{
+		MessageId:            aws.String(messageId),
+		ClusterArn:           aws.String(cluster),
+		ContainerInstanceArn: aws.String(containerInstanceArn),
+		Tasks:                []*ecsacs.TaskIdentifier{},
+		Timeline:             aws.Int64(testSeqNum),
+	}
+	require.NoError(t, newTaskManifest.handleTaskManifestSingleMessage(message))
+
+	expectedStopCandidates := []*ecsacs.TaskIdentifier{
+		{DesiredStatus: aws.String(apitaskstatus.TaskStoppedString), TaskArn: aws.String("arn1"), TaskClusterArn: aws.String(cluster)},
+	}
+	ack := <-newTaskManifest.messageBufferTaskManifestAck
+	assert.Equal(t, &ecsacs.TaskManifestAckRequest{
+		Cluster:           aws.String(cluster),
+		ContainerInstance: aws.String(containerInstanceArn),
+		MessageId:         aws.String(messageId),
+		SeqNum:            aws.Int64(testSeqNum),
+		Tasks:             expectedStopCandidates,
+	}, ack)
+
+	taskStopVerificationMessage := <-newTaskManifest.messageBufferTaskStopVerificationMessage
+	assert.Equal(t, expectedStopCandidates, taskStopVerificationMessage.StopCandidates)
+	assert.Equal(t, messageId, manifestMessageIDAccessor.GetMessageID())
+}
+
+// Tests that a task manifest message without a sequence number is rejected without being acked
+func TestManifestHandlerMissingSequenceNumber(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	taskEngine := mock_engine.NewMockTaskEngine(ctrl)
+	mockWSClient := mock_wsclient.NewMockClientServer(ctrl)
+	newTaskManifest := newTaskManifestHandler(context.TODO(), cluster, containerInstanceArn, mockWSClient,
+		data.NewNoopClient(), taskEngine, aws.Int64(11), &manifestMessageIDAccessor{})
+
+	err := newTaskManifest.handleTaskManifestSingleMessage(&ecsacs.TaskManifestMessage{
+		MessageId:  aws.String("mock-message-id"),
+		ClusterArn: aws.String(cluster),
+	})
+	assert.Error(t, err)
+	assert.Equal(t, int64(11), *newTaskManifest.latestSeqNumberTaskManifest)
+}
+
 func TestCompareTasksDifferentTasks(t *testing.T) {
 	receivedTaskList := []*ecsacs.TaskIdentifier{
 		{
@@ -569,7 +641,7 @@ func TestTaskManifestHandlerSendPendingTaskManifestMessageAck(t *testing.T) {
 
 	// write a dummy ack into the messageBufferTaskManifestAck
 	go func() {
-		handler.messageBufferTaskManifestAck <- "testMessageID"
+		handler.messageBufferTaskManifestAck <- &ecsacs.TaskManifestAckRequest{MessageId: aws.String("testMessageID")}
 		wg.Done()
 	}()
 
@@ -17,6 +17,7 @@ import (
 	"strings"
 
 	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/credentials"
 	"github.com/aws/aws-sdk-go/aws"
 )
 
@@ -30,7 +31,6 @@ const (
 	appPorts                   = "AppPorts"
 	egressIgnoredIPs           = "EgressIgnoredIPs"
 	egressIgnoredPorts         = "EgressIgnoredPorts"
-	taskMetadataEndpointIP     = "169.254.170.2"
 	instanceMetadataEndpointIP = "169.254.169.254"
 )
 
@@ -107,7 +107,7 @@ func appendDefaultEgressIgnoredIPs(egressIgnoredIPs []string) []string {
 	hasTaskMetadataEndpointIP := false
 	hasInstanceMetadataEndpointIP := false
 	for _, egressIgnoredIP := range egressIgnoredIPs {
-		if strings.TrimSpace(egressIgnoredIP) == taskMetadataEndpointIP {
+		if strings.TrimSpace(egressIgnoredIP) == credentials.CredentialsEndpointIP {
 			hasTaskMetadataEndpointIP = true
 		}
 		if strings.TrimSpace(egressIgnoredIP) == instanceMetadataEndpointIP {
@@ -116,7 +116,7 @@ func appendDefaultEgressIgnoredIPs(egressIgnoredIPs []string) []string {
 	}
 
 	if !hasTaskMetadataEndpointIP {
-		egressIgnoredIPs = append(egressIgnoredIPs, taskMetadataEndpointIP)
+		egressIgnoredIPs = append(egressIgnoredIPs, credentials.CredentialsEndpointIP)
 	}
 	if !hasInstanceMetadataEndpointIP {
 		egressIgnoredIPs = append(egressIgnoredIPs, instanceMetadataEndpointIP)
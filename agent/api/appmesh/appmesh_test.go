@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/credentials"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/stretchr/testify/assert"
 )
@@ -57,7 +58,7 @@ func TestAppMeshFromACS(t *testing.T) {
 	assert.Equal(t, mockAppPort2, appMesh.AppPorts[1])
 	assert.Equal(t, mockEgressIgnoredIP1, appMesh.EgressIgnoredIPs[0])
 	assert.Equal(t, mockEgressIgnoredIP2, appMesh.EgressIgnoredIPs[1])
-	assert.Equal(t, taskMetadataEndpointIP, appMesh.EgressIgnoredIPs[2])
+	assert.Equal(t, credentials.CredentialsEndpointIP, appMesh.EgressIgnoredIPs[2])
 	assert.Equal(t, instanceMetadataEndpointIP, appMesh.EgressIgnoredIPs[3])
 	assert.Equal(t, mockEgressIgnoredPort1, appMesh.EgressIgnoredPorts[0])
 	assert.Equal(t, mockEgressIgnoredPort2, appMesh.EgressIgnoredPorts[1])
@@ -65,7 +66,7 @@ func TestAppMeshFromACS(t *testing.T) {
 
 func TestAppMeshFromACSContainsDefaultEgressIgnoredIP(t *testing.T) {
 	testProxyConfig := prepareProxyConfig()
-	egressIgnoredIPs := mockEgressIgnoredIPs + splitter + taskMetadataEndpointIP + splitter + instanceMetadataEndpointIP
+	egressIgnoredIPs := mockEgressIgnoredIPs + splitter + credentials.CredentialsEndpointIP + splitter + instanceMetadataEndpointIP
 	testProxyConfig.Properties[egressIgnoredIPs] = aws.String(egressIgnoredIPs)
 
 	appMesh, err := AppMeshFromACS(&testProxyConfig)
@@ -80,7 +81,7 @@ func TestAppMeshFromACSContainsDefaultEgressIgnoredIP(t *testing.T) {
 	assert.Equal(t, mockAppPort2, appMesh.AppPorts[1])
 	assert.Equal(t, mockEgressIgnoredIP1, appMesh.EgressIgnoredIPs[0])
 	assert.Equal(t, mockEgressIgnoredIP2, appMesh.EgressIgnoredIPs[1])
-	assert.Equal(t, taskMetadataEndpointIP, appMesh.EgressIgnoredIPs[2])
+	assert.Equal(t, credentials.CredentialsEndpointIP, appMesh.EgressIgnoredIPs[2])
 	assert.Equal(t, instanceMetadataEndpointIP, appMesh.EgressIgnoredIPs[3])
 	assert.Equal(t, mockEgressIgnoredPort1, appMesh.EgressIgnoredPorts[0])
 	assert.Equal(t, mockEgressIgnoredPort2, appMesh.EgressIgnoredPorts[1])
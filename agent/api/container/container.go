@@ -33,6 +33,7 @@ import (
 	"github.com/cihub/seelog"
 	"github.com/docker/docker/api/types"
 	dockercontainer "github.com/docker/docker/api/types/container"
+	dockermount "github.com/docker/docker/api/types/mount"
 )
 
 const (
@@ -49,6 +50,10 @@ const (
 	// DockerHealthCheckType is the type of container health check provided by docker
 	DockerHealthCheckType = "docker"
 
+	// ContainerTagLabelPrefix is the prefix of the docker labels that carry container level
+	// resource tags. The tag key is the rest of the label name.
+	ContainerTagLabelPrefix = "com.amazonaws.ecs.container-tag."
+
 	// AuthTypeECR is to use image pull auth over ECR
 	AuthTypeECR = "ecr"
 
@@ -93,6 +98,16 @@ const (
 	credentialSpecPrefix = "credentialspec"
 
 	credentialSpecDomainlessPrefix = credentialSpecPrefix + "domainless"
+
+	seccompSecurityOptKey  = "seccomp"
+	apparmorSecurityOptKey = "apparmor"
+
+	// SecurityProfileDefault indicates that the runtime's default security profile is applied.
+	SecurityProfileDefault = "default"
+	// SecurityProfileUnconfined indicates that no security profile is applied.
+	SecurityProfileUnconfined = "unconfined"
+	// SecurityProfileCustom indicates that an inline custom seccomp profile is applied.
+	SecurityProfileCustom = "custom"
 )
 
 var (
@@ -223,6 +238,15 @@ type Container struct {
 	StartTimeout uint
 	// StopTimeout specifies the time value to be passed as StopContainer api call
 	StopTimeout uint
+	// ImagePullTimeout specifies the time value in seconds after which pulling the container's
+	// image is aborted. If unset, the agent's configured image pull timeout is used.
+	ImagePullTimeout uint
+	// RestartPolicy specifies whether the container is restarted when it exits. It is one of
+	// "NEVER", "UNLESS_TASK_STOPPED" or "ON_FAILURE", and is empty when no restart policy is set.
+	RestartPolicy string `json:"restartPolicy,omitempty"`
+	// RestartMaxAttempts is the maximum number of times the container is restarted. The number
+	// of restarts is not limited when it is zero.
+	RestartMaxAttempts int64 `json:"restartMaxAttempts,omitempty"`
 
 	// lock is used for fields that are accessed and updated concurrently
 	lock sync.RWMutex
@@ -308,6 +332,10 @@ type Container struct {
 	// NetworksUnsafe denotes the Docker Network Settings in the container.
 	NetworkSettingsUnsafe *types.NetworkSettings `json:"-"`
 
+	// HostPIDUnsafe is the host PID of the container's main process, as last inspected
+	// while the container was running
+	HostPIDUnsafe int `json:"-"`
+
 	// SteadyStateStatusUnsafe specifies the steady state status for the container
 	// If uninitialized, it's assumed to be set to 'ContainerRunning'. Even though
 	// it's not only supposed to be set when the container is being created, it's
@@ -739,6 +767,26 @@ func (c *Container) GetLabels() map[string]string {
 	return c.labels
 }
 
+// GetContainerTags returns the container level resource tags, which are taken from the labels
+// of the container prefixed with ContainerTagLabelPrefix
+func (c *Container) GetContainerTags() map[string]string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	var tags map[string]string
+	for label, value := range c.labels {
+		key := strings.TrimPrefix(label, ContainerTagLabelPrefix)
+		if key == label || key == "" {
+			continue
+		}
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		tags[key] = value
+	}
+	return tags
+}
+
 // SetKnownPortBindings sets the ports for a container
 func (c *Container) SetKnownPortBindings(ports []PortBinding) {
 	c.lock.Lock()
@@ -810,6 +858,22 @@ func (c *Container) GetNetworkMode() string {
 	return c.NetworkModeUnsafe
 }
 
+// SetHostPID sets the host PID of the container's main process
+func (c *Container) SetHostPID(pid int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.HostPIDUnsafe = pid
+}
+
+// GetHostPID returns the host PID of the container's main process, or zero if it is not known
+func (c *Container) GetHostPID() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.HostPIDUnsafe
+}
+
 // HealthStatusShouldBeReported returns true if the health check is defined in
 // the task definition
 func (c *Container) HealthStatusShouldBeReported() bool {
@@ -1126,6 +1190,15 @@ func (c *Container) GetStopTimeout() time.Duration {
 	return time.Duration(c.StopTimeout) * time.Second
 }
 
+// GetImagePullTimeout returns the image pull timeout for the container, or zero if
+// the container doesn't specify one
+func (c *Container) GetImagePullTimeout() time.Duration {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return time.Duration(c.ImagePullTimeout) * time.Second
+}
+
 func (c *Container) GetDependsOn() []DependsOn {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
@@ -1133,6 +1206,15 @@ func (c *Container) GetDependsOn() []DependsOn {
 	return c.DependsOnUnsafe
 }
 
+// GetRestartPolicy returns the restart policy of the container and the maximum number of times
+// it is restarted
+func (c *Container) GetRestartPolicy() (string, int64) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.RestartPolicy, c.RestartMaxAttempts
+}
+
 func (c *Container) SetDependsOn(dependsOn []DependsOn) {
 	c.lock.Lock()
 	defer c.lock.Unlock()
@@ -1232,6 +1314,206 @@ func (c *Container) GetNetworkModeFromHostConfig() string {
 	return hostConfig.NetworkMode.NetworkName()
 }
 
+// GetSecurityProfiles returns the seccomp and AppArmor profiles applied to the container, based
+// on the security options in its host config. A profile that isn't overridden is reported as
+// SecurityProfileDefault and an inline seccomp profile is reported as SecurityProfileCustom.
+func (c *Container) GetSecurityProfiles() (string, string) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	seccompProfile, apparmorProfile := SecurityProfileDefault, SecurityProfileDefault
+	if c.DockerConfig.HostConfig == nil {
+		return seccompProfile, apparmorProfile
+	}
+
+	hostConfig := &dockercontainer.HostConfig{}
+	err := json.Unmarshal([]byte(*c.DockerConfig.HostConfig), hostConfig)
+	if err != nil {
+		seelog.Warnf("Encountered error when trying to get security profiles for container %s: %v", c.RuntimeID, err)
+		return "", ""
+	}
+
+	for _, opt := range hostConfig.SecurityOpt {
+		// Docker accepts both '=' and the legacy ':' as the security option separator
+		idx := strings.IndexAny(opt, "=:")
+		if idx < 0 {
+			continue
+		}
+		key, value := opt[:idx], opt[idx+1:]
+		switch key {
+		case seccompSecurityOptKey:
+			if strings.HasPrefix(strings.TrimSpace(value), "{") {
+				value = SecurityProfileCustom
+			}
+			seccompProfile = value
+		case apparmorSecurityOptKey:
+			apparmorProfile = value
+		}
+	}
+	return seccompProfile, apparmorProfile
+}
+
+// GetExtraHosts returns the extra /etc/hosts entries of the container from its host config,
+// in the "hostname:IP" format used by Docker.
+func (c *Container) GetExtraHosts() []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.DockerConfig.HostConfig == nil {
+		return nil
+	}
+
+	hostConfig := &dockercontainer.HostConfig{}
+	err := json.Unmarshal([]byte(*c.DockerConfig.HostConfig), hostConfig)
+	if err != nil {
+		seelog.Warnf("Encountered error when trying to get extra hosts for container %s: %v", c.RuntimeID, err)
+		return nil
+	}
+
+	return hostConfig.ExtraHosts
+}
+
+// GetInitProcessEnabled returns whether the container is configured to run an init process
+// inside it, according to its host config.
+func (c *Container) GetInitProcessEnabled() bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.DockerConfig.HostConfig == nil {
+		return false
+	}
+
+	hostConfig := &dockercontainer.HostConfig{}
+	err := json.Unmarshal([]byte(*c.DockerConfig.HostConfig), hostConfig)
+	if err != nil {
+		seelog.Warnf("Encountered error when trying to get init process setting for container %s: %v", c.RuntimeID, err)
+		return false
+	}
+
+	return hostConfig.Init != nil && *hostConfig.Init
+}
+
+// GetCpusetCpus returns the cpus the container is pinned to, according to its host config, in the
+// docker cpuset format such as "0-3" or "0,2". An empty string is returned if the container is not pinned.
+func (c *Container) GetCpusetCpus() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.DockerConfig.HostConfig == nil {
+		return ""
+	}
+
+	hostConfig := &dockercontainer.HostConfig{}
+	err := json.Unmarshal([]byte(*c.DockerConfig.HostConfig), hostConfig)
+	if err != nil {
+		seelog.Warnf("Encountered error when trying to get cpuset cpus for container %s: %v", c.RuntimeID, err)
+		return ""
+	}
+
+	return hostConfig.CpusetCpus
+}
+
+// GetMountPropagation returns the propagation mode of the bind mount at containerPath, according to the
+// binds and mounts in its host config, such as "rshared". An empty string is returned if no propagation
+// mode is set for the mount.
+func (c *Container) GetMountPropagation(containerPath string) string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.DockerConfig.HostConfig == nil {
+		return ""
+	}
+
+	hostConfig := &dockercontainer.HostConfig{}
+	err := json.Unmarshal([]byte(*c.DockerConfig.HostConfig), hostConfig)
+	if err != nil {
+		seelog.Warnf("Encountered error when trying to get mount propagation for container %s: %v", c.RuntimeID, err)
+		return ""
+	}
+
+	for _, mount := range hostConfig.Mounts {
+		if mount.Target == containerPath && mount.BindOptions != nil {
+			return string(mount.BindOptions.Propagation)
+		}
+	}
+	// Binds are in the "source:destination[:options]" format, where options are comma separated
+	for _, bind := range hostConfig.Binds {
+		parts := strings.Split(bind, ":")
+		if len(parts) < 3 || parts[1] != containerPath {
+			continue
+		}
+		for _, option := range strings.Split(parts[2], ",") {
+			switch propagation := dockermount.Propagation(option); propagation {
+			case dockermount.PropagationPrivate, dockermount.PropagationRPrivate,
+				dockermount.PropagationShared, dockermount.PropagationRShared,
+				dockermount.PropagationSlave, dockermount.PropagationRSlave:
+				return option
+			}
+		}
+	}
+	return ""
+}
+
+// GetMemoryReservation returns the memory soft limit, in bytes, of the container from its docker
+// host config. Zero is returned if no memory reservation is set.
+func (c *Container) GetMemoryReservation() int64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.DockerConfig.HostConfig == nil {
+		return 0
+	}
+
+	hostConfig := &dockercontainer.HostConfig{}
+	err := json.Unmarshal([]byte(*c.DockerConfig.HostConfig), hostConfig)
+	if err != nil {
+		seelog.Warnf("Encountered error when trying to get memory reservation for container %s: %v", c.RuntimeID, err)
+		return 0
+	}
+
+	return hostConfig.MemoryReservation
+}
+
+// GetWorkingDirectory returns the working directory of the container from its docker config.
+// An empty string is returned if the working directory is not set.
+func (c *Container) GetWorkingDirectory() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.DockerConfig.Config == nil {
+		return ""
+	}
+
+	config := &dockercontainer.Config{}
+	err := json.Unmarshal([]byte(*c.DockerConfig.Config), config)
+	if err != nil {
+		seelog.Warnf("Encountered error when trying to get working directory for container %s: %v", c.RuntimeID, err)
+		return ""
+	}
+
+	return config.WorkingDir
+}
+
+// GetStopSignal returns the signal the container is stopped with from its docker config.
+// An empty string is returned if the stop signal is not set.
+func (c *Container) GetStopSignal() string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.DockerConfig.Config == nil {
+		return ""
+	}
+
+	config := &dockercontainer.Config{}
+	err := json.Unmarshal([]byte(*c.DockerConfig.Config), config)
+	if err != nil {
+		seelog.Warnf("Encountered error when trying to get stop signal for container %s: %v", c.RuntimeID, err)
+		return ""
+	}
+
+	return config.StopSignal
+}
+
 // GetHostConfig returns the container's host config.
 func (c *Container) GetHostConfig() *string {
 	c.lock.RLock()
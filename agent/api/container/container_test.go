@@ -672,6 +672,97 @@ func TestGetLogDriver(t *testing.T) {
 	}
 }
 
+func TestGetWorkingDirectory(t *testing.T) {
+	getContainer := func(config string) *Container {
+		c := &Container{
+			Name: "c",
+		}
+		c.DockerConfig.Config = &config
+		return c
+	}
+
+	testCases := []struct {
+		name             string
+		container        *Container
+		workingDirectory string
+	}{
+		{
+			name:             "positive case",
+			container:        getContainer(`{"WorkingDir":"/app"}`),
+			workingDirectory: "/app",
+		},
+		{
+			name:             "no config",
+			container:        &Container{Name: "c"},
+			workingDirectory: "",
+		},
+		{
+			name:             "negative case",
+			container:        getContainer("invalid"),
+			workingDirectory: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.workingDirectory, tc.container.GetWorkingDirectory())
+		})
+	}
+}
+
+func TestGetMountPropagation(t *testing.T) {
+	getContainer := func(hostConfig string) *Container {
+		c := &Container{
+			Name: "c",
+		}
+		c.DockerConfig.HostConfig = &hostConfig
+		return c
+	}
+
+	testCases := []struct {
+		name             string
+		container        *Container
+		mountPropagation string
+	}{
+		{
+			name:             "bind with propagation",
+			container:        getContainer(`{"Binds":["/host:/data:ro,rslave"]}`),
+			mountPropagation: "rslave",
+		},
+		{
+			name:             "mount with propagation",
+			container:        getContainer(`{"Mounts":[{"Type":"bind","Source":"/host","Target":"/data","BindOptions":{"Propagation":"shared"}}]}`),
+			mountPropagation: "shared",
+		},
+		{
+			name:             "bind without propagation",
+			container:        getContainer(`{"Binds":["/host:/data:ro"]}`),
+			mountPropagation: "",
+		},
+		{
+			name:             "other mount",
+			container:        getContainer(`{"Binds":["/host:/other:rshared"]}`),
+			mountPropagation: "",
+		},
+		{
+			name:             "no host config",
+			container:        &Container{Name: "c"},
+			mountPropagation: "",
+		},
+		{
+			name:             "negative case",
+			container:        getContainer("invalid"),
+			mountPropagation: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.mountPropagation, tc.container.GetMountPropagation("/data"))
+		})
+	}
+}
+
 func TestGetNetworkModeFromHostConfig(t *testing.T) {
 	getContainer := func(hostConfig string) *Container {
 		c := &Container{
@@ -14,10 +14,12 @@
 package ecsclient
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/amazon-ecs-agent/agent/api"
@@ -44,7 +46,6 @@ const (
 	ecsMaxContainerReasonLength = 255
 	ecsMaxTaskReasonLength      = 1024
 	ecsMaxRuntimeIDLength       = 255
-	pollEndpointCacheTTL        = 12 * time.Hour
 	azAttrName                  = "ecs.availability-zone"
 	cpuArchAttrName             = "ecs.cpu-architecture"
 	osTypeAttrName              = "ecs.os-type"
@@ -63,6 +64,9 @@ type APIECSClient struct {
 	submitStateChangeClient api.ECSSubmitStateSDK
 	ec2metadata             ec2.EC2MetadataClient
 	pollEndpointCache       async.TTLCache
+	// pollEndpointRegion is the region the cached poll endpoint was discovered in
+	pollEndpointRegion string
+	pollEndpointLock   sync.Mutex
 }
 
 // NewECSClient creates a new ECSClient interface object
@@ -86,10 +90,19 @@ func NewECSClient(
 		standardClient:          standardClient,
 		submitStateChangeClient: submitStateChangeClient,
 		ec2metadata:             ec2MetadataClient,
-		pollEndpointCache:       async.NewTTLCache(pollEndpointCacheTTL),
+		pollEndpointCache:       async.NewTTLCache(pollEndpointCacheTTL(config)),
 	}
 }
 
+// pollEndpointCacheTTL returns the configured TTL of the poll endpoint cache, or the default TTL
+// if the configuration doesn't set a valid one.
+func pollEndpointCacheTTL(cfg *config.Config) time.Duration {
+	if cfg.PollEndpointCacheTTL <= 0 {
+		return config.DefaultPollEndpointCacheTTL
+	}
+	return cfg.PollEndpointCacheTTL
+}
+
 // SetSDK overrides the SDK to the given one. This is useful for injecting a
 // test implementation
 func (client *APIECSClient) SetSDK(sdk api.ECSSDK) {
@@ -633,8 +646,8 @@ func (client *APIECSClient) SubmitAttachmentStateChange(change api.AttachmentSta
 	return nil
 }
 
-func (client *APIECSClient) DiscoverPollEndpoint(containerInstanceArn string) (string, error) {
-	resp, err := client.discoverPollEndpoint(containerInstanceArn)
+func (client *APIECSClient) DiscoverPollEndpoint(ctx context.Context, containerInstanceArn string) (string, error) {
+	resp, err := client.discoverPollEndpoint(ctx, containerInstanceArn)
 	if err != nil {
 		return "", err
 	}
@@ -643,7 +656,7 @@ func (client *APIECSClient) DiscoverPollEndpoint(containerInstanceArn string) (s
 }
 
 func (client *APIECSClient) DiscoverTelemetryEndpoint(containerInstanceArn string) (string, error) {
-	resp, err := client.discoverPollEndpoint(containerInstanceArn)
+	resp, err := client.discoverPollEndpoint(context.Background(), containerInstanceArn)
 	if err != nil {
 		return "", err
 	}
@@ -655,7 +668,7 @@ func (client *APIECSClient) DiscoverTelemetryEndpoint(containerInstanceArn strin
 }
 
 func (client *APIECSClient) DiscoverServiceConnectEndpoint(containerInstanceArn string) (string, error) {
-	resp, err := client.discoverPollEndpoint(containerInstanceArn)
+	resp, err := client.discoverPollEndpoint(context.Background(), containerInstanceArn)
 	if err != nil {
 		return "", err
 	}
@@ -666,7 +679,11 @@ func (client *APIECSClient) DiscoverServiceConnectEndpoint(containerInstanceArn
 	return aws.StringValue(resp.ServiceConnectEndpoint), nil
 }
 
-func (client *APIECSClient) discoverPollEndpoint(containerInstanceArn string) (*ecs.DiscoverPollEndpointOutput, error) {
+func (client *APIECSClient) discoverPollEndpoint(ctx context.Context,
+	containerInstanceArn string) (*ecs.DiscoverPollEndpointOutput, error) {
+	region := client.config.AWSRegion
+	client.invalidatePollEndpointCacheOnRegionChange(containerInstanceArn, region)
+
 	// Try getting an entry from the cache
 	cachedEndpoint, expired, found := client.pollEndpointCache.Get(containerInstanceArn)
 	if !expired && found {
@@ -684,7 +701,7 @@ func (client *APIECSClient) discoverPollEndpoint(containerInstanceArn string) (*
 
 	// Cache miss or expired, invoke the ECS DiscoverPollEndpoint API.
 	seelog.Debugf("Invoking DiscoverPollEndpoint for '%s'", containerInstanceArn)
-	output, err := client.standardClient.DiscoverPollEndpoint(&ecs.DiscoverPollEndpointInput{
+	output, err := client.standardClient.DiscoverPollEndpointWithContext(ctx, &ecs.DiscoverPollEndpointInput{
 		ContainerInstance: &containerInstanceArn,
 		Cluster:           &client.config.Cluster,
 	})
@@ -706,10 +723,30 @@ func (client *APIECSClient) discoverPollEndpoint(containerInstanceArn string) (*
 	}
 
 	// Cache the response from ECS.
+	client.pollEndpointLock.Lock()
 	client.pollEndpointCache.Set(containerInstanceArn, output)
+	client.pollEndpointRegion = region
+	client.pollEndpointLock.Unlock()
 	return output, nil
 }
 
+// invalidatePollEndpointCacheOnRegionChange removes the cached poll endpoint if it was discovered in a
+// region other than the configured one, so that neither it nor its expired fallback is used to connect.
+func (client *APIECSClient) invalidatePollEndpointCacheOnRegionChange(containerInstanceArn, region string) {
+	client.pollEndpointLock.Lock()
+	defer client.pollEndpointLock.Unlock()
+	if client.pollEndpointRegion == "" || client.pollEndpointRegion == region {
+		return
+	}
+	logger.Info("Region changed since the poll endpoint was discovered, discarding the cached endpoint", logger.Fields{
+		"cachedRegion":         client.pollEndpointRegion,
+		"region":               region,
+		"containerInstanceARN": containerInstanceArn,
+	})
+	client.pollEndpointCache.Delete(containerInstanceArn)
+	client.pollEndpointRegion = ""
+}
+
 func (client *APIECSClient) GetResourceTags(resourceArn string) ([]*ecs.Tag, error) {
 	output, err := client.standardClient.ListTagsForResource(&ecs.ListTagsForResourceInput{
 		ResourceArn: &resourceArn,
@@ -17,6 +17,7 @@
 package ecsclient
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
@@ -773,7 +774,7 @@ func TestDiscoverTelemetryEndpoint(t *testing.T) {
 	defer mockCtrl.Finish()
 	client, mc, _ := NewMockClient(mockCtrl, ec2.NewBlackholeEC2MetadataClient(), nil)
 	expectedEndpoint := "http://127.0.0.1"
-	mc.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(&ecs.DiscoverPollEndpointOutput{TelemetryEndpoint: &expectedEndpoint}, nil)
+	mc.EXPECT().DiscoverPollEndpointWithContext(gomock.Any(), gomock.Any()).Return(&ecs.DiscoverPollEndpointOutput{TelemetryEndpoint: &expectedEndpoint}, nil)
 	endpoint, err := client.DiscoverTelemetryEndpoint("containerInstance")
 	if err != nil {
 		t.Error("Error getting telemetry endpoint: ", err)
@@ -787,7 +788,7 @@ func TestDiscoverTelemetryEndpointError(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 	client, mc, _ := NewMockClient(mockCtrl, ec2.NewBlackholeEC2MetadataClient(), nil)
-	mc.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(nil, fmt.Errorf("Error getting endpoint"))
+	mc.EXPECT().DiscoverPollEndpointWithContext(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("Error getting endpoint"))
 	_, err := client.DiscoverTelemetryEndpoint("containerInstance")
 	if err == nil {
 		t.Error("Expected error getting telemetry endpoint, didn't get any")
@@ -799,7 +800,7 @@ func TestDiscoverNilTelemetryEndpoint(t *testing.T) {
 	defer mockCtrl.Finish()
 	client, mc, _ := NewMockClient(mockCtrl, ec2.NewBlackholeEC2MetadataClient(), nil)
 	pollEndpoint := "http://127.0.0.1"
-	mc.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(&ecs.DiscoverPollEndpointOutput{Endpoint: &pollEndpoint}, nil)
+	mc.EXPECT().DiscoverPollEndpointWithContext(gomock.Any(), gomock.Any()).Return(&ecs.DiscoverPollEndpointOutput{Endpoint: &pollEndpoint}, nil)
 	_, err := client.DiscoverTelemetryEndpoint("containerInstance")
 	if err == nil {
 		t.Error("Expected error getting telemetry endpoint with old response")
@@ -811,7 +812,7 @@ func TestDiscoverServiceConnectEndpoint(t *testing.T) {
 	defer mockCtrl.Finish()
 	client, mc, _ := NewMockClient(mockCtrl, ec2.NewBlackholeEC2MetadataClient(), nil)
 	expectedEndpoint := "http://127.0.0.1"
-	mc.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(&ecs.DiscoverPollEndpointOutput{ServiceConnectEndpoint: &expectedEndpoint}, nil)
+	mc.EXPECT().DiscoverPollEndpointWithContext(gomock.Any(), gomock.Any()).Return(&ecs.DiscoverPollEndpointOutput{ServiceConnectEndpoint: &expectedEndpoint}, nil)
 	endpoint, err := client.DiscoverServiceConnectEndpoint("containerInstance")
 	if err != nil {
 		t.Error("Error getting service connect endpoint: ", err)
@@ -825,7 +826,7 @@ func TestDiscoverServiceConnectEndpointError(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
 	client, mc, _ := NewMockClient(mockCtrl, ec2.NewBlackholeEC2MetadataClient(), nil)
-	mc.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(nil, fmt.Errorf("Error getting endpoint"))
+	mc.EXPECT().DiscoverPollEndpointWithContext(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("Error getting endpoint"))
 	_, err := client.DiscoverServiceConnectEndpoint("containerInstance")
 	if err == nil {
 		t.Error("Expected error getting service connect endpoint, didn't get any")
@@ -837,7 +838,7 @@ func TestDiscoverNilServiceConnectEndpoint(t *testing.T) {
 	defer mockCtrl.Finish()
 	client, mc, _ := NewMockClient(mockCtrl, ec2.NewBlackholeEC2MetadataClient(), nil)
 	pollEndpoint := "http://127.0.0.1"
-	mc.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(&ecs.DiscoverPollEndpointOutput{Endpoint: &pollEndpoint}, nil)
+	mc.EXPECT().DiscoverPollEndpointWithContext(gomock.Any(), gomock.Any()).Return(&ecs.DiscoverPollEndpointOutput{Endpoint: &pollEndpoint}, nil)
 	_, err := client.DiscoverServiceConnectEndpoint("containerInstance")
 	if err == nil {
 		t.Error("Expected error getting service connect endpoint with old response")
@@ -930,7 +931,7 @@ func TestDiscoverPollEndpointCacheHit(t *testing.T) {
 		&ecs.DiscoverPollEndpointOutput{
 			Endpoint: aws.String(pollEndpoint),
 		}, false, true)
-	output, err := client.discoverPollEndpoint("containerInstance")
+	output, err := client.discoverPollEndpoint(context.Background(), "containerInstance")
 	if err != nil {
 		t.Fatalf("Error in discoverPollEndpoint: %v", err)
 	}
@@ -962,11 +963,11 @@ func TestDiscoverPollEndpointCacheMiss(t *testing.T) {
 
 	gomock.InOrder(
 		pollEndpointCache.EXPECT().Get("containerInstance").Return(nil, false, false),
-		mockSDK.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(pollEndpointOutput, nil),
+		mockSDK.EXPECT().DiscoverPollEndpointWithContext(gomock.Any(), gomock.Any()).Return(pollEndpointOutput, nil),
 		pollEndpointCache.EXPECT().Set("containerInstance", pollEndpointOutput),
 	)
 
-	output, err := client.discoverPollEndpoint("containerInstance")
+	output, err := client.discoverPollEndpoint(context.Background(), "containerInstance")
 	if err != nil {
 		t.Fatalf("Error in discoverPollEndpoint: %v", err)
 	}
@@ -998,10 +999,10 @@ func TestDiscoverPollEndpointExpiredButDPEFailed(t *testing.T) {
 
 	gomock.InOrder(
 		pollEndpointCache.EXPECT().Get("containerInstance").Return(pollEndpointOutput, true, false),
-		mockSDK.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(nil, fmt.Errorf("error!")),
+		mockSDK.EXPECT().DiscoverPollEndpointWithContext(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("error!")),
 	)
 
-	output, err := client.discoverPollEndpoint("containerInstance")
+	output, err := client.discoverPollEndpoint(context.Background(), "containerInstance")
 	if err != nil {
 		t.Fatalf("Error in discoverPollEndpoint: %v", err)
 	}
@@ -1028,12 +1029,12 @@ func TestDiscoverTelemetryEndpointAfterPollEndpointCacheHit(t *testing.T) {
 	}
 
 	pollEndpoint := "http://127.0.0.1"
-	mockSDK.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return(
+	mockSDK.EXPECT().DiscoverPollEndpointWithContext(gomock.Any(), gomock.Any()).Return(
 		&ecs.DiscoverPollEndpointOutput{
 			Endpoint:          &pollEndpoint,
 			TelemetryEndpoint: &pollEndpoint,
 		}, nil)
-	endpoint, err := client.DiscoverPollEndpoint("containerInstance")
+	endpoint, err := client.DiscoverPollEndpoint(context.Background(), "containerInstance")
 	if err != nil {
 		t.Fatalf("Error in discoverPollEndpoint: %v", err)
 	}
@@ -1049,6 +1050,68 @@ func TestDiscoverTelemetryEndpointAfterPollEndpointCacheHit(t *testing.T) {
 	}
 }
 
+// TestDiscoverPollEndpointCachedWithoutConfiguredTTL tests that the poll endpoint is cached with
+// the default TTL when the configuration doesn't set one.
+func TestDiscoverPollEndpointCachedWithoutConfiguredTTL(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	client, mockSDK, _ := NewMockClientWithConfig(mockCtrl, ec2.NewBlackholeEC2MetadataClient(), nil,
+		&config.Config{
+			Cluster:   configuredCluster,
+			AWSRegion: "us-east-1",
+		})
+
+	pollEndpoint := "http://127.0.0.1"
+	mockSDK.EXPECT().DiscoverPollEndpointWithContext(gomock.Any(), gomock.Any()).Return(
+		&ecs.DiscoverPollEndpointOutput{Endpoint: &pollEndpoint}, nil).Times(1)
+
+	for i := 0; i < 2; i++ {
+		endpoint, err := client.DiscoverPollEndpoint(context.Background(), "containerInstance")
+		require.NoError(t, err)
+		assert.Equal(t, pollEndpoint, endpoint)
+	}
+}
+
+func TestDiscoverPollEndpointRefreshedOnRegionChange(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockSDK := mock_api.NewMockECSSDK(mockCtrl)
+	cfg := &config.Config{
+		Cluster:   configuredCluster,
+		AWSRegion: "us-east-1",
+	}
+	client := &APIECSClient{
+		credentialProvider: credentials.AnonymousCredentials,
+		config:             cfg,
+		standardClient:     mockSDK,
+		ec2metadata:        ec2.NewBlackholeEC2MetadataClient(),
+		pollEndpointCache:  async.NewTTLCache(10 * time.Minute),
+	}
+
+	gomock.InOrder(
+		mockSDK.EXPECT().DiscoverPollEndpointWithContext(gomock.Any(), gomock.Any()).Return(
+			&ecs.DiscoverPollEndpointOutput{Endpoint: aws.String("https://ecs-a-1.us-east-1.amazonaws.com")}, nil),
+		mockSDK.EXPECT().DiscoverPollEndpointWithContext(gomock.Any(), gomock.Any()).Return(
+			&ecs.DiscoverPollEndpointOutput{Endpoint: aws.String("https://ecs-a-1.us-west-2.amazonaws.com")}, nil),
+	)
+
+	endpoint, err := client.DiscoverPollEndpoint(context.Background(), "containerInstance")
+	require.NoError(t, err)
+	assert.Equal(t, "https://ecs-a-1.us-east-1.amazonaws.com", endpoint)
+
+	// The endpoint is cached, so reconnecting in the same region doesn't discover it again.
+	endpoint, err = client.DiscoverPollEndpoint(context.Background(), "containerInstance")
+	require.NoError(t, err)
+	assert.Equal(t, "https://ecs-a-1.us-east-1.amazonaws.com", endpoint)
+
+	cfg.AWSRegion = "us-west-2"
+	endpoint, err = client.DiscoverPollEndpoint(context.Background(), "containerInstance")
+	require.NoError(t, err)
+	assert.Equal(t, "https://ecs-a-1.us-west-2.amazonaws.com", endpoint)
+}
+
 // TestSubmitTaskStateChangeWithAttachments tests the SubmitTaskStateChange API
 // also send the Attachment Status
 func TestSubmitTaskStateChangeWithAttachments(t *testing.T) {
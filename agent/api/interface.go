@@ -14,6 +14,8 @@
 package api
 
 import (
+	"context"
+
 	"github.com/aws/amazon-ecs-agent/agent/api/serviceconnect"
 	"github.com/aws/amazon-ecs-agent/agent/ecs_client/model/ecs"
 	"github.com/aws/aws-sdk-go/aws"
@@ -45,8 +47,9 @@ type ECSClient interface {
 	// indicating if it was submitted
 	SubmitAttachmentStateChange(change AttachmentStateChange) error
 	// DiscoverPollEndpoint takes a ContainerInstanceARN and returns the
-	// endpoint at which this Agent should contact ACS
-	DiscoverPollEndpoint(containerInstanceArn string) (string, error)
+	// endpoint at which this Agent should contact ACS. The call to ECS is
+	// cancelled when ctx is done.
+	DiscoverPollEndpoint(ctx context.Context, containerInstanceArn string) (string, error)
 	// DiscoverTelemetryEndpoint takes a ContainerInstanceARN and returns the
 	// endpoint at which this Agent should contact Telemetry Service
 	DiscoverTelemetryEndpoint(containerInstanceArn string) (string, error)
@@ -66,7 +69,8 @@ type ECSClient interface {
 type ECSSDK interface {
 	CreateCluster(*ecs.CreateClusterInput) (*ecs.CreateClusterOutput, error)
 	RegisterContainerInstance(*ecs.RegisterContainerInstanceInput) (*ecs.RegisterContainerInstanceOutput, error)
-	DiscoverPollEndpoint(*ecs.DiscoverPollEndpointInput) (*ecs.DiscoverPollEndpointOutput, error)
+	DiscoverPollEndpointWithContext(ctx aws.Context, input *ecs.DiscoverPollEndpointInput,
+		opts ...request.Option) (*ecs.DiscoverPollEndpointOutput, error)
 	ListTagsForResource(*ecs.ListTagsForResourceInput) (*ecs.ListTagsForResourceOutput, error)
 	UpdateContainerInstancesState(input *ecs.UpdateContainerInstancesStateInput) (*ecs.UpdateContainerInstancesStateOutput, error)
 }
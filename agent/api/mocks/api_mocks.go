@@ -68,19 +68,24 @@ func (mr *MockECSSDKMockRecorder) CreateCluster(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCluster", reflect.TypeOf((*MockECSSDK)(nil).CreateCluster), arg0)
 }
 
-// DiscoverPollEndpoint mocks base method.
-func (m *MockECSSDK) DiscoverPollEndpoint(arg0 *ecs.DiscoverPollEndpointInput) (*ecs.DiscoverPollEndpointOutput, error) {
+// DiscoverPollEndpointWithContext mocks base method.
+func (m *MockECSSDK) DiscoverPollEndpointWithContext(arg0 context.Context, arg1 *ecs.DiscoverPollEndpointInput, arg2 ...request.Option) (*ecs.DiscoverPollEndpointOutput, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DiscoverPollEndpoint", arg0)
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DiscoverPollEndpointWithContext", varargs...)
 	ret0, _ := ret[0].(*ecs.DiscoverPollEndpointOutput)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// DiscoverPollEndpoint indicates an expected call of DiscoverPollEndpoint.
-func (mr *MockECSSDKMockRecorder) DiscoverPollEndpoint(arg0 interface{}) *gomock.Call {
+// DiscoverPollEndpointWithContext indicates an expected call of DiscoverPollEndpointWithContext.
+func (mr *MockECSSDKMockRecorder) DiscoverPollEndpointWithContext(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscoverPollEndpoint", reflect.TypeOf((*MockECSSDK)(nil).DiscoverPollEndpoint), arg0)
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscoverPollEndpointWithContext", reflect.TypeOf((*MockECSSDK)(nil).DiscoverPollEndpointWithContext), varargs...)
 }
 
 // ListTagsForResource mocks base method.
@@ -220,18 +225,18 @@ func (m *MockECSClient) EXPECT() *MockECSClientMockRecorder {
 }
 
 // DiscoverPollEndpoint mocks base method.
-func (m *MockECSClient) DiscoverPollEndpoint(arg0 string) (string, error) {
+func (m *MockECSClient) DiscoverPollEndpoint(arg0 context.Context, arg1 string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DiscoverPollEndpoint", arg0)
+	ret := m.ctrl.Call(m, "DiscoverPollEndpoint", arg0, arg1)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // DiscoverPollEndpoint indicates an expected call of DiscoverPollEndpoint.
-func (mr *MockECSClientMockRecorder) DiscoverPollEndpoint(arg0 interface{}) *gomock.Call {
+func (mr *MockECSClientMockRecorder) DiscoverPollEndpoint(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscoverPollEndpoint", reflect.TypeOf((*MockECSClient)(nil).DiscoverPollEndpoint), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscoverPollEndpoint", reflect.TypeOf((*MockECSClient)(nil).DiscoverPollEndpoint), arg0, arg1)
 }
 
 // DiscoverServiceConnectEndpoint mocks base method.
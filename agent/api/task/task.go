@@ -178,6 +178,9 @@ type Task struct {
 	// ServiceName is the name of the service to which the task belongs.
 	// It is empty if the task does not belong to any service.
 	ServiceName string
+	// SchedulingStrategy is the scheduling strategy of the service to which
+	// the task belongs, REPLICA or DAEMON. It is empty for standalone tasks.
+	SchedulingStrategy string
 	// Containers are the containers for the task
 	Containers []*apicontainer.Container
 	// Associations are the available associations for the task.
@@ -285,6 +288,10 @@ type Task struct {
 	// LaunchType is the launch type of this task.
 	LaunchType string `json:"LaunchType,omitempty"`
 
+	// EphemeralStorageEncrypted reports whether the task's ephemeral storage is encrypted. It is
+	// only set on platforms that report it (Fargate) and is nil otherwise.
+	EphemeralStorageEncrypted *bool `json:"EphemeralStorageEncrypted,omitempty"`
+
 	// lock is for protecting all fields in the task struct
 	lock sync.RWMutex
 
@@ -1556,7 +1563,7 @@ func (task *Task) getBridgeModeTaskContainerForPauseContainer(container *apicont
 
 func (task *Task) addNamespaceSharingProvisioningDependency(cfg *config.Config) {
 	// Pause container does not need to be created if no namespace sharing will be done at task level
-	if task.getIPCMode() != ipcModeTask && task.getPIDMode() != pidModeTask {
+	if task.GetIPCMode() != ipcModeTask && task.GetPIDMode() != pidModeTask {
 		return
 	}
 	namespacePauseContainer := apicontainer.NewContainerWithSteadyState(apicontainerstatus.ContainerRunning)
@@ -2167,7 +2174,7 @@ func (task *Task) pidModeOverride(container *apicontainer.Container, dockerConta
 		return
 	}
 
-	switch task.getPIDMode() {
+	switch task.GetPIDMode() {
 	case pidModeHost:
 		setPIDMode(hostConfig, pidModeHost)
 		return
@@ -2212,7 +2219,7 @@ func (task *Task) ipcModeOverride(container *apicontainer.Container, dockerConta
 	if container.IsInternal() {
 		if container.Type == apicontainer.ContainerNamespacePause {
 			// Setting NamespaceContainerPause to be sharable with other containers
-			if task.getIPCMode() == ipcModeTask {
+			if task.GetIPCMode() == ipcModeTask {
 				setIPCMode(hostConfig, ipcModeSharable)
 				return
 			}
@@ -2221,7 +2228,7 @@ func (task *Task) ipcModeOverride(container *apicontainer.Container, dockerConta
 		return
 	}
 
-	switch task.getIPCMode() {
+	switch task.GetIPCMode() {
 	// IPCMode is none - container will have own private namespace with /dev/shm not mounted
 	case ipcModeNone:
 		setIPCMode(hostConfig, ipcModeNone)
@@ -2862,12 +2869,16 @@ func (task *Task) GetPullStartedAt() time.Time {
 	return task.PullStartedAtUnsafe
 }
 
-// SetPullStoppedAt sets the task pullstoppedat timestamp
+// SetPullStoppedAt sets the task pullstoppedat timestamp, unless it is already set to a later
+// timestamp. Images of the task's containers can be pulled in parallel, and their pulls don't
+// necessarily record their stop in the order in which they stopped.
 func (task *Task) SetPullStoppedAt(timestamp time.Time) {
 	task.lock.Lock()
 	defer task.lock.Unlock()
 
-	task.PullStoppedAtUnsafe = timestamp
+	if timestamp.After(task.PullStoppedAtUnsafe) {
+		task.PullStoppedAtUnsafe = timestamp
+	}
 }
 
 // GetPullStoppedAt returns the PullStoppedAt timestamp
@@ -3272,16 +3283,16 @@ func (task *Task) InitializeResources(resourceFields *taskresource.ResourceField
 	}
 }
 
-// Retrieves a Task's PIDMode
-func (task *Task) getPIDMode() string {
+// GetPIDMode retrieves a Task's PIDMode
+func (task *Task) GetPIDMode() string {
 	task.lock.RLock()
 	defer task.lock.RUnlock()
 
 	return task.PIDMode
 }
 
-// Retrieves a Task's IPCMode
-func (task *Task) getIPCMode() string {
+// GetIPCMode retrieves a Task's IPCMode
+func (task *Task) GetIPCMode() string {
 	task.lock.RLock()
 	defer task.lock.RUnlock()
 
@@ -121,6 +121,28 @@ func buildCgroupV2Root(taskID string) string {
 	return fmt.Sprintf("%s-%s.slice", config.DefaultTaskCgroupV2Prefix, taskID)
 }
 
+// GetContainerCgroupPath returns the path of a container's cgroup, relative to the root of the
+// cgroup hierarchy, when task cgroups are enabled. An empty path is returned otherwise, as the
+// container's cgroup is then chosen by docker.
+// Example v1: /ecs/task-id/docker-id
+// Example v2: /ecstasks.slice/ecstasks-task-id.slice/docker-docker-id.scope
+func (task *Task) GetContainerCgroupPath(dockerID string) (string, error) {
+	task.lock.RLock()
+	defer task.lock.RUnlock()
+	if !task.MemoryCPULimitsEnabled || dockerID == "" {
+		return "", nil
+	}
+	cgroupRoot, err := task.BuildCgroupRoot()
+	if err != nil {
+		return "", errors.Wrapf(err, "unable to obtain cgroup root for task: %s", task.Arn)
+	}
+	if config.CgroupV2 {
+		return filepath.Join("/", config.DefaultTaskCgroupV2Prefix+".slice", cgroupRoot,
+			fmt.Sprintf("docker-%s.scope", dockerID)), nil
+	}
+	return filepath.Join(cgroupRoot, dockerID), nil
+}
+
 // BuildLinuxResourceSpec returns a linuxResources object for the task cgroup
 func (task *Task) BuildLinuxResourceSpec(cGroupCPUPeriod time.Duration) (specs.LinuxResources, error) {
 	linuxResourceSpec := specs.LinuxResources{}
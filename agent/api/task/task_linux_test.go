@@ -270,6 +270,41 @@ func TestBuildCgroupRootErrorPath(t *testing.T) {
 	assert.Empty(t, cgroupRoot)
 }
 
+func TestGetContainerCgroupPath(t *testing.T) {
+	task := &Task{
+		Arn:                    validTaskArn,
+		MemoryCPULimitsEnabled: true,
+	}
+
+	cgroupPath, err := task.GetContainerCgroupPath("dockerid")
+	require.NoError(t, err)
+	if config.CgroupV2 {
+		assert.Equal(t, "/ecstasks.slice/"+expectedCgroupV2Root+"/docker-dockerid.scope", cgroupPath)
+	} else {
+		assert.Equal(t, expectedCgroupV1Root+"/dockerid", cgroupPath)
+	}
+}
+
+func TestGetContainerCgroupPathTaskCgroupsDisabled(t *testing.T) {
+	task := &Task{
+		Arn: validTaskArn,
+	}
+
+	cgroupPath, err := task.GetContainerCgroupPath("dockerid")
+	require.NoError(t, err)
+	assert.Empty(t, cgroupPath)
+}
+
+func TestGetContainerCgroupPathErrorPath(t *testing.T) {
+	task := &Task{
+		Arn:                    invalidTaskArn,
+		MemoryCPULimitsEnabled: true,
+	}
+
+	_, err := task.GetContainerCgroupPath("dockerid")
+	assert.Error(t, err)
+}
+
 func TestBuildCgroupV1Root(t *testing.T) {
 	cgroupRoot := buildCgroupV1Root("111mytaskid")
 	assert.Equal(t, "/ecs/111mytaskid", cgroupRoot)
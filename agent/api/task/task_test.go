@@ -1463,8 +1463,8 @@ func TestGetPIDAndIPCFromTask(t *testing.T) {
 			PIDMode: aTest.PIDMode,
 			IPCMode: aTest.IPCMode,
 		}
-		assert.Equal(t, aTest.PIDMode, testTask.getPIDMode())
-		assert.Equal(t, aTest.IPCMode, testTask.getIPCMode())
+		assert.Equal(t, aTest.PIDMode, testTask.GetPIDMode())
+		assert.Equal(t, aTest.IPCMode, testTask.GetIPCMode())
 	}
 }
 
@@ -1491,8 +1491,8 @@ func TestPostUnmarshalTaskWithPIDSharing(t *testing.T) {
 		seqNum := int64(42)
 		task, err := TaskFromACS(&testTaskFromACS, &ecsacs.PayloadMessage{SeqNum: &seqNum})
 		assert.Nil(t, err, "Should be able to handle acs task")
-		assert.Equal(t, aTest.PIDMode, task.getPIDMode())
-		assert.Equal(t, aTest.IPCMode, task.getIPCMode())
+		assert.Equal(t, aTest.PIDMode, task.GetPIDMode())
+		assert.Equal(t, aTest.IPCMode, task.GetIPCMode())
 		assert.Equal(t, 2, len(task.Containers)) // before PostUnmarshalTask
 		cfg := config.Config{}
 		task.PostUnmarshalTask(&cfg, nil, nil, nil, nil)
@@ -1525,8 +1525,8 @@ func TestNamespaceProvisionDependencyAndHostConfig(t *testing.T) {
 		seqNum := int64(42)
 		task, err := TaskFromACS(&taskFromACS, &ecsacs.PayloadMessage{SeqNum: &seqNum})
 		assert.Nil(t, err, "Should be able to handle acs task")
-		assert.Equal(t, aTest.PIDMode, task.getPIDMode())
-		assert.Equal(t, aTest.IPCMode, task.getIPCMode())
+		assert.Equal(t, aTest.PIDMode, task.GetPIDMode())
+		assert.Equal(t, aTest.IPCMode, task.GetIPCMode())
 		assert.Equal(t, 2, len(task.Containers)) // before PostUnmarshalTask
 		cfg := config.Config{}
 		task.PostUnmarshalTask(&cfg, nil, nil, nil, nil)
@@ -1661,11 +1661,12 @@ func TestTaskFromACS(t *testing.T) {
 	// Testing type conversions, bleh. At least the type conversion itself
 	// doesn't look this messy.
 	taskFromAcs := ecsacs.Task{
-		Arn:           strptr("myArn"),
-		DesiredStatus: strptr("RUNNING"),
-		Family:        strptr("myFamily"),
-		Version:       strptr("1"),
-		ServiceName:   strptr("myService"),
+		Arn:                strptr("myArn"),
+		DesiredStatus:      strptr("RUNNING"),
+		Family:             strptr("myFamily"),
+		Version:            strptr("1"),
+		ServiceName:        strptr("myService"),
+		SchedulingStrategy: strptr("DAEMON"),
 		Containers: []*ecsacs.Container{
 			{
 				Name:        strptr("myName"),
@@ -1766,6 +1767,7 @@ func TestTaskFromACS(t *testing.T) {
 		Family:              "myFamily",
 		Version:             "1",
 		ServiceName:         "myService",
+		SchedulingStrategy:  "DAEMON",
 		NetworkMode:         BridgeNetworkMode,
 		Containers: []*apicontainer.Container{
 			{
@@ -2230,6 +2232,24 @@ func TestSetPullStartedAt(t *testing.T) {
 	assert.Equal(t, t1, testTask.GetPullStartedAt(), "second set of pullStartedAt should have no impact")
 }
 
+// TestSetPullStoppedAt tests that the task SetPullStoppedAt keeps the latest timestamp
+func TestSetPullStoppedAt(t *testing.T) {
+	testTask := &Task{}
+
+	t1 := time.Now()
+	t2 := t1.Add(1 * time.Second)
+
+	testTask.SetPullStoppedAt(t2)
+	assert.Equal(t, t2, testTask.GetPullStoppedAt(), "first set of pullStoppedAt should succeed")
+
+	testTask.SetPullStoppedAt(t1)
+	assert.Equal(t, t2, testTask.GetPullStoppedAt(), "set of an earlier pullStoppedAt should have no impact")
+
+	t3 := t2.Add(1 * time.Second)
+	testTask.SetPullStoppedAt(t3)
+	assert.Equal(t, t3, testTask.GetPullStoppedAt(), "set of a later pullStoppedAt should succeed")
+}
+
 // TestSetExecutionStoppedAt tests the task SetExecutionStoppedAt
 func TestSetExecutionStoppedAt(t *testing.T) {
 	testTask := &Task{}
@@ -3768,8 +3788,9 @@ func TestTaskFromACSPerContainerTimeouts(t *testing.T) {
 	taskFromACS := ecsacs.Task{
 		Containers: []*ecsacs.Container{
 			{
-				StartTimeout: aws.Int64(modelTimeout),
-				StopTimeout:  aws.Int64(modelTimeout),
+				StartTimeout:     aws.Int64(modelTimeout),
+				StopTimeout:      aws.Int64(modelTimeout),
+				ImagePullTimeout: aws.Int64(modelTimeout),
 			},
 		},
 	}
@@ -3779,6 +3800,30 @@ func TestTaskFromACSPerContainerTimeouts(t *testing.T) {
 
 	assert.Equal(t, task.Containers[0].StartTimeout, expectedTimeout)
 	assert.Equal(t, task.Containers[0].StopTimeout, expectedTimeout)
+	assert.Equal(t, task.Containers[0].ImagePullTimeout, expectedTimeout)
+	assert.Equal(t, 10*time.Second, task.Containers[0].GetImagePullTimeout())
+}
+
+func TestTaskFromACSContainerRestartPolicy(t *testing.T) {
+	taskFromACS := ecsacs.Task{
+		Containers: []*ecsacs.Container{
+			{
+				RestartPolicy:      aws.String("ON_FAILURE"),
+				RestartMaxAttempts: aws.Int64(3),
+			},
+			{},
+		},
+	}
+	seqNum := int64(42)
+	task, err := TaskFromACS(&taskFromACS, &ecsacs.PayloadMessage{SeqNum: &seqNum})
+	assert.Nil(t, err, "Should be able to handle acs task")
+
+	policy, maxAttempts := task.Containers[0].GetRestartPolicy()
+	assert.Equal(t, "ON_FAILURE", policy)
+	assert.Equal(t, int64(3), maxAttempts)
+	policy, maxAttempts = task.Containers[1].GetRestartPolicy()
+	assert.Empty(t, policy)
+	assert.Zero(t, maxAttempts)
 }
 
 // Tests that ACS Task to Task translation does not fail when ServiceName is missing.
@@ -116,3 +116,9 @@ func (task *Task) BuildCNIConfigAwsvpc(includeIPAMConfig bool, cniConfig *ecscni
 func (task *Task) BuildCNIConfigBridgeMode(cniConfig *ecscni.Config, containerName string) (*ecscni.Config, error) {
 	return nil, errors.New("unsupported platform")
 }
+
+// GetContainerCgroupPath returns the path of a container's cgroup. Task cgroups are not
+// supported on this platform, so the path is always empty.
+func (task *Task) GetContainerCgroupPath(dockerID string) (string, error) {
+	return "", nil
+}
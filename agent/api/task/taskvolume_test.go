@@ -92,6 +92,7 @@ func TestMarshalTaskVolumesEFS(t *testing.T) {
 		"Family": "",
 		"Version": "",
 		"ServiceName": "",
+		"SchedulingStrategy": "",
 		"Containers": null,
 		"associations": null,
 		"resources": null,
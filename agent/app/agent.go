@@ -66,7 +66,9 @@ import (
 	acsclient "github.com/aws/amazon-ecs-agent/ecs-agent/acs/client"
 	apierrors "github.com/aws/amazon-ecs-agent/ecs-agent/api/errors"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/credentials"
+	ecsmetrics "github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/utils/retry"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/wsclient"
 	"github.com/aws/aws-sdk-go/aws"
 	aws_credentials "github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/cihub/seelog"
@@ -107,6 +109,10 @@ const (
 	// as the number of messages in the channel is equal to the number of times we call `getInstanceMetrics`, which collects
 	// metrics from all tasks and containers and put them into one TelemetryMessage object.
 	telemetryChannelDefaultBufferSize = 15
+
+	// persistedTaskDataPruneInterval is the interval at which the persisted data of stopped tasks
+	// older than ECS_PERSISTED_TASK_DATA_MAX_AGE is pruned
+	persistedTaskDataPruneInterval = time.Hour
 )
 
 var (
@@ -158,6 +164,12 @@ type ecsAgent struct {
 	resourceFields              *taskresource.ResourceFields
 	availabilityZone            string
 	latestSeqNumberTaskManifest *int64
+	capabilityUpdates           *acshandler.CapabilityUpdates
+	drainState                  *acshandler.DrainState
+	acsProcessingPauser         *acshandler.ProcessingPauser
+	acsRecentMessages           *acshandler.RecentMessages
+	metricsFactory              ecsmetrics.EntryFactory
+	duplicateInstanceARN        bool
 }
 
 // newAgent returns a new ecsAgent object, but does not start anything
@@ -206,7 +218,7 @@ func newAgent(blackholeEC2Metadata bool, acceptInsecureCert *bool) (agent, error
 
 	var dataClient data.Client
 	if cfg.Checkpoint.Enabled() {
-		dataClient, err = data.New(cfg.DataDir)
+		dataClient, err = data.New(cfg.DataDir, data.WithRecordCompression(cfg.DataStoreCompression.Enabled()))
 		if err != nil {
 			logger.Critical("Error creating Docker client", logger.Fields{
 				field.Error: err,
@@ -243,11 +255,15 @@ func newAgent(blackholeEC2Metadata bool, acceptInsecureCert *bool) (agent, error
 		saveableOptionFactory:       factory.NewSaveableOption(),
 		pauseLoader:                 pause.New(),
 		serviceconnectManager:       engineserviceconnect.NewManager(),
-		cniClient:                   ecscni.NewClient(cfg.CNIPluginsPath),
+		cniClient:                   ecscni.NewClient(cfg.CNIPluginsPath, cfg.CNIMaxConcurrentOperations),
 		metadataManager:             metadataManager,
 		terminationHandler:          sighandlers.StartDefaultTerminationHandler,
 		mobyPlugins:                 mobypkgwrapper.NewPlugins(),
 		latestSeqNumberTaskManifest: &initialSeqNumber,
+		acsProcessingPauser:         acshandler.NewProcessingPauser(),
+		drainState:                  acshandler.NewDrainState(),
+		acsRecentMessages:           acshandler.NewRecentMessages(acshandler.DefaultRecentMessagesCapacity),
+		metricsFactory:              ecsmetrics.NewNopEntryFactory(),
 	}, nil
 }
 
@@ -273,9 +289,20 @@ func (agent *ecsAgent) setTerminationHandler(handler sighandlers.TerminationHand
 	agent.terminationHandler = handler
 }
 
+// dumpRecentACSMessages writes the most recent (redacted) ACS messages to a file in the
+// data directory.
+func (agent *ecsAgent) dumpRecentACSMessages() {
+	path, err := agent.acsRecentMessages.Dump(agent.cfg.DataDir)
+	if err != nil {
+		seelog.Errorf("Unable to dump recent ACS messages: %v", err)
+		return
+	}
+	seelog.Infof("Dumped recent ACS messages to %s", path)
+}
+
 // start starts the ECS Agent
 func (agent *ecsAgent) start() int {
-	sighandlers.StartDebugHandler()
+	sighandlers.StartDebugHandler(agent.dumpRecentACSMessages)
 
 	containerChangeEventStream := eventstream.NewEventStream(containerChangeEventStreamName, agent.ctx)
 	credentialsManager := credentials.NewManager()
@@ -446,6 +473,8 @@ func (agent *ecsAgent) doStart(containerChangeEventStream *eventstream.EventStre
 		deregisterContainerInstanceEventStreamName, agent.ctx)
 	deregisterInstanceEventStream.StartListening()
 	taskHandler := eventhandler.NewTaskHandler(agent.ctx, agent.dataClient, state, client)
+	taskHandler.SetEventBufferLimits(agent.cfg.TaskEventBufferSize, agent.cfg.TaskEventBufferOverflowPolicy)
+	taskHandler.SetMaxSubmitAttempts(agent.cfg.TaskStateChangeMaxSubmitAttempts)
 	attachmentEventHandler := eventhandler.NewAttachmentEventHandler(agent.ctx, agent.dataClient, client)
 	agent.startAsyncRoutines(containerChangeEventStream, credentialsManager, imageManager,
 		taskEngine, deregisterInstanceEventStream, client, taskHandler, attachmentEventHandler, state, doctor)
@@ -576,6 +605,8 @@ func (agent *ecsAgent) newTaskEngine(containerChangeEventStream *eventstream.Eve
 	agent.containerInstanceARN = savedData.containerInstanceARN
 	agent.availabilityZone = savedData.availabilityZone
 	agent.latestSeqNumberTaskManifest = &savedData.latestTaskManifestSeqNum
+	agent.capabilityUpdates = savedData.capabilityUpdates
+	agent.drainState = savedData.drainState
 
 	return savedData.taskEngine, currentEC2InstanceID, nil
 }
@@ -598,15 +629,23 @@ func (agent *ecsAgent) initMetricsEngine() {
 // the healthchecks that the doctor should be running
 func (agent *ecsAgent) newDoctorWithHealthchecks(cluster, containerInstanceARN string) (*doctor.Doctor, error) {
 	// configure the required healthchecks
-	runtimeHealthCheck := dockerdoctor.NewDockerRuntimeHealthcheck(agent.dockerClient)
+	runtimeHealthCheck := dockerdoctor.NewDockerRuntimeHealthcheck(agent.dockerClient,
+		agent.cfg.DockerPingLatencyThreshold)
+	instanceIdentityHealthCheck := dockerdoctor.NewInstanceIdentityHealthcheck(agent.duplicateInstanceARN)
 
 	// put the healthechecks in a list
 	healthcheckList := []doctor.Healthcheck{
 		runtimeHealthCheck,
+		instanceIdentityHealthCheck,
 	}
 
 	// set up the doctor and return it
-	return doctor.NewDoctor(healthcheckList, cluster, containerInstanceARN)
+	newDoctor, err := doctor.NewDoctor(healthcheckList, cluster, containerInstanceARN)
+	if err != nil {
+		return nil, err
+	}
+	newDoctor.SetHealthcheckJitter(agent.cfg.InstanceHealthcheckJitter)
+	return newDoctor, nil
 }
 
 // setClusterInConfig sets the cluster name in the config object based on
@@ -724,6 +763,8 @@ func (agent *ecsAgent) registerContainerInstance(
 	if err != nil {
 		return err
 	}
+	// Capabilities updated by ACS since the container instance last registered
+	agentCapabilities = agent.capabilityUpdates.Apply(agentCapabilities)
 	capabilities := append(agentCapabilities, additionalAttributes...)
 
 	// Get the tags of this container instance defined in config file
@@ -792,15 +833,32 @@ func (agent *ecsAgent) registerContainerInstance(
 // reregisterContainerInstance registers a container instance that has already been
 // registered with ECS. This is for cases where the ECS Agent is being restored
 // from a check point.
+// checkForDuplicateInstanceARN compares the container instance ARN restored
+// from saved state with the one returned on re-registration. A mismatch means
+// the saved state is likely shared with another instance (e.g. a state file
+// baked into an AMI), so the agent is flagged as unhealthy via the doctor.
+func (agent *ecsAgent) checkForDuplicateInstanceARN(registeredARN string) {
+	if registeredARN == "" || registeredARN == agent.containerInstanceARN {
+		return
+	}
+	logger.Critical("Container instance ARN restored from saved state does not match the registered "+
+		"container instance ARN; saved state may be shared with another instance", logger.Fields{
+		"savedContainerInstanceARN":      agent.containerInstanceARN,
+		"registeredContainerInstanceARN": registeredARN,
+	})
+	agent.duplicateInstanceARN = true
+}
+
 func (agent *ecsAgent) reregisterContainerInstance(client api.ECSClient, capabilities []*ecs.Attribute,
 	tags []*ecs.Tag, registrationToken string, platformDevices []*ecs.PlatformDevice, outpostARN string) error {
-	_, availabilityZone, err := client.RegisterContainerInstance(agent.containerInstanceARN, capabilities, tags,
-		registrationToken, platformDevices, outpostARN)
+	containerInstanceARN, availabilityZone, err := client.RegisterContainerInstance(agent.containerInstanceARN,
+		capabilities, tags, registrationToken, platformDevices, outpostARN)
 
 	//set az to agent
 	agent.availabilityZone = availabilityZone
 
 	if err == nil {
+		agent.checkForDuplicateInstanceARN(containerInstanceARN)
 		return nil
 	}
 	logger.Error("Error re-registering container instance", logger.Fields{
@@ -847,8 +905,14 @@ func (agent *ecsAgent) startAsyncRoutines(
 		go agent.startSpotInstanceDrainingPoller(agent.ctx, client)
 	}
 
+	// Start of the periodic pruning of persisted stopped task data
+	if agent.cfg.PersistedTaskDataMaxAge > 0 {
+		go agent.startPersistedTaskDataPruner(agent.ctx)
+	}
+
 	// Agent introspection api
-	go handlers.ServeIntrospectionHTTPEndpoint(agent.ctx, &agent.containerInstanceARN, taskEngine, agent.cfg)
+	go handlers.ServeIntrospectionHTTPEndpoint(agent.ctx, &agent.containerInstanceARN, taskEngine, agent.acsProcessingPauser,
+		taskHandler, agent.drainState, agent.cfg)
 
 	telemetryMessages := make(chan ecstcs.TelemetryMessage, telemetryChannelDefaultBufferSize)
 	healthMessages := make(chan ecstcs.HealthMessage, telemetryChannelDefaultBufferSize)
@@ -902,6 +966,34 @@ func (agent *ecsAgent) startSpotInstanceDrainingPoller(ctx context.Context, clie
 	}
 }
 
+// startPersistedTaskDataPruner prunes the persisted data of the tasks that stopped longer than
+// PersistedTaskDataMaxAge ago, every persistedTaskDataPruneInterval until the context is canceled.
+func (agent *ecsAgent) startPersistedTaskDataPruner(ctx context.Context) {
+	ticker := time.NewTicker(persistedTaskDataPruneInterval)
+	defer ticker.Stop()
+	for {
+		agent.prunePersistedTaskData()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// prunePersistedTaskData prunes the persisted data of the tasks that stopped longer than
+// PersistedTaskDataMaxAge ago.
+func (agent *ecsAgent) prunePersistedTaskData() {
+	prunedIDs, err := agent.dataClient.PruneStoppedTasks(time.Now().Add(-agent.cfg.PersistedTaskDataMaxAge))
+	if err != nil {
+		seelog.Warnf("Failed to prune persisted data of stopped tasks: %v", err)
+		return
+	}
+	if len(prunedIDs) > 0 {
+		seelog.Infof("Pruned persisted data of %d stopped tasks: %v", len(prunedIDs), prunedIDs)
+	}
+}
+
 // spotInstanceDrainingPoller returns true if spot instance interruption has been
 // set AND the container instance state is successfully updated to DRAINING.
 func (agent *ecsAgent) spotInstanceDrainingPoller(client api.ECSClient) bool {
@@ -949,6 +1041,19 @@ func (agent *ecsAgent) startACSSession(
 	taskHandler *eventhandler.TaskHandler,
 	doctor *doctor.Doctor) int {
 
+	acsClientOptions := []acsclient.Option{acsclient.WithMetricsFactory(agent.metricsFactory)}
+	if agent.cfg.ACSPayloadCaptureFile != "" {
+		capture, err := wsclient.NewMessageCapture(agent.cfg.ACSPayloadCaptureFile, wsclient.DefaultMessageCaptureMaxBytes)
+		if err != nil {
+			seelog.Warnf("Unable to capture ACS messages to %s: %v", agent.cfg.ACSPayloadCaptureFile, err)
+		} else {
+			seelog.Infof("Capturing ACS messages, with credentials redacted, to %s", agent.cfg.ACSPayloadCaptureFile)
+			defer capture.Close()
+			acsClientOptions = append(acsClientOptions, acsclient.WithReadMessageHook(capture.Capture))
+		}
+	}
+	acsClientFactory := acsclient.NewACSClientFactory(acsClientOptions...)
+
 	acsSession := acshandler.NewSession(
 		agent.ctx,
 		agent.cfg,
@@ -964,7 +1069,10 @@ func (agent *ecsAgent) startACSSession(
 		taskHandler,
 		agent.latestSeqNumberTaskManifest,
 		doctor,
-		acsclient.NewACSClientFactory(),
+		acsClientFactory,
+		agent.acsProcessingPauser,
+		agent.acsRecentMessages,
+		agent.drainState,
 	)
 	seelog.Info("Beginning Polling for updates")
 	err := acsSession.Start()
@@ -51,6 +51,7 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/version"
 	apierrors "github.com/aws/amazon-ecs-agent/ecs-agent/api/errors"
 	mock_credentials "github.com/aws/amazon-ecs-agent/ecs-agent/credentials/mocks"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/doctor"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	aws_credentials "github.com/aws/aws-sdk-go/aws/credentials"
@@ -427,11 +428,11 @@ func testDoStartHappyPathWithConditions(t *testing.T, blackholed bool, warmPools
 	imageManager.EXPECT().StartImageCleanupProcess(gomock.Any()).MaxTimes(1)
 	dockerClient.EXPECT().ListContainers(gomock.Any(), gomock.Any(), gomock.Any()).Return(
 		dockerapi.ListContainersResponse{}).AnyTimes()
-	client.EXPECT().DiscoverPollEndpoint(gomock.Any()).Do(func(x interface{}) {
+	client.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Do(func(_, x interface{}) {
 		// Ensures that the test waits until acs session has bee started
 		discoverEndpointsInvoked.Done()
 	}).Return("poll-endpoint", nil)
-	client.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return("acs-endpoint", nil).AnyTimes()
+	client.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return("acs-endpoint", nil).AnyTimes()
 	client.EXPECT().DiscoverTelemetryEndpoint(gomock.Any()).Do(func(x interface{}) {
 		// Ensures that the test waits until telemetry session has bee started
 		discoverEndpointsInvoked.Done()
@@ -953,6 +954,75 @@ func TestReregisterContainerInstanceHappyPath(t *testing.T) {
 
 	err := agent.registerContainerInstance(client, nil)
 	assert.NoError(t, err)
+	assert.False(t, agent.duplicateInstanceARN)
+}
+
+func TestReregisterContainerInstanceDuplicateInstanceARN(t *testing.T) {
+	// Simulates saved state shared with another instance: re-registering with the
+	// persisted container instance ARN yields a different one.
+	registeredContainerInstanceARN := "arn:aws:ecs:us-west-2:123456789012:container-instance/registered"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDockerClient := mock_dockerapi.NewMockDockerClient(ctrl)
+	client := mock_api.NewMockECSClient(ctrl)
+	mockCredentialsProvider := app_mocks.NewMockProvider(ctrl)
+	mockMobyPlugins := mock_mobypkgwrapper.NewMockPlugins(ctrl)
+	mockEC2Metadata := mock_ec2.NewMockEC2MetadataClient(ctrl)
+	mockPauseLoader := mock_loader.NewMockLoader(ctrl)
+
+	mockPauseLoader.EXPECT().IsLoaded(gomock.Any()).Return(false, nil).AnyTimes()
+	mockPauseLoader.EXPECT().LoadImage(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	mockServiceConnectManager := mock_serviceconnect.NewMockManager(ctrl)
+	mockServiceConnectManager.EXPECT().IsLoaded(gomock.Any()).Return(true, nil).AnyTimes()
+	mockServiceConnectManager.EXPECT().GetLoadedAppnetVersion().AnyTimes()
+	mockServiceConnectManager.EXPECT().GetCapabilitiesForAppnetInterfaceVersion("").AnyTimes()
+	mockServiceConnectManager.EXPECT().SetECSClient(gomock.Any(), gomock.Any()).AnyTimes()
+	gomock.InOrder(
+		mockCredentialsProvider.EXPECT().Retrieve().Return(aws_credentials.Value{}, nil),
+		mockDockerClient.EXPECT().SupportedVersions().Return(nil),
+		mockDockerClient.EXPECT().KnownVersions().Return(nil),
+		mockMobyPlugins.EXPECT().Scan().AnyTimes().Return([]string{""}, nil),
+		mockDockerClient.EXPECT().ListPluginsWithFilters(gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any()).AnyTimes().Return([]string{}, nil),
+		client.EXPECT().RegisterContainerInstance(containerInstanceARN, gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any()).Return(registeredContainerInstanceARN, availabilityZone, nil),
+	)
+	cfg := getTestConfig()
+	cfg.Cluster = clusterName
+	ctx, cancel := context.WithCancel(context.TODO())
+	// Cancel the context to cancel async routines
+	defer cancel()
+
+	mockEC2Metadata.EXPECT().OutpostARN().Return("", nil)
+
+	agent := &ecsAgent{
+		ctx:                   ctx,
+		cfg:                   &cfg,
+		dockerClient:          mockDockerClient,
+		pauseLoader:           mockPauseLoader,
+		credentialProvider:    aws_credentials.NewCredentials(mockCredentialsProvider),
+		mobyPlugins:           mockMobyPlugins,
+		ec2MetadataClient:     mockEC2Metadata,
+		serviceconnectManager: mockServiceConnectManager,
+	}
+	agent.containerInstanceARN = containerInstanceARN
+	agent.availabilityZone = availabilityZone
+
+	err := agent.registerContainerInstance(client, nil)
+	assert.NoError(t, err)
+	assert.True(t, agent.duplicateInstanceARN)
+
+	agentDoctor, err := agent.newDoctorWithHealthchecks(clusterName, containerInstanceARN)
+	require.NoError(t, err)
+	var identityStatuses []doctor.HealthcheckStatus
+	for _, healthcheck := range *agentDoctor.GetHealthchecks() {
+		if healthcheck.GetHealthcheckType() == doctor.HealthcheckTypeAgent {
+			identityStatuses = append(identityStatuses, healthcheck.RunCheck())
+		}
+	}
+	assert.Equal(t, []doctor.HealthcheckStatus{doctor.HealthcheckStatusImpaired}, identityStatuses)
 }
 
 func TestReregisterContainerInstanceInstanceTypeChanged(t *testing.T) {
@@ -93,11 +93,11 @@ func TestDoStartTaskENIHappyPath(t *testing.T) {
 	dockerClient.EXPECT().ListContainers(gomock.Any(), gomock.Any(), gomock.Any()).Return(
 		dockerapi.ListContainersResponse{}).AnyTimes()
 	imageManager.EXPECT().StartImageCleanupProcess(gomock.Any()).MaxTimes(1)
-	client.EXPECT().DiscoverPollEndpoint(gomock.Any()).Do(func(x interface{}) {
+	client.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Do(func(_, x interface{}) {
 		// Ensures that the test waits until acs session has bee started
 		discoverEndpointsInvoked.Done()
 	}).Return("poll-endpoint", nil)
-	client.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return("acs-endpoint", nil).AnyTimes()
+	client.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return("acs-endpoint", nil).AnyTimes()
 	client.EXPECT().DiscoverTelemetryEndpoint(gomock.Any()).Do(func(x interface{}) {
 		// Ensures that the test waits until telemetry session has bee started
 		discoverEndpointsInvoked.Done()
@@ -476,11 +476,11 @@ func TestDoStartCgroupInitHappyPath(t *testing.T) {
 		state.EXPECT().AllImageStates().Return(nil),
 		state.EXPECT().AllENIAttachments().Return(nil),
 		state.EXPECT().AllTasks().Return(nil),
-		client.EXPECT().DiscoverPollEndpoint(gomock.Any()).Do(func(x interface{}) {
+		client.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Do(func(_, x interface{}) {
 			// Ensures that the test waits until acs session has bee started
 			discoverEndpointsInvoked.Done()
 		}).Return("poll-endpoint", nil),
-		client.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return("acs-endpoint", nil).AnyTimes(),
+		client.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return("acs-endpoint", nil).AnyTimes(),
 		client.EXPECT().DiscoverTelemetryEndpoint(gomock.Any()).Do(func(x interface{}) {
 			// Ensures that the test waits until telemetry session has bee started
 			discoverEndpointsInvoked.Done()
@@ -642,11 +642,11 @@ func TestDoStartGPUManagerHappyPath(t *testing.T) {
 		state.EXPECT().AllImageStates().Return(nil),
 		state.EXPECT().AllENIAttachments().Return(nil),
 		state.EXPECT().AllTasks().Return(nil),
-		client.EXPECT().DiscoverPollEndpoint(gomock.Any()).Do(func(x interface{}) {
+		client.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Do(func(_, x interface{}) {
 			// Ensures that the test waits until acs session has been started
 			discoverEndpointsInvoked.Done()
 		}).Return("poll-endpoint", nil),
-		client.EXPECT().DiscoverPollEndpoint(gomock.Any()).Return("acs-endpoint", nil).AnyTimes(),
+		client.EXPECT().DiscoverPollEndpoint(gomock.Any(), gomock.Any()).Return("acs-endpoint", nil).AnyTimes(),
 		client.EXPECT().DiscoverTelemetryEndpoint(gomock.Any()).Do(func(x interface{}) {
 			// Ensures that the test waits until telemetry session has been started
 			discoverEndpointsInvoked.Done()
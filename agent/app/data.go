@@ -17,6 +17,7 @@ import (
 	"strconv"
 	"strings"
 
+	acshandler "github.com/aws/amazon-ecs-agent/agent/acs/handler"
 	"github.com/aws/amazon-ecs-agent/agent/data"
 	"github.com/aws/amazon-ecs-agent/agent/engine"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
@@ -41,6 +42,8 @@ type savedData struct {
 	containerInstanceARN     string
 	ec2InstanceID            string
 	latestTaskManifestSeqNum int64
+	capabilityUpdates        *acshandler.CapabilityUpdates
+	drainState               *acshandler.DrainState
 }
 
 // loadData loads data from previous checkpoint file, if any, with backward compatibility preserved. It first tries to
@@ -136,6 +139,14 @@ func (agent *ecsAgent) loadDataFromBoltDB(s *savedData) error {
 			return errors.Wrapf(err, "failed to convert saved task manifest sequence number to int64: %s", seqNumStr)
 		}
 	}
+	s.capabilityUpdates, err = acshandler.LoadCapabilityUpdates(agent.dataClient)
+	if err != nil {
+		return err
+	}
+	s.drainState, err = acshandler.LoadDrainState(agent.dataClient)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
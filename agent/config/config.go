@@ -118,6 +118,9 @@ const (
 	// defaultCNIPluginsPath is the default path where cni binaries are located
 	defaultCNIPluginsPath = "/amazon-ecs-cni-plugins"
 
+	// DefaultCNIMaxConcurrentOperations is the default maximum number of concurrent cni operations
+	DefaultCNIMaxConcurrentOperations = 10
+
 	// DefaultMinSupportedCNIVersion denotes the minimum version of cni spec required
 	DefaultMinSupportedCNIVersion = "0.3.0"
 
@@ -129,9 +132,106 @@ const (
 	// will be able to send out 40 rps.
 	DefaultTaskMetadataSteadyStateRate = 40
 
+	// DefaultTaskEventBufferSize is the default number of task state change events that may be
+	// queued to be sent to ECS before the overflow policy is applied
+	DefaultTaskEventBufferSize = 1000
+
+	// TaskEventBufferOverflowPolicyBlock blocks new task state change events until there is
+	// room for them in the task event buffer
+	TaskEventBufferOverflowPolicyBlock = "block"
+
+	// TaskEventBufferOverflowPolicyDropOldest drops the oldest queued task state change event
+	// to make room for a new one when the task event buffer is full
+	TaskEventBufferOverflowPolicyDropOldest = "drop-oldest"
+
+	// TaskEventBufferOverflowPolicyCoalesce replaces the latest queued task state change event of
+	// the same task with a new one when the task event buffer is full, and blocks new events of
+	// tasks that have no queued event
+	TaskEventBufferOverflowPolicyCoalesce = "coalesce"
+
+	// TaskEventBufferOverflowPolicyDropOldestNonStopped drops the oldest queued task state change
+	// event that does not report a STOPPED status to make room for a new one when the task event
+	// buffer is full. The oldest event is dropped if every queued event reports a STOPPED status
+	TaskEventBufferOverflowPolicyDropOldestNonStopped = "drop-oldest-non-stopped"
+
 	// DefaultTaskMetadataBurstRate is set to handle 60 burst requests at once
 	DefaultTaskMetadataBurstRate = 60
 
+	// DefaultTaskMetadataTagLookupMaxAttempts is the default number of attempts made for each
+	// ECS tag lookup served by the task metadata endpoint when the lookup is throttled
+	DefaultTaskMetadataTagLookupMaxAttempts = 3
+
+	// DefaultTaskMetadataTagLookupMinBackoff is the default minimum backoff between throttled
+	// task metadata tag lookups
+	DefaultTaskMetadataTagLookupMinBackoff = 100 * time.Millisecond
+
+	// DefaultTaskMetadataTagLookupMaxBackoff is the default maximum backoff between throttled
+	// task metadata tag lookups
+	DefaultTaskMetadataTagLookupMaxBackoff = time.Second
+
+	// DefaultACSCredentialsRefreshConcurrency is the default maximum number of credentials
+	// refresh messages from ACS that are applied concurrently
+	DefaultACSCredentialsRefreshConcurrency = 4
+
+	// DefaultACSHandlerStallThreshold is the default time handling a single ACS message may take
+	// before the message handling loop is considered stalled
+	DefaultACSHandlerStallThreshold = 5 * time.Minute
+
+	// DefaultACSConnectFailureWarnThreshold is the default number of consecutive failures to
+	// connect to ACS from which the failures are logged as warnings
+	DefaultACSConnectFailureWarnThreshold = 3
+
+	// DefaultACSConnectFailureErrorThreshold is the default number of consecutive failures to
+	// connect to ACS from which the failures are logged as errors
+	DefaultACSConnectFailureErrorThreshold = 10
+
+	// DefaultACSMaxSessionsPerInstance is the default maximum number of concurrent ACS sessions
+	// for a container instance
+	DefaultACSMaxSessionsPerInstance = 1
+
+	// DefaultDockerPingLatencyThreshold is the default Docker daemon ping latency above which
+	// the container runtime is reported as impaired
+	DefaultDockerPingLatencyThreshold = time.Second
+
+	// DefaultInstanceHealthcheckJitter is the default window over which the instance health checks
+	// are staggered
+	DefaultInstanceHealthcheckJitter = 5 * time.Second
+
+	// MaxInstanceHealthcheckJitter is the maximum window over which the instance health checks can
+	// be staggered, which keeps the health checks well within the ACS heartbeat interval
+	MaxInstanceHealthcheckJitter = 30 * time.Second
+
+	// DefaultDiscoverPollEndpointMinBackoff is the default minimum backoff between DiscoverPollEndpoint
+	// retries
+	DefaultDiscoverPollEndpointMinBackoff = time.Second
+
+	// DefaultDiscoverPollEndpointMaxBackoff is the default maximum backoff between DiscoverPollEndpoint
+	// retries
+	DefaultDiscoverPollEndpointMaxBackoff = 5 * time.Minute
+
+	// DefaultDiscoverPollEndpointTimeout is the default time to wait for DiscoverPollEndpoint to
+	// return before retrying it
+	DefaultDiscoverPollEndpointTimeout = 30 * time.Second
+
+	// DefaultPollEndpointCacheTTL is the default time a discovered ACS endpoint is reused before it
+	// is discovered again
+	DefaultPollEndpointCacheTTL = 12 * time.Hour
+
+	// DefaultDiscoverAuthFailureThreshold is the default number of consecutive authentication errors
+	// from DiscoverPollEndpoint after which the agent reports itself as impaired
+	DefaultDiscoverAuthFailureThreshold = 5
+
+	// DefaultDiscoverDNSRetryAttempts is the default number of DiscoverPollEndpoint DNS resolution
+	// errors that are retried after a fixed interval, rather than with backoff
+	DefaultDiscoverDNSRetryAttempts = 5
+
+	// DefaultDiscoverDNSRetryInterval is the default interval between the first retries of
+	// DiscoverPollEndpoint DNS resolution errors
+	DefaultDiscoverDNSRetryInterval = 500 * time.Millisecond
+
+	// MinACSAgentMetricsInterval is the minimum interval at which agent metrics can be sent to ACS
+	MinACSAgentMetricsInterval = 10 * time.Second
+
 	//Known cached image names
 	CachedImageNameAgentContainer = "amazon/amazon-ecs-agent:latest"
 
@@ -361,6 +461,140 @@ func (cfg *Config) validateAndOverrideBounds() error {
 		cfg.TaskMetadataBurstRate = DefaultTaskMetadataBurstRate
 	}
 
+	if cfg.TaskMetadataTagLookupMaxAttempts <= 0 {
+		seelog.Warnf("Invalid value for ECS_TASK_METADATA_TAG_LOOKUP_MAX_ATTEMPTS, will be overridden with the default value: %d. Parsed value: %d.", DefaultTaskMetadataTagLookupMaxAttempts, cfg.TaskMetadataTagLookupMaxAttempts)
+		cfg.TaskMetadataTagLookupMaxAttempts = DefaultTaskMetadataTagLookupMaxAttempts
+	}
+
+	if cfg.ACSCredentialsRefreshConcurrency <= 0 {
+		seelog.Warnf("Invalid value for ECS_ACS_CREDENTIALS_REFRESH_CONCURRENCY, will be overridden with the default value: %d. Parsed value: %d.", DefaultACSCredentialsRefreshConcurrency, cfg.ACSCredentialsRefreshConcurrency)
+		cfg.ACSCredentialsRefreshConcurrency = DefaultACSCredentialsRefreshConcurrency
+	}
+
+	if cfg.ACSHandlerStallThreshold <= 0 {
+		seelog.Warnf("Invalid value for ECS_ACS_HANDLER_STALL_THRESHOLD, will be overridden with the default value: %s. Parsed value: %s.", DefaultACSHandlerStallThreshold, cfg.ACSHandlerStallThreshold)
+		cfg.ACSHandlerStallThreshold = DefaultACSHandlerStallThreshold
+	}
+
+	if cfg.ACSConnectFailureWarnThreshold <= 0 {
+		seelog.Warnf("Invalid value for ECS_ACS_CONNECT_FAILURE_WARN_THRESHOLD, will be overridden with the default value: %d. Parsed value: %d.", DefaultACSConnectFailureWarnThreshold, cfg.ACSConnectFailureWarnThreshold)
+		cfg.ACSConnectFailureWarnThreshold = DefaultACSConnectFailureWarnThreshold
+	}
+
+	if cfg.ACSConnectFailureErrorThreshold < cfg.ACSConnectFailureWarnThreshold {
+		seelog.Warnf("Value for ECS_ACS_CONNECT_FAILURE_ERROR_THRESHOLD is below the warn threshold, will be overridden with the warn threshold: %d. Parsed value: %d.", cfg.ACSConnectFailureWarnThreshold, cfg.ACSConnectFailureErrorThreshold)
+		cfg.ACSConnectFailureErrorThreshold = cfg.ACSConnectFailureWarnThreshold
+	}
+
+	if cfg.ACSMaxSessionsPerInstance <= 0 {
+		seelog.Warnf("Invalid value for ECS_ACS_MAX_SESSIONS_PER_INSTANCE, will be overridden with the default value: %d. Parsed value: %d.", DefaultACSMaxSessionsPerInstance, cfg.ACSMaxSessionsPerInstance)
+		cfg.ACSMaxSessionsPerInstance = DefaultACSMaxSessionsPerInstance
+	}
+
+	if cfg.DockerPingLatencyThreshold <= 0 {
+		seelog.Warnf("Invalid value for ECS_DOCKER_PING_LATENCY_THRESHOLD, will be overridden with the default value: %s. Parsed value: %s.", DefaultDockerPingLatencyThreshold, cfg.DockerPingLatencyThreshold)
+		cfg.DockerPingLatencyThreshold = DefaultDockerPingLatencyThreshold
+	}
+
+	if cfg.InstanceHealthcheckJitter < 0 {
+		seelog.Warnf("Invalid value for ECS_INSTANCE_HEALTHCHECK_JITTER, will be overridden with the default value: %s. Parsed value: %s.", DefaultInstanceHealthcheckJitter, cfg.InstanceHealthcheckJitter)
+		cfg.InstanceHealthcheckJitter = DefaultInstanceHealthcheckJitter
+	} else if cfg.InstanceHealthcheckJitter > MaxInstanceHealthcheckJitter {
+		seelog.Warnf("ECS_INSTANCE_HEALTHCHECK_JITTER is above the maximum, will be overridden with the maximum value: %s. Parsed value: %s.", MaxInstanceHealthcheckJitter, cfg.InstanceHealthcheckJitter)
+		cfg.InstanceHealthcheckJitter = MaxInstanceHealthcheckJitter
+	}
+
+	if cfg.TaskMetadataTagLookupMinBackoff <= 0 || cfg.TaskMetadataTagLookupMaxBackoff < cfg.TaskMetadataTagLookupMinBackoff {
+		seelog.Warnf("Invalid values for task metadata tag lookup backoff, will be overridden with default values: %s,%s. Parsed values: %s,%s.", DefaultTaskMetadataTagLookupMinBackoff, DefaultTaskMetadataTagLookupMaxBackoff, cfg.TaskMetadataTagLookupMinBackoff, cfg.TaskMetadataTagLookupMaxBackoff)
+		cfg.TaskMetadataTagLookupMinBackoff = DefaultTaskMetadataTagLookupMinBackoff
+		cfg.TaskMetadataTagLookupMaxBackoff = DefaultTaskMetadataTagLookupMaxBackoff
+	}
+
+	if cfg.DiscoverPollEndpointMinBackoff <= 0 || cfg.DiscoverPollEndpointMaxBackoff < cfg.DiscoverPollEndpointMinBackoff {
+		seelog.Warnf("Invalid values for DiscoverPollEndpoint backoff, will be overridden with default values: %s,%s. Parsed values: %s,%s.", DefaultDiscoverPollEndpointMinBackoff, DefaultDiscoverPollEndpointMaxBackoff, cfg.DiscoverPollEndpointMinBackoff, cfg.DiscoverPollEndpointMaxBackoff)
+		cfg.DiscoverPollEndpointMinBackoff = DefaultDiscoverPollEndpointMinBackoff
+		cfg.DiscoverPollEndpointMaxBackoff = DefaultDiscoverPollEndpointMaxBackoff
+	}
+
+	if cfg.DiscoverPollEndpointTimeout <= 0 {
+		seelog.Warnf("Invalid value for ECS_DISCOVER_POLL_ENDPOINT_TIMEOUT, will be overridden with the default value: %s. Parsed value: %s.", DefaultDiscoverPollEndpointTimeout, cfg.DiscoverPollEndpointTimeout)
+		cfg.DiscoverPollEndpointTimeout = DefaultDiscoverPollEndpointTimeout
+	}
+
+	if cfg.PollEndpointCacheTTL <= 0 {
+		seelog.Warnf("Invalid value for ECS_POLL_ENDPOINT_CACHE_TTL, will be overridden with the default value: %s. Parsed value: %s.", DefaultPollEndpointCacheTTL, cfg.PollEndpointCacheTTL)
+		cfg.PollEndpointCacheTTL = DefaultPollEndpointCacheTTL
+	}
+
+	if cfg.DiscoverAuthFailureThreshold <= 0 {
+		seelog.Warnf("Invalid value for ECS_DISCOVER_POLL_ENDPOINT_AUTH_FAILURE_THRESHOLD, will be overridden with the default value: %d. Parsed value: %d.", DefaultDiscoverAuthFailureThreshold, cfg.DiscoverAuthFailureThreshold)
+		cfg.DiscoverAuthFailureThreshold = DefaultDiscoverAuthFailureThreshold
+	}
+
+	if cfg.CNIMaxConcurrentOperations <= 0 {
+		seelog.Warnf("Invalid value for ECS_CNI_MAX_CONCURRENT_OPERATIONS, will be overridden with the default value: %d. Parsed value: %d.", DefaultCNIMaxConcurrentOperations, cfg.CNIMaxConcurrentOperations)
+		cfg.CNIMaxConcurrentOperations = DefaultCNIMaxConcurrentOperations
+	}
+
+	if cfg.DiscoverDNSRetryAttempts < 0 {
+		seelog.Warnf("Invalid value for ECS_DISCOVER_POLL_ENDPOINT_DNS_RETRY_ATTEMPTS, will be overridden with the default value: %d. Parsed value: %d.", DefaultDiscoverDNSRetryAttempts, cfg.DiscoverDNSRetryAttempts)
+		cfg.DiscoverDNSRetryAttempts = DefaultDiscoverDNSRetryAttempts
+	}
+
+	if cfg.DiscoverDNSRetryInterval <= 0 {
+		seelog.Warnf("Invalid value for ECS_DISCOVER_POLL_ENDPOINT_DNS_RETRY_INTERVAL, will be overridden with the default value: %s. Parsed value: %s.", DefaultDiscoverDNSRetryInterval, cfg.DiscoverDNSRetryInterval)
+		cfg.DiscoverDNSRetryInterval = DefaultDiscoverDNSRetryInterval
+	}
+
+	if cfg.StatsSampleBufferMaxMemoryMiB < 0 {
+		seelog.Warnf("Invalid value for ECS_STATS_SAMPLE_BUFFER_MAX_MEMORY_MIB, retained stats samples will not be capped. Parsed value: %d.", cfg.StatsSampleBufferMaxMemoryMiB)
+		cfg.StatsSampleBufferMaxMemoryMiB = 0
+	}
+
+	if cfg.TaskEventBufferSize <= 0 {
+		seelog.Warnf("Invalid value for ECS_TASK_EVENT_BUFFER_SIZE, will be overridden with the default value: %d. Parsed value: %d.", DefaultTaskEventBufferSize, cfg.TaskEventBufferSize)
+		cfg.TaskEventBufferSize = DefaultTaskEventBufferSize
+	}
+
+	if cfg.TaskEventBufferOverflowPolicy != TaskEventBufferOverflowPolicyBlock &&
+		cfg.TaskEventBufferOverflowPolicy != TaskEventBufferOverflowPolicyDropOldest &&
+		cfg.TaskEventBufferOverflowPolicy != TaskEventBufferOverflowPolicyCoalesce &&
+		cfg.TaskEventBufferOverflowPolicy != TaskEventBufferOverflowPolicyDropOldestNonStopped {
+		seelog.Warnf("Invalid value for ECS_TASK_EVENT_BUFFER_OVERFLOW_POLICY, will be overridden with the default value: %s. Parsed value: %s.", TaskEventBufferOverflowPolicyDropOldestNonStopped, cfg.TaskEventBufferOverflowPolicy)
+		cfg.TaskEventBufferOverflowPolicy = TaskEventBufferOverflowPolicyDropOldestNonStopped
+	}
+
+	if cfg.TaskStateChangeMaxSubmitAttempts < 0 {
+		seelog.Warnf("Invalid value for ECS_TASK_STATE_CHANGE_MAX_SUBMIT_ATTEMPTS, state changes will be retried until they are submitted. Parsed value: %d.", cfg.TaskStateChangeMaxSubmitAttempts)
+		cfg.TaskStateChangeMaxSubmitAttempts = 0
+	}
+
+	if cfg.TaskMetadataServerMaxLifetime < 0 {
+		seelog.Warnf("Invalid value for ECS_TASK_METADATA_SERVER_MAX_LIFETIME, the task metadata server will not be recreated. Parsed value: %s.", cfg.TaskMetadataServerMaxLifetime)
+		cfg.TaskMetadataServerMaxLifetime = 0
+	}
+
+	if cfg.ACSSessionMaxLifetime < 0 {
+		seelog.Warnf("Invalid value for ECS_ACS_SESSION_MAX_LIFETIME, the ACS session will not be ended. Parsed value: %s.", cfg.ACSSessionMaxLifetime)
+		cfg.ACSSessionMaxLifetime = 0
+	}
+
+	if cfg.PersistedTaskDataMaxAge < 0 {
+		seelog.Warnf("Invalid value for ECS_PERSISTED_TASK_DATA_MAX_AGE, stopped task data will not be pruned. Parsed value: %s.", cfg.PersistedTaskDataMaxAge)
+		cfg.PersistedTaskDataMaxAge = 0
+	} else if cfg.PersistedTaskDataMaxAge > 0 && cfg.PersistedTaskDataMaxAge < cfg.TaskCleanupWaitDuration {
+		seelog.Warnf("Value for ECS_PERSISTED_TASK_DATA_MAX_AGE is below the task cleanup wait duration, will be overridden with the task cleanup wait duration: %s. Parsed value: %s.", cfg.TaskCleanupWaitDuration, cfg.PersistedTaskDataMaxAge)
+		cfg.PersistedTaskDataMaxAge = cfg.TaskCleanupWaitDuration
+	}
+
+	if cfg.ACSAgentMetricsInterval < 0 {
+		seelog.Warnf("Invalid value for ECS_ACS_AGENT_METRICS_INTERVAL, agent metrics will not be sent to ACS. Parsed value: %s.", cfg.ACSAgentMetricsInterval)
+		cfg.ACSAgentMetricsInterval = 0
+	} else if cfg.ACSAgentMetricsInterval > 0 && cfg.ACSAgentMetricsInterval < MinACSAgentMetricsInterval {
+		seelog.Warnf("Value for ECS_ACS_AGENT_METRICS_INTERVAL is below the minimum, will be overridden with the minimum value: %s. Parsed value: %s.", MinACSAgentMetricsInterval, cfg.ACSAgentMetricsInterval)
+		cfg.ACSAgentMetricsInterval = MinACSAgentMetricsInterval
+	}
+
 	// check the PollMetrics specific configurations
 	cfg.pollMetricsOverrides()
 
@@ -518,6 +752,7 @@ func environmentConfig() (Config, error) {
 	containerInstanceTags, errs := parseContainerInstanceTags(errs)
 
 	additionalLocalRoutes, errs := parseAdditionalLocalRoutes(errs)
+	agentAPIAllowedSourceCIDRs, errs := parseAgentAPIAllowedSourceCIDRs(errs)
 
 	var err error
 	if len(errs) > 0 {
@@ -567,6 +802,7 @@ func environmentConfig() (Config, error) {
 		ImageCleanupExclusionList:           parseImageCleanupExclusionList("ECS_EXCLUDE_UNTRACKED_IMAGE"),
 		InstanceAttributes:                  instanceAttributes,
 		CNIPluginsPath:                      os.Getenv("ECS_CNI_PLUGINS_PATH"),
+		CNIMaxConcurrentOperations:          parseEnvVariableInt("ECS_CNI_MAX_CONCURRENT_OPERATIONS"),
 		AWSVPCBlockInstanceMetdata:          parseBooleanDefaultFalseConfig("ECS_AWSVPC_BLOCK_IMDS"),
 		AWSVPCAdditionalLocalRoutes:         additionalLocalRoutes,
 		ContainerMetadataEnabled:            parseBooleanDefaultFalseConfig("ECS_ENABLE_CONTAINER_METADATA"),
@@ -580,6 +816,7 @@ func environmentConfig() (Config, error) {
 		ContainerInstancePropagateTagsFrom:  parseContainerInstancePropagateTagsFrom(),
 		PollMetrics:                         parseBooleanDefaultFalseConfig("ECS_POLL_METRICS"),
 		PollingMetricsWaitDuration:          parseEnvVariableDuration("ECS_POLLING_METRICS_WAIT_DURATION"),
+		StatsSampleBufferMaxMemoryMiB:       parseEnvVariableInt("ECS_STATS_SAMPLE_BUFFER_MAX_MEMORY_MIB"),
 		DisableDockerHealthCheck:            parseBooleanDefaultFalseConfig("ECS_DISABLE_DOCKER_HEALTH_CHECK"),
 		GPUSupportEnabled:                   utils.ParseBool(os.Getenv("ECS_ENABLE_GPU_SUPPORT"), false),
 		InferentiaSupportEnabled:            utils.ParseBool(os.Getenv("ECS_ENABLE_INF_SUPPORT"), false),
@@ -593,9 +830,46 @@ func environmentConfig() (Config, error) {
 		FSxWindowsFileServerCapable:         parseFSxWindowsFileServerCapability(),
 		External:                            parseBooleanDefaultFalseConfig("ECS_EXTERNAL"),
 		EnableRuntimeStats:                  parseBooleanDefaultFalseConfig("ECS_ENABLE_RUNTIME_STATS"),
+		DisableIntrospectionEndpoint:        parseBooleanDefaultFalseConfig("ECS_DISABLE_INTROSPECTION_ENDPOINT"),
 		ShouldExcludeIPv6PortBinding:        parseBooleanDefaultTrueConfig("ECS_EXCLUDE_IPV6_PORTBINDING"),
 		WarmPoolsSupport:                    parseBooleanDefaultFalseConfig("ECS_WARM_POOLS_CHECK"),
 		DynamicHostPortRange:                parseDynamicHostPortRange("ECS_DYNAMIC_HOST_PORT_RANGE"),
+		ACSExpectedServerName:               os.Getenv("ECS_ACS_EXPECTED_SERVER_NAME"),
+		EnableACSProcessingPauseEndpoint:    parseBooleanDefaultFalseConfig("ECS_ENABLE_ACS_PROCESSING_PAUSE_ENDPOINT"),
+		ACSAckAfterTaskPersisted:            parseBooleanDefaultFalseConfig("ECS_ACS_ACK_AFTER_TASK_PERSISTED"),
+		ACSTLSSessionResumption:             parseBooleanDefaultFalseConfig("ECS_ACS_TLS_SESSION_RESUMPTION"),
+		ACSCredentialsRefreshConcurrency:    parseEnvVariableInt("ECS_ACS_CREDENTIALS_REFRESH_CONCURRENCY"),
+		ACSConnectFailureWarnThreshold:      parseEnvVariableInt("ECS_ACS_CONNECT_FAILURE_WARN_THRESHOLD"),
+		ACSConnectFailureErrorThreshold:     parseEnvVariableInt("ECS_ACS_CONNECT_FAILURE_ERROR_THRESHOLD"),
+		ACSMaxSessionsPerInstance:           parseEnvVariableInt("ECS_ACS_MAX_SESSIONS_PER_INSTANCE"),
+		ACSSessionMaxLifetime:               parseEnvVariableDuration("ECS_ACS_SESSION_MAX_LIFETIME"),
+		DockerPingLatencyThreshold:          parseEnvVariableDuration("ECS_DOCKER_PING_LATENCY_THRESHOLD"),
+		ACSMessageProcessingTimeouts:        parseACSMessageProcessingTimeouts(),
+		ACSHandlerStallThreshold:            parseEnvVariableDuration("ECS_ACS_HANDLER_STALL_THRESHOLD"),
+		AgentAPIAllowedSourceCIDRs:          agentAPIAllowedSourceCIDRs,
+		InstanceHealthcheckJitter:           parseEnvVariableDuration("ECS_INSTANCE_HEALTHCHECK_JITTER"),
+		DataStoreCompression:                parseBooleanDefaultFalseConfig("ECS_ENABLE_DATA_STORE_COMPRESSION"),
+		PersistedTaskDataMaxAge:             parseEnvVariableDuration("ECS_PERSISTED_TASK_DATA_MAX_AGE"),
+		TaskMetadataUnixSocketPath:          os.Getenv("ECS_TASK_METADATA_UNIX_SOCKET_PATH"),
+		TaskMetadataServerMaxLifetime:       parseEnvVariableDuration("ECS_TASK_METADATA_SERVER_MAX_LIFETIME"),
+		TaskEventBufferSize:                 parseEnvVariableInt("ECS_TASK_EVENT_BUFFER_SIZE"),
+		TaskEventBufferOverflowPolicy:       os.Getenv("ECS_TASK_EVENT_BUFFER_OVERFLOW_POLICY"),
+		TaskStateChangeMaxSubmitAttempts:    parseEnvVariableInt("ECS_TASK_STATE_CHANGE_MAX_SUBMIT_ATTEMPTS"),
+		TaskMetadataCgroupPathEnabled:       parseBooleanDefaultFalseConfig("ECS_ENABLE_TASK_METADATA_CGROUP_PATH"),
+		TaskMetadataNanosecondTimestamps:    parseBooleanDefaultFalseConfig("ECS_TASK_METADATA_NANOSECOND_TIMESTAMPS"),
+		DiscoverPollEndpointMinBackoff:      parseEnvVariableDuration("ECS_DISCOVER_POLL_ENDPOINT_MIN_BACKOFF"),
+		DiscoverPollEndpointMaxBackoff:      parseEnvVariableDuration("ECS_DISCOVER_POLL_ENDPOINT_MAX_BACKOFF"),
+		DiscoverPollEndpointTimeout:         parseEnvVariableDuration("ECS_DISCOVER_POLL_ENDPOINT_TIMEOUT"),
+		PollEndpointCacheTTL:                parseEnvVariableDuration("ECS_POLL_ENDPOINT_CACHE_TTL"),
+		DiscoverAuthFailureThreshold:        parseEnvVariableInt("ECS_DISCOVER_POLL_ENDPOINT_AUTH_FAILURE_THRESHOLD"),
+		DiscoverDNSRetryAttempts:            parseEnvVariableInt("ECS_DISCOVER_POLL_ENDPOINT_DNS_RETRY_ATTEMPTS"),
+		DiscoverDNSRetryInterval:            parseEnvVariableDuration("ECS_DISCOVER_POLL_ENDPOINT_DNS_RETRY_INTERVAL"),
+		ACSURLLoggingEnabled:                parseBooleanDefaultFalseConfig("ECS_ACS_URL_LOGGING_ENABLED"),
+		ACSAgentMetricsInterval:             parseEnvVariableDuration("ECS_ACS_AGENT_METRICS_INTERVAL"),
+		ACSPayloadCaptureFile:               os.Getenv("ECS_ACS_PAYLOAD_CAPTURE_FILE"),
+		TaskMetadataTagLookupMaxAttempts:    parseEnvVariableInt("ECS_TASK_METADATA_TAG_LOOKUP_MAX_ATTEMPTS"),
+		TaskMetadataTagLookupMinBackoff:     parseEnvVariableDuration("ECS_TASK_METADATA_TAG_LOOKUP_MIN_BACKOFF"),
+		TaskMetadataTagLookupMaxBackoff:     parseEnvVariableDuration("ECS_TASK_METADATA_TAG_LOOKUP_MAX_BACKOFF"),
 	}, err
 }
 
@@ -150,6 +150,44 @@ func TestEnvironmentConfig(t *testing.T) {
 	defer setTestEnv("ECS_CONTAINER_INSTANCE_TAGS", `{"my_tag": "testing"}`)()
 	defer setTestEnv("ECS_ENABLE_TASK_ENI", "true")()
 	defer setTestEnv("ECS_TASK_METADATA_RPS_LIMIT", "1000,1100")()
+	defer setTestEnv("ECS_TASK_METADATA_TAG_LOOKUP_MAX_ATTEMPTS", "5")()
+	defer setTestEnv("ECS_ACS_EXPECTED_SERVER_NAME", "ecs-a-1.us-west-2.amazonaws.com")()
+	defer setTestEnv("ECS_ENABLE_ACS_PROCESSING_PAUSE_ENDPOINT", "true")()
+	defer setTestEnv("ECS_ACS_ACK_AFTER_TASK_PERSISTED", "true")()
+	defer setTestEnv("ECS_ACS_TLS_SESSION_RESUMPTION", "true")()
+	defer setTestEnv("ECS_ACS_CREDENTIALS_REFRESH_CONCURRENCY", "8")()
+	defer setTestEnv("ECS_ACS_CONNECT_FAILURE_WARN_THRESHOLD", "5")()
+	defer setTestEnv("ECS_ACS_CONNECT_FAILURE_ERROR_THRESHOLD", "20")()
+	defer setTestEnv("ECS_ACS_MAX_SESSIONS_PER_INSTANCE", "2")()
+	defer setTestEnv("ECS_ACS_SESSION_MAX_LIFETIME", "2h")()
+	defer setTestEnv("ECS_DOCKER_PING_LATENCY_THRESHOLD", "500ms")()
+	defer setTestEnv("ECS_ACS_MESSAGE_PROCESSING_TIMEOUTS", "PayloadMessage=30s,IAMRoleCredentialsMessage=5s")()
+	defer setTestEnv("ECS_ACS_HANDLER_STALL_THRESHOLD", "10m")()
+	defer setTestEnv("ECS_AGENT_API_ALLOWED_SOURCE_CIDRS", `["169.254.172.0/22"]`)()
+	defer setTestEnv("ECS_INSTANCE_HEALTHCHECK_JITTER", "10s")()
+	defer setTestEnv("ECS_ENABLE_DATA_STORE_COMPRESSION", "true")()
+	defer setTestEnv("ECS_PERSISTED_TASK_DATA_MAX_AGE", "72h")()
+	defer setTestEnv("ECS_TASK_METADATA_UNIX_SOCKET_PATH", "/var/run/ecs/tmds.sock")()
+	defer setTestEnv("ECS_TASK_METADATA_SERVER_MAX_LIFETIME", "24h")()
+	defer setTestEnv("ECS_TASK_EVENT_BUFFER_SIZE", "500")()
+	defer setTestEnv("ECS_TASK_EVENT_BUFFER_OVERFLOW_POLICY", "drop-oldest")()
+	defer setTestEnv("ECS_TASK_STATE_CHANGE_MAX_SUBMIT_ATTEMPTS", "20")()
+	defer setTestEnv("ECS_ENABLE_TASK_METADATA_CGROUP_PATH", "true")()
+	defer setTestEnv("ECS_TASK_METADATA_NANOSECOND_TIMESTAMPS", "true")()
+	defer setTestEnv("ECS_DISCOVER_POLL_ENDPOINT_MIN_BACKOFF", "2s")()
+	defer setTestEnv("ECS_DISCOVER_POLL_ENDPOINT_MAX_BACKOFF", "10m")()
+	defer setTestEnv("ECS_DISCOVER_POLL_ENDPOINT_TIMEOUT", "45s")()
+	defer setTestEnv("ECS_POLL_ENDPOINT_CACHE_TTL", "1h")()
+	defer setTestEnv("ECS_DISCOVER_POLL_ENDPOINT_AUTH_FAILURE_THRESHOLD", "8")()
+	defer setTestEnv("ECS_DISCOVER_POLL_ENDPOINT_DNS_RETRY_ATTEMPTS", "3")()
+	defer setTestEnv("ECS_DISCOVER_POLL_ENDPOINT_DNS_RETRY_INTERVAL", "250ms")()
+	defer setTestEnv("ECS_ACS_URL_LOGGING_ENABLED", "true")()
+	defer setTestEnv("ECS_CNI_MAX_CONCURRENT_OPERATIONS", "4")()
+	defer setTestEnv("ECS_STATS_SAMPLE_BUFFER_MAX_MEMORY_MIB", "64")()
+	defer setTestEnv("ECS_ACS_AGENT_METRICS_INTERVAL", "5m")()
+	defer setTestEnv("ECS_ACS_PAYLOAD_CAPTURE_FILE", "/var/log/ecs/acs-payloads.log")()
+	defer setTestEnv("ECS_TASK_METADATA_TAG_LOOKUP_MIN_BACKOFF", "200ms")()
+	defer setTestEnv("ECS_TASK_METADATA_TAG_LOOKUP_MAX_BACKOFF", "2s")()
 	defer setTestEnv("ECS_SHARED_VOLUME_MATCH_FULL_CONFIG", "true")()
 	defer setTestEnv("ECS_ENABLE_GPU_SUPPORT", "true")()
 	defer setTestEnv("ECS_DISABLE_TASK_METADATA_AZ", "true")()
@@ -210,6 +248,49 @@ func TestEnvironmentConfig(t *testing.T) {
 	assert.True(t, conf.ContainerMetadataEnabled.Enabled(), "Wrong value for ContainerMetadataEnabled")
 	assert.Equal(t, 1000, conf.TaskMetadataSteadyStateRate)
 	assert.Equal(t, 1100, conf.TaskMetadataBurstRate)
+	assert.Equal(t, 5, conf.TaskMetadataTagLookupMaxAttempts)
+	assert.Equal(t, "ecs-a-1.us-west-2.amazonaws.com", conf.ACSExpectedServerName)
+	assert.True(t, conf.EnableACSProcessingPauseEndpoint.Enabled(), "Wrong value for EnableACSProcessingPauseEndpoint")
+	assert.True(t, conf.ACSAckAfterTaskPersisted.Enabled(), "Wrong value for ACSAckAfterTaskPersisted")
+	assert.True(t, conf.ACSTLSSessionResumption.Enabled(), "Wrong value for ACSTLSSessionResumption")
+	assert.Equal(t, 8, conf.ACSCredentialsRefreshConcurrency)
+	assert.Equal(t, 5, conf.ACSConnectFailureWarnThreshold)
+	assert.Equal(t, 20, conf.ACSConnectFailureErrorThreshold)
+	assert.Equal(t, 2, conf.ACSMaxSessionsPerInstance)
+	assert.Equal(t, 2*time.Hour, conf.ACSSessionMaxLifetime)
+	assert.Equal(t, 500*time.Millisecond, conf.DockerPingLatencyThreshold)
+	assert.Equal(t, map[string]time.Duration{
+		"PayloadMessage":            30 * time.Second,
+		"IAMRoleCredentialsMessage": 5 * time.Second,
+	}, conf.ACSMessageProcessingTimeouts)
+	assert.Equal(t, 10*time.Minute, conf.ACSHandlerStallThreshold)
+	serializedAgentAPIAllowedSourceCIDRs, err := json.Marshal(conf.AgentAPIAllowedSourceCIDRs)
+	assert.NoError(t, err)
+	assert.Equal(t, `["169.254.172.0/22"]`, string(serializedAgentAPIAllowedSourceCIDRs))
+	assert.Equal(t, 10*time.Second, conf.InstanceHealthcheckJitter)
+	assert.True(t, conf.DataStoreCompression.Enabled(), "Wrong value for DataStoreCompression")
+	assert.Equal(t, 72*time.Hour, conf.PersistedTaskDataMaxAge, "Wrong value for PersistedTaskDataMaxAge")
+	assert.Equal(t, "/var/run/ecs/tmds.sock", conf.TaskMetadataUnixSocketPath)
+	assert.Equal(t, 24*time.Hour, conf.TaskMetadataServerMaxLifetime)
+	assert.Equal(t, 500, conf.TaskEventBufferSize)
+	assert.Equal(t, 20, conf.TaskStateChangeMaxSubmitAttempts)
+	assert.Equal(t, TaskEventBufferOverflowPolicyDropOldest, conf.TaskEventBufferOverflowPolicy)
+	assert.True(t, conf.TaskMetadataCgroupPathEnabled.Enabled(), "Wrong value for TaskMetadataCgroupPathEnabled")
+	assert.True(t, conf.TaskMetadataNanosecondTimestamps.Enabled(), "Wrong value for TaskMetadataNanosecondTimestamps")
+	assert.Equal(t, 2*time.Second, conf.DiscoverPollEndpointMinBackoff)
+	assert.Equal(t, 10*time.Minute, conf.DiscoverPollEndpointMaxBackoff)
+	assert.Equal(t, 45*time.Second, conf.DiscoverPollEndpointTimeout)
+	assert.Equal(t, time.Hour, conf.PollEndpointCacheTTL)
+	assert.Equal(t, 8, conf.DiscoverAuthFailureThreshold)
+	assert.Equal(t, 3, conf.DiscoverDNSRetryAttempts)
+	assert.Equal(t, 250*time.Millisecond, conf.DiscoverDNSRetryInterval)
+	assert.True(t, conf.ACSURLLoggingEnabled.Enabled(), "Wrong value for ACSURLLoggingEnabled")
+	assert.Equal(t, 4, conf.CNIMaxConcurrentOperations)
+	assert.Equal(t, 64, conf.StatsSampleBufferMaxMemoryMiB)
+	assert.Equal(t, 5*time.Minute, conf.ACSAgentMetricsInterval)
+	assert.Equal(t, "/var/log/ecs/acs-payloads.log", conf.ACSPayloadCaptureFile)
+	assert.Equal(t, 200*time.Millisecond, conf.TaskMetadataTagLookupMinBackoff)
+	assert.Equal(t, 2*time.Second, conf.TaskMetadataTagLookupMaxBackoff)
 	assert.True(t, conf.SharedVolumeMatchFullConfig.Enabled(), "Wrong value for SharedVolumeMatchFullConfig")
 	assert.True(t, conf.GPUSupportEnabled, "Wrong value for GPUSupportEnabled")
 	assert.Equal(t, "nvidia", conf.NvidiaRuntime)
@@ -461,6 +542,188 @@ func TestInvalidValuePollingMetricsWaitDuration(t *testing.T) {
 	assert.Equal(t, DefaultPollingMetricsWaitDuration, conf.PollingMetricsWaitDuration, "Wrong value for PollingMetricsWaitDuration")
 }
 
+func TestInvalidValueMaxInstanceHealthcheckJitter(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_INSTANCE_HEALTHCHECK_JITTER", "1m")()
+	conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Equal(t, MaxInstanceHealthcheckJitter, conf.InstanceHealthcheckJitter, "Wrong value for InstanceHealthcheckJitter")
+}
+
+func TestInvalidValueInstanceHealthcheckJitter(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_INSTANCE_HEALTHCHECK_JITTER", "-1s")()
+	conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultInstanceHealthcheckJitter, conf.InstanceHealthcheckJitter, "Wrong value for InstanceHealthcheckJitter")
+}
+
+func TestInvalidDiscoverPollEndpointBackoff(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_DISCOVER_POLL_ENDPOINT_MIN_BACKOFF", "1m")()
+	defer setTestEnv("ECS_DISCOVER_POLL_ENDPOINT_MAX_BACKOFF", "10s")()
+	conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultDiscoverPollEndpointMinBackoff, conf.DiscoverPollEndpointMinBackoff)
+	assert.Equal(t, DefaultDiscoverPollEndpointMaxBackoff, conf.DiscoverPollEndpointMaxBackoff)
+}
+
+func TestInvalidDiscoverPollEndpointTimeout(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_DISCOVER_POLL_ENDPOINT_TIMEOUT", "-1s")()
+	conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultDiscoverPollEndpointTimeout, conf.DiscoverPollEndpointTimeout)
+}
+
+func TestInvalidPollEndpointCacheTTL(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_POLL_ENDPOINT_CACHE_TTL", "0s")()
+	conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPollEndpointCacheTTL, conf.PollEndpointCacheTTL)
+}
+
+func TestInvalidDiscoverAuthFailureThreshold(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_DISCOVER_POLL_ENDPOINT_AUTH_FAILURE_THRESHOLD", "0")()
+	conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultDiscoverAuthFailureThreshold, conf.DiscoverAuthFailureThreshold)
+}
+
+func TestInvalidCNIMaxConcurrentOperations(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_CNI_MAX_CONCURRENT_OPERATIONS", "-1")()
+	conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultCNIMaxConcurrentOperations, conf.CNIMaxConcurrentOperations)
+}
+
+func TestInvalidDiscoverDNSRetry(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_DISCOVER_POLL_ENDPOINT_DNS_RETRY_ATTEMPTS", "-1")()
+	defer setTestEnv("ECS_DISCOVER_POLL_ENDPOINT_DNS_RETRY_INTERVAL", "-1s")()
+	conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultDiscoverDNSRetryAttempts, conf.DiscoverDNSRetryAttempts)
+	assert.Equal(t, DefaultDiscoverDNSRetryInterval, conf.DiscoverDNSRetryInterval)
+}
+
+func TestInvalidStatsSampleBufferMaxMemory(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_STATS_SAMPLE_BUFFER_MAX_MEMORY_MIB", "-1")()
+	conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Zero(t, conf.StatsSampleBufferMaxMemoryMiB)
+}
+
+func TestInvalidTaskMetadataServerMaxLifetime(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_TASK_METADATA_SERVER_MAX_LIFETIME", "-1h")()
+	conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Zero(t, conf.TaskMetadataServerMaxLifetime)
+}
+
+func TestInvalidACSSessionMaxLifetime(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_ACS_SESSION_MAX_LIFETIME", "-1h")()
+	conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Zero(t, conf.ACSSessionMaxLifetime)
+}
+
+func TestInvalidPersistedTaskDataMaxAge(t *testing.T) {
+	defer setTestRegion()()
+	t.Run("negative", func(t *testing.T) {
+		defer setTestEnv("ECS_PERSISTED_TASK_DATA_MAX_AGE", "-1h")()
+		conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+		assert.NoError(t, err)
+		assert.Zero(t, conf.PersistedTaskDataMaxAge)
+	})
+	t.Run("below task cleanup wait duration", func(t *testing.T) {
+		defer setTestEnv("ECS_ENGINE_TASK_CLEANUP_WAIT_DURATION", "2h")()
+		defer setTestEnv("ECS_PERSISTED_TASK_DATA_MAX_AGE", "1h")()
+		conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Hour, conf.PersistedTaskDataMaxAge)
+	})
+}
+
+func TestInvalidTaskEventBuffer(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_TASK_EVENT_BUFFER_SIZE", "-1")()
+	defer setTestEnv("ECS_TASK_EVENT_BUFFER_OVERFLOW_POLICY", "drop-newest")()
+	conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultTaskEventBufferSize, conf.TaskEventBufferSize)
+	assert.Equal(t, TaskEventBufferOverflowPolicyDropOldestNonStopped, conf.TaskEventBufferOverflowPolicy)
+}
+
+func TestInvalidTaskStateChangeMaxSubmitAttempts(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_TASK_STATE_CHANGE_MAX_SUBMIT_ATTEMPTS", "-1")()
+	conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, conf.TaskStateChangeMaxSubmitAttempts)
+}
+
+func TestTaskEventBufferCoalescePolicy(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_TASK_EVENT_BUFFER_OVERFLOW_POLICY", "coalesce")()
+	conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Equal(t, TaskEventBufferOverflowPolicyCoalesce, conf.TaskEventBufferOverflowPolicy)
+}
+
+func TestInvalidACSConnectFailureThresholds(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_ACS_CONNECT_FAILURE_WARN_THRESHOLD", "-1")()
+	defer setTestEnv("ECS_ACS_CONNECT_FAILURE_ERROR_THRESHOLD", "2")()
+	conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultACSConnectFailureWarnThreshold, conf.ACSConnectFailureWarnThreshold)
+	assert.Equal(t, DefaultACSConnectFailureWarnThreshold, conf.ACSConnectFailureErrorThreshold)
+}
+
+func TestInvalidACSHandlerStallThreshold(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_ACS_HANDLER_STALL_THRESHOLD", "-1s")()
+	conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultACSHandlerStallThreshold, conf.ACSHandlerStallThreshold)
+}
+
+func TestInvalidACSMaxSessionsPerInstance(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_ACS_MAX_SESSIONS_PER_INSTANCE", "0")()
+	conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultACSMaxSessionsPerInstance, conf.ACSMaxSessionsPerInstance)
+}
+
+func TestACSAgentMetricsIntervalBounds(t *testing.T) {
+	testCases := []struct {
+		value            string
+		expectedInterval time.Duration
+	}{
+		{value: "", expectedInterval: 0},
+		{value: "-1m", expectedInterval: 0},
+		{value: "1s", expectedInterval: MinACSAgentMetricsInterval},
+		{value: "1m", expectedInterval: time.Minute},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			defer setTestRegion()()
+			defer setTestEnv("ECS_ACS_AGENT_METRICS_INTERVAL", tc.value)()
+			conf, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedInterval, conf.ACSAgentMetricsInterval)
+		})
+	}
+}
+
 func TestInvalidFormatParseEnvVariableUint16(t *testing.T) {
 	defer setTestRegion()()
 	setTestEnv("FOO", "foo")
@@ -678,6 +941,14 @@ func TestEnableRuntimeStatsConfigEnabled(t *testing.T) {
 	assert.True(t, cfg.EnableRuntimeStats.Enabled(), "Wrong value for EnableRuntimeStats")
 }
 
+func TestDisableIntrospectionEndpointConfig(t *testing.T) {
+	defer setTestRegion()()
+	defer setTestEnv("ECS_DISABLE_INTROSPECTION_ENDPOINT", "true")()
+	cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+	assert.NoError(t, err)
+	assert.True(t, cfg.DisableIntrospectionEndpoint.Enabled(), "Wrong value for DisableIntrospectionEndpoint")
+}
+
 func TestParseImagePullBehavior(t *testing.T) {
 	testcases := []struct {
 		name                      string
@@ -744,6 +1015,12 @@ func TestInvalidAWSVPCAdditionalLocalRoutes(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestInvalidAgentAPIAllowedSourceCIDRs(t *testing.T) {
+	defer setTestEnv("ECS_AGENT_API_ALLOWED_SOURCE_CIDRS", `["10.0.0.0/33"]`)()
+	_, err := environmentConfig()
+	assert.Error(t, err)
+}
+
 func TestAWSLogsExecutionRole(t *testing.T) {
 	setTestEnv("ECS_ENABLE_AWSLOGS_EXECUTIONROLE_OVERRIDE", "true")
 	conf, err := environmentConfig()
@@ -819,6 +1096,39 @@ func TestTaskMetadataRPSLimits(t *testing.T) {
 	}
 }
 
+func TestACSMessageProcessingTimeouts(t *testing.T) {
+	testCases := []struct {
+		name             string
+		envVarVal        string
+		expectedTimeouts map[string]time.Duration
+	}{
+		{
+			name:             "empty variable",
+			envVarVal:        "",
+			expectedTimeouts: nil,
+		},
+		{
+			name:             "values with spaces",
+			envVarVal:        " PayloadMessage = 30s , AttachTaskNetworkInterfacesMessage=1m",
+			expectedTimeouts: map[string]time.Duration{"PayloadMessage": 30 * time.Second, "AttachTaskNetworkInterfacesMessage": time.Minute},
+		},
+		{
+			name:             "invalid pairs are ignored",
+			envVarVal:        "PayloadMessage=30s,IAMRoleCredentialsMessage,=5s,TaskManifestMessage=abc,HeartbeatMessage=-1s",
+			expectedTimeouts: map[string]time.Duration{"PayloadMessage": 30 * time.Second},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer setTestEnv("ECS_ACS_MESSAGE_PROCESSING_TIMEOUTS", tc.envVarVal)()
+			defer setTestRegion()()
+			cfg, err := NewConfig(ec2.NewBlackholeEC2MetadataClient())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedTimeouts, cfg.ACSMessageProcessingTimeouts)
+		})
+	}
+}
+
 func TestUserDataConfig(t *testing.T) {
 	testcases := []struct {
 		name                      string
@@ -85,6 +85,7 @@ func DefaultConfig() Config {
 		NumImagesToDeletePerCycle:           DefaultNumImagesToDeletePerCycle,
 		NumNonECSContainersToDeletePerCycle: DefaultNumNonECSContainersToDeletePerCycle,
 		CNIPluginsPath:                      defaultCNIPluginsPath,
+		CNIMaxConcurrentOperations:          DefaultCNIMaxConcurrentOperations,
 		PauseContainerTarballPath:           pauseContainerTarballPath,
 		PauseContainerImageName:             DefaultPauseContainerImageName,
 		PauseContainerTag:                   DefaultPauseContainerTag,
@@ -93,7 +94,12 @@ func DefaultConfig() Config {
 		TaskCPUMemLimit:                     BooleanDefaultTrue{Value: NotSet},
 		CgroupPath:                          defaultCgroupPath,
 		TaskMetadataSteadyStateRate:         DefaultTaskMetadataSteadyStateRate,
+		TaskEventBufferSize:                 DefaultTaskEventBufferSize,
+		TaskEventBufferOverflowPolicy:       TaskEventBufferOverflowPolicyDropOldestNonStopped,
 		TaskMetadataBurstRate:               DefaultTaskMetadataBurstRate,
+		TaskMetadataTagLookupMaxAttempts:    DefaultTaskMetadataTagLookupMaxAttempts,
+		TaskMetadataTagLookupMinBackoff:     DefaultTaskMetadataTagLookupMinBackoff,
+		TaskMetadataTagLookupMaxBackoff:     DefaultTaskMetadataTagLookupMaxBackoff,
 		SharedVolumeMatchFullConfig:         BooleanDefaultFalse{Value: ExplicitlyDisabled}, // only requiring shared volumes to match on name, which is default docker behavior
 		ContainerInstancePropagateTagsFrom:  ContainerInstancePropagateTagsFromNoneType,
 		PrometheusMetricsEnabled:            false,
@@ -106,6 +112,28 @@ func DefaultConfig() Config {
 		FSxWindowsFileServerCapable:         BooleanDefaultFalse{Value: ExplicitlyDisabled},
 		RuntimeStatsLogFile:                 defaultRuntimeStatsLogFile,
 		EnableRuntimeStats:                  BooleanDefaultFalse{Value: NotSet},
+		DisableIntrospectionEndpoint:        BooleanDefaultFalse{Value: NotSet},
+		EnableACSProcessingPauseEndpoint:    BooleanDefaultFalse{Value: NotSet},
+		ACSAckAfterTaskPersisted:            BooleanDefaultFalse{Value: NotSet},
+		ACSTLSSessionResumption:             BooleanDefaultFalse{Value: NotSet},
+		ACSCredentialsRefreshConcurrency:    DefaultACSCredentialsRefreshConcurrency,
+		ACSHandlerStallThreshold:            DefaultACSHandlerStallThreshold,
+		ACSConnectFailureWarnThreshold:      DefaultACSConnectFailureWarnThreshold,
+		ACSConnectFailureErrorThreshold:     DefaultACSConnectFailureErrorThreshold,
+		ACSMaxSessionsPerInstance:           DefaultACSMaxSessionsPerInstance,
+		DockerPingLatencyThreshold:          DefaultDockerPingLatencyThreshold,
+		InstanceHealthcheckJitter:           DefaultInstanceHealthcheckJitter,
+		DataStoreCompression:                BooleanDefaultFalse{Value: NotSet},
+		TaskMetadataCgroupPathEnabled:       BooleanDefaultFalse{Value: NotSet},
+		TaskMetadataNanosecondTimestamps:    BooleanDefaultFalse{Value: NotSet},
+		DiscoverPollEndpointMinBackoff:      DefaultDiscoverPollEndpointMinBackoff,
+		DiscoverPollEndpointMaxBackoff:      DefaultDiscoverPollEndpointMaxBackoff,
+		DiscoverPollEndpointTimeout:         DefaultDiscoverPollEndpointTimeout,
+		PollEndpointCacheTTL:                DefaultPollEndpointCacheTTL,
+		DiscoverAuthFailureThreshold:        DefaultDiscoverAuthFailureThreshold,
+		DiscoverDNSRetryAttempts:            DefaultDiscoverDNSRetryAttempts,
+		DiscoverDNSRetryInterval:            DefaultDiscoverDNSRetryInterval,
+		ACSURLLoggingEnabled:                BooleanDefaultFalse{Value: NotSet},
 		ShouldExcludeIPv6PortBinding:        BooleanDefaultTrue{Value: ExplicitlyEnabled},
 	}
 }
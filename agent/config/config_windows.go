@@ -139,7 +139,12 @@ func DefaultConfig() Config {
 		TaskCPUMemLimit:                     BooleanDefaultTrue{Value: ExplicitlyDisabled},
 		PlatformVariables:                   platformVariables,
 		TaskMetadataSteadyStateRate:         DefaultTaskMetadataSteadyStateRate,
+		TaskEventBufferSize:                 DefaultTaskEventBufferSize,
+		TaskEventBufferOverflowPolicy:       TaskEventBufferOverflowPolicyDropOldestNonStopped,
 		TaskMetadataBurstRate:               DefaultTaskMetadataBurstRate,
+		TaskMetadataTagLookupMaxAttempts:    DefaultTaskMetadataTagLookupMaxAttempts,
+		TaskMetadataTagLookupMinBackoff:     DefaultTaskMetadataTagLookupMinBackoff,
+		TaskMetadataTagLookupMaxBackoff:     DefaultTaskMetadataTagLookupMaxBackoff,
 		SharedVolumeMatchFullConfig:         BooleanDefaultFalse{Value: ExplicitlyDisabled}, //only requiring shared volumes to match on name, which is default docker behavior
 		PollMetrics:                         BooleanDefaultFalse{Value: NotSet},
 		PollingMetricsWaitDuration:          DefaultPollingMetricsWaitDuration,
@@ -149,8 +154,31 @@ func DefaultConfig() Config {
 		PauseContainerImageName:             DefaultPauseContainerImageName,
 		PauseContainerTag:                   DefaultPauseContainerTag,
 		CNIPluginsPath:                      filepath.Join(ecsBinaryDir, defaultCNIPluginDirName),
+		CNIMaxConcurrentOperations:          DefaultCNIMaxConcurrentOperations,
 		RuntimeStatsLogFile:                 filepath.Join(ecsRoot, defaultRuntimeStatsLogFile),
 		EnableRuntimeStats:                  BooleanDefaultFalse{Value: NotSet},
+		DisableIntrospectionEndpoint:        BooleanDefaultFalse{Value: NotSet},
+		EnableACSProcessingPauseEndpoint:    BooleanDefaultFalse{Value: NotSet},
+		ACSAckAfterTaskPersisted:            BooleanDefaultFalse{Value: NotSet},
+		ACSTLSSessionResumption:             BooleanDefaultFalse{Value: NotSet},
+		ACSCredentialsRefreshConcurrency:    DefaultACSCredentialsRefreshConcurrency,
+		ACSHandlerStallThreshold:            DefaultACSHandlerStallThreshold,
+		ACSConnectFailureWarnThreshold:      DefaultACSConnectFailureWarnThreshold,
+		ACSConnectFailureErrorThreshold:     DefaultACSConnectFailureErrorThreshold,
+		ACSMaxSessionsPerInstance:           DefaultACSMaxSessionsPerInstance,
+		DockerPingLatencyThreshold:          DefaultDockerPingLatencyThreshold,
+		InstanceHealthcheckJitter:           DefaultInstanceHealthcheckJitter,
+		DataStoreCompression:                BooleanDefaultFalse{Value: NotSet},
+		TaskMetadataCgroupPathEnabled:       BooleanDefaultFalse{Value: NotSet},
+		TaskMetadataNanosecondTimestamps:    BooleanDefaultFalse{Value: NotSet},
+		DiscoverPollEndpointMinBackoff:      DefaultDiscoverPollEndpointMinBackoff,
+		DiscoverPollEndpointMaxBackoff:      DefaultDiscoverPollEndpointMaxBackoff,
+		DiscoverPollEndpointTimeout:         DefaultDiscoverPollEndpointTimeout,
+		PollEndpointCacheTTL:                DefaultPollEndpointCacheTTL,
+		DiscoverAuthFailureThreshold:        DefaultDiscoverAuthFailureThreshold,
+		DiscoverDNSRetryAttempts:            DefaultDiscoverDNSRetryAttempts,
+		DiscoverDNSRetryInterval:            DefaultDiscoverDNSRetryInterval,
+		ACSURLLoggingEnabled:                BooleanDefaultFalse{Value: NotSet},
 		ShouldExcludeIPv6PortBinding:        BooleanDefaultTrue{Value: ExplicitlyEnabled},
 	}
 }
@@ -230,6 +230,20 @@ func parseAdditionalLocalRoutes(errs []error) ([]cnitypes.IPNet, []error) {
 	return additionalLocalRoutes, errs
 }
 
+func parseAgentAPIAllowedSourceCIDRs(errs []error) ([]cnitypes.IPNet, []error) {
+	var allowedSourceCIDRs []cnitypes.IPNet
+	allowedSourceCIDRsEnv := os.Getenv("ECS_AGENT_API_ALLOWED_SOURCE_CIDRS")
+	if allowedSourceCIDRsEnv != "" {
+		err := json.Unmarshal([]byte(allowedSourceCIDRsEnv), &allowedSourceCIDRs)
+		if err != nil {
+			seelog.Errorf("Invalid format for ECS_AGENT_API_ALLOWED_SOURCE_CIDRS, expected a json array of CIDRs: %v", err)
+			errs = append(errs, err)
+		}
+	}
+
+	return allowedSourceCIDRs, errs
+}
+
 func parseBooleanDefaultFalseConfig(envVarName string) BooleanDefaultFalse {
 	boolDefaultFalseCofig := BooleanDefaultFalse{Value: NotSet}
 	configString := strings.TrimSpace(os.Getenv(envVarName))
@@ -297,6 +311,31 @@ func parseTaskMetadataThrottles() (int, int) {
 	return steadyStateRate, burstRate
 }
 
+// parseACSMessageProcessingTimeouts parses the ECS_ACS_MESSAGE_PROCESSING_TIMEOUTS environment
+// variable, a comma separated list of "MessageType=duration" pairs. Invalid pairs are ignored.
+func parseACSMessageProcessingTimeouts() map[string]time.Duration {
+	envVal := os.Getenv("ECS_ACS_MESSAGE_PROCESSING_TIMEOUTS")
+	if envVal == "" {
+		seelog.Debug("Environment variable empty: ECS_ACS_MESSAGE_PROCESSING_TIMEOUTS")
+		return nil
+	}
+	timeouts := make(map[string]time.Duration)
+	for _, pair := range strings.Split(envVal, ",") {
+		messageTypeAndTimeout := strings.SplitN(pair, "=", 2)
+		if len(messageTypeAndTimeout) != 2 || strings.TrimSpace(messageTypeAndTimeout[0]) == "" {
+			seelog.Warnf(`Invalid format for "ECS_ACS_MESSAGE_PROCESSING_TIMEOUTS", expected: "MessageType=duration", got: %q`, pair)
+			continue
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(messageTypeAndTimeout[1]))
+		if err != nil || timeout <= 0 {
+			seelog.Warnf(`Invalid timeout for "ECS_ACS_MESSAGE_PROCESSING_TIMEOUTS", expected a positive duration, got: %q`, pair)
+			continue
+		}
+		timeouts[strings.TrimSpace(messageTypeAndTimeout[0])] = timeout
+	}
+	return timeouts
+}
+
 func parseContainerInstanceTags(errs []error) (map[string]string, []error) {
 	var containerInstanceTags map[string]string
 	containerInstanceTagsConfigString := os.Getenv("ECS_CONTAINER_INSTANCE_TAGS")
@@ -344,6 +383,19 @@ func parseEnvVariableUint16(envVar string) uint16 {
 	return var16
 }
 
+func parseEnvVariableInt(envVar string) int {
+	envVal := os.Getenv(envVar)
+	var intVal int
+	if envVal != "" {
+		var err error
+		intVal, err = strconv.Atoi(envVal)
+		if err != nil {
+			seelog.Warnf("Invalid format for \""+envVar+"\" environment variable; expected integer. err %v", err)
+		}
+	}
+	return intVal
+}
+
 func parseEnvVariableDuration(envVar string) time.Duration {
 	var duration time.Duration
 	envVal := os.Getenv(envVar)
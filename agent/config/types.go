@@ -98,6 +98,11 @@ type Config struct {
 	// again when PollMetrics is set to true
 	PollingMetricsWaitDuration time.Duration
 
+	// StatsSampleBufferMaxMemoryMiB caps the memory, in MiB, used by the stats samples retained for
+	// the containers on the instance. The cap is shared evenly between containers and their oldest
+	// samples are evicted to stay under it. Retained samples are not capped when it is zero.
+	StatsSampleBufferMaxMemoryMiB int
+
 	// DisableDockerHealthCheck configures whether container health feature was enabled
 	// on the instance
 	DisableDockerHealthCheck BooleanDefaultFalse
@@ -217,9 +222,18 @@ type Config struct {
 	// Set if clients validate ssl certificates. Used mainly for testing
 	AcceptInsecureCert bool `json:"-"`
 
+	// ACSExpectedServerName, if set, is the name that the ACS server certificate must be
+	// valid for. It is enforced during the TLS handshake even when AcceptInsecureCert is set.
+	ACSExpectedServerName string `trim:"true"`
+
 	// CNIPluginsPath is the path for the cni plugins
 	CNIPluginsPath string
 
+	// CNIMaxConcurrentOperations specifies the maximum number of task network namespace setups and
+	// cleanups that invoke the cni plugins at the same time, so that starting many awsvpc tasks at
+	// once doesn't overwhelm the host networking stack. Cleanups are never starved by setups.
+	CNIMaxConcurrentOperations int
+
 	// PauseContainerTarballPath is the path to the pause container tarball
 	PauseContainerTarballPath string
 
@@ -275,6 +289,18 @@ type Config struct {
 	// TaskMetadataBurstRate specifies the burst rate throttle for the task metadata endpoint
 	TaskMetadataBurstRate int
 
+	// TaskMetadataTagLookupMaxAttempts specifies the number of attempts made for each ECS tag
+	// lookup served by the task metadata endpoint before a degraded response is returned
+	TaskMetadataTagLookupMaxAttempts int
+
+	// TaskMetadataTagLookupMinBackoff specifies the minimum backoff between throttled
+	// task metadata tag lookups
+	TaskMetadataTagLookupMinBackoff time.Duration
+
+	// TaskMetadataTagLookupMaxBackoff specifies the maximum backoff between throttled
+	// task metadata tag lookups
+	TaskMetadataTagLookupMaxBackoff time.Duration
+
 	// SharedVolumeMatchFullConfig is config option used to short-circuit volume validation against a
 	// provisioned volume, if false (default). If true, we perform deep comparison including driver options
 	// and labels. For comparing shared volume across 2 instances, this should be set to false as docker's
@@ -356,6 +382,168 @@ type Config struct {
 	// is set to false and can be overridden by means of the ECS_ENABLE_RUNTIME_STATS environment variable.
 	EnableRuntimeStats BooleanDefaultFalse
 
+	// DisableIntrospectionEndpoint specifies if the agent introspection endpoint should not be served. The versioned
+	// task metadata endpoints are not affected. By default, this configuration is set to false and can be overridden
+	// by means of the ECS_DISABLE_INTROSPECTION_ENDPOINT environment variable.
+	DisableIntrospectionEndpoint BooleanDefaultFalse
+
+	// EnableACSProcessingPauseEndpoint specifies if the endpoint used to pause and resume processing of ACS
+	// payload messages should be enabled on the agent introspection port. By default, this configuration is
+	// set to false and can be overridden by means of the ECS_ENABLE_ACS_PROCESSING_PAUSE_ENDPOINT environment variable.
+	EnableACSProcessingPauseEndpoint BooleanDefaultFalse
+
+	// ACSAckAfterTaskPersisted specifies if new tasks received from ACS should be saved to the agent's data
+	// store before being handed to the task engine, so that a payload message is only acked once its tasks
+	// are recoverable across agent restarts. By default, this configuration is set to false and can be
+	// overridden by means of the ECS_ACS_ACK_AFTER_TASK_PERSISTED environment variable.
+	ACSAckAfterTaskPersisted BooleanDefaultFalse
+
+	// ACSTLSSessionResumption specifies if TLS sessions established with ACS should be cached and resumed
+	// when reconnecting, to save full handshakes when the server supports resumption. By default, this
+	// configuration is set to false and can be overridden by means of the ECS_ACS_TLS_SESSION_RESUMPTION
+	// environment variable.
+	ACSTLSSessionResumption BooleanDefaultFalse
+
+	// ACSCredentialsRefreshConcurrency specifies the maximum number of credentials refresh messages
+	// from ACS that are applied concurrently. Refreshes for the same task are always applied in order.
+	ACSCredentialsRefreshConcurrency int
+
+	// ACSConnectFailureWarnThreshold and ACSConnectFailureErrorThreshold specify the numbers of
+	// consecutive failures to connect to ACS from which the failures are logged as warnings and
+	// errors respectively. Fewer consecutive failures are logged as info, as they are routine.
+	ACSConnectFailureWarnThreshold  int
+	ACSConnectFailureErrorThreshold int
+
+	// ACSMaxSessionsPerInstance specifies the maximum number of ACS sessions that can be active
+	// at the same time for a container instance. Sessions started beyond the limit wait for an
+	// active session to end before connecting.
+	ACSMaxSessionsPerInstance int
+
+	// ACSSessionMaxLifetime specifies how long the ACS session runs before it ends regardless of
+	// its connectivity, for ephemeral agents such as short-lived CI runners. The session runs until
+	// the agent stops when it is zero.
+	ACSSessionMaxLifetime time.Duration
+
+	// DockerPingLatencyThreshold specifies the Docker daemon ping latency above which the container
+	// runtime is reported as impaired by the instance health checks.
+	DockerPingLatencyThreshold time.Duration
+
+	// ACSMessageProcessingTimeouts maps ACS message types to the maximum time allowed for handling a
+	// message of that type. Messages that take longer are nacked with a timeout reason and the session
+	// moves on to the next message. Message types without a timeout are not bounded.
+	ACSMessageProcessingTimeouts map[string]time.Duration
+
+	// ACSHandlerStallThreshold specifies how long handling a single ACS message may take before the
+	// message handling loop is considered stalled. A stalled loop has the stacks of all goroutines
+	// logged and the session reconnects to ACS. Message types with a processing timeout are only
+	// considered stalled once their timeout has passed, so that they are nacked first.
+	ACSHandlerStallThreshold time.Duration
+
+	// AgentAPIAllowedSourceCIDRs restricts the source addresses that may call the agent API
+	// endpoints (such as task protection) served by the task metadata server. Requests from
+	// addresses outside these CIDRs are rejected. No restriction is applied when empty.
+	AgentAPIAllowedSourceCIDRs []cnitypes.IPNet
+
+	// InstanceHealthcheckJitter specifies the window over which the instance health checks run on
+	// each ACS heartbeat are staggered, so that they don't all run at the same time. Each health check
+	// runs at a random time within its own slot of the window.
+	InstanceHealthcheckJitter time.Duration
+
+	// DataStoreCompression specifies whether the agent's state is gzip compressed before it is saved
+	// to the data store. Records saved with or without compression can always be read back, so this can
+	// be turned on or off on an existing data store.
+	DataStoreCompression BooleanDefaultFalse
+
+	// PersistedTaskDataMaxAge specifies how long the data of a stopped task is kept in the data store
+	// before it is pruned by a periodic sweep. It is never shorter than TaskCleanupWaitDuration, so that
+	// the data of tasks that have not been cleaned up yet is kept. Stopped task data is only removed when
+	// the task is cleaned up when it is zero.
+	PersistedTaskDataMaxAge time.Duration
+
+	// TaskMetadataUnixSocketPath is the path of a unix domain socket on which the task metadata
+	// server listens in addition to its TCP address. The socket can be bind mounted into containers
+	// that should reach task metadata through filesystem permissions instead of the network. The
+	// unix socket is not served when empty.
+	TaskMetadataUnixSocketPath string
+
+	// TaskMetadataServerMaxLifetime specifies how long the task metadata server runs before it is
+	// gracefully shut down and recreated, which mitigates slow resource leaks in long running agents.
+	// In-flight requests are completed by the old server. The server is not recreated when it is zero.
+	TaskMetadataServerMaxLifetime time.Duration
+
+	// TaskEventBufferSize bounds the number of task state change events, across all tasks, that
+	// are queued to be sent to ECS. TaskEventBufferOverflowPolicy is applied to events added
+	// when the buffer is full.
+	TaskEventBufferSize int
+
+	// TaskEventBufferOverflowPolicy specifies what happens to task state change events added to a
+	// full task event buffer. "drop-oldest-non-stopped" drops the oldest queued event that does not
+	// report a STOPPED status to make room for it, "block" waits until there is room for the event,
+	// "drop-oldest" drops the oldest queued event to make room for it and "coalesce" replaces the
+	// latest queued event of the same task with it, waiting for room if the task has no queued event.
+	TaskEventBufferOverflowPolicy string
+
+	// TaskStateChangeMaxSubmitAttempts specifies how many times submitting a task or container state
+	// change to ECS is attempted before it's given up on and moved to the dead letters reported by the
+	// introspection server, so that newer state changes of the task can be submitted. State changes are
+	// retried until they're submitted when it is zero.
+	TaskStateChangeMaxSubmitAttempts int
+
+	// TaskMetadataCgroupPathEnabled specifies whether the v4 task metadata endpoints report the cgroup
+	// path of each of the task's containers, so that profiling tools can read cgroup stats directly.
+	TaskMetadataCgroupPathEnabled BooleanDefaultFalse
+
+	// TaskMetadataNanosecondTimestamps specifies whether the v4 task metadata endpoints report
+	// timestamps with nanosecond precision. Timestamps are reported in UTC with second precision
+	// (RFC3339) otherwise.
+	TaskMetadataNanosecondTimestamps BooleanDefaultFalse
+
+	// DiscoverPollEndpointMinBackoff specifies the minimum backoff between DiscoverPollEndpoint
+	// retries when the ACS endpoint cannot be discovered
+	DiscoverPollEndpointMinBackoff time.Duration
+
+	// DiscoverPollEndpointMaxBackoff specifies the maximum backoff between DiscoverPollEndpoint
+	// retries. It is separate from, and higher than, the ACS connection backoff so that the agent
+	// backs off further when the control plane throttles endpoint discovery.
+	DiscoverPollEndpointMaxBackoff time.Duration
+
+	// DiscoverPollEndpointTimeout specifies how long to wait for DiscoverPollEndpoint to return
+	// before giving up on the call and retrying it with backoff, so that a hung control plane
+	// doesn't block reconnecting to ACS.
+	DiscoverPollEndpointTimeout time.Duration
+
+	// PollEndpointCacheTTL specifies how long a discovered ACS endpoint is reused across reconnects
+	// before DiscoverPollEndpoint is called again. An expired endpoint is still used as a fallback
+	// when the call fails, but never once the configured region has changed.
+	PollEndpointCacheTTL time.Duration
+
+	// DiscoverAuthFailureThreshold specifies the number of consecutive authentication or permission
+	// errors from DiscoverPollEndpoint after which the agent logs a critical error and reports itself
+	// as impaired. Endpoint discovery keeps being retried, so that fixed credentials are picked up.
+	DiscoverAuthFailureThreshold int
+
+	// DiscoverDNSRetryAttempts specifies the number of consecutive DNS resolution errors from
+	// DiscoverPollEndpoint that are retried after DiscoverDNSRetryInterval, rather than with
+	// backoff. DNS is often briefly unavailable at boot and usually recovers quickly.
+	DiscoverDNSRetryAttempts int
+
+	// DiscoverDNSRetryInterval specifies the fixed interval between the first DiscoverDNSRetryAttempts
+	// retries of DiscoverPollEndpoint that failed to resolve the ECS endpoint.
+	DiscoverDNSRetryInterval time.Duration
+
+	// ACSURLLoggingEnabled specifies whether the ACS url is logged at info level on each connection
+	// attempt, rather than at debug level, to help debug endpoint and region issues. The values of
+	// query parameters that could carry credentials are redacted.
+	ACSURLLoggingEnabled BooleanDefaultFalse
+
+	// ACSAgentMetricsInterval specifies the interval at which the agent sends its own metrics,
+	// such as its reconnect and task counts, to ACS. Agent metrics are not sent when it is zero.
+	ACSAgentMetricsInterval time.Duration
+
+	// ACSPayloadCaptureFile specifies the path of a local file to which the raw messages received from
+	// ACS are written, with their credentials redacted, for debugging. Messages are not captured when it is empty.
+	ACSPayloadCaptureFile string
+
 	// ShouldExcludeIPv6PortBinding specifies whether agent should exclude IPv6 port bindings reported from docker. This configuration
 	// is set to true by default, and can be overridden by the ECS_EXCLUDE_IPV6_PORTBINDING environment variable. This is a workaround
 	// for docker's bug as detailed in https://github.com/aws/amazon-ecs-agent/issues/2870.
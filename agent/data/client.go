@@ -16,6 +16,7 @@ package data
 import (
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/aws/amazon-ecs-agent/agent/api/container"
 	"github.com/aws/amazon-ecs-agent/agent/api/task"
@@ -69,6 +70,9 @@ type Client interface {
 	DeleteTask(string) error
 	// GetTasks gets the data of all the tasks.
 	GetTasks() ([]*task.Task, error)
+	// PruneStoppedTasks deletes the data of the tasks that stopped before the given time, along with
+	// the data of their containers, and returns the ids of the pruned tasks.
+	PruneStoppedTasks(time.Time) ([]string, error)
 
 	// SaveImageState saves the data of an image state.
 	SaveImageState(*image.ImageState) error
@@ -96,13 +100,27 @@ type Client interface {
 // client implements the Client interface using boltdb as the backing data store.
 type client struct {
 	db *bolt.DB
+	// compressRecords indicates whether records are gzip compressed before they are persisted.
+	compressRecords bool
+}
+
+// Option configures optional behavior of the data client.
+type Option func(*client)
+
+// WithRecordCompression sets whether records are gzip compressed before they are persisted. Records are
+// always readable regardless of this setting, so an existing data store migrates transparently as its
+// records are saved again.
+func WithRecordCompression(enabled bool) Option {
+	return func(c *client) {
+		c.compressRecords = enabled
+	}
 }
 
 // New returns a data client that implements the Client interface with boltdb.
-func New(dataDir string) (Client, error) {
+func New(dataDir string, opts ...Option) (Client, error) {
 	var err error
 	once.Do(func() {
-		dbClient, err = setup(dataDir)
+		dbClient, err = setup(dataDir, opts...)
 	})
 	if err != nil {
 		return nil, err
@@ -112,12 +130,12 @@ func New(dataDir string) (Client, error) {
 
 // NewWithSetup returns a data client that implements the Client interface with boltdb.
 // It always runs the db setup. Used for testing.
-func NewWithSetup(dataDir string) (Client, error) {
-	return setup(dataDir)
+func NewWithSetup(dataDir string, opts ...Option) (Client, error) {
+	return setup(dataDir, opts...)
 }
 
 // setup initiates the boltdb client and makes sure the buckets we use are created.
-func setup(dataDir string) (*client, error) {
+func setup(dataDir string, opts ...Option) (*client, error) {
 	db, err := bolt.Open(filepath.Join(dataDir, dbName), dbMode, nil)
 	err = db.Update(func(tx *bolt.Tx) error {
 		for _, b := range buckets {
@@ -132,9 +150,13 @@ func setup(dataDir string) (*client, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &client{
+	c := &client{
 		db: db,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
 }
 
 // Close closes the boltdb connection.
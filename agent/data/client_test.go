@@ -20,6 +20,9 @@ import (
 	"path/filepath"
 	"testing"
 
+	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
+
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	bolt "go.etcd.io/bbolt"
 )
@@ -48,3 +51,35 @@ func newTestClient(t *testing.T) Client {
 	})
 	return testClient
 }
+
+func TestClientRecordCompressionMigration(t *testing.T) {
+	testDir := t.TempDir()
+
+	// Persist state without compression, as agents did before compression was supported.
+	legacyClient, err := NewWithSetup(testDir)
+	require.NoError(t, err)
+	require.NoError(t, legacyClient.SaveTask(&apitask.Task{Arn: testTaskArn}))
+	require.NoError(t, legacyClient.SaveMetadata(ClusterNameKey, "test-cluster"))
+	require.NoError(t, legacyClient.Close())
+
+	// Turn on compression: legacy records remain readable and new records are compressed.
+	compressedClient, err := NewWithSetup(testDir, WithRecordCompression(true))
+	require.NoError(t, err)
+	tasks, err := compressedClient.GetTasks()
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, testTaskArn, tasks[0].Arn)
+	val, err := compressedClient.GetMetadata(ClusterNameKey)
+	require.NoError(t, err)
+	assert.Equal(t, "test-cluster", val)
+	require.NoError(t, compressedClient.SaveMetadata(ClusterNameKey, "new-cluster"))
+	require.NoError(t, compressedClient.Close())
+
+	// Turn compression back off: compressed records remain readable.
+	uncompressedClient, err := NewWithSetup(testDir)
+	require.NoError(t, err)
+	defer uncompressedClient.Close()
+	val, err = uncompressedClient.GetMetadata(ClusterNameKey)
+	require.NoError(t, err)
+	assert.Equal(t, "new-cluster", val)
+}
@@ -31,7 +31,7 @@ func (c *client) SaveDockerContainer(container *apicontainer.DockerContainer) er
 	}
 	return c.db.Batch(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(containersBucketName))
-		return putObject(b, id, container)
+		return putObject(b, id, container, c.compressRecords)
 	})
 }
 
@@ -51,7 +51,7 @@ func (c *client) SaveContainer(container *apicontainer.Container) error {
 	dockerContainer.Container = container
 	return c.db.Batch(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(containersBucketName))
-		return putObject(b, id, dockerContainer)
+		return putObject(b, id, dockerContainer, c.compressRecords)
 	})
 }
 
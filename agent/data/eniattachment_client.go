@@ -30,7 +30,7 @@ func (c *client) SaveENIAttachment(eni *apieni.ENIAttachment) error {
 	}
 	return c.db.Batch(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(eniAttachmentsBucketName))
-		return putObject(b, id, eni)
+		return putObject(b, id, eni, c.compressRecords)
 	})
 }
 
@@ -14,19 +14,33 @@
 package data
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"io"
 
 	"github.com/pkg/errors"
 	bolt "go.etcd.io/bbolt"
 )
 
-func putObject(bucket *bolt.Bucket, key string, obj interface{}) error {
+// compressedRecordMagic prefixes every gzip compressed record in the data store. Serialized JSON never
+// starts with this byte, which lets reads tell compressed records apart from legacy uncompressed ones.
+const compressedRecordMagic byte = 0x00
+
+func putObject(bucket *bolt.Bucket, key string, obj interface{}, compress bool) error {
 	keyBytes := []byte(key)
 	data, err := json.Marshal(obj)
 	if err != nil {
 		return errors.Wrapf(err, "failed to marshal object with key %q", key)
 	}
 
+	if compress {
+		data, err = compressRecord(data)
+		if err != nil {
+			return errors.Wrapf(err, "failed to compress object with key %q", key)
+		}
+	}
+
 	if err := bucket.Put(keyBytes, data); err != nil {
 		return errors.Wrapf(err, "failed to insert object with key %q", key)
 	}
@@ -42,6 +56,10 @@ func getObject(tx *bolt.Tx, bucketName, id string, out interface{}) error {
 	}
 
 	if out != nil {
+		data, err := decompressRecord(data)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decompress object with key %q", id)
+		}
 		if err := json.Unmarshal(data, out); err != nil {
 			return errors.Wrapf(err, "failed to unmarshal object with key %q", id)
 		}
@@ -50,10 +68,15 @@ func getObject(tx *bolt.Tx, bucketName, id string, out interface{}) error {
 	return nil
 }
 
+// walk invokes the callback with the id and the uncompressed data of every record in the bucket.
 func walk(bucket *bolt.Bucket, callback func(id string, data []byte) error) error {
 	cursor := bucket.Cursor()
 
 	for id, data := cursor.First(); id != nil; id, data = cursor.Next() {
+		data, err := decompressRecord(data)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decompress object with key %q", string(id))
+		}
 		if err := callback(string(id), data); err != nil {
 			return err
 		}
@@ -61,3 +84,32 @@ func walk(bucket *bolt.Bucket, callback func(id string, data []byte) error) erro
 
 	return nil
 }
+
+// compressRecord gzips the serialized record and prefixes it with compressedRecordMagic.
+func compressRecord(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(compressedRecordMagic)
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressRecord returns the serialized record stored in data. Records without the compressedRecordMagic
+// prefix were written uncompressed and are returned as is, so that existing data stores keep working after
+// compression is turned on or off.
+func decompressRecord(data []byte) ([]byte, error) {
+	if len(data) == 0 || data[0] != compressedRecordMagic {
+		return data, nil
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(data[1:]))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
@@ -61,7 +61,7 @@ func TestHelpers(t *testing.T) {
 
 	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(testBucketName))
-		return putObject(b, testObj.Key, testObj)
+		return putObject(b, testObj.Key, testObj, false)
 	}))
 
 	assert.Error(t, db.Update(func(tx *bolt.Tx) error {
@@ -89,3 +89,97 @@ func TestHelpers(t *testing.T) {
 	assert.Len(t, resArr, 1)
 	assert.Equal(t, testObj.Val, resArr[0].Val)
 }
+
+func TestHelpersCompressedRecord(t *testing.T) {
+	db := setupHelpersTest(t)
+
+	testObj := &testObjType{
+		Key: "key",
+		Val: "test",
+	}
+
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(testBucketName))
+		return putObject(b, testObj.Key, testObj, true)
+	}))
+
+	require.NoError(t, db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket([]byte(testBucketName)).Get([]byte(testObj.Key))
+		require.NotEmpty(t, data)
+		assert.Equal(t, compressedRecordMagic, data[0])
+		return nil
+	}))
+
+	res := &testObjType{}
+	require.NoError(t, db.View(func(tx *bolt.Tx) error {
+		return getObject(tx, testBucketName, testObj.Key, res)
+	}))
+	assert.Equal(t, testObj, res)
+
+	var resArr []*testObjType
+	require.NoError(t, db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(testBucketName))
+		return walk(b, func(id string, data []byte) error {
+			obj := &testObjType{}
+			if err := json.Unmarshal(data, &obj); err != nil {
+				return err
+			}
+			resArr = append(resArr, obj)
+			return nil
+		})
+	}))
+	require.Len(t, resArr, 1)
+	assert.Equal(t, testObj, resArr[0])
+}
+
+func TestHelpersLegacyUncompressedRecord(t *testing.T) {
+	db := setupHelpersTest(t)
+
+	legacyObj := &testObjType{
+		Key: "legacy",
+		Val: "uncompressed",
+	}
+	newObj := &testObjType{
+		Key: "new",
+		Val: "compressed",
+	}
+
+	// Write a record the way older agents did, then a compressed one next to it.
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		data, err := json.Marshal(legacyObj)
+		require.NoError(t, err)
+		b := tx.Bucket([]byte(testBucketName))
+		if err := b.Put([]byte(legacyObj.Key), data); err != nil {
+			return err
+		}
+		return putObject(b, newObj.Key, newObj, true)
+	}))
+
+	res := &testObjType{}
+	require.NoError(t, db.View(func(tx *bolt.Tx) error {
+		return getObject(tx, testBucketName, legacyObj.Key, res)
+	}))
+	assert.Equal(t, legacyObj, res)
+
+	resMap := make(map[string]*testObjType)
+	require.NoError(t, db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(testBucketName))
+		return walk(b, func(id string, data []byte) error {
+			obj := &testObjType{}
+			if err := json.Unmarshal(data, &obj); err != nil {
+				return err
+			}
+			resMap[id] = obj
+			return nil
+		})
+	}))
+	assert.Equal(t, map[string]*testObjType{
+		legacyObj.Key: legacyObj,
+		newObj.Key:    newObj,
+	}, resMap)
+}
+
+func TestDecompressRecordCorrupted(t *testing.T) {
+	_, err := decompressRecord([]byte{compressedRecordMagic, 'x', 'y'})
+	assert.Error(t, err)
+}
@@ -29,7 +29,7 @@ func (c *client) SaveImageState(img *image.ImageState) error {
 	}
 	return c.db.Batch(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(imagesBucketName))
-		return putObject(b, id, img)
+		return putObject(b, id, img, c.compressRecords)
 	})
 }
 
@@ -20,8 +20,10 @@ import (
 const (
 	AgentVersionKey         = "agent-version"
 	AvailabilityZoneKey     = "availability-zone"
+	CapabilityUpdatesKey    = "capability-updates"
 	ClusterNameKey          = "cluster-name"
 	ContainerInstanceARNKey = "container-instance-arn"
+	DrainStateKey           = "drain-state"
 	EC2InstanceIDKey        = "ec2-instance-id"
 	TaskManifestSeqNumKey   = "task-manifest-seq-num"
 )
@@ -29,7 +31,7 @@ const (
 func (c *client) SaveMetadata(key, val string) error {
 	return c.db.Batch(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(metadataBucketName))
-		return putObject(b, key, val)
+		return putObject(b, key, val, c.compressRecords)
 	})
 }
 
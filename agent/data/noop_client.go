@@ -14,6 +14,8 @@
 package data
 
 import (
+	"time"
+
 	"github.com/aws/amazon-ecs-agent/agent/api/container"
 	"github.com/aws/amazon-ecs-agent/agent/api/task"
 	"github.com/aws/amazon-ecs-agent/agent/engine/image"
@@ -56,6 +58,10 @@ func (c *noopClient) GetTasks() ([]*task.Task, error) {
 	return nil, nil
 }
 
+func (c *noopClient) PruneStoppedTasks(time.Time) ([]string, error) {
+	return nil, nil
+}
+
 func (c *noopClient) SaveImageState(*image.ImageState) error {
 	return nil
 }
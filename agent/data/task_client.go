@@ -14,9 +14,12 @@
 package data
 
 import (
+	"bytes"
 	"encoding/json"
+	"time"
 
 	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
+	apitaskstatus "github.com/aws/amazon-ecs-agent/agent/api/task/status"
 	"github.com/aws/amazon-ecs-agent/agent/utils"
 
 	"github.com/pkg/errors"
@@ -31,7 +34,7 @@ func (c *client) SaveTask(task *apitask.Task) error {
 	}
 	return c.db.Batch(func(tx *bolt.Tx) error {
 		b := tx.Bucket([]byte(tasksBucketName))
-		return putObject(b, id, task)
+		return putObject(b, id, task, c.compressRecords)
 	})
 }
 
@@ -59,3 +62,60 @@ func (c *client) GetTasks() ([]*apitask.Task, error) {
 	})
 	return tasks, err
 }
+
+// PruneStoppedTasks deletes the tasks in the task bucket that are known to be stopped and that stopped
+// before the given time, along with their containers in the container bucket. Tasks that are not stopped
+// are never pruned. All the deletes are done in a single transaction, so that a crash never leaves the
+// containers of a pruned task behind.
+func (c *client) PruneStoppedTasks(stoppedBefore time.Time) ([]string, error) {
+	var prunedIDs []string
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		tasksBucket := tx.Bucket([]byte(tasksBucketName))
+		err := walk(tasksBucket, func(id string, data []byte) error {
+			task := apitask.Task{}
+			if err := json.Unmarshal(data, &task); err != nil {
+				return err
+			}
+			stoppedAt := task.GetExecutionStoppedAt()
+			if task.GetKnownStatus() == apitaskstatus.TaskStopped && !stoppedAt.IsZero() &&
+				stoppedAt.Before(stoppedBefore) {
+				prunedIDs = append(prunedIDs, id)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		containersBucket := tx.Bucket([]byte(containersBucketName))
+		for _, id := range prunedIDs {
+			// Container ids are of the form "<task id>-<container name>", see GetContainerID.
+			if err := deleteWithPrefix(containersBucket, []byte(id+"-")); err != nil {
+				return errors.Wrapf(err, "failed to delete containers of task %s", id)
+			}
+			if err := tasksBucket.Delete([]byte(id)); err != nil {
+				return errors.Wrapf(err, "failed to delete task %s", id)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return prunedIDs, nil
+}
+
+// deleteWithPrefix deletes all the records in the bucket whose key starts with the prefix.
+func deleteWithPrefix(bucket *bolt.Bucket, prefix []byte) error {
+	var keys [][]byte
+	cursor := bucket.Cursor()
+	for key, _ := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+		keys = append(keys, append([]byte(nil), key...))
+	}
+	for _, key := range keys {
+		if err := bucket.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
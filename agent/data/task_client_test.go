@@ -18,8 +18,11 @@ package data
 
 import (
 	"testing"
+	"time"
 
+	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
 	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
+	apitaskstatus "github.com/aws/amazon-ecs-agent/agent/api/task/status"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -55,3 +58,58 @@ func TestSaveTaskInvalidID(t *testing.T) {
 	}
 	assert.Error(t, testClient.SaveTask(testTask))
 }
+
+func TestPruneStoppedTasks(t *testing.T) {
+	testClient := newTestClient(t)
+	now := time.Now()
+	maxAge := 24 * time.Hour
+
+	newTask := func(id string, knownStatus apitaskstatus.TaskStatus, stoppedAt time.Time) *apitask.Task {
+		task := &apitask.Task{
+			Arn:                      "arn:aws:ecs:us-west-2:1234567890:task/test-cluster/" + id,
+			KnownStatusUnsafe:        knownStatus,
+			ExecutionStoppedAtUnsafe: stoppedAt,
+		}
+		require.NoError(t, testClient.SaveTask(task))
+		require.NoError(t, testClient.SaveContainer(&apicontainer.Container{
+			Name:          testContainerName,
+			TaskARNUnsafe: task.Arn,
+		}))
+		return task
+	}
+	newTask("old-stopped", apitaskstatus.TaskStopped, now.Add(-2*maxAge))
+	newTask("other-old-stopped", apitaskstatus.TaskStopped, now.Add(-maxAge-time.Minute))
+	newTask("recent-stopped", apitaskstatus.TaskStopped, now.Add(-time.Hour))
+	// A task that is still stopping is never pruned, even if its containers stopped long ago.
+	newTask("old-stopping", apitaskstatus.TaskRunning, now.Add(-2*maxAge))
+	newTask("running", apitaskstatus.TaskRunning, time.Time{})
+
+	prunedIDs, err := testClient.PruneStoppedTasks(now.Add(-maxAge))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"old-stopped", "other-old-stopped"}, prunedIDs)
+
+	tasks, err := testClient.GetTasks()
+	require.NoError(t, err)
+	var taskArns []string
+	for _, task := range tasks {
+		taskArns = append(taskArns, task.Arn)
+	}
+	assert.ElementsMatch(t, []string{
+		"arn:aws:ecs:us-west-2:1234567890:task/test-cluster/recent-stopped",
+		"arn:aws:ecs:us-west-2:1234567890:task/test-cluster/old-stopping",
+		"arn:aws:ecs:us-west-2:1234567890:task/test-cluster/running",
+	}, taskArns)
+
+	containers, err := testClient.GetContainers()
+	require.NoError(t, err)
+	var containerTaskArns []string
+	for _, container := range containers {
+		containerTaskArns = append(containerTaskArns, container.Container.GetTaskARN())
+	}
+	assert.ElementsMatch(t, taskArns, containerTaskArns, "containers of pruned tasks should be pruned")
+
+	// Pruning again is a no-op.
+	prunedIDs, err = testClient.PruneStoppedTasks(now.Add(-maxAge))
+	require.NoError(t, err)
+	assert.Empty(t, prunedIDs)
+}
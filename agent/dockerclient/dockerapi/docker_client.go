@@ -824,6 +824,9 @@ func MetadataFromContainer(dockerContainer *types.ContainerJSON) DockerContainer
 	if dockerContainer.State == nil {
 		return metadata
 	}
+	if dockerContainer.State.Running {
+		metadata.PID = dockerContainer.State.Pid
+	}
 	if !dockerContainer.State.Running && !finishedTime.IsZero() {
 		// Only record an exitcode if it has exited
 		metadata.ExitCode = &dockerContainer.State.ExitCode
@@ -1817,6 +1817,7 @@ func TestMetadataFromContainer(t *testing.T) {
 			Created: created,
 			State: &types.ContainerState{
 				Running:    true,
+				Pid:        4242,
 				StartedAt:  started,
 				FinishedAt: finished,
 			},
@@ -1838,6 +1839,7 @@ func TestMetadataFromContainer(t *testing.T) {
 	assert.Equal(t, "bridge", metadata.NetworkMode)
 	assert.NotNil(t, metadata.NetworkSettings)
 	assert.Equal(t, "17.0.0.3", metadata.NetworkSettings.IPAddress)
+	assert.Equal(t, 4242, metadata.PID)
 
 	// Need to convert both strings to same format to be able to compare. Parse and Format are not inverses.
 	createdTimeSDK, _ := time.Parse(time.RFC3339, dockerContainer.Created)
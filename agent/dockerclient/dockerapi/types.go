@@ -75,6 +75,8 @@ type DockerContainerMetadata struct {
 	NetworkMode string
 	// NetworksUnsafe denotes the Docker Network Settings in the container
 	NetworkSettings *types.NetworkSettings
+	// PID is the host PID of the container's main process. It is only set if the container is running
+	PID int
 }
 
 // ListContainersResponse encapsulates the response from the docker client for the
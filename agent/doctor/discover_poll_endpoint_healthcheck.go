@@ -0,0 +1,131 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//      http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package doctor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/doctor"
+	"github.com/cihub/seelog"
+)
+
+// discoverPollEndpointHealthcheck reports the agent as impaired while discovering
+// the ACS endpoint keeps failing with authentication or permission errors, e.g.
+// because the instance credentials are invalid.
+type discoverPollEndpointHealthcheck struct {
+	// HealthcheckType is the reported healthcheck type
+	HealthcheckType string `json:"HealthcheckType,omitempty"`
+	// Status is the endpoint discovery health status
+	Status doctor.HealthcheckStatus `json:"HealthcheckStatus,omitempty"`
+	// Timestamp is the timestamp when endpoint discovery health status changed
+	TimeStamp time.Time `json:"TimeStamp,omitempty"`
+	// StatusChangeTime is the latest time the health status changed
+	StatusChangeTime time.Time `json:"StatusChangeTime,omitempty"`
+
+	// LastStatus is the last endpoint discovery health status
+	LastStatus doctor.HealthcheckStatus `json:"LastStatus,omitempty"`
+	// LastTimeStamp is the timestamp of last endpoint discovery health status
+	LastTimeStamp time.Time `json:"LastTimeStamp,omitempty"`
+
+	authFailing bool
+	lock        sync.RWMutex
+}
+
+// NewDiscoverPollEndpointHealthcheck returns a healthcheck that is impaired while
+// endpoint discovery is reported to be failing with authentication errors through
+// SetAuthFailing, and healthy otherwise.
+func NewDiscoverPollEndpointHealthcheck() *discoverPollEndpointHealthcheck {
+	nowTime := time.Now()
+	return &discoverPollEndpointHealthcheck{
+		HealthcheckType:  doctor.HealthcheckTypeAgent,
+		Status:           doctor.HealthcheckStatusInitializing,
+		TimeStamp:        nowTime,
+		StatusChangeTime: nowTime,
+	}
+}
+
+// SetAuthFailing sets whether endpoint discovery is failing with authentication errors.
+func (dpe *discoverPollEndpointHealthcheck) SetAuthFailing(authFailing bool) {
+	dpe.lock.Lock()
+	defer dpe.lock.Unlock()
+	dpe.authFailing = authFailing
+}
+
+func (dpe *discoverPollEndpointHealthcheck) RunCheck() doctor.HealthcheckStatus {
+	dpe.lock.RLock()
+	authFailing := dpe.authFailing
+	dpe.lock.RUnlock()
+
+	resultStatus := doctor.HealthcheckStatusOk
+	if authFailing {
+		seelog.Warn("[DiscoverPollEndpointHealthcheck] Discovering the ACS endpoint is failing with authentication errors")
+		resultStatus = doctor.HealthcheckStatusImpaired
+	}
+	dpe.SetHealthcheckStatus(resultStatus)
+	return resultStatus
+}
+
+func (dpe *discoverPollEndpointHealthcheck) SetHealthcheckStatus(healthStatus doctor.HealthcheckStatus) {
+	dpe.lock.Lock()
+	defer dpe.lock.Unlock()
+	nowTime := time.Now()
+	// if the status has changed, update status change timestamp
+	if dpe.Status != healthStatus {
+		dpe.StatusChangeTime = nowTime
+	}
+	// track previous status
+	dpe.LastStatus = dpe.Status
+	dpe.LastTimeStamp = dpe.TimeStamp
+
+	// update latest status
+	dpe.Status = healthStatus
+	dpe.TimeStamp = nowTime
+}
+
+func (dpe *discoverPollEndpointHealthcheck) GetHealthcheckType() string {
+	dpe.lock.RLock()
+	defer dpe.lock.RUnlock()
+	return dpe.HealthcheckType
+}
+
+func (dpe *discoverPollEndpointHealthcheck) GetHealthcheckStatus() doctor.HealthcheckStatus {
+	dpe.lock.RLock()
+	defer dpe.lock.RUnlock()
+	return dpe.Status
+}
+
+func (dpe *discoverPollEndpointHealthcheck) GetHealthcheckTime() time.Time {
+	dpe.lock.RLock()
+	defer dpe.lock.RUnlock()
+	return dpe.TimeStamp
+}
+
+func (dpe *discoverPollEndpointHealthcheck) GetStatusChangeTime() time.Time {
+	dpe.lock.RLock()
+	defer dpe.lock.RUnlock()
+	return dpe.StatusChangeTime
+}
+
+func (dpe *discoverPollEndpointHealthcheck) GetLastHealthcheckStatus() doctor.HealthcheckStatus {
+	dpe.lock.RLock()
+	defer dpe.lock.RUnlock()
+	return dpe.LastStatus
+}
+
+func (dpe *discoverPollEndpointHealthcheck) GetLastHealthcheckTime() time.Time {
+	dpe.lock.RLock()
+	defer dpe.lock.RUnlock()
+	return dpe.LastTimeStamp
+}
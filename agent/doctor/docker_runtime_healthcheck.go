@@ -19,12 +19,16 @@ import (
 	"time"
 
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient/dockerapi"
+	"github.com/aws/amazon-ecs-agent/agent/metrics"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/doctor"
 	"github.com/cihub/seelog"
 )
 
 const systemPingTimeout = time.Second * 2
 
+// dockerRuntimeHealthcheck reports the container runtime as impaired when the
+// Docker daemon fails to respond to pings, or responds slower than the configured
+// latency threshold.
 type dockerRuntimeHealthcheck struct {
 	// HealthcheckType is the reported healthcheck type
 	HealthcheckType string `json:"HealthcheckType,omitempty"`
@@ -40,11 +44,15 @@ type dockerRuntimeHealthcheck struct {
 	// LastTimeStamp is the timestamp of last container health status
 	LastTimeStamp time.Time `json:"LastTimeStamp,omitempty"`
 
-	client dockerapi.DockerClient
-	lock   sync.RWMutex
+	client           dockerapi.DockerClient
+	latencyThreshold time.Duration
+	lock             sync.RWMutex
 }
 
-func NewDockerRuntimeHealthcheck(client dockerapi.DockerClient) *dockerRuntimeHealthcheck {
+// NewDockerRuntimeHealthcheck returns a healthcheck that pings the Docker daemon
+// and is impaired when the ping fails or takes longer than latencyThreshold.
+func NewDockerRuntimeHealthcheck(client dockerapi.DockerClient,
+	latencyThreshold time.Duration) *dockerRuntimeHealthcheck {
 	nowTime := time.Now()
 	return &dockerRuntimeHealthcheck{
 		HealthcheckType:  doctor.HealthcheckTypeContainerRuntime,
@@ -52,16 +60,27 @@ func NewDockerRuntimeHealthcheck(client dockerapi.DockerClient) *dockerRuntimeHe
 		TimeStamp:        nowTime,
 		StatusChangeTime: nowTime,
 		client:           client,
+		latencyThreshold: latencyThreshold,
 	}
 }
 
 func (dhc *dockerRuntimeHealthcheck) RunCheck() doctor.HealthcheckStatus {
 	// TODO pass in context as an argument
+	start := time.Now()
 	res := dhc.client.SystemPing(context.TODO(), systemPingTimeout)
+	latency := time.Since(start)
+	if res.Error == nil {
+		// Failed pings are not recorded, as their latency is bounded by the timeout
+		metrics.MetricsEngineGlobal.RecordDockerPingLatency(latency)
+	}
 	resultStatus := doctor.HealthcheckStatusOk
 	if res.Error != nil {
 		seelog.Infof("[DockerRuntimeHealthcheck] Docker Ping failed with error: %v", res.Error)
 		resultStatus = doctor.HealthcheckStatusImpaired
+	} else if latency > dhc.latencyThreshold {
+		seelog.Infof("[DockerRuntimeHealthcheck] Docker Ping took %s, exceeding threshold of %s",
+			latency, dhc.latencyThreshold)
+		resultStatus = doctor.HealthcheckStatusImpaired
 	}
 	dhc.SetHealthcheckStatus(resultStatus)
 	return resultStatus
@@ -1,6 +1,7 @@
 package doctor
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -16,7 +17,7 @@ func TestNewDockerRuntimeHealthCheck(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	mockDockerClient := mock_dockerapi.NewMockDockerClient(ctrl)
-	dockerRuntimeHealthCheck := NewDockerRuntimeHealthcheck(mockDockerClient)
+	dockerRuntimeHealthCheck := NewDockerRuntimeHealthcheck(mockDockerClient, time.Second)
 	assert.Equal(t, doctor.HealthcheckStatusInitializing, dockerRuntimeHealthCheck.Status)
 }
 
@@ -52,7 +53,7 @@ func TestRunCheck(t *testing.T) {
 
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
-			dockerRuntimeHealthCheck := NewDockerRuntimeHealthcheck(dockerClient)
+			dockerRuntimeHealthCheck := NewDockerRuntimeHealthcheck(dockerClient, time.Second)
 			dockerClient.EXPECT().SystemPing(gomock.Any(), gomock.Any()).Return(*tc.dockerPingResponse)
 			dockerRuntimeHealthCheck.RunCheck()
 			assert.Equal(t, tc.expectedStatus, dockerRuntimeHealthCheck.Status)
@@ -66,7 +67,7 @@ func TestSetHealthCheckStatus(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	dockerClient := mock_dockerapi.NewMockDockerClient(ctrl)
-	dockerRuntimeHealthCheck := NewDockerRuntimeHealthcheck(dockerClient)
+	dockerRuntimeHealthCheck := NewDockerRuntimeHealthcheck(dockerClient, time.Second)
 	healthCheckStatus := doctor.HealthcheckStatusOk
 	dockerRuntimeHealthCheck.SetHealthcheckStatus(healthCheckStatus)
 	assert.Equal(t, doctor.HealthcheckStatusOk, dockerRuntimeHealthCheck.Status)
@@ -76,7 +77,7 @@ func TestSetHealthcheckStatusChange(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	dockerClient := mock_dockerapi.NewMockDockerClient(ctrl)
-	dockerRuntimeHealthcheck := NewDockerRuntimeHealthcheck(dockerClient)
+	dockerRuntimeHealthcheck := NewDockerRuntimeHealthcheck(dockerClient, time.Second)
 
 	// we should start in initializing status
 	assert.Equal(t, doctor.HealthcheckStatusInitializing, dockerRuntimeHealthcheck.Status)
@@ -98,3 +99,67 @@ func TestSetHealthcheckStatusChange(t *testing.T) {
 	// have we updated our change time?
 	assert.True(t, okChangeTime.After(initializationChangeTime))
 }
+
+func TestRunCheckPingLatency(t *testing.T) {
+	testcases := []struct {
+		name           string
+		pingDelay      time.Duration
+		pingResponse   dockerapi.PingResponse
+		expectedStatus doctor.HealthcheckStatus
+	}{
+		{
+			name:           "fast ping",
+			pingResponse:   dockerapi.PingResponse{Response: &types.Ping{APIVersion: "test_api_version"}},
+			expectedStatus: doctor.HealthcheckStatusOk,
+		},
+		{
+			name:           "slow ping",
+			pingDelay:      100 * time.Millisecond,
+			pingResponse:   dockerapi.PingResponse{Response: &types.Ping{APIVersion: "test_api_version"}},
+			expectedStatus: doctor.HealthcheckStatusImpaired,
+		},
+		{
+			name:           "ping error",
+			pingResponse:   dockerapi.PingResponse{Error: &dockerapi.DockerTimeoutError{}},
+			expectedStatus: doctor.HealthcheckStatusImpaired,
+		},
+	}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	dockerClient := mock_dockerapi.NewMockDockerClient(ctrl)
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			healthcheck := NewDockerRuntimeHealthcheck(dockerClient, 50*time.Millisecond)
+			assert.Equal(t, doctor.HealthcheckStatusInitializing, healthcheck.GetHealthcheckStatus())
+			dockerClient.EXPECT().SystemPing(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, timeout time.Duration) dockerapi.PingResponse {
+					time.Sleep(tc.pingDelay)
+					return tc.pingResponse
+				})
+			assert.Equal(t, tc.expectedStatus, healthcheck.RunCheck())
+			assert.Equal(t, tc.expectedStatus, healthcheck.GetHealthcheckStatus())
+			assert.Equal(t, doctor.HealthcheckStatusInitializing, healthcheck.GetLastHealthcheckStatus())
+		})
+	}
+}
+
+func TestRunCheckPingLatencyRecovers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	dockerClient := mock_dockerapi.NewMockDockerClient(ctrl)
+	healthcheck := NewDockerRuntimeHealthcheck(dockerClient, 50*time.Millisecond)
+
+	gomock.InOrder(
+		dockerClient.EXPECT().SystemPing(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, timeout time.Duration) dockerapi.PingResponse {
+				time.Sleep(100 * time.Millisecond)
+				return dockerapi.PingResponse{Response: &types.Ping{}}
+			}),
+		dockerClient.EXPECT().SystemPing(gomock.Any(), gomock.Any()).Return(
+			dockerapi.PingResponse{Response: &types.Ping{}}),
+	)
+	assert.Equal(t, doctor.HealthcheckStatusImpaired, healthcheck.RunCheck())
+	assert.Equal(t, doctor.HealthcheckStatusOk, healthcheck.RunCheck())
+	assert.Equal(t, doctor.HealthcheckStatusImpaired, healthcheck.GetLastHealthcheckStatus())
+}
@@ -0,0 +1,120 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//      http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package doctor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/doctor"
+	"github.com/cihub/seelog"
+)
+
+// instanceIdentityHealthcheck reports the agent as impaired when the container
+// instance identity restored from saved state turned out to be shared with
+// another instance, e.g. because the state file was baked into an AMI.
+type instanceIdentityHealthcheck struct {
+	// HealthcheckType is the reported healthcheck type
+	HealthcheckType string `json:"HealthcheckType,omitempty"`
+	// Status is the instance identity health status
+	Status doctor.HealthcheckStatus `json:"HealthcheckStatus,omitempty"`
+	// Timestamp is the timestamp when instance identity health status changed
+	TimeStamp time.Time `json:"TimeStamp,omitempty"`
+	// StatusChangeTime is the latest time the health status changed
+	StatusChangeTime time.Time `json:"StatusChangeTime,omitempty"`
+
+	// LastStatus is the last instance identity health status
+	LastStatus doctor.HealthcheckStatus `json:"LastStatus,omitempty"`
+	// LastTimeStamp is the timestamp of last instance identity health status
+	LastTimeStamp time.Time `json:"LastTimeStamp,omitempty"`
+
+	duplicateIdentity bool
+	lock              sync.RWMutex
+}
+
+// NewInstanceIdentityHealthcheck returns a healthcheck that is impaired when
+// duplicateIdentity is set, and healthy otherwise.
+func NewInstanceIdentityHealthcheck(duplicateIdentity bool) *instanceIdentityHealthcheck {
+	nowTime := time.Now()
+	return &instanceIdentityHealthcheck{
+		HealthcheckType:   doctor.HealthcheckTypeAgent,
+		Status:            doctor.HealthcheckStatusInitializing,
+		TimeStamp:         nowTime,
+		StatusChangeTime:  nowTime,
+		duplicateIdentity: duplicateIdentity,
+	}
+}
+
+func (ihc *instanceIdentityHealthcheck) RunCheck() doctor.HealthcheckStatus {
+	resultStatus := doctor.HealthcheckStatusOk
+	if ihc.duplicateIdentity {
+		seelog.Warn("[InstanceIdentityHealthcheck] Container instance ARN restored from saved state is in use by another instance")
+		resultStatus = doctor.HealthcheckStatusImpaired
+	}
+	ihc.SetHealthcheckStatus(resultStatus)
+	return resultStatus
+}
+
+func (ihc *instanceIdentityHealthcheck) SetHealthcheckStatus(healthStatus doctor.HealthcheckStatus) {
+	ihc.lock.Lock()
+	defer ihc.lock.Unlock()
+	nowTime := time.Now()
+	// if the status has changed, update status change timestamp
+	if ihc.Status != healthStatus {
+		ihc.StatusChangeTime = nowTime
+	}
+	// track previous status
+	ihc.LastStatus = ihc.Status
+	ihc.LastTimeStamp = ihc.TimeStamp
+
+	// update latest status
+	ihc.Status = healthStatus
+	ihc.TimeStamp = nowTime
+}
+
+func (ihc *instanceIdentityHealthcheck) GetHealthcheckType() string {
+	ihc.lock.RLock()
+	defer ihc.lock.RUnlock()
+	return ihc.HealthcheckType
+}
+
+func (ihc *instanceIdentityHealthcheck) GetHealthcheckStatus() doctor.HealthcheckStatus {
+	ihc.lock.RLock()
+	defer ihc.lock.RUnlock()
+	return ihc.Status
+}
+
+func (ihc *instanceIdentityHealthcheck) GetHealthcheckTime() time.Time {
+	ihc.lock.RLock()
+	defer ihc.lock.RUnlock()
+	return ihc.TimeStamp
+}
+
+func (ihc *instanceIdentityHealthcheck) GetStatusChangeTime() time.Time {
+	ihc.lock.RLock()
+	defer ihc.lock.RUnlock()
+	return ihc.StatusChangeTime
+}
+
+func (ihc *instanceIdentityHealthcheck) GetLastHealthcheckStatus() doctor.HealthcheckStatus {
+	ihc.lock.RLock()
+	defer ihc.lock.RUnlock()
+	return ihc.LastStatus
+}
+
+func (ihc *instanceIdentityHealthcheck) GetLastHealthcheckTime() time.Time {
+	ihc.lock.RLock()
+	defer ihc.lock.RUnlock()
+	return ihc.LastTimeStamp
+}
@@ -0,0 +1,55 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//      http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package doctor
+
+import (
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/doctor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstanceIdentityHealthcheckRunCheck(t *testing.T) {
+	testcases := []struct {
+		name              string
+		duplicateIdentity bool
+		expectedStatus    doctor.HealthcheckStatus
+	}{
+		{
+			name:              "unique identity",
+			duplicateIdentity: false,
+			expectedStatus:    doctor.HealthcheckStatusOk,
+		},
+		{
+			name:              "duplicate identity",
+			duplicateIdentity: true,
+			expectedStatus:    doctor.HealthcheckStatusImpaired,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			healthcheck := NewInstanceIdentityHealthcheck(tc.duplicateIdentity)
+			assert.Equal(t, doctor.HealthcheckStatusInitializing, healthcheck.GetHealthcheckStatus())
+			assert.Equal(t, doctor.HealthcheckTypeAgent, healthcheck.GetHealthcheckType())
+
+			assert.Equal(t, tc.expectedStatus, healthcheck.RunCheck())
+			assert.Equal(t, tc.expectedStatus, healthcheck.GetHealthcheckStatus())
+			assert.Equal(t, doctor.HealthcheckStatusInitializing, healthcheck.GetLastHealthcheckStatus())
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ecscni
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// operationLimiter bounds the number of CNI operations that run concurrently, so that starting
+// or stopping many tasks at once doesn't overwhelm the host networking stack. When more than one
+// operation is allowed, setups can only use all but one of the slots, so that teardowns, which
+// release the resources setups may be waiting on, aren't starved when there's setup load.
+type operationLimiter struct {
+	// slots holds a token for each running operation
+	slots chan struct{}
+	// setupSlots holds a token for each running setup
+	setupSlots chan struct{}
+}
+
+// newOperationLimiter returns a limiter of up to maxOperations concurrent CNI operations. nil is
+// returned if maxOperations is not positive, in which case operations are not limited.
+func newOperationLimiter(maxOperations int) *operationLimiter {
+	if maxOperations <= 0 {
+		return nil
+	}
+	maxSetups := maxOperations - 1
+	if maxSetups < 1 {
+		maxSetups = 1
+	}
+	return &operationLimiter{
+		slots:      make(chan struct{}, maxOperations),
+		setupSlots: make(chan struct{}, maxSetups),
+	}
+}
+
+// acquireSetup waits for a slot to set up a namespace. The returned function releases the slot.
+func (l *operationLimiter) acquireSetup(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	if err := acquireSlot(ctx, l.setupSlots); err != nil {
+		return nil, err
+	}
+	if err := acquireSlot(ctx, l.slots); err != nil {
+		<-l.setupSlots
+		return nil, err
+	}
+	return func() {
+		<-l.slots
+		<-l.setupSlots
+	}, nil
+}
+
+// acquireTeardown waits for a slot to tear down a namespace. The returned function releases the slot.
+func (l *operationLimiter) acquireTeardown(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	if err := acquireSlot(ctx, l.slots); err != nil {
+		return nil, err
+	}
+	return func() {
+		<-l.slots
+	}, nil
+}
+
+func acquireSlot(ctx context.Context, slots chan struct{}) error {
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "timed out waiting for other CNI operations to complete")
+	}
+}
@@ -0,0 +1,75 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package ecscni
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOperationLimiterTeardownNotStarved tests that a teardown gets a slot while setups are
+// waiting for one
+func TestOperationLimiterTeardownNotStarved(t *testing.T) {
+	limiter := newOperationLimiter(2)
+
+	releaseSetup, err := limiter.acquireSetup(context.TODO())
+	require.NoError(t, err)
+	defer releaseSetup()
+
+	// All the slots setups can use are taken
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limiter.acquireSetup(ctx)
+	assert.Error(t, err)
+
+	ctx, cancel = context.WithTimeout(context.TODO(), time.Second)
+	defer cancel()
+	releaseTeardown, err := limiter.acquireTeardown(ctx)
+	require.NoError(t, err)
+	releaseTeardown()
+}
+
+// TestOperationLimiterReleasesSlots tests that released slots can be acquired again
+func TestOperationLimiterReleasesSlots(t *testing.T) {
+	limiter := newOperationLimiter(1)
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.TODO(), time.Second)
+		release, err := limiter.acquireSetup(ctx)
+		require.NoError(t, err)
+		release()
+		release, err = limiter.acquireTeardown(ctx)
+		require.NoError(t, err)
+		release()
+		cancel()
+	}
+}
+
+// TestOperationLimiterUnlimited tests that operations are not limited if the maximum is not positive
+func TestOperationLimiterUnlimited(t *testing.T) {
+	limiter := newOperationLimiter(0)
+	assert.Nil(t, limiter)
+	for i := 0; i < 3; i++ {
+		_, err := limiter.acquireSetup(context.TODO())
+		require.NoError(t, err)
+		_, err = limiter.acquireTeardown(context.TODO())
+		require.NoError(t, err)
+	}
+}
@@ -121,6 +121,7 @@ func NewENINetworkConfig(eni *eni.ENI, cfg *Config) (string, *libcni.NetworkConf
 		IPAddresses:           eni.GetIPAddressesWithPrefixLength(),
 		GatewayIPAddresses:    []string{eni.GetSubnetGatewayIPv4Address()},
 		BlockInstanceMetadata: cfg.BlockInstanceMetadata,
+		MTU:                   eni.MTU,
 	}
 
 	networkConfig, err := newNetworkConfig(eniConf, ECSENIPluginName, cfg.MinSupportedCNIVersion)
@@ -142,6 +143,7 @@ func NewBranchENINetworkConfig(eni *eni.ENI, cfg *Config) (string, *libcni.Netwo
 		GatewayIPAddresses:    []string{eni.GetSubnetGatewayIPv4Address()},
 		BlockInstanceMetadata: cfg.BlockInstanceMetadata,
 		InterfaceType:         vpcCNIPluginInterfaceType,
+		MTU:                   eni.MTU,
 	}
 
 	networkConfig, err := newNetworkConfig(eniConf, ECSBranchENIPluginName, cfg.MinSupportedCNIVersion)
@@ -61,6 +61,7 @@ type cniClient struct {
 	pluginsPath string
 	libcni      libcni.CNI
 	guard       cniGuard
+	limiter     *operationLimiter
 }
 
 // guard is the client to call lock and unlock methods on the mutex.
@@ -68,8 +69,9 @@ type guard struct {
 	mutex *sync.Mutex
 }
 
-// NewClient creates a client of ecscni which is used to invoke the plugin
-func NewClient(pluginsPath string) CNIClient {
+// NewClient creates a client of ecscni which is used to invoke the plugin. No more than
+// maxConcurrentOperations namespace setups and cleanups run at once, unless it is not positive.
+func NewClient(pluginsPath string, maxConcurrentOperations int) CNIClient {
 	libcniConfig := &libcni.CNIConfig{
 		Path: []string{pluginsPath},
 	}
@@ -78,6 +80,7 @@ func NewClient(pluginsPath string) CNIClient {
 		pluginsPath: pluginsPath,
 		libcni:      libcniConfig,
 		guard:       newCNIGuard(),
+		limiter:     newOperationLimiter(maxConcurrentOperations),
 	}
 	cniClient.init()
 	return cniClient
@@ -99,6 +102,12 @@ func (client *cniClient) SetupNS(
 	timeout time.Duration) (*current.Result, error) {
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
+
+	release, err := client.limiter.acquireSetup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
 	return client.setupNS(ctx, cfg)
 }
 
@@ -112,6 +121,11 @@ func (client *cniClient) CleanupNS(
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	release, err := client.limiter.acquireTeardown(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
 	return client.cleanupNS(ctx, cfg)
 }
 
@@ -103,6 +103,12 @@ func (client *cniClient) ReleaseIPResource(ctx context.Context, cfg *Config, tim
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	release, err := client.limiter.acquireTeardown(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	runtimeConfig := libcni.RuntimeConf{
 		ContainerID: cfg.ContainerID,
 		NetNS:       fmt.Sprintf(NetnsFormat, cfg.ContainerPID),
@@ -22,6 +22,8 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -59,7 +61,7 @@ func TestSetupNS(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ecscniClient := NewClient("")
+	ecscniClient := NewClient("", 0)
 	libcniClient := mock_libcni.NewMockCNI(ctrl)
 	ecscniClient.(*cniClient).libcni = libcniClient
 
@@ -120,7 +122,7 @@ func TestSetupNSTrunk(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ecscniClient := NewClient("")
+	ecscniClient := NewClient("", 0)
 	libcniClient := mock_libcni.NewMockCNI(ctrl)
 	ecscniClient.(*cniClient).libcni = libcniClient
 
@@ -178,7 +180,7 @@ func TestSetupNSAppMeshEnabled(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ecscniClient := NewClient("")
+	ecscniClient := NewClient("", 0)
 	libcniClient := mock_libcni.NewMockCNI(ctrl)
 	ecscniClient.(*cniClient).libcni = libcniClient
 
@@ -242,7 +244,7 @@ func TestSetupNSServiceConnectEnabled(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ecscniClient := NewClient("")
+	ecscniClient := NewClient("", 0)
 	libcniClient := mock_libcni.NewMockCNI(ctrl)
 	ecscniClient.(*cniClient).libcni = libcniClient
 
@@ -313,7 +315,7 @@ func TestSetupNSTimeout(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ecscniClient := NewClient("")
+	ecscniClient := NewClient("", 0)
 	libcniClient := mock_libcni.NewMockCNI(ctrl)
 	ecscniClient.(*cniClient).libcni = libcniClient
 
@@ -340,7 +342,7 @@ func TestCleanupNS(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ecscniClient := NewClient("")
+	ecscniClient := NewClient("", 0)
 	libcniClient := mock_libcni.NewMockCNI(ctrl)
 	ecscniClient.(*cniClient).libcni = libcniClient
 
@@ -364,7 +366,7 @@ func TestCleanupNSTrunk(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ecscniClient := NewClient("")
+	ecscniClient := NewClient("", 0)
 	libcniClient := mock_libcni.NewMockCNI(ctrl)
 	ecscniClient.(*cniClient).libcni = libcniClient
 
@@ -402,7 +404,7 @@ func TestCleanupNSAppMeshEnabled(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ecscniClient := NewClient("")
+	ecscniClient := NewClient("", 0)
 	libcniClient := mock_libcni.NewMockCNI(ctrl)
 	ecscniClient.(*cniClient).libcni = libcniClient
 
@@ -428,7 +430,7 @@ func TestCleanupNSServiceConnectEnabled(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ecscniClient := NewClient("")
+	ecscniClient := NewClient("", 0)
 	libcniClient := mock_libcni.NewMockCNI(ctrl)
 	ecscniClient.(*cniClient).libcni = libcniClient
 
@@ -449,7 +451,7 @@ func TestCleanupNSTimeout(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ecscniClient := NewClient("")
+	ecscniClient := NewClient("", 0)
 	libcniClient := mock_libcni.NewMockCNI(ctrl)
 	ecscniClient.(*cniClient).libcni = libcniClient
 
@@ -474,11 +476,67 @@ func TestCleanupNSTimeout(t *testing.T) {
 	assert.Error(t, err)
 }
 
+// TestCNIOperationsConcurrencyLimit tests that no more than the configured number of namespace
+// setups and cleanups invoke the cni plugins at the same time
+func TestCNIOperationsConcurrencyLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const maxConcurrentOperations = 3
+	ecscniClient := NewClient("", maxConcurrentOperations)
+	libcniClient := mock_libcni.NewMockCNI(ctrl)
+	ecscniClient.(*cniClient).libcni = libcniClient
+
+	var running, maxRunning int32
+	invokePlugin := func() {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			observed := atomic.LoadInt32(&maxRunning)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxRunning, observed, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+	}
+	libcniClient.EXPECT().AddNetwork(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(context.Context, *libcni.NetworkConfig, *libcni.RuntimeConf) (cnitypes.Result, error) {
+			invokePlugin()
+			return &current.Result{}, nil
+		}).AnyTimes()
+	libcniClient.EXPECT().DelNetwork(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(context.Context, *libcni.NetworkConfig, *libcni.RuntimeConf) error {
+			invokePlugin()
+			return nil
+		}).AnyTimes()
+
+	config := &Config{NetworkConfigs: []*NetworkConfig{}}
+	config.NetworkConfigs = append(config.NetworkConfigs, eniNetworkConfig(config))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := ecscniClient.SetupNS(context.TODO(), config, 5*time.Second)
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, ecscniClient.CleanupNS(context.TODO(), config, 5*time.Second))
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(maxConcurrentOperations))
+	assert.Greater(t, atomic.LoadInt32(&maxRunning), int32(1), "operations should run concurrently")
+}
+
 func TestReleaseIPInIPAM(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ecscniClient := NewClient("")
+	ecscniClient := NewClient("", 0)
 	libcniClient := mock_libcni.NewMockCNI(ctrl)
 	ecscniClient.(*cniClient).libcni = libcniClient
 
@@ -572,6 +630,59 @@ func TestConstructBranchENINetworkConfig(t *testing.T) {
 	}, branchENIConfig)
 }
 
+// TestConstructENINetworkConfigWithMTU tests that the eni MTU is propagated to the
+// eni and branch eni plugin configurations
+func TestConstructENINetworkConfigWithMTU(t *testing.T) {
+	config := &Config{
+		ContainerID:  "containerid12",
+		ContainerPID: "pid",
+	}
+	taskENI := &eni.ENI{
+		ID: eniID,
+		IPV4Addresses: []*eni.ENIIPV4Address{
+			{Address: eniIPV4Address, Primary: true},
+		},
+		MacAddress:               eniMACAddress,
+		SubnetGatewayIPV4Address: eniSubnetGatewayIPV4Address,
+		InterfaceVlanProperties: &eni.InterfaceVlanProperties{
+			TrunkInterfaceMacAddress: trunkENIMACAddress,
+			VlanID:                   branchENIVLANID,
+		},
+		MTU: 9001,
+	}
+
+	_, eniNetworkConfig, err := NewENINetworkConfig(taskENI, config)
+	require.NoError(t, err, "Failed to construct eni network config")
+	eniConfig := &ENIConfig{}
+	require.NoError(t, json.Unmarshal(eniNetworkConfig.Bytes, eniConfig))
+	assert.Equal(t, 9001, eniConfig.MTU)
+
+	_, branchENINetworkConfig, err := NewBranchENINetworkConfig(taskENI, config)
+	require.NoError(t, err, "Failed to construct branch eni network config")
+	branchENIConfig := &BranchENIConfig{}
+	require.NoError(t, json.Unmarshal(branchENINetworkConfig.Bytes, branchENIConfig))
+	assert.Equal(t, 9001, branchENIConfig.MTU)
+}
+
+// TestConstructENINetworkConfigWithoutMTU tests that the MTU is omitted from the eni
+// plugin configuration when the eni uses the standard MTU
+func TestConstructENINetworkConfigWithoutMTU(t *testing.T) {
+	_, eniNetworkConfig, err := NewENINetworkConfig(
+		&eni.ENI{
+			ID: eniID,
+			IPV4Addresses: []*eni.ENIIPV4Address{
+				{Address: eniIPV4Address, Primary: true},
+			},
+			MacAddress:               eniMACAddress,
+			SubnetGatewayIPV4Address: eniSubnetGatewayIPV4Address,
+		},
+		&Config{})
+	require.NoError(t, err, "Failed to construct eni network config")
+	var rawConfig map[string]interface{}
+	require.NoError(t, json.Unmarshal(eniNetworkConfig.Bytes, &rawConfig))
+	assert.NotContains(t, rawConfig, "mtu")
+}
+
 // TestConstructBridgeNetworkConfigWithoutIPAM tests createBridgeNetworkConfigWithoutIPAM creates the right configuration for bridge plugin
 func TestConstructBridgeNetworkConfigWithoutIPAM(t *testing.T) {
 	config := &Config{
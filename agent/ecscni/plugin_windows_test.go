@@ -81,7 +81,7 @@ func TestSetupNS(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ecscniClient := NewClient("")
+	ecscniClient := NewClient("", 0)
 	libcniClient := mock_libcni.NewMockCNI(ctrl)
 	ecscniClient.(*cniClient).libcni = libcniClient
 
@@ -107,7 +107,7 @@ func TestSetupNSTimeout(t *testing.T) {
 	// Override the maximum retry timeout for the tests
 	setupNSBackoffMax = setupNSBackoffMin
 
-	ecscniClient := NewClient("")
+	ecscniClient := NewClient("", 0)
 	libcniClient := mock_libcni.NewMockCNI(ctrl)
 	ecscniClient.(*cniClient).libcni = libcniClient
 
@@ -129,7 +129,7 @@ func TestSetupNSWithRetry(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ecscniClient := NewClient("")
+	ecscniClient := NewClient("", 0)
 	libcniClient := mock_libcni.NewMockCNI(ctrl)
 	ecscniClient.(*cniClient).libcni = libcniClient
 
@@ -155,7 +155,7 @@ func TestCleanupNS(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ecscniClient := NewClient("")
+	ecscniClient := NewClient("", 0)
 	libcniClient := mock_libcni.NewMockCNI(ctrl)
 	ecscniClient.(*cniClient).libcni = libcniClient
 
@@ -172,7 +172,7 @@ func TestCleanupNSTimeout(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ecscniClient := NewClient("")
+	ecscniClient := NewClient("", 0)
 	libcniClient := mock_libcni.NewMockCNI(ctrl)
 	ecscniClient.(*cniClient).libcni = libcniClient
 
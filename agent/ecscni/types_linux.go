@@ -123,6 +123,8 @@ type ENIConfig struct {
 	GatewayIPAddresses []string `json:"gateway-ip-addresses"`
 	// BlockInstanceMetadata specifies if InstanceMetadata endpoint should be blocked
 	BlockInstanceMetadata bool `json:"block-instance-metadata"`
+	// MTU is the MTU of the eni. It's omitted when the eni uses the standard MTU
+	MTU int `json:"mtu,omitempty"`
 }
 
 // AppMeshConfig contains all the information needed to invoke the app mesh plugin
@@ -170,6 +172,8 @@ type BranchENIConfig struct {
 	BlockInstanceMetadata bool `json:"blockInstanceMetadata"`
 	// InterfaceType is the type of the interface to connect the branch ENI to
 	InterfaceType string `json:"interfaceType,omitempty"`
+	// MTU is the MTU of the branch ENI. It's omitted when the branch ENI uses the standard MTU
+	MTU int `json:"mtu,omitempty"`
 }
 
 type ServiceConnectConfig struct {
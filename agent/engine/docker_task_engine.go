@@ -155,6 +155,10 @@ type DockerTaskEngine struct {
 	// The write mutex should be taken when adding and removing tasks from managedTasks.
 	tasksLock sync.RWMutex
 
+	// reconcileLock serializes the reconciliations of the stored task state
+	// against the state of the containers in Docker
+	reconcileLock sync.Mutex
+
 	credentialsManager                  credentials.Manager
 	_time                               ttime.Time
 	_timeOnce                           sync.Once
@@ -214,7 +218,7 @@ func NewDockerTaskEngine(cfg *config.Config,
 
 		containerChangeEventStream: containerChangeEventStream,
 		imageManager:               imageManager,
-		cniClient:                  ecscni.NewClient(cfg.CNIPluginsPath),
+		cniClient:                  ecscni.NewClient(cfg.CNIPluginsPath, cfg.CNIMaxConcurrentOperations),
 		appnetClient:               appnet.Client(),
 
 		metadataManager:                   metadataManager,
@@ -555,6 +559,11 @@ func updateContainerMetadata(metadata *dockerapi.DockerContainerMetadata, contai
 	}
 	container.SetNetworkMode(metadata.NetworkMode)
 	container.SetNetworkSettings(metadata.NetworkSettings)
+
+	// Only inspecting a running container reports its PID
+	if metadata.PID != 0 {
+		container.SetHostPID(metadata.PID)
+	}
 }
 
 // synchronizeContainerStatus checks and updates the container status with docker
@@ -1208,7 +1217,7 @@ func (engine *DockerTaskEngine) pullAndUpdateContainerReference(task *apitask.Ta
 		defer container.SetASMDockerAuthConfig(types.AuthConfig{})
 	}
 
-	metadata := engine.client.PullImage(engine.ctx, container.Image, container.RegistryAuthentication, engine.cfg.ImagePullTimeout)
+	metadata := engine.pullImageWithTimeout(container)
 
 	// Don't add internal images(created by ecs-agent) into imagemanger state
 	if container.IsInternal() {
@@ -1257,6 +1266,32 @@ func (engine *DockerTaskEngine) pullAndUpdateContainerReference(task *apitask.Ta
 	return metadata
 }
 
+// pullImageWithTimeout pulls the image for the container, aborting the pull if it doesn't complete
+// within the container's image pull timeout, or the configured image pull timeout if the container
+// doesn't specify one. An ImagePullTimeoutError is returned when the pull is aborted.
+func (engine *DockerTaskEngine) pullImageWithTimeout(container *apicontainer.Container) dockerapi.DockerContainerMetadata {
+	pullTimeout := container.GetImagePullTimeout()
+	if pullTimeout <= 0 {
+		pullTimeout = engine.cfg.ImagePullTimeout
+	}
+	ctx := engine.ctx
+	if pullTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(engine.ctx, pullTimeout)
+		defer cancel()
+	}
+
+	metadata := engine.client.PullImage(ctx, container.Image, container.RegistryAuthentication, pullTimeout)
+	if metadata.Error != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		metadata.Error = ImagePullTimeoutError{
+			container: container.Name,
+			image:     container.Image,
+			timeout:   pullTimeout,
+		}
+	}
+	return metadata
+}
+
 func (engine *DockerTaskEngine) updateContainerReference(pullSucceeded bool, container *apicontainer.Container, taskId string) {
 	err := engine.imageManager.RecordContainerReference(container)
 	if err != nil {
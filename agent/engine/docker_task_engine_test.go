@@ -1635,6 +1635,78 @@ func TestPullAndUpdateContainerReference(t *testing.T) {
 	}
 }
 
+// TestPullAndUpdateContainerReferenceTimeout tests that an image pull that doesn't complete within
+// the container's image pull timeout, or the configured one if the container doesn't specify one,
+// is aborted and reported as an ImagePullTimeoutError.
+func TestPullAndUpdateContainerReferenceTimeout(t *testing.T) {
+	testcases := []struct {
+		name                    string
+		configImagePullTimeout  time.Duration
+		containerPullTimeoutSec uint
+		expectedTimeout         time.Duration
+	}{
+		{
+			name:                   "container without image pull timeout",
+			configImagePullTimeout: 10 * time.Millisecond,
+			expectedTimeout:        10 * time.Millisecond,
+		},
+		{
+			name:                    "container with image pull timeout",
+			configImagePullTimeout:  time.Hour,
+			containerPullTimeoutSec: 1,
+			expectedTimeout:         time.Second,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			defer cancel()
+			cfg := &config.Config{
+				ImagePullBehavior: config.ImagePullDefaultBehavior,
+				ImagePullTimeout:  tc.configImagePullTimeout,
+			}
+			ctrl, client, _, privateTaskEngine, _, imageManager, _, _ := mocks(t, ctx, cfg)
+			defer ctrl.Finish()
+
+			taskEngine, _ := privateTaskEngine.(*DockerTaskEngine)
+			taskEngine._time = nil
+			imageName := "image"
+			container := &apicontainer.Container{
+				Name:             "c1",
+				Type:             apicontainer.ContainerNormal,
+				Image:            imageName,
+				Essential:        true,
+				ImagePullTimeout: tc.containerPullTimeoutSec,
+			}
+			task := &apitask.Task{
+				Arn:        "taskArn",
+				Containers: []*apicontainer.Container{container},
+			}
+
+			// Stub a pull that hangs until it is aborted
+			client.EXPECT().PullImage(gomock.Any(), imageName, nil, tc.expectedTimeout).DoAndReturn(
+				func(ctx context.Context, image string, auth *apicontainer.RegistryAuthenticationData,
+					timeout time.Duration) dockerapi.DockerContainerMetadata {
+					<-ctx.Done()
+					return dockerapi.DockerContainerMetadata{Error: &dockerapi.DockerTimeoutError{}}
+				})
+			imageManager.EXPECT().RecordContainerReference(container)
+			imageManager.EXPECT().GetImageStateFromImageName(imageName).Return(nil, false)
+
+			start := time.Now()
+			metadata := taskEngine.pullAndUpdateContainerReference(task, container)
+			assert.GreaterOrEqual(t, time.Since(start), tc.expectedTimeout)
+			assert.Equal(t, ImagePullTimeoutError{
+				container: "c1",
+				image:     imageName,
+				timeout:   tc.expectedTimeout,
+			}, metadata.Error)
+			assert.Contains(t, metadata.Error.Error(), "did not complete within "+tc.expectedTimeout.String())
+		})
+	}
+}
+
 // TestMetadataFileUpdatedAgentRestart checks whether metadataManager.Update(...) is
 // invoked in the path DockerTaskEngine.Init() -> .synchronizeState() -> .updateMetadataFile(...)
 // for the following case:
@@ -14,6 +14,9 @@
 package engine
 
 import (
+	"fmt"
+	"time"
+
 	apicontainerstatus "github.com/aws/amazon-ecs-agent/agent/api/container/status"
 	apierrors "github.com/aws/amazon-ecs-agent/ecs-agent/api/errors"
 )
@@ -71,6 +74,24 @@ func (TaskStoppedBeforePullBeginError) ErrorName() string {
 	return "TaskStoppedBeforePullBeginError"
 }
 
+// ImagePullTimeoutError is a type for container image pulls that didn't complete
+// within the container's image pull timeout
+type ImagePullTimeoutError struct {
+	container string
+	image     string
+	timeout   time.Duration
+}
+
+func (err ImagePullTimeoutError) Error() string {
+	return fmt.Sprintf("image pull for container %s (%s) did not complete within %s and was aborted",
+		err.container, err.image, err.timeout)
+}
+
+// ErrorName returns the name of the error
+func (ImagePullTimeoutError) ErrorName() string {
+	return "ImagePullTimeoutError"
+}
+
 // ContainerNetworkingError indicates any error when dealing with the network
 // namespace of container
 type ContainerNetworkingError struct {
@@ -68,6 +68,8 @@ var (
 type dockerContainerChange struct {
 	container *apicontainer.Container
 	event     dockerapi.DockerContainerChangeEvent
+	// handled, if set, is closed once the change was handled or discarded by the managed task
+	handled chan struct{}
 }
 
 // resourceStateChange represents the required status change after resource transition
@@ -380,6 +382,9 @@ func (mtask *managedTask) waitEvent(stopWaiting <-chan struct{}) bool {
 		return false
 	case dockerChange := <-mtask.dockerMessages:
 		mtask.handleContainerChange(dockerChange)
+		if dockerChange.handled != nil {
+			close(dockerChange.handled)
+		}
 		return false
 	case resChange := <-mtask.resourceStateChangeEvent:
 		res := resChange.resource
@@ -851,6 +856,19 @@ func (mtask *managedTask) handleEventError(containerChange dockerContainerChange
 	// event.Status is the desired container transition from container's known status
 	// (* -> event.Status)
 	case apicontainerstatus.ContainerPulled:
+		// An image pull that timed out is aborted, and fails the task regardless of the
+		// agent pull behavior, instead of leaving it to hang on a slow registry.
+		if timeoutErr, ok := event.Error.(ImagePullTimeoutError); ok {
+			logger.Error("Image pull timed out; moving task to STOPPED", logger.Fields{
+				field.TaskID:    mtask.GetID(),
+				field.Image:     container.Image,
+				field.Container: container.Name,
+				field.Error:     timeoutErr,
+			})
+			mtask.Task.SetTerminalReason(timeoutErr.Error())
+			mtask.SetDesiredStatus(apitaskstatus.TaskStopped)
+			return false
+		}
 		// If the agent pull behavior is always or once, we receive the error because
 		// the image pull fails, the task should fail. If we don't fail task here,
 		// then the cached image will probably be used for creating container, and we
@@ -1529,7 +1547,10 @@ func (mtask *managedTask) cleanupTask(taskStoppedDuration time.Duration) {
 func (mtask *managedTask) discardEvents() {
 	for {
 		select {
-		case <-mtask.dockerMessages:
+		case dockerChange := <-mtask.dockerMessages:
+			if dockerChange.handled != nil {
+				close(dockerChange.handled)
+			}
 		case <-mtask.acsMessages:
 		case <-mtask.resourceStateChangeEvent:
 		case <-mtask.ctx.Done():
@@ -69,6 +69,7 @@ func TestHandleEventError(t *testing.T) {
 		ExpectedContainerKnownStatus          apicontainerstatus.ContainerStatus
 		ExpectedContainerDesiredStatusStopped bool
 		ExpectedTaskDesiredStatusStopped      bool
+		ExpectedTaskTerminalReason            string
 		ExpectedOK                            bool
 	}{
 		{
@@ -187,6 +188,20 @@ func TestHandleEventError(t *testing.T) {
 			ExpectedTaskDesiredStatusStopped: true,
 			ExpectedOK:                       false,
 		},
+		{
+			Name:        "Pull image times out and task fails",
+			EventStatus: apicontainerstatus.ContainerPulled,
+			Error: ImagePullTimeoutError{
+				container: "c1",
+				image:     "image",
+				timeout:   time.Minute,
+			},
+			ImagePullBehavior:                config.ImagePullDefaultBehavior,
+			ExpectedContainerKnownStatusSet:  false,
+			ExpectedTaskDesiredStatusStopped: true,
+			ExpectedTaskTerminalReason:       "Image pull for container c1 (image) did not complete within 1m0s and was aborted",
+			ExpectedOK:                       false,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -232,6 +247,10 @@ func TestHandleEventError(t *testing.T) {
 				assert.Equal(t, apicontainerstatus.ContainerStopped, containerDesiredStatus,
 					"desired status %s != %s", apicontainerstatus.ContainerStopped.String(), containerDesiredStatus.String())
 			}
+			if tc.ExpectedTaskDesiredStatusStopped {
+				assert.Equal(t, apitaskstatus.TaskStopped, mtask.GetDesiredStatus())
+			}
+			assert.Equal(t, tc.ExpectedTaskTerminalReason, mtask.GetTerminalReason())
 			assert.Equal(t, tc.Error.ErrorName(), containerChange.container.ApplyingError.ErrorName())
 		})
 	}
@@ -0,0 +1,236 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+	apicontainerstatus "github.com/aws/amazon-ecs-agent/agent/api/container/status"
+	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
+	"github.com/aws/amazon-ecs-agent/agent/dockerclient"
+	"github.com/aws/amazon-ecs-agent/agent/dockerclient/dockerapi"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger/field"
+
+	"github.com/docker/docker/errdefs"
+)
+
+// TaskNotFoundError is the error returned when reconciling the state of a task
+// that the task engine doesn't know about
+type TaskNotFoundError struct {
+	taskArn string
+}
+
+func (err TaskNotFoundError) Error() string {
+	return "Task not found: " + err.taskArn
+}
+
+// ContainerStateDiff is a difference between the state of a container stored by
+// the task engine and its actual state in Docker
+type ContainerStateDiff struct {
+	ContainerName string
+	Field         string
+	Stored        string
+	Actual        string
+	// Corrected is set when the stored state was updated to the actual state.
+	// The known status of a container is never moved backwards, and is only
+	// moved forward by the managed task of the container, so other differences
+	// of the known status are only reported
+	Corrected bool
+}
+
+// TaskStateReconciliation is the summary of the reconciliation of the stored
+// state of a task against the state of its containers in Docker
+type TaskStateReconciliation struct {
+	TaskARN string
+	Diffs   []ContainerStateDiff
+}
+
+// ReconcileTaskState inspects the containers of the task in Docker and updates
+// their state stored by the task engine where it drifted from the actual state
+// of the containers. Status changes are handled by the managed task like Docker
+// events, other differences are updated with the containers' synchronized
+// setters, so that this is safe while the task is being managed.
+// Reconciliations are serialized, and each one waits for its status changes to
+// be handled. The stored state is left untouched if any container can't be
+// inspected.
+func (engine *DockerTaskEngine) ReconcileTaskState(taskARN string) (*TaskStateReconciliation, error) {
+	engine.reconcileLock.Lock()
+	defer engine.reconcileLock.Unlock()
+
+	task, ok := engine.state.TaskByArn(taskARN)
+	if !ok {
+		return nil, TaskNotFoundError{taskArn: taskARN}
+	}
+	containerMap, _ := engine.state.ContainerMapByArn(taskARN)
+
+	// Inspect every container before updating any, so that the task isn't left
+	// partially reconciled
+	type inspectedContainer struct {
+		container *apicontainer.Container
+		status    apicontainerstatus.ContainerStatus
+		metadata  dockerapi.DockerContainerMetadata
+	}
+	var inspected []inspectedContainer
+	for _, container := range task.Containers {
+		dockerContainer, ok := containerMap[container.Name]
+		if !ok || dockerContainer.DockerID == "" {
+			// The container hasn't been created yet
+			continue
+		}
+		status, metadata, err := engine.describeContainerForReconciliation(dockerContainer.DockerID)
+		if err != nil {
+			return nil, fmt.Errorf("unable to inspect container %s of task %s: %w", container.Name, taskARN, err)
+		}
+		inspected = append(inspected, inspectedContainer{container: container, status: status, metadata: metadata})
+	}
+
+	engine.tasksLock.RLock()
+	managedTask := engine.managedTasks[taskARN]
+	engine.tasksLock.RUnlock()
+
+	reconciliation := &TaskStateReconciliation{TaskARN: taskARN}
+	for _, c := range inspected {
+		diffs := engine.reconcileContainerState(managedTask, task, c.container, c.status, c.metadata)
+		reconciliation.Diffs = append(reconciliation.Diffs, diffs...)
+	}
+	if len(reconciliation.Diffs) > 0 {
+		logger.Info("Reconciled task state against Docker", logger.Fields{
+			field.TaskARN: taskARN,
+			"diffs":       len(reconciliation.Diffs),
+		})
+	}
+	return reconciliation, nil
+}
+
+// describeContainerForReconciliation returns the status and the metadata of the
+// container in Docker. A container that no longer exists is reported as stopped.
+func (engine *DockerTaskEngine) describeContainerForReconciliation(dockerID string) (
+	apicontainerstatus.ContainerStatus, dockerapi.DockerContainerMetadata, error) {
+	describedContainer, err := engine.client.InspectContainer(engine.ctx, dockerID, dockerclient.InspectContainerTimeout)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return apicontainerstatus.ContainerStopped, dockerapi.DockerContainerMetadata{DockerID: dockerID}, nil
+		}
+		return apicontainerstatus.ContainerStatusNone, dockerapi.DockerContainerMetadata{}, err
+	}
+	return dockerapi.DockerStateToState(describedContainer.State), dockerapi.MetadataFromContainer(describedContainer), nil
+}
+
+// reconcileContainerState updates the stored state of the container where it
+// differs from the actual status and metadata of the container in Docker, and
+// returns the differences. A status that moved forward is sent to the managed
+// task as a Docker event would be, so that the change is emitted and saved, and
+// the metadata is updated along with it. Otherwise, the metadata is updated in
+// place and the container is saved. The status of a task that isn't managed is
+// not corrected.
+func (engine *DockerTaskEngine) reconcileContainerState(managedTask *managedTask, task *apitask.Task,
+	container *apicontainer.Container, actualStatus apicontainerstatus.ContainerStatus,
+	metadata dockerapi.DockerContainerMetadata) []ContainerStateDiff {
+	var diffs []ContainerStateDiff
+	addDiff := func(fieldName, stored, actual string, corrected bool) {
+		diffs = append(diffs, ContainerStateDiff{
+			ContainerName: container.Name,
+			Field:         fieldName,
+			Stored:        stored,
+			Actual:        actual,
+			Corrected:     corrected,
+		})
+	}
+
+	statusCorrected := false
+	if storedStatus := container.GetKnownStatus(); actualStatus != storedStatus {
+		statusCorrected = actualStatus > storedStatus && managedTask != nil
+		addDiff("KnownStatus", storedStatus.String(), actualStatus.String(), statusCorrected)
+	}
+	// The metadata is updated by the managed task when the status is corrected
+	metadataCorrected := false
+	correct := func(set func()) {
+		if !statusCorrected {
+			set()
+			metadataCorrected = true
+		}
+	}
+
+	if metadata.ExitCode != nil {
+		storedExitCode := container.GetKnownExitCode()
+		if storedExitCode == nil || *storedExitCode != *metadata.ExitCode {
+			stored := ""
+			if storedExitCode != nil {
+				stored = strconv.Itoa(*storedExitCode)
+			}
+			correct(func() { container.SetKnownExitCode(metadata.ExitCode) })
+			addDiff("ExitCode", stored, strconv.Itoa(*metadata.ExitCode), true)
+		}
+	}
+
+	if container.HealthStatusShouldBeReported() && metadata.Health.Status != apicontainerstatus.ContainerHealthUnknown {
+		if storedHealth := container.GetHealthStatus(); storedHealth.Status != metadata.Health.Status {
+			correct(func() { container.SetHealthStatus(metadata.Health) })
+			addDiff("HealthStatus", storedHealth.Status.String(), metadata.Health.Status.String(), true)
+		}
+	}
+
+	if storedStartedAt := container.GetStartedAt(); !metadata.StartedAt.IsZero() && !storedStartedAt.Equal(metadata.StartedAt) {
+		correct(func() { container.SetStartedAt(metadata.StartedAt) })
+		addDiff("StartedAt", formatReconciledTime(storedStartedAt), formatReconciledTime(metadata.StartedAt), true)
+	}
+	if storedFinishedAt := container.GetFinishedAt(); !metadata.FinishedAt.IsZero() && !storedFinishedAt.Equal(metadata.FinishedAt) {
+		correct(func() { container.SetFinishedAt(metadata.FinishedAt) })
+		addDiff("FinishedAt", formatReconciledTime(storedFinishedAt), formatReconciledTime(metadata.FinishedAt), true)
+	}
+
+	for _, diff := range diffs {
+		logger.Info("Container state drifted from Docker", logger.Fields{
+			field.TaskID:    task.GetID(),
+			field.Container: container.Name,
+			"field":         diff.Field,
+			"stored":        diff.Stored,
+			"actual":        diff.Actual,
+			"corrected":     diff.Corrected,
+		})
+	}
+
+	if statusCorrected {
+		handled := make(chan struct{})
+		managedTask.emitDockerContainerChange(dockerContainerChange{
+			container: container,
+			event: dockerapi.DockerContainerChangeEvent{
+				Status:                  actualStatus,
+				DockerContainerMetadata: metadata,
+				Type:                    apicontainer.ContainerStatusEvent,
+			},
+			handled: handled,
+		})
+		select {
+		case <-handled:
+		case <-managedTask.ctx.Done():
+		}
+	} else if metadataCorrected {
+		engine.saveContainerData(container)
+	}
+	return diffs
+}
+
+// formatReconciledTime formats a timestamp of a container state diff. An unset
+// timestamp is formatted as an empty string
+func formatReconciledTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,330 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+	apicontainerstatus "github.com/aws/amazon-ecs-agent/agent/api/container/status"
+	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
+	apitaskstatus "github.com/aws/amazon-ecs-agent/agent/api/task/status"
+	"github.com/aws/amazon-ecs-agent/agent/statechange"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const reconciliationTaskARN = "arn:aws:ecs:us-west-2:1234567890:task/test-cluster/reconcile"
+
+// reconciliationTestTask adds a task with the given containers, which are all
+// known to be running, to the state of the engine
+func reconciliationTestTask(engine *DockerTaskEngine, names ...string) *apitask.Task {
+	task := &apitask.Task{
+		Arn:                 reconciliationTaskARN,
+		KnownStatusUnsafe:   apitaskstatus.TaskRunning,
+		DesiredStatusUnsafe: apitaskstatus.TaskRunning,
+	}
+	for _, name := range names {
+		task.Containers = append(task.Containers, &apicontainer.Container{
+			Name:                name,
+			TaskARNUnsafe:       reconciliationTaskARN,
+			KnownStatusUnsafe:   apicontainerstatus.ContainerRunning,
+			DesiredStatusUnsafe: apicontainerstatus.ContainerRunning,
+		})
+	}
+	engine.state.AddTask(task)
+	for _, container := range task.Containers {
+		engine.state.AddContainer(&apicontainer.DockerContainer{
+			DockerID:   name2DockerID(container.Name),
+			DockerName: container.Name,
+			Container:  container,
+		}, task)
+	}
+	return task
+}
+
+// manageReconciledTask makes the task managed, handling the docker messages it
+// receives as the managed task does, and returns the state change events it
+// emits
+func manageReconciledTask(ctx context.Context, engine *DockerTaskEngine, task *apitask.Task) <-chan statechange.Event {
+	mtask := engine.newManagedTask(task)
+	events := make(chan statechange.Event, 10)
+	go func() {
+		for {
+			select {
+			case event := <-engine.stateChangeEvents:
+				events <- event
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		for {
+			select {
+			case change := <-mtask.dockerMessages:
+				mtask.handleContainerChange(change)
+				close(change.handled)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events
+}
+
+func name2DockerID(name string) string {
+	return "docker-" + name
+}
+
+func TestReconcileTaskState(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, dockerClient, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+	engine := taskEngine.(*DockerTaskEngine)
+	dataClient := newTestDataClient(t)
+	engine.dataClient = dataClient
+
+	task := reconciliationTestTask(engine, "exited", "vanished", "running")
+	events := manageReconciledTask(ctx, engine, task)
+	finishedAt := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	startedAt := finishedAt.Add(-time.Hour)
+
+	// The stored state of the first two containers drifted: one exited and the
+	// other was removed, but the task engine missed both events
+	dockerClient.EXPECT().InspectContainer(gomock.Any(), name2DockerID("exited"), gomock.Any()).Return(&types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID: name2DockerID("exited"),
+			State: &types.ContainerState{
+				Status:     "exited",
+				ExitCode:   137,
+				StartedAt:  startedAt.Format(time.RFC3339),
+				FinishedAt: finishedAt.Format(time.RFC3339),
+			},
+		},
+	}, nil)
+	dockerClient.EXPECT().InspectContainer(gomock.Any(), name2DockerID("vanished"), gomock.Any()).
+		Return(nil, errdefs.NotFound(errors.New("no such container")))
+	dockerClient.EXPECT().InspectContainer(gomock.Any(), name2DockerID("running"), gomock.Any()).Return(&types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:    name2DockerID("running"),
+			State: &types.ContainerState{Status: "running", Running: true},
+		},
+	}, nil)
+
+	reconciliation, err := engine.ReconcileTaskState(reconciliationTaskARN)
+	require.NoError(t, err)
+	assert.Equal(t, reconciliationTaskARN, reconciliation.TaskARN)
+	assert.Equal(t, []ContainerStateDiff{
+		{ContainerName: "exited", Field: "KnownStatus", Stored: "RUNNING", Actual: "STOPPED", Corrected: true},
+		{ContainerName: "exited", Field: "ExitCode", Stored: "", Actual: "137", Corrected: true},
+		{ContainerName: "exited", Field: "StartedAt", Stored: "", Actual: "2023-01-02T02:04:05Z", Corrected: true},
+		{ContainerName: "exited", Field: "FinishedAt", Stored: "", Actual: "2023-01-02T03:04:05Z", Corrected: true},
+		{ContainerName: "vanished", Field: "KnownStatus", Stored: "RUNNING", Actual: "STOPPED", Corrected: true},
+	}, reconciliation.Diffs)
+
+	// The stored state was corrected
+	exited := task.Containers[0]
+	assert.Equal(t, apicontainerstatus.ContainerStopped, exited.GetKnownStatus())
+	require.NotNil(t, exited.GetKnownExitCode())
+	assert.Equal(t, 137, *exited.GetKnownExitCode())
+	assert.Equal(t, finishedAt, exited.GetFinishedAt().UTC())
+	assert.Equal(t, apicontainerstatus.ContainerStopped, task.Containers[1].GetKnownStatus())
+	assert.Equal(t, apicontainerstatus.ContainerRunning, task.Containers[2].GetKnownStatus())
+
+	// The status changes were emitted and saved by the managed task
+	for _, name := range []string{"exited", "vanished"} {
+		event := <-events
+		require.Equal(t, statechange.ContainerEvent, event.GetEventType())
+		containerChange := event.(api.ContainerStateChange)
+		assert.Equal(t, name, containerChange.ContainerName)
+		assert.Equal(t, apicontainerstatus.ContainerStopped, containerChange.Status)
+	}
+	savedContainers, err := dataClient.GetContainers()
+	require.NoError(t, err)
+	savedStatuses := make(map[string]apicontainerstatus.ContainerStatus)
+	for _, saved := range savedContainers {
+		savedStatuses[saved.Container.Name] = saved.Container.GetKnownStatus()
+	}
+	assert.Equal(t, map[string]apicontainerstatus.ContainerStatus{
+		"exited":   apicontainerstatus.ContainerStopped,
+		"vanished": apicontainerstatus.ContainerStopped,
+	}, savedStatuses)
+}
+
+func TestReconcileTaskStateUnmanagedTask(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, dockerClient, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+	engine := taskEngine.(*DockerTaskEngine)
+	dataClient := newTestDataClient(t)
+	engine.dataClient = dataClient
+
+	task := reconciliationTestTask(engine, "container")
+	finishedAt := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	dockerClient.EXPECT().InspectContainer(gomock.Any(), name2DockerID("container"), gomock.Any()).Return(&types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID: name2DockerID("container"),
+			State: &types.ContainerState{
+				Status:     "exited",
+				ExitCode:   1,
+				StartedAt:  finishedAt.Add(-time.Hour).Format(time.RFC3339),
+				FinishedAt: finishedAt.Format(time.RFC3339),
+			},
+		},
+	}, nil)
+
+	reconciliation, err := engine.ReconcileTaskState(reconciliationTaskARN)
+	require.NoError(t, err)
+	// Only the managed task of the container moves its status forward
+	assert.Equal(t, []ContainerStateDiff{
+		{ContainerName: "container", Field: "KnownStatus", Stored: "RUNNING", Actual: "STOPPED", Corrected: false},
+		{ContainerName: "container", Field: "ExitCode", Stored: "", Actual: "1", Corrected: true},
+		{ContainerName: "container", Field: "StartedAt", Stored: "", Actual: "2023-01-02T02:04:05Z", Corrected: true},
+		{ContainerName: "container", Field: "FinishedAt", Stored: "", Actual: "2023-01-02T03:04:05Z", Corrected: true},
+	}, reconciliation.Diffs)
+	assert.Equal(t, apicontainerstatus.ContainerRunning, task.Containers[0].GetKnownStatus())
+
+	savedContainers, err := dataClient.GetContainers()
+	require.NoError(t, err)
+	require.Len(t, savedContainers, 1)
+	require.NotNil(t, savedContainers[0].Container.GetKnownExitCode())
+	assert.Equal(t, 1, *savedContainers[0].Container.GetKnownExitCode())
+}
+
+func TestReconcileTaskStateDoesNotMoveStatusBackwards(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, dockerClient, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+	engine := taskEngine.(*DockerTaskEngine)
+
+	task := reconciliationTestTask(engine, "container")
+	task.Containers[0].SetKnownStatus(apicontainerstatus.ContainerStopped)
+	dockerClient.EXPECT().InspectContainer(gomock.Any(), name2DockerID("container"), gomock.Any()).Return(&types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:    name2DockerID("container"),
+			State: &types.ContainerState{Status: "running", Running: true},
+		},
+	}, nil)
+
+	reconciliation, err := engine.ReconcileTaskState(reconciliationTaskARN)
+	require.NoError(t, err)
+	assert.Equal(t, []ContainerStateDiff{
+		{ContainerName: "container", Field: "KnownStatus", Stored: "STOPPED", Actual: "RUNNING", Corrected: false},
+	}, reconciliation.Diffs)
+	assert.Equal(t, apicontainerstatus.ContainerStopped, task.Containers[0].GetKnownStatus())
+}
+
+func TestReconcileTaskStateInspectError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, dockerClient, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+	engine := taskEngine.(*DockerTaskEngine)
+
+	task := reconciliationTestTask(engine, "exited", "unknown")
+	dockerClient.EXPECT().InspectContainer(gomock.Any(), name2DockerID("exited"), gomock.Any()).Return(&types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:    name2DockerID("exited"),
+			State: &types.ContainerState{Status: "exited"},
+		},
+	}, nil)
+	dockerClient.EXPECT().InspectContainer(gomock.Any(), name2DockerID("unknown"), gomock.Any()).
+		Return(nil, errors.New("docker is unavailable"))
+
+	_, err := engine.ReconcileTaskState(reconciliationTaskARN)
+	assert.Error(t, err)
+	// No container is updated when any of them can't be inspected
+	assert.Equal(t, apicontainerstatus.ContainerRunning, task.Containers[0].GetKnownStatus())
+}
+
+func TestReconcileTaskStateTaskNotFound(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, _, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+
+	_, err := taskEngine.(*DockerTaskEngine).ReconcileTaskState(reconciliationTaskARN)
+	assert.ErrorAs(t, err, &TaskNotFoundError{})
+}
+
+func TestReconcileTaskStateConcurrent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ctrl, dockerClient, _, taskEngine, _, _, _, _ := mocks(t, ctx, &defaultConfig)
+	defer ctrl.Finish()
+	engine := taskEngine.(*DockerTaskEngine)
+
+	task := reconciliationTestTask(engine, "container")
+	events := manageReconciledTask(ctx, engine, task)
+	finishedAt := time.Now()
+	dockerClient.EXPECT().InspectContainer(gomock.Any(), name2DockerID("container"), gomock.Any()).Return(&types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID: name2DockerID("container"),
+			State: &types.ContainerState{
+				Status:     "exited",
+				ExitCode:   1,
+				StartedAt:  finishedAt.Add(-time.Minute).Format(time.RFC3339),
+				FinishedAt: finishedAt.Format(time.RFC3339),
+			},
+		},
+	}, nil).AnyTimes()
+
+	const reconciliations = 10
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var diffs int
+	for i := 0; i < reconciliations; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reconciliation, err := engine.ReconcileTaskState(reconciliationTaskARN)
+			assert.NoError(t, err)
+			lock.Lock()
+			defer lock.Unlock()
+			diffs += len(reconciliation.Diffs)
+		}()
+		// Read the state while it's reconciled
+		task.Containers[0].GetKnownStatus()
+	}
+	wg.Wait()
+
+	// Reconciliations are serialized, so only the first one finds differences:
+	// the known status, exit code, and start and finish times
+	assert.Equal(t, 4, diffs)
+	assert.Equal(t, apicontainerstatus.ContainerStopped, task.Containers[0].GetKnownStatus())
+	// The status change was emitted once
+	require.NotEmpty(t, events)
+	event := <-events
+	require.Equal(t, statechange.ContainerEvent, event.GetEventType())
+	assert.Equal(t, apicontainerstatus.ContainerStopped, event.(api.ContainerStateChange).Status)
+	for len(events) > 0 {
+		assert.NotEqual(t, statechange.ContainerEvent, (<-events).GetEventType())
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package eventhandler
+
+import (
+	"sync"
+	"time"
+)
+
+// maxDeadLetters is the maximum number of dead letters kept. The oldest dead
+// letter is discarded to make room for a new one
+const maxDeadLetters = 100
+
+// DeadLetter is a state change that was given up on after failing to be
+// submitted to ECS for the maximum number of attempts
+type DeadLetter struct {
+	TaskARN string
+	// ContainerName is only set for container state changes
+	ContainerName  string `json:",omitempty"`
+	Status         string
+	Reason         string `json:",omitempty"`
+	Attempts       int
+	LastError      string
+	DeadLetteredAt time.Time
+}
+
+// deadLetters is an in-memory list of the most recent dead letters
+type deadLetters struct {
+	letters []DeadLetter
+	lock    sync.Mutex
+}
+
+// add adds the dead letter of the event, which failed to be submitted with
+// lastErr, to the list
+func (dl *deadLetters) add(event *sendableEvent, lastErr error) {
+	letter := DeadLetter{
+		Attempts:       event.submitAttempts,
+		LastError:      lastErr.Error(),
+		DeadLetteredAt: time.Now(),
+	}
+	event.lock.RLock()
+	if event.isContainerEvent {
+		letter.TaskARN = event.containerChange.TaskArn
+		letter.ContainerName = event.containerChange.ContainerName
+		letter.Status = event.containerChange.Status.String()
+		letter.Reason = event.containerChange.Reason
+	} else {
+		letter.TaskARN = event.taskChange.TaskARN
+		letter.Status = event.taskChange.Status.String()
+		letter.Reason = event.taskChange.Reason
+	}
+	event.lock.RUnlock()
+
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	if len(dl.letters) >= maxDeadLetters {
+		dl.letters = dl.letters[1:]
+	}
+	dl.letters = append(dl.letters, letter)
+}
+
+// list returns a copy of the dead letters, oldest first
+func (dl *deadLetters) list() []DeadLetter {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	return append([]DeadLetter{}, dl.letters...)
+}
@@ -0,0 +1,88 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package eventhandler
+
+import (
+	"container/list"
+	"sync"
+)
+
+// prioritySemaphore is a counting semaphore whose waiters may be prioritized. When a
+// resource is released while there are waiters, it goes to the first waiter, in the
+// order they started waiting, that is prioritized at that time. It goes to the first
+// waiter if none is.
+type prioritySemaphore struct {
+	// available is the number of resources that are not held
+	available int
+	// waiters is the list of *semaphoreWaiters waiting for a resource, in the order
+	// they started waiting
+	waiters *list.List
+	lock    sync.Mutex
+}
+
+type semaphoreWaiter struct {
+	// prioritized returns true if the waiter should get a resource ahead of the
+	// waiters that are not prioritized
+	prioritized func() bool
+	// granted is closed once the waiter holds a resource
+	granted chan struct{}
+}
+
+// newPrioritySemaphore returns a prioritySemaphore with count resources
+func newPrioritySemaphore(count int) *prioritySemaphore {
+	return &prioritySemaphore{
+		available: count,
+		waiters:   list.New(),
+	}
+}
+
+// wait waits for a resource. prioritized is called, with the semaphore locked, each
+// time a resource is released while waiting for one. It must not wait for a resource
+// of the semaphore
+func (s *prioritySemaphore) wait(prioritized func() bool) {
+	s.lock.Lock()
+	if s.available > 0 && s.waiters.Len() == 0 {
+		s.available--
+		s.lock.Unlock()
+		return
+	}
+	waiter := &semaphoreWaiter{
+		prioritized: prioritized,
+		granted:     make(chan struct{}),
+	}
+	s.waiters.PushBack(waiter)
+	s.lock.Unlock()
+
+	<-waiter.granted
+}
+
+// post releases a resource, handing it over to a waiter if there are any
+func (s *prioritySemaphore) post() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	next := s.waiters.Front()
+	if next == nil {
+		s.available++
+		return
+	}
+	for elem := next; elem != nil; elem = elem.Next() {
+		if elem.Value.(*semaphoreWaiter).prioritized() {
+			next = elem
+			break
+		}
+	}
+	s.waiters.Remove(next)
+	close(next.Value.(*semaphoreWaiter).granted)
+}
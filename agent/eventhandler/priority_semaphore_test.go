@@ -0,0 +1,70 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package eventhandler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// numWaiters returns the number of waiters waiting for a resource of the semaphore
+func numWaiters(s *prioritySemaphore) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.waiters.Len()
+}
+
+// numAvailable returns the number of resources of the semaphore that are not held
+func numAvailable(s *prioritySemaphore) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.available
+}
+
+func TestPrioritySemaphore(t *testing.T) {
+	s := newPrioritySemaphore(1)
+	s.wait(func() bool { return false })
+
+	granted := make(chan string, 3)
+	waitFor := func(name string, prioritized bool) {
+		waiters := numWaiters(s)
+		go func() {
+			s.wait(func() bool { return prioritized })
+			granted <- name
+		}()
+		require.Eventually(t, func() bool {
+			return numWaiters(s) == waiters+1
+		}, 5*time.Second, time.Millisecond)
+	}
+	waitFor("first", false)
+	waitFor("second", false)
+	waitFor("prioritized", true)
+
+	// The prioritized waiter gets the resource first, then the others in the order they
+	// started waiting
+	for _, expected := range []string{"prioritized", "first", "second"} {
+		s.post()
+		assert.Equal(t, expected, <-granted)
+	}
+	s.post()
+	assert.Equal(t, 1, numAvailable(s))
+}
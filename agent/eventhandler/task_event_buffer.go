@@ -0,0 +1,206 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package eventhandler
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/config"
+	"github.com/aws/amazon-ecs-agent/agent/metrics"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	"github.com/pkg/errors"
+)
+
+// taskEventBuffer bounds the number of task state change events that are
+// queued by the TaskHandler across all tasks. Events stay in the buffer from
+// the time they are queued until they are removed from their task's event list.
+// The zero value is an unbounded buffer.
+type taskEventBuffer struct {
+	// maxSize is the maximum number of events in the buffer. The buffer is
+	// unbounded when it is zero
+	maxSize int
+	// overflowPolicy decides what happens to an event added to a full buffer
+	overflowPolicy string
+	// events is a list of the buffered *sendableEvents, oldest first
+	events *list.List
+	// spaceAvailable is signaled whenever an event leaves the buffer
+	spaceAvailable *sync.Cond
+	// lock guards the fields above, as well as the bufferElement and
+	// droppedFromBuffer fields of the events
+	lock sync.Mutex
+}
+
+// setLimits sets the maximum size and the overflow policy of the buffer
+func (buffer *taskEventBuffer) setLimits(maxSize int, overflowPolicy string) {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+
+	buffer.maxSize = maxSize
+	buffer.overflowPolicy = overflowPolicy
+	buffer.initUnsafe()
+	// Blocked events may fit in a larger buffer
+	buffer.spaceAvailable.Broadcast()
+}
+
+func (buffer *taskEventBuffer) initUnsafe() {
+	if buffer.events == nil {
+		buffer.events = list.New()
+	}
+	if buffer.spaceAvailable == nil {
+		buffer.spaceAvailable = sync.NewCond(&buffer.lock)
+	}
+}
+
+// add adds the event to the buffer. When the buffer is full, the event either
+// waits for room in the buffer, the oldest (non-STOPPED) buffered event is
+// dropped, or the latest buffered event of the same task is coalesced into it,
+// as per the overflow policy. Waiting for room relies on events being removed
+// from their task's event list, so add must not be called with the
+// TaskHandler's lock held. An error is returned if the context is done while
+// waiting for room in the buffer
+func (buffer *taskEventBuffer) add(ctx context.Context, event *sendableEvent) error {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+
+	buffer.initUnsafe()
+	for buffer.isFullUnsafe() {
+		if buffer.overflowPolicy == config.TaskEventBufferOverflowPolicyDropOldest {
+			buffer.dropOldestUnsafe()
+			continue
+		}
+		if buffer.overflowPolicy == config.TaskEventBufferOverflowPolicyDropOldestNonStopped {
+			buffer.dropOldestNonStoppedUnsafe()
+			continue
+		}
+		if buffer.overflowPolicy == config.TaskEventBufferOverflowPolicyCoalesce && buffer.coalesceUnsafe(event) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return errors.Wrap(ctx.Err(), "eventhandler: stopped waiting for room in the task event buffer")
+		}
+		logger.Debug("TaskHandler: Task event buffer is full, waiting for room", event.toFields())
+		buffer.spaceAvailable.Wait()
+	}
+	event.bufferElement = buffer.events.PushBack(event)
+	metrics.MetricsEngineGlobal.RecordTaskEventBufferDepth(buffer.events.Len())
+	return nil
+}
+
+func (buffer *taskEventBuffer) isFullUnsafe() bool {
+	return buffer.maxSize > 0 && buffer.events.Len() >= buffer.maxSize
+}
+
+// dropOldestUnsafe drops the oldest buffered event. The event stays in its
+// task's event list until it is skipped by submitFirstEvent
+func (buffer *taskEventBuffer) dropOldestUnsafe() {
+	event := buffer.events.Remove(buffer.events.Front()).(*sendableEvent)
+	event.bufferElement = nil
+	event.droppedFromBuffer = true
+	logger.Warn("TaskHandler: Task event buffer is full, dropping the oldest event", event.toFields())
+}
+
+// dropOldestNonStoppedUnsafe drops the oldest buffered event that does not
+// report a STOPPED status, so that the final state of tasks and containers is
+// still sent to ECS. The oldest event is dropped if all the buffered events
+// report a STOPPED status
+func (buffer *taskEventBuffer) dropOldestNonStoppedUnsafe() {
+	for element := buffer.events.Front(); element != nil; element = element.Next() {
+		event := element.Value.(*sendableEvent)
+		if event.isStopped() {
+			continue
+		}
+		buffer.events.Remove(element)
+		event.bufferElement = nil
+		event.droppedFromBuffer = true
+		logger.Warn("TaskHandler: Task event buffer is full, dropping the oldest non-STOPPED event", event.toFields())
+		return
+	}
+	buffer.dropOldestUnsafe()
+}
+
+// coalesceUnsafe replaces the latest buffered event of the same task with the
+// event. The container and managed agent changes of the replaced event are
+// carried over, so that only its task status, which the event supersedes, is
+// not sent. It returns false if no event of the task is buffered
+func (buffer *taskEventBuffer) coalesceUnsafe(event *sendableEvent) bool {
+	taskARN := event.taskArn()
+	for element := buffer.events.Back(); element != nil; element = element.Prev() {
+		coalesced := element.Value.(*sendableEvent)
+		if coalesced.isContainerEvent || coalesced.taskArn() != taskARN {
+			continue
+		}
+		buffer.events.Remove(element)
+		coalesced.bufferElement = nil
+		coalesced.droppedFromBuffer = true
+
+		coalesced.lock.RLock()
+		event.taskChange.Containers = append(append([]api.ContainerStateChange(nil),
+			coalesced.taskChange.Containers...), event.taskChange.Containers...)
+		event.taskChange.ManagedAgents = append(append([]api.ManagedAgentStateChange(nil),
+			coalesced.taskChange.ManagedAgents...), event.taskChange.ManagedAgents...)
+		coalesced.lock.RUnlock()
+		logger.Info("TaskHandler: Task event buffer is full, coalescing the latest event of the task into a new one", coalesced.toFields())
+		return true
+	}
+	return false
+}
+
+// remove removes the event from the buffer, making room for new events. It is
+// a no-op for events that are not in the buffer
+func (buffer *taskEventBuffer) remove(event *sendableEvent) {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+
+	if event.bufferElement == nil {
+		return
+	}
+	buffer.events.Remove(event.bufferElement)
+	event.bufferElement = nil
+	metrics.MetricsEngineGlobal.RecordTaskEventBufferDepth(buffer.events.Len())
+	buffer.spaceAvailable.Signal()
+}
+
+// isDropped returns true if the event was dropped from the buffer to make room
+// for newer events
+func (buffer *taskEventBuffer) isDropped(event *sendableEvent) bool {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+
+	return event.droppedFromBuffer
+}
+
+// depth returns the number of events in the buffer
+func (buffer *taskEventBuffer) depth() int {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+
+	if buffer.events == nil {
+		return 0
+	}
+	return buffer.events.Len()
+}
+
+// wakeOnDone wakes up the events waiting for room in the buffer once the
+// context is done, so that they stop waiting
+func (buffer *taskEventBuffer) wakeOnDone(ctx context.Context) {
+	<-ctx.Done()
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+
+	buffer.initUnsafe()
+	buffer.spaceAvailable.Broadcast()
+}
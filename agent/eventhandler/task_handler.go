@@ -23,6 +23,7 @@ import (
 
 	"github.com/aws/amazon-ecs-agent/agent/api"
 	apitaskstatus "github.com/aws/amazon-ecs-agent/agent/api/task/status"
+	"github.com/aws/amazon-ecs-agent/agent/config"
 	"github.com/aws/amazon-ecs-agent/agent/data"
 	"github.com/aws/amazon-ecs-agent/agent/ecs_client/model/ecs"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
@@ -30,6 +31,8 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/statechange"
 	"github.com/aws/amazon-ecs-agent/agent/utils"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger/field"
+	ecsmetrics "github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/utils/retry"
 	"github.com/cihub/seelog"
 )
@@ -48,13 +51,18 @@ const (
 	submitStateBackoffMax            = 30 * time.Second
 	submitStateBackoffJitterMultiple = 0.20
 	submitStateBackoffMultiple       = 1.3
+
+	// duplicateEventsWindow is the time within which consecutive identical state
+	// changes of a task are coalesced into a single submission
+	duplicateEventsWindow = 2 * time.Second
 )
 
 // TaskHandler encapsulates the the map of a task arn to task and container events
 // associated with said task
 type TaskHandler struct {
-	// submitSemaphore for the number of tasks that may be handled at once
-	submitSemaphore utils.Semaphore
+	// submitSemaphore for the number of tasks that may be handled at once.
+	// Tasks with a STOPPED state change queued are handled first
+	submitSemaphore *prioritySemaphore
 	// taskToEvents is arn:*eventList map so events may be serialized per task
 	tasksToEvents map[string]*taskSendableEvents
 	// tasksToContainerStates is used to collect container events
@@ -78,6 +86,25 @@ type TaskHandler struct {
 	minDrainEventsFrequency time.Duration
 	maxDrainEventsFrequency time.Duration
 
+	// duplicateEventsWindow is the time within which a task state change
+	// identical to the previous queued one for the task replaces it
+	duplicateEventsWindow time.Duration
+
+	// eventBuffer bounds the number of task events queued in the
+	// tasksToEvents map
+	eventBuffer taskEventBuffer
+
+	// maxSubmitAttempts is the number of times submitting a state change
+	// is attempted before it's moved to the dead letters. State changes are
+	// retried until they're submitted if it's not positive
+	maxSubmitAttempts int
+	// deadLetters holds the state changes given up on
+	deadLetters deadLetters
+
+	// metricsFactory records the latency and outcome of state change
+	// submissions
+	metricsFactory ecsmetrics.EntryFactory
+
 	state  dockerstate.TaskEngineState
 	client api.ECSClient
 	ctx    context.Context
@@ -109,20 +136,46 @@ func NewTaskHandler(ctx context.Context,
 	taskHandler := &TaskHandler{
 		ctx:                       ctx,
 		tasksToEvents:             make(map[string]*taskSendableEvents),
-		submitSemaphore:           utils.NewSemaphore(concurrentEventCalls),
+		submitSemaphore:           newPrioritySemaphore(concurrentEventCalls),
 		tasksToContainerStates:    make(map[string][]api.ContainerStateChange),
 		tasksToManagedAgentStates: make(map[string][]api.ManagedAgentStateChange),
 		dataClient:                dataClient,
 		state:                     state,
 		client:                    client,
+		metricsFactory:            ecsmetrics.NewNopEntryFactory(),
 		minDrainEventsFrequency:   minDrainEventsFrequency,
 		maxDrainEventsFrequency:   maxDrainEventsFrequency,
+		duplicateEventsWindow:     duplicateEventsWindow,
 	}
+	taskHandler.eventBuffer.setLimits(config.DefaultTaskEventBufferSize, config.TaskEventBufferOverflowPolicyDropOldestNonStopped)
+	go taskHandler.eventBuffer.wakeOnDone(ctx)
 	go taskHandler.startDrainEventsTicker()
 
 	return taskHandler
 }
 
+// SetEventBufferLimits sets the maximum number of task events, across all tasks,
+// that may be queued to be sent to ECS and the policy applied to events added
+// when that many events are queued
+func (handler *TaskHandler) SetEventBufferLimits(maxSize int, overflowPolicy string) {
+	handler.eventBuffer.setLimits(maxSize, overflowPolicy)
+}
+
+// SetMaxSubmitAttempts sets the number of times submitting a state change to ECS
+// is attempted before it's given up on, so that the state changes queued after
+// it for the task can be submitted. State changes are retried until they're
+// submitted if maxAttempts is not positive. It must be called before state
+// change events are added
+func (handler *TaskHandler) SetMaxSubmitAttempts(maxAttempts int) {
+	handler.maxSubmitAttempts = maxAttempts
+}
+
+// DeadLetters returns the most recent state changes that were given up on after
+// failing to be submitted to ECS, oldest first
+func (handler *TaskHandler) DeadLetters() []DeadLetter {
+	return handler.deadLetters.list()
+}
+
 // AddStateChangeEvent queues up the state change event to be sent to ECS.
 // If the event is for a container state change, it just gets added to the
 // handler.tasksToContainerStates map.
@@ -132,8 +185,6 @@ func NewTaskHandler(ctx context.Context,
 // handler.submitTaskEvents method to submit the batched container state
 // changes and the task state change to ECS
 func (handler *TaskHandler) AddStateChangeEvent(change statechange.Event, client api.ECSClient) error {
-	handler.lock.Lock()
-	defer handler.lock.Unlock()
 	switch change.GetEventType() {
 	case statechange.TaskEvent:
 		event, ok := change.(api.TaskStateChange)
@@ -143,14 +194,15 @@ func (handler *TaskHandler) AddStateChangeEvent(change statechange.Event, client
 		// Task event: gather all the container and managed agent events and send them
 		// to ECS by invoking the async submitTaskEvents method from
 		// the sendable event list object
-		handler.flushBatchUnsafe(&event, client)
-		return nil
+		return handler.flushBatch(&event, client)
 
 	case statechange.ContainerEvent:
 		event, ok := change.(api.ContainerStateChange)
 		if !ok {
 			return errors.New("eventhandler: unable to get container event from state change event")
 		}
+		handler.lock.Lock()
+		defer handler.lock.Unlock()
 		handler.batchContainerEventUnsafe(event)
 		return nil
 
@@ -159,7 +211,8 @@ func (handler *TaskHandler) AddStateChangeEvent(change statechange.Event, client
 		if !ok {
 			return errors.New("eventhandler: unable to get managed agent event from state change event")
 		}
-
+		handler.lock.Lock()
+		defer handler.lock.Unlock()
 		handler.batchManagedAgentEventUnsafe(event)
 		return nil
 
@@ -271,9 +324,13 @@ func (handler *TaskHandler) batchManagedAgentEventUnsafe(event api.ManagedAgentS
 	handler.tasksToManagedAgentStates[event.TaskArn] = append(handler.tasksToManagedAgentStates[event.TaskArn], event)
 }
 
-// flushBatchUnsafe attaches the task arn's container events to TaskStateChange event
-// by creating the sendable event list. It then submits this event to ECS asynchronously
-func (handler *TaskHandler) flushBatchUnsafe(taskStateChange *api.TaskStateChange, client api.ECSClient) {
+// flushBatch attaches the task arn's container events to TaskStateChange event
+// by creating the sendable event list. It then submits this event to ECS asynchronously.
+// The handler's lock is released while the event is added to the task event buffer,
+// which may wait for room, so that other events can be batched and sent meanwhile.
+// An error is returned if the event could not be added to the task event buffer
+func (handler *TaskHandler) flushBatch(taskStateChange *api.TaskStateChange, client api.ECSClient) error {
+	handler.lock.Lock()
 	taskStateChange.Containers = append(taskStateChange.Containers,
 		handler.tasksToContainerStates[taskStateChange.TaskARN]...)
 	// All container events for the task have now been copied to the
@@ -284,14 +341,25 @@ func (handler *TaskHandler) flushBatchUnsafe(taskStateChange *api.TaskStateChang
 	// All managed agent events for the task have now been copied to the
 	// task state change object. Remove them from the map
 	delete(handler.tasksToManagedAgentStates, taskStateChange.TaskARN)
+	handler.lock.Unlock()
+
 	// Prepare a given event to be sent by adding it to the handler's
 	// eventList
 	event := newSendableTaskEvent(*taskStateChange)
+	// Account for the event in the task event buffer, which either waits
+	// for room or drops an older event when the buffer is full
+	if err := handler.eventBuffer.add(handler.ctx, event); err != nil {
+		return err
+	}
+
+	handler.lock.Lock()
+	defer handler.lock.Unlock()
 	taskEvents := handler.getTaskEventsUnsafe(event)
 
 	// Add the event to the sendable events queue for the task and
 	// start sending it asynchronously if possible
 	taskEvents.sendChange(event, client, handler)
+	return nil
 }
 
 // getTaskEventsUnsafe gets the event list for the task arn in the sendableEvent
@@ -328,6 +396,9 @@ func (handler *TaskHandler) submitTaskEvents(taskEvents *taskSendableEvents, cli
 	// Mirror events.sending, but without the need to lock since this is local
 	// to our goroutine
 	done := false
+	// holdsSemaphore is set when the submit semaphore is held on to for the
+	// next event of the task
+	holdsSemaphore := false
 	// TODO: wire in the context here. Else, we have go routine leaks in tests
 	for !done {
 		// If we looped back up here, we successfully submitted an event, but
@@ -336,12 +407,23 @@ func (handler *TaskHandler) submitTaskEvents(taskEvents *taskSendableEvents, cli
 		retry.RetryWithBackoff(backoff, func() error {
 			// Lock and unlock within this function, allowing the list to be added
 			// to while we're not actively sending an event
-			seelog.Debug("TaskHandler: Waiting on semaphore to send events...")
-			handler.submitSemaphore.Wait()
-			defer handler.submitSemaphore.Post()
+			if !holdsSemaphore {
+				seelog.Debug("TaskHandler: Waiting on semaphore to send events...")
+				// Submitting STOPPED state changes first frees up the capacity of
+				// the stopped tasks sooner
+				handler.submitSemaphore.wait(taskEvents.hasStoppedEvent)
+			}
 
 			var err error
 			done, err = taskEvents.submitFirstEvent(handler, backoff)
+			// The events queued ahead of a STOPPED state change are submitted
+			// first to preserve ordering. The semaphore is held on to until the
+			// STOPPED state change is submitted, so that other tasks don't get
+			// ahead of it
+			holdsSemaphore = err == nil && !done && taskEvents.hasStoppedEvent()
+			if !holdsSemaphore {
+				handler.submitSemaphore.post()
+			}
 			return err
 		})
 	}
@@ -364,6 +446,20 @@ func (taskEvents *taskSendableEvents) sendChange(change *sendableEvent,
 	taskEvents.lock.Lock()
 	defer taskEvents.lock.Unlock()
 
+	// Events in the queue have not been submitted yet, as they are removed from
+	// the queue under the lock once submitted. If the last one reports the
+	// same state and was queued shortly before, the change replaces it so that
+	// the state is submitted once, with the latest details
+	if last := taskEvents.events.Back(); last != nil {
+		lastEvent := last.Value.(*sendableEvent)
+		if change.queuedAt.Sub(lastEvent.queuedAt) < handler.duplicateEventsWindow && change.duplicates(lastEvent) {
+			logger.Debug("TaskHandler: Coalescing event with an identical queued event", change.toFields())
+			last.Value = change
+			handler.eventBuffer.remove(lastEvent)
+			return
+		}
+	}
+
 	// Add event to the queue
 	logger.Debug("TaskHandler: Adding event", change.toFields())
 	taskEvents.events.PushBack(change)
@@ -402,28 +498,45 @@ func (taskEvents *taskSendableEvents) submitFirstEvent(handler *TaskHandler, bac
 	// Extract the wrapped event from the list element
 	event := eventToSubmit.Value.(*sendableEvent)
 
-	if event.containerShouldBeSent() {
+	if handler.eventBuffer.isDropped(event) {
+		logger.Warn("TaskHandler: Not submitting event dropped from the task event buffer; just removing", event.toFields())
+		taskEvents.events.Remove(eventToSubmit)
+	} else if event.containerShouldBeSent() {
 		if err := event.send(sendContainerStatusToECS, setContainerChangeSent, "container",
-			handler.client, eventToSubmit, handler.dataClient, backoff, taskEvents); err != nil {
-			return false, err
+			handler.client, eventToSubmit, handler.dataClient, handler.metricsFactory, backoff, taskEvents); err != nil {
+			if !taskEvents.deadLetterIfExhaustedUnsafe(handler, eventToSubmit, err) {
+				return false, err
+			}
 		}
 	} else if event.taskShouldBeSent() {
 		if err := event.send(sendTaskStatusToECS, setTaskChangeSent, "task",
-			handler.client, eventToSubmit, handler.dataClient, backoff, taskEvents); err != nil {
-			handleInvalidParamException(err, taskEvents.events, eventToSubmit)
-			return false, err
+			handler.client, eventToSubmit, handler.dataClient, handler.metricsFactory, backoff, taskEvents); err != nil {
+			if handleInvalidParamException(err, taskEvents.events, eventToSubmit) {
+				handler.eventBuffer.remove(event)
+				return false, err
+			}
+			if !taskEvents.deadLetterIfExhaustedUnsafe(handler, eventToSubmit, err) {
+				return false, err
+			}
 		}
 	} else if event.taskAttachmentShouldBeSent() {
 		if err := event.send(sendTaskStatusToECS, setTaskAttachmentSent, "task attachment",
-			handler.client, eventToSubmit, handler.dataClient, backoff, taskEvents); err != nil {
-			handleInvalidParamException(err, taskEvents.events, eventToSubmit)
-			return false, err
+			handler.client, eventToSubmit, handler.dataClient, handler.metricsFactory, backoff, taskEvents); err != nil {
+			if handleInvalidParamException(err, taskEvents.events, eventToSubmit) {
+				handler.eventBuffer.remove(event)
+				return false, err
+			}
+			if !taskEvents.deadLetterIfExhaustedUnsafe(handler, eventToSubmit, err) {
+				return false, err
+			}
 		}
 	} else {
 		// Shouldn't be sent as either a task or container change event; must have been already sent
 		logger.Info("TaskHandler: Not submitting redundant event; just removing", event.toFields())
 		taskEvents.events.Remove(eventToSubmit)
 	}
+	// The event has been removed from the list; make room for new events
+	handler.eventBuffer.remove(event)
 
 	if taskEvents.events.Len() == 0 {
 		logger.Debug("TaskHandler: Removed the last element, no longer sending")
@@ -434,17 +547,52 @@ func (taskEvents *taskSendableEvents) submitFirstEvent(handler *TaskHandler, bac
 	return false, nil
 }
 
+// deadLetterIfExhaustedUnsafe counts the failed attempt to submit the event, which
+// failed with err. Once the event has been attempted the maximum number of times, it's
+// removed from the event list and added to the dead letters, and true is returned
+func (taskEvents *taskSendableEvents) deadLetterIfExhaustedUnsafe(handler *TaskHandler,
+	eventToSubmit *list.Element, err error) bool {
+	event := eventToSubmit.Value.(*sendableEvent)
+	event.submitAttempts++
+	if handler.maxSubmitAttempts <= 0 || event.submitAttempts < handler.maxSubmitAttempts {
+		return false
+	}
+	fields := event.toFields()
+	fields[field.Error] = err
+	fields["attempts"] = event.submitAttempts
+	logger.Error("TaskHandler: Giving up on submitting state change to ECS; moving it to the dead letters", fields)
+	taskEvents.events.Remove(eventToSubmit)
+	handler.deadLetters.add(event, err)
+	return true
+}
+
+// hasStoppedEvent returns true if a STOPPED task or container state change is
+// queued for the task
+func (taskEvents *taskSendableEvents) hasStoppedEvent() bool {
+	taskEvents.lock.Lock()
+	defer taskEvents.lock.Unlock()
+
+	for elem := taskEvents.events.Front(); elem != nil; elem = elem.Next() {
+		if elem.Value.(*sendableEvent).isStopped() {
+			return true
+		}
+	}
+	return false
+}
+
 func (taskEvents *taskSendableEvents) toStringUnsafe() string {
 	return fmt.Sprintf("Task event list [taskARN: %s, sending: %t, createdAt: %s]",
 		taskEvents.taskARN, taskEvents.sending, taskEvents.createdAt.String())
 }
 
 // handleInvalidParamException removes the event from event queue when its parameters are
-// invalid to reduce redundant API call
-func handleInvalidParamException(err error, events *list.List, eventToSubmit *list.Element) {
+// invalid to reduce redundant API call. It returns true if the event was removed
+func handleInvalidParamException(err error, events *list.List, eventToSubmit *list.Element) bool {
 	if utils.IsAWSErrorCodeEqual(err, ecs.ErrCodeInvalidParameterException) {
 		event := eventToSubmit.Value.(*sendableEvent)
 		logger.Warn("TaskHandler: Event is sent with invalid parameters; just removing", event.toFields())
 		events.Remove(eventToSubmit)
+		return true
 	}
+	return false
 }
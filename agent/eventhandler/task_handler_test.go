@@ -19,8 +19,10 @@ package eventhandler
 import (
 	"container/list"
 	"context"
+	"fmt"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -30,21 +32,24 @@ import (
 	mock_api "github.com/aws/amazon-ecs-agent/agent/api/mocks"
 	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
 	apitaskstatus "github.com/aws/amazon-ecs-agent/agent/api/task/status"
+	"github.com/aws/amazon-ecs-agent/agent/config"
 	"github.com/aws/amazon-ecs-agent/agent/data"
 	"github.com/aws/amazon-ecs-agent/agent/ecs_client/model/ecs"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
 	mock_dockerstate "github.com/aws/amazon-ecs-agent/agent/engine/dockerstate/mocks"
 	"github.com/aws/amazon-ecs-agent/agent/statechange"
-	"github.com/aws/amazon-ecs-agent/agent/utils"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/api/attachmentinfo"
 	apieni "github.com/aws/amazon-ecs-agent/ecs-agent/api/eni"
 	apierrors "github.com/aws/amazon-ecs-agent/ecs-agent/api/errors"
+	ecsmetrics "github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
+	mock_metrics "github.com/aws/amazon-ecs-agent/ecs-agent/metrics/mocks"
 	mock_retry "github.com/aws/amazon-ecs-agent/ecs-agent/utils/retry/mock"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const taskARN = "taskarn"
@@ -171,6 +176,59 @@ func TestSendsEventsConcurrentLimit(t *testing.T) {
 	}
 }
 
+// Tests that when tasks wait to submit state changes, the tasks with a STOPPED state change
+// queued submit first, while the state changes of each task are still submitted in order.
+func TestSendsStoppedEventsFirst(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_api.NewMockECSClient(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := NewTaskHandler(ctx, data.NewNoopClient(), dockerstate.NewTaskEngineState(), client)
+	defer cancel()
+	// Submit the state changes of one task at a time
+	handler.submitSemaphore = newPrioritySemaphore(1)
+
+	unblock := make(chan struct{})
+	submitted := make(chan api.TaskStateChange, 4)
+	client.EXPECT().SubmitTaskStateChange(gomock.Any()).DoAndReturn(func(change api.TaskStateChange) error {
+		if change.TaskARN == "blocking" {
+			<-unblock
+		}
+		submitted <- change
+		return nil
+	}).Times(4)
+
+	// Hold the only submission slot until the other tasks are waiting for it
+	require.NoError(t, handler.AddStateChangeEvent(taskEvent("blocking"), client))
+	require.Eventually(t, func() bool {
+		return numAvailable(handler.submitSemaphore) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, handler.AddStateChangeEvent(taskEvent("running"), client))
+	require.Eventually(t, func() bool {
+		return numWaiters(handler.submitSemaphore) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, handler.AddStateChangeEvent(taskEvent("stopping"), client))
+	require.NoError(t, handler.AddStateChangeEvent(taskEventStopped("stopping"), client))
+	require.Eventually(t, func() bool {
+		return numWaiters(handler.submitSemaphore) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	close(unblock)
+
+	var order []string
+	for i := 0; i < 4; i++ {
+		change := <-submitted
+		order = append(order, change.TaskARN+":"+change.Status.String())
+	}
+	assert.Equal(t, []string{
+		"blocking:RUNNING",
+		// The RUNNING state change of the stopping task is queued ahead of its STOPPED one
+		"stopping:RUNNING",
+		"stopping:STOPPED",
+		"running:RUNNING",
+	}, order)
+}
+
 func TestSendsEventsContainerDifferences(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -315,7 +373,7 @@ func TestCleanupTaskEventAfterSubmit(t *testing.T) {
 	wg.Add(3)
 
 	taskEvent1 := taskEvent(taskARN)
-	taskEvent2 := taskEvent(taskARN)
+	taskEvent2 := taskEventStopped(taskARN)
 	taskEvent3 := taskEvent(taskARN2)
 
 	client.EXPECT().SubmitTaskStateChange(gomock.Any()).Do(
@@ -409,6 +467,550 @@ func TestENISentStatusChange(t *testing.T) {
 	assert.True(t, eniAttachment.AttachStatusSent)
 }
 
+func TestTaskEventBufferDropOldestPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_api.NewMockECSClient(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := NewTaskHandler(ctx, data.NewNoopClient(), dockerstate.NewTaskEngineState(), client)
+	defer cancel()
+	handler.SetEventBufferLimits(2, config.TaskEventBufferOverflowPolicyDropOldest)
+
+	retriable := apierrors.NewRetriableError(apierrors.NewRetriable(true), errors.New("test"))
+	failed := make(chan struct{})
+	var submitted []string
+	var wg sync.WaitGroup
+	wg.Add(3)
+	client.EXPECT().SubmitTaskStateChange(gomock.Any()).Times(3).DoAndReturn(func(change api.TaskStateChange) error {
+		defer wg.Done()
+		submitted = append(submitted, change.Reason)
+		if len(submitted) == 1 {
+			// Fail the first submission to let the buffer fill up while backing off
+			close(failed)
+			return retriable
+		}
+		return nil
+	})
+
+	assert.NoError(t, handler.AddStateChangeEvent(bufferedTaskEvent("event1"), client))
+	<-failed
+	for _, reason := range []string{"event2", "event3", "event4"} {
+		assert.NoError(t, handler.AddStateChangeEvent(bufferedTaskEvent(reason), client))
+	}
+	// event1 and event2 are dropped to make room for event3 and event4
+	assert.Equal(t, 2, handler.eventBuffer.depth())
+
+	wg.Wait()
+	// The dropped events are not retried
+	assert.Equal(t, []string{"event1", "event3", "event4"}, submitted)
+	assert.Eventually(t, func() bool {
+		return handler.eventBuffer.depth() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestTaskEventBufferBlockPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_api.NewMockECSClient(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := NewTaskHandler(ctx, data.NewNoopClient(), dockerstate.NewTaskEngineState(), client)
+	defer cancel()
+	handler.SetEventBufferLimits(1, config.TaskEventBufferOverflowPolicyBlock)
+
+	submitting := make(chan struct{})
+	release := make(chan struct{})
+	var submitted []string
+	var wg sync.WaitGroup
+	wg.Add(2)
+	client.EXPECT().SubmitTaskStateChange(gomock.Any()).Times(2).DoAndReturn(func(change api.TaskStateChange) error {
+		defer wg.Done()
+		submitted = append(submitted, change.Reason)
+		if len(submitted) == 1 {
+			// Hold the first event to keep the buffer full
+			close(submitting)
+			<-release
+		}
+		return nil
+	})
+
+	assert.NoError(t, handler.AddStateChangeEvent(bufferedTaskEvent("event1"), client))
+	<-submitting
+
+	added := make(chan error)
+	go func() {
+		added <- handler.AddStateChangeEvent(bufferedTaskEvent("event2"), client)
+	}()
+	select {
+	case <-added:
+		t.Fatal("Expected the event to wait for room in the full buffer")
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.Equal(t, 1, handler.eventBuffer.depth())
+
+	close(release)
+	assert.NoError(t, <-added)
+	wg.Wait()
+	assert.Equal(t, []string{"event1", "event2"}, submitted)
+}
+
+func TestTaskEventBufferBlockPolicyContextCancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_api.NewMockECSClient(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := NewTaskHandler(ctx, data.NewNoopClient(), dockerstate.NewTaskEngineState(), client)
+	handler.SetEventBufferLimits(1, config.TaskEventBufferOverflowPolicyBlock)
+
+	release := make(chan struct{})
+	defer close(release)
+	submitting := make(chan struct{})
+	client.EXPECT().SubmitTaskStateChange(gomock.Any()).DoAndReturn(func(change api.TaskStateChange) error {
+		close(submitting)
+		<-release
+		return nil
+	})
+
+	assert.NoError(t, handler.AddStateChangeEvent(bufferedTaskEvent("event1"), client))
+	<-submitting
+
+	added := make(chan error)
+	go func() {
+		added <- handler.AddStateChangeEvent(bufferedTaskEvent("event2"), client)
+	}()
+	cancel()
+	assert.Error(t, <-added)
+}
+
+func TestTaskEventBufferBlockPolicyHandlerNotLocked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_api.NewMockECSClient(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := NewTaskHandler(ctx, data.NewNoopClient(), dockerstate.NewTaskEngineState(), client)
+	defer cancel()
+	handler.SetEventBufferLimits(1, config.TaskEventBufferOverflowPolicyBlock)
+
+	submitting := make(chan struct{})
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	client.EXPECT().SubmitTaskStateChange(gomock.Any()).Times(2).DoAndReturn(func(change api.TaskStateChange) error {
+		defer wg.Done()
+		if change.Reason == "event1" {
+			// Hold the first event to keep the buffer full
+			close(submitting)
+			<-release
+		}
+		return nil
+	})
+
+	assert.NoError(t, handler.AddStateChangeEvent(bufferedTaskEvent("event1"), client))
+	<-submitting
+
+	added := make(chan error)
+	go func() {
+		added <- handler.AddStateChangeEvent(bufferedTaskEvent("event2"), client)
+	}()
+
+	// The handler keeps accepting events while the task event waits for room
+	accepted := make(chan error)
+	go func() {
+		accepted <- handler.AddStateChangeEvent(containerEvent("otherTaskARN"), client)
+		handler.taskStateChangesToSend()
+		accepted <- handler.AddStateChangeEvent(managedAgentEvent("otherTaskARN"), client)
+	}()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-accepted:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("Expected the handler to accept events while the task event buffer is full")
+		}
+	}
+	select {
+	case <-added:
+		t.Fatal("Expected the event to wait for room in the full buffer")
+	default:
+	}
+
+	close(release)
+	assert.NoError(t, <-added)
+	wg.Wait()
+}
+
+func TestTaskEventBufferDropOldestNonStoppedPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_api.NewMockECSClient(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := NewTaskHandler(ctx, data.NewNoopClient(), dockerstate.NewTaskEngineState(), client)
+	defer cancel()
+	handler.SetEventBufferLimits(2, config.TaskEventBufferOverflowPolicyDropOldestNonStopped)
+
+	retriable := apierrors.NewRetriableError(apierrors.NewRetriable(true), errors.New("test"))
+	failed := make(chan struct{})
+	var submitted []string
+	var wg sync.WaitGroup
+	wg.Add(3)
+	client.EXPECT().SubmitTaskStateChange(gomock.Any()).Times(3).DoAndReturn(func(change api.TaskStateChange) error {
+		defer wg.Done()
+		submitted = append(submitted, change.Reason)
+		if len(submitted) == 1 {
+			// Fail the first submission to let the buffer fill up while backing off
+			close(failed)
+			return retriable
+		}
+		return nil
+	})
+
+	assert.NoError(t, handler.AddStateChangeEvent(bufferedTaskEvent("event1"), client))
+	<-failed
+	stopped := bufferedTaskEvent("event2").(api.TaskStateChange)
+	stopped.Status = apitaskstatus.TaskStopped
+	assert.NoError(t, handler.AddStateChangeEvent(stopped, client))
+	for _, reason := range []string{"event3", "event4"} {
+		assert.NoError(t, handler.AddStateChangeEvent(bufferedTaskEvent(reason), client))
+	}
+	// event1 and event3 are dropped to make room, the STOPPED event2 is kept
+	assert.Equal(t, 2, handler.eventBuffer.depth())
+
+	wg.Wait()
+	assert.Equal(t, []string{"event1", "event2", "event4"}, submitted)
+	assert.Eventually(t, func() bool {
+		return handler.eventBuffer.depth() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestTaskEventBufferCoalescePolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_api.NewMockECSClient(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := NewTaskHandler(ctx, data.NewNoopClient(), dockerstate.NewTaskEngineState(), client)
+	defer cancel()
+	handler.SetEventBufferLimits(2, config.TaskEventBufferOverflowPolicyCoalesce)
+
+	retriable := apierrors.NewRetriableError(apierrors.NewRetriable(true), errors.New("test"))
+	failed := make(chan struct{})
+	var submitted []api.TaskStateChange
+	var wg sync.WaitGroup
+	wg.Add(3)
+	client.EXPECT().SubmitTaskStateChange(gomock.Any()).Times(3).DoAndReturn(func(change api.TaskStateChange) error {
+		defer wg.Done()
+		submitted = append(submitted, change)
+		if len(submitted) == 1 {
+			// Fail the first submission to let the buffer fill up while backing off
+			close(failed)
+			return retriable
+		}
+		return nil
+	})
+
+	assert.NoError(t, handler.AddStateChangeEvent(bufferedTaskEvent("event1"), client))
+	<-failed
+	// Flood the buffer with events of the task, each carrying a container change
+	for i := 2; i <= 10; i++ {
+		event := bufferedTaskEvent("event" + strconv.Itoa(i)).(api.TaskStateChange)
+		event.Containers = []api.ContainerStateChange{{
+			TaskArn:       taskARN,
+			ContainerName: "container" + strconv.Itoa(i),
+			Status:        apicontainerstatus.ContainerRunning,
+			Container:     &apicontainer.Container{},
+		}}
+		assert.NoError(t, handler.AddStateChangeEvent(event, client))
+	}
+	// The events after event2 are coalesced into a single queued event
+	assert.Equal(t, 2, handler.eventBuffer.depth())
+
+	wg.Wait()
+	require.Len(t, submitted, 3)
+	assert.Equal(t, "event1", submitted[0].Reason)
+	assert.Equal(t, "event1", submitted[1].Reason)
+	assert.Equal(t, "event10", submitted[2].Reason)
+	// The container changes of the coalesced events are not lost
+	var containerNames []string
+	for _, containerChange := range submitted[2].Containers {
+		containerNames = append(containerNames, containerChange.ContainerName)
+	}
+	assert.Equal(t, []string{"container2", "container3", "container4", "container5", "container6",
+		"container7", "container8", "container9", "container10"}, containerNames)
+	assert.Eventually(t, func() bool {
+		return handler.eventBuffer.depth() == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestTaskEventBufferCoalescePolicyBlocksOtherTasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_api.NewMockECSClient(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := NewTaskHandler(ctx, data.NewNoopClient(), dockerstate.NewTaskEngineState(), client)
+	defer cancel()
+	handler.SetEventBufferLimits(1, config.TaskEventBufferOverflowPolicyCoalesce)
+
+	submitting := make(chan struct{})
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	client.EXPECT().SubmitTaskStateChange(gomock.Any()).Times(2).DoAndReturn(func(change api.TaskStateChange) error {
+		defer wg.Done()
+		if change.TaskARN == taskARN {
+			// Hold the first event to keep the buffer full
+			close(submitting)
+			<-release
+		}
+		return nil
+	})
+
+	assert.NoError(t, handler.AddStateChangeEvent(bufferedTaskEvent("event1"), client))
+	<-submitting
+
+	added := make(chan error)
+	go func() {
+		event := bufferedTaskEvent("event2").(api.TaskStateChange)
+		event.TaskARN = "otherTaskARN"
+		added <- handler.AddStateChangeEvent(event, client)
+	}()
+	select {
+	case <-added:
+		t.Fatal("Expected the event of another task to wait for room in the full buffer")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	assert.NoError(t, <-added)
+	wg.Wait()
+}
+
+func TestCoalesceDuplicateTaskStateChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_api.NewMockECSClient(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := NewTaskHandler(ctx, data.NewNoopClient(), dockerstate.NewTaskEngineState(), client)
+	defer cancel()
+
+	retriable := apierrors.NewRetriableError(apierrors.NewRetriable(true), errors.New("test"))
+	failed := make(chan struct{})
+	submitted := make(chan api.TaskStateChange)
+	gomock.InOrder(
+		// Fail the first submission to queue up the duplicates while backing off
+		client.EXPECT().SubmitTaskStateChange(gomock.Any()).DoAndReturn(func(change api.TaskStateChange) error {
+			close(failed)
+			return retriable
+		}),
+		client.EXPECT().SubmitTaskStateChange(gomock.Any()).DoAndReturn(func(change api.TaskStateChange) error {
+			submitted <- change
+			return nil
+		}),
+	)
+
+	assert.NoError(t, handler.AddStateChangeEvent(taskEvent(taskARN), client))
+	<-failed
+	var latestTask *apitask.Task
+	for i := 0; i < 5; i++ {
+		event := taskEvent(taskARN).(api.TaskStateChange)
+		latestTask = event.Task
+		assert.NoError(t, handler.AddStateChangeEvent(event, client))
+	}
+
+	// The duplicates are submitted once, with the latest state
+	change := <-submitted
+	assert.Same(t, latestTask, change.Task)
+	assert.Eventually(t, func() bool {
+		return getTasksToEventsLen(handler) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, handler.eventBuffer.depth())
+}
+
+func TestDuplicateTaskStateChangesOutsideWindow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_api.NewMockECSClient(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := NewTaskHandler(ctx, data.NewNoopClient(), dockerstate.NewTaskEngineState(), client)
+	defer cancel()
+	handler.duplicateEventsWindow = 0
+
+	retriable := apierrors.NewRetriableError(apierrors.NewRetriable(true), errors.New("test"))
+	failed := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	gomock.InOrder(
+		client.EXPECT().SubmitTaskStateChange(gomock.Any()).DoAndReturn(func(change api.TaskStateChange) error {
+			close(failed)
+			return retriable
+		}),
+		client.EXPECT().SubmitTaskStateChange(gomock.Any()).Times(2).DoAndReturn(func(change api.TaskStateChange) error {
+			wg.Done()
+			return nil
+		}),
+	)
+
+	assert.NoError(t, handler.AddStateChangeEvent(taskEvent(taskARN), client))
+	<-failed
+	assert.NoError(t, handler.AddStateChangeEvent(taskEvent(taskARN), client))
+	wg.Wait()
+}
+
+func bufferedTaskEvent(reason string) statechange.Event {
+	return api.TaskStateChange{TaskARN: taskARN, Status: apitaskstatus.TaskRunning, Task: &apitask.Task{}, Reason: reason}
+}
+
+func TestTaskStateChangeDeadLetteredAfterMaxSubmitAttempts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_api.NewMockECSClient(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := NewTaskHandler(ctx, data.NewNoopClient(), dockerstate.NewTaskEngineState(), client)
+	defer cancel()
+	handler.SetMaxSubmitAttempts(2)
+
+	retriable := apierrors.NewRetriableError(apierrors.NewRetriable(true), errors.New("test"))
+	var runningAttempts int32
+	submitted := make(chan api.TaskStateChange, 1)
+	// Submitting the running state change always fails
+	client.EXPECT().SubmitTaskStateChange(gomock.Any()).DoAndReturn(func(change api.TaskStateChange) error {
+		if change.Status == apitaskstatus.TaskRunning {
+			atomic.AddInt32(&runningAttempts, 1)
+			return retriable
+		}
+		submitted <- change
+		return nil
+	}).AnyTimes()
+
+	assert.NoError(t, handler.AddStateChangeEvent(taskEvent(taskARN), client))
+	assert.NoError(t, handler.AddStateChangeEvent(taskEventStopped(taskARN), client))
+
+	// The state change queued after the dead lettered one is submitted
+	select {
+	case change := <-submitted:
+		assert.Equal(t, apitaskstatus.TaskStopped, change.Status)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected the stopped state change to be submitted")
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&runningAttempts))
+
+	deadLetters := handler.DeadLetters()
+	require.Len(t, deadLetters, 1)
+	assert.Equal(t, taskARN, deadLetters[0].TaskARN)
+	assert.Equal(t, apitaskstatus.TaskRunning.String(), deadLetters[0].Status)
+	assert.Equal(t, 2, deadLetters[0].Attempts)
+	assert.Equal(t, retriable.Error(), deadLetters[0].LastError)
+
+	assert.Eventually(t, func() bool {
+		return getTasksToEventsLen(handler) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, handler.eventBuffer.depth())
+}
+
+func TestDeadLettersBounded(t *testing.T) {
+	var dl deadLetters
+	for i := 0; i < maxDeadLetters+1; i++ {
+		event := newSendableTaskEvent(api.TaskStateChange{
+			TaskARN: fmt.Sprintf("%s-%d", taskARN, i),
+			Status:  apitaskstatus.TaskStopped,
+		})
+		dl.add(event, errors.New("test"))
+	}
+
+	letters := dl.list()
+	require.Len(t, letters, maxDeadLetters)
+	// The oldest dead letter is discarded
+	assert.Equal(t, taskARN+"-1", letters[0].TaskARN)
+	assert.Equal(t, fmt.Sprintf("%s-%d", taskARN, maxDeadLetters), letters[maxDeadLetters-1].TaskARN)
+}
+
+func TestSubmitMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	client := mock_api.NewMockECSClient(ctrl)
+	metricsFactory := mock_metrics.NewMockEntryFactory(ctrl)
+	latencyEntry := mock_metrics.NewMockEntry(ctrl)
+	submitEntry := mock_metrics.NewMockEntry(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := NewTaskHandler(ctx, data.NewNoopClient(), dockerstate.NewTaskEngineState(), client)
+	defer cancel()
+	handler.metricsFactory = metricsFactory
+
+	var lock sync.Mutex
+	var latencies []time.Duration
+	var outcomes []string
+	var submitErrs []error
+	metricsFactory.EXPECT().New(ecsmetrics.TaskHandlerSubmitLatencyMetricName).Return(latencyEntry).Times(2)
+	latencyEntry.EXPECT().WithFields(map[string]interface{}{submitMetricEventTypeField: "task"}).
+		Return(latencyEntry).Times(2)
+	latencyEntry.EXPECT().WithGauge(gomock.Any()).DoAndReturn(func(value interface{}) ecsmetrics.Entry {
+		lock.Lock()
+		defer lock.Unlock()
+		latencies = append(latencies, value.(time.Duration))
+		return latencyEntry
+	}).Times(2)
+	latencyEntry.EXPECT().Done(gomock.Any()).Return(func() {}).Times(2)
+	metricsFactory.EXPECT().New(ecsmetrics.TaskHandlerSubmitStateChangeMetricName).Return(submitEntry).Times(2)
+	submitEntry.EXPECT().WithFields(gomock.Any()).DoAndReturn(func(fields map[string]interface{}) ecsmetrics.Entry {
+		lock.Lock()
+		defer lock.Unlock()
+		assert.Equal(t, "task", fields[submitMetricEventTypeField])
+		outcomes = append(outcomes, fields[submitMetricOutcomeField].(string))
+		return submitEntry
+	}).Times(2)
+	submitEntry.EXPECT().WithCount(1).Return(submitEntry).Times(2)
+	submitEntry.EXPECT().Done(gomock.Any()).DoAndReturn(func(err error) func() {
+		lock.Lock()
+		defer lock.Unlock()
+		submitErrs = append(submitErrs, err)
+		return func() {}
+	}).Times(2)
+
+	submitDelay := 50 * time.Millisecond
+	retriable := apierrors.NewRetriableError(apierrors.NewRetriable(true), errors.New("test"))
+	submitted := make(chan struct{})
+	gomock.InOrder(
+		// The first submission is slow and fails
+		client.EXPECT().SubmitTaskStateChange(gomock.Any()).DoAndReturn(func(change api.TaskStateChange) error {
+			time.Sleep(submitDelay)
+			return retriable
+		}),
+		client.EXPECT().SubmitTaskStateChange(gomock.Any()).DoAndReturn(func(change api.TaskStateChange) error {
+			time.Sleep(submitDelay)
+			close(submitted)
+			return nil
+		}),
+	)
+
+	assert.NoError(t, handler.AddStateChangeEvent(taskEvent(taskARN), client))
+
+	select {
+	case <-submitted:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected the state change to be submitted")
+	}
+	assert.Eventually(t, func() bool {
+		return getTasksToEventsLen(handler) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.Len(t, latencies, 2)
+	for _, latency := range latencies {
+		assert.GreaterOrEqual(t, latency, submitDelay)
+	}
+	assert.Equal(t, []string{submitOutcomeFailure, submitOutcomeSuccess}, outcomes)
+	assert.Equal(t, []error{retriable, nil}, submitErrs)
+}
+
 func TestGetBatchedContainerEvents(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -459,11 +1061,12 @@ func TestSubmitTaskEventsWhenSubmittingTaskRunningAfterStopped(t *testing.T) {
 
 	handler := &TaskHandler{
 		state:                  state,
-		submitSemaphore:        utils.NewSemaphore(concurrentEventCalls),
+		submitSemaphore:        newPrioritySemaphore(concurrentEventCalls),
 		tasksToEvents:          make(map[string]*taskSendableEvents),
 		tasksToContainerStates: make(map[string][]api.ContainerStateChange),
 		client:                 client,
 		dataClient:             data.NewNoopClient(),
+		metricsFactory:         ecsmetrics.NewNopEntryFactory(),
 	}
 
 	taskEvents := &taskSendableEvents{events: list.New(),
@@ -523,11 +1126,12 @@ func TestSubmitTaskEventsWhenSubmittingTaskStoppedAfterRunning(t *testing.T) {
 
 	handler := &TaskHandler{
 		state:                  state,
-		submitSemaphore:        utils.NewSemaphore(concurrentEventCalls),
+		submitSemaphore:        newPrioritySemaphore(concurrentEventCalls),
 		tasksToEvents:          make(map[string]*taskSendableEvents),
 		tasksToContainerStates: make(map[string][]api.ContainerStateChange),
 		client:                 client,
 		dataClient:             data.NewNoopClient(),
+		metricsFactory:         ecsmetrics.NewNopEntryFactory(),
 	}
 
 	taskEvents := &taskSendableEvents{events: list.New(),
@@ -16,9 +16,11 @@ package eventhandler
 import (
 	"container/list"
 	"sync"
+	"time"
 
 	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/logger/field"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
 
 	"github.com/aws/amazon-ecs-agent/agent/api"
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
@@ -30,6 +32,16 @@ import (
 	"github.com/cihub/seelog"
 )
 
+const (
+	// submitMetricEventTypeField and submitMetricOutcomeField are the fields of
+	// the state change submission metrics
+	submitMetricEventTypeField = "EventType"
+	submitMetricOutcomeField   = "Outcome"
+
+	submitOutcomeSuccess = "Success"
+	submitOutcomeFailure = "Failure"
+)
+
 // a state change that may have a container and, optionally, a task event to
 // send
 type sendableEvent struct {
@@ -42,6 +54,18 @@ type sendableEvent struct {
 	taskSent   bool
 	taskChange api.TaskStateChange
 
+	// bufferElement is the event's element in the task event buffer. It is nil
+	// when the event is not in the buffer
+	bufferElement *list.Element
+	// droppedFromBuffer is set when the event is dropped from the task event
+	// buffer to make room for newer events, or coalesced into a newer event
+	droppedFromBuffer bool
+
+	// queuedAt is the time the event was created to be queued
+	queuedAt time.Time
+	// submitAttempts is the number of failed attempts to submit the event
+	submitAttempts int
+
 	lock sync.RWMutex
 }
 
@@ -50,9 +74,57 @@ func newSendableTaskEvent(event api.TaskStateChange) *sendableEvent {
 		isContainerEvent: false,
 		taskSent:         false,
 		taskChange:       event,
+		queuedAt:         time.Now(),
 	}
 }
 
+// duplicates returns true if both events are task state changes that report the same
+// task, container and managed agent states, so that sending both would be redundant
+func (event *sendableEvent) duplicates(other *sendableEvent) bool {
+	event.lock.RLock()
+	defer event.lock.RUnlock()
+	other.lock.RLock()
+	defer other.lock.RUnlock()
+
+	if event.isContainerEvent || other.isContainerEvent {
+		return false
+	}
+	change, otherChange := event.taskChange, other.taskChange
+	if change.TaskARN != otherChange.TaskARN || change.Status != otherChange.Status ||
+		change.Reason != otherChange.Reason ||
+		change.Attachment != nil || otherChange.Attachment != nil ||
+		len(change.Containers) != len(otherChange.Containers) ||
+		len(change.ManagedAgents) != len(otherChange.ManagedAgents) {
+		return false
+	}
+	for i, container := range change.Containers {
+		otherContainer := otherChange.Containers[i]
+		if container.ContainerName != otherContainer.ContainerName || container.Status != otherContainer.Status ||
+			container.Reason != otherContainer.Reason {
+			return false
+		}
+	}
+	for i, managedAgent := range change.ManagedAgents {
+		otherManagedAgent := otherChange.ManagedAgents[i]
+		if managedAgent.Name != otherManagedAgent.Name || managedAgent.Status != otherManagedAgent.Status ||
+			managedAgent.Reason != otherManagedAgent.Reason {
+			return false
+		}
+	}
+	return true
+}
+
+// isStopped returns true if the event is a STOPPED task or container state change
+func (event *sendableEvent) isStopped() bool {
+	event.lock.RLock()
+	defer event.lock.RUnlock()
+
+	if event.isContainerEvent {
+		return event.containerChange.Status == apicontainerstatus.ContainerStopped
+	}
+	return event.taskChange.Status == apitaskstatus.TaskStopped
+}
+
 func (event *sendableEvent) taskArn() string {
 	if event.isContainerEvent {
 		return event.containerChange.TaskArn
@@ -148,13 +220,17 @@ func (event *sendableEvent) send(
 	client api.ECSClient,
 	eventToSubmit *list.Element,
 	dataClient data.Client,
+	metricsFactory metrics.EntryFactory,
 	backoff retry.Backoff,
 	taskEvents *taskSendableEvents) error {
 
 	fields := event.toFields()
 	logger.Info("Sending state change to ECS", fields)
 	// Try submitting the change to ECS
-	if err := sendStatusToECS(client, event); err != nil {
+	start := time.Now()
+	err := sendStatusToECS(client, event)
+	recordSubmitMetrics(metricsFactory, eventType, time.Since(start), err)
+	if err != nil {
 		fields[field.Error] = err
 		logger.Error("Unretriable error sending state change to ECS", fields)
 		return err
@@ -169,6 +245,24 @@ func (event *sendableEvent) send(
 	return nil
 }
 
+// recordSubmitMetrics records the latency and the outcome of submitting a
+// state change of type 'eventType' to ECS
+func recordSubmitMetrics(metricsFactory metrics.EntryFactory, eventType string, latency time.Duration, err error) {
+	outcome := submitOutcomeSuccess
+	if err != nil {
+		outcome = submitOutcomeFailure
+	}
+	metricsFactory.New(metrics.TaskHandlerSubmitLatencyMetricName).
+		WithFields(map[string]interface{}{submitMetricEventTypeField: eventType}).
+		WithGauge(latency).Done(err)()
+	metricsFactory.New(metrics.TaskHandlerSubmitStateChangeMetricName).
+		WithFields(map[string]interface{}{
+			submitMetricEventTypeField: eventType,
+			submitMetricOutcomeField:   outcome,
+		}).
+		WithCount(1).Done(err)()
+}
+
 // sendStatusChangeToECS defines a function type for invoking the appropriate ECS state change API
 type sendStatusChangeToECS func(client api.ECSClient, event *sendableEvent) error
 
@@ -70,7 +70,8 @@ type TaskProtectionClientFactory struct {
 }
 
 // UpdateTaskProtectionHandler returns an HTTP request handler function for
-// UpdateTaskProtection API
+// UpdateTaskProtection API. PUT requests replace the task protection, while PATCH
+// requests merge the fields that are set with the current task protection from ECS.
 func UpdateTaskProtectionHandler(state dockerstate.TaskEngineState, credentialsManager credentials.Manager,
 	factory TaskProtectionClientFactoryInterface, cluster string) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -98,7 +99,17 @@ func UpdateTaskProtectionHandler(state dockerstate.TaskEngineState, credentialsM
 			return
 		}
 
-		if request.ProtectionEnabled == nil {
+		// A PATCH request is a partial update, which only changes the fields that are set in the request
+		partialUpdate := r.Method == http.MethodPatch
+		if partialUpdate {
+			if err := validatePartialTaskProtectionRequest(request); err != nil {
+				writeJSONResponse(w, http.StatusBadRequest,
+					types.NewTaskProtectionResponseError(types.NewErrorResponsePtr(task.Arn, ecs.ErrCodeInvalidParameterException,
+						err.Error()), nil),
+					updateTaskProtectionRequestType)
+				return
+			}
+		} else if request.ProtectionEnabled == nil {
 			writeJSONResponse(w, http.StatusBadRequest,
 				types.NewTaskProtectionResponseError(types.NewErrorResponsePtr(task.Arn, ecs.ErrCodeInvalidParameterException,
 					"Invalid request: does not contain 'ProtectionEnabled' field"), nil),
@@ -106,14 +117,6 @@ func UpdateTaskProtectionHandler(state dockerstate.TaskEngineState, credentialsM
 			return
 		}
 
-		taskProtection := types.NewTaskProtection(*request.ProtectionEnabled, request.ExpiresInMinutes)
-
-		logger.Info("UpdateTaskProtection endpoint was called", logger.Fields{
-			loggerfield.Cluster:        cluster,
-			loggerfield.TaskARN:        task.Arn,
-			loggerfield.TaskProtection: taskProtection,
-		})
-
 		taskRoleCredential, ok := credentialsManager.GetTaskCredentials(task.GetCredentialsID())
 		if !ok {
 			err = fmt.Errorf("Invalid Request: no task IAM role credentials available for task")
@@ -127,6 +130,31 @@ func UpdateTaskProtectionHandler(state dockerstate.TaskEngineState, credentialsM
 		}
 		ecsClient := factory.newTaskProtectionClient(taskRoleCredential)
 
+		protectionEnabled, expiresInMinutes := aws.BoolValue(request.ProtectionEnabled), request.ExpiresInMinutes
+		if partialUpdate {
+			currentProtection, statusCode, response := getCurrentTaskProtection(r.Context(), ecsClient, cluster, task.Arn)
+			if response != nil {
+				writeJSONResponse(w, statusCode, *response, updateTaskProtectionRequestType)
+				return
+			}
+			protectionEnabled, expiresInMinutes, err = mergeTaskProtection(request, currentProtection, time.Now())
+			if err != nil {
+				writeJSONResponse(w, http.StatusBadRequest,
+					types.NewTaskProtectionResponseError(types.NewErrorResponsePtr(task.Arn, ecs.ErrCodeInvalidParameterException,
+						err.Error()), nil),
+					updateTaskProtectionRequestType)
+				return
+			}
+		}
+		taskProtection := types.NewTaskProtection(protectionEnabled, expiresInMinutes)
+
+		logger.Info("UpdateTaskProtection endpoint was called", logger.Fields{
+			loggerfield.Cluster:        cluster,
+			loggerfield.TaskARN:        task.Arn,
+			loggerfield.TaskProtection: taskProtection,
+			"PartialUpdate":            partialUpdate,
+		})
+
 		ctx, cancel := context.WithTimeout(r.Context(), ecsCallTimeout)
 		defer cancel()
 		response, err := ecsClient.UpdateTaskProtectionWithContext(ctx, &ecs.UpdateTaskProtectionInput{
@@ -285,6 +313,84 @@ func GetTaskProtectionHandler(state dockerstate.TaskEngineState, credentialsMana
 	}
 }
 
+// validatePartialTaskProtectionRequest validates a partial UpdateTaskProtection request, which must set
+// at least one field and must not set an expiry while disabling protection.
+func validatePartialTaskProtectionRequest(request TaskProtectionRequest) error {
+	if request.ProtectionEnabled == nil && request.ExpiresInMinutes == nil {
+		return errors.New("Invalid request: does not contain 'ProtectionEnabled' or 'ExpiresInMinutes' field")
+	}
+	if request.ProtectionEnabled != nil && !*request.ProtectionEnabled && request.ExpiresInMinutes != nil {
+		return errors.New("Invalid request: 'ExpiresInMinutes' conflicts with 'ProtectionEnabled' set to false")
+	}
+	return nil
+}
+
+// mergeTaskProtection merges a partial UpdateTaskProtection request with the current protection of the
+// task. Fields that are not set in the request keep their current values, so the remaining time of a
+// current protection is kept if only 'ProtectionEnabled' is set.
+func mergeTaskProtection(request TaskProtectionRequest, current *ecs.ProtectedTask, now time.Time) (bool, *int64, error) {
+	currentEnabled := aws.BoolValue(current.ProtectionEnabled)
+	protectionEnabled := currentEnabled
+	if request.ProtectionEnabled != nil {
+		protectionEnabled = *request.ProtectionEnabled
+	}
+	if request.ExpiresInMinutes != nil {
+		if !protectionEnabled {
+			return false, nil, errors.New(
+				"Invalid request: 'ExpiresInMinutes' cannot be set when task protection is not enabled")
+		}
+		return protectionEnabled, request.ExpiresInMinutes, nil
+	}
+	if protectionEnabled && currentEnabled && current.ExpirationDate != nil {
+		remaining := current.ExpirationDate.Sub(now)
+		if remaining > 0 {
+			// Round up so that the protection doesn't expire earlier than it currently does
+			remainingMinutes := int64((remaining + time.Minute - 1) / time.Minute)
+			return protectionEnabled, &remainingMinutes, nil
+		}
+	}
+	return protectionEnabled, nil, nil
+}
+
+// getCurrentTaskProtection gets the current protection of the task from ECS. If it can't be found, the
+// status code and response to reply to the request with are returned instead.
+func getCurrentTaskProtection(ctx context.Context, ecsClient api.ECSTaskProtectionSDK, cluster string,
+	taskARN string) (*ecs.ProtectedTask, int, *types.TaskProtectionResponse) {
+	ctx, cancel := context.WithTimeout(ctx, ecsCallTimeout)
+	defer cancel()
+	response, err := ecsClient.GetTaskProtectionWithContext(ctx, &ecs.GetTaskProtectionInput{
+		Cluster: aws.String(cluster),
+		Tasks:   aws.StringSlice([]string{taskARN}),
+	})
+	if err != nil {
+		errorCode, errorMsg, statusCode, reqId := getErrorCodeAndStatusCode(err)
+		logger.Error("Got an exception when calling GetTaskProtection for a partial update.", logger.Fields{
+			loggerfield.Error:  err,
+			"ErrorCode":        errorCode,
+			"ExceptionMessage": errorMsg,
+			"StatusCode":       statusCode,
+			"RequestId":        aws.StringValue(reqId),
+		})
+		errResponse := types.NewTaskProtectionResponseError(types.NewErrorResponsePtr(taskARN, errorCode, errorMsg), reqId)
+		return nil, statusCode, &errResponse
+	}
+	if len(response.Failures) == 1 && len(response.ProtectedTasks) == 0 {
+		failureResponse := types.NewTaskProtectionResponseFailure(response.Failures[0])
+		return nil, http.StatusOK, &failureResponse
+	}
+	if len(response.Failures) > 0 || len(response.ProtectedTasks) != ExpectedProtectionResponseLength {
+		logger.Error("Unexpected GetTaskProtection response for a partial update", logger.Fields{
+			loggerfield.TaskARN:        taskARN,
+			loggerfield.TaskProtection: response.ProtectedTasks,
+			loggerfield.Reason:         response.Failures,
+		})
+		errResponse := types.NewTaskProtectionResponseError(
+			types.NewErrorResponsePtr(taskARN, ecs.ErrCodeServerException, "Unexpected error occurred"), nil)
+		return nil, http.StatusInternalServerError, &errResponse
+	}
+	return response.ProtectedTasks[0], http.StatusOK, nil
+}
+
 // Helper function for retrieving credential from credentials manager and create ecs client
 func (factory TaskProtectionClientFactory) newTaskProtectionClient(taskRoleCredential credentials.TaskIAMRoleCredentials) api.ECSTaskProtectionSDK {
 	taskCredential := taskRoleCredential.GetIAMRoleCredentials()
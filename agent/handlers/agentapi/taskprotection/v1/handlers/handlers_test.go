@@ -107,13 +107,21 @@ func getRequestWithUnknownFields(t *testing.T) map[string]interface{} {
 
 // Helper function for running tests for UpdateTaskProtection handler
 func testUpdateTaskProtectionHandler(t *testing.T, state dockerstate.TaskEngineState,
+	v3EndpointID string, credentialsManager credentials.Manager, factory TaskProtectionClientFactoryInterface,
+	request interface{}, expectedResponse interface{}, expectedResponseCode int) {
+	testUpdateTaskProtectionHandlerWithMethod(t, "PUT", state, v3EndpointID, credentialsManager, factory,
+		request, expectedResponse, expectedResponseCode)
+}
+
+// Helper function for running tests for UpdateTaskProtection handler with the given request method
+func testUpdateTaskProtectionHandlerWithMethod(t *testing.T, method string, state dockerstate.TaskEngineState,
 	v3EndpointID string, credentialsManager credentials.Manager, factory TaskProtectionClientFactoryInterface,
 	request interface{}, expectedResponse interface{}, expectedResponseCode int) {
 	// Prepare request
 	requestBytes, err := json.Marshal(request)
 	assert.NoError(t, err)
 	bodyReader := bytes.NewReader(requestBytes)
-	req, err := http.NewRequest("PUT", "", bodyReader)
+	req, err := http.NewRequest(method, "", bodyReader)
 	assert.NoError(t, err)
 	req = mux.SetURLVars(req, map[string]string{v3.V3EndpointIDMuxName: v3EndpointID})
 
@@ -422,6 +430,210 @@ func TestUpdateTaskProtectionHandler_PostCall(t *testing.T) {
 	}
 }
 
+// TestPartialUpdateTaskProtectionHandler_InputValidations tests UpdateTaskProtection handler's
+// behavior with invalid partial update requests, which are rejected without calling ECS
+func TestPartialUpdateTaskProtectionHandler_InputValidations(t *testing.T) {
+	testCases := []struct {
+		name          string
+		request       TaskProtectionRequest
+		expectedError *types.ErrorResponse
+	}{
+		{
+			name:    "EmptyRequest",
+			request: TaskProtectionRequest{},
+			expectedError: &types.ErrorResponse{Arn: testTaskArn, Code: ecs.ErrCodeInvalidParameterException,
+				Message: "Invalid request: does not contain 'ProtectionEnabled' or 'ExpiresInMinutes' field"},
+		},
+		{
+			name: "ConflictingFields",
+			request: TaskProtectionRequest{
+				ProtectionEnabled: utils.BoolPtr(false),
+				ExpiresInMinutes:  utils.Int64Ptr(testExpiresInMinutes),
+			},
+			expectedError: &types.ErrorResponse{Arn: testTaskArn, Code: ecs.ErrCodeInvalidParameterException,
+				Message: "Invalid request: 'ExpiresInMinutes' conflicts with 'ProtectionEnabled' set to false"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			testTask := task.Task{
+				Arn:         testTaskArn,
+				ServiceName: testServiceName,
+			}
+			mockState := mock_dockerstate.NewMockTaskEngineState(ctrl)
+			mockState.EXPECT().TaskARNByV3EndpointID(gomock.Eq(testV3EndpointId)).Return(testTaskArn, true)
+			mockState.EXPECT().TaskByArn(gomock.Eq(testTaskArn)).Return(&testTask, true)
+
+			expectedResponse := types.TaskProtectionResponse{Error: tc.expectedError}
+			testUpdateTaskProtectionHandlerWithMethod(t, "PATCH", mockState, testV3EndpointId, nil, nil,
+				tc.request, expectedResponse, http.StatusBadRequest)
+		})
+	}
+}
+
+// TestPartialUpdateTaskProtectionHandler tests that UpdateTaskProtection handler merges partial
+// update requests with the current task protection from ECS
+func TestPartialUpdateTaskProtectionHandler(t *testing.T) {
+	expirationDate := time.Now().Add(30 * time.Minute)
+	testCases := []struct {
+		name                string
+		request             TaskProtectionRequest
+		currentProtection   *ecs.ProtectedTask
+		expectedUpdateInput *ecs.UpdateTaskProtectionInput
+		expectedError       *types.ErrorResponse
+		expectedStatusCode  int
+	}{
+		{
+			name:    "ExtendOnly",
+			request: TaskProtectionRequest{ExpiresInMinutes: utils.Int64Ptr(60)},
+			currentProtection: &ecs.ProtectedTask{
+				ProtectionEnabled: aws.Bool(true),
+				ExpirationDate:    aws.Time(expirationDate),
+				TaskArn:           aws.String(testTaskArn),
+			},
+			expectedUpdateInput: &ecs.UpdateTaskProtectionInput{
+				Cluster:           aws.String(testCluster),
+				ExpiresInMinutes:  aws.Int64(60),
+				ProtectionEnabled: aws.Bool(true),
+				Tasks:             aws.StringSlice([]string{testTaskArn}),
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:    "ExtendOnlyNotProtected",
+			request: TaskProtectionRequest{ExpiresInMinutes: utils.Int64Ptr(60)},
+			currentProtection: &ecs.ProtectedTask{
+				ProtectionEnabled: aws.Bool(false),
+				TaskArn:           aws.String(testTaskArn),
+			},
+			expectedError: &types.ErrorResponse{Arn: testTaskArn, Code: ecs.ErrCodeInvalidParameterException,
+				Message: "Invalid request: 'ExpiresInMinutes' cannot be set when task protection is not enabled"},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:    "EnableOnly",
+			request: TaskProtectionRequest{ProtectionEnabled: utils.BoolPtr(true)},
+			currentProtection: &ecs.ProtectedTask{
+				ProtectionEnabled: aws.Bool(false),
+				TaskArn:           aws.String(testTaskArn),
+			},
+			expectedUpdateInput: &ecs.UpdateTaskProtectionInput{
+				Cluster:           aws.String(testCluster),
+				ProtectionEnabled: aws.Bool(true),
+				Tasks:             aws.StringSlice([]string{testTaskArn}),
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:    "EnableOnlyAlreadyProtected",
+			request: TaskProtectionRequest{ProtectionEnabled: utils.BoolPtr(true)},
+			currentProtection: &ecs.ProtectedTask{
+				ProtectionEnabled: aws.Bool(true),
+				ExpirationDate:    aws.Time(expirationDate),
+				TaskArn:           aws.String(testTaskArn),
+			},
+			expectedUpdateInput: &ecs.UpdateTaskProtectionInput{
+				Cluster:           aws.String(testCluster),
+				ExpiresInMinutes:  aws.Int64(30),
+				ProtectionEnabled: aws.Bool(true),
+				Tasks:             aws.StringSlice([]string{testTaskArn}),
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			testTask := task.Task{
+				Arn:         testTaskArn,
+				ServiceName: testServiceName,
+			}
+			testTask.SetCredentialsID(testTaskCredentialsId)
+
+			mockState := mock_dockerstate.NewMockTaskEngineState(ctrl)
+			mockManager := mock_credentials.NewMockManager(ctrl)
+			mockFactory := NewMockTaskProtectionClientFactoryInterface(ctrl)
+			mockECSClient := mock_api.NewMockECSTaskProtectionSDK(ctrl)
+
+			mockState.EXPECT().TaskARNByV3EndpointID(gomock.Eq(testV3EndpointId)).Return(testTaskArn, true)
+			mockState.EXPECT().TaskByArn(gomock.Eq(testTaskArn)).Return(&testTask, true)
+			mockManager.EXPECT().GetTaskCredentials(gomock.Eq(testTaskCredentialsId)).Return(credentials.TaskIAMRoleCredentials{}, true)
+			mockFactory.EXPECT().newTaskProtectionClient(gomock.Eq(credentials.TaskIAMRoleCredentials{})).Return(mockECSClient)
+			mockECSClient.EXPECT().
+				GetTaskProtectionWithContext(gomock.Any(), gomock.Eq(&ecs.GetTaskProtectionInput{
+					Cluster: aws.String(testCluster),
+					Tasks:   aws.StringSlice([]string{testTaskArn}),
+				})).
+				Return(&ecs.GetTaskProtectionOutput{
+					Failures:       []*ecs.Failure{},
+					ProtectedTasks: []*ecs.ProtectedTask{tc.currentProtection},
+				}, nil)
+
+			expectedResponse := types.TaskProtectionResponse{Error: tc.expectedError}
+			if tc.expectedUpdateInput != nil {
+				updatedProtection := &ecs.ProtectedTask{
+					ProtectionEnabled: tc.expectedUpdateInput.ProtectionEnabled,
+					TaskArn:           aws.String(testTaskArn),
+				}
+				mockECSClient.EXPECT().
+					UpdateTaskProtectionWithContext(gomock.Any(), gomock.Eq(tc.expectedUpdateInput)).
+					Return(&ecs.UpdateTaskProtectionOutput{
+						Failures:       []*ecs.Failure{},
+						ProtectedTasks: []*ecs.ProtectedTask{updatedProtection},
+					}, nil)
+				expectedResponse = types.TaskProtectionResponse{Protection: updatedProtection}
+			}
+
+			testUpdateTaskProtectionHandlerWithMethod(t, "PATCH", mockState, testV3EndpointId, mockManager, mockFactory,
+				tc.request, expectedResponse, tc.expectedStatusCode)
+		})
+	}
+}
+
+// TestPartialUpdateTaskProtectionHandler_GetFailure tests that UpdateTaskProtection handler replies to
+// a partial update request with the failure from ECS if the current task protection can't be found
+func TestPartialUpdateTaskProtectionHandler_GetFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testTask := task.Task{
+		Arn:         testTaskArn,
+		ServiceName: testServiceName,
+	}
+	testTask.SetCredentialsID(testTaskCredentialsId)
+
+	mockState := mock_dockerstate.NewMockTaskEngineState(ctrl)
+	mockManager := mock_credentials.NewMockManager(ctrl)
+	mockFactory := NewMockTaskProtectionClientFactoryInterface(ctrl)
+	mockECSClient := mock_api.NewMockECSTaskProtectionSDK(ctrl)
+
+	failure := &ecs.Failure{
+		Arn:    aws.String(testTaskArn),
+		Reason: aws.String(testFailureReason),
+	}
+	mockState.EXPECT().TaskARNByV3EndpointID(gomock.Eq(testV3EndpointId)).Return(testTaskArn, true)
+	mockState.EXPECT().TaskByArn(gomock.Eq(testTaskArn)).Return(&testTask, true)
+	mockManager.EXPECT().GetTaskCredentials(gomock.Eq(testTaskCredentialsId)).Return(credentials.TaskIAMRoleCredentials{}, true)
+	mockFactory.EXPECT().newTaskProtectionClient(gomock.Eq(credentials.TaskIAMRoleCredentials{})).Return(mockECSClient)
+	mockECSClient.EXPECT().
+		GetTaskProtectionWithContext(gomock.Any(), gomock.Any()).
+		Return(&ecs.GetTaskProtectionOutput{
+			Failures:       []*ecs.Failure{failure},
+			ProtectedTasks: []*ecs.ProtectedTask{},
+		}, nil)
+
+	request := TaskProtectionRequest{ExpiresInMinutes: utils.Int64Ptr(60)}
+	expectedResponse := types.TaskProtectionResponse{Failure: failure}
+	testUpdateTaskProtectionHandlerWithMethod(t, "PATCH", mockState, testV3EndpointId, mockManager, mockFactory,
+		request, expectedResponse, http.StatusOK)
+}
+
 func testGetTaskProtectionHandler(t *testing.T, state dockerstate.TaskEngineState,
 	v3EndpointID string, credentialsManager credentials.Manager, factory TaskProtectionClientFactoryInterface, expectedResponse interface{}, expectedResponseCode int) {
 	// Prepare request
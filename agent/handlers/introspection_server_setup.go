@@ -56,9 +56,34 @@ var (
 	pprofTraceHandler   = pprof.Trace
 )
 
-func introspectionServerSetup(containerInstanceArn *string, taskEngine handlersutils.DockerStateResolver, cfg *config.Config) *http.Server {
+func introspectionServerSetup(containerInstanceArn *string, taskEngine handlersutils.DockerStateResolver,
+	acsProcessingPauser v1.ACSProcessingPauser, stateChangeDeadLetters v1.StateChangeDeadLetters,
+	taskStateReconciler v1.TaskStateReconciler, drainState v1.ContainerInstanceDrainState,
+	cfg *config.Config) *http.Server {
+	if cfg.DisableIntrospectionEndpoint.Enabled() {
+		// Serve nothing, so that every introspection path is not found.
+		return &http.Server{
+			Addr:         ":" + strconv.Itoa(config.AgentIntrospectionPort),
+			Handler:      http.NotFoundHandler(),
+			ReadTimeout:  readTimeout,
+			WriteTimeout: writeTimeout,
+		}
+	}
+
 	paths := []string{v1.AgentMetadataPath, v1.TaskContainerMetadataPath, v1.LicensePath}
 
+	if cfg.EnableACSProcessingPauseEndpoint.Enabled() {
+		paths = append(paths, v1.ACSProcessingPath)
+	}
+
+	if stateChangeDeadLetters != nil {
+		paths = append(paths, v1.StateChangeDeadLettersPath)
+	}
+
+	if taskStateReconciler != nil {
+		paths = append(paths, v1.TaskStateReconciliationPath)
+	}
+
 	if cfg.EnableRuntimeStats.Enabled() {
 		paths = append(paths, pprofBasePath, pprofCMDLinePath, pprofProfilePath, pprofSymbolPath, pprofTracePath)
 	}
@@ -77,7 +102,8 @@ func introspectionServerSetup(containerInstanceArn *string, taskEngine handlersu
 	serverMux := http.NewServeMux()
 	serverMux.HandleFunc("/", defaultHandler)
 
-	v1HandlersSetup(serverMux, containerInstanceArn, taskEngine, cfg)
+	v1HandlersSetup(serverMux, containerInstanceArn, taskEngine, acsProcessingPauser, stateChangeDeadLetters,
+		taskStateReconciler, drainState, cfg)
 	pprofHandlerSetup(serverMux, cfg)
 
 	// Log all requests and then pass through to serverMux
@@ -102,10 +128,23 @@ func introspectionServerSetup(containerInstanceArn *string, taskEngine handlersu
 func v1HandlersSetup(serverMux *http.ServeMux,
 	containerInstanceArn *string,
 	taskEngine handlersutils.DockerStateResolver,
+	acsProcessingPauser v1.ACSProcessingPauser,
+	stateChangeDeadLetters v1.StateChangeDeadLetters,
+	taskStateReconciler v1.TaskStateReconciler,
+	drainState v1.ContainerInstanceDrainState,
 	cfg *config.Config) {
-	serverMux.HandleFunc(v1.AgentMetadataPath, v1.AgentMetadataHandler(containerInstanceArn, cfg))
+	serverMux.HandleFunc(v1.AgentMetadataPath, v1.AgentMetadataHandler(containerInstanceArn, drainState, cfg))
 	serverMux.HandleFunc(v1.TaskContainerMetadataPath, v1.TaskContainerMetadataHandler(taskEngine))
 	serverMux.HandleFunc(v1.LicensePath, v1.LicenseHandler)
+	if cfg.EnableACSProcessingPauseEndpoint.Enabled() {
+		serverMux.HandleFunc(v1.ACSProcessingPath, v1.ACSProcessingHandler(acsProcessingPauser))
+	}
+	if stateChangeDeadLetters != nil {
+		serverMux.HandleFunc(v1.StateChangeDeadLettersPath, v1.StateChangeDeadLettersHandler(stateChangeDeadLetters))
+	}
+	if taskStateReconciler != nil {
+		serverMux.HandleFunc(v1.TaskStateReconciliationPath, v1.TaskStateReconciliationHandler(taskStateReconciler))
+	}
 }
 
 func pprofHandlerSetup(serverMux *http.ServeMux, cfg *config.Config) {
@@ -122,12 +161,20 @@ func pprofHandlerSetup(serverMux *http.ServeMux, cfg *config.Config) {
 // ServeIntrospectionHTTPEndpoint serves information about this agent/containerInstance and tasks
 // running on it. "V1" here indicates the hostname version of this server instead
 // of the handler versions, i.e. "V1" server can include "V1" and "V2" handlers.
-func ServeIntrospectionHTTPEndpoint(ctx context.Context, containerInstanceArn *string, taskEngine engine.TaskEngine, cfg *config.Config) {
+func ServeIntrospectionHTTPEndpoint(ctx context.Context, containerInstanceArn *string, taskEngine engine.TaskEngine,
+	acsProcessingPauser v1.ACSProcessingPauser, stateChangeDeadLetters v1.StateChangeDeadLetters,
+	drainState v1.ContainerInstanceDrainState, cfg *config.Config) {
+	if cfg.DisableIntrospectionEndpoint.Enabled() {
+		seelog.Info("Agent introspection endpoint is disabled, not serving it")
+		return
+	}
+
 	// Is this the right level to type assert, assuming we'd abstract multiple taskengines here?
 	// Revisit if we ever add another type..
 	dockerTaskEngine := taskEngine.(*engine.DockerTaskEngine)
 
-	server := introspectionServerSetup(containerInstanceArn, dockerTaskEngine, cfg)
+	server := introspectionServerSetup(containerInstanceArn, dockerTaskEngine, acsProcessingPauser,
+		stateChangeDeadLetters, dockerTaskEngine, drainState, cfg)
 
 	go func() {
 		<-ctx.Done()
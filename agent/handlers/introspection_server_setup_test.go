@@ -47,7 +47,7 @@ const (
 var runtimeStatsConfigForTest = config.BooleanDefaultFalse{}
 
 func TestMetadataHandler(t *testing.T) {
-	metadataHandler := v1.AgentMetadataHandler(utils.Strptr(testContainerInstanceArn), &config.Config{Cluster: testClusterArn})
+	metadataHandler := v1.AgentMetadataHandler(utils.Strptr(testContainerInstanceArn), nil, &config.Config{Cluster: testClusterArn})
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "http://localhost:"+strconv.Itoa(config.AgentIntrospectionPort), nil)
@@ -64,6 +64,28 @@ func TestMetadataHandler(t *testing.T) {
 	}
 }
 
+// drainState is a container instance drain state for tests.
+type drainState bool
+
+func (state drainState) Draining() bool {
+	return bool(state)
+}
+
+func TestMetadataHandlerDraining(t *testing.T) {
+	for _, draining := range []bool{true, false} {
+		metadataHandler := v1.AgentMetadataHandler(utils.Strptr(testContainerInstanceArn), drainState(draining),
+			&config.Config{Cluster: testClusterArn})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "http://localhost:"+strconv.Itoa(config.AgentIntrospectionPort), nil)
+		metadataHandler(w, req)
+
+		var resp v1.MetadataResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, draining, resp.Draining)
+	}
+}
+
 func TestListMultipleTasks(t *testing.T) {
 	recorder := performMockRequest(t, "/v1/tasks")
 
@@ -481,7 +503,7 @@ func performMockRequest(t *testing.T, path string) *httptest.ResponseRecorder {
 		mockStateResolver.EXPECT().State().Return(state)
 	}
 
-	requestHandler := introspectionServerSetup(utils.Strptr(testContainerInstanceArn), mockStateResolver, &config.Config{
+	requestHandler := introspectionServerSetup(utils.Strptr(testContainerInstanceArn), mockStateResolver, nil, nil, nil, nil, &config.Config{
 		Cluster:            testClusterArn,
 		EnableRuntimeStats: runtimeStatsConfigForTest,
 	})
@@ -492,3 +514,24 @@ func performMockRequest(t *testing.T, path string) *httptest.ResponseRecorder {
 
 	return recorder
 }
+
+func TestIntrospectionEndpointDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockStateResolver := mock_utils.NewMockDockerStateResolver(ctrl)
+
+	server := introspectionServerSetup(utils.Strptr(testContainerInstanceArn), mockStateResolver, nil, nil, nil, nil, &config.Config{
+		Cluster:                      testClusterArn,
+		EnableRuntimeStats:           config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled},
+		DisableIntrospectionEndpoint: config.BooleanDefaultFalse{Value: config.ExplicitlyEnabled},
+	})
+
+	for _, path := range []string{"/", v1.AgentMetadataPath, v1.TaskContainerMetadataPath, v1.LicensePath, pprofBasePath} {
+		t.Run(path, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path, nil)
+			server.Handler.ServeHTTP(recorder, req)
+			assert.Equal(t, http.StatusNotFound, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"sync"
+
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	v3 "github.com/aws/amazon-ecs-agent/agent/handlers/v3"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// maxRequestMetricTaskLabels is the maximum number of task ARNs that task metadata request
+	// metrics are labeled with. Requests from other tasks are labeled with otherTaskLabel.
+	maxRequestMetricTaskLabels = 64
+	// otherTaskLabel labels requests from tasks beyond maxRequestMetricTaskLabels.
+	otherTaskLabel = "other"
+	// unknownTaskLabel labels requests that can't be attributed to a task, such as requests
+	// to endpoints that are not task scoped.
+	unknownTaskLabel = "unknown"
+	// unknownEndpointLabel labels requests that didn't match a route.
+	unknownEndpointLabel = "unknown"
+)
+
+// muxVarPattern matches the variables of a route path template, along with their patterns.
+var muxVarPattern = regexp.MustCompile(`\{([^:}]+):[^}]*\}`)
+
+// requestMetrics returns a middleware that records the count and latency of task metadata
+// requests, labeled by the endpoint requested and the task making the request.
+func requestMetrics(state dockerstate.TaskEngineState, metricsFactory metrics.EntryFactory) mux.MiddlewareFunc {
+	taskLabels := newRequestMetricTaskLabels(state, maxRequestMetricTaskLabels)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			entry := metricsFactory.New(metrics.TaskMetadataRequestMetricName)
+			next.ServeHTTP(w, r)
+			entry.WithFields(map[string]interface{}{
+				"endpoint": requestEndpointLabel(r),
+				"task":     taskLabels.label(r),
+			}).WithCount(1).Done(nil)()
+		})
+	}
+}
+
+// requestEndpointLabel returns the path template of the route matched by the request, with the
+// patterns of its variables removed, so that the label does not depend on their values.
+func requestEndpointLabel(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return unknownEndpointLabel
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return unknownEndpointLabel
+	}
+	return muxVarPattern.ReplaceAllString(template, "{$1}")
+}
+
+// requestMetricTaskLabels bounds the number of distinct task ARNs that request metrics are labeled
+// with, to keep the cardinality of the metrics bounded as tasks come and go.
+type requestMetricTaskLabels struct {
+	state     dockerstate.TaskEngineState
+	maxLabels int
+	lock      sync.Mutex
+	taskARNs  map[string]struct{}
+}
+
+func newRequestMetricTaskLabels(state dockerstate.TaskEngineState, maxLabels int) *requestMetricTaskLabels {
+	return &requestMetricTaskLabels{
+		state:     state,
+		maxLabels: maxLabels,
+		taskARNs:  make(map[string]struct{}),
+	}
+}
+
+// label returns the task label of the request. Once maxLabels task ARNs are in use, the ARNs
+// of tasks that are no longer known to the agent are dropped to make room for new ones, and
+// requests from new tasks are labeled with otherTaskLabel if none can be dropped.
+func (l *requestMetricTaskLabels) label(r *http.Request) string {
+	taskARN, err := v3.GetTaskARNByRequest(r, l.state)
+	if err != nil {
+		return unknownTaskLabel
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if _, ok := l.taskARNs[taskARN]; ok {
+		return taskARN
+	}
+	if len(l.taskARNs) >= l.maxLabels {
+		for arn := range l.taskARNs {
+			if _, ok := l.state.TaskByArn(arn); !ok {
+				delete(l.taskARNs, arn)
+			}
+		}
+	}
+	if len(l.taskARNs) >= l.maxLabels {
+		return otherTaskLabel
+	}
+	l.taskARNs[taskARN] = struct{}{}
+	return taskARN
+}
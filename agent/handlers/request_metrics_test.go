@@ -0,0 +1,63 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
+	mock_dockerstate "github.com/aws/amazon-ecs-agent/agent/engine/dockerstate/mocks"
+	v3 "github.com/aws/amazon-ecs-agent/agent/handlers/v3"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestMetricTaskLabels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	state := mock_dockerstate.NewMockTaskEngineState(ctrl)
+	state.EXPECT().TaskARNByV3EndpointID("endpoint-1").Return("task-1", true).AnyTimes()
+	state.EXPECT().TaskARNByV3EndpointID("endpoint-2").Return("task-2", true).AnyTimes()
+	state.EXPECT().TaskARNByV3EndpointID("endpoint-3").Return("task-3", true).AnyTimes()
+
+	newRequest := func(endpointID string) *http.Request {
+		req, _ := http.NewRequest("GET", "/v4/"+endpointID, nil)
+		return mux.SetURLVars(req, map[string]string{v3.V3EndpointIDMuxName: endpointID})
+	}
+	labels := newRequestMetricTaskLabels(state, 2)
+
+	assert.Equal(t, "task-1", labels.label(newRequest("endpoint-1")))
+	assert.Equal(t, "task-2", labels.label(newRequest("endpoint-2")))
+	assert.Equal(t, "task-1", labels.label(newRequest("endpoint-1")))
+
+	// Both labeled tasks are still running, so a third one is bucketed
+	state.EXPECT().TaskByArn("task-1").Return(&apitask.Task{}, true)
+	state.EXPECT().TaskByArn("task-2").Return(&apitask.Task{}, true)
+	assert.Equal(t, otherTaskLabel, labels.label(newRequest("endpoint-3")))
+
+	// Once a labeled task is gone, its label is reused
+	state.EXPECT().TaskByArn("task-1").Return(nil, false)
+	state.EXPECT().TaskByArn("task-2").Return(&apitask.Task{}, true)
+	assert.Equal(t, "task-3", labels.label(newRequest("endpoint-3")))
+
+	// Requests that are not task scoped can't be attributed to a task
+	req, _ := http.NewRequest("GET", "/v2/metadata", nil)
+	assert.Equal(t, unknownTaskLabel, labels.label(req))
+}
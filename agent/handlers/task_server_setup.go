@@ -15,7 +15,9 @@ package handlers
 
 import (
 	"context"
+	"net"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/aws/amazon-ecs-agent/agent/api"
@@ -31,12 +33,15 @@ import (
 	auditinterface "github.com/aws/amazon-ecs-agent/ecs-agent/logger/audit"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/tmds"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/utils"
 	tmdsv1 "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/v1"
 	tmdsv2 "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/v2"
 	tmdsv4 "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/v4"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/utils/retry"
 	"github.com/cihub/seelog"
+	cnitypes "github.com/containernetworking/cni/pkg/types"
 	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
 )
 
 const (
@@ -47,6 +52,13 @@ const (
 	// writeTimeout specifies the maximum duration before timing out write of the response.
 	// The value is set to 5 seconds as per AWS SDK defaults.
 	writeTimeout = 5 * time.Second
+
+	// requestTypeAgentAPI specifies the request type of the agent API handlers.
+	requestTypeAgentAPI = "agent api"
+
+	// taskMetadataUnixSocketMode is the file mode of the task metadata unix socket. Access
+	// is limited to the owner and group of the socket file.
+	taskMetadataUnixSocketMode = 0660
 )
 
 func taskServerSetup(credentialsManager credentials.Manager,
@@ -62,7 +74,11 @@ func taskServerSetup(credentialsManager credentials.Manager,
 	vpcID string,
 	containerInstanceArn string,
 	apiEndpoint string,
-	acceptInsecureCert bool) (*http.Server, error) {
+	acceptInsecureCert bool,
+	agentAPIAllowedSourceCIDRs []cnitypes.IPNet,
+	includeCgroupPath bool,
+	nanosecondTimestamps bool,
+	metricsFactory metrics.EntryFactory) (*http.Server, error) {
 
 	muxRouter := mux.NewRouter()
 
@@ -70,6 +86,13 @@ func taskServerSetup(credentialsManager credentials.Manager,
 	// to permanently redirect(301) to "/v3/metadata/task" handler
 	muxRouter.SkipClean(false)
 
+	// Requests are not recorded when no metrics factory is set
+	if metricsFactory != nil {
+		muxRouter.Use(requestMetrics(state, metricsFactory))
+	} else {
+		metricsFactory = metrics.NewNopEntryFactory()
+	}
+
 	muxRouter.HandleFunc(tmdsv1.CredentialsPath,
 		tmdsv1.CredentialsHandler(credentialsManager, auditLogger))
 
@@ -77,9 +100,11 @@ func taskServerSetup(credentialsManager credentials.Manager,
 
 	v3HandlersSetup(muxRouter, state, ecsClient, statsEngine, cluster, availabilityZone, containerInstanceArn)
 
-	v4HandlersSetup(muxRouter, state, ecsClient, statsEngine, cluster, availabilityZone, vpcID, containerInstanceArn)
+	v4HandlersSetup(muxRouter, state, ecsClient, statsEngine, credentialsManager, cluster, availabilityZone, vpcID, containerInstanceArn,
+		includeCgroupPath, nanosecondTimestamps, steadyStateRate, burstRate, metricsFactory)
 
-	agentAPIV1HandlersSetup(muxRouter, state, credentialsManager, cluster, region, apiEndpoint, acceptInsecureCert)
+	agentAPIV1HandlersSetup(muxRouter, state, credentialsManager, cluster, region, apiEndpoint, acceptInsecureCert,
+		agentAPIAllowedSourceCIDRs)
 
 	return tmds.NewServer(auditLogger,
 		tmds.WithHandler(muxRouter),
@@ -134,40 +159,100 @@ func v4HandlersSetup(muxRouter *mux.Router,
 	state dockerstate.TaskEngineState,
 	ecsClient api.ECSClient,
 	statsEngine stats.Engine,
+	credentialsManager credentials.Manager,
 	cluster string,
 	availabilityZone string,
 	vpcID string,
 	containerInstanceArn string,
+	includeCgroupPath bool,
+	nanosecondTimestamps bool,
+	steadyStateRate int,
+	burstRate int,
+	metricsFactory metrics.EntryFactory,
 ) {
-	tmdsAgentState := v4.NewTMDSAgentState(state)
-	metricsFactory := metrics.NewNopEntryFactory()
+	tmdsAgentState := v4.NewTMDSAgentState(state, includeCgroupPath, nanosecondTimestamps)
+	// The self and limits paths have to be registered first as the container metadata path matches them too.
+	muxRouter.HandleFunc(tmdsv4.SelfContainerMetadataPath(), tmdsv4.SelfContainerMetadataHandler(tmdsAgentState, metricsFactory))
+	muxRouter.HandleFunc(v4.LimitsPath, v4.LimitsHandler(float64(steadyStateRate), burstRate))
 	muxRouter.HandleFunc(tmdsv4.ContainerMetadataPath(), tmdsv4.ContainerMetadataHandler(tmdsAgentState, metricsFactory))
-	muxRouter.HandleFunc(v4.TaskMetadataPath, v4.TaskMetadataHandler(state, ecsClient, cluster, availabilityZone, vpcID, containerInstanceArn, false))
-	muxRouter.HandleFunc(v4.TaskWithTagsMetadataPath, v4.TaskMetadataHandler(state, ecsClient, cluster, availabilityZone, vpcID, containerInstanceArn, true))
+	muxRouter.HandleFunc(v4.TaskMetadataPath, v4.TaskMetadataHandler(state, ecsClient, credentialsManager, cluster, availabilityZone, vpcID, containerInstanceArn, false, includeCgroupPath, nanosecondTimestamps))
+	muxRouter.HandleFunc(v4.TaskWithTagsMetadataPath, v4.TaskMetadataHandler(state, ecsClient, credentialsManager, cluster, availabilityZone, vpcID, containerInstanceArn, true, includeCgroupPath, nanosecondTimestamps))
+	muxRouter.HandleFunc(v4.TaskVolumesPath, v4.TaskVolumesHandler(state))
 	muxRouter.HandleFunc(v4.ContainerStatsPath, v4.ContainerStatsHandler(state, statsEngine))
 	muxRouter.HandleFunc(v4.TaskStatsPath, v4.TaskStatsHandler(state, statsEngine))
 	muxRouter.HandleFunc(v4.ContainerAssociationsPath, v4.ContainerAssociationsHandler(state))
 	muxRouter.HandleFunc(v4.ContainerAssociationPathWithSlash, v4.ContainerAssociationHandler(state))
 	muxRouter.HandleFunc(v4.ContainerAssociationPath, v4.ContainerAssociationHandler(state))
+	muxRouter.HandleFunc(v4.AgentVersionPath, v4.AgentVersionHandler())
 }
 
 // agentAPIV1HandlersSetup adds handlers for Agent API V1
-func agentAPIV1HandlersSetup(muxRouter *mux.Router, state dockerstate.TaskEngineState, credentialsManager credentials.Manager, cluster string, region string, endpoint string, acceptInsecureCert bool) {
+func agentAPIV1HandlersSetup(muxRouter *mux.Router, state dockerstate.TaskEngineState, credentialsManager credentials.Manager, cluster string, region string, endpoint string, acceptInsecureCert bool, allowedSourceCIDRs []cnitypes.IPNet) {
 	factory := agentAPITaskProtectionV1.TaskProtectionClientFactory{
 		Region: region, Endpoint: endpoint, AcceptInsecureCert: acceptInsecureCert,
 	}
 	muxRouter.
 		HandleFunc(
 			agentAPITaskProtectionV1.TaskProtectionPath(),
-			agentAPITaskProtectionV1.UpdateTaskProtectionHandler(state, credentialsManager, factory, cluster)).
-		Methods("PUT")
+			sourceCIDRFilter(allowedSourceCIDRs,
+				agentAPITaskProtectionV1.UpdateTaskProtectionHandler(state, credentialsManager, factory, cluster))).
+		Methods("PUT", "PATCH")
 	muxRouter.
 		HandleFunc(
 			agentAPITaskProtectionV1.TaskProtectionPath(),
-			agentAPITaskProtectionV1.GetTaskProtectionHandler(state, credentialsManager, factory, cluster)).
+			sourceCIDRFilter(allowedSourceCIDRs,
+				agentAPITaskProtectionV1.GetTaskProtectionHandler(state, credentialsManager, factory, cluster))).
 		Methods("GET")
 }
 
+// sourceCIDRFilter wraps the handler so that requests whose source address is not within one of
+// the allowed CIDRs are rejected with a 403. All requests are passed through if no CIDRs are set.
+// Requests received on a unix socket have no source IP address and are always passed through, as
+// access to the socket is restricted by its file mode.
+func sourceCIDRFilter(allowedCIDRs []cnitypes.IPNet, next http.HandlerFunc) http.HandlerFunc {
+	if len(allowedCIDRs) == 0 {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isUnixSocketRequest(r) && !isSourceAllowed(r.RemoteAddr, allowedCIDRs) {
+			seelog.Warnf("Rejecting agent API request for %s from disallowed source address %s",
+				r.URL.Path, r.RemoteAddr)
+			utils.WriteJSONResponse(w, http.StatusForbidden, utils.ErrorMessage{
+				Code:          "AccessDeniedException",
+				Message:       "Source address is not allowed to access the agent API",
+				HTTPErrorCode: http.StatusForbidden,
+			}, requestTypeAgentAPI)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// isUnixSocketRequest returns true if the request was received on a unix socket listener.
+func isUnixSocketRequest(r *http.Request) bool {
+	localAddr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return ok && localAddr.Network() == "unix"
+}
+
+// isSourceAllowed returns true if the host portion of remoteAddr is within one of the allowed CIDRs.
+func isSourceAllowed(remoteAddr string, allowedCIDRs []cnitypes.IPNet) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range allowedCIDRs {
+		ipNet := net.IPNet(cidr)
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 // ServeTaskHTTPEndpoint serves task/container metadata, task/container stats, IAM Role Credentials, and Agent APIs
 // for tasks being managed by the agent.
 func ServeTaskHTTPEndpoint(
@@ -190,30 +275,112 @@ func ServeTaskHTTPEndpoint(
 
 	auditLogger := audit.NewAuditLog(containerInstanceArn, cfg, logger)
 
-	server, err := taskServerSetup(credentialsManager, auditLogger, state, ecsClient, cfg.Cluster, cfg.AWSRegion, statsEngine,
-		cfg.TaskMetadataSteadyStateRate, cfg.TaskMetadataBurstRate, availabilityZone, vpcID, containerInstanceArn, cfg.APIEndpoint,
-		cfg.AcceptInsecureCert)
-	if err != nil {
-		seelog.Criticalf("Failed to set up Task Metadata Server: %v", err)
-		return
+	// Retry throttled tag lookups made on behalf of the taskWithTags endpoints
+	ecsClient = v2.NewTagLookupECSClient(ecsClient, cfg.TaskMetadataTagLookupMaxAttempts,
+		cfg.TaskMetadataTagLookupMinBackoff, cfg.TaskMetadataTagLookupMaxBackoff)
+
+	newServer := func() (*http.Server, error) {
+		return taskServerSetup(credentialsManager, auditLogger, state, ecsClient, cfg.Cluster, cfg.AWSRegion, statsEngine,
+			cfg.TaskMetadataSteadyStateRate, cfg.TaskMetadataBurstRate, availabilityZone, vpcID, containerInstanceArn, cfg.APIEndpoint,
+			cfg.AcceptInsecureCert, cfg.AgentAPIAllowedSourceCIDRs, cfg.TaskMetadataCgroupPathEnabled.Enabled(),
+			cfg.TaskMetadataNanosecondTimestamps.Enabled(), metrics.NewNopEntryFactory())
 	}
+	serveTaskHTTPEndpoint(ctx, newServer, cfg.TaskMetadataUnixSocketPath, cfg.TaskMetadataServerMaxLifetime)
+}
 
-	go func() {
-		<-ctx.Done()
-		if err := server.Shutdown(context.Background()); err != nil {
-			// Error from closing listeners, or context timeout:
-			seelog.Infof("HTTP server Shutdown: %v", err)
+// serveTaskHTTPEndpoint serves the servers created by newServer until ctx is canceled. If maxLifetime
+// is positive, each server is gracefully shut down once it has run for maxLifetime and replaced by a
+// new one. The old server stops accepting connections before the new one starts listening, and
+// finishes its in-flight requests in the background.
+func serveTaskHTTPEndpoint(
+	ctx context.Context,
+	newServer func() (*http.Server, error),
+	socketPath string,
+	maxLifetime time.Duration) {
+	for ctx.Err() == nil {
+		server, err := newServer()
+		if err != nil {
+			seelog.Criticalf("Failed to set up Task Metadata Server: %v", err)
+			return
+		}
+
+		var serverCtx context.Context
+		var cancel context.CancelFunc
+		if maxLifetime > 0 {
+			serverCtx, cancel = context.WithTimeout(ctx, maxLifetime)
+		} else {
+			serverCtx, cancel = context.WithCancel(ctx)
+		}
+		go func() {
+			<-serverCtx.Done()
+			if err := server.Shutdown(context.Background()); err != nil {
+				// Error from closing listeners, or context timeout:
+				seelog.Infof("HTTP server Shutdown: %v", err)
+			}
+		}()
+
+		unixSocketServed := make(chan struct{})
+		if socketPath != "" {
+			go func() {
+				defer close(unixSocketServed)
+				serveTaskHTTPEndpointOnUnixSocket(server, socketPath)
+			}()
+		} else {
+			close(unixSocketServed)
 		}
-	}()
 
-	for {
 		retry.RetryWithBackoff(retry.NewExponentialBackoff(time.Second, time.Minute, 0.2, 2), func() error {
 			if err := server.ListenAndServe(); err != http.ErrServerClosed {
 				seelog.Errorf("Error running task api: %v", err)
 				return err
 			}
-			// server was cleanly closed via context
+			// server was cleanly closed via context, or has reached its maximum lifetime
 			return nil
 		})
+		cancel()
+		// Wait for the old server to release the unix socket, so that closing its listener
+		// doesn't remove the socket file created for the new server.
+		<-unixSocketServed
+
+		if ctx.Err() == nil {
+			seelog.Infof("Recreating Task Metadata Server after its maximum lifetime of %s", maxLifetime)
+		}
+	}
+}
+
+// serveTaskHTTPEndpointOnUnixSocket serves the task metadata server on the unix socket at
+// socketPath, in addition to its TCP address, until the server is shut down. Shutting down
+// the server closes the listener, which removes the socket file.
+func serveTaskHTTPEndpointOnUnixSocket(server *http.Server, socketPath string) {
+	retry.RetryWithBackoff(retry.NewExponentialBackoff(time.Second, time.Minute, 0.2, 2), func() error {
+		listener, err := listenTaskMetadataUnixSocket(socketPath)
+		if err != nil {
+			seelog.Errorf("Error listening on task metadata unix socket: %v", err)
+			return err
+		}
+		seelog.Infof("Serving task metadata on unix socket %s", socketPath)
+		if err := server.Serve(listener); err != http.ErrServerClosed {
+			seelog.Errorf("Error running task api on unix socket: %v", err)
+			return err
+		}
+		// server was cleanly closed via context
+		return nil
+	})
+}
+
+// listenTaskMetadataUnixSocket listens on a unix socket at socketPath, replacing any socket file
+// left behind by a previous agent, and restricts the permissions of the socket file.
+func listenTaskMetadataUnixSocket(socketPath string) (net.Listener, error) {
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "unable to remove stale unix socket %s", socketPath)
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to listen on unix socket %s", socketPath)
+	}
+	if err := os.Chmod(socketPath, taskMetadataUnixSocketMode); err != nil {
+		listener.Close()
+		return nil, errors.Wrapf(err, "unable to set permissions of unix socket %s", socketPath)
 	}
+	return listener, nil
 }
@@ -0,0 +1,315 @@
+//go:build linux && unit
+// +build linux,unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+	apicontainerstatus "github.com/aws/amazon-ecs-agent/agent/api/container/status"
+	mock_api "github.com/aws/amazon-ecs-agent/agent/api/mocks"
+	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
+	apitaskstatus "github.com/aws/amazon-ecs-agent/agent/api/task/status"
+	"github.com/aws/amazon-ecs-agent/agent/config"
+	mock_dockerstate "github.com/aws/amazon-ecs-agent/agent/engine/dockerstate/mocks"
+	mock_stats "github.com/aws/amazon-ecs-agent/agent/stats/mock"
+	taskresourcevolume "github.com/aws/amazon-ecs-agent/agent/taskresource/volume"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/credentials"
+	mock_audit "github.com/aws/amazon-ecs-agent/ecs-agent/logger/audit/mocks"
+	tmdsresponse "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/response"
+	v2 "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/v2"
+	v4 "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/v4/state"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskHTTPEndpointOnUnixSocket(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	state := mock_dockerstate.NewMockTaskEngineState(ctrl)
+	auditLog := mock_audit.NewMockAuditLogger(ctrl)
+	statsEngine := mock_stats.NewMockEngine(ctrl)
+	ecsClient := mock_api.NewMockECSClient(ctrl)
+	auditLog.EXPECT().Log(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return("", false)
+
+	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region,
+		statsEngine, config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, availabilityzone,
+		vpcID, containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
+	require.NoError(t, err)
+
+	socketPath := filepath.Join(t.TempDir(), "tmds.sock")
+	// A socket file left behind by a previous agent is replaced.
+	require.NoError(t, os.WriteFile(socketPath, nil, 0600))
+
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		serveTaskHTTPEndpointOnUnixSocket(server, socketPath)
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		resp, err = client.Get("http://unix" + v4BasePath + v3EndpointID + "/task")
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	defer resp.Body.Close()
+
+	var body string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, fmt.Sprintf(
+		"V4 task metadata handler: unable to get task arn from request: unable to get task Arn from v3 endpoint ID: %s",
+		v3EndpointID), body)
+
+	info, err := os.Stat(socketPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeSocket, info.Mode()&os.ModeSocket)
+	assert.Equal(t, os.FileMode(taskMetadataUnixSocketMode), info.Mode().Perm())
+
+	// Shutting down the server stops serving on the socket and removes the socket file.
+	require.NoError(t, server.Shutdown(context.Background()))
+	<-served
+	_, err = os.Stat(socketPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+// Returns an awsvpc task with task cgroups enabled, whose containers have a known cgroup path.
+func cgroupTask() *apitask.Task {
+	return &apitask.Task{
+		Arn:                      cgroupTaskARN,
+		Associations:             []apitask.Association{association},
+		Family:                   family,
+		Version:                  version,
+		DesiredStatusUnsafe:      apitaskstatus.TaskRunning,
+		KnownStatusUnsafe:        apitaskstatus.TaskRunning,
+		NetworkMode:              apitask.AWSVPCNetworkMode,
+		ENIs:                     task.ENIs,
+		CPU:                      cpu,
+		Memory:                   memory,
+		PullStartedAtUnsafe:      now,
+		PullStoppedAtUnsafe:      now,
+		ExecutionStoppedAtUnsafe: now,
+		LaunchType:               "EC2",
+		MemoryCPULimitsEnabled:   true,
+	}
+}
+
+const cgroupTaskARN = "arn:aws:ecs:us-west-2:123456789012:task/default/cgroup-task-id"
+
+func expectedContainerCgroupPath() string {
+	if config.CgroupV2 {
+		return "/ecstasks.slice/ecstasks-cgroup-task-id.slice/docker-" + containerID + ".scope"
+	}
+	return "/ecs/cgroup-task-id/" + containerID
+}
+
+func TestV4ContainerMetadataCgroupPath(t *testing.T) {
+	t.Run("cgroup path included", func(t *testing.T) {
+		cgroupTask := cgroupTask()
+		expectedResponse := expectedV4ContainerResponse
+		expectedResponse.CgroupPath = expectedContainerCgroupPath()
+		testTMDSRequest(t, TMDSTestCase[v4.ContainerResponse]{
+			path: v4BasePath + v3EndpointID,
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().DockerIDByV3EndpointID(v3EndpointID).Return(containerID, true),
+					state.EXPECT().ContainerByID(containerID).Return(dockerContainer, true),
+					state.EXPECT().TaskByID(containerID).Return(cgroupTask, true).Times(3),
+				)
+			},
+			includeCgroupPath:    true,
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: expectedResponse,
+		})
+	})
+	t.Run("cgroup path not included", func(t *testing.T) {
+		cgroupTask := cgroupTask()
+		testTMDSRequest(t, TMDSTestCase[v4.ContainerResponse]{
+			path: v4BasePath + v3EndpointID,
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().DockerIDByV3EndpointID(v3EndpointID).Return(containerID, true),
+					state.EXPECT().ContainerByID(containerID).Return(dockerContainer, true),
+					state.EXPECT().TaskByID(containerID).Return(cgroupTask, true).Times(2),
+				)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: expectedV4ContainerResponse,
+		})
+	})
+}
+
+func TestV4TaskMetadataCgroupPath(t *testing.T) {
+	for _, tc := range []struct {
+		name               string
+		includeCgroupPath  bool
+		expectedCgroupPath string
+	}{
+		{
+			name:               "cgroup path included",
+			includeCgroupPath:  true,
+			expectedCgroupPath: expectedContainerCgroupPath(),
+		},
+		{
+			name:              "cgroup path not included",
+			includeCgroupPath: false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cgroupTask := cgroupTask()
+			expectedResponse := expectedV4TaskResponse()
+			expectedResponse.TaskARN = cgroupTaskARN
+			expectedResponse.Containers[0].CgroupPath = tc.expectedCgroupPath
+			testTMDSRequest(t, TMDSTestCase[v4.TaskResponse]{
+				path: v4BasePath + v3EndpointID + "/task",
+				setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+					gomock.InOrder(
+						state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(cgroupTaskARN, true),
+						state.EXPECT().TaskByArn(cgroupTaskARN).Return(cgroupTask, true).Times(2),
+						state.EXPECT().ContainerMapByArn(cgroupTaskARN).Return(containerNameToDockerContainer, true),
+						state.EXPECT().TaskByArn(cgroupTaskARN).Return(cgroupTask, true),
+						state.EXPECT().PulledContainerMapByArn(cgroupTaskARN).Return(nil, true),
+					)
+				},
+				includeCgroupPath:    tc.includeCgroupPath,
+				expectedStatusCode:   http.StatusOK,
+				expectedResponseBody: expectedResponse,
+			})
+		})
+	}
+}
+
+func TestV4ContainerMetadataHostPID(t *testing.T) {
+	const hostPID = 4242
+	for _, tc := range []struct {
+		name            string
+		knownStatus     apicontainerstatus.ContainerStatus
+		expectedHostPID *int
+	}{
+		{
+			name:            "running container",
+			knownStatus:     apicontainerstatus.ContainerRunning,
+			expectedHostPID: aws.Int(hostPID),
+		},
+		{
+			name:        "stopped container",
+			knownStatus: apicontainerstatus.ContainerStopped,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testV4ContainerMetadataOf(t, nil,
+				func(c *apicontainer.Container) {
+					c.SetHostPID(hostPID)
+					c.SetKnownStatus(tc.knownStatus)
+				},
+				func(r *v2.ContainerResponse) {
+					r.KnownStatus = tc.knownStatus.String()
+					r.HostPID = tc.expectedHostPID
+				})
+		})
+	}
+}
+
+func TestV4ContainerMetadataCpusetCpus(t *testing.T) {
+	for _, tc := range []struct {
+		name               string
+		hostConfig         string
+		expectedCpusetCpus string
+	}{
+		{
+			name:               "pinned container",
+			hostConfig:         `{"CpusetCpus":"0-1,3"}`,
+			expectedCpusetCpus: "0-1,3",
+		},
+		{
+			name:       "unpinned container",
+			hostConfig: `{}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testV4ContainerMetadataOf(t, nil,
+				func(c *apicontainer.Container) { c.DockerConfig.HostConfig = &tc.hostConfig },
+				func(r *v2.ContainerResponse) { r.CpusetCpus = tc.expectedCpusetCpus })
+		})
+	}
+}
+
+func TestV4ContainerMetadataMountPropagation(t *testing.T) {
+	volumesTask := newTestTask()
+	volumesTask.Volumes = []apitask.TaskVolume{
+		{
+			Name:   "host-logs",
+			Type:   apitask.HostVolumeType,
+			Volume: &taskresourcevolume.FSHostVolume{FSSourcePath: "/var/log/app"},
+		},
+	}
+
+	for _, tc := range []struct {
+		name                     string
+		hostConfig               string
+		expectedMountPropagation string
+	}{
+		{
+			name:                     "rshared bind mount",
+			hostConfig:               `{"Binds":["/var/log/app:/logs:rshared"]}`,
+			expectedMountPropagation: "rshared",
+		},
+		{
+			name:       "default propagation",
+			hostConfig: `{"Binds":["/var/log/app:/logs:rprivate"]}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testV4ContainerMetadataOf(t, volumesTask,
+				func(c *apicontainer.Container) {
+					c.MountPoints = []apicontainer.MountPoint{{SourceVolume: "host-logs", ContainerPath: "/logs"}}
+					c.DockerConfig.HostConfig = &tc.hostConfig
+				},
+				func(r *v2.ContainerResponse) {
+					r.VolumeMounts = []tmdsresponse.VolumeMountResponse{
+						{
+							SourceType:       "bind",
+							Source:           "/var/log/app",
+							Destination:      "/logs",
+							MountPropagation: tc.expectedMountPropagation,
+						},
+					}
+				})
+		})
+	}
+}
@@ -18,34 +18,44 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
 	apicontainerstatus "github.com/aws/amazon-ecs-agent/agent/api/container/status"
 	mock_api "github.com/aws/amazon-ecs-agent/agent/api/mocks"
+	"github.com/aws/amazon-ecs-agent/agent/api/serviceconnect"
 	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
 	apitaskstatus "github.com/aws/amazon-ecs-agent/agent/api/task/status"
 	"github.com/aws/amazon-ecs-agent/agent/config"
 	"github.com/aws/amazon-ecs-agent/agent/ecs_client/model/ecs"
 	mock_dockerstate "github.com/aws/amazon-ecs-agent/agent/engine/dockerstate/mocks"
 	task_protection_v1 "github.com/aws/amazon-ecs-agent/agent/handlers/agentapi/taskprotection/v1/handlers"
+	handlersv2 "github.com/aws/amazon-ecs-agent/agent/handlers/v2"
 	v3 "github.com/aws/amazon-ecs-agent/agent/handlers/v3"
+	handlersv4 "github.com/aws/amazon-ecs-agent/agent/handlers/v4"
 	"github.com/aws/amazon-ecs-agent/agent/stats"
 	mock_stats "github.com/aws/amazon-ecs-agent/agent/stats/mock"
+	taskresourcevolume "github.com/aws/amazon-ecs-agent/agent/taskresource/volume"
 	agentutils "github.com/aws/amazon-ecs-agent/agent/utils"
+	agentversion "github.com/aws/amazon-ecs-agent/agent/version"
 	apieni "github.com/aws/amazon-ecs-agent/ecs-agent/api/eni"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/credentials"
 	mock_credentials "github.com/aws/amazon-ecs-agent/ecs-agent/credentials/mocks"
 	mock_audit "github.com/aws/amazon-ecs-agent/ecs-agent/logger/audit/mocks"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
+	mock_metrics "github.com/aws/amazon-ecs-agent/ecs-agent/metrics/mocks"
 	tmdsresponse "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/response"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/utils"
 	tmdsv1 "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/v1"
@@ -53,7 +63,10 @@ import (
 	v4 "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/v4/state"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	cnitypes "github.com/containernetworking/cni/pkg/types"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -110,7 +123,8 @@ const (
 )
 
 var (
-	now         = time.Now()
+	// now has second precision, as v4 metadata timestamps do by default
+	now         = time.Now().Truncate(time.Second)
 	association = apitask.Association{
 		Containers: []string{containerName},
 		Content: apitask.EncodedString{
@@ -129,33 +143,7 @@ var (
 		Name: associationName,
 		Type: associationType,
 	}
-	task = &apitask.Task{
-		Arn:                 taskARN,
-		Associations:        []apitask.Association{association},
-		Family:              family,
-		Version:             version,
-		DesiredStatusUnsafe: apitaskstatus.TaskRunning,
-		KnownStatusUnsafe:   apitaskstatus.TaskRunning,
-		NetworkMode:         apitask.AWSVPCNetworkMode,
-		ENIs: []*apieni.ENI{
-			{
-				IPV4Addresses: []*apieni.ENIIPV4Address{
-					{
-						Address: eniIPv4Address,
-					},
-				},
-				MacAddress:               macAddress,
-				PrivateDNSName:           privateDNSName,
-				SubnetGatewayIPV4Address: subnetGatewayIpv4Address,
-			},
-		},
-		CPU:                      cpu,
-		Memory:                   memory,
-		PullStartedAtUnsafe:      now,
-		PullStoppedAtUnsafe:      now,
-		ExecutionStoppedAtUnsafe: now,
-		LaunchType:               "EC2",
-	}
+	task       = newTestTask()
 	pulledTask = &apitask.Task{
 		Arn:                 taskARN,
 		Associations:        []apitask.Association{pulledAssociation},
@@ -183,23 +171,7 @@ var (
 		ExecutionStoppedAtUnsafe: now,
 		LaunchType:               "EC2",
 	}
-	container = &apicontainer.Container{
-		Name:                containerName,
-		Image:               imageName,
-		ImageID:             imageID,
-		DesiredStatusUnsafe: apicontainerstatus.ContainerRunning,
-		KnownStatusUnsafe:   apicontainerstatus.ContainerRunning,
-		CPU:                 cpu,
-		Memory:              memory,
-		Type:                apicontainer.ContainerNormal,
-		ContainerArn:        "arn:aws:ecs:ap-northnorth-1:NNN:container/NNNNNNNN-aaaa-4444-bbbb-00000000000",
-		KnownPortBindingsUnsafe: []apicontainer.PortBinding{
-			{
-				ContainerPort: containerPort,
-				Protocol:      apicontainer.TransportProtocolTCP,
-			},
-		},
-	}
+	container       = newTestContainer()
 	pulledContainer = &apicontainer.Container{
 		Name:                pulledContainerName,
 		Image:               imageName,
@@ -281,6 +253,7 @@ var (
 		Version:                  version,
 		DesiredStatusUnsafe:      apitaskstatus.TaskRunning,
 		KnownStatusUnsafe:        apitaskstatus.TaskRunning,
+		NetworkMode:              apitask.BridgeNetworkMode,
 		CPU:                      cpu,
 		Memory:                   memory,
 		PullStartedAtUnsafe:      now,
@@ -382,8 +355,11 @@ var (
 				CPU:    aws.Float64(cpu),
 				Memory: aws.Int64(memory),
 			},
-			Type:   containerType,
-			Labels: labels,
+			Type:            containerType,
+			Labels:          labels,
+			SeccompProfile:  apicontainer.SecurityProfileDefault,
+			AppArmorProfile: apicontainer.SecurityProfileDefault,
+			StopSignal:      defaultStopSignalResponse(),
 			Ports: []tmdsresponse.PortResponse{
 				{
 					ContainerPort: containerPort,
@@ -391,6 +367,9 @@ var (
 					HostPort:      containerPort,
 				},
 			},
+			NetworkIPAddresses: map[string][]string{
+				utils.NetworkModeAWSVPC: {eniIPv4Address},
+			},
 		},
 		Networks: []v4.Network{{
 			Network: tmdsresponse.Network{
@@ -418,22 +397,26 @@ var (
 				CPU:    aws.Float64(cpu),
 				Memory: aws.Int64(memory),
 			},
-			Type: containerType,
+			Type:            containerType,
+			SeccompProfile:  apicontainer.SecurityProfileDefault,
+			AppArmorProfile: apicontainer.SecurityProfileDefault,
+			StopSignal:      defaultStopSignalResponse(),
 		},
 	}
-	expectedV4BridgeContainerResponse = v4ContainerResponseFromV2(expectedBridgeContainerResponse, []v4.Network{{
-		Network: tmdsresponse.Network{
-			NetworkMode:   bridgeMode,
-			IPv4Addresses: []string{bridgeIPAddr},
-		},
-		NetworkInterfaceProperties: v4.NetworkInterfaceProperties{
-			AttachmentIndex:          nil,
-			IPV4SubnetCIDRBlock:      "",
-			MACAddress:               "",
-			PrivateDNSName:           "",
-			SubnetGatewayIPV4Address: "",
-		}},
-	})
+	expectedV4BridgeContainerResponse = v4ContainerResponseFromV2(
+		stripPortsFromV2ContainerResponse(expectedBridgeContainerResponse), []v4.Network{{
+			Network: tmdsresponse.Network{
+				NetworkMode:   bridgeMode,
+				IPv4Addresses: []string{bridgeIPAddr},
+			},
+			NetworkInterfaceProperties: v4.NetworkInterfaceProperties{
+				AttachmentIndex:          nil,
+				IPV4SubnetCIDRBlock:      "",
+				MACAddress:               "",
+				PrivateDNSName:           "",
+				SubnetGatewayIPV4Address: "",
+			}},
+		})
 )
 
 // Returns a standard v2 task response. This getter function protects against tests mutating the
@@ -458,10 +441,23 @@ func expectedTaskResponse() v2.TaskResponse {
 	}
 }
 
+// Returns the stop signal response of containers that don't configure a stop signal
+func defaultStopSignalResponse() *tmdsresponse.StopSignalResponse {
+	return &tmdsresponse.StopSignalResponse{Signal: "SIGTERM", Default: true}
+}
+
 // Creates a v4 ContainerResponse given a v2 ContainerResponse and v4 networks
 func v4ContainerResponseFromV2(
 	v2ContainerResponse v2.ContainerResponse, networks []v4.Network) v4.ContainerResponse {
 	v2ContainerResponse.Networks = nil
+	v2ContainerResponse.NetworkIPAddresses = make(map[string][]string)
+	for _, network := range networks {
+		v2ContainerResponse.NetworkIPAddresses[network.NetworkMode] = append(network.IPv4Addresses,
+			network.IPv6Addresses...)
+	}
+	v2ContainerResponse.SeccompProfile = apicontainer.SecurityProfileDefault
+	v2ContainerResponse.AppArmorProfile = apicontainer.SecurityProfileDefault
+	v2ContainerResponse.StopSignal = defaultStopSignalResponse()
 	return v4.ContainerResponse{
 		ContainerResponse: &v2ContainerResponse,
 		Networks:          networks,
@@ -491,6 +487,7 @@ func expectedV4TaskResponse() v4.TaskResponse {
 		},
 		[]v4.ContainerResponse{expectedV4ContainerResponse},
 		vpcID,
+		apitask.AWSVPCNetworkMode,
 	)
 }
 
@@ -517,6 +514,7 @@ func expectedV4PulledTaskResponse() v4.TaskResponse {
 		},
 		[]v4.ContainerResponse{expectedV4ContainerResponse, expectedV4PulledContainerResponse},
 		vpcID,
+		apitask.AWSVPCNetworkMode,
 	)
 }
 
@@ -543,6 +541,7 @@ func expectedV4BridgeTaskResponse() v4.TaskResponse {
 		},
 		[]v4.ContainerResponse{expectedV4BridgeContainerResponse},
 		vpcID,
+		apitask.BridgeNetworkMode,
 	)
 }
 
@@ -568,8 +567,10 @@ func v4TaskResponseFromV2(
 	v2TaskResponse v2.TaskResponse,
 	containers []v4.ContainerResponse,
 	vcpID string,
+	networkMode string,
 ) v4.TaskResponse {
 	v2TaskResponse.Containers = nil
+	v2TaskResponse.NetworkMode = networkMode
 	return v4.TaskResponse{
 		TaskResponse: &v2TaskResponse,
 		Containers:   containers,
@@ -584,6 +585,13 @@ func stripContainersFromV2TaskResponse(response v2.TaskResponse) v2.TaskResponse
 	return response
 }
 
+// Returns a new v2 container response by stripping the "ports" field from the provided
+// container response. V4 leaves out port mappings that have not been assigned a host port.
+func stripPortsFromV2ContainerResponse(response v2.ContainerResponse) v2.ContainerResponse {
+	response.Ports = nil
+	return response
+}
+
 // Returns a standard container instance tags map for testing
 func standardContainerInstanceTags() map[string]string {
 	return map[string]string{
@@ -620,6 +628,59 @@ func standardECSTaskTags() []*ecs.Tag {
 	return ecsTags
 }
 
+// newTestTask returns a running awsvpc task with the test fields
+func newTestTask() *apitask.Task {
+	return &apitask.Task{
+		Arn:                 taskARN,
+		Associations:        []apitask.Association{association},
+		Family:              family,
+		Version:             version,
+		DesiredStatusUnsafe: apitaskstatus.TaskRunning,
+		KnownStatusUnsafe:   apitaskstatus.TaskRunning,
+		NetworkMode:         apitask.AWSVPCNetworkMode,
+		ENIs: []*apieni.ENI{
+			{
+				IPV4Addresses: []*apieni.ENIIPV4Address{
+					{
+						Address: eniIPv4Address,
+					},
+				},
+				MacAddress:               macAddress,
+				PrivateDNSName:           privateDNSName,
+				SubnetGatewayIPV4Address: subnetGatewayIpv4Address,
+			},
+		},
+		CPU:                      cpu,
+		Memory:                   memory,
+		PullStartedAtUnsafe:      now,
+		PullStoppedAtUnsafe:      now,
+		ExecutionStoppedAtUnsafe: now,
+		LaunchType:               "EC2",
+	}
+}
+
+// newTestContainer returns a running container with the test fields. Its labels
+// are not set
+func newTestContainer() *apicontainer.Container {
+	return &apicontainer.Container{
+		Name:                containerName,
+		Image:               imageName,
+		ImageID:             imageID,
+		DesiredStatusUnsafe: apicontainerstatus.ContainerRunning,
+		KnownStatusUnsafe:   apicontainerstatus.ContainerRunning,
+		CPU:                 cpu,
+		Memory:              memory,
+		Type:                apicontainer.ContainerNormal,
+		ContainerArn:        "arn:aws:ecs:ap-northnorth-1:NNN:container/NNNNNNNN-aaaa-4444-bbbb-00000000000",
+		KnownPortBindingsUnsafe: []apicontainer.PortBinding{
+			{
+				ContainerPort: containerPort,
+				Protocol:      apicontainer.TransportProtocolTCP,
+			},
+		},
+	}
+}
+
 func init() {
 	container.SetLabels(labels)
 	container1.SetLabels(labels)
@@ -765,7 +826,7 @@ func testErrorResponsesFromServer(t *testing.T, path string, expectedErrorMessag
 	ecsClient := mock_api.NewMockECSClient(ctrl)
 	server, err := taskServerSetup(credentialsManager, auditLog, nil, ecsClient, "", "", nil,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-		containerInstanceArn, "", true)
+		containerInstanceArn, "", true, nil, false, false, nil)
 	require.NoError(t, err)
 
 	recorder := httptest.NewRecorder()
@@ -802,7 +863,7 @@ func getResponseForCredentialsRequest(t *testing.T, expectedStatus int,
 	ecsClient := mock_api.NewMockECSClient(ctrl)
 	server, err := taskServerSetup(credentialsManager, auditLog, nil, ecsClient, "", "", nil,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-		containerInstanceArn, "", true)
+		containerInstanceArn, "", true, nil, false, false, nil)
 	require.NoError(t, err)
 
 	recorder := httptest.NewRecorder()
@@ -861,7 +922,7 @@ func TestV2ContainerStats(t *testing.T) {
 	)
 	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-		containerInstanceArn, endpoint, acceptInsecureCert)
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
 	require.NoError(t, err)
 	recorder := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", v2BaseStatsPath+"/"+containerID, nil)
@@ -912,7 +973,7 @@ func TestV2TaskStats(t *testing.T) {
 			)
 			server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 				config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-				containerInstanceArn, endpoint, acceptInsecureCert)
+				containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
 			require.NoError(t, err)
 			recorder := httptest.NewRecorder()
 			req, _ := http.NewRequest("GET", tc.path, nil)
@@ -956,7 +1017,7 @@ func TestV3TaskStats(t *testing.T) {
 	)
 	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-		containerInstanceArn, endpoint, acceptInsecureCert)
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
 	require.NoError(t, err)
 	recorder := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", v3BasePath+v3EndpointID+"/task/stats", nil)
@@ -991,7 +1052,7 @@ func TestV3ContainerStats(t *testing.T) {
 	)
 	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-		containerInstanceArn, endpoint, acceptInsecureCert)
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
 	require.NoError(t, err)
 	recorder := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", v3BasePath+v3EndpointID+"/stats", nil)
@@ -1022,7 +1083,7 @@ func TestV3ContainerAssociations(t *testing.T) {
 	)
 	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-		containerInstanceArn, endpoint, acceptInsecureCert)
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
 	require.NoError(t, err)
 	recorder := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", v3BasePath+v3EndpointID+"/associations/"+associationType, nil)
@@ -1052,7 +1113,7 @@ func TestV3ContainerAssociation(t *testing.T) {
 	)
 	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-		containerInstanceArn, endpoint, acceptInsecureCert)
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
 	require.NoError(t, err)
 	recorder := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", v3BasePath+v3EndpointID+"/associations/"+associationType+"/"+associationName, nil)
@@ -1086,10 +1147,11 @@ func TestV4TaskStats(t *testing.T) {
 		state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
 		state.EXPECT().ContainerMapByArn(taskARN).Return(containerMap, true),
 		statsEngine.EXPECT().ContainerDockerStats(taskARN, containerID).Return(dockerStats, &stats.NetworkStatsPerSec{}, nil),
+		statsEngine.EXPECT().ContainerStatsRetentionWindow(taskARN, containerID).Return(80*time.Second, nil),
 	)
 	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-		containerInstanceArn, endpoint, acceptInsecureCert)
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
 	require.NoError(t, err)
 	recorder := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", v4BasePath+v3EndpointID+"/task/stats", nil)
@@ -1097,12 +1159,13 @@ func TestV4TaskStats(t *testing.T) {
 	res, err := ioutil.ReadAll(recorder.Body)
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, recorder.Code)
-	var statsFromResult map[string]*types.StatsJSON
+	var statsFromResult map[string]*handlersv4.StatsResponse
 	err = json.Unmarshal(res, &statsFromResult)
 	assert.NoError(t, err)
 	containerStats, ok := statsFromResult[containerID]
-	assert.True(t, ok)
+	require.True(t, ok)
 	assert.Equal(t, dockerStats.NumProcs, containerStats.NumProcs)
+	assert.Equal(t, float64(80), containerStats.Sample_retention_window_seconds)
 }
 
 func TestV4ContainerStats(t *testing.T) {
@@ -1121,10 +1184,12 @@ func TestV4ContainerStats(t *testing.T) {
 		state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
 		state.EXPECT().DockerIDByV3EndpointID(v3EndpointID).Return(containerID, true),
 		statsEngine.EXPECT().ContainerDockerStats(taskARN, containerID).Return(dockerStats, &stats.NetworkStatsPerSec{}, nil),
+		statsEngine.EXPECT().ContainerStatsRetentionWindow(taskARN, containerID).Return(80*time.Second, nil),
+		state.EXPECT().ContainerByID(containerID).Return(nil, false),
 	)
 	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-		containerInstanceArn, endpoint, acceptInsecureCert)
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
 	require.NoError(t, err)
 	recorder := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", v4BasePath+v3EndpointID+"/stats", nil)
@@ -1132,10 +1197,290 @@ func TestV4ContainerStats(t *testing.T) {
 	res, err := ioutil.ReadAll(recorder.Body)
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, recorder.Code)
-	var statsFromResult *types.StatsJSON
+	var statsFromResult *handlersv4.StatsResponse
 	err = json.Unmarshal(res, &statsFromResult)
 	assert.NoError(t, err)
 	assert.Equal(t, dockerStats.NumProcs, statsFromResult.NumProcs)
+	assert.Equal(t, float64(80), statsFromResult.Sample_retention_window_seconds)
+}
+
+func TestTaskMetadataRequestMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	state := mock_dockerstate.NewMockTaskEngineState(ctrl)
+	auditLog := mock_audit.NewMockAuditLogger(ctrl)
+	statsEngine := mock_stats.NewMockEngine(ctrl)
+	ecsClient := mock_api.NewMockECSClient(ctrl)
+	metricsFactory := mock_metrics.NewMockEntryFactory(ctrl)
+	entry := mock_metrics.NewMockEntry(ctrl)
+
+	containerMap := map[string]*apicontainer.DockerContainer{
+		containerName: {
+			DockerID: containerID,
+		},
+	}
+	state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true).AnyTimes()
+	state.EXPECT().DockerIDByV3EndpointID(v3EndpointID).Return(containerID, true).AnyTimes()
+	state.EXPECT().ContainerMapByArn(taskARN).Return(containerMap, true).AnyTimes()
+	state.EXPECT().ContainerByID(containerID).Return(nil, false).AnyTimes()
+	statsEngine.EXPECT().ContainerDockerStats(taskARN, containerID).
+		Return(&types.StatsJSON{}, &stats.NetworkStatsPerSec{}, nil).AnyTimes()
+	statsEngine.EXPECT().ContainerStatsRetentionWindow(taskARN, containerID).Return(time.Minute, nil).AnyTimes()
+
+	var recordedFields []map[string]interface{}
+	metricsFactory.EXPECT().New(metrics.TaskMetadataRequestMetricName).Return(entry).Times(2)
+	entry.EXPECT().WithFields(gomock.Any()).DoAndReturn(func(fields map[string]interface{}) metrics.Entry {
+		recordedFields = append(recordedFields, fields)
+		return entry
+	}).Times(2)
+	entry.EXPECT().WithCount(1).Return(entry).Times(2)
+	entry.EXPECT().Done(nil).Return(func() {}).Times(2)
+
+	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
+		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, metricsFactory)
+	require.NoError(t, err)
+	for _, path := range []string{v4BasePath + v3EndpointID + "/stats", v4BasePath + v3EndpointID + "/task/stats"} {
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		server.Handler.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	require.Len(t, recordedFields, 2)
+	assert.Equal(t, "/v4/{v3EndpointIDMuxName}/stats", recordedFields[0]["endpoint"])
+	assert.Equal(t, "/v4/{v3EndpointIDMuxName}/task/stats", recordedFields[1]["endpoint"])
+	assert.Equal(t, taskARN, recordedFields[0]["task"])
+	assert.Equal(t, taskARN, recordedFields[1]["task"])
+}
+
+func TestV4ContainerMetadataImagePullCredentialsSource(t *testing.T) {
+	const (
+		secretARN      = "arn:aws:secretsmanager:us-west-2:123456789012:secret:registry-creds"
+		secretPassword = "registry-password"
+		secretKey      = "pull-secret-access-key"
+	)
+	asmAuthData := &apicontainer.ASMAuthData{
+		CredentialsParameter: secretARN,
+		Region:               region,
+	}
+	asmAuthData.SetDockerAuthConfig(types.AuthConfig{Username: "registry-user", Password: secretPassword})
+	ecrAuthData := &apicontainer.ECRAuthData{
+		RegistryID:       "123456789012",
+		Region:           region,
+		UseExecutionRole: true,
+	}
+	ecrAuthData.SetPullCredentials(credentials.IAMRoleCredentials{
+		AccessKeyID:     "pull-access-key",
+		SecretAccessKey: secretKey,
+		SessionToken:    "pull-session-token",
+	})
+
+	for _, tc := range []struct {
+		name           string
+		registryAuth   *apicontainer.RegistryAuthenticationData
+		expectedSource *tmdsresponse.ImagePullCredentialsSourceResponse
+	}{
+		{
+			name: "secrets manager",
+			registryAuth: &apicontainer.RegistryAuthenticationData{
+				Type:        apicontainer.AuthTypeASM,
+				ASMAuthData: asmAuthData,
+			},
+			expectedSource: &tmdsresponse.ImagePullCredentialsSourceResponse{
+				Type:                 apicontainer.AuthTypeASM,
+				CredentialsParameter: secretARN,
+				Region:               region,
+			},
+		},
+		{
+			name: "ecr",
+			registryAuth: &apicontainer.RegistryAuthenticationData{
+				Type:        apicontainer.AuthTypeECR,
+				ECRAuthData: ecrAuthData,
+			},
+			expectedSource: &tmdsresponse.ImagePullCredentialsSourceResponse{
+				Type:             apicontainer.AuthTypeECR,
+				RegistryID:       "123456789012",
+				Region:           region,
+				UseExecutionRole: true,
+			},
+		},
+		{
+			name: "no registry authentication",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			state := mock_dockerstate.NewMockTaskEngineState(ctrl)
+			auditLog := mock_audit.NewMockAuditLogger(ctrl)
+			statsEngine := mock_stats.NewMockEngine(ctrl)
+			ecsClient := mock_api.NewMockECSClient(ctrl)
+
+			authContainer := &apicontainer.Container{
+				Name:                   containerName,
+				Image:                  imageName,
+				ImageID:                imageID,
+				DesiredStatusUnsafe:    apicontainerstatus.ContainerRunning,
+				KnownStatusUnsafe:      apicontainerstatus.ContainerRunning,
+				Type:                   apicontainer.ContainerNormal,
+				RegistryAuthentication: tc.registryAuth,
+			}
+			authDockerContainer := &apicontainer.DockerContainer{
+				DockerID:   containerID,
+				DockerName: containerName,
+				Container:  authContainer,
+			}
+			gomock.InOrder(
+				state.EXPECT().DockerIDByV3EndpointID(v3EndpointID).Return(containerID, true),
+				state.EXPECT().ContainerByID(containerID).Return(authDockerContainer, true),
+				state.EXPECT().TaskByID(containerID).Return(task, true).Times(2),
+			)
+			server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
+				config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
+				containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", v4BasePath+v3EndpointID, nil)
+			server.Handler.ServeHTTP(recorder, req)
+			res, err := ioutil.ReadAll(recorder.Body)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+
+			var containerResponse v4.ContainerResponse
+			require.NoError(t, json.Unmarshal(res, &containerResponse))
+			assert.Equal(t, tc.expectedSource, containerResponse.ImagePullCredentialsSource)
+			for _, secret := range []string{secretPassword, secretKey, "pull-session-token"} {
+				assert.NotContains(t, string(res), secret)
+			}
+		})
+	}
+}
+
+func TestV4ContainerMetadataAWSCredentialsEndpoint(t *testing.T) {
+	for _, tc := range []struct {
+		name                           string
+		credentialsRelativeURI         string
+		expectedAWSCredentialsEndpoint string
+	}{
+		{
+			name:                           "task with credentials",
+			credentialsRelativeURI:         "/v2/credentials/credsid",
+			expectedAWSCredentialsEndpoint: "http://169.254.170.2/v2/credentials/credsid",
+		},
+		{
+			name: "task without credentials",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			credentialsTask := newTestTask()
+			credentialsTask.SetCredentialsRelativeURI(tc.credentialsRelativeURI)
+			testV4ContainerMetadataOf(t, credentialsTask, nil, func(r *v2.ContainerResponse) {
+				r.AWSCredentialsEndpoint = tc.expectedAWSCredentialsEndpoint
+			})
+		})
+	}
+}
+
+func TestV4ContainerMetadataStopSignal(t *testing.T) {
+	for _, tc := range []struct {
+		name               string
+		dockerConfig       *string
+		expectedStopSignal *tmdsresponse.StopSignalResponse
+	}{
+		{
+			name:               "custom stop signal",
+			dockerConfig:       aws.String(`{"StopSignal":"SIGQUIT"}`),
+			expectedStopSignal: &tmdsresponse.StopSignalResponse{Signal: "SIGQUIT"},
+		},
+		{
+			name:               "default stop signal",
+			expectedStopSignal: defaultStopSignalResponse(),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testV4ContainerMetadataOf(t, nil,
+				func(c *apicontainer.Container) { c.DockerConfig.Config = tc.dockerConfig },
+				func(r *v2.ContainerResponse) { r.StopSignal = tc.expectedStopSignal })
+		})
+	}
+}
+
+func TestV4ContainerStatsMemoryAllocation(t *testing.T) {
+	testCases := []struct {
+		name                string
+		memory              uint
+		hostConfig          *string
+		expectedReservation uint64
+		expectedLimit       uint64
+	}{
+		{
+			name:                "reservation and limit",
+			memory:              512,
+			hostConfig:          aws.String(`{"MemoryReservation":268435456}`),
+			expectedReservation: 268435456,
+			expectedLimit:       536870912,
+		},
+		{
+			name:                "no limit",
+			hostConfig:          aws.String(`{"MemoryReservation":268435456}`),
+			expectedReservation: 268435456,
+			expectedLimit:       0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			state := mock_dockerstate.NewMockTaskEngineState(ctrl)
+			auditLog := mock_audit.NewMockAuditLogger(ctrl)
+			statsEngine := mock_stats.NewMockEngine(ctrl)
+			ecsClient := mock_api.NewMockECSClient(ctrl)
+
+			dockerStats := &types.StatsJSON{}
+			dockerStats.MemoryStats.Usage = 104857600
+			memoryContainer := &apicontainer.DockerContainer{
+				DockerID: containerID,
+				Container: &apicontainer.Container{
+					Name:   containerName,
+					Memory: tc.memory,
+					DockerConfig: apicontainer.DockerConfig{
+						HostConfig: tc.hostConfig,
+					},
+				},
+			}
+
+			gomock.InOrder(
+				state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
+				state.EXPECT().DockerIDByV3EndpointID(v3EndpointID).Return(containerID, true),
+				statsEngine.EXPECT().ContainerDockerStats(taskARN, containerID).Return(dockerStats, &stats.NetworkStatsPerSec{}, nil),
+				statsEngine.EXPECT().ContainerStatsRetentionWindow(taskARN, containerID).Return(80*time.Second, nil),
+				state.EXPECT().ContainerByID(containerID).Return(memoryContainer, true),
+			)
+			server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
+				config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
+				containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
+			require.NoError(t, err)
+			recorder := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", v4BasePath+v3EndpointID+"/stats", nil)
+			server.Handler.ServeHTTP(recorder, req)
+			res, err := ioutil.ReadAll(recorder.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			var statsFromResult *handlersv4.StatsResponse
+			err = json.Unmarshal(res, &statsFromResult)
+			assert.NoError(t, err)
+			require.NotNil(t, statsFromResult.Memory_allocation_stats)
+			assert.Equal(t, tc.expectedReservation, statsFromResult.Memory_allocation_stats.Reservation)
+			assert.Equal(t, tc.expectedLimit, statsFromResult.Memory_allocation_stats.Limit)
+			assert.Equal(t, uint64(104857600), statsFromResult.Memory_allocation_stats.Usage)
+		})
+	}
 }
 
 func TestV4ContainerAssociations(t *testing.T) {
@@ -1155,7 +1500,7 @@ func TestV4ContainerAssociations(t *testing.T) {
 	)
 	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-		containerInstanceArn, endpoint, acceptInsecureCert)
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
 	require.NoError(t, err)
 	recorder := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", v4BasePath+v3EndpointID+"/associations/"+associationType, nil)
@@ -1185,7 +1530,7 @@ func TestV4ContainerAssociation(t *testing.T) {
 	)
 	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-		containerInstanceArn, endpoint, acceptInsecureCert)
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
 	require.NoError(t, err)
 	recorder := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", v4BasePath+v3EndpointID+"/associations/"+associationType+"/"+associationName, nil)
@@ -1196,6 +1541,34 @@ func TestV4ContainerAssociation(t *testing.T) {
 	assert.Equal(t, expectedAssociationResponse, string(res))
 }
 
+// Tests that the v4 agent version endpoint serves the compiled-in agent version
+func TestV4AgentVersion(t *testing.T) {
+	testTMDSRequest(t, TMDSTestCase[handlersv4.AgentVersionResponse]{
+		path:                 "/v4/agent/version",
+		setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {},
+		expectedStatusCode:   http.StatusOK,
+		expectedResponseBody: handlersv4.AgentVersionResponse{
+			Version:      agentversion.Version,
+			GitShortHash: agentversion.GitShortHash,
+			GitDirty:     agentversion.GitDirty,
+			BuildTime:    agentversion.BuildTime,
+		},
+	})
+}
+
+// Tests that the v4 limits endpoint serves the rate limits the server is configured with
+func TestV4Limits(t *testing.T) {
+	testTMDSRequest(t, TMDSTestCase[handlersv4.LimitsResponse]{
+		path:                 "/v4/limits",
+		setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {},
+		expectedStatusCode:   http.StatusOK,
+		expectedResponseBody: handlersv4.LimitsResponse{
+			SteadyStateRate: config.DefaultTaskMetadataSteadyStateRate,
+			BurstRate:       config.DefaultTaskMetadataBurstRate,
+		},
+	})
+}
+
 func TestTaskHTTPEndpoint301Redirect(t *testing.T) {
 	testPathsMap := map[string]string{
 		"http://127.0.0.1/v3///task/":           "http://127.0.0.1/v3/task/",
@@ -1212,7 +1585,7 @@ func TestTaskHTTPEndpoint301Redirect(t *testing.T) {
 
 	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-		containerInstanceArn, endpoint, acceptInsecureCert)
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
 	require.NoError(t, err)
 
 	for testPath, expectedPath := range testPathsMap {
@@ -1255,7 +1628,7 @@ func TestTaskHTTPEndpointErrorCode404(t *testing.T) {
 
 	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-		containerInstanceArn, endpoint, acceptInsecureCert)
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
 	require.NoError(t, err)
 
 	for _, testPath := range testPaths {
@@ -1295,7 +1668,7 @@ func TestTaskHTTPEndpointErrorCode400(t *testing.T) {
 
 	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-		containerInstanceArn, endpoint, acceptInsecureCert)
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
 	require.NoError(t, err)
 
 	for _, testPath := range testPaths {
@@ -1334,7 +1707,7 @@ func TestTaskHTTPEndpointErrorCode500(t *testing.T) {
 
 	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-		containerInstanceArn, endpoint, acceptInsecureCert)
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
 	require.NoError(t, err)
 
 	for _, testPath := range testPaths {
@@ -1404,7 +1777,7 @@ func TestV4TaskNotFoundError404(t *testing.T) {
 
 			server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 				config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-				containerInstanceArn, endpoint, acceptInsecureCert)
+				containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
 			require.NoError(t, err)
 
 			state.EXPECT().TaskARNByV3EndpointID(gomock.Any()).Return("", tc.taskFound).AnyTimes()
@@ -1460,7 +1833,7 @@ func TestV4Unexpected500Error(t *testing.T) {
 
 			server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 				config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-				containerInstanceArn, endpoint, acceptInsecureCert)
+				containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
 			require.NoError(t, err)
 
 			// Initial lookups succeed
@@ -1489,7 +1862,8 @@ func TestV4Unexpected500Error(t *testing.T) {
 
 // Types of TMDS responses, add more types as needed
 type TMDSResponse interface {
-	v2.ContainerResponse | v2.TaskResponse | v4.ContainerResponse | v4.TaskResponse | string
+	v2.ContainerResponse | v2.TaskResponse | v4.ContainerResponse | v4.TaskResponse |
+		handlersv4.AgentVersionResponse | handlersv4.LimitsResponse | tmdsresponse.TaskVolumesResponse | string
 }
 
 // Represents a test case for TMDS. Supports generic TMDS response body types using type parametesrs.
@@ -1500,6 +1874,12 @@ type TMDSTestCase[R TMDSResponse] struct {
 	setStateExpectations func(state *mock_dockerstate.MockTaskEngineState)
 	// Function to set expectations on mock ECS Client
 	setECSClientExpectations func(ecsClient *mock_api.MockECSClient)
+	// Whether container cgroup paths are included in v4 metadata
+	includeCgroupPath bool
+	// Whether v4 metadata timestamps have nanosecond precision
+	nanosecondTimestamps bool
+	// Credentials manager of the server, an empty one is used if not set
+	credentialsManager credentials.Manager
 	// Expected HTTP status code of the response
 	expectedStatusCode int
 	// Expected response body, all JSON compatible types are accepted
@@ -1532,10 +1912,14 @@ func testTMDSRequest[R TMDSResponse](t *testing.T, tc TMDSTestCase[R]) {
 	}
 
 	// Initialize server
-	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient,
+	credentialsManager := tc.credentialsManager
+	if credentialsManager == nil {
+		credentialsManager = credentials.NewManager()
+	}
+	server, err := taskServerSetup(credentialsManager, auditLog, state, ecsClient,
 		clusterName, region, statsEngine,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, availabilityzone, vpcID,
-		containerInstanceArn, endpoint, acceptInsecureCert)
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, tc.includeCgroupPath, tc.nanosecondTimestamps, nil)
 	require.NoError(t, err)
 
 	// Create the request
@@ -1894,20 +2278,246 @@ func TestV3TaskMetadata(t *testing.T) {
 	})
 }
 
-func TestV4ContainerMetadata(t *testing.T) {
-	t.Run("v3EndpointID is invalid", func(t *testing.T) {
-		testTMDSRequest(t, TMDSTestCase[string]{
-			path: v4BasePath + v3EndpointID,
+func TestV4TaskVolumes(t *testing.T) {
+	t.Run("task with docker and EFS volumes", func(t *testing.T) {
+		volumesTask := &apitask.Task{
+			Arn: taskARN,
+			Volumes: []apitask.TaskVolume{
+				{
+					Name: "shared",
+					Type: apitask.DockerVolumeType,
+					Volume: &taskresourcevolume.DockerVolumeConfig{
+						Scope:            "shared",
+						Driver:           "local",
+						DockerVolumeName: "shared",
+						DriverOpts: map[string]string{
+							"type":   "cifs",
+							"device": "//fileserver/share",
+							"o":      "addr=fileserver,username=user,password=secret",
+						},
+					},
+				},
+				{
+					Name: "efs",
+					Type: apitask.EFSVolumeType,
+					Volume: &taskresourcevolume.EFSVolumeConfig{
+						FileSystemID: "fs-12345",
+					},
+				},
+			},
+		}
+		testTMDSRequest(t, TMDSTestCase[tmdsresponse.TaskVolumesResponse]{
+			path: v4BasePath + v3EndpointID + "/task/volumes",
 			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
 				gomock.InOrder(
-					state.EXPECT().DockerIDByV3EndpointID(v3EndpointID).Return("", false),
+					state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
+					state.EXPECT().TaskByArn(taskARN).Return(volumesTask, true),
 				)
 			},
-			expectedStatusCode: http.StatusNotFound,
-			expectedResponseBody: fmt.Sprintf(
-				"V4 container metadata handler: unable to get container ID from request: unable to get docker ID from v3 endpoint ID: %s",
-				v3EndpointID),
-		})
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: tmdsresponse.TaskVolumesResponse{
+				TaskARN: taskARN,
+				Volumes: []tmdsresponse.TaskVolumeResponse{
+					{
+						Name:   "shared",
+						Type:   "volume",
+						Source: "shared",
+						Driver: "local",
+						DriverOptions: map[string]string{
+							"type":   "cifs",
+							"device": "//fileserver/share",
+							"o":      "addr=fileserver,username=user,password=REDACTED",
+						},
+					},
+					{
+						Name:   "efs",
+						Type:   "efs",
+						Source: "fs-12345",
+					},
+				},
+			},
+		})
+	})
+	t.Run("task with only bind mounts", func(t *testing.T) {
+		volumesTask := &apitask.Task{
+			Arn: taskARN,
+			Volumes: []apitask.TaskVolume{
+				{
+					Name:   "data",
+					Type:   apitask.HostVolumeType,
+					Volume: &taskresourcevolume.FSHostVolume{FSSourcePath: "/var/data"},
+				},
+			},
+		}
+		testTMDSRequest(t, TMDSTestCase[tmdsresponse.TaskVolumesResponse]{
+			path: v4BasePath + v3EndpointID + "/task/volumes",
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
+					state.EXPECT().TaskByArn(taskARN).Return(volumesTask, true),
+				)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: tmdsresponse.TaskVolumesResponse{
+				TaskARN: taskARN,
+				Volumes: []tmdsresponse.TaskVolumeResponse{
+					{Name: "data", Type: "bind", Source: "/var/data"},
+				},
+			},
+		})
+	})
+	t.Run("unknown task", func(t *testing.T) {
+		testTMDSRequest(t, TMDSTestCase[string]{
+			path: v4BasePath + v3EndpointID + "/task/volumes",
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return("", false)
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponseBody: "V4 task volumes handler: unable to get task arn from request: " +
+				"unable to get task Arn from v3 endpoint ID: " + v3EndpointID,
+		})
+	})
+}
+
+// Tests that v4 container metadata reports the IP addresses of the container on each of its networks
+func TestV4ContainerMetadataNetworkIPAddresses(t *testing.T) {
+	t.Run("bridge mode container on multiple networks", func(t *testing.T) {
+		multiNetworkContainer := &apicontainer.DockerContainer{
+			DockerID:   containerID,
+			DockerName: containerName,
+			Container: &apicontainer.Container{
+				Name:                containerName,
+				Image:               imageName,
+				ImageID:             imageID,
+				DesiredStatusUnsafe: apicontainerstatus.ContainerRunning,
+				KnownStatusUnsafe:   apicontainerstatus.ContainerRunning,
+				CPU:                 cpu,
+				Memory:              memory,
+				Type:                apicontainer.ContainerNormal,
+				NetworkModeUnsafe:   bridgeMode,
+				NetworkSettingsUnsafe: &types.NetworkSettings{
+					DefaultNetworkSettings: types.DefaultNetworkSettings{
+						IPAddress: bridgeIPAddr,
+					},
+					Networks: map[string]*network.EndpointSettings{
+						bridgeMode: {
+							IPAddress:         bridgeIPAddr,
+							GlobalIPv6Address: "2001:db8::3",
+						},
+						"user-defined": {
+							IPAddress: "172.18.0.2",
+						},
+					},
+				},
+			},
+		}
+		multiNetworkContainer.Container.SetLabels(labels)
+		v2Response := v2.ContainerResponse{
+			ID:              containerID,
+			Name:            containerName,
+			DockerName:      containerName,
+			Image:           imageName,
+			ImageID:         imageID,
+			DesiredStatus:   statusRunning,
+			KnownStatus:     statusRunning,
+			Limits:          v2.LimitsResponse{CPU: aws.Float64(cpu), Memory: aws.Int64(memory)},
+			Type:            containerType,
+			Labels:          labels,
+			SeccompProfile:  apicontainer.SecurityProfileDefault,
+			AppArmorProfile: apicontainer.SecurityProfileDefault,
+			StopSignal:      defaultStopSignalResponse(),
+			NetworkIPAddresses: map[string][]string{
+				bridgeMode:     {bridgeIPAddr, "2001:db8::3"},
+				"user-defined": {"172.18.0.2"},
+			},
+		}
+		expectedResponse := v4.ContainerResponse{
+			ContainerResponse: &v2Response,
+			Networks: []v4.Network{
+				{Network: tmdsresponse.Network{NetworkMode: bridgeMode, IPv4Addresses: []string{bridgeIPAddr}}},
+				{Network: tmdsresponse.Network{NetworkMode: "user-defined", IPv4Addresses: []string{"172.18.0.2"}}},
+			},
+		}
+		testTMDSRequest(t, TMDSTestCase[v4.ContainerResponse]{
+			path: v4BasePath + v3EndpointID,
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().DockerIDByV3EndpointID(v3EndpointID).Return(containerID, true),
+					state.EXPECT().ContainerByID(containerID).Return(multiNetworkContainer, true),
+					state.EXPECT().TaskByID(containerID).Return(bridgeTask, true),
+					state.EXPECT().ContainerByID(containerID).Return(multiNetworkContainer, true),
+				)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: expectedResponse,
+		})
+	})
+	t.Run("awsvpc mode container", func(t *testing.T) {
+		// Containers of awsvpc tasks report the addresses of the task's ENI
+		require.Equal(t, map[string][]string{utils.NetworkModeAWSVPC: {eniIPv4Address}},
+			expectedV4ContainerResponse.NetworkIPAddresses)
+		testTMDSRequest(t, TMDSTestCase[v4.ContainerResponse]{
+			path: v4BasePath + v3EndpointID,
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().DockerIDByV3EndpointID(v3EndpointID).Return(containerID, true),
+					state.EXPECT().ContainerByID(containerID).Return(dockerContainer, true),
+					state.EXPECT().TaskByID(containerID).Return(task, true).Times(2),
+				)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: expectedV4ContainerResponse,
+		})
+	})
+}
+
+func TestV4SelfContainerMetadata(t *testing.T) {
+	t.Run("known caller", func(t *testing.T) {
+		testTMDSRequest(t, TMDSTestCase[v4.ContainerResponse]{
+			path: v4BasePath + "self",
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().GetTaskByIPAddress(remoteIP).Return(taskARN, true),
+					state.EXPECT().ContainerMapByArn(taskARN).Return(containerNameToDockerContainer, true),
+					state.EXPECT().ContainerByID(containerID).Return(dockerContainer, true),
+					state.EXPECT().TaskByID(containerID).Return(task, true).Times(2),
+				)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: expectedV4ContainerResponse,
+		})
+	})
+	t.Run("unknown caller", func(t *testing.T) {
+		testTMDSRequest(t, TMDSTestCase[string]{
+			path: v4BasePath + "self",
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().GetTaskByIPAddress(remoteIP).Return("", false),
+					state.EXPECT().AllTasks().Return([]*apitask.Task{bridgeTask}),
+					state.EXPECT().ContainerMapByArn(taskARN).Return(containerNameToBridgeContainer, true),
+				)
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponseBody: "V4 container metadata handler: " +
+				"unable to resolve the calling container from source IP: " + remoteIP,
+		})
+	})
+}
+
+func TestV4ContainerMetadata(t *testing.T) {
+	t.Run("v3EndpointID is invalid", func(t *testing.T) {
+		testTMDSRequest(t, TMDSTestCase[string]{
+			path: v4BasePath + v3EndpointID,
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().DockerIDByV3EndpointID(v3EndpointID).Return("", false),
+				)
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedResponseBody: fmt.Sprintf(
+				"V4 container metadata handler: unable to get container ID from request: unable to get docker ID from v3 endpoint ID: %s",
+				v3EndpointID),
+		})
 	})
 	t.Run("container not found", func(t *testing.T) {
 		testTMDSRequest(t, TMDSTestCase[string]{
@@ -1968,6 +2578,217 @@ func TestV4ContainerMetadata(t *testing.T) {
 			expectedResponseBody: expectedV4ContainerResponse,
 		})
 	})
+	volumesTask := &apitask.Task{
+		Arn:                 taskARN,
+		Family:              family,
+		Version:             version,
+		DesiredStatusUnsafe: apitaskstatus.TaskRunning,
+		KnownStatusUnsafe:   apitaskstatus.TaskRunning,
+		NetworkMode:         apitask.AWSVPCNetworkMode,
+		ENIs:                task.ENIs,
+		Volumes: []apitask.TaskVolume{
+			{
+				Name: "efs-data",
+				Type: apitask.EFSVolumeType,
+				Volume: &taskresourcevolume.EFSVolumeConfig{
+					FileSystemID:     "fs-12345678",
+					RootDirectory:    "/data",
+					DockerVolumeName: "ecs-sleep-1-efs-data",
+				},
+			},
+			{
+				Name:   "host-logs",
+				Type:   apitask.HostVolumeType,
+				Volume: &taskresourcevolume.FSHostVolume{FSSourcePath: "/var/log/app"},
+			},
+		},
+	}
+	sharedPIDTask := newTestTask()
+	sharedPIDTask.PIDMode = "task"
+	hostConfig := func(hostConfig string) func(*apicontainer.Container) {
+		return func(c *apicontainer.Container) {
+			c.DockerConfig.HostConfig = &hostConfig
+		}
+	}
+	for _, tc := range []struct {
+		name string
+		// task is the task of the container, the test task if nil
+		task *apitask.Task
+		// setContainer sets the fields under test of the test container
+		setContainer func(*apicontainer.Container)
+		// setResponse sets the fields under test of the expected response
+		setResponse func(*v2.ContainerResponse)
+	}{
+		{
+			name:         "security profiles unconfined and named profiles",
+			setContainer: hostConfig(`{"SecurityOpt":["seccomp=unconfined","apparmor=custom-apparmor-profile"]}`),
+			setResponse: func(r *v2.ContainerResponse) {
+				r.SeccompProfile = apicontainer.SecurityProfileUnconfined
+				r.AppArmorProfile = "custom-apparmor-profile"
+			},
+		},
+		{
+			name:         "security profiles inline seccomp profile and legacy separator",
+			setContainer: hostConfig(`{"SecurityOpt":["seccomp={\"defaultAction\":\"SCMP_ACT_ERRNO\"}","apparmor:unconfined"]}`),
+			setResponse: func(r *v2.ContainerResponse) {
+				r.SeccompProfile = apicontainer.SecurityProfileCustom
+				r.AppArmorProfile = apicontainer.SecurityProfileUnconfined
+			},
+		},
+		{
+			name:         "security profiles unrelated security options",
+			setContainer: hostConfig(`{"SecurityOpt":["no-new-privileges"]}`),
+			setResponse: func(r *v2.ContainerResponse) {
+				r.SeccompProfile = apicontainer.SecurityProfileDefault
+				r.AppArmorProfile = apicontainer.SecurityProfileDefault
+			},
+		},
+		{
+			name:         "container with extra hosts",
+			setContainer: hostConfig(`{"ExtraHosts":["db.internal:10.0.0.12","ipv6.internal:2001:db8::1"]}`),
+			setResponse: func(r *v2.ContainerResponse) {
+				r.ExtraHosts = []tmdsresponse.ExtraHostResponse{
+					{Hostname: "db.internal", IPAddress: "10.0.0.12"},
+					{Hostname: "ipv6.internal", IPAddress: "2001:db8::1"},
+				}
+			},
+		},
+		{
+			name:         "container without extra hosts",
+			setContainer: hostConfig(`{}`),
+		},
+		{
+			name: "container with restart policy",
+			setContainer: func(c *apicontainer.Container) {
+				c.RestartPolicy = "ON_FAILURE"
+				c.RestartMaxAttempts = 3
+			},
+			setResponse: func(r *v2.ContainerResponse) {
+				r.RestartPolicy = &tmdsresponse.RestartPolicyResponse{Policy: "ON_FAILURE", MaxAttempts: aws.Int64(3)}
+			},
+		},
+		{
+			name:         "container with unlimited restart policy",
+			setContainer: func(c *apicontainer.Container) { c.RestartPolicy = "UNLESS_TASK_STOPPED" },
+			setResponse: func(r *v2.ContainerResponse) {
+				r.RestartPolicy = &tmdsresponse.RestartPolicyResponse{Policy: "UNLESS_TASK_STOPPED"}
+			},
+		},
+		{
+			name: "container without restart policy",
+		},
+		{
+			name: "container with container tags",
+			setContainer: func(c *apicontainer.Container) {
+				c.SetLabels(map[string]string{
+					"foo": "bar",
+					apicontainer.ContainerTagLabelPrefix + "team":        "payments",
+					apicontainer.ContainerTagLabelPrefix + "cost-center": "1234",
+				})
+			},
+			setResponse: func(r *v2.ContainerResponse) {
+				// Prefixed labels are reported as container tags and kept in the raw labels
+				r.Labels = map[string]string{
+					"foo": "bar",
+					apicontainer.ContainerTagLabelPrefix + "team":        "payments",
+					apicontainer.ContainerTagLabelPrefix + "cost-center": "1234",
+				}
+				r.ContainerTags = map[string]string{"team": "payments", "cost-center": "1234"}
+			},
+		},
+		{
+			name:         "container without container tags",
+			setContainer: func(c *apicontainer.Container) { c.SetLabels(map[string]string{"foo": "bar"}) },
+			setResponse:  func(r *v2.ContainerResponse) { r.Labels = map[string]string{"foo": "bar"} },
+		},
+		{
+			name: "container with entrypoint, command and working directory",
+			setContainer: func(c *apicontainer.Container) {
+				c.EntryPoint = &[]string{"/bin/sh", "-c"}
+				c.Command = []string{"echo", "hello"}
+				c.DockerConfig.Config = aws.String(`{"WorkingDir":"/app"}`)
+			},
+			setResponse: func(r *v2.ContainerResponse) {
+				r.Entrypoint = []string{"/bin/sh", "-c"}
+				r.Command = []string{"echo", "hello"}
+				r.WorkingDirectory = "/app"
+			},
+		},
+		{
+			name:         "container with command only",
+			setContainer: func(c *apicontainer.Container) { c.Command = []string{"sleep", "3600"} },
+			setResponse:  func(r *v2.ContainerResponse) { r.Command = []string{"sleep", "3600"} },
+		},
+		{
+			name:         "container without entrypoint, command and working directory",
+			setContainer: func(c *apicontainer.Container) { c.DockerConfig.Config = aws.String(`{}`) },
+		},
+		{
+			name:         "container with init process enabled",
+			setContainer: hostConfig(`{"Init":true}`),
+			setResponse:  func(r *v2.ContainerResponse) { r.InitProcessEnabled = true },
+		},
+		{
+			name:         "container with init process disabled",
+			setContainer: hostConfig(`{"Init":false}`),
+		},
+		{
+			name:         "container without init process setting",
+			setContainer: hostConfig(`{}`),
+		},
+		{
+			name: "container with json-file log rotation",
+			setContainer: hostConfig(
+				`{"LogConfig":{"Type":"json-file","Config":{"max-size":"10m","max-file":"3","mode":"non-blocking"}}}`),
+			setResponse: func(r *v2.ContainerResponse) {
+				r.LogDriver = "json-file"
+				r.LogOptions = map[string]string{"max-size": "10m", "max-file": "3", "mode": "non-blocking"}
+				r.LogRetention = &tmdsresponse.LogRetentionResponse{MaxSize: "10m", MaxFile: "3", Mode: "non-blocking"}
+			},
+		},
+		{
+			name:         "container with json-file driver and no rotation",
+			setContainer: hostConfig(`{"LogConfig":{"Type":"json-file","Config":{"labels":"app"}}}`),
+			setResponse: func(r *v2.ContainerResponse) {
+				r.LogDriver = "json-file"
+				r.LogOptions = map[string]string{"labels": "app"}
+			},
+		},
+		{
+			name: "container with EFS and bind volume mounts",
+			task: volumesTask,
+			setContainer: func(c *apicontainer.Container) {
+				c.MountPoints = []apicontainer.MountPoint{
+					{SourceVolume: "efs-data", ContainerPath: "/mnt/efs", ReadOnly: true},
+					{SourceVolume: "host-logs", ContainerPath: "/logs"},
+				}
+			},
+			setResponse: func(r *v2.ContainerResponse) {
+				r.VolumeMounts = []tmdsresponse.VolumeMountResponse{
+					{SourceType: "efs", Source: "fs-12345678", Destination: "/mnt/efs", ReadOnly: true},
+					{SourceType: "bind", Source: "/var/log/app", Destination: "/logs", ReadOnly: false},
+				}
+			},
+		},
+		{
+			name: "container with HEALTHY dependency",
+			setContainer: func(c *apicontainer.Container) {
+				c.DependsOnUnsafe = []apicontainer.DependsOn{{ContainerName: "database", Condition: "HEALTHY"}}
+			},
+			setResponse: func(r *v2.ContainerResponse) {
+				r.DependsOn = []tmdsresponse.DependsOnResponse{{ContainerName: "database", Condition: "HEALTHY"}}
+			},
+		},
+		{
+			name:        "container in task with shared PID namespace",
+			task:        sharedPIDTask,
+			setResponse: func(r *v2.ContainerResponse) { r.PidMode = "task" },
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			testV4ContainerMetadataOf(t, tc.task, tc.setContainer, tc.setResponse)
+		})
+	}
 	t.Run("bridge mode container not found during network population", func(t *testing.T) {
 		testTMDSRequest(t, TMDSTestCase[string]{
 			path: v4BasePath + v3EndpointID,
@@ -2014,6 +2835,116 @@ func TestV4ContainerMetadata(t *testing.T) {
 			expectedResponseBody: expectedV4BridgeContainerResponse,
 		})
 	})
+	t.Run("bridge mode container with dynamic host ports", func(t *testing.T) {
+		dynamicPortsContainer := &apicontainer.DockerContainer{
+			DockerID:   containerID,
+			DockerName: containerName,
+			Container: &apicontainer.Container{
+				Name:                containerName,
+				Image:               imageName,
+				ImageID:             imageID,
+				DesiredStatusUnsafe: apicontainerstatus.ContainerRunning,
+				KnownStatusUnsafe:   apicontainerstatus.ContainerRunning,
+				CPU:                 cpu,
+				Memory:              memory,
+				Type:                apicontainer.ContainerNormal,
+				KnownPortBindingsUnsafe: []apicontainer.PortBinding{
+					{
+						ContainerPort: 80,
+						HostPort:      32768,
+						BindIP:        "0.0.0.0",
+						Protocol:      apicontainer.TransportProtocolTCP,
+					},
+					{
+						ContainerPort: 53,
+						HostPort:      32769,
+						BindIP:        "0.0.0.0",
+						Protocol:      apicontainer.TransportProtocolUDP,
+					},
+					{
+						ContainerPort: 8080,
+						Protocol:      apicontainer.TransportProtocolTCP,
+					},
+				},
+				NetworkModeUnsafe: bridgeMode,
+				NetworkSettingsUnsafe: &types.NetworkSettings{
+					DefaultNetworkSettings: types.DefaultNetworkSettings{
+						IPAddress: bridgeIPAddr,
+					},
+				},
+			},
+		}
+		dynamicPortsContainer.Container.SetLabels(labels)
+		expectedResponse := expectedV4BridgeContainerResponse
+		v2Response := *expectedResponse.ContainerResponse
+		v2Response.Ports = []tmdsresponse.PortResponse{
+			{
+				ContainerPort: 80,
+				Protocol:      "tcp",
+				HostPort:      32768,
+				HostIp:        "0.0.0.0",
+			},
+			{
+				ContainerPort: 53,
+				Protocol:      "udp",
+				HostPort:      32769,
+				HostIp:        "0.0.0.0",
+			},
+		}
+		expectedResponse.ContainerResponse = &v2Response
+		testTMDSRequest(t, TMDSTestCase[v4.ContainerResponse]{
+			path: v4BasePath + v3EndpointID,
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().DockerIDByV3EndpointID(v3EndpointID).Return(containerID, true),
+					state.EXPECT().ContainerByID(containerID).Return(dynamicPortsContainer, true),
+					state.EXPECT().TaskByID(containerID).Return(bridgeTask, true),
+					state.EXPECT().ContainerByID(containerID).Return(dynamicPortsContainer, true),
+				)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: expectedResponse,
+		})
+	})
+}
+
+// testV4ContainerMetadataOf tests the v4 metadata of a test container of the task, the
+// test task if nil. setContainer sets the fields under test of the container, and
+// setResponse sets them in a copy of expectedV4ContainerResponse. Either may be nil.
+func testV4ContainerMetadataOf(t *testing.T, containerTask *apitask.Task,
+	setContainer func(*apicontainer.Container), setResponse func(*v2.ContainerResponse)) {
+	if containerTask == nil {
+		containerTask = newTestTask()
+	}
+	testContainer := newTestContainer()
+	testContainer.SetLabels(labels)
+	if setContainer != nil {
+		setContainer(testContainer)
+	}
+	testDockerContainer := &apicontainer.DockerContainer{
+		DockerID:   containerID,
+		DockerName: containerName,
+		Container:  testContainer,
+	}
+	v2Response := *expectedV4ContainerResponse.ContainerResponse
+	if setResponse != nil {
+		setResponse(&v2Response)
+	}
+	testTMDSRequest(t, TMDSTestCase[v4.ContainerResponse]{
+		path: v4BasePath + v3EndpointID,
+		setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+			gomock.InOrder(
+				state.EXPECT().DockerIDByV3EndpointID(v3EndpointID).Return(containerID, true),
+				state.EXPECT().ContainerByID(containerID).Return(testDockerContainer, true),
+				state.EXPECT().TaskByID(containerID).Return(containerTask, true).Times(2),
+			)
+		},
+		expectedStatusCode: http.StatusOK,
+		expectedResponseBody: v4.ContainerResponse{
+			ContainerResponse: &v2Response,
+			Networks:          expectedV4ContainerResponse.Networks,
+		},
+	})
 }
 
 func TestV4TaskMetadata(t *testing.T) {
@@ -2105,6 +3036,448 @@ func TestV4TaskMetadata(t *testing.T) {
 			expectedResponseBody: expectedV4TaskResponse(),
 		})
 	})
+	t.Run("task with task and execution roles", func(t *testing.T) {
+		roleTask := &apitask.Task{
+			Arn:                      taskARN,
+			Family:                   family,
+			Version:                  version,
+			DesiredStatusUnsafe:      apitaskstatus.TaskRunning,
+			KnownStatusUnsafe:        apitaskstatus.TaskRunning,
+			NetworkMode:              apitask.AWSVPCNetworkMode,
+			CPU:                      cpu,
+			Memory:                   memory,
+			PullStartedAtUnsafe:      now,
+			PullStoppedAtUnsafe:      now,
+			ExecutionStoppedAtUnsafe: now,
+			LaunchType:               "EC2",
+		}
+		roleTask.SetCredentialsID("task-role-credentials")
+		roleTask.SetExecutionRoleCredentialsID("execution-role-credentials")
+		credentialsManager := credentials.NewManager()
+		for _, roleCredentials := range []credentials.IAMRoleCredentials{
+			{
+				CredentialsID:   "task-role-credentials",
+				RoleArn:         "arn:aws:iam::123456789012:role/task-role",
+				AccessKeyID:     "task-access-key",
+				SecretAccessKey: "task-secret-key",
+				SessionToken:    "task-session-token",
+			},
+			{
+				CredentialsID:   "execution-role-credentials",
+				RoleArn:         "arn:aws:iam::123456789012:role/execution-role",
+				AccessKeyID:     "execution-access-key",
+				SecretAccessKey: "execution-secret-key",
+				SessionToken:    "execution-session-token",
+			},
+		} {
+			require.NoError(t, credentialsManager.SetTaskCredentials(&credentials.TaskIAMRoleCredentials{
+				ARN:                taskARN,
+				IAMRoleCredentials: roleCredentials,
+			}))
+		}
+		expectedResponse := expectedV4TaskResponseNoContainers()
+		expectedResponse.TaskRoleArn = "arn:aws:iam::123456789012:role/task-role"
+		expectedResponse.ExecutionRoleArn = "arn:aws:iam::123456789012:role/execution-role"
+		testTMDSRequest(t, TMDSTestCase[v4.TaskResponse]{
+			path:               v4BasePath + v3EndpointID + "/task",
+			credentialsManager: credentialsManager,
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
+					state.EXPECT().TaskByArn(taskARN).Return(roleTask, true).Times(2),
+					state.EXPECT().ContainerMapByArn(taskARN).Return(nil, false),
+					state.EXPECT().PulledContainerMapByArn(taskARN).Return(nil, true),
+				)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: expectedResponse,
+		})
+	})
+	for _, tc := range []struct {
+		name                   string
+		serviceName            string
+		schedulingStrategy     string
+		expectedServiceContext *v4.ServiceContext
+	}{
+		{
+			name:                   "service task",
+			serviceName:            "my-service",
+			expectedServiceContext: &v4.ServiceContext{ServiceName: "my-service"},
+		},
+		{
+			name:               "daemon service task",
+			serviceName:        "my-daemon-service",
+			schedulingStrategy: "DAEMON",
+			expectedServiceContext: &v4.ServiceContext{
+				ServiceName:        "my-daemon-service",
+				SchedulingStrategy: "DAEMON",
+			},
+		},
+		{
+			name:                   "standalone task",
+			expectedServiceContext: nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			serviceTask := &apitask.Task{
+				Arn:                      taskARN,
+				Family:                   family,
+				Version:                  version,
+				DesiredStatusUnsafe:      apitaskstatus.TaskRunning,
+				KnownStatusUnsafe:        apitaskstatus.TaskRunning,
+				NetworkMode:              apitask.AWSVPCNetworkMode,
+				CPU:                      cpu,
+				Memory:                   memory,
+				PullStartedAtUnsafe:      now,
+				PullStoppedAtUnsafe:      now,
+				ExecutionStoppedAtUnsafe: now,
+				LaunchType:               "EC2",
+				ServiceName:              tc.serviceName,
+				SchedulingStrategy:       tc.schedulingStrategy,
+			}
+			expectedResponse := expectedV4TaskResponseNoContainers()
+			expectedResponse.ServiceName = tc.serviceName
+			expectedResponse.ServiceContext = tc.expectedServiceContext
+			testTMDSRequest(t, TMDSTestCase[v4.TaskResponse]{
+				path: v4BasePath + v3EndpointID + "/task",
+				setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+					gomock.InOrder(
+						state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
+						state.EXPECT().TaskByArn(taskARN).Return(serviceTask, true).Times(2),
+						state.EXPECT().ContainerMapByArn(taskARN).Return(nil, false),
+						state.EXPECT().PulledContainerMapByArn(taskARN).Return(nil, true),
+					)
+				},
+				expectedStatusCode:   http.StatusOK,
+				expectedResponseBody: expectedResponse,
+			})
+		})
+	}
+	t.Run("fargate task with ephemeral storage encryption", func(t *testing.T) {
+		fargateTask := &apitask.Task{
+			Arn:                       taskARN,
+			Family:                    family,
+			Version:                   version,
+			DesiredStatusUnsafe:       apitaskstatus.TaskRunning,
+			KnownStatusUnsafe:         apitaskstatus.TaskRunning,
+			NetworkMode:               apitask.AWSVPCNetworkMode,
+			CPU:                       cpu,
+			Memory:                    memory,
+			PullStartedAtUnsafe:       now,
+			PullStoppedAtUnsafe:       now,
+			ExecutionStoppedAtUnsafe:  now,
+			LaunchType:                "FARGATE",
+			EphemeralStorageEncrypted: aws.Bool(true),
+		}
+		expectedResponse := expectedV4TaskResponseNoContainers()
+		expectedResponse.LaunchType = "FARGATE"
+		expectedResponse.EphemeralStorageEncrypted = aws.Bool(true)
+		testTMDSRequest(t, TMDSTestCase[v4.TaskResponse]{
+			path: v4BasePath + v3EndpointID + "/task",
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
+					state.EXPECT().TaskByArn(taskARN).Return(fargateTask, true).Times(2),
+					state.EXPECT().ContainerMapByArn(taskARN).Return(nil, false),
+					state.EXPECT().PulledContainerMapByArn(taskARN).Return(nil, true),
+				)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: expectedResponse,
+		})
+	})
+	for _, tc := range []struct {
+		name                 string
+		nanosecondTimestamps bool
+		expectedTimestamp    time.Time
+	}{
+		{
+			name:              "timestamps with second precision",
+			expectedTimestamp: now.UTC(),
+		},
+		{
+			name:                 "timestamps with nanosecond precision",
+			nanosecondTimestamps: true,
+			expectedTimestamp:    now.Add(123456789 * time.Nanosecond).UTC(),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// Timestamps are reported in UTC regardless of the location they are recorded in
+			timestamp := now.Add(123456789 * time.Nanosecond).In(time.FixedZone("UTC+5", 5*60*60))
+			timestampedTask := &apitask.Task{
+				Arn:                      taskARN,
+				Family:                   family,
+				Version:                  version,
+				DesiredStatusUnsafe:      apitaskstatus.TaskRunning,
+				KnownStatusUnsafe:        apitaskstatus.TaskRunning,
+				NetworkMode:              apitask.AWSVPCNetworkMode,
+				CPU:                      cpu,
+				Memory:                   memory,
+				PullStartedAtUnsafe:      timestamp,
+				PullStoppedAtUnsafe:      timestamp,
+				ExecutionStoppedAtUnsafe: timestamp,
+				LaunchType:               "EC2",
+			}
+			expectedResponse := expectedV4TaskResponseNoContainers()
+			expectedResponse.PullStartedAt = aws.Time(tc.expectedTimestamp)
+			expectedResponse.PullStoppedAt = aws.Time(tc.expectedTimestamp)
+			expectedResponse.ExecutionStoppedAt = aws.Time(tc.expectedTimestamp)
+			testTMDSRequest(t, TMDSTestCase[v4.TaskResponse]{
+				path: v4BasePath + v3EndpointID + "/task",
+				setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+					gomock.InOrder(
+						state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
+						state.EXPECT().TaskByArn(taskARN).Return(timestampedTask, true).Times(2),
+						state.EXPECT().ContainerMapByArn(taskARN).Return(nil, false),
+						state.EXPECT().PulledContainerMapByArn(taskARN).Return(nil, true),
+					)
+				},
+				nanosecondTimestamps: tc.nanosecondTimestamps,
+				expectedStatusCode:   http.StatusOK,
+				expectedResponseBody: expectedResponse,
+			})
+		})
+	}
+	t.Run("host mode task", func(t *testing.T) {
+		hostTask := &apitask.Task{
+			Arn:                      taskARN,
+			Family:                   family,
+			Version:                  version,
+			DesiredStatusUnsafe:      apitaskstatus.TaskRunning,
+			KnownStatusUnsafe:        apitaskstatus.TaskRunning,
+			NetworkMode:              apitask.HostNetworkMode,
+			CPU:                      cpu,
+			Memory:                   memory,
+			PullStartedAtUnsafe:      now,
+			PullStoppedAtUnsafe:      now,
+			ExecutionStoppedAtUnsafe: now,
+			LaunchType:               "EC2",
+		}
+		expectedResponse := expectedV4TaskResponseNoContainers()
+		expectedResponse.NetworkMode = apitask.HostNetworkMode
+		testTMDSRequest(t, TMDSTestCase[v4.TaskResponse]{
+			path: v4BasePath + v3EndpointID + "/task",
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
+					state.EXPECT().TaskByArn(taskARN).Return(hostTask, true).Times(2),
+					state.EXPECT().ContainerMapByArn(taskARN).Return(nil, false),
+					state.EXPECT().PulledContainerMapByArn(taskARN).Return(nil, true),
+				)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: expectedResponse,
+		})
+	})
+	t.Run("task with shared PID and IPC namespaces", func(t *testing.T) {
+		sharedNamespacesTask := &apitask.Task{
+			Arn:                      taskARN,
+			Family:                   family,
+			Version:                  version,
+			DesiredStatusUnsafe:      apitaskstatus.TaskRunning,
+			KnownStatusUnsafe:        apitaskstatus.TaskRunning,
+			NetworkMode:              apitask.HostNetworkMode,
+			CPU:                      cpu,
+			Memory:                   memory,
+			PullStartedAtUnsafe:      now,
+			PullStoppedAtUnsafe:      now,
+			ExecutionStoppedAtUnsafe: now,
+			LaunchType:               "EC2",
+			PIDMode:                  "task",
+			IPCMode:                  "host",
+		}
+		expectedResponse := expectedV4TaskResponseNoContainers()
+		expectedResponse.NetworkMode = apitask.HostNetworkMode
+		expectedResponse.PidMode = "task"
+		expectedResponse.IpcMode = "host"
+		testTMDSRequest(t, TMDSTestCase[v4.TaskResponse]{
+			path: v4BasePath + v3EndpointID + "/task",
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
+					state.EXPECT().TaskByArn(taskARN).Return(sharedNamespacesTask, true).Times(2),
+					state.EXPECT().ContainerMapByArn(taskARN).Return(nil, false),
+					state.EXPECT().PulledContainerMapByArn(taskARN).Return(nil, true),
+				)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: expectedResponse,
+		})
+	})
+	t.Run("service connect task", func(t *testing.T) {
+		scTask := &apitask.Task{
+			Arn:                      taskARN,
+			Family:                   family,
+			Version:                  version,
+			DesiredStatusUnsafe:      apitaskstatus.TaskRunning,
+			KnownStatusUnsafe:        apitaskstatus.TaskRunning,
+			NetworkMode:              apitask.BridgeNetworkMode,
+			CPU:                      cpu,
+			Memory:                   memory,
+			PullStartedAtUnsafe:      now,
+			PullStoppedAtUnsafe:      now,
+			ExecutionStoppedAtUnsafe: now,
+			LaunchType:               "EC2",
+			Containers:               []*apicontainer.Container{{Name: "service-connect"}},
+			ServiceConnectConfig: &serviceconnect.Config{
+				ContainerName: "service-connect",
+				IngressConfig: []serviceconnect.IngressConfigEntry{
+					{ListenerName: "web", ListenerPort: 15000, HostPort: aws.Uint16(8080)},
+				},
+				DNSConfig: []serviceconnect.DNSConfigEntry{
+					{HostName: "backend.my.corp", Address: "169.254.1.1"},
+					{HostName: "db.my.corp", Address: "169.254.1.2"},
+				},
+			},
+		}
+		expectedResponse := expectedV4TaskResponseNoContainers()
+		expectedResponse.NetworkMode = apitask.BridgeNetworkMode
+		expectedResponse.ServiceDiscovery = &tmdsresponse.ServiceDiscoveryResponse{
+			Ingress: []tmdsresponse.ServiceDiscoveryIngressResponse{
+				{ListenerName: "web", ListenerPort: 15000, HostPort: 8080},
+			},
+			DNSRecords: []tmdsresponse.ServiceDiscoveryDNSResponse{
+				{HostName: "backend.my.corp", Address: "169.254.1.1"},
+				{HostName: "db.my.corp", Address: "169.254.1.2"},
+			},
+		}
+		testTMDSRequest(t, TMDSTestCase[v4.TaskResponse]{
+			path: v4BasePath + v3EndpointID + "/task",
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
+					state.EXPECT().TaskByArn(taskARN).Return(scTask, true).Times(2),
+					state.EXPECT().ContainerMapByArn(taskARN).Return(nil, false),
+					state.EXPECT().PulledContainerMapByArn(taskARN).Return(nil, true),
+				)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: expectedResponse,
+		})
+	})
+	t.Run("pull timestamps across the pull lifecycle", func(t *testing.T) {
+		testCases := []struct {
+			name                  string
+			pullStartedAt         time.Time
+			pullStoppedAt         time.Time
+			expectedPullStartedAt *time.Time
+			expectedPullStoppedAt *time.Time
+		}{
+			{
+				name: "pull not started",
+			},
+			{
+				name:                  "pull in progress",
+				pullStartedAt:         now,
+				expectedPullStartedAt: aws.Time(now.UTC()),
+			},
+			{
+				name:                  "pull completed",
+				pullStartedAt:         now,
+				pullStoppedAt:         now.Add(time.Minute),
+				expectedPullStartedAt: aws.Time(now.UTC()),
+				expectedPullStoppedAt: aws.Time(now.Add(time.Minute).UTC()),
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				pullingTask := &apitask.Task{
+					Arn:                 taskARN,
+					Family:              family,
+					Version:             version,
+					DesiredStatusUnsafe: apitaskstatus.TaskRunning,
+					KnownStatusUnsafe:   apitaskstatus.TaskStatusNone,
+					NetworkMode:         apitask.HostNetworkMode,
+					CPU:                 cpu,
+					Memory:              memory,
+					PullStartedAtUnsafe: tc.pullStartedAt,
+					PullStoppedAtUnsafe: tc.pullStoppedAt,
+					LaunchType:          "EC2",
+				}
+				expectedResponse := expectedV4TaskResponseNoContainers()
+				expectedResponse.KnownStatus = statusNone
+				expectedResponse.NetworkMode = apitask.HostNetworkMode
+				expectedResponse.PullStartedAt = tc.expectedPullStartedAt
+				expectedResponse.PullStoppedAt = tc.expectedPullStoppedAt
+				expectedResponse.ExecutionStoppedAt = nil
+				testTMDSRequest(t, TMDSTestCase[v4.TaskResponse]{
+					path: v4BasePath + v3EndpointID + "/task",
+					setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+						gomock.InOrder(
+							state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
+							state.EXPECT().TaskByArn(taskARN).Return(pullingTask, true).Times(2),
+							state.EXPECT().ContainerMapByArn(taskARN).Return(nil, false),
+							state.EXPECT().PulledContainerMapByArn(taskARN).Return(nil, true),
+						)
+					},
+					expectedStatusCode:   http.StatusOK,
+					expectedResponseBody: expectedResponse,
+				})
+			})
+		}
+	})
+	t.Run("stopped task with stop reason", func(t *testing.T) {
+		stoppedTask := &apitask.Task{
+			Arn:                      taskARN,
+			Family:                   family,
+			Version:                  version,
+			DesiredStatusUnsafe:      apitaskstatus.TaskRunning,
+			KnownStatusUnsafe:        apitaskstatus.TaskRunning,
+			NetworkMode:              apitask.HostNetworkMode,
+			CPU:                      cpu,
+			Memory:                   memory,
+			PullStartedAtUnsafe:      now,
+			PullStoppedAtUnsafe:      now,
+			ExecutionStoppedAtUnsafe: now,
+			LaunchType:               "EC2",
+		}
+		stoppedTask.SetTerminalReason("image pull for container c1 (image) did not complete within 1m0s and was aborted")
+		expectedResponse := expectedV4TaskResponseNoContainers()
+		expectedResponse.NetworkMode = apitask.HostNetworkMode
+		expectedResponse.StopReason = "Image pull for container c1 (image) did not complete within 1m0s and was aborted"
+		testTMDSRequest(t, TMDSTestCase[v4.TaskResponse]{
+			path: v4BasePath + v3EndpointID + "/task",
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
+					state.EXPECT().TaskByArn(taskARN).Return(stoppedTask, true).Times(2),
+					state.EXPECT().ContainerMapByArn(taskARN).Return(nil, false),
+					state.EXPECT().PulledContainerMapByArn(taskARN).Return(nil, true),
+				)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: expectedResponse,
+		})
+	})
+	t.Run("task with ENI but no network mode is reported as awsvpc", func(t *testing.T) {
+		legacyTask := &apitask.Task{
+			Arn:                      taskARN,
+			Family:                   family,
+			Version:                  version,
+			DesiredStatusUnsafe:      apitaskstatus.TaskRunning,
+			KnownStatusUnsafe:        apitaskstatus.TaskRunning,
+			ENIs:                     []*apieni.ENI{{MacAddress: macAddress}},
+			CPU:                      cpu,
+			Memory:                   memory,
+			PullStartedAtUnsafe:      now,
+			PullStoppedAtUnsafe:      now,
+			ExecutionStoppedAtUnsafe: now,
+			LaunchType:               "EC2",
+		}
+		testTMDSRequest(t, TMDSTestCase[v4.TaskResponse]{
+			path: v4BasePath + v3EndpointID + "/task",
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
+					state.EXPECT().TaskByArn(taskARN).Return(legacyTask, true).Times(2),
+					state.EXPECT().ContainerMapByArn(taskARN).Return(nil, false),
+					state.EXPECT().PulledContainerMapByArn(taskARN).Return(nil, true),
+				)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: expectedV4TaskResponseNoContainers(),
+		})
+	})
 	t.Run("happy case pulled containers", func(t *testing.T) {
 		testTMDSRequest(t, TMDSTestCase[v4.TaskResponse]{
 			path: v4BasePath + v3EndpointID + "/task",
@@ -2121,6 +3494,60 @@ func TestV4TaskMetadata(t *testing.T) {
 			expectedResponseBody: expectedV4PulledTaskResponse(),
 		})
 	})
+	t.Run("pull window across containers pulled in parallel", func(t *testing.T) {
+		parallelPullTask := &apitask.Task{
+			Arn:                 taskARN,
+			Associations:        []apitask.Association{pulledAssociation},
+			Family:              family,
+			Version:             version,
+			DesiredStatusUnsafe: apitaskstatus.TaskRunning,
+			KnownStatusUnsafe:   apitaskstatus.TaskStatusNone,
+			NetworkMode:         apitask.AWSVPCNetworkMode,
+			ENIs: []*apieni.ENI{
+				{
+					IPV4Addresses: []*apieni.ENIIPV4Address{
+						{
+							Address: eniIPv4Address,
+						},
+					},
+					MacAddress:               macAddress,
+					PrivateDNSName:           privateDNSName,
+					SubnetGatewayIPV4Address: subnetGatewayIpv4Address,
+				},
+			},
+			CPU:                      cpu,
+			Memory:                   memory,
+			ExecutionStoppedAtUnsafe: now,
+			LaunchType:               "EC2",
+		}
+		// The images of both containers are pulled in parallel. The pull of the first container
+		// starts first and stops last, but the pull of the second container records its stop last.
+		firstPullStartedAt, firstPullStoppedAt := now.Add(-time.Minute), now.Add(-5*time.Second)
+		secondPullStartedAt, secondPullStoppedAt := now.Add(-50*time.Second), now.Add(-20*time.Second)
+		parallelPullTask.SetPullStartedAt(firstPullStartedAt)
+		parallelPullTask.SetPullStartedAt(secondPullStartedAt)
+		parallelPullTask.SetPullStoppedAt(firstPullStoppedAt)
+		parallelPullTask.SetPullStoppedAt(secondPullStoppedAt)
+
+		expectedResponse := expectedV4PulledTaskResponse()
+		require.Len(t, expectedResponse.Containers, 2)
+		expectedResponse.PullStartedAt = aws.Time(firstPullStartedAt.UTC())
+		expectedResponse.PullStoppedAt = aws.Time(firstPullStoppedAt.UTC())
+		testTMDSRequest(t, TMDSTestCase[v4.TaskResponse]{
+			path: v4BasePath + v3EndpointID + "/task",
+			setStateExpectations: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
+					state.EXPECT().TaskByArn(taskARN).Return(parallelPullTask, true).Times(2),
+					state.EXPECT().ContainerMapByArn(taskARN).Return(containerNameToDockerContainer, true),
+					state.EXPECT().TaskByArn(taskARN).Return(parallelPullTask, true),
+					state.EXPECT().PulledContainerMapByArn(taskARN).Return(pulledContainerNameToDockerContainer, true),
+				)
+			},
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: expectedResponse,
+		})
+	})
 	t.Run("bridge mode container not found", func(t *testing.T) {
 		testTMDSRequest(t, TMDSTestCase[v4.TaskResponse]{
 			path: v4BasePath + v3EndpointID + "/task",
@@ -2677,6 +4104,131 @@ func TestV4TaskMetadataWithTags(t *testing.T) {
 	})
 }
 
+// Tests that throttled tag lookups are retried and that a degraded response is returned
+// once retries are exhausted.
+// Tests that v4 task metadata is served with an ETag, that a request with a matching
+// If-None-Match header gets a 304 response, and that the ETag changes with the task state.
+func TestV4TaskMetadataETag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	state := mock_dockerstate.NewMockTaskEngineState(ctrl)
+	auditLog := mock_audit.NewMockAuditLogger(ctrl)
+	statsEngine := mock_stats.NewMockEngine(ctrl)
+	ecsClient := mock_api.NewMockECSClient(ctrl)
+
+	newHostTask := func(knownStatus apitaskstatus.TaskStatus) *apitask.Task {
+		return &apitask.Task{
+			Arn:                 taskARN,
+			Family:              family,
+			Version:             version,
+			DesiredStatusUnsafe: apitaskstatus.TaskRunning,
+			KnownStatusUnsafe:   knownStatus,
+			NetworkMode:         apitask.HostNetworkMode,
+			CPU:                 cpu,
+			Memory:              memory,
+			LaunchType:          "EC2",
+		}
+	}
+	currentTask := newHostTask(apitaskstatus.TaskRunning)
+	auditLog.EXPECT().Log(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true).AnyTimes()
+	state.EXPECT().TaskByArn(taskARN).DoAndReturn(func(string) (*apitask.Task, bool) {
+		return currentTask, true
+	}).AnyTimes()
+	state.EXPECT().ContainerMapByArn(taskARN).Return(nil, false).AnyTimes()
+	state.EXPECT().PulledContainerMapByArn(taskARN).Return(nil, true).AnyTimes()
+
+	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient,
+		clusterName, region, statsEngine,
+		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, availabilityzone, vpcID,
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
+	require.NoError(t, err)
+
+	sendRequest := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", v4BasePath+v3EndpointID+"/task", nil)
+		require.NoError(t, err)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	first := sendRequest("")
+	require.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	var firstResponse v4.TaskResponse
+	require.NoError(t, json.Unmarshal(first.Body.Bytes(), &firstResponse))
+	assert.Equal(t, taskARN, firstResponse.TaskARN)
+
+	unchanged := sendRequest(etag)
+	assert.Equal(t, http.StatusNotModified, unchanged.Code)
+	assert.Equal(t, etag, unchanged.Header().Get("ETag"))
+	assert.Empty(t, unchanged.Body.Bytes())
+
+	currentTask = newHostTask(apitaskstatus.TaskStopped)
+	changed := sendRequest(etag)
+	assert.Equal(t, http.StatusOK, changed.Code)
+	assert.NotEqual(t, etag, changed.Header().Get("ETag"))
+	var changedResponse v4.TaskResponse
+	require.NoError(t, json.Unmarshal(changed.Body.Bytes(), &changedResponse))
+	assert.Equal(t, apitaskstatus.TaskStopped.String(), changedResponse.KnownStatus)
+}
+
+func TestV4TaskMetadataWithTagsThrottled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	state := mock_dockerstate.NewMockTaskEngineState(ctrl)
+	auditLog := mock_audit.NewMockAuditLogger(ctrl)
+	statsEngine := mock_stats.NewMockEngine(ctrl)
+	ecsClient := mock_api.NewMockECSClient(ctrl)
+
+	const maxAttempts = 3
+	throttlingErr := awserr.New("ThrottlingException", "Rate exceeded", nil)
+	auditLog.EXPECT().Log(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	gomock.InOrder(
+		state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
+		state.EXPECT().TaskByArn(taskARN).Return(task, true).AnyTimes(),
+		state.EXPECT().ContainerMapByArn(taskARN).Return(containerNameToDockerContainer, true),
+		state.EXPECT().TaskByArn(taskARN).Return(task, true).AnyTimes(),
+		state.EXPECT().PulledContainerMapByArn(taskARN).Return(nil, true),
+	)
+	gomock.InOrder(
+		ecsClient.EXPECT().GetResourceTags(containerInstanceArn).Return(nil, throttlingErr).Times(maxAttempts),
+		ecsClient.EXPECT().GetResourceTags(taskARN).Return(nil, throttlingErr),
+		ecsClient.EXPECT().GetResourceTags(taskARN).Return(standardECSTaskTags(), nil),
+	)
+
+	tagLookupClient := handlersv2.NewTagLookupECSClient(ecsClient, maxAttempts, time.Millisecond, time.Millisecond)
+	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, tagLookupClient,
+		clusterName, region, statsEngine,
+		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, availabilityzone, vpcID,
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("GET", v4BasePath+v3EndpointID+"/taskWithTags", nil)
+	require.NoError(t, err)
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, req)
+
+	expectedResponse := expectedV4TaskResponse()
+	expectedResponse.TaskTags = standardTaskTags()
+	expectedResponse.Errors = []v2.ErrorResponse{{
+		ErrorField:   "ContainerInstanceTags",
+		ErrorCode:    "ThrottlingException",
+		ErrorMessage: "Rate exceeded",
+		ResourceARN:  containerInstanceArn,
+	}}
+	var actualResponse v4.TaskResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &actualResponse))
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, expectedResponse, actualResponse)
+}
+
 // Helper function for testing Agent API Task Protection v1 handlers
 func testAgentAPITaskProtectionV1Handler(t *testing.T, requestBody interface{}, method string) {
 	// Prepare dependency mocks
@@ -2696,7 +4248,7 @@ func testAgentAPITaskProtectionV1Handler(t *testing.T, requestBody interface{},
 	// Set up the server
 	server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region, statsEngine,
 		config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
-		containerInstanceArn, endpoint, acceptInsecureCert)
+		containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
 	require.NoError(t, err)
 
 	// Prepare the request
@@ -2729,3 +4281,163 @@ func TestAgentAPIV1UpdateTaskProtectionHandler(t *testing.T) {
 	}
 	testAgentAPITaskProtectionV1Handler(t, requestBody, "PUT")
 }
+
+// Tests that Agent API v1 UpdateTaskProtection handler is registered correctly for partial updates
+func TestAgentAPIV1PartialUpdateTaskProtectionHandler(t *testing.T) {
+	requestBody := task_protection_v1.TaskProtectionRequest{
+		ExpiresInMinutes: agentutils.Int64Ptr(60),
+	}
+	testAgentAPITaskProtectionV1Handler(t, requestBody, "PATCH")
+}
+
+// Tests that Agent API v1 handlers only serve requests from source addresses in the
+// configured allowlist, while metadata handlers remain reachable from any source.
+func TestAgentAPIV1AllowedSourceCIDRs(t *testing.T) {
+	_, allowedCIDR, err := net.ParseCIDR("169.254.172.0/22")
+	require.NoError(t, err)
+	allowedSourceCIDRs := []cnitypes.IPNet{cnitypes.IPNet(*allowedCIDR)}
+
+	tcs := []struct {
+		name           string
+		path           string
+		remoteAddr     string
+		localAddr      net.Addr
+		setStateExpect func(state *mock_dockerstate.MockTaskEngineState)
+		expectRejected bool
+	}{
+		{
+			name:       "agent API request from allowed source",
+			path:       fmt.Sprintf("/api/%s/task-protection/v1/state", v3EndpointID),
+			remoteAddr: "169.254.172.2:" + remotePort,
+			setStateExpect: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
+					state.EXPECT().TaskByArn(taskARN).Return(task, true),
+				)
+			},
+		},
+		{
+			name:           "agent API request from disallowed source",
+			path:           fmt.Sprintf("/api/%s/task-protection/v1/state", v3EndpointID),
+			remoteAddr:     "10.0.0.1:" + remotePort,
+			setStateExpect: func(state *mock_dockerstate.MockTaskEngineState) {},
+			expectRejected: true,
+		},
+		{
+			name:       "agent API request from unix socket",
+			path:       fmt.Sprintf("/api/%s/task-protection/v1/state", v3EndpointID),
+			remoteAddr: "@",
+			localAddr:  &net.UnixAddr{Name: "/var/run/ecs/tmds.sock", Net: "unix"},
+			setStateExpect: func(state *mock_dockerstate.MockTaskEngineState) {
+				gomock.InOrder(
+					state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return(taskARN, true),
+					state.EXPECT().TaskByArn(taskARN).Return(task, true),
+				)
+			},
+		},
+		{
+			name:       "metadata request from disallowed source",
+			path:       v4BasePath + v3EndpointID + "/task",
+			remoteAddr: "10.0.0.1:" + remotePort,
+			setStateExpect: func(state *mock_dockerstate.MockTaskEngineState) {
+				state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return("", false)
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			state := mock_dockerstate.NewMockTaskEngineState(ctrl)
+			auditLog := mock_audit.NewMockAuditLogger(ctrl)
+			statsEngine := mock_stats.NewMockEngine(ctrl)
+			ecsClient := mock_api.NewMockECSClient(ctrl)
+			tc.setStateExpect(state)
+
+			server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region,
+				statsEngine, config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, "", vpcID,
+				containerInstanceArn, endpoint, acceptInsecureCert, allowedSourceCIDRs, false, false, nil)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest("GET", tc.path, nil)
+			require.NoError(t, err)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.localAddr != nil {
+				req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, tc.localAddr))
+			}
+			recorder := httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, req)
+
+			var errorMessage utils.ErrorMessage
+			json.Unmarshal(recorder.Body.Bytes(), &errorMessage)
+			if tc.expectRejected {
+				assert.Equal(t, http.StatusForbidden, recorder.Code)
+				assert.Equal(t, "AccessDeniedException", errorMessage.Code)
+			} else {
+				assert.NotEqual(t, "AccessDeniedException", errorMessage.Code)
+			}
+		})
+	}
+}
+
+func TestServeTaskHTTPEndpointRecreatesServerAfterMaxLifetime(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	state := mock_dockerstate.NewMockTaskEngineState(ctrl)
+	auditLog := mock_audit.NewMockAuditLogger(ctrl)
+	statsEngine := mock_stats.NewMockEngine(ctrl)
+	ecsClient := mock_api.NewMockECSClient(ctrl)
+	auditLog.EXPECT().Log(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	state.EXPECT().TaskARNByV3EndpointID(v3EndpointID).Return("", false).AnyTimes()
+
+	// Pick a free port for the servers to listen on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	var serversCreated int32
+	newServer := func() (*http.Server, error) {
+		server, err := taskServerSetup(credentials.NewManager(), auditLog, state, ecsClient, clusterName, region,
+			statsEngine, config.DefaultTaskMetadataSteadyStateRate, config.DefaultTaskMetadataBurstRate, availabilityzone,
+			vpcID, containerInstanceArn, endpoint, acceptInsecureCert, nil, false, false, nil)
+		if err != nil {
+			return nil, err
+		}
+		server.Addr = addr
+		atomic.AddInt32(&serversCreated, 1)
+		return server, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		serveTaskHTTPEndpoint(ctx, newServer, "", 100*time.Millisecond)
+	}()
+
+	// The server is recreated once it has run for its maximum lifetime
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&serversCreated) >= 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// and the recreated server continues serving
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	require.Eventually(t, func() bool {
+		resp, err := client.Get("http://" + addr + v4BasePath + v3EndpointID + "/task")
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode == http.StatusNotFound
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Canceling the context stops serving without recreating the server
+	cancel()
+	<-served
+	_, err = client.Get("http://" + addr + v4BasePath + v3EndpointID + "/task")
+	assert.Error(t, err)
+}
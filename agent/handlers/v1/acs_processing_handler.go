@@ -0,0 +1,71 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/utils"
+)
+
+// ACSProcessingPath is the path for the v1 handler used to pause and resume
+// processing of ACS payload messages.
+const ACSProcessingPath = "/v1/acs/processing"
+
+// ACSProcessingPauser pauses and resumes processing of ACS payload messages.
+type ACSProcessingPauser interface {
+	Pause()
+	Resume()
+	IsPaused() bool
+}
+
+// ACSProcessingResponse is the schema for the ACS processing state.
+type ACSProcessingResponse struct {
+	Paused bool
+}
+
+// ACSProcessingHandler creates response for 'v1/acs/processing' API. GET returns the
+// current processing state; PUT sets it from an ACSProcessingResponse body.
+func ACSProcessingHandler(pauser ACSProcessingPauser) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var req ACSProcessingResponse
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				responseJSON, err := json.Marshal("Unable to decode ACS processing request: " + err.Error())
+				if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+					return
+				}
+				utils.WriteJSONToResponse(w, http.StatusBadRequest, responseJSON, utils.RequestTypeACSProcessing)
+				return
+			}
+			if req.Paused {
+				pauser.Pause()
+			} else {
+				pauser.Resume()
+			}
+		default:
+			w.Header().Set("Allow", http.MethodGet+", "+http.MethodPut)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		responseJSON, err := json.Marshal(&ACSProcessingResponse{Paused: pauser.IsPaused()})
+		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+			return
+		}
+		utils.WriteJSONToResponse(w, http.StatusOK, responseJSON, utils.RequestTypeACSProcessing)
+	}
+}
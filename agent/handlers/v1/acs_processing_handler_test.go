@@ -0,0 +1,79 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testACSProcessingPauser struct {
+	paused bool
+}
+
+func (p *testACSProcessingPauser) Pause()         { p.paused = true }
+func (p *testACSProcessingPauser) Resume()        { p.paused = false }
+func (p *testACSProcessingPauser) IsPaused() bool { return p.paused }
+
+func performACSProcessingRequest(t *testing.T, pauser ACSProcessingPauser, method, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	req, err := http.NewRequest(method, ACSProcessingPath, strings.NewReader(body))
+	require.NoError(t, err)
+	ACSProcessingHandler(pauser)(recorder, req)
+	return recorder
+}
+
+func TestACSProcessingHandler(t *testing.T) {
+	pauser := &testACSProcessingPauser{}
+
+	for _, tc := range []struct {
+		method         string
+		body           string
+		expectedStatus int
+		expectedPaused bool
+	}{
+		{http.MethodGet, "", http.StatusOK, false},
+		{http.MethodPut, `{"Paused":true}`, http.StatusOK, true},
+		{http.MethodGet, "", http.StatusOK, true},
+		{http.MethodPut, `{"Paused":false}`, http.StatusOK, false},
+	} {
+		recorder := performACSProcessingRequest(t, pauser, tc.method, tc.body)
+		require.Equal(t, tc.expectedStatus, recorder.Code)
+		var resp ACSProcessingResponse
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+		assert.Equal(t, tc.expectedPaused, resp.Paused)
+		assert.Equal(t, tc.expectedPaused, pauser.IsPaused())
+	}
+}
+
+func TestACSProcessingHandlerInvalidRequest(t *testing.T) {
+	pauser := &testACSProcessingPauser{}
+
+	recorder := performACSProcessingRequest(t, pauser, http.MethodPut, "not json")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.False(t, pauser.IsPaused())
+
+	recorder = performACSProcessingRequest(t, pauser, http.MethodPost, `{"Paused":true}`)
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	assert.False(t, pauser.IsPaused())
+}
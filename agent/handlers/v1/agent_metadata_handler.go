@@ -25,13 +25,20 @@ import (
 // AgentMetadataPath is the Agent metadata path for v1 handler.
 const AgentMetadataPath = "/v1/metadata"
 
+// ContainerInstanceDrainState reports whether ACS set the container instance to draining.
+type ContainerInstanceDrainState interface {
+	Draining() bool
+}
+
 // AgentMetadataHandler creates response for 'v1/metadata' API.
-func AgentMetadataHandler(containerInstanceArn *string, cfg *config.Config) func(http.ResponseWriter, *http.Request) {
+func AgentMetadataHandler(containerInstanceArn *string, drainState ContainerInstanceDrainState,
+	cfg *config.Config) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		resp := &MetadataResponse{
 			Cluster:              cfg.Cluster,
 			ContainerInstanceArn: containerInstanceArn,
 			Version:              agentversion.String(),
+			Draining:             drainState != nil && drainState.Draining(),
 		}
 		responseJSON, err := json.Marshal(resp)
 		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
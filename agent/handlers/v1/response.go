@@ -27,6 +27,7 @@ type MetadataResponse struct {
 	Cluster              string  `json:"Cluster"`
 	ContainerInstanceArn *string `json:"ContainerInstanceArn"`
 	Version              string  `json:"Version"`
+	Draining             bool    `json:"Draining,omitempty"`
 }
 
 // TaskResponse is the schema for the task response JSON object
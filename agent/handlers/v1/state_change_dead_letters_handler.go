@@ -0,0 +1,48 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/amazon-ecs-agent/agent/eventhandler"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/utils"
+)
+
+// StateChangeDeadLettersPath is the path for the v1 handler used to list the state
+// changes that were given up on after failing to be submitted to ECS.
+const StateChangeDeadLettersPath = "/v1/statechanges/deadletters"
+
+// StateChangeDeadLetters lists the state changes that were given up on after failing
+// to be submitted to ECS.
+type StateChangeDeadLetters interface {
+	DeadLetters() []eventhandler.DeadLetter
+}
+
+// StateChangeDeadLettersResponse is the schema for the state change dead letters.
+type StateChangeDeadLettersResponse struct {
+	DeadLetters []eventhandler.DeadLetter
+}
+
+// StateChangeDeadLettersHandler creates response for 'v1/statechanges/deadletters' API.
+func StateChangeDeadLettersHandler(deadLetters StateChangeDeadLetters) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		responseJSON, err := json.Marshal(&StateChangeDeadLettersResponse{DeadLetters: deadLetters.DeadLetters()})
+		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+			return
+		}
+		utils.WriteJSONToResponse(w, http.StatusOK, responseJSON, utils.RequestTypeStateChangeDeadLetters)
+	}
+}
@@ -0,0 +1,54 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/eventhandler"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testStateChangeDeadLetters []eventhandler.DeadLetter
+
+func (dl testStateChangeDeadLetters) DeadLetters() []eventhandler.DeadLetter { return dl }
+
+func TestStateChangeDeadLettersHandler(t *testing.T) {
+	deadLetters := testStateChangeDeadLetters{{
+		TaskARN:        "taskArn",
+		Status:         "STOPPED",
+		Attempts:       3,
+		LastError:      "error",
+		DeadLetteredAt: time.Now().UTC().Truncate(time.Second),
+	}}
+
+	recorder := httptest.NewRecorder()
+	req, err := http.NewRequest(http.MethodGet, StateChangeDeadLettersPath, nil)
+	require.NoError(t, err)
+	StateChangeDeadLettersHandler(deadLetters)(recorder, req)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var resp StateChangeDeadLettersResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Equal(t, []eventhandler.DeadLetter(deadLetters), resp.DeadLetters)
+}
@@ -0,0 +1,92 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/aws/amazon-ecs-agent/agent/engine"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/utils"
+	"github.com/cihub/seelog"
+)
+
+// TaskStateReconciliationPath is the path for the v1 handler used to reconcile the
+// stored state of a task against the state of its containers in Docker.
+const TaskStateReconciliationPath = "/v1/tasks/reconcile"
+
+// TaskStateReconciler reconciles the stored state of a task against the state of
+// its containers in Docker.
+type TaskStateReconciler interface {
+	ReconcileTaskState(taskARN string) (*engine.TaskStateReconciliation, error)
+}
+
+// TaskStateReconciliationHandler creates response for 'v1/tasks/reconcile' API. A POST
+// with the 'taskarn' query parameter reconciles the state of the task and returns the
+// differences that were found. Only requests from the loopback interface are served.
+func TaskStateReconciliationHandler(reconciler TaskStateReconciler) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopbackRequest(r) {
+			writeTaskStateReconciliationError(w, http.StatusForbidden,
+				"Task state reconciliation is only allowed from the loopback interface")
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		taskARN, _ := utils.ValueFromRequest(r, taskARNQueryField)
+		if taskARN == "" {
+			writeTaskStateReconciliationError(w, http.StatusBadRequest, "Missing "+taskARNQueryField+" in the request")
+			return
+		}
+
+		reconciliation, err := reconciler.ReconcileTaskState(taskARN)
+		if err != nil {
+			seelog.Warnf("Unable to reconcile the state of task %s: %v", taskARN, err)
+			statusCode := http.StatusInternalServerError
+			if errors.As(err, &engine.TaskNotFoundError{}) {
+				statusCode = http.StatusNotFound
+			}
+			writeTaskStateReconciliationError(w, statusCode, err.Error())
+			return
+		}
+		responseJSON, err := json.Marshal(reconciliation)
+		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+			return
+		}
+		utils.WriteJSONToResponse(w, http.StatusOK, responseJSON, utils.RequestTypeTaskStateReconciliation)
+	}
+}
+
+// isLoopbackRequest returns true if the request was made from the loopback interface
+func isLoopbackRequest(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func writeTaskStateReconciliationError(w http.ResponseWriter, statusCode int, msg string) {
+	responseJSON, err := json.Marshal(msg)
+	if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+		return
+	}
+	utils.WriteJSONToResponse(w, statusCode, responseJSON, utils.RequestTypeTaskStateReconciliation)
+}
@@ -0,0 +1,139 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/agent/engine"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testTaskStateReconciler struct {
+	reconciliation *engine.TaskStateReconciliation
+	err            error
+	taskARNs       []string
+}
+
+func (r *testTaskStateReconciler) ReconcileTaskState(taskARN string) (*engine.TaskStateReconciliation, error) {
+	r.taskARNs = append(r.taskARNs, taskARN)
+	return r.reconciliation, r.err
+}
+
+func TestTaskStateReconciliationHandler(t *testing.T) {
+	reconciliation := &engine.TaskStateReconciliation{
+		TaskARN: "taskArn",
+		Diffs: []engine.ContainerStateDiff{
+			{ContainerName: "c1", Field: "KnownStatus", Stored: "RUNNING", Actual: "STOPPED", Corrected: true},
+		},
+	}
+
+	testCases := []struct {
+		name               string
+		method             string
+		remoteAddr         string
+		path               string
+		reconcileErr       error
+		expectedStatusCode int
+		expectReconcile    bool
+	}{
+		{
+			name:               "reconciled",
+			method:             http.MethodPost,
+			remoteAddr:         "127.0.0.1:51234",
+			path:               TaskStateReconciliationPath + "?taskarn=taskArn",
+			expectedStatusCode: http.StatusOK,
+			expectReconcile:    true,
+		},
+		{
+			name:               "ipv6 loopback",
+			method:             http.MethodPost,
+			remoteAddr:         "[::1]:51234",
+			path:               TaskStateReconciliationPath + "?taskarn=taskArn",
+			expectedStatusCode: http.StatusOK,
+			expectReconcile:    true,
+		},
+		{
+			name:               "not from loopback",
+			method:             http.MethodPost,
+			remoteAddr:         "10.0.0.1:51234",
+			path:               TaskStateReconciliationPath + "?taskarn=taskArn",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "wrong method",
+			method:             http.MethodGet,
+			remoteAddr:         "127.0.0.1:51234",
+			path:               TaskStateReconciliationPath + "?taskarn=taskArn",
+			expectedStatusCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:               "missing task arn",
+			method:             http.MethodPost,
+			remoteAddr:         "127.0.0.1:51234",
+			path:               TaskStateReconciliationPath,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "task not found",
+			method:             http.MethodPost,
+			remoteAddr:         "127.0.0.1:51234",
+			path:               TaskStateReconciliationPath + "?taskarn=taskArn",
+			reconcileErr:       engine.TaskNotFoundError{},
+			expectedStatusCode: http.StatusNotFound,
+			expectReconcile:    true,
+		},
+		{
+			name:               "reconciliation error",
+			method:             http.MethodPost,
+			remoteAddr:         "127.0.0.1:51234",
+			path:               TaskStateReconciliationPath + "?taskarn=taskArn",
+			reconcileErr:       errors.New("docker is unavailable"),
+			expectedStatusCode: http.StatusInternalServerError,
+			expectReconcile:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reconciler := &testTaskStateReconciler{reconciliation: reconciliation, err: tc.reconcileErr}
+			recorder := httptest.NewRecorder()
+			req, err := http.NewRequest(tc.method, tc.path, nil)
+			require.NoError(t, err)
+			req.RemoteAddr = tc.remoteAddr
+			TaskStateReconciliationHandler(reconciler)(recorder, req)
+
+			require.Equal(t, tc.expectedStatusCode, recorder.Code)
+			if !tc.expectReconcile {
+				assert.Empty(t, reconciler.taskARNs)
+				return
+			}
+			assert.Equal(t, []string{"taskArn"}, reconciler.taskARNs)
+			if tc.expectedStatusCode == http.StatusOK {
+				var resp engine.TaskStateReconciliation
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+				assert.Equal(t, *reconciliation, resp)
+			}
+		})
+	}
+}
@@ -14,14 +14,18 @@
 package v2
 
 import (
+	"strings"
+
 	"github.com/aws/aws-sdk-go/aws/awserr"
 
 	"github.com/aws/amazon-ecs-agent/agent/api"
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
 	apicontainerstatus "github.com/aws/amazon-ecs-agent/agent/api/container/status"
+	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
 	v1 "github.com/aws/amazon-ecs-agent/agent/handlers/v1"
-	apieni "github.com/aws/amazon-ecs-agent/ecs-agent/api/eni"
+	taskresourcevolume "github.com/aws/amazon-ecs-agent/agent/taskresource/volume"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/credentials"
 	tmdsresponse "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/response"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/utils"
 	tmdsv2 "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/v2"
@@ -34,6 +38,23 @@ import (
 // are passed to Docker as two CPU shares
 const minimumCPUUnit = 2
 
+const (
+	// redactedValue replaces the values of volume options that look like credentials.
+	redactedValue = "REDACTED"
+	// volumeMountSourceTypeBind is the volume mount source type of host volumes.
+	volumeMountSourceTypeBind = "bind"
+	// volumeMountSourceTypeVolume is the volume mount source type of docker volumes.
+	volumeMountSourceTypeVolume = "volume"
+	// volumeMountSourceTypeEFS is the volume mount source type of EFS volumes.
+	volumeMountSourceTypeEFS = "efs"
+	// defaultStopSignal is the signal containers are stopped with when their definition doesn't
+	// configure one.
+	defaultStopSignal = "SIGTERM"
+)
+
+// credentialOptionNames are the substrings of volume option names whose values are redacted.
+var credentialOptionNames = []string{"password", "passwd", "secret", "token", "credential"}
+
 // NewTaskResponse creates a new response object for the task
 func NewTaskResponse(
 	taskARN string,
@@ -61,6 +82,14 @@ func NewTaskResponse(
 	}
 	if includeV4Metadata {
 		resp.LaunchType = task.LaunchType
+		resp.EphemeralStorageEncrypted = task.EphemeralStorageEncrypted
+		resp.NetworkMode = taskNetworkMode(task)
+		resp.StopReason = task.GetTerminalReason()
+		// PID and IPC namespace sharing is only supported for Linux tasks, the modes
+		// are empty for tasks that use the default namespaces
+		resp.PidMode = task.GetPIDMode()
+		resp.IpcMode = task.GetIPCMode()
+		resp.ServiceDiscovery = newServiceDiscoveryResponse(task)
 	}
 
 	taskCPU := task.CPU
@@ -93,7 +122,7 @@ func NewTaskResponse(
 	}
 
 	for _, dockerContainer := range containerNameToDockerContainer {
-		containerResponse := NewContainerResponse(dockerContainer, task.GetPrimaryENI(), includeV4Metadata)
+		containerResponse := NewContainerResponse(dockerContainer, task, includeV4Metadata)
 		resp.Containers = append(resp.Containers, containerResponse)
 	}
 
@@ -104,6 +133,17 @@ func NewTaskResponse(
 	return resp, nil
 }
 
+// taskNetworkMode returns the network mode of the task as stored in the task engine state.
+// A task with an ENI attached is always reported as awsvpc, since only awsvpc tasks have ENIs.
+func taskNetworkMode(task *apitask.Task) string {
+	if task.GetPrimaryENI() != nil && !task.IsNetworkModeAWSVPC() {
+		seelog.Warnf("V2 task response: task '%s' has an ENI attached but network mode '%s', reporting '%s'",
+			task.Arn, task.NetworkMode, apitask.AWSVPCNetworkMode)
+		return apitask.AWSVPCNetworkMode
+	}
+	return task.NetworkMode
+}
+
 // propagateTagsToMetadata retrieves container instance and task tags from ECS
 func propagateTagsToMetadata(ecsClient api.ECSClient, containerInstanceARN, taskARN string, resp *tmdsv2.TaskResponse, includeV4Metadata bool) {
 	containerInstanceTags, err := ecsClient.GetResourceTags(containerInstanceARN)
@@ -146,7 +186,7 @@ func NewContainerResponseFromState(
 			"v2 container response: unable to find task for container '%s'", containerID)
 	}
 
-	resp := NewContainerResponse(dockerContainer, task.GetPrimaryENI(), includeV4Metadata)
+	resp := NewContainerResponse(dockerContainer, task, includeV4Metadata)
 	return &resp, nil
 }
 
@@ -154,10 +194,11 @@ func NewContainerResponseFromState(
 // TODO: remove includeV4Metadata from NewContainerResponse
 func NewContainerResponse(
 	dockerContainer *apicontainer.DockerContainer,
-	eni *apieni.ENI,
+	task *apitask.Task,
 	includeV4Metadata bool,
 ) tmdsv2.ContainerResponse {
 	container := dockerContainer.Container
+	eni := task.GetPrimaryENI()
 	resp := tmdsv2.ContainerResponse{
 		ID:            dockerContainer.DockerID,
 		Name:          container.Name,
@@ -186,6 +227,27 @@ func NewContainerResponse(
 		resp.LogDriver = container.GetLogDriver()
 		resp.LogOptions = container.GetLogOptions()
 		resp.ContainerARN = container.ContainerArn
+		resp.SeccompProfile, resp.AppArmorProfile = container.GetSecurityProfiles()
+		resp.VolumeMounts = newVolumeMountsResponse(task, container)
+		resp.DependsOn = newDependsOnResponse(container)
+		resp.ExtraHosts = newExtraHostsResponse(container)
+		resp.InitProcessEnabled = container.GetInitProcessEnabled()
+		resp.PidMode = task.GetPIDMode()
+		resp.IpcMode = task.GetIPCMode()
+		resp.HostPID = containerHostPID(container)
+		resp.CpusetCpus = containerCpusetCpus(container)
+		resp.RestartPolicy = newRestartPolicyResponse(container)
+		resp.ContainerTags = container.GetContainerTags()
+		if container.EntryPoint != nil {
+			resp.Entrypoint = *container.EntryPoint
+		}
+		resp.Command = container.Command
+		resp.WorkingDirectory = container.GetWorkingDirectory()
+		resp.NetworkIPAddresses = newNetworkIPAddressesResponse(container, task)
+		resp.LogRetention = newLogRetentionResponse(container)
+		resp.ImagePullCredentialsSource = newImagePullCredentialsSourceResponse(container)
+		resp.StopSignal = newStopSignalResponse(container)
+		resp.AWSCredentialsEndpoint = credentialsEndpointResponse(task)
 	}
 
 	// Write the container health status inside the container
@@ -218,6 +280,11 @@ func NewContainerResponse(
 			port.HostPort = port.ContainerPort
 		}
 		if includeV4Metadata {
+			// Docker has not assigned a host port to this mapping yet, so leave it out
+			// rather than report a mapping that can't be reached.
+			if port.HostPort == 0 {
+				continue
+			}
 			port.HostIp = binding.BindIP
 		}
 
@@ -238,6 +305,312 @@ func NewContainerResponse(
 	return resp
 }
 
+// newVolumeMountsResponse creates the volume mounts response for a container from the mount points in
+// its container definition and the volumes defined in its task. Mount points that do not reference a
+// task volume are skipped.
+func newVolumeMountsResponse(task *apitask.Task, container *apicontainer.Container) []tmdsresponse.VolumeMountResponse {
+	var resp []tmdsresponse.VolumeMountResponse
+	for _, mountPoint := range container.MountPoints {
+		var taskVolume *apitask.TaskVolume
+		for i := range task.Volumes {
+			if task.Volumes[i].Name == mountPoint.SourceVolume {
+				taskVolume = &task.Volumes[i]
+				break
+			}
+		}
+		if taskVolume == nil || taskVolume.Volume == nil {
+			seelog.Debugf("V2 container response: unable to find volume '%s' mounted by container '%s' in task '%s'",
+				mountPoint.SourceVolume, container.Name, task.Arn)
+			continue
+		}
+		sourceType, source := volumeMountSource(taskVolume)
+		resp = append(resp, tmdsresponse.VolumeMountResponse{
+			SourceType:       sourceType,
+			Source:           source,
+			Destination:      mountPoint.ContainerPath,
+			ReadOnly:         mountPoint.ReadOnly,
+			MountPropagation: mountPropagation(container, mountPoint.ContainerPath),
+		})
+	}
+	return resp
+}
+
+// NewTaskVolumesResponse creates the volumes response of a task from the volumes defined in it.
+// Credentials in the driver options of docker volumes are redacted.
+func NewTaskVolumesResponse(task *apitask.Task) *tmdsresponse.TaskVolumesResponse {
+	resp := &tmdsresponse.TaskVolumesResponse{
+		TaskARN: task.Arn,
+		Volumes: []tmdsresponse.TaskVolumeResponse{},
+	}
+	for i := range task.Volumes {
+		taskVolume := &task.Volumes[i]
+		if taskVolume.Volume == nil {
+			continue
+		}
+		sourceType, source := volumeMountSource(taskVolume)
+		volumeResp := tmdsresponse.TaskVolumeResponse{
+			Name:   taskVolume.Name,
+			Type:   sourceType,
+			Source: source,
+		}
+		if dockerVolume, ok := taskVolume.Volume.(*taskresourcevolume.DockerVolumeConfig); ok {
+			volumeResp.Driver = dockerVolume.Driver
+			volumeResp.DriverOptions = redactVolumeDriverOptions(dockerVolume.DriverOpts)
+		}
+		resp.Volumes = append(resp.Volumes, volumeResp)
+	}
+	return resp
+}
+
+// redactVolumeDriverOptions returns a copy of the driver options of a volume in which the values
+// of options that look like credentials are redacted. Options such as "o" hold comma separated
+// "key=value" mount options, in which credentials are redacted as well.
+func redactVolumeDriverOptions(driverOpts map[string]string) map[string]string {
+	if len(driverOpts) == 0 {
+		return nil
+	}
+	redacted := make(map[string]string, len(driverOpts))
+	for key, value := range driverOpts {
+		if isCredentialOption(key) {
+			redacted[key] = redactedValue
+			continue
+		}
+		mountOptions := strings.Split(value, ",")
+		for i, mountOption := range mountOptions {
+			if optionKey, _, ok := strings.Cut(mountOption, "="); ok && isCredentialOption(optionKey) {
+				mountOptions[i] = optionKey + "=" + redactedValue
+			}
+		}
+		redacted[key] = strings.Join(mountOptions, ",")
+	}
+	return redacted
+}
+
+// isCredentialOption returns true if the name of a volume option suggests its value is a credential.
+func isCredentialOption(name string) bool {
+	name = strings.ToLower(name)
+	for _, credentialName := range credentialOptionNames {
+		if strings.Contains(name, credentialName) {
+			return true
+		}
+	}
+	return false
+}
+
+// newDependsOnResponse creates the startup dependency response for a container from the
+// dependencies in its container definition.
+func newDependsOnResponse(container *apicontainer.Container) []tmdsresponse.DependsOnResponse {
+	var resp []tmdsresponse.DependsOnResponse
+	for _, dependsOn := range container.GetDependsOn() {
+		resp = append(resp, tmdsresponse.DependsOnResponse{
+			ContainerName: dependsOn.ContainerName,
+			Condition:     dependsOn.Condition,
+		})
+	}
+	return resp
+}
+
+// newRestartPolicyResponse creates the restart policy response for a container. Nil is
+// returned for containers without a restart policy.
+func newRestartPolicyResponse(container *apicontainer.Container) *tmdsresponse.RestartPolicyResponse {
+	policy, maxAttempts := container.GetRestartPolicy()
+	if policy == "" {
+		return nil
+	}
+	resp := &tmdsresponse.RestartPolicyResponse{
+		Policy: policy,
+	}
+	if maxAttempts > 0 {
+		resp.MaxAttempts = aws.Int64(maxAttempts)
+	}
+	return resp
+}
+
+// newLogRetentionResponse returns the log rotation and buffering settings of the container's log
+// driver. Only these settings are read from the log options, as the other options can contain
+// secrets. nil is returned if none of the settings are configured.
+func newLogRetentionResponse(container *apicontainer.Container) *tmdsresponse.LogRetentionResponse {
+	options := container.GetLogOptions()
+	resp := tmdsresponse.LogRetentionResponse{
+		Mode:          options["mode"],
+		MaxBufferSize: options["max-buffer-size"],
+	}
+	switch container.GetLogDriver() {
+	case "json-file", "local":
+		resp.MaxSize = options["max-size"]
+		resp.MaxFile = options["max-file"]
+	case "awslogs":
+		resp.ForceFlushIntervalSeconds = options["awslogs-force-flush-interval-seconds"]
+		resp.MaxBufferedEvents = options["awslogs-max-buffered-events"]
+	}
+	if resp == (tmdsresponse.LogRetentionResponse{}) {
+		return nil
+	}
+	return &resp
+}
+
+// newStopSignalResponse returns the signal the container is sent when it is stopped, which is
+// SIGTERM unless the container definition configures another one.
+func newStopSignalResponse(container *apicontainer.Container) *tmdsresponse.StopSignalResponse {
+	if signal := container.GetStopSignal(); signal != "" {
+		return &tmdsresponse.StopSignalResponse{Signal: signal}
+	}
+	return &tmdsresponse.StopSignalResponse{Signal: defaultStopSignal, Default: true}
+}
+
+// credentialsEndpointResponse returns the absolute URL of the credentials endpoint that AWS SDKs in the
+// task's containers get their credentials from. An empty string is returned if the task has no role.
+func credentialsEndpointResponse(task *apitask.Task) string {
+	relativeURI := task.GetCredentialsRelativeURI()
+	if relativeURI == "" {
+		return ""
+	}
+	return "http://" + credentials.CredentialsEndpointIP + relativeURI
+}
+
+// newImagePullCredentialsSourceResponse returns the source of the credentials used to pull the
+// container's image. Only references to the source are read from the registry authentication data,
+// never the credentials retrieved from it. nil is returned if the image is pulled without credentials.
+func newImagePullCredentialsSourceResponse(container *apicontainer.Container) *tmdsresponse.ImagePullCredentialsSourceResponse {
+	auth := container.RegistryAuthentication
+	if auth == nil {
+		return nil
+	}
+	switch auth.Type {
+	case apicontainer.AuthTypeECR:
+		if auth.ECRAuthData == nil {
+			return nil
+		}
+		return &tmdsresponse.ImagePullCredentialsSourceResponse{
+			Type:             auth.Type,
+			RegistryID:       auth.ECRAuthData.RegistryID,
+			Region:           auth.ECRAuthData.Region,
+			UseExecutionRole: auth.ECRAuthData.UseExecutionRole,
+		}
+	case apicontainer.AuthTypeASM:
+		if auth.ASMAuthData == nil {
+			return nil
+		}
+		return &tmdsresponse.ImagePullCredentialsSourceResponse{
+			Type:                 auth.Type,
+			CredentialsParameter: auth.ASMAuthData.CredentialsParameter,
+			Region:               auth.ASMAuthData.Region,
+		}
+	}
+	return nil
+}
+
+// newNetworkIPAddressesResponse returns the IP addresses assigned to the container on each of the
+// networks it is attached to, keyed by network name. Containers of awsvpc tasks share the addresses
+// of the task's ENI. nil is returned if the container has no IP addresses.
+func newNetworkIPAddressesResponse(container *apicontainer.Container, task *apitask.Task) map[string][]string {
+	addresses := make(map[string][]string)
+	if task.IsNetworkModeAWSVPC() {
+		if eni := task.GetPrimaryENI(); eni != nil {
+			ips := append(eni.GetIPV4Addresses(), eni.GetIPV6Addresses()...)
+			if len(ips) > 0 {
+				addresses[utils.NetworkModeAWSVPC] = ips
+			}
+		}
+	} else if settings := container.GetNetworkSettings(); settings != nil {
+		// Docker API versions 1.17-1.20 only report the addresses of the container's first network
+		if len(settings.Networks) == 0 {
+			if ips := nonEmptyIPAddresses(settings.IPAddress, settings.GlobalIPv6Address); len(ips) > 0 {
+				addresses[container.GetNetworkMode()] = ips
+			}
+		}
+		for name, network := range settings.Networks {
+			if network == nil {
+				continue
+			}
+			if ips := nonEmptyIPAddresses(network.IPAddress, network.GlobalIPv6Address); len(ips) > 0 {
+				addresses[name] = ips
+			}
+		}
+	}
+	if len(addresses) == 0 {
+		return nil
+	}
+	return addresses
+}
+
+func nonEmptyIPAddresses(ipAddresses ...string) []string {
+	var ips []string
+	for _, ip := range ipAddresses {
+		if ip != "" {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+// newExtraHostsResponse creates the extra hosts response for a container from the
+// "hostname:IP" entries in its host config.
+func newExtraHostsResponse(container *apicontainer.Container) []tmdsresponse.ExtraHostResponse {
+	var resp []tmdsresponse.ExtraHostResponse
+	for _, extraHost := range container.GetExtraHosts() {
+		// Hostnames can't contain colons, so everything after the first one is the IP address
+		hostname, ipAddress, ok := strings.Cut(extraHost, ":")
+		if !ok {
+			seelog.Warnf("V2 container response: ignoring malformed extra host '%s' of container '%s'",
+				extraHost, container.Name)
+			continue
+		}
+		resp = append(resp, tmdsresponse.ExtraHostResponse{
+			Hostname:  hostname,
+			IPAddress: ipAddress,
+		})
+	}
+	return resp
+}
+
+// newServiceDiscoveryResponse creates the service discovery response of a Service Connect task from
+// its ingress listeners and DNS records. Nil is returned for tasks without Service Connect.
+func newServiceDiscoveryResponse(task *apitask.Task) *tmdsresponse.ServiceDiscoveryResponse {
+	if !task.IsServiceConnectEnabled() {
+		return nil
+	}
+	scConfig := task.ServiceConnectConfig
+	if len(scConfig.IngressConfig) == 0 && len(scConfig.DNSConfig) == 0 {
+		return nil
+	}
+	resp := &tmdsresponse.ServiceDiscoveryResponse{}
+	for _, ingress := range scConfig.IngressConfig {
+		ingressResp := tmdsresponse.ServiceDiscoveryIngressResponse{
+			ListenerName: ingress.ListenerName,
+			ListenerPort: ingress.ListenerPort,
+		}
+		if ingress.HostPort != nil {
+			ingressResp.HostPort = *ingress.HostPort
+		}
+		resp.Ingress = append(resp.Ingress, ingressResp)
+	}
+	for _, dns := range scConfig.DNSConfig {
+		resp.DNSRecords = append(resp.DNSRecords, tmdsresponse.ServiceDiscoveryDNSResponse{
+			HostName: dns.HostName,
+			Address:  dns.Address,
+		})
+	}
+	return resp
+}
+
+// volumeMountSource returns the mount source type and source identifier of a task volume.
+func volumeMountSource(taskVolume *apitask.TaskVolume) (string, string) {
+	switch taskVolume.Type {
+	case apitask.DockerVolumeType:
+		return volumeMountSourceTypeVolume, taskVolume.Volume.Source()
+	case apitask.EFSVolumeType:
+		if efsVolume, ok := taskVolume.Volume.(*taskresourcevolume.EFSVolumeConfig); ok {
+			return volumeMountSourceTypeEFS, efsVolume.FileSystemID
+		}
+		return volumeMountSourceTypeEFS, taskVolume.Volume.Source()
+	case apitask.HostVolumeType, "":
+		return volumeMountSourceTypeBind, taskVolume.Volume.Source()
+	default:
+		return taskVolume.Type, taskVolume.Volume.Source()
+	}
+}
+
 // Converts apicontainer HealthStatus type to v2 Metadata HealthStatus type
 func dockerContainerHealthToV2Health(health apicontainer.HealthStatus) *tmdsv2.HealthStatus {
 	status := health.Status.String()
@@ -0,0 +1,51 @@
+//go:build linux
+// +build linux
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v2
+
+import (
+	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+	apicontainerstatus "github.com/aws/amazon-ecs-agent/agent/api/container/status"
+
+	dockermount "github.com/docker/docker/api/types/mount"
+)
+
+// containerHostPID returns the host PID of the container's main process. It returns nil if the
+// container isn't running or its PID is not known.
+func containerHostPID(container *apicontainer.Container) *int {
+	if container.GetKnownStatus() != apicontainerstatus.ContainerRunning {
+		return nil
+	}
+	pid := container.GetHostPID()
+	if pid <= 0 {
+		return nil
+	}
+	return &pid
+}
+
+func containerCpusetCpus(container *apicontainer.Container) string {
+	return container.GetCpusetCpus()
+}
+
+// mountPropagation returns the propagation mode configured for the container's mount at containerPath.
+// It returns an empty string if the mount uses the default "rprivate" propagation.
+func mountPropagation(container *apicontainer.Container, containerPath string) string {
+	propagation := container.GetMountPropagation(containerPath)
+	if propagation == string(dockermount.PropagationRPrivate) {
+		return ""
+	}
+	return propagation
+}
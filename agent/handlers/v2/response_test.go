@@ -29,7 +29,9 @@ import (
 	apitaskstatus "github.com/aws/amazon-ecs-agent/agent/api/task/status"
 	"github.com/aws/amazon-ecs-agent/agent/ecs_client/model/ecs"
 	mock_dockerstate "github.com/aws/amazon-ecs-agent/agent/engine/dockerstate/mocks"
+	taskresourcevolume "github.com/aws/amazon-ecs-agent/agent/taskresource/volume"
 	apieni "github.com/aws/amazon-ecs-agent/ecs-agent/api/eni"
+	tmdsresponse "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/response"
 	tmdsv2 "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/v2"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -333,6 +335,46 @@ func TestContainerResponse(t *testing.T) {
 	}
 }
 
+func TestNewVolumeMountsResponse(t *testing.T) {
+	task := &apitask.Task{
+		Arn: taskARN,
+		Volumes: []apitask.TaskVolume{
+			{
+				Name: "docker-volume",
+				Type: apitask.DockerVolumeType,
+				Volume: &taskresourcevolume.DockerVolumeConfig{
+					Scope:            taskresourcevolume.TaskScope,
+					DockerVolumeName: "ecs-sleep-1-docker-volume",
+				},
+			},
+			{
+				Name:   "empty-host-volume",
+				Volume: &taskresourcevolume.LocalDockerVolume{HostPath: "/var/lib/docker/volumes/empty/_data"},
+			},
+		},
+	}
+
+	t.Run("docker and empty host volumes", func(t *testing.T) {
+		container := &apicontainer.Container{
+			Name: containerName,
+			MountPoints: []apicontainer.MountPoint{
+				{SourceVolume: "docker-volume", ContainerPath: "/data", ReadOnly: true},
+				{SourceVolume: "empty-host-volume", ContainerPath: "/scratch"},
+				{SourceVolume: "unknown-volume", ContainerPath: "/unknown"},
+			},
+		}
+		assert.Equal(t, []tmdsresponse.VolumeMountResponse{
+			{SourceType: "volume", Source: "ecs-sleep-1-docker-volume", Destination: "/data", ReadOnly: true},
+			{SourceType: "bind", Source: "/var/lib/docker/volumes/empty/_data", Destination: "/scratch"},
+		}, newVolumeMountsResponse(task, container))
+	})
+
+	t.Run("no mount points", func(t *testing.T) {
+		container := &apicontainer.Container{Name: containerName}
+		assert.Nil(t, newVolumeMountsResponse(task, container))
+	})
+}
+
 func TestTaskResponseMarshal(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -597,6 +639,12 @@ func TestContainerResponseMarshal(t *testing.T) {
 			if tc.includeV4Metadata {
 				container.KnownPortBindingsUnsafe[0].BindIP = hostIp
 				expectedContainerResponseMap["Ports"].([]interface{})[0].(map[string]interface{})["HostIp"] = hostIp
+				expectedContainerResponseMap["SeccompProfile"] = apicontainer.SecurityProfileDefault
+				expectedContainerResponseMap["AppArmorProfile"] = apicontainer.SecurityProfileDefault
+				expectedContainerResponseMap["StopSignal"] = map[string]interface{}{
+					"Signal":  defaultStopSignal,
+					"Default": true,
+				}
 			}
 			containerResponse, err := NewContainerResponseFromState(containerID, state, tc.includeV4Metadata)
 			assert.NoError(t, err)
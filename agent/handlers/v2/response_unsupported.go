@@ -0,0 +1,33 @@
+//go:build !linux
+// +build !linux
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v2
+
+import apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
+
+// containerHostPID returns nil, as the host PIDs of containers are only reported on Linux.
+func containerHostPID(container *apicontainer.Container) *int {
+	return nil
+}
+
+func containerCpusetCpus(container *apicontainer.Container) string {
+	return ""
+}
+
+// mountPropagation returns an empty string, as mount propagation is only reported on Linux.
+func mountPropagation(container *apicontainer.Container, containerPath string) string {
+	return ""
+}
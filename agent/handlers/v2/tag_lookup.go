@@ -0,0 +1,114 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v2
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/agent/api"
+	"github.com/aws/amazon-ecs-agent/agent/ecs_client/model/ecs"
+	apierrors "github.com/aws/amazon-ecs-agent/ecs-agent/api/errors"
+	tmdsv2 "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/v2"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/utils/retry"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/cihub/seelog"
+)
+
+const (
+	// tagLookupBackoffJitter is the jitter multiple applied to tag lookup backoffs
+	tagLookupBackoffJitter = 0.2
+	// tagLookupBackoffMultiple is the multiple applied to tag lookup backoffs
+	tagLookupBackoffMultiple = 2
+)
+
+// throttlingErrorCodes are the ECS API error codes that indicate a request was throttled
+var throttlingErrorCodes = map[string]struct{}{
+	"ThrottlingException":                    {},
+	"Throttling":                             {},
+	"TooManyRequestsException":               {},
+	"RequestLimitExceeded":                   {},
+	"ProvisionedThroughputExceededException": {},
+}
+
+// tagLookupECSClient wraps an ECS client so that throttled GetResourceTags
+// calls are retried with backoff. All other calls are passed through.
+type tagLookupECSClient struct {
+	api.ECSClient
+	maxAttempts int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+}
+
+// NewTagLookupECSClient returns an ECS client that retries throttled tag lookups
+// up to maxAttempts times, backing off exponentially between minBackoff and maxBackoff.
+func NewTagLookupECSClient(ecsClient api.ECSClient, maxAttempts int,
+	minBackoff, maxBackoff time.Duration) api.ECSClient {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &tagLookupECSClient{
+		ECSClient:   ecsClient,
+		maxAttempts: maxAttempts,
+		minBackoff:  minBackoff,
+		maxBackoff:  maxBackoff,
+	}
+}
+
+// GetResourceTags retrieves the tags of a resource, retrying on throttling errors.
+func (c *tagLookupECSClient) GetResourceTags(resourceArn string) ([]*ecs.Tag, error) {
+	var tags []*ecs.Tag
+	var lookupErr error
+	backoff := retry.NewExponentialBackoff(c.minBackoff, c.maxBackoff,
+		tagLookupBackoffJitter, tagLookupBackoffMultiple)
+	retry.RetryNWithBackoff(backoff, c.maxAttempts, func() error {
+		tags, lookupErr = c.ECSClient.GetResourceTags(resourceArn)
+		if lookupErr == nil {
+			return nil
+		}
+		if !IsThrottlingError(lookupErr) {
+			return apierrors.NewRetriableError(apierrors.NewRetriable(false), lookupErr)
+		}
+		seelog.Debugf("Task Metadata: tag lookup for '%s' throttled, retrying: %v", resourceArn, lookupErr)
+		return lookupErr
+	})
+	return tags, lookupErr
+}
+
+// IsThrottlingError returns true if the error was returned by the ECS API
+// because the request was throttled.
+func IsThrottlingError(err error) bool {
+	if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusTooManyRequests {
+		return true
+	}
+	if awsErr, ok := err.(awserr.Error); ok {
+		_, throttled := throttlingErrorCodes[awsErr.Code()]
+		return throttled
+	}
+	return false
+}
+
+// HasThrottlingError returns true if any of the error responses were caused by
+// throttling of the ECS API.
+func HasThrottlingError(errResponses []tmdsv2.ErrorResponse) bool {
+	for _, errResp := range errResponses {
+		if errResp.StatusCode == http.StatusTooManyRequests {
+			return true
+		}
+		if _, throttled := throttlingErrorCodes[errResp.ErrorCode]; throttled {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v4
+
+import (
+	"encoding/json"
+	"net/http"
+
+	agentversion "github.com/aws/amazon-ecs-agent/agent/version"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/utils"
+)
+
+// AgentVersionPath specifies the relative URI path for serving the version of the agent.
+const AgentVersionPath = "/v4/agent/version"
+
+// AgentVersionResponse is the schema for the agent version response JSON object
+type AgentVersionResponse struct {
+	Version      string `json:"Version"`
+	GitShortHash string `json:"GitShortHash"`
+	GitDirty     bool   `json:"GitDirty"`
+	BuildTime    string `json:"BuildTime,omitempty"`
+}
+
+// AgentVersionHandler returns the handler method for handling agent version requests.
+func AgentVersionHandler() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &AgentVersionResponse{
+			Version:      agentversion.Version,
+			GitShortHash: agentversion.GitShortHash,
+			GitDirty:     agentversion.GitDirty,
+			BuildTime:    agentversion.BuildTime,
+		}
+		responseJSON, err := json.Marshal(resp)
+		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+			return
+		}
+		utils.WriteJSONToResponse(w, http.StatusOK, responseJSON, utils.RequestTypeAgentVersion)
+	}
+}
@@ -14,6 +14,8 @@
 package v4
 
 import (
+	"sort"
+
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
 	tmdsresponse "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/response"
 	tmdsv4 "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/v4/state"
@@ -49,6 +51,10 @@ func GetContainerNetworkMetadata(containerID string, state dockerstate.TaskEngin
 			network := tmdsv4.Network{Network: tmdsresponse.Network{NetworkMode: networkMode, IPv4Addresses: ipv4Addresses}}
 			networks = append(networks, network)
 		}
+		// Networks are looked up from a map, order them so that the response is stable
+		sort.Slice(networks, func(i, j int) bool {
+			return networks[i].NetworkMode < networks[j].NetworkMode
+		})
 	} else {
 		ipv4Addresses := []string{ipv4AddressFromSettings}
 		network := tmdsv4.Network{Network: tmdsresponse.Network{NetworkMode: networkModeFromHostConfig, IPv4Addresses: ipv4Addresses}}
@@ -53,7 +53,7 @@ func ContainerStatsHandler(state dockerstate.TaskEngineState, statsEngine stats.
 
 		seelog.Infof("V4 container stats handler: writing response for container '%s'", containerID)
 		// v4 handler shares the same container states response format with v2 handler.
-		WriteV4ContainerStatsResponse(w, taskArn, containerID, statsEngine)
+		WriteV4ContainerStatsResponse(w, taskArn, containerID, state, statsEngine)
 	}
 }
 
@@ -61,6 +61,7 @@ func ContainerStatsHandler(state dockerstate.TaskEngineState, statsEngine stats.
 func WriteV4ContainerStatsResponse(w http.ResponseWriter,
 	taskARN string,
 	containerID string,
+	state dockerstate.TaskEngineState,
 	statsEngine stats.Engine) {
 	dockerStats, network_rate_stats, err := statsEngine.ContainerDockerStats(taskARN, containerID)
 	if err != nil {
@@ -73,8 +74,12 @@ func WriteV4ContainerStatsResponse(w http.ResponseWriter,
 	}
 
 	containerStatsResponse := StatsResponse{
-		StatsJSON:          dockerStats,
-		Network_rate_stats: network_rate_stats,
+		StatsJSON:                       dockerStats,
+		Network_rate_stats:              network_rate_stats,
+		Sample_retention_window_seconds: sampleRetentionWindowSeconds(statsEngine, taskARN, containerID),
+	}
+	if dockerContainer, ok := state.ContainerByID(containerID); ok {
+		containerStatsResponse.Memory_allocation_stats = newMemoryAllocationStats(dockerContainer.Container, dockerStats)
 	}
 
 	responseJSON, err := json.Marshal(containerStatsResponse)
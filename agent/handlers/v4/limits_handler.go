@@ -0,0 +1,49 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v4
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/utils"
+)
+
+// LimitsPath specifies the relative URI path for serving the rate limits of the task metadata server.
+const LimitsPath = "/v4/limits"
+
+// LimitsResponse is the schema for the limits response JSON object. The limits apply to all the
+// requests to the task metadata server.
+type LimitsResponse struct {
+	// SteadyStateRate is the number of requests per second that are served in the steady state
+	SteadyStateRate float64 `json:"SteadyStateRate"`
+	// BurstRate is the number of requests that are served in a burst above the steady state rate
+	BurstRate int `json:"BurstRate"`
+}
+
+// LimitsHandler returns the handler method for handling rate limits requests, so that clients
+// can throttle themselves.
+func LimitsHandler(steadyStateRate float64, burstRate int) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := &LimitsResponse{
+			SteadyStateRate: steadyStateRate,
+			BurstRate:       burstRate,
+		}
+		responseJSON, err := json.Marshal(resp)
+		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+			return
+		}
+		utils.WriteJSONToResponse(w, http.StatusOK, responseJSON, utils.RequestTypeLimits)
+	}
+}
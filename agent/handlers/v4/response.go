@@ -14,16 +14,18 @@
 package v4
 
 import (
+	"time"
+
 	"github.com/aws/amazon-ecs-agent/agent/api"
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
 	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
 	v2 "github.com/aws/amazon-ecs-agent/agent/handlers/v2"
-	apieni "github.com/aws/amazon-ecs-agent/ecs-agent/api/eni"
 	tmdsresponse "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/response"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/utils"
 	tmdsv4 "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/v4/state"
 
+	"github.com/cihub/seelog"
 	"github.com/pkg/errors"
 )
 
@@ -38,6 +40,7 @@ func NewTaskResponse(
 	vpcID string,
 	containerInstanceARN string,
 	serviceName string,
+	schedulingStrategy string,
 	propagateTags bool,
 ) (*tmdsv4.TaskResponse, error) {
 	// Construct the v2 response first.
@@ -61,11 +64,20 @@ func NewTaskResponse(
 		})
 	}
 
+	var serviceContext *tmdsv4.ServiceContext
+	if serviceName != "" {
+		serviceContext = &tmdsv4.ServiceContext{
+			ServiceName:        serviceName,
+			SchedulingStrategy: schedulingStrategy,
+		}
+	}
+
 	return &tmdsv4.TaskResponse{
-		TaskResponse: v2Resp,
-		Containers:   containers,
-		VPCID:        vpcID,
-		ServiceName:  serviceName,
+		TaskResponse:   v2Resp,
+		Containers:     containers,
+		VPCID:          vpcID,
+		ServiceName:    serviceName,
+		ServiceContext: serviceContext,
 	}, nil
 }
 
@@ -93,6 +105,57 @@ func NewContainerResponse(
 	}, nil
 }
 
+// normalizeTaskTimestamps converts the timestamps of the task response, including those of its
+// containers, to UTC. They are truncated to second precision, so that they are formatted as
+// RFC3339, unless nanosecondPrecision is true.
+func normalizeTaskTimestamps(resp *tmdsv4.TaskResponse, nanosecondPrecision bool) {
+	if resp.TaskResponse != nil {
+		resp.PullStartedAt = normalizeTimestamp(resp.PullStartedAt, nanosecondPrecision)
+		resp.PullStoppedAt = normalizeTimestamp(resp.PullStoppedAt, nanosecondPrecision)
+		resp.ExecutionStoppedAt = normalizeTimestamp(resp.ExecutionStoppedAt, nanosecondPrecision)
+	}
+	for i := range resp.Containers {
+		normalizeContainerTimestamps(&resp.Containers[i], nanosecondPrecision)
+	}
+}
+
+// normalizeContainerTimestamps converts the timestamps of the container response to UTC. They are
+// truncated to second precision, so that they are formatted as RFC3339, unless nanosecondPrecision
+// is true.
+func normalizeContainerTimestamps(resp *tmdsv4.ContainerResponse, nanosecondPrecision bool) {
+	if resp.ContainerResponse == nil {
+		return
+	}
+	resp.CreatedAt = normalizeTimestamp(resp.CreatedAt, nanosecondPrecision)
+	resp.StartedAt = normalizeTimestamp(resp.StartedAt, nanosecondPrecision)
+	resp.FinishedAt = normalizeTimestamp(resp.FinishedAt, nanosecondPrecision)
+	if resp.Health != nil {
+		resp.Health.Since = normalizeTimestamp(resp.Health.Since, nanosecondPrecision)
+	}
+}
+
+func normalizeTimestamp(timestamp *time.Time, nanosecondPrecision bool) *time.Time {
+	if timestamp == nil {
+		return nil
+	}
+	normalized := timestamp.UTC()
+	if !nanosecondPrecision {
+		normalized = normalized.Truncate(time.Second)
+	}
+	return &normalized
+}
+
+// containerCgroupPath returns the cgroup path of the task's container with the given docker ID.
+// An empty path is returned if it cannot be determined.
+func containerCgroupPath(task *apitask.Task, dockerID string) string {
+	cgroupPath, err := task.GetContainerCgroupPath(dockerID)
+	if err != nil {
+		seelog.Warnf("Unable to get cgroup path of container %s: %v", dockerID, err)
+		return ""
+	}
+	return cgroupPath
+}
+
 // toV4NetworkResponse converts v2 network response to v4. Additional fields are only
 // added if the networking mode is 'awsvpc'. The `lookup` function pointer is used to
 // look up the task information in the local state based on the id, which could be
@@ -151,9 +214,11 @@ func newNetworkInterfaceProperties(task *apitask.Task) (tmdsv4.NetworkInterfaceP
 // It augments v4 container response with an additional empty network interface field.
 func NewPulledContainerResponse(
 	dockerContainer *apicontainer.DockerContainer,
-	eni *apieni.ENI,
+	task *apitask.Task,
 ) tmdsv4.ContainerResponse {
-	resp := v2.NewContainerResponse(dockerContainer, eni, true)
+	resp := v2.NewContainerResponse(dockerContainer, task, true)
+	// Pulled containers are not attached to any network yet
+	resp.NetworkIPAddresses = nil
 	return tmdsv4.ContainerResponse{
 		ContainerResponse: &resp,
 	}
@@ -28,7 +28,10 @@ import (
 	apitaskstatus "github.com/aws/amazon-ecs-agent/agent/api/task/status"
 	mock_dockerstate "github.com/aws/amazon-ecs-agent/agent/engine/dockerstate/mocks"
 	apieni "github.com/aws/amazon-ecs-agent/ecs-agent/api/eni"
+	tmdsv2 "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/v2"
+	tmdsv4 "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/v4/state"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/docker/docker/api/types"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -139,7 +142,7 @@ func TestNewTaskContainerResponses(t *testing.T) {
 	)
 
 	taskResponse, err := NewTaskResponse(taskARN, state, ecsClient, cluster,
-		availabilityZone, vpcID, containerInstanceArn, task.ServiceName, false)
+		availabilityZone, vpcID, containerInstanceArn, task.ServiceName, task.SchedulingStrategy, false)
 	require.NoError(t, err)
 	_, err = json.Marshal(taskResponse)
 	require.NoError(t, err)
@@ -149,6 +152,7 @@ func TestNewTaskContainerResponses(t *testing.T) {
 	assert.Equal(t, ipv6SubnetCIDRBlock, taskResponse.Containers[0].Networks[0].IPv6SubnetCIDRBlock)
 	assert.Equal(t, subnetGatewayIPV4Address, taskResponse.Containers[0].Networks[0].SubnetGatewayIPV4Address)
 	assert.Equal(t, serviceName, taskResponse.ServiceName)
+	assert.Equal(t, &tmdsv4.ServiceContext{ServiceName: serviceName}, taskResponse.ServiceContext)
 
 	gomock.InOrder(
 		state.EXPECT().ContainerByID(containerID).Return(dockerContainer, true),
@@ -162,3 +166,55 @@ func TestNewTaskContainerResponses(t *testing.T) {
 	assert.Equal(t, "192.168.0.0/24", containerResponse.Networks[0].IPV4SubnetCIDRBlock)
 	assert.Equal(t, subnetGatewayIPV4Address, containerResponse.Networks[0].SubnetGatewayIPV4Address)
 }
+
+func TestNormalizeTaskTimestamps(t *testing.T) {
+	timestamp := time.Date(2023, 6, 1, 12, 30, 45, 123456789, time.FixedZone("UTC-7", -7*60*60))
+	for _, tc := range []struct {
+		name                 string
+		nanosecondTimestamps bool
+		expectedTimestamp    string
+	}{
+		{
+			name:              "second precision",
+			expectedTimestamp: `"2023-06-01T19:30:45Z"`,
+		},
+		{
+			name:                 "nanosecond precision",
+			nanosecondTimestamps: true,
+			expectedTimestamp:    `"2023-06-01T19:30:45.123456789Z"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			taskResponse := &tmdsv4.TaskResponse{
+				TaskResponse: &tmdsv2.TaskResponse{
+					PullStartedAt:      aws.Time(timestamp),
+					PullStoppedAt:      aws.Time(timestamp),
+					ExecutionStoppedAt: aws.Time(timestamp),
+				},
+				Containers: []tmdsv4.ContainerResponse{{
+					ContainerResponse: &tmdsv2.ContainerResponse{
+						CreatedAt:  aws.Time(timestamp),
+						StartedAt:  aws.Time(timestamp),
+						FinishedAt: aws.Time(timestamp),
+						Health:     &tmdsv2.HealthStatus{Since: aws.Time(timestamp)},
+					},
+				}},
+			}
+			normalizeTaskTimestamps(taskResponse, tc.nanosecondTimestamps)
+
+			for _, ts := range []*time.Time{
+				taskResponse.PullStartedAt,
+				taskResponse.PullStoppedAt,
+				taskResponse.ExecutionStoppedAt,
+				taskResponse.Containers[0].CreatedAt,
+				taskResponse.Containers[0].StartedAt,
+				taskResponse.Containers[0].FinishedAt,
+				taskResponse.Containers[0].Health.Since,
+			} {
+				formatted, err := json.Marshal(ts)
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedTimestamp, string(formatted))
+			}
+		})
+	}
+}
@@ -14,6 +14,7 @@
 package v4
 
 import (
+	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
 	"github.com/aws/amazon-ecs-agent/agent/stats"
 	"github.com/cihub/seelog"
@@ -22,10 +23,22 @@ import (
 )
 
 // StatsResponse is the v4 Stats response. It augments the v4 Stats response
-// with the docker stats.
+// with the docker stats. Sample_retention_window_seconds is how far back the stats
+// samples retained by the agent for the container go.
 type StatsResponse struct {
 	*types.StatsJSON
-	Network_rate_stats *stats.NetworkStatsPerSec `json:"network_rate_stats,omitempty"`
+	Network_rate_stats              *stats.NetworkStatsPerSec `json:"network_rate_stats,omitempty"`
+	Sample_retention_window_seconds float64                   `json:"sample_retention_window_seconds,omitempty"`
+	Memory_allocation_stats         *MemoryAllocationStats    `json:"memory_allocation_stats,omitempty"`
+}
+
+// MemoryAllocationStats reports the memory reservation and limit of a container, as specified
+// in its task definition, together with its current memory usage. All values are in bytes.
+// A Limit of zero means the container's memory is unbounded.
+type MemoryAllocationStats struct {
+	Reservation uint64 `json:"reservation"`
+	Limit       uint64 `json:"limit"`
+	Usage       uint64 `json:"usage"`
 }
 
 // NewV4TaskStatsResponse returns a new v4 task stats response object
@@ -52,8 +65,10 @@ func NewV4TaskStatsResponse(taskARN string,
 		}
 
 		statsResponse := StatsResponse{
-			StatsJSON:          dockerStats,
-			Network_rate_stats: network_rate_stats,
+			StatsJSON:                       dockerStats,
+			Network_rate_stats:              network_rate_stats,
+			Sample_retention_window_seconds: sampleRetentionWindowSeconds(statsEngine, taskARN, containerID),
+			Memory_allocation_stats:         newMemoryAllocationStats(dockerContainer.Container, dockerStats),
 		}
 
 		resp[containerID] = statsResponse
@@ -61,3 +76,35 @@ func NewV4TaskStatsResponse(taskARN string,
 
 	return resp, nil
 }
+
+// sampleRetentionWindowSeconds returns how far back, in seconds, the stats samples retained for a
+// container go. Zero is returned when it can't be determined.
+func sampleRetentionWindowSeconds(statsEngine stats.Engine, taskARN, containerID string) float64 {
+	retentionWindow, err := statsEngine.ContainerStatsRetentionWindow(taskARN, containerID)
+	if err != nil {
+		seelog.Debugf("V4 stats response: Unable to get stats retention window for container '%s' for task '%s': %v",
+			containerID, taskARN, err)
+		return 0
+	}
+	return retentionWindow.Seconds()
+}
+
+// newMemoryAllocationStats returns the memory allocation stats of a container. Nil is returned
+// when the container definition is not known.
+func newMemoryAllocationStats(container *apicontainer.Container, dockerStats *types.StatsJSON) *MemoryAllocationStats {
+	if container == nil {
+		return nil
+	}
+
+	memoryStats := &MemoryAllocationStats{
+		// Container.Memory is specified in MiB in the task definition
+		Limit: uint64(container.Memory) * 1024 * 1024,
+	}
+	if reservation := container.GetMemoryReservation(); reservation > 0 {
+		memoryStats.Reservation = uint64(reservation)
+	}
+	if dockerStats != nil {
+		memoryStats.Usage = dockerStats.MemoryStats.Usage
+	}
+	return memoryStats
+}
@@ -17,10 +17,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 
 	"github.com/aws/amazon-ecs-agent/agent/api"
 	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	v2 "github.com/aws/amazon-ecs-agent/agent/handlers/v2"
 	v3 "github.com/aws/amazon-ecs-agent/agent/handlers/v3"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/credentials"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/utils"
 	tmdsv4 "github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/v4/state"
 
@@ -35,7 +38,10 @@ var TaskMetadataPath = "/v4/" + utils.ConstructMuxVar(v3.V3EndpointIDMuxName, ut
 var TaskWithTagsMetadataPath = "/v4/" + utils.ConstructMuxVar(v3.V3EndpointIDMuxName, utils.AnythingButSlashRegEx) + "/taskWithTags"
 
 // TaskMetadataHandler returns the handler method for handling task metadata requests.
-func TaskMetadataHandler(state dockerstate.TaskEngineState, ecsClient api.ECSClient, cluster, az, vpcID, containerInstanceArn string, propagateTags bool) func(http.ResponseWriter, *http.Request) {
+// The cgroup paths of the task's containers are reported when includeCgroupPath is true, and
+// timestamps are reported with nanosecond precision when nanosecondTimestamps is true. The
+// ARNs of the task's roles are looked up from their credentials in credentialsManager.
+func TaskMetadataHandler(state dockerstate.TaskEngineState, ecsClient api.ECSClient, credentialsManager credentials.Manager, cluster, az, vpcID, containerInstanceArn string, propagateTags, includeCgroupPath, nanosecondTimestamps bool) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var taskArn, err = v3.GetTaskARNByRequest(r, state)
 		if err != nil {
@@ -60,7 +66,7 @@ func TaskMetadataHandler(state dockerstate.TaskEngineState, ecsClient api.ECSCli
 		seelog.Infof("V4 taskMetadata handler: Writing response for task '%s'", taskArn)
 
 		taskResponse, err := NewTaskResponse(taskArn, state, ecsClient, cluster,
-			az, vpcID, containerInstanceArn, task.ServiceName, propagateTags)
+			az, vpcID, containerInstanceArn, task.ServiceName, task.SchedulingStrategy, propagateTags)
 		if err != nil {
 			errResponseJson, err := json.Marshal("Unable to generate metadata for v4 task: '" + taskArn + "'")
 			if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
@@ -84,18 +90,64 @@ func TaskMetadataHandler(state dockerstate.TaskEngineState, ecsClient api.ECSCli
 			taskResponse.Containers = responses
 		}
 
+		taskResponse.TaskRoleArn = roleARN(credentialsManager, task.GetCredentialsID())
+		taskResponse.ExecutionRoleArn = roleARN(credentialsManager, task.GetExecutionCredentialsID())
+
+		if includeCgroupPath {
+			for i := range taskResponse.Containers {
+				taskResponse.Containers[i].CgroupPath = containerCgroupPath(task, taskResponse.Containers[i].ID)
+			}
+		}
+
 		pulledContainers, _ := state.PulledContainerMapByArn(task.Arn)
 		// Convert each pulled container into v4 container response
 		// and append pulled containers to taskResponse.Containers
+		var pulledContainerResponses []tmdsv4.ContainerResponse
 		for _, dockerContainer := range pulledContainers {
-			taskResponse.Containers = append(taskResponse.Containers,
-				NewPulledContainerResponse(dockerContainer, task.GetPrimaryENI()))
+			pulledContainerResponses = append(pulledContainerResponses,
+				NewPulledContainerResponse(dockerContainer, task))
 		}
+		// Containers are looked up from maps, order them so that the ETag of the
+		// response only changes when the metadata does
+		sortContainersByName(taskResponse.Containers)
+		sortContainersByName(pulledContainerResponses)
+		taskResponse.Containers = append(taskResponse.Containers, pulledContainerResponses...)
+		normalizeTaskTimestamps(taskResponse, nanosecondTimestamps)
 
 		responseJSON, err := json.Marshal(taskResponse)
 		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
 			return
 		}
-		utils.WriteJSONToResponse(w, http.StatusOK, responseJSON, utils.RequestTypeTaskMetadata)
+		// Tag lookups that remained throttled after retries yield a degraded response
+		// that still carries all locally known metadata.
+		statusCode := http.StatusOK
+		if propagateTags && v2.HasThrottlingError(taskResponse.Errors) {
+			seelog.Warnf("V4 taskMetadata handler: tag lookups throttled for task '%s', returning degraded response", taskArn)
+			statusCode = http.StatusTooManyRequests
+		}
+		if statusCode != http.StatusOK {
+			utils.WriteJSONToResponse(w, statusCode, responseJSON, utils.RequestTypeTaskMetadata)
+			return
+		}
+		utils.WriteJSONToResponseWithETag(w, r, responseJSON, utils.RequestTypeTaskMetadata)
+	}
+}
+
+func sortContainersByName(containers []tmdsv4.ContainerResponse) {
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].Name < containers[j].Name
+	})
+}
+
+// roleARN returns the ARN of the role of the credentials with the given ID. Only the ARN is read
+// from the credentials. An empty string is returned if there are no such credentials.
+func roleARN(credentialsManager credentials.Manager, credentialsID string) string {
+	if credentialsID == "" {
+		return ""
+	}
+	roleCredentials, ok := credentialsManager.GetTaskCredentials(credentialsID)
+	if !ok {
+		return ""
 	}
+	return roleCredentials.GetIAMRoleCredentials().RoleArn
 }
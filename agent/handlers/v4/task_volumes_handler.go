@@ -0,0 +1,63 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package v4
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/aws/amazon-ecs-agent/agent/engine/dockerstate"
+	v2 "github.com/aws/amazon-ecs-agent/agent/handlers/v2"
+	v3 "github.com/aws/amazon-ecs-agent/agent/handlers/v3"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/tmds/handlers/utils"
+
+	"github.com/cihub/seelog"
+)
+
+// TaskVolumesPath specifies the relative URI path for serving the volumes of a task.
+var TaskVolumesPath = "/v4/" + utils.ConstructMuxVar(v3.V3EndpointIDMuxName, utils.AnythingButSlashRegEx) + "/task/volumes"
+
+// TaskVolumesHandler returns the handler method for handling task volumes requests.
+func TaskVolumesHandler(state dockerstate.TaskEngineState) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		taskARN, err := v3.GetTaskARNByRequest(r, state)
+		if err != nil {
+			responseJSON, err := json.Marshal(
+				fmt.Sprintf("V4 task volumes handler: unable to get task arn from request: %s", err.Error()))
+			if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+				return
+			}
+			utils.WriteJSONToResponse(w, http.StatusNotFound, responseJSON, utils.RequestTypeTaskVolumes)
+			return
+		}
+
+		task, ok := state.TaskByArn(taskARN)
+		if !ok {
+			responseJSON, err := json.Marshal("Unable to generate volumes for v4 task: '" + taskARN + "'")
+			if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+				return
+			}
+			utils.WriteJSONToResponse(w, http.StatusInternalServerError, responseJSON, utils.RequestTypeTaskVolumes)
+			return
+		}
+
+		seelog.Infof("V4 task volumes handler: Writing response for task '%s'", taskARN)
+		responseJSON, err := json.Marshal(v2.NewTaskVolumesResponse(task))
+		if e := utils.WriteResponseIfMarshalError(w, err); e != nil {
+			return
+		}
+		utils.WriteJSONToResponse(w, http.StatusOK, responseJSON, utils.RequestTypeTaskVolumes)
+	}
+}
@@ -24,10 +24,18 @@ import (
 // Implements AgentState interface for TMDS v4.
 type TMDSAgentState struct {
 	state dockerstate.TaskEngineState
+	// includeCgroupPath specifies whether the cgroup path of the container is reported.
+	includeCgroupPath bool
+	// nanosecondTimestamps specifies whether timestamps are reported with nanosecond precision.
+	nanosecondTimestamps bool
 }
 
-func NewTMDSAgentState(state dockerstate.TaskEngineState) *TMDSAgentState {
-	return &TMDSAgentState{state: state}
+func NewTMDSAgentState(state dockerstate.TaskEngineState, includeCgroupPath, nanosecondTimestamps bool) *TMDSAgentState {
+	return &TMDSAgentState{
+		state:                state,
+		includeCgroupPath:    includeCgroupPath,
+		nanosecondTimestamps: nanosecondTimestamps,
+	}
 }
 
 // Returns container metadata in v4 format for the container identified by the provided
@@ -41,6 +49,22 @@ func (s *TMDSAgentState) GetContainerMetadata(v3EndpointID string) (tmdsv4.Conta
 			v3EndpointID))
 	}
 
+	return s.getContainerMetadata(containerID)
+}
+
+// Returns container metadata in v4 format for the container that sends requests from the
+// provided source IP address.
+func (s *TMDSAgentState) GetContainerMetadataBySourceIP(sourceIP string) (tmdsv4.ContainerResponse, error) {
+	containerID, ok := containerIDBySourceIP(sourceIP, s.state)
+	if !ok {
+		return tmdsv4.ContainerResponse{}, tmdsv4.NewErrorLookupFailure(fmt.Sprintf(
+			"unable to resolve the calling container from source IP: %s", sourceIP))
+	}
+	return s.getContainerMetadata(containerID)
+}
+
+// Returns container metadata in v4 format for the container with the given docker ID.
+func (s *TMDSAgentState) getContainerMetadata(containerID string) (tmdsv4.ContainerResponse, error) {
 	containerResponse, err := NewContainerResponse(containerID, s.state)
 	if err != nil {
 		seelog.Errorf("Unable to get container metadata for container '%s'", containerID)
@@ -55,5 +79,54 @@ func (s *TMDSAgentState) GetContainerMetadata(v3EndpointID string) (tmdsv4.Conta
 		}
 	}
 
+	if s.includeCgroupPath {
+		if task, ok := s.state.TaskByID(containerID); ok {
+			containerResponse.CgroupPath = containerCgroupPath(task, containerID)
+		}
+	}
+	normalizeContainerTimestamps(containerResponse, s.nanosecondTimestamps)
+
 	return *containerResponse, nil
 }
+
+// containerIDBySourceIP returns the docker ID of the container that sends requests from the
+// given IP address. Containers of a task with its own IP address (awsvpc) share that address,
+// so it only resolves to a container when the task has a single non-internal container. Other
+// containers are resolved by the IP addresses of their docker networks (bridge).
+func containerIDBySourceIP(sourceIP string, state dockerstate.TaskEngineState) (string, bool) {
+	if taskARN, ok := state.GetTaskByIPAddress(sourceIP); ok {
+		containers, _ := state.ContainerMapByArn(taskARN)
+		var containerID string
+		for _, dockerContainer := range containers {
+			if dockerContainer.Container.IsInternal() {
+				continue
+			}
+			if containerID != "" {
+				seelog.Warnf("Unable to resolve the calling container of task '%s': task has more than one container",
+					taskARN)
+				return "", false
+			}
+			containerID = dockerContainer.DockerID
+		}
+		return containerID, containerID != ""
+	}
+
+	for _, task := range state.AllTasks() {
+		containers, _ := state.ContainerMapByArn(task.Arn)
+		for _, dockerContainer := range containers {
+			settings := dockerContainer.Container.GetNetworkSettings()
+			if settings == nil {
+				continue
+			}
+			if settings.IPAddress == sourceIP {
+				return dockerContainer.DockerID, true
+			}
+			for _, network := range settings.Networks {
+				if network != nil && network.IPAddress == sourceIP {
+					return dockerContainer.DockerID, true
+				}
+			}
+		}
+	}
+	return "", false
+}
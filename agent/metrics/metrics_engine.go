@@ -16,6 +16,7 @@ package metrics
 import (
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/aws/amazon-ecs-agent/agent/config"
@@ -30,6 +31,14 @@ type MetricsEngine struct {
 	cfg            *config.Config
 	Registry       *prometheus.Registry
 	managedMetrics map[APIType]MetricsClient
+	// taskEventBufferDepth is the number of task state change events queued
+	// to be sent to ECS. It is registered when the first depth is recorded
+	taskEventBufferDepth         prometheus.Gauge
+	registerTaskEventBufferDepth sync.Once
+	// dockerPingLatency is the round-trip latency of the latest Docker daemon
+	// ping. It is registered when the first latency is recorded
+	dockerPingLatency         prometheus.Gauge
+	registerDockerPingLatency sync.Once
 }
 
 const (
@@ -86,6 +95,18 @@ func NewMetricsEngine(cfg *config.Config, registry *prometheus.Registry) *Metric
 		cfg:            cfg,
 		Registry:       registry,
 		managedMetrics: make(map[APIType]MetricsClient),
+		taskEventBufferDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: AgentNamespace,
+			Subsystem: EventHandlerSubsystem,
+			Name:      "task_event_buffer_depth",
+			Help:      "Number of task state change events queued to be sent to ECS",
+		}),
+		dockerPingLatency: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: AgentNamespace,
+			Subsystem: DockerSubsystem,
+			Name:      "ping_latency_seconds",
+			Help:      "Round-trip latency of the latest Docker daemon ping",
+		}),
 	}
 	for managedAPI := range managedAPIs {
 		aClient := NewMetricsClient(managedAPI, metricsEngine.Registry)
@@ -114,6 +135,29 @@ func (engine *MetricsEngine) RecordECSClientMetric(callName string) func() {
 	return engine.recordGenericMetric(ECSClient, callName)
 }
 
+// RecordTaskEventBufferDepth records the number of task state change events
+// currently queued to be sent to ECS
+func (engine *MetricsEngine) RecordTaskEventBufferDepth(depth int) {
+	if engine == nil || !engine.collection {
+		return
+	}
+	engine.registerTaskEventBufferDepth.Do(func() {
+		engine.Registry.MustRegister(engine.taskEventBufferDepth)
+	})
+	engine.taskEventBufferDepth.Set(float64(depth))
+}
+
+// RecordDockerPingLatency records the round-trip latency of a Docker daemon ping
+func (engine *MetricsEngine) RecordDockerPingLatency(latency time.Duration) {
+	if engine == nil || !engine.collection {
+		return
+	}
+	engine.registerDockerPingLatency.Do(func() {
+		engine.Registry.MustRegister(engine.dockerPingLatency)
+	})
+	engine.dockerPingLatency.Set(latency.Seconds())
+}
+
 // Records a call's start and returns a function to be deferred.
 // Wrapper functions will use this function for GenericMetricsClients.
 // If Metrics collection is enabled from the cfg, we record a metric with callID
@@ -26,6 +26,7 @@ const (
 	TaskEngineSubsystem   = "TaskEngine"
 	StateManagerSubsystem = "StateManager"
 	ECSClientSubsystem    = "ECSClient"
+	EventHandlerSubsystem = "EventHandler"
 )
 
 // A factory method that enables various MetricsClients to be created.
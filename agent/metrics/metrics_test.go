@@ -159,6 +159,55 @@ func TestMetricCollection(t *testing.T) {
 	assert.True(t, verifyStats(metricFamilies, expected), "Metrics are not accurate")
 }
 
+// Tests that the task event buffer depth is exposed as a gauge once recorded
+func TestRecordTaskEventBufferDepth(t *testing.T) {
+	defer func() {
+		MetricsEngineGlobal = &MetricsEngine{
+			collection: false,
+		}
+	}()
+	cfg := getTestConfig()
+	MustInit(&cfg, prometheus.NewRegistry())
+
+	MetricsEngineGlobal.RecordTaskEventBufferDepth(3)
+	MetricsEngineGlobal.RecordTaskEventBufferDepth(5)
+
+	metricFamilies, err := MetricsEngineGlobal.Registry.Gather()
+	assert.NoError(t, err)
+	found := false
+	for _, metricFamily := range metricFamilies {
+		if metricFamily.GetName() == "AgentMetrics_EventHandler_task_event_buffer_depth" {
+			found = true
+			assert.Equal(t, 5.0, metricFamily.GetMetric()[0].GetGauge().GetValue())
+		}
+	}
+	assert.True(t, found, "Task event buffer depth metric not found")
+}
+
+func TestRecordDockerPingLatency(t *testing.T) {
+	defer func() {
+		MetricsEngineGlobal = &MetricsEngine{
+			collection: false,
+		}
+	}()
+	cfg := getTestConfig()
+	MustInit(&cfg, prometheus.NewRegistry())
+
+	MetricsEngineGlobal.RecordDockerPingLatency(2 * time.Second)
+	MetricsEngineGlobal.RecordDockerPingLatency(250 * time.Millisecond)
+
+	metricFamilies, err := MetricsEngineGlobal.Registry.Gather()
+	assert.NoError(t, err)
+	found := false
+	for _, metricFamily := range metricFamilies {
+		if metricFamily.GetName() == "AgentMetrics_DockerAPI_ping_latency_seconds" {
+			found = true
+			assert.Equal(t, 0.25, metricFamily.GetMetric()[0].GetGauge().GetValue())
+		}
+	}
+	assert.True(t, found, "Docker ping latency metric not found")
+}
+
 // A type for storing a Tree-based map. We map the MetricName to a map of metrics
 // under that name. This second map indexes by MetricLabelName+MetricLabelValue to
 // a slice MetricType and MetricValue.
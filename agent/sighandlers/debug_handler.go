@@ -26,7 +26,10 @@ import (
 	"github.com/cihub/seelog"
 )
 
-func StartDebugHandler() {
+// StartDebugHandler dumps the stack traces of all goroutines to the log on SIGUSR1.
+// The given debug dumpers are invoked after the stack traces are logged, so that
+// other debugging state can be captured with the same signal.
+func StartDebugHandler(debugDumpers ...DebugDumper) {
 	signalChannel := make(chan os.Signal, 1)
 	signal.Notify(signalChannel, syscall.SIGUSR1)
 	go func() {
@@ -37,6 +40,9 @@ func StartDebugHandler() {
 			// Resize the buffer to the size of the actual stack
 			stackDump = stackDump[:n]
 			seelog.Criticalf("====== STACKTRACE ======\n%v\n%s\n====== /STACKTRACE ======", time.Now(), stackDump)
+			for _, dump := range debugDumpers {
+				dump()
+			}
 		}
 	}()
 }
@@ -16,5 +16,6 @@
 
 package sighandlers
 
-func StartDebugHandler() {
+// StartDebugHandler is a no-op on Windows, which has no SIGUSR1.
+func StartDebugHandler(debugDumpers ...DebugDumper) {
 }
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"time"
 
+	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
 	"github.com/aws/amazon-ecs-agent/agent/config"
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient"
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient/dockerapi"
@@ -26,8 +27,13 @@ import (
 	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/utils/retry"
 	"github.com/cihub/seelog"
+	"github.com/docker/docker/api/types"
 )
 
+// statsCollectionIntervalLabel is the container label used to override the stats collection interval
+// of a task. Its value is a duration, such as "10s".
+const statsCollectionIntervalLabel = "com.amazonaws.ecs.stats-collection-interval"
+
 func newStatsContainer(dockerID string, client dockerapi.DockerClient, resolver resolver.ContainerMetadataResolver,
 	cfg *config.Config) (*StatsContainer, error) {
 	dockerContainer, err := resolver.ResolveContainer(dockerID)
@@ -50,15 +56,38 @@ func newStatsContainer(dockerID string, client dockerapi.DockerClient, resolver
 	}, nil
 }
 
+// taskStatsCollectionInterval returns the stats collection interval set for the task through the
+// statsCollectionIntervalLabel of its containers, or 0 if no valid interval is set.
+func taskStatsCollectionInterval(task *apitask.Task) time.Duration {
+	for _, container := range task.Containers {
+		value, ok := container.GetLabels()[statsCollectionIntervalLabel]
+		if !ok {
+			continue
+		}
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			seelog.Warnf("Task [%s]: ignoring invalid stats collection interval %q of container %s",
+				task.Arn, value, container.Name)
+			continue
+		}
+		return interval
+	}
+	return 0
+}
+
 func (container *StatsContainer) StartStatsCollection() {
 	// queue will be sized to hold enough stats for 4 publishing intervals.
-	var queueSize int
+	// for streaming stats we assume 1 stat every second
+	sampleInterval := time.Second
 	if container.config != nil && container.config.PollMetrics.Enabled() {
-		pollingInterval := container.config.PollingMetricsWaitDuration.Seconds()
-		queueSize = int(config.DefaultContainerMetricsPublishInterval.Seconds() / pollingInterval * 4)
-	} else {
-		// for streaming stats we assume 1 stat every second
-		queueSize = int(config.DefaultContainerMetricsPublishInterval.Seconds() * 4)
+		sampleInterval = container.config.PollingMetricsWaitDuration
+	}
+	if container.collectionInterval > sampleInterval {
+		sampleInterval = container.collectionInterval
+	}
+	queueSize := int(config.DefaultContainerMetricsPublishInterval.Seconds() / sampleInterval.Seconds() * 4)
+	if queueSize < 1 {
+		queueSize = 1
 	}
 	container.statsQueue = NewQueue(queueSize)
 	go container.collect()
@@ -139,6 +168,9 @@ func (container *StatsContainer) processStatsStream() error {
 				return err
 			}
 
+			if !container.keepSample(rawStat) {
+				continue
+			}
 			if err := container.statsQueue.Add(rawStat); err != nil {
 				seelog.Warnf("Container [%s]: error converting stats for container: %v", dockerID, err)
 			}
@@ -146,6 +178,24 @@ func (container *StatsContainer) processStatsStream() error {
 	}
 }
 
+// keepSample returns whether the stats sample should be kept, which is the case unless it was read
+// less than collectionInterval after the last kept sample. The previous read time and cpu stats of a
+// kept sample are set to those of the last kept sample, so that they reflect the collection interval.
+func (container *StatsContainer) keepSample(rawStat *types.StatsJSON) bool {
+	if container.collectionInterval <= 0 {
+		return true
+	}
+	if container.lastSample != nil {
+		if rawStat.Read.Sub(container.lastSample.Read) < container.collectionInterval {
+			return false
+		}
+		rawStat.PreRead = container.lastSample.Read
+		rawStat.PreCPUStats = container.lastSample.CPUStats
+	}
+	container.lastSample = rawStat
+	return true
+}
+
 func (container *StatsContainer) terminal() (bool, error) {
 	dockerContainer, err := container.resolver.ResolveContainer(container.containerMetadata.DockerID)
 	if err != nil {
@@ -26,11 +26,14 @@ import (
 
 	apicontainer "github.com/aws/amazon-ecs-agent/agent/api/container"
 	apicontainerstatus "github.com/aws/amazon-ecs-agent/agent/api/container/status"
+	apitask "github.com/aws/amazon-ecs-agent/agent/api/task"
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient"
 	mock_dockerapi "github.com/aws/amazon-ecs-agent/agent/dockerclient/dockerapi/mocks"
 	mock_resolver "github.com/aws/amazon-ecs-agent/agent/stats/resolver/mock"
 	"github.com/docker/docker/api/types"
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type StatTestData struct {
@@ -209,3 +212,118 @@ func TestContainerStatsCollectionStopsIfContainerIsTerminal(t *testing.T) {
 	case <-ctx.Done():
 	}
 }
+
+func TestTaskStatsCollectionInterval(t *testing.T) {
+	testCases := []struct {
+		name             string
+		labels           map[string]string
+		expectedInterval time.Duration
+	}{
+		{
+			name:             "interval label set",
+			labels:           map[string]string{statsCollectionIntervalLabel: "10s"},
+			expectedInterval: 10 * time.Second,
+		},
+		{
+			name:   "invalid interval label",
+			labels: map[string]string{statsCollectionIntervalLabel: "often"},
+		},
+		{
+			name:   "negative interval label",
+			labels: map[string]string{statsCollectionIntervalLabel: "-5s"},
+		},
+		{
+			name: "no interval label",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			container := &apicontainer.Container{Name: "c1"}
+			container.SetLabels(tc.labels)
+			task := &apitask.Task{
+				Arn:        "t1",
+				Containers: []*apicontainer.Container{{Name: "c0"}, container},
+			}
+			assert.Equal(t, tc.expectedInterval, taskStatsCollectionInterval(task))
+		})
+	}
+}
+
+func TestContainerStatsCollectionInterval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockDockerClient := mock_dockerapi.NewMockDockerClient(ctrl)
+
+	// Docker delivers one stats sample per second for both containers.
+	start := parseNanoTime("2015-02-12T21:22:05.131117533Z")
+	const numSamples = 9
+	newContainer := func(dockerID string, interval time.Duration) *StatsContainer {
+		ctx, cancel := context.WithCancel(context.TODO())
+		statChan := make(chan *types.StatsJSON)
+		mockDockerClient.EXPECT().Stats(ctx, dockerID, dockerclient.StatsInactivityTimeout).Return(statChan, make(chan error))
+		go func() {
+			for i := 0; i < numSamples; i++ {
+				dockerStat := &types.StatsJSON{}
+				json.Unmarshal([]byte(`{"memory_stats":{"usage":1024,"privateworkingset":1024},`+
+					`"cpu_stats":{"cpu_usage":{"percpu_usage":[100],"total_usage":100}}}`), dockerStat)
+				dockerStat.Read = start.Add(time.Duration(i) * time.Second)
+				dockerStat.PreRead = dockerStat.Read.Add(-time.Second)
+				select {
+				case statChan <- dockerStat:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return &StatsContainer{
+			containerMetadata:  &ContainerMetadata{DockerID: dockerID},
+			ctx:                ctx,
+			cancel:             cancel,
+			client:             mockDockerClient,
+			collectionInterval: interval,
+		}
+	}
+
+	testCases := []struct {
+		dockerID                string
+		interval                time.Duration
+		expectedSamples         int
+		expectedLastSampleRead  time.Time
+		expectedLastSampleSpace time.Duration
+	}{
+		{
+			dockerID:                "latency-sensitive",
+			interval:                2 * time.Second,
+			expectedSamples:         5,
+			expectedLastSampleRead:  start.Add(8 * time.Second),
+			expectedLastSampleSpace: 2 * time.Second,
+		},
+		{
+			dockerID:                "background",
+			interval:                4 * time.Second,
+			expectedSamples:         3,
+			expectedLastSampleRead:  start.Add(8 * time.Second),
+			expectedLastSampleSpace: 4 * time.Second,
+		},
+	}
+	containers := make([]*StatsContainer, len(testCases))
+	for i, tc := range testCases {
+		containers[i] = newContainer(tc.dockerID, tc.interval)
+		containers[i].StartStatsCollection()
+	}
+	time.Sleep(checkPointSleep)
+
+	for i, tc := range testCases {
+		container := containers[i]
+		container.StopStatsCollection()
+		container.statsQueue.lock.RLock()
+		samples := len(container.statsQueue.buffer)
+		container.statsQueue.lock.RUnlock()
+		assert.Equal(t, tc.expectedSamples, samples, "unexpected number of samples for %s", tc.dockerID)
+		lastStat := container.statsQueue.GetLastStat()
+		require.NotNil(t, lastStat)
+		assert.Equal(t, tc.expectedLastSampleRead, lastStat.Read)
+		assert.Equal(t, tc.expectedLastSampleSpace, lastStat.Read.Sub(lastStat.PreRead),
+			"unexpected sample spacing for %s", tc.dockerID)
+	}
+}
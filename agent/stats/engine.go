@@ -79,6 +79,7 @@ type DockerContainerMetadataResolver struct {
 type Engine interface {
 	GetInstanceMetrics(includeServiceConnectStats bool) (*ecstcs.MetricsMetadata, []*ecstcs.TaskMetric, error)
 	ContainerDockerStats(taskARN string, containerID string) (*types.StatsJSON, *NetworkStatsPerSec, error)
+	ContainerStatsRetentionWindow(taskARN string, containerID string) (time.Duration, error)
 	GetTaskHealthMetrics() (*ecstcs.HealthMetadata, []*ecstcs.TaskHealth, error)
 	GetPublishServiceConnectTickerInterval() int32
 	SetPublishServiceConnectTickerInterval(int32)
@@ -228,7 +229,36 @@ func (engine *DockerStatsEngine) addAndStartStatsContainer(containerID string) {
 			})
 		}
 	}
+	engine.limitSampleMemoryUnsafe()
+}
 
+// limitSampleMemoryUnsafe shares the configured cap on the memory used by retained stats samples
+// evenly between the queues being collected, which evict their oldest samples to fit.
+func (engine *DockerStatsEngine) limitSampleMemoryUnsafe() {
+	maxBytes := engine.config.StatsSampleBufferMaxMemoryMiB * BytesInMiB
+	if maxBytes <= 0 {
+		return
+	}
+	var queues []*Queue
+	for _, containerMap := range engine.tasksToContainers {
+		for _, container := range containerMap {
+			if container.statsQueue != nil {
+				queues = append(queues, container.statsQueue)
+			}
+		}
+	}
+	for _, taskStats := range engine.taskToTaskStats {
+		if taskStats.StatsQueue != nil {
+			queues = append(queues, taskStats.StatsQueue)
+		}
+	}
+	if len(queues) == 0 {
+		return
+	}
+	maxSize := maxBytes / (len(queues) * usageStatsSizeBytes)
+	for _, queue := range queues {
+		queue.SetMaxSize(maxSize)
+	}
 }
 
 // MustInit initializes fields of the DockerStatsEngine object.
@@ -365,6 +395,7 @@ func (engine *DockerStatsEngine) addContainerUnsafe(dockerID string) (*StatsCont
 	if err != nil {
 		return nil, nil, errors.Wrapf(err, "could not map docker container ID to container, ignoring container: %s", dockerID)
 	}
+	statsContainer.collectionInterval = taskStatsCollectionInterval(task)
 
 	seelog.Debugf("Adding container to stats watch list, id: %s, task: %s", dockerID, task.Arn)
 	engine.tasksToDefinitions[task.Arn] = &taskDefinition{family: task.Family, version: task.Version}
@@ -917,6 +948,8 @@ func (engine *DockerStatsEngine) taskContainerMetricsUnsafe(taskArn string) ([]*
 }
 
 func (engine *DockerStatsEngine) doRemoveContainerUnsafe(container *StatsContainer, taskArn string) {
+	// The memory freed up by the container is shared between the remaining ones
+	defer engine.limitSampleMemoryUnsafe()
 	container.StopStatsCollection()
 	dockerID := container.containerMetadata.DockerID
 	delete(engine.tasksToContainers[taskArn], dockerID)
@@ -1002,6 +1035,18 @@ func (engine *DockerStatsEngine) ContainerDockerStats(taskARN string, containerI
 	return containerStats, containerNetworkRateStats, nil
 }
 
+// ContainerStatsRetentionWindow returns how far back the stats samples retained for a container go
+func (engine *DockerStatsEngine) ContainerStatsRetentionWindow(taskARN string, containerID string) (time.Duration, error) {
+	engine.lock.RLock()
+	defer engine.lock.RUnlock()
+
+	container, ok := engine.tasksToContainers[taskARN][containerID]
+	if !ok || container.statsQueue == nil {
+		return 0, errors.Errorf("stats engine: container '%s' of task '%s' not found", containerID, taskARN)
+	}
+	return container.statsQueue.RetentionWindow(), nil
+}
+
 // getTaskStatsToCollect returns a map of taskArns for which task metrics needs to collected
 func (engine *DockerStatsEngine) getTaskStatsToCollect() map[string]bool {
 	taskStatsToCollect := make(map[string]bool)
@@ -67,6 +67,21 @@ func (mr *MockEngineMockRecorder) ContainerDockerStats(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContainerDockerStats", reflect.TypeOf((*MockEngine)(nil).ContainerDockerStats), arg0, arg1)
 }
 
+// ContainerStatsRetentionWindow mocks base method.
+func (m *MockEngine) ContainerStatsRetentionWindow(arg0, arg1 string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ContainerStatsRetentionWindow", arg0, arg1)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ContainerStatsRetentionWindow indicates an expected call of ContainerStatsRetentionWindow.
+func (mr *MockEngineMockRecorder) ContainerStatsRetentionWindow(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContainerStatsRetentionWindow", reflect.TypeOf((*MockEngine)(nil).ContainerStatsRetentionWindow), arg0, arg1)
+}
+
 // GetInstanceMetrics mocks base method.
 func (m *MockEngine) GetInstanceMetrics(arg0 bool) (*ecstcs.MetricsMetadata, []*ecstcs.TaskMetric, error) {
 	m.ctrl.T.Helper()
@@ -18,6 +18,7 @@ import (
 	"math"
 	"sync"
 	"time"
+	"unsafe"
 
 	"github.com/aws/amazon-ecs-agent/agent/tcs/model/ecstcs"
 	"github.com/cihub/seelog"
@@ -31,10 +32,20 @@ const (
 	NanoSecToSec    float32 = 1000000000
 )
 
+// minQueueSize is the minimum number of samples a queue retains, as stats sets need at least
+// two data points to be calculated.
+const minQueueSize = 2
+
+// usageStatsSizeBytes is an estimate of the memory retained by a sample in a queue.
+var usageStatsSizeBytes = int(unsafe.Sizeof(UsageStats{}) + unsafe.Sizeof(NetworkStats{}))
+
 // Queue abstracts a queue using UsageStats slice.
 type Queue struct {
-	buffer                []UsageStats
+	buffer []UsageStats
+	// maxSize is the number of samples the queue currently retains. It is lowered below
+	// capacity to limit the memory used by the queue.
 	maxSize               int
+	capacity              int
 	lastStat              *types.StatsJSON
 	lastNetworkStatPerSec *NetworkStatsPerSec
 	lock                  sync.RWMutex
@@ -43,9 +54,44 @@ type Queue struct {
 // NewQueue creates a queue.
 func NewQueue(maxSize int) *Queue {
 	return &Queue{
-		buffer:  make([]UsageStats, 0, maxSize),
-		maxSize: maxSize,
+		buffer:   make([]UsageStats, 0, maxSize),
+		maxSize:  maxSize,
+		capacity: maxSize,
+	}
+}
+
+// SetMaxSize limits the number of samples retained by the queue, evicting the oldest samples
+// that no longer fit. The limit is never raised above the size the queue was created with, nor
+// lowered below minQueueSize.
+func (queue *Queue) SetMaxSize(maxSize int) {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	if maxSize > queue.capacity {
+		maxSize = queue.capacity
+	}
+	if maxSize < minQueueSize {
+		maxSize = minQueueSize
+	}
+	queue.maxSize = maxSize
+	if len(queue.buffer) > maxSize {
+		// Copy the retained samples so that the memory of the evicted ones is released
+		retained := make([]UsageStats, maxSize)
+		copy(retained, queue.buffer[len(queue.buffer)-maxSize:])
+		queue.buffer = retained
+	}
+}
+
+// RetentionWindow returns the time between the oldest and the newest sample in the queue, which
+// is how far back stats can be calculated from.
+func (queue *Queue) RetentionWindow() time.Duration {
+	queue.lock.RLock()
+	defer queue.lock.RUnlock()
+
+	if len(queue.buffer) < 2 {
+		return 0
 	}
+	return queue.buffer[len(queue.buffer)-1].Timestamp.Sub(queue.buffer[0].Timestamp)
 }
 
 // Reset resets the queue's buffer so that only new metrics added after
@@ -717,3 +717,36 @@ func TestPerSecNetworkStatSetFailWithOneDatapoint(t *testing.T) {
 	stats, err := queue.GetNetworkStatsSet()
 	require.Errorf(t, err, "Received unexpected network stats set %v", stats)
 }
+
+func TestQueueSetMaxSizeEvictsOldestSamples(t *testing.T) {
+	timestamps := getTimestamps()
+	queue := createQueue(len(timestamps), false)
+	require.Len(t, queue.buffer, len(timestamps))
+
+	// Shrinking a full queue keeps only the most recent samples
+	queue.SetMaxSize(5)
+	require.Len(t, queue.buffer, 5)
+	for i, stat := range queue.buffer {
+		assert.Equal(t, timestamps[len(timestamps)-5+i], stat.Timestamp)
+	}
+	assert.Equal(t, 400*time.Millisecond, queue.RetentionWindow())
+
+	// Adding past the new maximum size keeps evicting the oldest samples
+	queue.add(&ContainerStats{
+		cpuUsage:     100,
+		memoryUsage:  100,
+		networkStats: &NetworkStats{},
+		timestamp:    now.Add(100 * time.Millisecond),
+	})
+	require.Len(t, queue.buffer, 5)
+	assert.Equal(t, timestamps[len(timestamps)-4], queue.buffer[0].Timestamp)
+	assert.Equal(t, now.Add(100*time.Millisecond), queue.buffer[4].Timestamp)
+	assert.Equal(t, 400*time.Millisecond, queue.RetentionWindow())
+
+	// The maximum size never grows past the queue's capacity or drops below the minimum
+	queue.SetMaxSize(len(timestamps) + 10)
+	assert.Equal(t, len(timestamps), queue.maxSize)
+	queue.SetMaxSize(0)
+	assert.Equal(t, minQueueSize, queue.maxSize)
+	assert.Len(t, queue.buffer, minQueueSize)
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/aws/amazon-ecs-agent/agent/config"
 	"github.com/aws/amazon-ecs-agent/agent/dockerclient/dockerapi"
 	"github.com/aws/amazon-ecs-agent/agent/stats/resolver"
+	"github.com/docker/docker/api/types"
 )
 
 // ContainerStats encapsulates the raw CPU and memory utilization from cgroup fs.
@@ -87,6 +88,10 @@ type StatsContainer struct {
 	statsQueue        *Queue
 	resolver          resolver.ContainerMetadataResolver
 	config            *config.Config
+	// collectionInterval, if set, is the minimum spacing of the stats samples kept for the container
+	collectionInterval time.Duration
+	// lastSample is the last stats sample kept for the container when collectionInterval is set
+	lastSample *types.StatsJSON
 }
 
 // taskDefinition encapsulates family and version strings for a task definition
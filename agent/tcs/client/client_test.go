@@ -119,6 +119,10 @@ func (*mockStatsEngine) ContainerDockerStats(taskARN string, id string) (*types.
 	return nil, nil, fmt.Errorf("not implemented")
 }
 
+func (*mockStatsEngine) ContainerStatsRetentionWindow(taskARN string, id string) (time.Duration, error) {
+	return 0, fmt.Errorf("not implemented")
+}
+
 func (*mockStatsEngine) GetTaskHealthMetrics() (*ecstcs.HealthMetadata, []*ecstcs.TaskHealth, error) {
 	return nil, nil, nil
 }
@@ -145,6 +149,10 @@ func (*emptyStatsEngine) ContainerDockerStats(taskARN string, id string) (*types
 	return nil, nil, fmt.Errorf("not implemented")
 }
 
+func (*emptyStatsEngine) ContainerStatsRetentionWindow(taskARN string, id string) (time.Duration, error) {
+	return 0, fmt.Errorf("not implemented")
+}
+
 func (*emptyStatsEngine) GetTaskHealthMetrics() (*ecstcs.HealthMetadata, []*ecstcs.TaskHealth, error) {
 	return nil, nil, nil
 }
@@ -177,6 +185,10 @@ func (*idleStatsEngine) ContainerDockerStats(taskARN string, id string) (*types.
 	return nil, nil, fmt.Errorf("not implemented")
 }
 
+func (*idleStatsEngine) ContainerStatsRetentionWindow(taskARN string, id string) (time.Duration, error) {
+	return 0, fmt.Errorf("not implemented")
+}
+
 func (*idleStatsEngine) GetTaskHealthMetrics() (*ecstcs.HealthMetadata, []*ecstcs.TaskHealth, error) {
 	return nil, nil, nil
 }
@@ -217,6 +229,10 @@ func (*nonIdleStatsEngine) ContainerDockerStats(taskARN string, id string) (*typ
 	return nil, nil, fmt.Errorf("not implemented")
 }
 
+func (*nonIdleStatsEngine) ContainerStatsRetentionWindow(taskARN string, id string) (time.Duration, error) {
+	return 0, fmt.Errorf("not implemented")
+}
+
 func (*nonIdleStatsEngine) GetTaskHealthMetrics() (*ecstcs.HealthMetadata, []*ecstcs.TaskHealth, error) {
 	return nil, nil, nil
 }
@@ -318,6 +334,10 @@ func (*serviceConnectStatsEngine) ContainerDockerStats(taskARN string, id string
 	return nil, nil, fmt.Errorf("not implemented")
 }
 
+func (*serviceConnectStatsEngine) ContainerStatsRetentionWindow(taskARN string, id string) (time.Duration, error) {
+	return 0, fmt.Errorf("not implemented")
+}
+
 func (*serviceConnectStatsEngine) GetTaskHealthMetrics() (*ecstcs.HealthMetadata, []*ecstcs.TaskHealth, error) {
 	return nil, nil, nil
 }
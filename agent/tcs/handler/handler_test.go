@@ -83,6 +83,10 @@ func (*mockStatsEngine) ContainerDockerStats(taskARN string, id string) (*types.
 	return nil, nil, fmt.Errorf("not implemented")
 }
 
+func (*mockStatsEngine) ContainerStatsRetentionWindow(taskARN string, id string) (time.Duration, error) {
+	return 0, fmt.Errorf("not implemented")
+}
+
 func (*mockStatsEngine) GetTaskHealthMetrics() (*ecstcs.HealthMetadata, []*ecstcs.TaskHealth, error) {
 	return nil, nil, nil
 }
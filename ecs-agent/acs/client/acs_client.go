@@ -20,10 +20,12 @@ package acsclient
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"time"
 
 	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/wsclient"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 )
@@ -33,18 +35,47 @@ type clientServer struct {
 	wsclient.ClientServerImpl
 }
 
-type acsClientFactory struct{}
+type acsClientFactory struct {
+	metricsFactory  metrics.EntryFactory
+	readMessageHook wsclient.ReadMessageHookFunc
+	// tlsSessionCache is shared by the clients created with TLS session
+	// resumption enabled, so that sessions are resumed across reconnects
+	tlsSessionCache tls.ClientSessionCache
+}
+
+// Option configures the ACS clients created by an ACS client factory.
+type Option func(*acsClientFactory)
+
+// WithMetricsFactory makes the ACS clients emit connection metrics using the given
+// metrics factory.
+func WithMetricsFactory(metricsFactory metrics.EntryFactory) Option {
+	return func(f *acsClientFactory) {
+		f.metricsFactory = metricsFactory
+	}
+}
+
+// WithReadMessageHook makes the ACS clients call the given hook with every raw message
+// read from ACS.
+func WithReadMessageHook(readMessageHook wsclient.ReadMessageHookFunc) Option {
+	return func(f *acsClientFactory) {
+		f.readMessageHook = readMessageHook
+	}
+}
 
 // NewACSClientFactory creates a new ACS client factory object. This can be
 // used to create new ACS clients.
-func NewACSClientFactory() wsclient.ClientFactory {
-	return &acsClientFactory{}
+func NewACSClientFactory(opts ...Option) wsclient.ClientFactory {
+	f := &acsClientFactory{tlsSessionCache: tls.NewLRUClientSessionCache(0)}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
 }
 
 // New returns a client/server to bidirectionally communicate with ACS
 // The returned struct should have both 'Connect' and 'Serve' called upon it
 // before being used.
-func (*acsClientFactory) New(url string, credentialProvider *credentials.Credentials, rwTimeout time.Duration, cfg *wsclient.WSClientMinAgentConfig) wsclient.ClientServer {
+func (f *acsClientFactory) New(url string, credentialProvider *credentials.Credentials, rwTimeout time.Duration, cfg *wsclient.WSClientMinAgentConfig) wsclient.ClientServer {
 	cs := &clientServer{}
 	cs.URL = url
 	cs.CredentialProvider = credentialProvider
@@ -53,6 +84,11 @@ func (*acsClientFactory) New(url string, credentialProvider *credentials.Credent
 	cs.RequestHandlers = make(map[string]wsclient.RequestHandler)
 	cs.TypeDecoder = NewACSDecoder()
 	cs.RWTimeout = rwTimeout
+	cs.MetricsFactory = f.metricsFactory
+	cs.ReadMessageHook = f.readMessageHook
+	if cfg.TLSSessionResumption {
+		cs.TLSSessionCache = f.tlsSessionCache
+	}
 	return cs
 }
 
@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/wsclient"
 	mock_wsconn "github.com/aws/amazon-ecs-agent/ecs-agent/wsclient/wsconn/mock"
 	"github.com/aws/aws-sdk-go/aws"
@@ -34,6 +35,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -252,6 +254,49 @@ func TestClosingConnection(t *testing.T) {
 	assert.Error(t, err)
 }
 
+// TestTLSSessionResumption tests that the clients created with TLS session
+// resumption enabled share the factory's session cache, so that sessions are
+// resumed across reconnects.
+func TestTLSSessionResumption(t *testing.T) {
+	factory := NewACSClientFactory()
+	resumptionCfg := &wsclient.WSClientMinAgentConfig{
+		AcceptInsecureCert:   true,
+		AWSRegion:            "us-east-1",
+		TLSSessionResumption: true,
+	}
+
+	cs1 := factory.New("localhost:443", testCreds, rwTimeout, resumptionCfg).(*clientServer)
+	cs2 := factory.New("localhost:443", testCreds, rwTimeout, resumptionCfg).(*clientServer)
+	require.NotNil(t, cs1.TLSSessionCache)
+	assert.True(t, cs1.TLSSessionCache == cs2.TLSSessionCache, "clients should share the session cache")
+
+	cs3 := factory.New("localhost:443", testCreds, rwTimeout, testCfg).(*clientServer)
+	assert.Nil(t, cs3.TLSSessionCache)
+}
+
+// TestNewACSClientFactoryOptions tests that the options passed to the factory are
+// applied together to the clients it creates.
+func TestNewACSClientFactoryOptions(t *testing.T) {
+	metricsFactory := metrics.NewNopEntryFactory()
+	hookCalled := false
+	factory := NewACSClientFactory(
+		WithMetricsFactory(metricsFactory),
+		WithReadMessageHook(func(message []byte) {
+			hookCalled = true
+		}),
+	)
+
+	cs := factory.New("localhost:443", testCreds, rwTimeout, testCfg).(*clientServer)
+	assert.Equal(t, metricsFactory, cs.MetricsFactory)
+	require.NotNil(t, cs.ReadMessageHook)
+	cs.ReadMessageHook([]byte("{}"))
+	assert.True(t, hookCalled)
+
+	cs = NewACSClientFactory().New("localhost:443", testCreds, rwTimeout, testCfg).(*clientServer)
+	assert.Nil(t, cs.MetricsFactory)
+	assert.Nil(t, cs.ReadMessageHook)
+}
+
 func TestConnect(t *testing.T) {
 	closeWS := make(chan bool)
 	server, serverChan, requestChan, serverErr, err := startMockAcsServer(t, closeWS)
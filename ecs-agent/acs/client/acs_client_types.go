@@ -35,10 +35,12 @@ func init() {
 		ecsacs.CloseMessage{},
 		ecsacs.AckRequest{},
 		ecsacs.NackRequest{},
+		ecsacs.AgentMetricsRequest{},
 		ecsacs.PerformUpdateMessage{},
 		ecsacs.StageUpdateMessage{},
 		ecsacs.IAMRoleCredentialsMessage{},
 		ecsacs.IAMRoleCredentialsAckRequest{},
+		ecsacs.RefreshInstanceCredentialsMessage{},
 		ecsacs.ServerException{},
 		ecsacs.BadRequestException{},
 		ecsacs.InvalidClusterException{},
@@ -50,8 +52,12 @@ func init() {
 		ecsacs.AttachInstanceNetworkInterfacesMessage{},
 		ecsacs.ConfirmAttachmentMessage{},
 		ecsacs.TaskManifestMessage{},
+		ecsacs.TaskManifestAckRequest{},
 		ecsacs.TaskStopVerificationAck{},
 		ecsacs.TaskStopVerificationMessage{},
+		ecsacs.UpdateCapabilitiesMessage{},
+		ecsacs.DrainContainerInstanceMessage{},
+		ecsacs.UpdateConnectionParametersMessage{},
 	}
 }
 
@@ -115,6 +115,72 @@ func (s AckRequest) GoString() string {
 	return s.String()
 }
 
+type AgentMetricsInput struct {
+	_ struct{} `type:"structure"`
+
+	Cluster *string `locationName:"cluster" type:"string"`
+
+	ContainerInstance *string `locationName:"containerInstance" type:"string"`
+
+	HeartbeatCount *int64 `locationName:"heartbeatCount" type:"long"`
+
+	LastHeartbeatAgeSeconds *int64 `locationName:"lastHeartbeatAgeSeconds" type:"long"`
+
+	ReconnectCount *int64 `locationName:"reconnectCount" type:"long"`
+
+	TaskCount *int64 `locationName:"taskCount" type:"long"`
+}
+
+// String returns the string representation
+func (s AgentMetricsInput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s AgentMetricsInput) GoString() string {
+	return s.String()
+}
+
+type AgentMetricsOutput struct {
+	_ struct{} `type:"structure"`
+}
+
+// String returns the string representation
+func (s AgentMetricsOutput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s AgentMetricsOutput) GoString() string {
+	return s.String()
+}
+
+type AgentMetricsRequest struct {
+	_ struct{} `type:"structure"`
+
+	Cluster *string `locationName:"cluster" type:"string"`
+
+	ContainerInstance *string `locationName:"containerInstance" type:"string"`
+
+	HeartbeatCount *int64 `locationName:"heartbeatCount" type:"long"`
+
+	LastHeartbeatAgeSeconds *int64 `locationName:"lastHeartbeatAgeSeconds" type:"long"`
+
+	ReconnectCount *int64 `locationName:"reconnectCount" type:"long"`
+
+	TaskCount *int64 `locationName:"taskCount" type:"long"`
+}
+
+// String returns the string representation
+func (s AgentMetricsRequest) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s AgentMetricsRequest) GoString() string {
+	return s.String()
+}
+
 type Association struct {
 	_ struct{} `type:"structure"`
 
@@ -382,6 +448,24 @@ func (s *BadRequestException) RequestID() string {
 	return s.RespMetadata.RequestID
 }
 
+type Capability struct {
+	_ struct{} `type:"structure"`
+
+	Name *string `locationName:"name" type:"string"`
+
+	Value *string `locationName:"value" type:"string"`
+}
+
+// String returns the string representation
+func (s Capability) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s Capability) GoString() string {
+	return s.String()
+}
+
 type CloseMessage struct {
 	_ struct{} `type:"structure"`
 
@@ -507,6 +591,8 @@ type Container struct {
 
 	Image *string `locationName:"image" type:"string"`
 
+	ImagePullTimeout *int64 `locationName:"imagePullTimeout" type:"integer"`
+
 	Links []*string `locationName:"links" type:"list"`
 
 	LogsAuthStrategy *string `locationName:"logsAuthStrategy" type:"string" enum:"AuthStrategy"`
@@ -612,6 +698,74 @@ func (s DockerVolumeConfiguration) GoString() string {
 	return s.String()
 }
 
+type DrainContainerInstanceInput struct {
+	_ struct{} `type:"structure"`
+
+	ClusterArn *string `locationName:"clusterArn" type:"string"`
+
+	ContainerInstanceArn *string `locationName:"containerInstanceArn" type:"string"`
+
+	Draining *bool `locationName:"draining" type:"boolean"`
+
+	MessageId *string `locationName:"messageId" type:"string"`
+
+	SeqNum *int64 `locationName:"seqNum" type:"long"`
+}
+
+// String returns the string representation
+func (s DrainContainerInstanceInput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s DrainContainerInstanceInput) GoString() string {
+	return s.String()
+}
+
+type DrainContainerInstanceMessage struct {
+	_ struct{} `type:"structure"`
+
+	ClusterArn *string `locationName:"clusterArn" type:"string"`
+
+	ContainerInstanceArn *string `locationName:"containerInstanceArn" type:"string"`
+
+	Draining *bool `locationName:"draining" type:"boolean"`
+
+	MessageId *string `locationName:"messageId" type:"string"`
+
+	SeqNum *int64 `locationName:"seqNum" type:"long"`
+}
+
+// String returns the string representation
+func (s DrainContainerInstanceMessage) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s DrainContainerInstanceMessage) GoString() string {
+	return s.String()
+}
+
+type DrainContainerInstanceOutput struct {
+	_ struct{} `type:"structure"`
+
+	Cluster *string `locationName:"cluster" type:"string"`
+
+	ContainerInstance *string `locationName:"containerInstance" type:"string"`
+
+	MessageId *string `locationName:"messageId" type:"string"`
+}
+
+// String returns the string representation
+func (s DrainContainerInstanceOutput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s DrainContainerInstanceOutput) GoString() string {
+	return s.String()
+}
+
 type EBSVolumeConfiguration struct {
 	_ struct{} `type:"structure"`
 
@@ -725,6 +879,8 @@ type ElasticNetworkInterface struct {
 
 	MacAddress *string `locationName:"macAddress" type:"string"`
 
+	Mtu *int64 `locationName:"mtu" type:"integer"`
+
 	Name *string `locationName:"name" type:"string"`
 
 	PrivateDnsName *string `locationName:"privateDnsName" type:"string"`
@@ -1599,6 +1755,66 @@ func (s ProxyConfiguration) GoString() string {
 	return s.String()
 }
 
+type RefreshInstanceCredentialsInput struct {
+	_ struct{} `type:"structure"`
+
+	ClusterArn *string `locationName:"clusterArn" type:"string"`
+
+	ContainerInstanceArn *string `locationName:"containerInstanceArn" type:"string"`
+
+	MessageId *string `locationName:"messageId" type:"string"`
+}
+
+// String returns the string representation
+func (s RefreshInstanceCredentialsInput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s RefreshInstanceCredentialsInput) GoString() string {
+	return s.String()
+}
+
+type RefreshInstanceCredentialsMessage struct {
+	_ struct{} `type:"structure"`
+
+	ClusterArn *string `locationName:"clusterArn" type:"string"`
+
+	ContainerInstanceArn *string `locationName:"containerInstanceArn" type:"string"`
+
+	MessageId *string `locationName:"messageId" type:"string"`
+}
+
+// String returns the string representation
+func (s RefreshInstanceCredentialsMessage) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s RefreshInstanceCredentialsMessage) GoString() string {
+	return s.String()
+}
+
+type RefreshInstanceCredentialsOutput struct {
+	_ struct{} `type:"structure"`
+
+	Cluster *string `locationName:"cluster" type:"string"`
+
+	ContainerInstance *string `locationName:"containerInstance" type:"string"`
+
+	MessageId *string `locationName:"messageId" type:"string"`
+}
+
+// String returns the string representation
+func (s RefreshInstanceCredentialsOutput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s RefreshInstanceCredentialsOutput) GoString() string {
+	return s.String()
+}
+
 type RefreshTaskIAMRoleCredentialsInput struct {
 	_ struct{} `type:"structure"`
 
@@ -1849,6 +2065,8 @@ type Task struct {
 
 	RoleCredentials *IAMRoleCredentials `locationName:"roleCredentials" type:"structure"`
 
+	SchedulingStrategy *string `locationName:"schedulingStrategy" type:"string" enum:"SchedulingStrategy"`
+
 	ServiceName *string `locationName:"serviceName" type:"string"`
 
 	TaskClusterArn *string `locationName:"taskClusterArn" type:"string"`
@@ -1892,6 +2110,30 @@ func (s TaskIdentifier) GoString() string {
 	return s.String()
 }
 
+type TaskManifestAckRequest struct {
+	_ struct{} `type:"structure"`
+
+	Cluster *string `locationName:"cluster" type:"string"`
+
+	ContainerInstance *string `locationName:"containerInstance" type:"string"`
+
+	MessageId *string `locationName:"messageId" type:"string"`
+
+	SeqNum *int64 `locationName:"seqNum" type:"long"`
+
+	Tasks []*TaskIdentifier `locationName:"tasks" type:"list"`
+}
+
+// String returns the string representation
+func (s TaskManifestAckRequest) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s TaskManifestAckRequest) GoString() string {
+	return s.String()
+}
+
 type TaskManifestInput struct {
 	_ struct{} `type:"structure"`
 
@@ -1952,6 +2194,10 @@ type TaskManifestOutput struct {
 	ContainerInstance *string `locationName:"containerInstance" type:"string"`
 
 	MessageId *string `locationName:"messageId" type:"string"`
+
+	SeqNum *int64 `locationName:"seqNum" type:"long"`
+
+	Tasks []*TaskIdentifier `locationName:"tasks" type:"list"`
 }
 
 // String returns the string representation
@@ -2040,6 +2286,146 @@ func (s TaskStopVerificationOutput) GoString() string {
 	return s.String()
 }
 
+type UpdateCapabilitiesInput struct {
+	_ struct{} `type:"structure"`
+
+	AddedCapabilities []*Capability `locationName:"addedCapabilities" type:"list"`
+
+	ClusterArn *string `locationName:"clusterArn" type:"string"`
+
+	ContainerInstanceArn *string `locationName:"containerInstanceArn" type:"string"`
+
+	MessageId *string `locationName:"messageId" type:"string"`
+
+	RemovedCapabilities []*string `locationName:"removedCapabilities" type:"list"`
+
+	SeqNum *int64 `locationName:"seqNum" type:"long"`
+}
+
+// String returns the string representation
+func (s UpdateCapabilitiesInput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s UpdateCapabilitiesInput) GoString() string {
+	return s.String()
+}
+
+type UpdateCapabilitiesMessage struct {
+	_ struct{} `type:"structure"`
+
+	AddedCapabilities []*Capability `locationName:"addedCapabilities" type:"list"`
+
+	ClusterArn *string `locationName:"clusterArn" type:"string"`
+
+	ContainerInstanceArn *string `locationName:"containerInstanceArn" type:"string"`
+
+	MessageId *string `locationName:"messageId" type:"string"`
+
+	RemovedCapabilities []*string `locationName:"removedCapabilities" type:"list"`
+
+	SeqNum *int64 `locationName:"seqNum" type:"long"`
+}
+
+// String returns the string representation
+func (s UpdateCapabilitiesMessage) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s UpdateCapabilitiesMessage) GoString() string {
+	return s.String()
+}
+
+type UpdateCapabilitiesOutput struct {
+	_ struct{} `type:"structure"`
+
+	Cluster *string `locationName:"cluster" type:"string"`
+
+	ContainerInstance *string `locationName:"containerInstance" type:"string"`
+
+	MessageId *string `locationName:"messageId" type:"string"`
+}
+
+// String returns the string representation
+func (s UpdateCapabilitiesOutput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s UpdateCapabilitiesOutput) GoString() string {
+	return s.String()
+}
+
+type UpdateConnectionParametersInput struct {
+	_ struct{} `type:"structure"`
+
+	ClusterArn *string `locationName:"clusterArn" type:"string"`
+
+	ConnectionTimeSeconds *int64 `locationName:"connectionTimeSeconds" type:"long"`
+
+	ContainerInstanceArn *string `locationName:"containerInstanceArn" type:"string"`
+
+	HeartbeatTimeoutSeconds *int64 `locationName:"heartbeatTimeoutSeconds" type:"long"`
+
+	MessageId *string `locationName:"messageId" type:"string"`
+}
+
+// String returns the string representation
+func (s UpdateConnectionParametersInput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s UpdateConnectionParametersInput) GoString() string {
+	return s.String()
+}
+
+type UpdateConnectionParametersMessage struct {
+	_ struct{} `type:"structure"`
+
+	ClusterArn *string `locationName:"clusterArn" type:"string"`
+
+	ConnectionTimeSeconds *int64 `locationName:"connectionTimeSeconds" type:"long"`
+
+	ContainerInstanceArn *string `locationName:"containerInstanceArn" type:"string"`
+
+	HeartbeatTimeoutSeconds *int64 `locationName:"heartbeatTimeoutSeconds" type:"long"`
+
+	MessageId *string `locationName:"messageId" type:"string"`
+}
+
+// String returns the string representation
+func (s UpdateConnectionParametersMessage) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s UpdateConnectionParametersMessage) GoString() string {
+	return s.String()
+}
+
+type UpdateConnectionParametersOutput struct {
+	_ struct{} `type:"structure"`
+
+	Cluster *string `locationName:"cluster" type:"string"`
+
+	ContainerInstance *string `locationName:"containerInstance" type:"string"`
+
+	MessageId *string `locationName:"messageId" type:"string"`
+}
+
+// String returns the string representation
+func (s UpdateConnectionParametersOutput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s UpdateConnectionParametersOutput) GoString() string {
+	return s.String()
+}
+
 type UpdateFailureInput struct {
 	_ struct{} `type:"structure"`
 
@@ -53,6 +53,8 @@ type ENI struct {
 	// InterfaceVlanProperties contains information for an interface
 	// that is supposed to be used as a VLAN device
 	InterfaceVlanProperties *InterfaceVlanProperties `json:",omitempty"`
+	// MTU is the MTU of the eni. It is zero when the eni uses the standard MTU.
+	MTU int `json:",omitempty"`
 
 	// Due to historical reasons, the IPv4 subnet prefix length is sent with IPv4 subnet gateway
 	// address instead of the ENI's IP addresses. However, CNI plugins and many OS APIs expect it
@@ -86,6 +88,11 @@ const (
 	// The ACS ENI payload structure does not contain an IPv6 subnet prefix length because "/64" is
 	// the only allowed length per RFCs above, and the only one that VPC supports.
 	IPv6SubnetPrefixLength = "64"
+
+	// minMTU and maxMTU are the bounds of the MTU values accepted for an ENI. The lower bound
+	// is the minimum IPv4 MTU (RFC 791) and the upper bound is the largest MTU supported by VPC.
+	minMTU = 68
+	maxMTU = 9001
 )
 
 var (
@@ -328,6 +335,7 @@ func ENIFromACS(acsENI *ecsacs.ElasticNetworkInterface) (*ENI, error) {
 		PrivateDNSName:               aws.StringValue(acsENI.PrivateDnsName),
 		InterfaceAssociationProtocol: aws.StringValue(acsENI.InterfaceAssociationProtocol),
 		InterfaceVlanProperties:      &interfaceVlanProperties,
+		MTU:                          int(aws.Int64Value(acsENI.Mtu)),
 	}
 
 	for _, nameserverIP := range acsENI.DomainNameServers {
@@ -365,6 +373,11 @@ func ValidateTaskENI(acsENI *ecsacs.ElasticNetworkInterface) error {
 		return errors.Errorf("eni message validation: empty eni id in the message")
 	}
 
+	// The MTU, if specified, must be within the range supported by VPC.
+	if acsENI.Mtu != nil && (aws.Int64Value(acsENI.Mtu) < minMTU || aws.Int64Value(acsENI.Mtu) > maxMTU) {
+		return errors.Errorf("eni message validation: invalid mtu %d in the message", aws.Int64Value(acsENI.Mtu))
+	}
+
 	// The association protocol, if specified, must be a supported value.
 	if (acsENI.InterfaceAssociationProtocol != nil) &&
 		(aws.StringValue(acsENI.InterfaceAssociationProtocol) != VLANInterfaceAssociationProtocol) &&
@@ -196,6 +196,26 @@ func TestENIFromACS(t *testing.T) {
 	assert.Len(t, eni.DomainNameSearchList, 1)
 	assert.Equal(t, customSearchDomain, eni.DomainNameSearchList[0])
 	assert.Equal(t, aws.StringValue(acsENI.PrivateDnsName), eni.PrivateDNSName)
+	assert.Zero(t, eni.MTU)
+}
+
+// TestENIFromACSWithMTU tests that the MTU of an eni from acs is propagated
+func TestENIFromACSWithMTU(t *testing.T) {
+	acsENI := getTestACSENI()
+	acsENI.Mtu = aws.Int64(9001)
+	eni, err := ENIFromACS(acsENI)
+	assert.NoError(t, err)
+	assert.Equal(t, 9001, eni.MTU)
+}
+
+// TestENIFromACSInvalidMTU tests that enis from acs with an out of range MTU are rejected
+func TestENIFromACSInvalidMTU(t *testing.T) {
+	for _, mtu := range []int64{0, minMTU - 1, maxMTU + 1} {
+		acsENI := getTestACSENI()
+		acsENI.Mtu = aws.Int64(mtu)
+		_, err := ENIFromACS(acsENI)
+		assert.Error(t, err, "expected error for mtu %d", mtu)
+	}
 }
 
 // TestValidateENIFromACS tests the validation of enis from acs
@@ -31,6 +31,10 @@ const (
 	V1CredentialsPath = "/v1/credentials"
 	V2CredentialsPath = "/v2/credentials"
 
+	// CredentialsEndpointIP is the IP address of the task metadata endpoint, which serves the
+	// credentials handler to the containers of tasks.
+	CredentialsEndpointIP = "169.254.170.2"
+
 	// credentialsEndpointRelativeURIFormat defines the relative URI format
 	// for the credentials endpoint. The place holders are the API Path and
 	// credentials ID
@@ -15,10 +15,12 @@ package doctor
 
 import (
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/utils/retry"
 )
 
 var (
@@ -29,9 +31,11 @@ var (
 type Doctor struct {
 	healthchecks         []Healthcheck
 	lock                 sync.RWMutex
+	runLock              sync.Mutex
 	cluster              string
 	containerInstanceArn string
 	statusReported       bool
+	healthcheckJitter    time.Duration
 }
 
 func NewDoctor(healthchecks []Healthcheck, cluster string, containerInstanceArn string) (*Doctor, error) {
@@ -91,26 +95,65 @@ func (doc *Doctor) AddHealthcheck(healthcheck Healthcheck) {
 	doc.healthchecks = append(doc.healthchecks, healthcheck)
 }
 
-// RunHealthchecks runs every healthcheck that the doctor knows about and
-// returns a cumulative result; true if they all pass, false otherwise
-func (doc *Doctor) RunHealthchecks() bool {
+// SetHealthcheckJitter sets the bound of the window over which doctor.RunHealthchecks()
+// staggers the healthchecks. With no jitter, all healthchecks are run one after another
+// as soon as doctor.RunHealthchecks() is called.
+func (doc *Doctor) SetHealthcheckJitter(jitter time.Duration) {
 	doc.lock.Lock()
 	defer doc.lock.Unlock()
-	allChecksResult := []HealthcheckStatus{}
-
-	for _, healthcheck := range doc.healthchecks {
-		res := healthcheck.RunCheck()
-		logger.Debug("Ran instance health check", logger.Fields{
-			"instanceHealthcheckType":   healthcheck.GetHealthcheckType(),
-			"instanceHealthCheckResult": res,
-		})
-		allChecksResult = append(allChecksResult, res)
+
+	doc.healthcheckJitter = jitter
+}
+
+// RunHealthchecks runs every healthcheck that the doctor knows about and
+// returns a cumulative result; true if they all pass, false otherwise.
+// If a healthcheck jitter is set, each healthcheck is started at a random
+// time within its own slot of the jitter window so that the healthchecks
+// don't all run at the same time.
+func (doc *Doctor) RunHealthchecks() bool {
+	doc.runLock.Lock()
+	defer doc.runLock.Unlock()
+
+	doc.lock.RLock()
+	healthchecks := make([]Healthcheck, len(doc.healthchecks))
+	copy(healthchecks, doc.healthchecks)
+	jitter := doc.healthcheckJitter
+	doc.lock.RUnlock()
+
+	allChecksResult := make([]HealthcheckStatus, len(healthchecks))
+	if jitter <= 0 || len(healthchecks) == 0 {
+		for i, healthcheck := range healthchecks {
+			allChecksResult[i] = runHealthcheck(healthcheck)
+		}
+	} else {
+		slot := jitter / time.Duration(len(healthchecks))
+		var wg sync.WaitGroup
+		for i, healthcheck := range healthchecks {
+			wg.Add(1)
+			go func(i int, healthcheck Healthcheck) {
+				defer wg.Done()
+				time.Sleep(retry.AddJitter(time.Duration(i)*slot, slot))
+				allChecksResult[i] = runHealthcheck(healthcheck)
+			}(i, healthcheck)
+		}
+		wg.Wait()
 	}
 
+	doc.lock.Lock()
+	defer doc.lock.Unlock()
 	doc.statusReported = false
 	return doc.allRight(allChecksResult)
 }
 
+func runHealthcheck(healthcheck Healthcheck) HealthcheckStatus {
+	res := healthcheck.RunCheck()
+	logger.Debug("Ran instance health check", logger.Fields{
+		"instanceHealthcheckType":   healthcheck.GetHealthcheckType(),
+		"instanceHealthCheckResult": res,
+	})
+	return res
+}
+
 // GetHealthchecks returns a copy of list of healthchecks that the
 // doctor is holding internally.
 func (doc *Doctor) GetHealthchecks() *[]Healthcheck {
@@ -17,6 +17,8 @@
 package doctor
 
 import (
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -70,6 +72,27 @@ func (fc *falseHealthcheck) GetLastHealthcheckTime() time.Time {
 	return time.Date(1974, time.May, 19, 1, 2, 3, 4, time.UTC)
 }
 
+// timedHealthcheck records the time at which its check was run
+type timedHealthcheck struct {
+	trueHealthcheck
+	status HealthcheckStatus
+	lock   sync.Mutex
+	ranAt  time.Time
+}
+
+func (tc *timedHealthcheck) RunCheck() HealthcheckStatus {
+	tc.lock.Lock()
+	defer tc.lock.Unlock()
+	tc.ranAt = time.Now()
+	return tc.status
+}
+
+func (tc *timedHealthcheck) getRanAt() time.Time {
+	tc.lock.Lock()
+	defer tc.lock.Unlock()
+	return tc.ranAt
+}
+
 func TestNewDoctor(t *testing.T) {
 	trueCheck := &trueHealthcheck{}
 	falseCheck := &falseHealthcheck{}
@@ -145,6 +168,42 @@ func TestRunHealthchecks(t *testing.T) {
 	}
 }
 
+func TestRunHealthchecksWithJitter(t *testing.T) {
+	const jitter = 200 * time.Millisecond
+	checks := []*timedHealthcheck{
+		{status: HealthcheckStatusOk},
+		{status: HealthcheckStatusOk},
+		{status: HealthcheckStatusImpaired},
+		{status: HealthcheckStatusOk},
+	}
+	var healthchecks []Healthcheck
+	for _, check := range checks {
+		healthchecks = append(healthchecks, check)
+	}
+	newDoctor, _ := NewDoctor(healthchecks, TEST_CLUSTER, TEST_INSTANCE_ARN)
+	newDoctor.SetHealthcheckJitter(jitter)
+
+	start := time.Now()
+	// The aggregate result still reflects every check, including the impaired one
+	assert.False(t, newDoctor.RunHealthchecks())
+
+	var ranAt []time.Time
+	for _, check := range checks {
+		checkRanAt := check.getRanAt()
+		assert.False(t, checkRanAt.IsZero(), "every healthcheck should have run")
+		// All checks are run within the jitter window
+		assert.True(t, checkRanAt.Sub(start) < jitter+100*time.Millisecond,
+			"healthcheck should run within the jitter window")
+		ranAt = append(ranAt, checkRanAt)
+	}
+	sort.Slice(ranAt, func(i, j int) bool { return ranAt[i].Before(ranAt[j]) })
+	// Each check runs in its own slot of the jitter window, so the first and last
+	// checks are at least two slots apart rather than running on the same tick
+	slot := jitter / time.Duration(len(checks))
+	assert.True(t, ranAt[len(ranAt)-1].Sub(ranAt[0]) >= 2*slot,
+		"healthchecks should be staggered across the jitter window")
+}
+
 func TestGetHealthchecks(t *testing.T) {
 	trueCheck := &trueHealthcheck{}
 	falseCheck := &falseHealthcheck{}
@@ -22,4 +22,21 @@ const (
 	GetTaskProtectionMetricName    = metadataServerMetricNamespace + ".GetTaskProtection"
 	UpdateTaskProtectionMetricName = metadataServerMetricNamespace + ".UpdateTaskProtection"
 	AuthConfigMetricName           = metadataServerMetricNamespace + ".AuthConfig"
+	TaskMetadataRequestMetricName  = metadataServerMetricNamespace + ".TaskMetadataRequest"
+
+	// ACS
+	acsMetricNamespace                       = "ACS"
+	ACSMessageProcessingTimeoutMetricName    = acsMetricNamespace + ".MessageProcessingTimeout"
+	ACSPrematureCredentialsRefreshMetricName = acsMetricNamespace + ".PrematureCredentialsRefresh"
+
+	// WSClient
+	wsClientMetricNamespace              = "WSClient"
+	WSClientConnectMetricName            = wsClientMetricNamespace + ".Connect"
+	WSClientDispatchQueueDepthMetricName = wsClientMetricNamespace + ".DispatchQueueDepth"
+	WSClientDroppedMessagesMetricName    = wsClientMetricNamespace + ".DroppedMessages"
+
+	// TaskHandler
+	taskHandlerMetricNamespace             = "TaskHandler"
+	TaskHandlerSubmitLatencyMetricName     = taskHandlerMetricNamespace + ".SubmitLatency"
+	TaskHandlerSubmitStateChangeMetricName = taskHandlerMetricNamespace + ".SubmitStateChange"
 )
@@ -0,0 +1,116 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package tmds
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	loggerfield "github.com/aws/amazon-ecs-agent/ecs-agent/logger/field"
+)
+
+// CertificateProvider provides the TLS certificate of the server from a certificate and key file
+// pair, and reloads them when either file changes on disk. The certificate is only used during the
+// TLS handshake, so a reload applies to new connections and leaves established ones alone.
+type CertificateProvider struct {
+	certFile string
+	keyFile  string
+
+	lock        sync.Mutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+}
+
+// NewCertificateProvider returns a new CertificateProvider object. An error is returned if the
+// certificate can't be loaded from the files.
+func NewCertificateProvider(certFile, keyFile string) (*CertificateProvider, error) {
+	p := &CertificateProvider{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload loads the certificate from the files, regardless of whether they changed. It can be used
+// to reload the certificate on a signal. The current certificate is kept if loading fails.
+func (p *CertificateProvider) Reload() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	certModTime, keyModTime, err := p.modTimes()
+	if err != nil {
+		return err
+	}
+	return p.load(certModTime, keyModTime)
+}
+
+// GetCertificate is meant to be used as the GetCertificate callback of a TLS config. It reloads
+// the certificate first if the files changed since it was loaded. If they can't be loaded, the
+// current certificate keeps being served so that a partially written file doesn't fail handshakes.
+func (p *CertificateProvider) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	certModTime, keyModTime, err := p.modTimes()
+	if err == nil && (!certModTime.Equal(p.certModTime) || !keyModTime.Equal(p.keyModTime)) {
+		err = p.load(certModTime, keyModTime)
+		if err == nil {
+			logger.Info("Reloaded TMDS TLS certificate", logger.Fields{
+				"certFile": p.certFile,
+				"keyFile":  p.keyFile,
+			})
+		}
+	}
+	if err != nil {
+		logger.Warn("Unable to reload TMDS TLS certificate, using the current one", logger.Fields{
+			"certFile":        p.certFile,
+			"keyFile":         p.keyFile,
+			loggerfield.Error: err,
+		})
+	}
+	return p.cert, nil
+}
+
+// modTimes returns the modification times of the certificate and key files.
+func (p *CertificateProvider) modTimes() (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(p.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("unable to stat certificate file: %w", err)
+	}
+	keyInfo, err := os.Stat(p.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("unable to stat key file: %w", err)
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+// load loads the certificate from the files and records the modification times it was loaded at.
+// It must be called with the lock held.
+func (p *CertificateProvider) load(certModTime, keyModTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(p.certFile, p.keyFile)
+	if err != nil {
+		return fmt.Errorf("unable to load certificate: %w", err)
+	}
+	p.cert = &cert
+	p.certModTime = certModTime
+	p.keyModTime = keyModTime
+	return nil
+}
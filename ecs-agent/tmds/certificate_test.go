@@ -0,0 +1,144 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+package tmds
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCertificate writes a self-signed certificate with the given serial number, and its key,
+// to the files. Their modification time is set to modTime.
+func writeTestCertificate(t *testing.T, certFile, keyFile string, serial int64, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "tmds"},
+		IPAddresses:  []net.IP{net.ParseIP(IPv4)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+func TestNewCertificateProviderErrors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := NewCertificateProvider(filepath.Join(dir, "missing.crt"), filepath.Join(dir, "missing.key"))
+	assert.Error(t, err)
+}
+
+// Tests that new connections to a server serving TLS present the certificate rotated on disk,
+// while connections established before the rotation keep working.
+func TestCertificateProviderReloadsChangedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tmds.crt")
+	keyFile := filepath.Join(dir, "tmds.key")
+	now := time.Now()
+	writeTestCertificate(t, certFile, keyFile, 1, now.Add(-time.Minute))
+
+	certProvider, err := NewCertificateProvider(certFile, keyFile)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	server, err := NewServer(nil, WithHandler(router), WithSteadyStateRate(100), WithBurstRate(100),
+		WithCertificateProvider(certProvider))
+	require.NoError(t, err)
+	require.NotNil(t, server.TLSConfig)
+
+	listener, err := net.Listen("tcp", IPv4+":0")
+	require.NoError(t, err)
+	go server.ServeTLS(listener, "", "")
+	defer server.Close()
+
+	dial := func() *tls.Conn {
+		conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+		require.NoError(t, err)
+		return conn
+	}
+	get := func(conn *tls.Conn) {
+		req, err := http.NewRequest("GET", "http://"+listener.Addr().String()+"/", nil)
+		require.NoError(t, err)
+		require.NoError(t, req.Write(conn))
+		res, err := http.ReadResponse(bufio.NewReader(conn), req)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	oldConn := dial()
+	defer oldConn.Close()
+	assert.Equal(t, int64(1), oldConn.ConnectionState().PeerCertificates[0].SerialNumber.Int64())
+	get(oldConn)
+
+	writeTestCertificate(t, certFile, keyFile, 2, now)
+
+	newConn := dial()
+	defer newConn.Close()
+	assert.Equal(t, int64(2), newConn.ConnectionState().PeerCertificates[0].SerialNumber.Int64())
+	get(newConn)
+
+	// The connection established before the rotation is not disrupted
+	get(oldConn)
+}
+
+// Tests that the current certificate keeps being served if the files can't be reloaded.
+func TestCertificateProviderKeepsCertificateOnReloadFailure(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tmds.crt")
+	keyFile := filepath.Join(dir, "tmds.key")
+	writeTestCertificate(t, certFile, keyFile, 1, time.Now().Add(-time.Minute))
+
+	certProvider, err := NewCertificateProvider(certFile, keyFile)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, []byte("partially written"), 0600))
+	assert.Error(t, certProvider.Reload())
+
+	cert, err := certProvider.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), leaf.SerialNumber.Int64())
+}
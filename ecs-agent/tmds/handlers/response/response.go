@@ -21,6 +21,113 @@ type VolumeResponse struct {
 	Destination string `json:"Destination,omitempty"`
 }
 
+// VolumeMountResponse is the schema for a volume mounted into a container. SourceType is one of
+// "bind", "volume" or "efs" and Source identifies the mounted volume for that type, such as the host
+// path of a bind mount or the file system ID of an EFS volume.
+type VolumeMountResponse struct {
+	SourceType  string `json:"SourceType,omitempty"`
+	Source      string `json:"Source,omitempty"`
+	Destination string `json:"Destination,omitempty"`
+	ReadOnly    bool   `json:"ReadOnly"`
+	// MountPropagation is the propagation mode of a bind mount, such as "rshared". It is only
+	// reported on Linux, when the mount doesn't use the default "rprivate" propagation.
+	MountPropagation string `json:"MountPropagation,omitempty"`
+}
+
+// TaskVolumesResponse is the schema for the volumes of a task.
+type TaskVolumesResponse struct {
+	TaskARN string               `json:"TaskARN"`
+	Volumes []TaskVolumeResponse `json:"Volumes"`
+}
+
+// TaskVolumeResponse is the schema for a volume of a task. Type is one of "bind", "volume" or
+// "efs" and Source identifies the volume for that type, such as the host path of a bind mount or
+// the file system ID of an EFS volume. Credentials in the driver options are redacted.
+type TaskVolumeResponse struct {
+	Name          string            `json:"Name"`
+	Type          string            `json:"Type"`
+	Source        string            `json:"Source,omitempty"`
+	Driver        string            `json:"Driver,omitempty"`
+	DriverOptions map[string]string `json:"DriverOptions,omitempty"`
+}
+
+// DependsOnResponse is the schema for a container startup dependency. Condition is one of
+// "START", "COMPLETE", "SUCCESS" or "HEALTHY".
+type DependsOnResponse struct {
+	ContainerName string `json:"ContainerName"`
+	Condition     string `json:"Condition"`
+}
+
+// RestartPolicyResponse is the schema for the restart policy of a container. Policy is one of
+// "NEVER", "UNLESS_TASK_STOPPED" or "ON_FAILURE". MaxAttempts is omitted when the number of
+// restarts is not limited.
+type RestartPolicyResponse struct {
+	Policy      string `json:"Policy"`
+	MaxAttempts *int64 `json:"MaxAttempts,omitempty"`
+}
+
+// LogRetentionResponse is the schema for the log rotation and buffering settings of a container's
+// log driver, so that log shippers can avoid re-shipping rotated-away data. MaxSize and MaxFile are
+// the rotation settings of the json-file and local drivers, and ForceFlushIntervalSeconds and
+// MaxBufferedEvents the flush settings of the awslogs driver. Mode and MaxBufferSize apply to all
+// drivers. Settings that are not configured are omitted.
+type LogRetentionResponse struct {
+	MaxSize                   string `json:"MaxSize,omitempty"`
+	MaxFile                   string `json:"MaxFile,omitempty"`
+	ForceFlushIntervalSeconds string `json:"ForceFlushIntervalSeconds,omitempty"`
+	MaxBufferedEvents         string `json:"MaxBufferedEvents,omitempty"`
+	Mode                      string `json:"Mode,omitempty"`
+	MaxBufferSize             string `json:"MaxBufferSize,omitempty"`
+}
+
+// ImagePullCredentialsSourceResponse is the schema for the source of the credentials used to pull a
+// container's image, to help debug private registry pull failures. It references where the
+// credentials come from but never the credentials themselves. Type is "ecr" for images pulled from
+// ECR, with the registry and region they were pulled from, or "asm" for private registry credentials
+// stored in Secrets Manager, with the ARN or name of the secret.
+type ImagePullCredentialsSourceResponse struct {
+	Type                 string `json:"Type"`
+	CredentialsParameter string `json:"CredentialsParameter,omitempty"`
+	RegistryID           string `json:"RegistryId,omitempty"`
+	Region               string `json:"Region,omitempty"`
+	UseExecutionRole     bool   `json:"UseExecutionRole,omitempty"`
+}
+
+// StopSignalResponse is the schema for the signal a container is sent when it is stopped. Default
+// is true when the container definition doesn't configure a stop signal and SIGTERM is sent.
+type StopSignalResponse struct {
+	Signal  string `json:"Signal"`
+	Default bool   `json:"Default"`
+}
+
+// ExtraHostResponse is the schema for an extra /etc/hosts entry of a container.
+type ExtraHostResponse struct {
+	Hostname  string `json:"Hostname"`
+	IPAddress string `json:"IpAddress"`
+}
+
+// ServiceDiscoveryResponse is the schema for the Service Connect names of a task. Ingress lists
+// the listeners through which the task's own services are reached and DNSRecords lists the
+// upstream service names the task can resolve.
+type ServiceDiscoveryResponse struct {
+	Ingress    []ServiceDiscoveryIngressResponse `json:"Ingress,omitempty"`
+	DNSRecords []ServiceDiscoveryDNSResponse     `json:"DNSRecords,omitempty"`
+}
+
+// ServiceDiscoveryIngressResponse is the schema for a Service Connect ingress listener. HostPort
+// is only set for bridge mode listeners with a predefined host port.
+type ServiceDiscoveryIngressResponse struct {
+	ListenerName string `json:"ListenerName"`
+	ListenerPort uint16 `json:"ListenerPort"`
+	HostPort     uint16 `json:"HostPort,omitempty"`
+}
+
+// ServiceDiscoveryDNSResponse is the schema for a Service Connect DNS record of an upstream service.
+type ServiceDiscoveryDNSResponse struct {
+	HostName string `json:"HostName"`
+	Address  string `json:"Address"`
+}
+
 // PortResponse defines the schema for portmapping response JSON
 // object.
 type PortResponse struct {
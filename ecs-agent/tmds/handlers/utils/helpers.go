@@ -14,9 +14,11 @@
 package utils
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/aws/amazon-ecs-agent/ecs-agent/logger/audit"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/logger/audit/request"
@@ -52,6 +54,26 @@ const (
 	// RequestTypeContainerAssociation specifies the container association request type of ContainerAssociationHandler.
 	RequestTypeContainerAssociation = "container association"
 
+	// RequestTypeAgentVersion specifies the Agent version request type of AgentVersionHandler.
+	RequestTypeAgentVersion = "agent version"
+
+	// RequestTypeACSProcessing specifies the ACS processing request type of ACSProcessingHandler.
+	RequestTypeACSProcessing = "acs processing"
+
+	// RequestTypeStateChangeDeadLetters specifies the state change dead letters request type of
+	// StateChangeDeadLettersHandler.
+	RequestTypeStateChangeDeadLetters = "state change dead letters"
+
+	// RequestTypeTaskStateReconciliation specifies the task state reconciliation request type of
+	// TaskStateReconciliationHandler.
+	RequestTypeTaskStateReconciliation = "task state reconciliation"
+
+	// RequestTypeTaskVolumes specifies the task volumes request type of TaskVolumesHandler.
+	RequestTypeTaskVolumes = "task volumes"
+
+	// RequestTypeLimits specifies the request type of LimitsHandler.
+	RequestTypeLimits = "limits"
+
 	// AnythingButSlashRegEx is a regex pattern that matches any string without slash.
 	AnythingButSlashRegEx = "[^/]*"
 
@@ -86,6 +108,56 @@ func WriteJSONResponse(
 	WriteJSONToResponse(w, httpStatusCode, responseJSON, requestType)
 }
 
+// WriteJSONResponseWithETag marshals the provided response to JSON and writes it to the
+// ResponseWriter with a 200 status code and an ETag header derived from the JSON response.
+// If the request's If-None-Match header matches the ETag, a 304 response without a body is
+// written instead.
+func WriteJSONResponseWithETag(
+	w http.ResponseWriter,
+	r *http.Request,
+	response interface{},
+	requestType string,
+) {
+	responseJSON, err := json.Marshal(response)
+	if e := WriteResponseIfMarshalError(w, err); e != nil {
+		return
+	}
+	WriteJSONToResponseWithETag(w, r, responseJSON, requestType)
+}
+
+// WriteJSONToResponseWithETag writes the JSON response to a ResponseWriter with a 200 status
+// code and an ETag header derived from the JSON response. If the request's If-None-Match
+// header matches the ETag, a 304 response without a body is written instead.
+func WriteJSONToResponseWithETag(w http.ResponseWriter, r *http.Request, responseJSON []byte, requestType string) {
+	etag := ETag(responseJSON)
+	w.Header().Set("ETag", etag)
+	if ETagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	WriteJSONToResponse(w, http.StatusOK, responseJSON, requestType)
+}
+
+// ETag returns a strong entity tag for the response body.
+func ETag(body []byte) string {
+	return fmt.Sprintf(`"%x"`, sha256.Sum256(body))
+}
+
+// ETagMatches returns true if the value of an If-None-Match header matches the entity tag.
+// Weak comparison is used, as recommended for If-None-Match.
+func ETagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
 // WriteJSONToResponse writes the header, JSON response to a ResponseWriter, and
 // log the error if necessary.
 func WriteJSONToResponse(w http.ResponseWriter, httpStatusCode int, responseJSON []byte, requestType string) {
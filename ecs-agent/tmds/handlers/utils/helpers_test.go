@@ -111,6 +111,64 @@ func TestWriteJSONResponseError(t *testing.T) {
 	assert.Equal(t, "{}", recorder.Body.String())
 }
 
+// Tests that WriteJSONResponseWithETag writes the response with an ETag header, and a 304
+// response without a body when the request's If-None-Match header matches the ETag.
+func TestWriteJSONResponseWithETag(t *testing.T) {
+	res := response.PortResponse{ContainerPort: 8080, Protocol: "TCP", HostPort: 80, HostIp: "IP"}
+	responseJSON, err := json.Marshal(res)
+	require.NoError(t, err)
+	etag := ETag(responseJSON)
+
+	testCases := []struct {
+		name               string
+		ifNoneMatch        string
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:               "no If-None-Match",
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       string(responseJSON),
+		},
+		{
+			name:               "matching If-None-Match",
+			ifNoneMatch:        etag,
+			expectedStatusCode: http.StatusNotModified,
+		},
+		{
+			name:               "matching weak If-None-Match in a list",
+			ifNoneMatch:        `"other", W/` + etag,
+			expectedStatusCode: http.StatusNotModified,
+		},
+		{
+			name:               "wildcard If-None-Match",
+			ifNoneMatch:        "*",
+			expectedStatusCode: http.StatusNotModified,
+		},
+		{
+			name:               "stale If-None-Match",
+			ifNoneMatch:        `"stale"`,
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       string(responseJSON),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/endpoint", nil)
+			require.NoError(t, err)
+			if tc.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+			recorder := httptest.NewRecorder()
+			WriteJSONResponseWithETag(recorder, req, res, RequestTypeTaskMetadata)
+
+			assert.Equal(t, tc.expectedStatusCode, recorder.Code)
+			assert.Equal(t, etag, recorder.Header().Get("ETag"))
+			assert.Equal(t, tc.expectedBody, recorder.Body.String())
+		})
+	}
+}
+
 func TestValueFromRequest(t *testing.T) {
 	r, _ := http.NewRequest("GET", "/v1/credentials?id=credid", nil)
 	val, ok := ValueFromRequest(r, "id")
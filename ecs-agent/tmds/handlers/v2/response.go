@@ -20,48 +20,74 @@ import (
 
 // TaskResponse defines the schema for the task response JSON object
 type TaskResponse struct {
-	Cluster               string              `json:"Cluster"`
-	TaskARN               string              `json:"TaskARN"`
-	Family                string              `json:"Family"`
-	Revision              string              `json:"Revision"`
-	DesiredStatus         string              `json:"DesiredStatus,omitempty"`
-	KnownStatus           string              `json:"KnownStatus"`
-	Containers            []ContainerResponse `json:"Containers,omitempty"`
-	Limits                *LimitsResponse     `json:"Limits,omitempty"`
-	PullStartedAt         *time.Time          `json:"PullStartedAt,omitempty"`
-	PullStoppedAt         *time.Time          `json:"PullStoppedAt,omitempty"`
-	ExecutionStoppedAt    *time.Time          `json:"ExecutionStoppedAt,omitempty"`
-	AvailabilityZone      string              `json:"AvailabilityZone,omitempty"`
-	TaskTags              map[string]string   `json:"TaskTags,omitempty"`
-	ContainerInstanceTags map[string]string   `json:"ContainerInstanceTags,omitempty"`
-	LaunchType            string              `json:"LaunchType,omitempty"`
-	Errors                []ErrorResponse     `json:"Errors,omitempty"`
+	Cluster                   string                             `json:"Cluster"`
+	TaskARN                   string                             `json:"TaskARN"`
+	Family                    string                             `json:"Family"`
+	Revision                  string                             `json:"Revision"`
+	DesiredStatus             string                             `json:"DesiredStatus,omitempty"`
+	KnownStatus               string                             `json:"KnownStatus"`
+	Containers                []ContainerResponse                `json:"Containers,omitempty"`
+	Limits                    *LimitsResponse                    `json:"Limits,omitempty"`
+	PullStartedAt             *time.Time                         `json:"PullStartedAt,omitempty"`
+	PullStoppedAt             *time.Time                         `json:"PullStoppedAt,omitempty"`
+	ExecutionStoppedAt        *time.Time                         `json:"ExecutionStoppedAt,omitempty"`
+	AvailabilityZone          string                             `json:"AvailabilityZone,omitempty"`
+	TaskTags                  map[string]string                  `json:"TaskTags,omitempty"`
+	ContainerInstanceTags     map[string]string                  `json:"ContainerInstanceTags,omitempty"`
+	LaunchType                string                             `json:"LaunchType,omitempty"`
+	EphemeralStorageEncrypted *bool                              `json:"EphemeralStorageEncrypted,omitempty"`
+	NetworkMode               string                             `json:"NetworkMode,omitempty"`
+	StopReason                string                             `json:"StopReason,omitempty"`
+	PidMode                   string                             `json:"PidMode,omitempty"`
+	IpcMode                   string                             `json:"IpcMode,omitempty"`
+	ServiceDiscovery          *response.ServiceDiscoveryResponse `json:"ServiceDiscovery,omitempty"`
+	Errors                    []ErrorResponse                    `json:"Errors,omitempty"`
 }
 
 // ContainerResponse defines the schema for the container response
 // JSON object
 type ContainerResponse struct {
-	ID            string                    `json:"DockerId"`
-	Name          string                    `json:"Name"`
-	DockerName    string                    `json:"DockerName"`
-	Image         string                    `json:"Image"`
-	ImageID       string                    `json:"ImageID"`
-	Ports         []response.PortResponse   `json:"Ports,omitempty"`
-	Labels        map[string]string         `json:"Labels,omitempty"`
-	DesiredStatus string                    `json:"DesiredStatus"`
-	KnownStatus   string                    `json:"KnownStatus"`
-	ExitCode      *int                      `json:"ExitCode,omitempty"`
-	Limits        LimitsResponse            `json:"Limits"`
-	CreatedAt     *time.Time                `json:"CreatedAt,omitempty"`
-	StartedAt     *time.Time                `json:"StartedAt,omitempty"`
-	FinishedAt    *time.Time                `json:"FinishedAt,omitempty"`
-	Type          string                    `json:"Type"`
-	Networks      []response.Network        `json:"Networks,omitempty"`
-	Health        *HealthStatus             `json:"Health,omitempty"`
-	Volumes       []response.VolumeResponse `json:"Volumes,omitempty"`
-	LogDriver     string                    `json:"LogDriver,omitempty"`
-	LogOptions    map[string]string         `json:"LogOptions,omitempty"`
-	ContainerARN  string                    `json:"ContainerARN,omitempty"`
+	ID                         string                                       `json:"DockerId"`
+	Name                       string                                       `json:"Name"`
+	DockerName                 string                                       `json:"DockerName"`
+	Image                      string                                       `json:"Image"`
+	ImageID                    string                                       `json:"ImageID"`
+	Ports                      []response.PortResponse                      `json:"Ports,omitempty"`
+	Labels                     map[string]string                            `json:"Labels,omitempty"`
+	DesiredStatus              string                                       `json:"DesiredStatus"`
+	KnownStatus                string                                       `json:"KnownStatus"`
+	ExitCode                   *int                                         `json:"ExitCode,omitempty"`
+	Limits                     LimitsResponse                               `json:"Limits"`
+	CreatedAt                  *time.Time                                   `json:"CreatedAt,omitempty"`
+	StartedAt                  *time.Time                                   `json:"StartedAt,omitempty"`
+	FinishedAt                 *time.Time                                   `json:"FinishedAt,omitempty"`
+	Type                       string                                       `json:"Type"`
+	Networks                   []response.Network                           `json:"Networks,omitempty"`
+	Health                     *HealthStatus                                `json:"Health,omitempty"`
+	Volumes                    []response.VolumeResponse                    `json:"Volumes,omitempty"`
+	VolumeMounts               []response.VolumeMountResponse               `json:"VolumeMounts,omitempty"`
+	DependsOn                  []response.DependsOnResponse                 `json:"DependsOn,omitempty"`
+	ExtraHosts                 []response.ExtraHostResponse                 `json:"ExtraHosts,omitempty"`
+	InitProcessEnabled         bool                                         `json:"InitProcessEnabled,omitempty"`
+	LogDriver                  string                                       `json:"LogDriver,omitempty"`
+	LogOptions                 map[string]string                            `json:"LogOptions,omitempty"`
+	ContainerARN               string                                       `json:"ContainerARN,omitempty"`
+	SeccompProfile             string                                       `json:"SeccompProfile,omitempty"`
+	AppArmorProfile            string                                       `json:"AppArmorProfile,omitempty"`
+	PidMode                    string                                       `json:"PidMode,omitempty"`
+	IpcMode                    string                                       `json:"IpcMode,omitempty"`
+	HostPID                    *int                                         `json:"HostPID,omitempty"`
+	CpusetCpus                 string                                       `json:"CpusetCpus,omitempty"`
+	RestartPolicy              *response.RestartPolicyResponse              `json:"RestartPolicy,omitempty"`
+	ContainerTags              map[string]string                            `json:"ContainerTags,omitempty"`
+	Entrypoint                 []string                                     `json:"Entrypoint,omitempty"`
+	Command                    []string                                     `json:"Command,omitempty"`
+	WorkingDirectory           string                                       `json:"WorkingDirectory,omitempty"`
+	NetworkIPAddresses         map[string][]string                          `json:"NetworkIPAddresses,omitempty"`
+	LogRetention               *response.LogRetentionResponse               `json:"LogRetention,omitempty"`
+	ImagePullCredentialsSource *response.ImagePullCredentialsSourceResponse `json:"ImagePullCredentialsSource,omitempty"`
+	StopSignal                 *response.StopSignalResponse                 `json:"StopSignal,omitempty"`
+	AWSCredentialsEndpoint     string                                       `json:"AWSCredentialsEndpoint,omitempty"`
 }
 
 // Container health status
@@ -15,7 +15,9 @@ package v4
 import (
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"strings"
 
 	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/logger/field"
@@ -30,6 +32,10 @@ import (
 const (
 	EndpointContainerIDMuxName = "endpointContainerIDMuxName"
 	version                    = "v4"
+
+	// LabelPrefixQueryField is the query parameter used to restrict the container labels
+	// returned by the container metadata handler to those with a given prefix.
+	LabelPrefixQueryField = "labelPrefix"
 )
 
 // ContainerMetadataPath specifies the relative URI path for serving container metadata.
@@ -37,6 +43,12 @@ func ContainerMetadataPath() string {
 	return "/v4/" + utils.ConstructMuxVar(EndpointContainerIDMuxName, utils.AnythingButSlashRegEx)
 }
 
+// SelfContainerMetadataPath specifies the relative URI path for serving the metadata of the
+// calling container. It must be registered before ContainerMetadataPath, which also matches it.
+func SelfContainerMetadataPath() string {
+	return "/v4/self"
+}
+
 // ContainerMetadataHandler returns the HTTP handler function for handling container metadata requests.
 func ContainerMetadataHandler(
 	agentState state.AgentState,
@@ -61,12 +73,83 @@ func ContainerMetadataHandler(
 			return
 		}
 
+		if labelPrefix, ok := utils.ValueFromRequest(r, LabelPrefixQueryField); ok {
+			containerMetadata = filterLabelsByPrefix(containerMetadata, labelPrefix)
+		}
+
 		logger.Info("Writing response for v4 container metadata", logger.Fields{
 			field.TMDSEndpointContainerID: endpointContainerID,
 			field.Container:               containerMetadata.ID,
 		})
-		utils.WriteJSONResponse(w, http.StatusOK, containerMetadata, utils.RequestTypeContainerMetadata)
+		utils.WriteJSONResponseWithETag(w, r, containerMetadata, utils.RequestTypeContainerMetadata)
+	}
+}
+
+// SelfContainerMetadataHandler returns the HTTP handler function for handling requests for
+// the metadata of the calling container, which is resolved from the request's source IP address.
+func SelfContainerMetadataHandler(
+	agentState state.AgentState,
+	metricsFactory metrics.EntryFactory,
+) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sourceIP, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			logger.Error("Failed to parse source address of v4 self container metadata request", logger.Fields{
+				field.SourceIP: r.RemoteAddr,
+				field.Error:    err,
+			})
+			utils.WriteJSONResponse(w, http.StatusNotFound,
+				"V4 container metadata handler: unable to resolve the calling container",
+				utils.RequestTypeContainerMetadata)
+			return
+		}
+
+		containerMetadata, err := agentState.GetContainerMetadataBySourceIP(sourceIP)
+		if err != nil {
+			logger.Error("Failed to get v4 self container metadata", logger.Fields{
+				field.SourceIP: sourceIP,
+				field.Error:    err,
+			})
+
+			responseCode, responseBody := getContainerErrorResponse(sourceIP, err)
+			utils.WriteJSONResponse(w, responseCode, responseBody, utils.RequestTypeContainerMetadata)
+
+			if utils.Is5XXStatus(responseCode) {
+				metricsFactory.New(metrics.InternalServerErrorMetricName).Done(err)()
+			}
+
+			return
+		}
+
+		if labelPrefix, ok := utils.ValueFromRequest(r, LabelPrefixQueryField); ok {
+			containerMetadata = filterLabelsByPrefix(containerMetadata, labelPrefix)
+		}
+
+		logger.Info("Writing response for v4 self container metadata", logger.Fields{
+			field.SourceIP:  sourceIP,
+			field.Container: containerMetadata.ID,
+		})
+		utils.WriteJSONResponseWithETag(w, r, containerMetadata, utils.RequestTypeContainerMetadata)
+	}
+}
+
+// Returns a copy of the container response that only contains the labels with the given prefix.
+func filterLabelsByPrefix(containerMetadata state.ContainerResponse, prefix string) state.ContainerResponse {
+	if containerMetadata.ContainerResponse == nil {
+		return containerMetadata
+	}
+	filtered := *containerMetadata.ContainerResponse
+	filtered.Labels = nil
+	for key, value := range containerMetadata.Labels {
+		if strings.HasPrefix(key, prefix) {
+			if filtered.Labels == nil {
+				filtered.Labels = make(map[string]string)
+			}
+			filtered.Labels[key] = value
+		}
 	}
+	containerMetadata.ContainerResponse = &filtered
+	return containerMetadata
 }
 
 // Returns an appropriate HTTP response status code and body for the error.
@@ -61,7 +61,8 @@ const (
 var (
 	attachmentIndex = 0
 	labels          = map[string]string{
-		"foo": "bar",
+		"foo":                       "bar",
+		"com.amazonaws.ecs.cluster": "default",
 	}
 	containerResponse = state.ContainerResponse{
 		ContainerResponse: &v2.ContainerResponse{
@@ -132,6 +133,75 @@ func TestContainerMetadata(t *testing.T) {
 			expectedResponseBody: containerResponse,
 		})
 	})
+	t.Run("labels filtered by prefix", func(t *testing.T) {
+		handler, _, agentState, _ := setup(t)
+		agentState.EXPECT().
+			GetContainerMetadata(endpointContainerID).
+			Return(containerResponse, nil)
+		expectedContainerResponse := *containerResponse.ContainerResponse
+		expectedContainerResponse.Labels = map[string]string{"com.amazonaws.ecs.cluster": "default"}
+		testTMDSRequest(t, handler, TMDSTestCase[state.ContainerResponse]{
+			path:               "/v4/" + endpointContainerID + "?" + LabelPrefixQueryField + "=com.amazonaws.ecs.",
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: state.ContainerResponse{
+				ContainerResponse: &expectedContainerResponse,
+				Networks:          containerResponse.Networks,
+			},
+		})
+		assert.Len(t, containerResponse.Labels, 2, "labels of the container metadata should not be modified")
+	})
+	t.Run("no labels match prefix", func(t *testing.T) {
+		handler, _, agentState, _ := setup(t)
+		agentState.EXPECT().
+			GetContainerMetadata(endpointContainerID).
+			Return(containerResponse, nil)
+		expectedContainerResponse := *containerResponse.ContainerResponse
+		expectedContainerResponse.Labels = nil
+		testTMDSRequest(t, handler, TMDSTestCase[state.ContainerResponse]{
+			path:               "/v4/" + endpointContainerID + "?" + LabelPrefixQueryField + "=nomatch",
+			expectedStatusCode: http.StatusOK,
+			expectedResponseBody: state.ContainerResponse{
+				ContainerResponse: &expectedContainerResponse,
+				Networks:          containerResponse.Networks,
+			},
+		})
+	})
+	t.Run("not modified since ETag", func(t *testing.T) {
+		handler, _, agentState, _ := setup(t)
+		stoppedContainer := *containerResponse.ContainerResponse
+		stoppedContainer.KnownStatus = "STOPPED"
+		gomock.InOrder(
+			agentState.EXPECT().GetContainerMetadata(endpointContainerID).Return(containerResponse, nil).Times(2),
+			agentState.EXPECT().GetContainerMetadata(endpointContainerID).Return(state.ContainerResponse{
+				ContainerResponse: &stoppedContainer,
+				Networks:          containerResponse.Networks,
+			}, nil),
+		)
+
+		sendRequest := func(ifNoneMatch string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest("GET", "/v4/"+endpointContainerID, nil)
+			require.NoError(t, err)
+			if ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", ifNoneMatch)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			return recorder
+		}
+
+		first := sendRequest("")
+		require.Equal(t, http.StatusOK, first.Code)
+		etag := first.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+
+		unchanged := sendRequest(etag)
+		assert.Equal(t, http.StatusNotModified, unchanged.Code)
+		assert.Empty(t, unchanged.Body.String())
+
+		changed := sendRequest(etag)
+		assert.Equal(t, http.StatusOK, changed.Code)
+		assert.NotEqual(t, etag, changed.Header().Get("ETag"))
+	})
 	t.Run("container lookup failed", func(t *testing.T) {
 		handler, _, agentState, _ := setup(t)
 		agentState.EXPECT().
@@ -181,12 +251,69 @@ func TestContainerMetadata(t *testing.T) {
 	})
 }
 
+func TestSelfContainerMetadata(t *testing.T) {
+	const sourceIP = "172.17.0.2"
+	var setup = func(t *testing.T) (*mux.Router, *mock_state.MockAgentState) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		agentState := mock_state.NewMockAgentState(ctrl)
+		metricsFactory := mock_metrics.NewMockEntryFactory(ctrl)
+
+		// The self path has to be registered first as the container metadata path matches it too.
+		router := mux.NewRouter()
+		router.HandleFunc(
+			SelfContainerMetadataPath(),
+			SelfContainerMetadataHandler(agentState, metricsFactory))
+		router.HandleFunc(
+			ContainerMetadataPath(),
+			ContainerMetadataHandler(agentState, metricsFactory))
+
+		return router, agentState
+	}
+
+	t.Run("known caller", func(t *testing.T) {
+		handler, agentState := setup(t)
+		agentState.EXPECT().
+			GetContainerMetadataBySourceIP(sourceIP).
+			Return(containerResponse, nil)
+		testTMDSRequest(t, handler, TMDSTestCase[state.ContainerResponse]{
+			path:                 "/v4/self",
+			remoteAddr:           sourceIP + ":54321",
+			expectedStatusCode:   http.StatusOK,
+			expectedResponseBody: containerResponse,
+		})
+	})
+	t.Run("unknown caller", func(t *testing.T) {
+		handler, agentState := setup(t)
+		agentState.EXPECT().
+			GetContainerMetadataBySourceIP(sourceIP).
+			Return(state.ContainerResponse{}, state.NewErrorLookupFailure(externalReason))
+		testTMDSRequest(t, handler, TMDSTestCase[string]{
+			path:                 "/v4/self",
+			remoteAddr:           sourceIP + ":54321",
+			expectedStatusCode:   http.StatusNotFound,
+			expectedResponseBody: "V4 container metadata handler: " + externalReason,
+		})
+	})
+	t.Run("unparseable source address", func(t *testing.T) {
+		handler, _ := setup(t)
+		testTMDSRequest(t, handler, TMDSTestCase[string]{
+			path:                 "/v4/self",
+			remoteAddr:           "invalid",
+			expectedStatusCode:   http.StatusNotFound,
+			expectedResponseBody: "V4 container metadata handler: unable to resolve the calling container",
+		})
+	})
+}
+
 type TMDSResponse interface {
 	string | state.ContainerResponse
 }
 
 type TMDSTestCase[R TMDSResponse] struct {
 	path                 string
+	remoteAddr           string
 	expectedStatusCode   int
 	expectedResponseBody R
 }
@@ -195,6 +322,7 @@ func testTMDSRequest[R TMDSResponse](t *testing.T, handler http.Handler, tc TMDS
 	// Create the request
 	req, err := http.NewRequest("GET", tc.path, nil)
 	require.NoError(t, err)
+	req.RemoteAddr = tc.remoteAddr
 
 	// Send the request and record the response
 	recorder := httptest.NewRecorder()
@@ -62,3 +62,18 @@ func (mr *MockAgentStateMockRecorder) GetContainerMetadata(arg0 interface{}) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContainerMetadata", reflect.TypeOf((*MockAgentState)(nil).GetContainerMetadata), arg0)
 }
+
+// GetContainerMetadataBySourceIP mocks base method.
+func (m *MockAgentState) GetContainerMetadataBySourceIP(arg0 string) (state.ContainerResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContainerMetadataBySourceIP", arg0)
+	ret0, _ := ret[0].(state.ContainerResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContainerMetadataBySourceIP indicates an expected call of GetContainerMetadataBySourceIP.
+func (mr *MockAgentStateMockRecorder) GetContainerMetadataBySourceIP(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContainerMetadataBySourceIP", reflect.TypeOf((*MockAgentState)(nil).GetContainerMetadataBySourceIP), arg0)
+}
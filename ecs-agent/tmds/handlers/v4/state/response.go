@@ -24,6 +24,23 @@ type TaskResponse struct {
 	Containers  []ContainerResponse `json:"Containers,omitempty"`
 	VPCID       string              `json:"VPCID,omitempty"`
 	ServiceName string              `json:"ServiceName,omitempty"`
+	// TaskRoleArn and ExecutionRoleArn are the ARNs of the task's IAM roles. They are
+	// omitted if the task has no such role.
+	TaskRoleArn      string `json:"TaskRoleArn,omitempty"`
+	ExecutionRoleArn string `json:"ExecutionRoleArn,omitempty"`
+	// ServiceContext describes the service the task belongs to. It is omitted for
+	// standalone tasks.
+	ServiceContext *ServiceContext `json:"ServiceContext,omitempty"`
+}
+
+// ServiceContext is the context of the service a task belongs to, as known to the agent.
+// ECS doesn't send the agent the desired count of a task's service, so only the context
+// that comes with the task is reported.
+type ServiceContext struct {
+	ServiceName string `json:"ServiceName"`
+	// SchedulingStrategy is the scheduling strategy of the service, REPLICA or DAEMON. It is
+	// omitted if ECS didn't send it with the task.
+	SchedulingStrategy string `json:"SchedulingStrategy,omitempty"`
 }
 
 // ContainerResponse is the v4 Container response. It augments the v4 Network response
@@ -31,6 +48,9 @@ type TaskResponse struct {
 type ContainerResponse struct {
 	*v2.ContainerResponse
 	Networks []Network `json:"Networks,omitempty"`
+	// CgroupPath is the path of the container's cgroup, relative to the root of the
+	// cgroup hierarchy. It is only populated when exposing cgroup paths is enabled.
+	CgroupPath string `json:"CgroupPath,omitempty"`
 }
 
 // Network is the v4 Network response. It adds a bunch of information about network
@@ -57,4 +57,10 @@ type AgentState interface {
 	// Returns ErrorLookupFailure if container lookup fails.
 	// Returns ErrorMetadataFetchFailure if something else goes wrong.
 	GetContainerMetadata(endpointContainerID string) (ContainerResponse, error)
+
+	// Returns container metadata in v4 format for the container that sends requests
+	// from the provided source IP address.
+	// Returns ErrorLookupFailure if the container cannot be resolved.
+	// Returns ErrorMetadataFetchFailure if something else goes wrong.
+	GetContainerMetadataBySourceIP(sourceIP string) (ContainerResponse, error)
 }
@@ -0,0 +1,75 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tmds
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+)
+
+// inactivityTracker closes connections that have not sent a request to the server for longer
+// than a timeout. A connection counts as inactive while it is waiting for its first request
+// or, between requests, for the next one. Connections that are in the middle of sending a
+// request body or being served are left alone, no matter how slow the client is, so that idle
+// clients can be told apart from slow but active ones.
+type inactivityTracker struct {
+	timeout time.Duration
+	lock    sync.Mutex
+	timers  map[net.Conn]*time.Timer
+}
+
+// newInactivityTracker returns a new inactivityTracker object
+func newInactivityTracker(timeout time.Duration) *inactivityTracker {
+	return &inactivityTracker{
+		timeout: timeout,
+		timers:  make(map[net.Conn]*time.Timer),
+	}
+}
+
+// connState is meant to be used as the ConnState callback of an HTTP server. It starts
+// the inactivity timer of a connection when the connection is opened or done being served
+// and stops it once the server has read the headers of the next request.
+func (t *inactivityTracker) connState(conn net.Conn, state http.ConnState) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	timer, ok := t.timers[conn]
+	switch state {
+	case http.StateNew, http.StateIdle:
+		if ok {
+			timer.Reset(t.timeout)
+			return
+		}
+		t.timers[conn] = time.AfterFunc(t.timeout, func() {
+			logger.Debug("Closing inactive connection", logger.Fields{
+				"remoteAddr": conn.RemoteAddr().String(),
+				"timeout":    t.timeout.String(),
+			})
+			conn.Close()
+		})
+	case http.StateActive:
+		if ok {
+			timer.Stop()
+		}
+	case http.StateClosed, http.StateHijacked:
+		if ok {
+			timer.Stop()
+			delete(t.timers, conn)
+		}
+	}
+}
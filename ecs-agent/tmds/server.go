@@ -13,6 +13,7 @@
 package tmds
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net/http"
@@ -40,12 +41,14 @@ func AddressIPv4() string {
 
 // Configuration for TMDS
 type Config struct {
-	listenAddress   string        // http server listen address
-	readTimeout     time.Duration // http server read timeout
-	writeTimeout    time.Duration // http server write timeout
-	steadyStateRate float64       // steady request rate limit
-	burstRate       int           // burst request rate limit
-	handler         http.Handler  // HTTP handler with routes configured
+	listenAddress     string               // http server listen address
+	readTimeout       time.Duration        // http server read timeout
+	writeTimeout      time.Duration        // http server write timeout
+	inactivityTimeout time.Duration        // timeout after which connections not sending anything are closed
+	steadyStateRate   float64              // steady request rate limit
+	burstRate         int                  // burst request rate limit
+	handler           http.Handler         // HTTP handler with routes configured
+	certProvider      *CertificateProvider // TLS certificate provider, if the server serves TLS
 }
 
 // Function type for updating TMDS config
@@ -72,6 +75,16 @@ func WithWriteTimeout(writeTimeout time.Duration) ConfigOpt {
 	}
 }
 
+// Set TMDS connection inactivity timeout. Connections that have not sent a request for
+// this long are closed, while connections that are slowly sending a request body or
+// waiting on a response are not. Connections are not closed for inactivity if the timeout
+// is not positive.
+func WithInactivityTimeout(inactivityTimeout time.Duration) ConfigOpt {
+	return func(c *Config) {
+		c.inactivityTimeout = inactivityTimeout
+	}
+}
+
 // Set TMDS steady request rate limit
 func WithSteadyStateRate(steadyStateRate float64) ConfigOpt {
 	return func(c *Config) {
@@ -93,6 +106,15 @@ func WithHandler(handler http.Handler) ConfigOpt {
 	}
 }
 
+// Set TMDS TLS certificate provider. The server's TLS config gets its certificate from the
+// provider, so certificates rotated on disk are served to new connections without a restart.
+// The server has to be started with ServeTLS or ListenAndServeTLS, with empty file names.
+func WithCertificateProvider(certProvider *CertificateProvider) ConfigOpt {
+	return func(c *Config) {
+		c.certProvider = certProvider
+	}
+}
+
 // Create a new HTTP Task Metadata Server (TMDS)
 func NewServer(auditLogger audit.AuditLogger, options ...ConfigOpt) (*http.Server, error) {
 	config := new(Config)
@@ -125,10 +147,20 @@ func setup(auditLogger audit.AuditLogger, config *Config) (*http.Server, error)
 	// explicitly enable path cleaning
 	loggingMuxRouter.SkipClean(false)
 
-	return &http.Server{
+	server := &http.Server{
 		Addr:         config.listenAddress,
 		Handler:      loggingMuxRouter,
 		ReadTimeout:  config.readTimeout,
 		WriteTimeout: config.writeTimeout,
-	}, nil
+	}
+	if config.inactivityTimeout > 0 {
+		server.ConnState = newInactivityTracker(config.inactivityTimeout).connState
+	}
+	if config.certProvider != nil {
+		server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: config.certProvider.GetCertificate,
+		}
+	}
+	return server, nil
 }
@@ -16,6 +16,11 @@
 package tmds
 
 import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
 	"testing"
 	"time"
 
@@ -48,6 +53,67 @@ func TestServerSettings(t *testing.T) {
 	assert.Equal(t, AddressIPv4(), server.Addr)
 	assert.Equal(t, writeTimeout, server.WriteTimeout)
 	assert.Equal(t, readTimeout, server.ReadTimeout)
+	assert.Nil(t, server.ConnState, "connections should not be tracked for inactivity by default")
+}
+
+// Tests that connections that don't send anything are closed after the inactivity timeout
+// while connections that are slowly sending a request are not.
+func TestInactivityTimeout(t *testing.T) {
+	inactivityTimeout := 200 * time.Millisecond
+
+	router := mux.NewRouter()
+	router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	server, err := NewServer(nil,
+		WithHandler(router),
+		WithInactivityTimeout(inactivityTimeout),
+		WithSteadyStateRate(10),
+		WithBurstRate(10))
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+	defer server.Close()
+
+	t.Run("idle client is closed", func(t *testing.T) {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*inactivityTimeout)))
+		_, err = conn.Read(make([]byte, 1))
+		assert.Equal(t, io.EOF, err, "idle connection should have been closed by the server")
+	})
+
+	t.Run("active but slow client stays open", func(t *testing.T) {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		// Send the request body a byte at a time so that it takes several times the
+		// inactivity timeout to be sent in full.
+		body := "slow-client"
+		_, err = conn.Write([]byte("POST / HTTP/1.1\r\nHost: localhost\r\nContent-Length: " +
+			strconv.Itoa(len(body)) + "\r\n\r\n"))
+		require.NoError(t, err)
+		for i := range body {
+			time.Sleep(inactivityTimeout / 2)
+			_, err := conn.Write([]byte{body[i]})
+			require.NoError(t, err, "connection should still be open")
+		}
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*inactivityTimeout)))
+		resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
 }
 
 func TestAddressIPv4(t *testing.T) {
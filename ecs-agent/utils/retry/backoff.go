@@ -14,6 +14,7 @@
 package retry
 
 import (
+	"fmt"
 	"math/rand"
 	"time"
 )
@@ -23,6 +24,38 @@ type Backoff interface {
 	Duration() time.Duration
 }
 
+// BackoffStrategy is the way the delays of a Backoff grow between retries
+type BackoffStrategy string
+
+const (
+	// BackoffStrategyExponential multiplies the delay each time, see
+	// NewExponentialBackoff
+	BackoffStrategyExponential BackoffStrategy = "exponential"
+	// BackoffStrategyDecorrelatedJitter picks each delay at random based on the
+	// previous one, see NewDecorrelatedJitterBackoff
+	BackoffStrategyDecorrelatedJitter BackoffStrategy = "decorrelated-jitter"
+	// BackoffStrategyConstant always waits for the min delay, see
+	// NewConstantBackoff
+	BackoffStrategyConstant BackoffStrategy = "constant"
+)
+
+// NewBackoff creates a Backoff using the given strategy. The parameters are
+// those of NewExponentialBackoff. The decorrelated jitter strategy has its own
+// randomness and ignores jitterMultiple, and the constant strategy ignores max
+// and multiple.
+func NewBackoff(strategy BackoffStrategy, min, max time.Duration, jitterMultiple, multiple float64) (Backoff, error) {
+	switch strategy {
+	case BackoffStrategyExponential:
+		return NewExponentialBackoff(min, max, jitterMultiple, multiple), nil
+	case BackoffStrategyDecorrelatedJitter:
+		return NewDecorrelatedJitterBackoff(min, max, multiple), nil
+	case BackoffStrategyConstant:
+		return NewConstantBackoff(min, jitterMultiple), nil
+	default:
+		return nil, fmt.Errorf("unknown backoff strategy: %q", strategy)
+	}
+}
+
 // AddJitter adds an amount of jitter between 0 and the given jitter to the
 // given duration
 func AddJitter(duration time.Duration, jitter time.Duration) time.Duration {
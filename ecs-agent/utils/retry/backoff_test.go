@@ -19,6 +19,9 @@ package retry
 import (
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestJitter(t *testing.T) {
@@ -29,3 +32,36 @@ func TestJitter(t *testing.T) {
 		}
 	}
 }
+
+func TestNewBackoff(t *testing.T) {
+	testCases := []struct {
+		strategy BackoffStrategy
+		expected Backoff
+	}{
+		{
+			strategy: BackoffStrategyExponential,
+			expected: &ExponentialBackoff{},
+		},
+		{
+			strategy: BackoffStrategyDecorrelatedJitter,
+			expected: &DecorrelatedJitterBackoff{},
+		},
+		{
+			strategy: BackoffStrategyConstant,
+			expected: &ConstantBackoff{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.strategy), func(t *testing.T) {
+			backoff, err := NewBackoff(tc.strategy, time.Second, time.Minute, 0.2, 2)
+			require.NoError(t, err)
+			assert.IsType(t, tc.expected, backoff)
+		})
+	}
+}
+
+func TestNewBackoffUnknownStrategy(t *testing.T) {
+	_, err := NewBackoff("linear", time.Second, time.Minute, 0.2, 2)
+	assert.Error(t, err)
+}
@@ -0,0 +1,39 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package retry
+
+import (
+	"time"
+)
+
+type ConstantBackoff struct {
+	delay          time.Duration
+	jitterMultiple float64
+}
+
+// NewConstantBackoff creates a Backoff which always waits for delay, plus a
+// random amount of jitter up to jitterMultiple percent of delay.
+func NewConstantBackoff(delay time.Duration, jitterMultiple float64) *ConstantBackoff {
+	return &ConstantBackoff{
+		delay:          delay,
+		jitterMultiple: jitterMultiple,
+	}
+}
+
+func (cb *ConstantBackoff) Duration() time.Duration {
+	return AddJitter(cb.delay, time.Duration(int64(float64(cb.delay)*cb.jitterMultiple)))
+}
+
+// Reset is a no-op as the delay of a ConstantBackoff never changes.
+func (cb *ConstantBackoff) Reset() {}
@@ -0,0 +1,42 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package retry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstantBackoff(t *testing.T) {
+	cb := NewConstantBackoff(10*time.Second, 0)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, 10*time.Second, cb.Duration())
+	}
+	cb.Reset()
+	assert.Equal(t, 10*time.Second, cb.Duration())
+}
+
+func TestConstantBackoffJitter(t *testing.T) {
+	cb := NewConstantBackoff(10*time.Second, 0.5)
+	for i := 0; i < 100; i++ {
+		duration := cb.Duration()
+		assert.GreaterOrEqual(t, duration, 10*time.Second)
+		assert.Less(t, duration, 15*time.Second)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+type DecorrelatedJitterBackoff struct {
+	previous time.Duration
+	start    time.Duration
+	max      time.Duration
+	multiple float64
+	mu       sync.Mutex
+}
+
+// NewDecorrelatedJitterBackoff creates a Backoff whose delays are picked at
+// random between min and multiple times the previous delay, capped at max.
+// (t = min(max, random(min, t' * multiple)) where t' is the previous delay and
+// starts at min). Unlike the ExponentialBackoff, the randomness carries over to
+// the following delays, which spreads out the retries of concurrent callers.
+func NewDecorrelatedJitterBackoff(min, max time.Duration, multiple float64) *DecorrelatedJitterBackoff {
+	return &DecorrelatedJitterBackoff{
+		start:    min,
+		previous: min,
+		max:      max,
+		multiple: multiple,
+	}
+}
+
+func (db *DecorrelatedJitterBackoff) Duration() time.Duration {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	upper := math.Min(float64(db.max.Nanoseconds()), float64(db.previous.Nanoseconds())*db.multiple)
+	ret := db.start
+	if spread := int64(upper) - db.start.Nanoseconds(); spread > 0 {
+		ret += time.Duration(rand.Int63n(spread))
+	}
+	db.previous = ret
+	return ret
+}
+
+func (db *DecorrelatedJitterBackoff) Reset() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.previous = db.start
+}
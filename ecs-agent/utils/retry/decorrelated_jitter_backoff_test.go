@@ -0,0 +1,73 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package retry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	db := NewDecorrelatedJitterBackoff(time.Second, time.Minute, 3)
+
+	for i := 0; i < 2; i++ {
+		// The first delay is picked between min and multiple times min
+		duration := db.Duration()
+		assert.GreaterOrEqual(t, duration, time.Second)
+		assert.Less(t, duration, 3*time.Second)
+
+		// Each delay is picked between min and multiple times the previous
+		// one, and never exceeds max
+		previous := duration
+		for j := 0; j < 100; j++ {
+			duration = db.Duration()
+			assert.GreaterOrEqual(t, duration, time.Second)
+			assert.Less(t, duration, 3*previous)
+			assert.LessOrEqual(t, duration, time.Minute)
+			previous = duration
+		}
+		db.Reset()
+		// loop to redo the above tests after resetting, they should be the same
+	}
+}
+
+func TestDecorrelatedJitterBackoffSpread(t *testing.T) {
+	// Backoffs of concurrent callers retrying at the same time should not
+	// wait for the same delays
+	delays := make(map[time.Duration]struct{})
+	var total time.Duration
+	const samples = 1000
+	for i := 0; i < samples; i++ {
+		db := NewDecorrelatedJitterBackoff(time.Second, time.Minute, 3)
+		db.Duration()
+		duration := db.Duration()
+		delays[duration] = struct{}{}
+		total += duration
+	}
+	assert.Greater(t, len(delays), samples/2, "delays should be spread out")
+	// The delays grow on average
+	assert.Greater(t, total/samples, 2*time.Second)
+}
+
+func TestDecorrelatedJitterBackoffMinEqualsMax(t *testing.T) {
+	db := NewDecorrelatedJitterBackoff(time.Second, time.Second, 3)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, time.Second, db.Duration())
+	}
+}
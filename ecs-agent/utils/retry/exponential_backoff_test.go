@@ -45,3 +45,13 @@ func TestExponentialBackoff(t *testing.T) {
 		// loop to redo the above tests after resetting, they should be the same
 	}
 }
+
+func TestExponentialBackoffJitter(t *testing.T) {
+	sb := NewExponentialBackoff(10*time.Second, time.Minute, 0.5, 2)
+	for _, expected := range []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute} {
+		duration := sb.Duration()
+		if duration < expected || duration >= expected+expected/2 {
+			t.Error("Jitter out of range. Got ", duration, " expected ", expected)
+		}
+	}
+}
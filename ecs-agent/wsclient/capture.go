@@ -0,0 +1,180 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package wsclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger/field"
+)
+
+const (
+	// DefaultMessageCaptureMaxBytes is the default size at which a message capture file is rotated.
+	DefaultMessageCaptureMaxBytes = 10 * 1024 * 1024
+	// redactedValue replaces the values of credential fields in captured messages.
+	redactedValue = "REDACTED"
+)
+
+// redactedFields are the (case-insensitive) names of message fields whose values are
+// never written to a message capture file.
+var redactedFields = map[string]struct{}{
+	"accesskeyid":     {},
+	"secretaccesskey": {},
+	"sessiontoken":    {},
+}
+
+// MessageCapture writes the raw messages read from a websocket connection, with their
+// credentials redacted, to a local file for debugging. The file is rotated once it
+// would grow past its maximum size, keeping a single backup with the ".1" suffix.
+type MessageCapture struct {
+	path     string
+	maxBytes int64
+	lock     sync.Mutex
+	// file is the open capture file. It's nil after a failed rotation, and is reopened
+	// when the next message is captured
+	file *os.File
+	size int64
+}
+
+// NewMessageCapture returns a new MessageCapture object that writes messages to the file at path.
+func NewMessageCapture(path string, maxBytes int64) (*MessageCapture, error) {
+	capture := &MessageCapture{
+		path:     path,
+		maxBytes: maxBytes,
+	}
+	if err := capture.open(); err != nil {
+		return nil, err
+	}
+	return capture, nil
+}
+
+// Capture redacts the credentials in a message and appends it to the capture file, one
+// message per line. It is meant to be used as the ReadMessageHook of a ClientServerImpl.
+// Messages that can't be redacted are not captured.
+func (c *MessageCapture) Capture(message []byte) {
+	redacted, err := RedactCredentials(message)
+	if err != nil {
+		logger.Warn("Unable to redact message, not capturing it", logger.Fields{
+			field.Error: err,
+		})
+		return
+	}
+	redacted = append(redacted, '\n')
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.file == nil {
+		if err := c.open(); err != nil {
+			logger.Warn("Unable to reopen message capture file", logger.Fields{
+				"path":      c.path,
+				field.Error: err,
+			})
+			return
+		}
+	}
+	if c.size > 0 && c.size+int64(len(redacted)) > c.maxBytes {
+		if err := c.rotate(); err != nil {
+			logger.Warn("Unable to rotate message capture file", logger.Fields{
+				"path":      c.path,
+				field.Error: err,
+			})
+			return
+		}
+	}
+	n, err := c.file.Write(redacted)
+	c.size += int64(n)
+	if err != nil {
+		logger.Warn("Unable to write message to capture file", logger.Fields{
+			"path":      c.path,
+			field.Error: err,
+		})
+	}
+}
+
+// Close closes the capture file.
+func (c *MessageCapture) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+func (c *MessageCapture) open() error {
+	file, err := os.OpenFile(c.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to open message capture file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("unable to stat message capture file: %w", err)
+	}
+	c.file = file
+	c.size = info.Size()
+	return nil
+}
+
+// rotate moves the capture file to its backup and opens a new one. The capture file is
+// left closed if rotating fails, so that it's reopened, and rotated again, when the next
+// message is captured.
+func (c *MessageCapture) rotate() error {
+	err := c.file.Close()
+	c.file = nil
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(c.path, c.path+".1"); err != nil {
+		return err
+	}
+	return c.open()
+}
+
+// RedactCredentials returns a copy of a JSON message in which the values of all
+// access key ID, secret access key and session token fields are redacted, at any depth.
+func RedactCredentials(message []byte) ([]byte, error) {
+	var decoded interface{}
+	if err := json.Unmarshal(message, &decoded); err != nil {
+		return nil, err
+	}
+	return json.Marshal(redact(decoded))
+}
+
+func redact(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, fieldValue := range typed {
+			if _, ok := redactedFields[strings.ToLower(key)]; ok {
+				typed[key] = redactedValue
+				continue
+			}
+			typed[key] = redact(fieldValue)
+		}
+	case []interface{}:
+		for i, element := range typed {
+			typed[i] = redact(element)
+		}
+	}
+	return value
+}
@@ -0,0 +1,147 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package wsclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	mock_wsconn "github.com/aws/amazon-ecs-agent/ecs-agent/wsclient/wsconn/mock"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const payloadWithCredentials = `{"type":"PayloadMessage","message":{"messageId":"mid","tasks":[{"arn":"arn",` +
+	`"roleCredentials":{"credentialsId":"cid","accessKeyId":"AKIDSECRET","secretAccessKey":"SAKSECRET",` +
+	`"sessionToken":"TOKENSECRET"}}],"extra":[{"SessionToken":"OTHERSECRET"}]}}`
+
+// Tests that messages read from the connection are captured with their credentials redacted.
+func TestConsumeMessagesCapturesRedactedMessages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	capturePath := filepath.Join(t.TempDir(), "acs-capture.log")
+	capture, err := NewMessageCapture(capturePath, DefaultMessageCaptureMaxBytes)
+	require.NoError(t, err)
+	defer capture.Close()
+
+	readErr := errors.New("read error")
+	conn := mock_wsconn.NewMockWebsocketConn(ctrl)
+	conn.EXPECT().SetReadDeadline(gomock.Any()).Return(nil).Times(2)
+	gomock.InOrder(
+		conn.EXPECT().ReadMessage().Return(websocket.TextMessage, []byte(payloadWithCredentials), nil),
+		conn.EXPECT().ReadMessage().Return(0, nil, readErr),
+	)
+
+	cs := getTestClientServer("", []interface{}{ecsacs.PayloadMessage{}}, 1)
+	cs.conn = conn
+	cs.ReadMessageHook = capture.Capture
+	assert.Equal(t, readErr, cs.ConsumeMessages(context.Background()))
+
+	captured, err := os.ReadFile(capturePath)
+	require.NoError(t, err)
+	for _, secret := range []string{"AKIDSECRET", "SAKSECRET", "TOKENSECRET", "OTHERSECRET"} {
+		assert.NotContains(t, string(captured), secret)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(captured), "\n"), "\n")
+	require.Len(t, lines, 1)
+
+	var message struct {
+		Type    string
+		Message ecsacs.PayloadMessage
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &message))
+	assert.Equal(t, "PayloadMessage", message.Type)
+	assert.Equal(t, "mid", *message.Message.MessageId)
+	require.Len(t, message.Message.Tasks, 1)
+	creds := message.Message.Tasks[0].RoleCredentials
+	assert.Equal(t, "cid", *creds.CredentialsId)
+	assert.Equal(t, redactedValue, *creds.AccessKeyId)
+	assert.Equal(t, redactedValue, *creds.SecretAccessKey)
+	assert.Equal(t, redactedValue, *creds.SessionToken)
+}
+
+// Tests that the capture file is rotated instead of growing past its maximum size.
+func TestMessageCaptureRotation(t *testing.T) {
+	capturePath := filepath.Join(t.TempDir(), "acs-capture.log")
+	message := []byte(`{"message":{"healthy":true},"type":"HeartbeatMessage"}`)
+	capture, err := NewMessageCapture(capturePath, int64(2*(len(message)+1)))
+	require.NoError(t, err)
+	defer capture.Close()
+
+	for i := 0; i < 3; i++ {
+		capture.Capture(message)
+	}
+
+	current, err := os.ReadFile(capturePath)
+	require.NoError(t, err)
+	assert.Equal(t, string(message)+"\n", string(current))
+	backup, err := os.ReadFile(capturePath + ".1")
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat(string(message)+"\n", 2), string(backup))
+}
+
+// Tests that capturing messages resumes, and the capture file is rotated, once a failed
+// rotation can succeed.
+func TestMessageCaptureRotationFailure(t *testing.T) {
+	capturePath := filepath.Join(t.TempDir(), "acs-capture.log")
+	message := []byte(`{"message":{"healthy":true},"type":"HeartbeatMessage"}`)
+	capture, err := NewMessageCapture(capturePath, int64(len(message)+1))
+	require.NoError(t, err)
+	defer capture.Close()
+
+	// A non-empty directory at the backup path makes renaming the capture file fail
+	backupPath := capturePath + ".1"
+	require.NoError(t, os.MkdirAll(filepath.Join(backupPath, "blocker"), 0700))
+
+	capture.Capture(message)
+	capture.Capture(message)
+	current, err := os.ReadFile(capturePath)
+	require.NoError(t, err)
+	assert.Equal(t, string(message)+"\n", string(current), "expected the message not to be captured")
+
+	require.NoError(t, os.RemoveAll(backupPath))
+	capture.Capture(message)
+	current, err = os.ReadFile(capturePath)
+	require.NoError(t, err)
+	assert.Equal(t, string(message)+"\n", string(current))
+	backup, err := os.ReadFile(backupPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(message)+"\n", string(backup))
+}
+
+// Tests that messages that are not valid JSON, and so can't be redacted, are not captured.
+func TestMessageCaptureSkipsInvalidMessages(t *testing.T) {
+	capturePath := filepath.Join(t.TempDir(), "acs-capture.log")
+	capture, err := NewMessageCapture(capturePath, DefaultMessageCaptureMaxBytes)
+	require.NoError(t, err)
+	defer capture.Close()
+
+	capture.Capture([]byte(`{"secretAccessKey":"SAKSECRET"`))
+
+	captured, err := os.ReadFile(capturePath)
+	require.NoError(t, err)
+	assert.Empty(t, captured)
+}
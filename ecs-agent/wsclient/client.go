@@ -21,6 +21,7 @@ package wsclient
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -34,6 +35,7 @@ import (
 	"time"
 
 	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/utils"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/utils/cipher"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/utils/httpproxy"
@@ -62,6 +64,9 @@ const (
 	// writeBufSize is the size of the write buffer for the ws connection.
 	writeBufSize = 32768
 
+	// minBufSize is the smallest read or write buffer size that can be configured for the ws connection.
+	minBufSize = 1024
+
 	// Default NO_PROXY env var IP addresses
 	defaultNoProxyIP = "169.254.169.254,169.254.170.2"
 
@@ -70,6 +75,19 @@ const (
 	// ExitTerminal indicates the agent run into error that's not recoverable
 	// no need to restart
 	ExitTerminal = 5
+
+	// connectOutcomeField is the metric field used to label the outcome of Connect.
+	connectOutcomeField = "outcome"
+)
+
+// Outcomes of a call to Connect, reported as the connectOutcomeField of the
+// metrics.WSClientConnectMetricName metric.
+const (
+	ConnectOutcomeSuccess    = "success"
+	ConnectOutcomeEOF        = "eof"
+	ConnectOutcomeTLSError   = "tls-error"
+	ConnectOutcomeProxyError = "proxy-error"
+	ConnectOutcomeOther      = "other"
 )
 
 // ReceivedMessage is the intermediate message used to unmarshal a
@@ -156,6 +174,12 @@ type WSClientMinAgentConfig struct {
 	AcceptInsecureCert bool
 	DockerEndpoint     string
 	IsDocker           bool
+	// ExpectedServerName, if set, pins the name that the server certificate must
+	// be valid for. It is enforced even when AcceptInsecureCert is set.
+	ExpectedServerName string
+	// TLSSessionResumption enables resuming TLS sessions when reconnecting to a
+	// server that supports it.
+	TLSSessionResumption bool
 }
 
 // ClientServerImpl wraps commonly used methods defined in ClientServer interface.
@@ -164,6 +188,9 @@ type ClientServerImpl struct {
 	Cfg *WSClientMinAgentConfig
 	// conn holds the underlying low-level websocket connection
 	conn wsconn.WebsocketConn
+	// handshakeHeader holds the headers of the server's response to the
+	// websocket handshake of conn
+	handshakeHeader http.Header
 	// CredentialProvider is used to retrieve AWS credentials
 	CredentialProvider *credentials.Credentials
 	// RequestHandlers is a map from message types to handler functions of the
@@ -174,14 +201,45 @@ type ClientServerImpl struct {
 	// message with said message. It will be called before a RequestHandler is
 	// called. It must take a single interface{} argument.
 	AnyRequestHandler RequestHandler
+	// RegisteredRequestTypes, if set, is the set of type-strings of the requests
+	// that this client may send. MakeRequest then returns an error instead of
+	// sending a request of any other type, even if the type is recognized by the
+	// TypeDecoder. Requests are not validated when it is nil.
+	RegisteredRequestTypes map[string]struct{}
 	// MakeRequestHook is an optional callback that, if set, is called on every
 	// generated request with the raw request body.
 	MakeRequestHook MakeRequestHookFunc
+	// ReadMessageHook is an optional callback that, if set, is called on every
+	// message read from the connection with the raw message, before the message
+	// is handled.
+	ReadMessageHook ReadMessageHookFunc
 	// URL is the full url to the backend, including path, querystring, and so on.
 	URL string
 	// RWTimeout is the duration used for setting read and write deadlines
 	// for the websocket connection
 	RWTimeout time.Duration
+	// MetricsFactory, if set, is used to emit a metric labeled with the
+	// outcome of every call to Connect, and the dispatch queue metrics.
+	MetricsFactory metrics.EntryFactory
+	// DispatchQueueSize, if positive, is the number of messages read from the
+	// connection that can wait to be dispatched to their handlers, which is then
+	// done by a separate goroutine. Otherwise messages are dispatched as they are
+	// read, and reading waits for each message to be handled.
+	DispatchQueueSize int
+	// DispatchQueueFullTimeout is how long reading waits for room in a full
+	// dispatch queue before dropping the message that was read.
+	DispatchQueueFullTimeout time.Duration
+	// TLSSessionCache, if set, caches the TLS sessions established by Connect
+	// so that they can be resumed when reconnecting. It should be shared by
+	// the clients connecting to the same backend, which is done by the client
+	// factories when TLSSessionResumption is enabled in Cfg.
+	TLSSessionCache tls.ClientSessionCache
+	// ReadBufferSize and WriteBufferSize, if positive, are the sizes in bytes of
+	// the buffers of the websocket connection. A larger read buffer reduces the
+	// number of reads of large messages. They default to readBufSize and
+	// writeBufSize, and must be at least minBufSize.
+	ReadBufferSize  int
+	WriteBufferSize int
 	// writeLock needed to ensure that only one routine is writing to the socket
 	writeLock sync.RWMutex
 	ClientServer
@@ -194,10 +252,25 @@ type ClientServerImpl struct {
 // to send or an error.
 type MakeRequestHookFunc func([]byte) ([]byte, error)
 
+// ReadMessageHookFunc is a function that is invoked on every message read from
+// the connection with the raw message. It must not modify the message.
+type ReadMessageHookFunc func([]byte)
+
 // Connect opens a connection to the backend and upgrades it to a websocket. Calls to
 // 'MakeRequest' can be made after calling this, but responses will not be
 // receivable until 'Serve' is also called.
 func (cs *ClientServerImpl) Connect() error {
+	err := cs.connect()
+	if cs.MetricsFactory != nil {
+		cs.MetricsFactory.New(metrics.WSClientConnectMetricName).
+			WithFields(map[string]interface{}{connectOutcomeField: connectOutcome(err)}).
+			WithCount(1).
+			Done(err)()
+	}
+	return err
+}
+
+func (cs *ClientServerImpl) connect() error {
 	logger.Info("Establishing a Websocket connection", logger.Fields{
 		"url": cs.URL,
 	})
@@ -224,6 +297,13 @@ func (cs *ClientServerImpl) Connect() error {
 
 	timeoutDialer := &net.Dialer{Timeout: wsConnectTimeout}
 	tlsConfig := &tls.Config{ServerName: parsedURL.Host, InsecureSkipVerify: cs.Cfg.AcceptInsecureCert, MinVersion: tls.VersionTLS12}
+	if cs.Cfg.ExpectedServerName != "" {
+		tlsConfig.ServerName = cs.Cfg.ExpectedServerName
+		// InsecureSkipVerify skips hostname verification as well, so verify the
+		// peer certificate against the expected name explicitly
+		tlsConfig.VerifyConnection = verifyServerName(cs.Cfg.ExpectedServerName)
+	}
+	tlsConfig.ClientSessionCache = cs.TLSSessionCache
 
 	//TODO: In order to get rid of the check -
 	// 1. Remove the hardcoded cipher suites, and rely on default by tls package
@@ -246,14 +326,18 @@ func (cs *ClientServerImpl) Connect() error {
 		}
 	}
 
-	dialer := websocket.Dialer{
-		ReadBufferSize:   readBufSize,
-		WriteBufferSize:  writeBufSize,
-		TLSClientConfig:  tlsConfig,
-		Proxy:            httpproxy.Proxy,
-		NetDial:          timeoutDialer.Dial,
-		HandshakeTimeout: wsHandshakeTimeout,
+	// Remember whether the connection goes through a proxy so that dial
+	// failures can be attributed to it
+	usingProxy := false
+	dialer, err := cs.newDialer(tlsConfig, func(req *http.Request) (*url.URL, error) {
+		proxyURL, err := httpproxy.Proxy(req)
+		usingProxy = proxyURL != nil
+		return proxyURL, err
+	})
+	if err != nil {
+		return err
 	}
+	dialer.NetDial = timeoutDialer.Dial
 
 	websocketConn, httpResponse, err := dialer.Dial(parsedURL.String(), request.Header)
 	if httpResponse != nil {
@@ -276,6 +360,7 @@ func (cs *ClientServerImpl) Connect() error {
 			}
 		}
 		logger.Warn(fmt.Sprintf("Error creating a websocket client: %v", err))
+		err = classifyDialError(err, usingProxy)
 		return errors.Wrapf(err, "websocket client: unable to dial %s response: %s",
 			parsedURL.Host, string(resp))
 	}
@@ -284,10 +369,20 @@ func (cs *ClientServerImpl) Connect() error {
 	defer cs.writeLock.Unlock()
 
 	cs.conn = websocketConn
+	cs.handshakeHeader = httpResponse.Header
 	logger.Debug(fmt.Sprintf("Established a Websocket connection to %s", cs.URL))
 	return nil
 }
 
+// HandshakeHeader returns the headers of the server's response to the websocket
+// handshake of the current connection. It is nil until a connection is established.
+func (cs *ClientServerImpl) HandshakeHeader() http.Header {
+	cs.writeLock.RLock()
+	defer cs.writeLock.RUnlock()
+
+	return cs.handshakeHeader
+}
+
 // IsReady gives a boolean response that informs the caller if the websocket
 // connection is fully established.
 func (cs *ClientServerImpl) IsReady() bool {
@@ -398,6 +493,9 @@ func (cs *ClientServerImpl) SetAnyRequestHandler(f RequestHandler) {
 // MakeRequest makes a request using the given input. Note, the input *MUST* be
 // a pointer to a valid backend type that this client recognises
 func (cs *ClientServerImpl) MakeRequest(input interface{}) error {
+	if err := cs.validateRequestType(input); err != nil {
+		return err
+	}
 	send, err := cs.CreateRequestMessage(input)
 	if err != nil {
 		return err
@@ -415,6 +513,23 @@ func (cs *ClientServerImpl) MakeRequest(input interface{}) error {
 	return cs.WriteMessage(send)
 }
 
+// validateRequestType returns an error if the input is not a pointer to one of
+// the registered request types. Any input is valid when no request types are
+// registered.
+func (cs *ClientServerImpl) validateRequestType(input interface{}) error {
+	if cs.RegisteredRequestTypes == nil {
+		return nil
+	}
+	inputType := reflect.TypeOf(input)
+	if inputType == nil || inputType.Kind() != reflect.Ptr {
+		return &UnregisteredWSRequestType{fmt.Sprintf("%T", input)}
+	}
+	if _, ok := cs.RegisteredRequestTypes[inputType.Elem().Name()]; !ok {
+		return &UnregisteredWSRequestType{inputType.String()}
+	}
+	return nil
+}
+
 // WriteMessage wraps the low level websocket write method with a lock
 func (cs *ClientServerImpl) WriteMessage(send []byte) error {
 	cs.writeLock.Lock()
@@ -452,6 +567,18 @@ func (cs *ClientServerImpl) WriteCloseMessage() error {
 // ConsumeMessages reads messages from the websocket connection and handles read
 // messages from an active connection.
 func (cs *ClientServerImpl) ConsumeMessages(ctx context.Context) error {
+	dispatch := cs.handleMessage
+	if cs.DispatchQueueSize > 0 {
+		queue := newDispatchQueue(cs.DispatchQueueSize, cs.DispatchQueueFullTimeout, cs.handleMessage,
+			cs.MetricsFactory)
+		queue.start()
+		// The queue is stopped only once messages are no longer being read
+		defer queue.stop()
+		dispatch = func(message []byte) {
+			queue.enqueue(message)
+		}
+	}
+
 	// Since ReadMessage is blocking, we don't want to wait for timeout when context gets cancelled
 	errChan := make(chan error, 1)
 	go func() {
@@ -469,7 +596,10 @@ func (cs *ClientServerImpl) ConsumeMessages(ctx context.Context) error {
 					logger.Error(fmt.Sprintf("Unexpected messageType: %v", messageType))
 				}
 
-				cs.handleMessage(message)
+				if cs.ReadMessageHook != nil {
+					cs.ReadMessageHook(message)
+				}
+				dispatch(message)
 
 			case permissibleCloseCode(err):
 				logger.Debug(fmt.Sprintf("Connection closed for a valid reason: %s", err))
@@ -550,6 +680,99 @@ func (cs *ClientServerImpl) handleMessage(data []byte) {
 	}
 }
 
+// newDialer returns the websocket dialer used by Connect, with the configured buffer sizes
+// or their defaults. An error is returned if a configured buffer size is too small.
+func (cs *ClientServerImpl) newDialer(tlsConfig *tls.Config,
+	proxy func(*http.Request) (*url.URL, error)) (*websocket.Dialer, error) {
+	readBufferSize, err := bufferSize("read", cs.ReadBufferSize, readBufSize)
+	if err != nil {
+		return nil, err
+	}
+	writeBufferSize, err := bufferSize("write", cs.WriteBufferSize, writeBufSize)
+	if err != nil {
+		return nil, err
+	}
+	return &websocket.Dialer{
+		ReadBufferSize:   readBufferSize,
+		WriteBufferSize:  writeBufferSize,
+		TLSClientConfig:  tlsConfig,
+		Proxy:            proxy,
+		HandshakeTimeout: wsHandshakeTimeout,
+	}, nil
+}
+
+// bufferSize returns the configured size of a websocket connection buffer, or the default
+// size if none is configured.
+func bufferSize(name string, configured, defaultSize int) (int, error) {
+	if configured <= 0 {
+		return defaultSize, nil
+	}
+	if configured < minBufSize {
+		return 0, fmt.Errorf("websocket %s buffer size %d is smaller than the minimum of %d bytes",
+			name, configured, minBufSize)
+	}
+	return configured, nil
+}
+
+// verifyServerName returns a TLS connection verification callback that fails the
+// handshake if the server certificate is not valid for the expected server name.
+func verifyServerName(expectedServerName string) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return fmt.Errorf("websocket client: no server certificate presented, expected server name %s",
+				expectedServerName)
+		}
+		if err := state.PeerCertificates[0].VerifyHostname(expectedServerName); err != nil {
+			return errors.Wrapf(err, "websocket client: server certificate does not match expected server name %s",
+				expectedServerName)
+		}
+		return nil
+	}
+}
+
+// classifyDialError wraps errors returned when dialing the backend in the
+// TLSError or ProxyError types when they can be attributed to the TLS
+// handshake or to the configured proxy.
+func classifyDialError(err error, usingProxy bool) error {
+	var recordHeaderErr tls.RecordHeaderError
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certInvalidErr x509.CertificateInvalidError
+	switch {
+	case errors.As(err, &recordHeaderErr),
+		errors.As(err, &unknownAuthorityErr),
+		errors.As(err, &hostnameErr),
+		errors.As(err, &certInvalidErr):
+		return &TLSError{Err: err}
+	case usingProxy && !isEOF(err) && !errors.Is(err, websocket.ErrBadHandshake):
+		// Without a handshake response, a failure to dial through a proxy happened
+		// either while connecting to the proxy or while tunnelling through it.
+		return &ProxyError{Err: err}
+	}
+	return err
+}
+
+// connectOutcome returns the outcome label reported for the error returned by Connect.
+func connectOutcome(err error) string {
+	var tlsErr *TLSError
+	var proxyErr *ProxyError
+	switch {
+	case err == nil:
+		return ConnectOutcomeSuccess
+	case errors.As(err, &tlsErr):
+		return ConnectOutcomeTLSError
+	case errors.As(err, &proxyErr):
+		return ConnectOutcomeProxyError
+	case isEOF(err):
+		return ConnectOutcomeEOF
+	}
+	return ConnectOutcomeOther
+}
+
+func isEOF(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
 func websocketScheme(httpScheme string) (string, error) {
 	// gorilla/websocket expects the websocket scheme (ws[s]://)
 	var wsScheme string
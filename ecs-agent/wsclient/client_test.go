@@ -18,9 +18,12 @@ package wsclient
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"io"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"strings"
@@ -29,6 +32,8 @@ import (
 	"time"
 
 	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
+	mock_metrics "github.com/aws/amazon-ecs-agent/ecs-agent/metrics/mocks"
 	"github.com/aws/amazon-ecs-agent/ecs-agent/wsclient/mock/utils"
 	mock_wsconn "github.com/aws/amazon-ecs-agent/ecs-agent/wsclient/wsconn/mock"
 	"github.com/golang/mock/gomock"
@@ -104,6 +109,163 @@ func TestConcurrentWritesDontPanic(t *testing.T) {
 	waitForRequests.Wait()
 }
 
+// TestConnectExpectedServerName tests that the handshake fails when the server
+// certificate doesn't match the expected server name, even when insecure
+// certificates are accepted.
+func TestConnectExpectedServerName(t *testing.T) {
+	testCases := []struct {
+		name               string
+		expectedServerName string
+		expectErr          bool
+	}{
+		{
+			name:               "matching server name",
+			expectedServerName: "example.com",
+			expectErr:          false,
+		},
+		{
+			name:               "mismatched server name",
+			expectedServerName: "ecs-a-1.us-west-2.amazonaws.com",
+			expectErr:          true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			closeWS := make(chan []byte)
+			defer close(closeWS)
+
+			// The test server certificate is valid for example.com and 127.0.0.1
+			mockServer, _, _, _, _ := utils.GetMockServer(closeWS)
+			mockServer.StartTLS()
+			defer mockServer.Close()
+
+			types := []interface{}{ecsacs.AckRequest{}}
+			cs := getTestClientServer(mockServer.URL, types, 1)
+			cs.Cfg.ExpectedServerName = tc.expectedServerName
+			err := cs.Connect()
+			if tc.expectErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedServerName)
+				return
+			}
+			require.NoError(t, err)
+			cs.Disconnect()
+		})
+	}
+}
+
+// TestConnectOutcomeMetric tests that every call to Connect emits a metric
+// labeled with the outcome of the call.
+func TestConnectOutcomeMetric(t *testing.T) {
+	closeWS := make(chan []byte)
+	defer close(closeWS)
+
+	// The test server certificate is valid for example.com and 127.0.0.1
+	mockServer, _, _, _, _ := utils.GetMockServer(closeWS)
+	mockServer.StartTLS()
+	defer mockServer.Close()
+
+	// eofListener closes connections as soon as the client has sent its
+	// first message. Reading it first makes sure the connection is closed
+	// cleanly instead of being reset
+	eofListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer eofListener.Close()
+	go func() {
+		for {
+			conn, err := eofListener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Read(make([]byte, 4096))
+			conn.Close()
+		}
+	}()
+
+	// closedListener is closed so that connections to it are refused
+	closedListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedAddr := closedListener.Addr().String()
+	closedListener.Close()
+
+	testCases := []struct {
+		name            string
+		url             string
+		acceptInsecure  bool
+		proxy           string
+		expectedOutcome string
+	}{
+		{
+			name:            "success",
+			url:             mockServer.URL,
+			acceptInsecure:  true,
+			expectedOutcome: ConnectOutcomeSuccess,
+		},
+		{
+			name:            "eof",
+			url:             "https://" + eofListener.Addr().String(),
+			acceptInsecure:  true,
+			expectedOutcome: ConnectOutcomeEOF,
+		},
+		{
+			name:            "untrusted certificate",
+			url:             mockServer.URL,
+			acceptInsecure:  false,
+			expectedOutcome: ConnectOutcomeTLSError,
+		},
+		{
+			name:            "unreachable proxy",
+			url:             "http://www.amazon.com",
+			acceptInsecure:  true,
+			proxy:           closedAddr,
+			expectedOutcome: ConnectOutcomeProxyError,
+		},
+		{
+			name:            "connection refused",
+			url:             "https://" + closedAddr,
+			acceptInsecure:  true,
+			expectedOutcome: ConnectOutcomeOther,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.proxy != "" {
+				os.Setenv("HTTP_PROXY", tc.proxy)
+				defer os.Unsetenv("HTTP_PROXY")
+			}
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			metricsFactory := mock_metrics.NewMockEntryFactory(ctrl)
+			entry := mock_metrics.NewMockEntry(ctrl)
+			var connectErr error
+			gomock.InOrder(
+				metricsFactory.EXPECT().New(metrics.WSClientConnectMetricName).Return(entry),
+				entry.EXPECT().WithFields(map[string]interface{}{connectOutcomeField: tc.expectedOutcome}).Return(entry),
+				entry.EXPECT().WithCount(1).Return(entry),
+				entry.EXPECT().Done(gomock.Any()).Do(func(err error) {
+					connectErr = err
+				}).Return(func() {}),
+			)
+
+			types := []interface{}{ecsacs.AckRequest{}}
+			cs := getTestClientServer(tc.url, types, 1)
+			cs.Cfg.AcceptInsecureCert = tc.acceptInsecure
+			cs.MetricsFactory = metricsFactory
+			err := cs.Connect()
+			assert.Equal(t, err, connectErr)
+			if tc.expectedOutcome == ConnectOutcomeSuccess {
+				require.NoError(t, err)
+				cs.Disconnect()
+				return
+			}
+			assert.Error(t, err)
+		})
+	}
+}
+
 func getTestClientServer(url string, msgType []interface{}, rwTimeout time.Duration) *ClientServerImpl {
 	testCreds := credentials.NewStaticCredentials("test-id", "test-secret", "test-token")
 
@@ -122,6 +284,161 @@ func getTestClientServer(url string, msgType []interface{}, rwTimeout time.Durat
 	}
 }
 
+// TestHandshakeHeader tests that the headers of the server's handshake response
+// are recorded when connecting.
+func TestHandshakeHeader(t *testing.T) {
+	upgrader := websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
+	mockServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, http.Header{"X-Test-Header": []string{"test-value"}})
+		if err == nil {
+			ws.Close()
+		}
+	}))
+	mockServer.StartTLS()
+	defer mockServer.Close()
+
+	cs := getTestClientServer(mockServer.URL, []interface{}{ecsacs.AckRequest{}}, 1)
+	assert.Nil(t, cs.HandshakeHeader())
+	require.NoError(t, cs.Connect())
+	defer cs.Close()
+
+	assert.Equal(t, "test-value", cs.HandshakeHeader().Get("X-Test-Header"))
+}
+
+// recordingSessionCache is a TLS client session cache that records the
+// number of sessions put in and found in the wrapped cache
+type recordingSessionCache struct {
+	tls.ClientSessionCache
+	puts int
+	hits int
+	lock sync.Mutex
+}
+
+func (c *recordingSessionCache) Get(sessionKey string) (*tls.ClientSessionState, bool) {
+	session, ok := c.ClientSessionCache.Get(sessionKey)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if ok {
+		c.hits++
+	}
+	return session, ok
+}
+
+func (c *recordingSessionCache) Put(sessionKey string, cs *tls.ClientSessionState) {
+	c.ClientSessionCache.Put(sessionKey, cs)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if cs != nil {
+		c.puts++
+	}
+}
+
+func (c *recordingSessionCache) counts() (int, int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.puts, c.hits
+}
+
+// TestConnectTLSSessionResumption tests that the TLS session established by the
+// first connection is cached and resumed when reconnecting to the same server
+// with the same session cache.
+func TestConnectTLSSessionResumption(t *testing.T) {
+	upgrader := websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
+	resumed := make(chan bool, 3)
+	mockServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resumed <- r.TLS.DidResume
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err == nil {
+			ws.Close()
+		}
+	}))
+	mockServer.StartTLS()
+	defer mockServer.Close()
+
+	sessionCache := &recordingSessionCache{ClientSessionCache: tls.NewLRUClientSessionCache(0)}
+	connect := func(sessionCache tls.ClientSessionCache) bool {
+		cs := getTestClientServer(mockServer.URL, []interface{}{ecsacs.AckRequest{}}, 1)
+		cs.TLSSessionCache = sessionCache
+		require.NoError(t, cs.Connect())
+		defer cs.Close()
+		return <-resumed
+	}
+
+	assert.False(t, connect(sessionCache), "first connection should do a full handshake")
+	puts, hits := sessionCache.counts()
+	assert.Positive(t, puts, "session cache should be populated after the first handshake")
+	assert.Zero(t, hits)
+
+	assert.True(t, connect(sessionCache), "reconnecting should resume the cached session")
+	_, hits = sessionCache.counts()
+	assert.Positive(t, hits, "cached session should be reused on reconnect")
+
+	assert.False(t, connect(nil), "sessions should not be resumed without a session cache")
+}
+
+// TestNewDialerBufferSizes tests that the configured buffer sizes of the
+// websocket connection reach the dialer, and that too small sizes are rejected.
+func TestNewDialerBufferSizes(t *testing.T) {
+	testCases := []struct {
+		name                    string
+		readBufferSize          int
+		writeBufferSize         int
+		expectedReadBufferSize  int
+		expectedWriteBufferSize int
+		expectError             bool
+	}{
+		{
+			name:                    "defaults",
+			expectedReadBufferSize:  readBufSize,
+			expectedWriteBufferSize: writeBufSize,
+		},
+		{
+			name:                    "configured sizes",
+			readBufferSize:          65536,
+			writeBufferSize:         8192,
+			expectedReadBufferSize:  65536,
+			expectedWriteBufferSize: 8192,
+		},
+		{
+			name:                    "minimum sizes",
+			readBufferSize:          minBufSize,
+			writeBufferSize:         minBufSize,
+			expectedReadBufferSize:  minBufSize,
+			expectedWriteBufferSize: minBufSize,
+		},
+		{
+			name:           "read buffer too small",
+			readBufferSize: minBufSize - 1,
+			expectError:    true,
+		},
+		{
+			name:            "write buffer too small",
+			writeBufferSize: 512,
+			expectError:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := getTestClientServer("https://localhost:443", []interface{}{ecsacs.AckRequest{}}, 1)
+			cs.ReadBufferSize = tc.readBufferSize
+			cs.WriteBufferSize = tc.writeBufferSize
+
+			tlsConfig := &tls.Config{}
+			dialer, err := cs.newDialer(tlsConfig, nil)
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.Error(t, cs.Connect(), "Connect should fail with a too small buffer size")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedReadBufferSize, dialer.ReadBufferSize)
+			assert.Equal(t, tc.expectedWriteBufferSize, dialer.WriteBufferSize)
+			assert.Equal(t, tlsConfig, dialer.TLSClientConfig)
+		})
+	}
+}
+
 // TestProxyVariableCustomValue ensures that a user is able to override the
 // proxy variable by setting an environment variable.
 func TestProxyVariableCustomValue(t *testing.T) {
@@ -375,6 +692,41 @@ func TestMakeUnrecognizedRequest(t *testing.T) {
 	}
 }
 
+// TestMakeUnregisteredRequest tests that a recognized request type that is not
+// registered to be sent is rejected only when request types are registered.
+func TestMakeUnregisteredRequest(t *testing.T) {
+	req := &ecsacs.NackRequest{MessageId: aws.String("test")}
+	types := []interface{}{ecsacs.AckRequest{}, ecsacs.NackRequest{}}
+
+	t.Run("validation on", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		conn := mock_wsconn.NewMockWebsocketConn(ctrl)
+
+		cs := getTestClientServer("https://www.amazon.com", types, 1)
+		cs.conn = conn
+		cs.RegisteredRequestTypes = BuildRequestTypeSet([]interface{}{ecsacs.AckRequest{}})
+
+		err := cs.MakeRequest(req)
+		var unregistered *UnregisteredWSRequestType
+		require.ErrorAs(t, err, &unregistered)
+		assert.Equal(t, "*ecsacs.NackRequest", unregistered.Type)
+	})
+
+	t.Run("validation off", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		conn := mock_wsconn.NewMockWebsocketConn(ctrl)
+		conn.EXPECT().SetWriteDeadline(gomock.Any()).Return(nil)
+		conn.EXPECT().WriteMessage(websocket.TextMessage, gomock.Any()).Return(nil)
+
+		cs := getTestClientServer("https://www.amazon.com", types, 1)
+		cs.conn = conn
+
+		assert.NoError(t, cs.MakeRequest(req))
+	})
+}
+
 // TestWriteCloseMessage tests if the wsclient can successfully close the connection
 // and write close message. The close message is expected to be received on server side.
 func TestWriteCloseMessage(t *testing.T) {
@@ -0,0 +1,113 @@
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package wsclient
+
+import (
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/logger"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
+)
+
+// dispatchQueue is a bounded queue of messages read from a websocket connection that
+// are waiting to be dispatched to their handlers. It decouples reading messages from
+// handling them, so that slow handlers show up as a growing queue depth rather than as
+// slower reads. When the queue is full, enqueuing a message waits for room for up to
+// fullTimeout, after which the message is dropped.
+type dispatchQueue struct {
+	messages       chan []byte
+	fullTimeout    time.Duration
+	dispatch       func([]byte)
+	metricsFactory metrics.EntryFactory
+	done           chan struct{}
+	stopped        chan struct{}
+}
+
+// newDispatchQueue returns a new dispatchQueue object that holds up to size messages and
+// dispatches them with the dispatch function.
+func newDispatchQueue(size int, fullTimeout time.Duration, dispatch func([]byte),
+	metricsFactory metrics.EntryFactory) *dispatchQueue {
+	if metricsFactory == nil {
+		metricsFactory = metrics.NewNopEntryFactory()
+	}
+	return &dispatchQueue{
+		messages:       make(chan []byte, size),
+		fullTimeout:    fullTimeout,
+		dispatch:       dispatch,
+		metricsFactory: metricsFactory,
+		done:           make(chan struct{}),
+		stopped:        make(chan struct{}),
+	}
+}
+
+// start dispatches queued messages, one at a time and in order, until the queue is stopped.
+func (q *dispatchQueue) start() {
+	go func() {
+		defer close(q.stopped)
+		for {
+			select {
+			case <-q.done:
+				if dropped := len(q.messages); dropped > 0 {
+					logger.Info("Dropping queued messages, the connection is done", logger.Fields{
+						"droppedMessages": dropped,
+					})
+				}
+				return
+			case message := <-q.messages:
+				q.dispatch(message)
+			}
+		}
+	}()
+}
+
+// stop stops dispatching messages, waiting for the message being dispatched, if any, to
+// be handled. Messages still in the queue are dropped.
+func (q *dispatchQueue) stop() {
+	close(q.done)
+	<-q.stopped
+}
+
+// enqueue adds a message to the queue, waiting for up to fullTimeout if the queue is full.
+// It returns false if the message was dropped because the queue stayed full.
+func (q *dispatchQueue) enqueue(message []byte) bool {
+	select {
+	case q.messages <- message:
+		q.reportDepth()
+		return true
+	default:
+	}
+
+	if q.fullTimeout > 0 {
+		timer := time.NewTimer(q.fullTimeout)
+		defer timer.Stop()
+		select {
+		case q.messages <- message:
+			q.reportDepth()
+			return true
+		case <-timer.C:
+		}
+	}
+
+	logger.Warn("Dropping message, the dispatch queue is full", logger.Fields{
+		"queueSize":   cap(q.messages),
+		"fullTimeout": q.fullTimeout.String(),
+	})
+	q.metricsFactory.New(metrics.WSClientDroppedMessagesMetricName).WithCount(1).Done(nil)()
+	return false
+}
+
+// reportDepth emits the number of messages waiting in the queue.
+func (q *dispatchQueue) reportDepth() {
+	q.metricsFactory.New(metrics.WSClientDispatchQueueDepthMetricName).WithGauge(len(q.messages)).Done(nil)()
+}
@@ -0,0 +1,154 @@
+//go:build unit
+// +build unit
+
+// Copyright Amazon.com Inc. or its affiliates. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"). You may
+// not use this file except in compliance with the License. A copy of the
+// License is located at
+//
+//	http://aws.amazon.com/apache2.0/
+//
+// or in the "license" file accompanying this file. This file is distributed
+// on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+// express or implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package wsclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/amazon-ecs-agent/ecs-agent/acs/model/ecsacs"
+	"github.com/aws/amazon-ecs-agent/ecs-agent/metrics"
+	mock_metrics "github.com/aws/amazon-ecs-agent/ecs-agent/metrics/mocks"
+	mock_wsconn "github.com/aws/amazon-ecs-agent/ecs-agent/wsclient/wsconn/mock"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dispatchQueueMetrics records the dispatch queue metrics emitted through a mock metrics factory.
+type dispatchQueueMetrics struct {
+	lock            sync.Mutex
+	depths          []interface{}
+	droppedMessages int
+}
+
+func newDispatchQueueMetricsFactory(ctrl *gomock.Controller, recorded *dispatchQueueMetrics) metrics.EntryFactory {
+	metricsFactory := mock_metrics.NewMockEntryFactory(ctrl)
+	metricsFactory.EXPECT().New(gomock.Any()).DoAndReturn(func(name string) metrics.Entry {
+		entry := mock_metrics.NewMockEntry(ctrl)
+		switch name {
+		case metrics.WSClientDispatchQueueDepthMetricName:
+			entry.EXPECT().WithGauge(gomock.Any()).DoAndReturn(func(value interface{}) metrics.Entry {
+				recorded.lock.Lock()
+				defer recorded.lock.Unlock()
+				recorded.depths = append(recorded.depths, value)
+				return entry
+			})
+		case metrics.WSClientDroppedMessagesMetricName:
+			entry.EXPECT().WithCount(1).DoAndReturn(func(int) metrics.Entry {
+				recorded.lock.Lock()
+				defer recorded.lock.Unlock()
+				recorded.droppedMessages++
+				return entry
+			})
+		}
+		entry.EXPECT().Done(nil).Return(func() {})
+		return entry
+	}).AnyTimes()
+	return metricsFactory
+}
+
+// Tests that a saturated dispatch queue reports its depth, drops messages once it has been
+// full for the timeout, and still dispatches the queued messages in order.
+func TestDispatchQueueSaturation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	recorded := &dispatchQueueMetrics{}
+	dispatched := make(chan string)
+	release := make(chan struct{})
+	fullTimeout := 20 * time.Millisecond
+	queue := newDispatchQueue(2, fullTimeout, func(message []byte) {
+		dispatched <- string(message)
+		<-release
+	}, newDispatchQueueMetricsFactory(ctrl, recorded))
+	queue.start()
+	defer queue.stop()
+
+	// The first message is taken off the queue and blocks the dispatcher
+	require.True(t, queue.enqueue([]byte("1")))
+	assert.Equal(t, "1", <-dispatched)
+
+	// The next two messages fill the queue, and the one after that is dropped
+	require.True(t, queue.enqueue([]byte("2")))
+	require.True(t, queue.enqueue([]byte("3")))
+	start := time.Now()
+	assert.False(t, queue.enqueue([]byte("4")), "message should be dropped when the queue is full")
+	assert.GreaterOrEqual(t, time.Since(start), fullTimeout, "enqueue should wait for room before dropping")
+
+	recorded.lock.Lock()
+	require.Len(t, recorded.depths, 3)
+	assert.Equal(t, []interface{}{1, 2}, recorded.depths[1:])
+	assert.Equal(t, 1, recorded.droppedMessages)
+	recorded.lock.Unlock()
+
+	close(release)
+	assert.Equal(t, "2", <-dispatched)
+	assert.Equal(t, "3", <-dispatched)
+}
+
+// Tests that a full dispatch queue drops messages right away when there is no timeout.
+func TestDispatchQueueFullNoTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	recorded := &dispatchQueueMetrics{}
+	// The queue isn't started, so nothing is taken off it
+	queue := newDispatchQueue(1, 0, func([]byte) {}, newDispatchQueueMetricsFactory(ctrl, recorded))
+
+	assert.True(t, queue.enqueue([]byte("1")))
+	assert.False(t, queue.enqueue([]byte("2")))
+	assert.Equal(t, []interface{}{1}, recorded.depths)
+	assert.Equal(t, 1, recorded.droppedMessages)
+}
+
+// Tests that messages read from the connection are dispatched to their handlers through
+// the dispatch queue when one is configured.
+func TestConsumeMessagesWithDispatchQueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handled := make(chan *ecsacs.PayloadMessage, 1)
+	readErr := errors.New("read error")
+	conn := mock_wsconn.NewMockWebsocketConn(ctrl)
+	conn.EXPECT().SetReadDeadline(gomock.Any()).Return(nil).Times(2)
+	gomock.InOrder(
+		conn.EXPECT().ReadMessage().Return(websocket.TextMessage,
+			[]byte(`{"type":"PayloadMessage","message":{"tasks":[{"arn":"arn"}]}}`), nil),
+		// Reading fails only once the first message has been handled
+		conn.EXPECT().ReadMessage().DoAndReturn(func() (int, []byte, error) {
+			payload := <-handled
+			assert.Equal(t, "arn", aws.StringValue(payload.Tasks[0].Arn))
+			return 0, nil, readErr
+		}),
+	)
+
+	cs := getTestClientServer("", []interface{}{ecsacs.PayloadMessage{}}, 1)
+	cs.conn = conn
+	cs.DispatchQueueSize = 1
+	cs.MetricsFactory = newDispatchQueueMetricsFactory(ctrl, &dispatchQueueMetrics{})
+	cs.AddRequestHandler(func(payload *ecsacs.PayloadMessage) {
+		handled <- payload
+	})
+
+	assert.Equal(t, readErr, cs.ConsumeMessages(context.Background()))
+}
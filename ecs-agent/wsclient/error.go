@@ -31,6 +31,23 @@ func (u *UnrecognizedWSRequestType) Retry() bool {
 	return false
 }
 
+// UnregisteredWSRequestType specifies that a given request type is recognized but is
+// not among the request types registered to be sent by the client.
+// This error is not retriable.
+type UnregisteredWSRequestType struct {
+	Type string
+}
+
+// Error implements error
+func (u *UnregisteredWSRequestType) Error() string {
+	return "Request type is not registered to be sent: " + u.Type
+}
+
+// Retry implements Retriable
+func (u *UnregisteredWSRequestType) Retry() bool {
+	return false
+}
+
 // NotMarshallableWSRequest represents that the given request input could not be
 // marshalled
 type NotMarshallableWSRequest struct {
@@ -118,3 +135,34 @@ func (err *WSError) Retry() bool {
 	}
 	return true
 }
+
+// TLSError indicates that the TLS handshake with the backend failed.
+type TLSError struct {
+	Err error
+}
+
+// Error implements error
+func (e *TLSError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying handshake error
+func (e *TLSError) Unwrap() error {
+	return e.Err
+}
+
+// ProxyError indicates that the connection to the backend could not be
+// established through the configured proxy.
+type ProxyError struct {
+	Err error
+}
+
+// Error implements error
+func (e *ProxyError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying dial error
+func (e *ProxyError) Unwrap() error {
+	return e.Err
+}
@@ -46,6 +46,18 @@ func BuildTypeDecoder(recognizedTypes []interface{}) TypeDecoder {
 	return &TypeDecoderImpl{typeMappings: typeMappings}
 }
 
+// BuildRequestTypeSet takes a list of request types and returns the set of their
+// type-strings in the format below, for use as the RegisteredRequestTypes of a
+// ClientServerImpl.
+// "MyRequest": {}
+func BuildRequestTypeSet(requestTypes []interface{}) map[string]struct{} {
+	typeSet := make(map[string]struct{})
+	for _, requestType := range requestTypes {
+		typeSet[reflect.TypeOf(requestType).Name()] = struct{}{}
+	}
+	return typeSet
+}
+
 func (d *TypeDecoderImpl) NewOfType(typeString string) (interface{}, bool) {
 	rtype, ok := d.typeMappings[typeString]
 	if !ok {